@@ -12,7 +12,9 @@ registry="registry.ci.openshift.org/openshift"
 serving="${registry}/knative-v$(metadata.get dependencies.serving):knative-serving"
 eventing="${registry}/knative-v$(metadata.get dependencies.eventing):knative-eventing"
 eventing_kafka="${registry}/knative-v$(metadata.get dependencies.eventing_kafka):knative-eventing-kafka"
+eventing_kafka_broker="${registry}/knative-v$(metadata.get dependencies.eventing_kafka_broker):knative-eventing-kafka-broker"
 rbac_proxy="registry.ci.openshift.org/origin/4.7:kube-rbac-proxy"
+oauth_proxy="registry.ci.openshift.org/origin/4.7:oauth-proxy"
 
 declare -a images
 declare -A images_addresses
@@ -45,6 +47,8 @@ image "webhook__webhook" "${serving}-webhook"
 image "domain-mapping" "${serving}-domain-mapping"
 image "domainmapping-webhook" "${serving}-domain-mapping-webhook"
 image "storage-version-migration-serving-serving-$(metadata.get dependencies.serving)__migrate" "${serving}-storage-version-migration"
+image "overprovisioning" "k8s.gcr.io/pause:3.5"
+image "otel-collector" "docker.io/otel/opentelemetry-collector:0.35.0"
 
 image "kourier-gateway" "quay.io/openshift-knative/proxyv2-ubi8:$(metadata.get dependencies.maistra)"
 image "kourier-control" "${registry}/knative-v$(metadata.get dependencies.kourier):kourier"
@@ -72,8 +76,13 @@ kafka_image "KAFKA_RA_IMAGE"                       "${eventing_kafka}-receive-ad
 kafka_image "kafka-ch-controller__controller"      "${eventing_kafka}-consolidated-controller"
 kafka_image "DISPATCHER_IMAGE"                     "${eventing_kafka}-consolidated-dispatcher"
 kafka_image "kafka-webhook__kafka-webhook"         "${eventing_kafka}-webhook"
+kafka_image "kafka-controller__controller"                    "${eventing_kafka_broker}-kafka-controller"
+kafka_image "kafka-webhook-eventing__kafka-webhook-eventing"  "${eventing_kafka_broker}-webhook-kafka"
+kafka_image "kafka-sink-receiver__kafka-sink-receiver"        "${eventing_kafka_broker}-receiver"
+kafka_image "KAFKA_CLI"                            "quay.io/strimzi/kafka:0.25.0-kafka-2.8.0"
 
 image "KUBE_RBAC_PROXY"   "${rbac_proxy}"
+image "OAUTH_PROXY"       "${oauth_proxy}"
 
 declare -A yaml_keys
 yaml_keys[spec.version]="$(metadata.get project.version)"
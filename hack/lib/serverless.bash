@@ -369,6 +369,9 @@ function teardown_serverless {
 
   if oc get knativeserving.operator.knative.dev knative-serving -n "${SERVING_NAMESPACE}" >/dev/null 2>&1; then
     logger.info 'Removing KnativeServing CR'
+    # Knative Services left behind by tests would otherwise block the deletion.
+    oc annotate knativeserving.operator.knative.dev knative-serving -n "${SERVING_NAMESPACE}" \
+      serving.knative.openshift.io/forceDeletion=true --overwrite
     oc delete knativeserving.operator.knative.dev knative-serving -n "${SERVING_NAMESPACE}"
   fi
   logger.info 'Ensure no knative serving pods running'
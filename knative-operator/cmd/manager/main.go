@@ -1,29 +1,44 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 
+	"github.com/go-logr/zapr"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/dependency"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring/dashboards/health"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/conversion"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/domainclaim"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/kafkasink"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/kafkasource"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/knativeeventing"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/knativekafka"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/knativeserving"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/namespacedomain"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/pingsource"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/servicequota"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/sourcescope"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/trigger"
+	"github.com/openshift-knative/serverless-operator/pkg/drift"
+	"github.com/openshift-knative/serverless-operator/pkg/loglevel"
 	"github.com/spf13/pflag"
-	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
-	zapr "sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -38,11 +53,8 @@ var (
 	log               = logf.Log.WithName("cmd")
 )
 
-func init() {
-	prodConf := zap.NewProductionEncoderConfig()
-	prodConf.EncodeTime = zapcore.ISO8601TimeEncoder
-	logf.SetLogger(zapr.New(zapr.Encoder(zapcore.NewJSONEncoder(prodConf))))
-}
+// component is the name of the operator's own logger, configured by "loglevel.knative-openshift".
+const component = "knative-openshift"
 
 func main() {
 	// Add flags registered by imported packages (e.g. glog and
@@ -50,8 +62,21 @@ func main() {
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
 
+	ctx := signals.SetupSignalHandler()
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
+	if err == nil {
+		err = setupLogging(ctx, cfg)
+	}
+	if err != nil {
+		// There's no logger to report this yet.
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Every resync detects manual edits of the managed resources.
+	syncPeriod, err := drift.ResyncInterval()
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
@@ -64,6 +89,10 @@ func main() {
 		LeaderElectionID:       "knative-serving-openshift-lock",
 		MetricsBindAddress:     fmt.Sprintf("%s:%d", metricsHost, metricsPort),
 		HealthProbeBindAddress: fmt.Sprintf(":%d", healthPort),
+		// Pods are only read to report the installed images. Caching them cluster-wide
+		// isn't worth the memory.
+		ClientDisableCacheFor: []client.Object{&corev1.Pod{}},
+		SyncPeriod:            syncPeriod,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -96,6 +125,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup metrics about Knative resources
+	if err := monitoring.RegisterResourceMetrics(mgr.GetCache()); err != nil {
+		log.Error(err, "Failed to register resource metrics")
+	}
+
 	// Setup all Webhooks
 	hookServer := mgr.GetWebhookServer()
 	hookServer.Port = 9876
@@ -118,6 +152,33 @@ func main() {
 	// Kafka Webhooks
 	hookServer.Register("/mutate-knativekafkas", &webhook.Admission{Handler: knativekafka.NewConfigurator(decoder)})
 	hookServer.Register("/validate-knativekafkas", &webhook.Admission{Handler: knativekafka.NewValidator(mgr.GetClient(), decoder)})
+	hookServer.Register("/mutate-kafkasources", &webhook.Admission{Handler: kafkasource.NewConfigurator(mgr.GetClient(), decoder)})
+	hookServer.Register("/mutate-kafkasinks", &webhook.Admission{Handler: kafkasink.NewConfigurator(mgr.GetClient(), decoder)})
+	hookServer.Register("/mutate-triggers", &webhook.Admission{Handler: trigger.NewConfigurator(mgr.GetClient(), decoder)})
+	// Knative Service quota Webhooks
+	hookServer.Register("/validate-knativeservices-quota", &webhook.Admission{Handler: servicequota.NewValidator(mgr.GetClient(), mgr.GetAPIReader(), decoder)})
+	hookServer.Register("/mutate-knativeservices-namespacedomain", &webhook.Admission{Handler: namespacedomain.NewConfigurator(mgr.GetClient(), decoder)})
+	hookServer.Register("/validate-pingsources", &webhook.Admission{Handler: pingsource.NewValidator(decoder)})
+	hookServer.Register("/validate-sources-scope", &webhook.Admission{Handler: sourcescope.NewValidator(mgr.GetClient())})
+	// DomainMapping Webhooks
+	hookServer.Register("/validate-clusterdomainclaims", &webhook.Admission{Handler: domainclaim.NewValidator(mgr.GetClient(), decoder)})
+	// Conversion Webhooks
+	hookServer.Register("/convert", conversion.NewWebhook())
+
+	// Add dependency checks to the readiness probe
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		log.Error(err, "unable to create a discovery client")
+		os.Exit(1)
+	}
+	if err := dependency.AddChecks(mgr,
+		dependency.Check{Name: "routes", Required: true, Checker: dependency.APIGroupVersion(discoveryClient, "route.openshift.io/v1")},
+		dependency.Check{Name: "monitoring", Checker: dependency.APIGroupVersion(discoveryClient, "monitoring.coreos.com/v1")},
+		dependency.Check{Name: "webhook-certificate", Required: true, Checker: dependency.Certificate(filepath.Join(hookServer.CertDir, hookServer.CertName))},
+	); err != nil {
+		log.Error(err, "unable to add the dependency checks")
+		os.Exit(1)
+	}
 
 	if err := setupServerlesOperatorMonitoring(cfg); err != nil {
 		log.Error(err, "Failed to start monitoring")
@@ -136,12 +197,38 @@ func main() {
 	}()
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
 }
 
+// setupLogging creates the loggers of the operator and of its kafka and monitoring subsystems
+// from config-logging, keeping their levels in sync with it.
+func setupLogging(ctx context.Context, cfg *rest.Config) error {
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create a client: %w", err)
+	}
+	namespace := os.Getenv(common.NamespaceEnvKey)
+	if namespace == "" {
+		return errors.New("NAMESPACE not provided via environment")
+	}
+
+	loggers, err := loglevel.New(ctx, kubeClient, namespace, component, loglevel.Kafka, loglevel.Monitoring)
+	if err != nil {
+		return err
+	}
+	if err := loggers.Watch(ctx); err != nil {
+		return fmt.Errorf("failed to watch the logging config: %w", err)
+	}
+
+	logf.SetLogger(zapr.NewLogger(loggers.Get(component).Desugar()))
+	common.KafkaLog.Fulfill(zapr.NewLogger(loggers.Get(loglevel.Kafka).Desugar()))
+	common.MonitoringLog.Fulfill(zapr.NewLogger(loggers.Get(loglevel.Monitoring).Desugar()))
+	return nil
+}
+
 func setupServerlesOperatorMonitoring(cfg *rest.Config) error {
 	cl, err := client.New(cfg, client.Options{})
 	if err != nil {
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/upgrade"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/validate"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+const usage = `Usage: serverless-operator validate -f FILE
+       serverless-operator upgrade-diff -f FILE --from DIR --to DIR
+`
+
+const validateUsage = `Usage: serverless-operator validate -f FILE
+
+Validates a KnativeServing without a cluster, reporting the errors the operator's webhook would
+reject it with and the defaults the operator would apply to it. Validations and defaults that
+depend on the state of the cluster are skipped. Use "-f -" to read from stdin.
+
+Exits with 1 if the KnativeServing is invalid.
+`
+
+const upgradeDiffUsage = `Usage: serverless-operator upgrade-diff -f FILE --from DIR --to DIR
+
+Reports the changes upgrading the operator applies to the resources installed for a
+KnativeServing as JSON, before the upgrade is approved. The manifests are rendered from the
+kodata directories of the current and the next operator image, with the overrides of the
+KnativeServing applied. The installed version is taken from the KnativeServing's status, so
+pass the KnativeServing as read from the cluster. Use "-f -" to read from stdin.
+
+Exits with 1 if the upgrade changes any resources.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "validate":
+		runValidate(os.Args[2:])
+	case "upgrade-diff":
+		runUpgradeDiff(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func runValidate(args []string) {
+	flags := pflag.NewFlagSet("validate", pflag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, validateUsage) }
+	file := flags.StringP("filename", "f", "", "The file containing the KnativeServing to validate.")
+	if err := flags.Parse(args); err != nil || *file == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	in, closer := open(*file)
+	defer closer()
+
+	result, err := validate.KnativeServing(context.Background(), in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(result.Errors) > 0 {
+		for _, reason := range result.Errors {
+			fmt.Printf("error: %s\n", reason)
+		}
+		os.Exit(1)
+	}
+	if result.Defaults == "" {
+		fmt.Println("valid, no defaults would be applied")
+		return
+	}
+	fmt.Printf("valid, the following defaults would be applied (-current +defaulted):\n%s", result.Defaults)
+}
+
+func runUpgradeDiff(args []string) {
+	flags := pflag.NewFlagSet("upgrade-diff", pflag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, upgradeDiffUsage) }
+	file := flags.StringP("filename", "f", "", "The file containing the KnativeServing to upgrade.")
+	from := flags.String("from", "", "The kodata directory of the current operator.")
+	to := flags.String("to", "", "The kodata directory of the next operator.")
+	if err := flags.Parse(args); err != nil || *file == "" || *from == "" || *to == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	in, closer := open(*file)
+	defer closer()
+
+	// Keep the log of the rendering out of the report.
+	ctx := logging.WithLogger(context.Background(), zap.NewNop().Sugar())
+	report, err := upgrade.KnativeServing(ctx, in, *from, *to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(report.Changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+// open opens the given file, or stdin for "-".
+func open(file string) (io.Reader, func()) {
+	if file == "-" {
+		return os.Stdin, func() {}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return f, func() { f.Close() }
+}
@@ -24,6 +24,9 @@ func TestUnallowedResourcesInManifest(t *testing.T) {
 	}, {
 		path:  "./2-source.yaml",
 		fails: false,
+	}, {
+		path:  "./3-sink.yaml",
+		fails: false,
 	}, {
 		path:  "./testdata/config-logging.yaml",
 		fails: true,
@@ -37,6 +37,24 @@ function download_kafka {
 
 download_kafka knativekafka "$KNATIVE_EVENTING_KAFKA_VERSION" "${kafka_files[@]}"
 
+# The KafkaSink is shipped by eventing-kafka-broker, as its control plane plus the sink data plane.
+function download_kafka_sink {
+  version=$1
+  target_file="$root/knative-operator/deploy/resources/knativekafka/3-sink.yaml"
+  base_url="https://github.com/knative-sandbox/eventing-kafka-broker/releases/download/$version"
+
+  : > "$target_file"
+  for file in eventing-kafka-controller.yaml eventing-kafka-sink.yaml; do
+    wget --no-check-certificate "$base_url/$file" -O - >> "$target_file"
+    echo "---" >> "$target_file"
+  done
+
+  # Break all image references so we know our overrides work correctly.
+  yaml.break_image_references "$target_file"
+}
+
+download_kafka_sink "$KNATIVE_EVENTING_KAFKA_BROKER_VERSION"
+
 # For 1.17 we still skip HPA
 git apply "$root/knative-operator/hack/001-eventing-kafka-remove_hpa.patch"
 
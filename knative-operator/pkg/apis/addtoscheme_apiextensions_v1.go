@@ -0,0 +1,11 @@
+package apis
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func init() {
+	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
+	// Adds schema for CustomResourceDefinitions
+	AddToSchemes = append(AddToSchemes, apiextensionsv1.AddToScheme)
+}
@@ -0,0 +1,11 @@
+package apis
+
+import (
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+)
+
+func init() {
+	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
+	// Adds schema for Knative Eventing resources
+	AddToSchemes = append(AddToSchemes, eventingv1.AddToScheme)
+}
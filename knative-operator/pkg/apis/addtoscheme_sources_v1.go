@@ -0,0 +1,11 @@
+package apis
+
+import (
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func init() {
+	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
+	// Adds schema for Knative Eventing sources
+	AddToSchemes = append(AddToSchemes, sourcesv1.AddToScheme)
+}
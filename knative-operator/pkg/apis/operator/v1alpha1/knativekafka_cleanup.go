@@ -0,0 +1,25 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDrainTimeoutSeconds is how long the dispatchers are given to drain by default.
+const DefaultDrainTimeoutSeconds = 300
+
+// Validate checks the drain timeout.
+func (c *Cleanup) Validate() error {
+	if c.DrainTimeoutSeconds < 0 {
+		return fmt.Errorf("drainTimeoutSeconds must not be negative")
+	}
+	return nil
+}
+
+// DrainTimeout returns the configured drain timeout or the default.
+func (c *Cleanup) DrainTimeout() time.Duration {
+	if c.DrainTimeoutSeconds == 0 {
+		return DefaultDrainTimeoutSeconds * time.Second
+	}
+	return time.Duration(c.DrainTimeoutSeconds) * time.Second
+}
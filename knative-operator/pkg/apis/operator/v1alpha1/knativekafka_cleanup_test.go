@@ -0,0 +1,18 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCleanupDrainTimeout(t *testing.T) {
+	if got := (&Cleanup{}).DrainTimeout(); got != DefaultDrainTimeoutSeconds*time.Second {
+		t.Errorf("DrainTimeout() = %v, want the default", got)
+	}
+	if got := (&Cleanup{DrainTimeoutSeconds: 30}).DrainTimeout(); got != 30*time.Second {
+		t.Errorf("DrainTimeout() = %v, want %v", got, 30*time.Second)
+	}
+	if err := (&Cleanup{DrainTimeoutSeconds: -1}).Validate(); err == nil {
+		t.Error("Validate() = nil, want an error for a negative timeout")
+	}
+}
@@ -0,0 +1,54 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultConsumerGroupTemplate is the template used if only a prefix is configured.
+const DefaultConsumerGroupTemplate = "{{ .Namespace }}.{{ .Name }}"
+
+// ConsumerGroupRef identifies the resource a consumer group is created for.
+type ConsumerGroupRef struct {
+	Namespace string
+	Name      string
+	UID       string
+}
+
+// IsSet returns true if the naming of consumer groups is configured at all.
+func (cg *ConsumerGroups) IsSet() bool {
+	return cg.Prefix != "" || cg.Template != ""
+}
+
+// Validate checks that the template can be rendered.
+func (cg *ConsumerGroups) Validate() error {
+	_, err := cg.GroupID(ConsumerGroupRef{})
+	return err
+}
+
+// GroupID renders the consumer group ID for the given resource.
+func (cg *ConsumerGroups) GroupID(ref ConsumerGroupRef) (string, error) {
+	tmpl, err := cg.parse()
+	if err != nil {
+		return "", err
+	}
+	var id strings.Builder
+	id.WriteString(cg.Prefix)
+	if err := tmpl.Execute(&id, ref); err != nil {
+		return "", fmt.Errorf("failed to render consumer group template: %w", err)
+	}
+	return id.String(), nil
+}
+
+func (cg *ConsumerGroups) parse() (*template.Template, error) {
+	raw := cg.Template
+	if raw == "" {
+		raw = DefaultConsumerGroupTemplate
+	}
+	tmpl, err := template.New("consumerGroup").Option("missingkey=error").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consumer group template %q: %w", raw, err)
+	}
+	return tmpl, nil
+}
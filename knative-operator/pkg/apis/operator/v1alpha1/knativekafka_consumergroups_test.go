@@ -0,0 +1,42 @@
+package v1alpha1
+
+import "testing"
+
+func TestConsumerGroupID(t *testing.T) {
+	ref := ConsumerGroupRef{Namespace: "ns", Name: "source", UID: "1234"}
+
+	tests := []struct {
+		name    string
+		groups  ConsumerGroups
+		want    string
+		wantErr bool
+	}{{
+		name:   "prefix only",
+		groups: ConsumerGroups{Prefix: "tenant-a."},
+		want:   "tenant-a.ns.source",
+	}, {
+		name:   "prefix and template",
+		groups: ConsumerGroups{Prefix: "cluster-1.", Template: "knative-{{ .Namespace }}-{{ .UID }}"},
+		want:   "cluster-1.knative-ns-1234",
+	}, {
+		name:    "malformed template",
+		groups:  ConsumerGroups{Template: "{{ .Namespace"},
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		groups:  ConsumerGroups{Template: "{{ .Topic }}"},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.groups.GroupID(ref)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("GroupID() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("GroupID() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// InitialOffsetAnnotation selects the initial offset of the consumer group of a Trigger or
+	// KafkaSource, overriding the default of the KnativeKafka instance.
+	InitialOffsetAnnotation = "kafka.knative.openshift.io/initial-offset"
+
+	// InitialOffsetEarliest starts new consumer groups at the oldest retained message.
+	InitialOffsetEarliest = "earliest"
+	// InitialOffsetLatest starts new consumer groups at the next produced message.
+	InitialOffsetLatest = "latest"
+)
+
+// saramaInitialOffsets maps the initial offsets to Sarama's Consumer.Offsets.Initial.
+var saramaInitialOffsets = map[string]int64{
+	InitialOffsetEarliest: -2, // sarama.OffsetOldest
+	InitialOffsetLatest:   -1, // sarama.OffsetNewest
+}
+
+// Validate checks the initial offset and the lag alert threshold.
+func (c *Consumers) Validate() error {
+	if c.InitialOffset != "" {
+		if err := ValidateInitialOffset(c.InitialOffset); err != nil {
+			return fmt.Errorf("initialOffset %w", err)
+		}
+	}
+	if c.LagAlertThreshold != nil && *c.LagAlertThreshold < 1 {
+		return fmt.Errorf("lagAlertThreshold must be positive")
+	}
+	return nil
+}
+
+// ValidateInitialOffset checks that the given initial offset is known.
+func ValidateInitialOffset(offset string) error {
+	if _, ok := saramaInitialOffsets[offset]; !ok {
+		return fmt.Errorf("must be either %q or %q", InitialOffsetEarliest, InitialOffsetLatest)
+	}
+	return nil
+}
+
+// ApplyInitialOffset validates the initial offset annotation of the given Trigger or
+// KafkaSource and defaults it to the configured initial offset if it's missing. It returns
+// whether the annotation has been defaulted.
+func (c *Consumers) ApplyInitialOffset(obj metav1.Object) (bool, error) {
+	if offset, ok := obj.GetAnnotations()[InitialOffsetAnnotation]; ok {
+		if err := ValidateInitialOffset(offset); err != nil {
+			return false, fmt.Errorf("annotation %s %w", InitialOffsetAnnotation, err)
+		}
+		return false, nil
+	}
+	if c.InitialOffset == "" {
+		return false, nil
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[InitialOffsetAnnotation] = c.InitialOffset
+	obj.SetAnnotations(annotations)
+	return true, nil
+}
+
+// SaramaConfig renders the Sarama config of the data plane's consumers. It returns an empty
+// string if nothing is configured.
+func (c *Consumers) SaramaConfig() string {
+	offset, ok := saramaInitialOffsets[c.InitialOffset]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("enableLogging: false\nconfig: |\n  Consumer:\n    Offsets:\n      Initial: %d\n", offset)
+}
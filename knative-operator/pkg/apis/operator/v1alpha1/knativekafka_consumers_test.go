@@ -0,0 +1,93 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConsumersValidate(t *testing.T) {
+	zero := int64(0)
+	threshold := int64(1000)
+
+	tests := []struct {
+		name      string
+		consumers Consumers
+		wantErr   bool
+	}{{
+		name: "unset",
+	}, {
+		name:      "valid",
+		consumers: Consumers{InitialOffset: InitialOffsetEarliest, LagAlertThreshold: &threshold},
+	}, {
+		name:      "unknown initial offset",
+		consumers: Consumers{InitialOffset: "oldest"},
+		wantErr:   true,
+	}, {
+		name:      "zero threshold",
+		consumers: Consumers{LagAlertThreshold: &zero},
+		wantErr:   true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.consumers.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestConsumersSaramaConfig(t *testing.T) {
+	if got := (&Consumers{}).SaramaConfig(); got != "" {
+		t.Errorf("SaramaConfig() = %q, want empty", got)
+	}
+	want := "enableLogging: false\nconfig: |\n  Consumer:\n    Offsets:\n      Initial: -2\n"
+	if got := (&Consumers{InitialOffset: InitialOffsetEarliest}).SaramaConfig(); got != want {
+		t.Errorf("SaramaConfig() = %q, want %q", got, want)
+	}
+}
+
+func TestConsumersApplyInitialOffset(t *testing.T) {
+	tests := []struct {
+		name        string
+		consumers   Consumers
+		annotations map[string]string
+		want        string
+		wantApplied bool
+		wantErr     bool
+	}{{
+		name: "no default",
+	}, {
+		name:        "defaulted",
+		consumers:   Consumers{InitialOffset: InitialOffsetEarliest},
+		want:        InitialOffsetEarliest,
+		wantApplied: true,
+	}, {
+		name:        "explicit",
+		consumers:   Consumers{InitialOffset: InitialOffsetEarliest},
+		annotations: map[string]string{InitialOffsetAnnotation: InitialOffsetLatest},
+		want:        InitialOffsetLatest,
+	}, {
+		name:        "invalid",
+		annotations: map[string]string{InitialOffsetAnnotation: "oldest"},
+		want:        "oldest",
+		wantErr:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Annotations: test.annotations}
+			applied, err := test.consumers.ApplyInitialOffset(obj)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ApplyInitialOffset() = %v, wantErr %v", err, test.wantErr)
+			}
+			if applied != test.wantApplied {
+				t.Errorf("ApplyInitialOffset() applied = %v, want %v", applied, test.wantApplied)
+			}
+			if got := obj.Annotations[InitialOffsetAnnotation]; got != test.want {
+				t.Errorf("Initial offset = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
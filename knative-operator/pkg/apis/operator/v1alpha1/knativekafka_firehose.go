@@ -0,0 +1,32 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultFirehoseNamespace is the namespace of the firehose's KafkaSink by default.
+const DefaultFirehoseNamespace = "knative-firehose"
+
+// Validate checks the namespace and requires a topic if the firehose is enabled.
+func (f *Firehose) Validate() error {
+	if f.Namespace != "" {
+		if errs := validation.IsDNS1123Label(f.Namespace); len(errs) > 0 {
+			return fmt.Errorf("namespace %q is invalid: %s", f.Namespace, strings.Join(errs, ", "))
+		}
+	}
+	if f.Enabled && f.Topic == "" {
+		return fmt.Errorf("topic is required when enabled")
+	}
+	return nil
+}
+
+// SinkNamespace returns the configured namespace of the KafkaSink or the default.
+func (f *Firehose) SinkNamespace() string {
+	if f.Namespace == "" {
+		return DefaultFirehoseNamespace
+	}
+	return f.Namespace
+}
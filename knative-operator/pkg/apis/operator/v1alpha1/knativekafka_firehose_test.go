@@ -0,0 +1,41 @@
+package v1alpha1
+
+import "testing"
+
+func TestFirehoseValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		firehose Firehose
+		wantErr  bool
+	}{{
+		name: "disabled",
+	}, {
+		name:     "valid",
+		firehose: Firehose{Enabled: true, Namespace: "audit", Topic: "cluster-events"},
+	}, {
+		name:     "no topic",
+		firehose: Firehose{Enabled: true},
+		wantErr:  true,
+	}, {
+		name:     "invalid namespace",
+		firehose: Firehose{Enabled: true, Namespace: "Audit", Topic: "cluster-events"},
+		wantErr:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.firehose.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestFirehoseSinkNamespace(t *testing.T) {
+	if got := (&Firehose{}).SinkNamespace(); got != DefaultFirehoseNamespace {
+		t.Errorf("SinkNamespace() = %q, want %q", got, DefaultFirehoseNamespace)
+	}
+	if got := (&Firehose{Namespace: "audit"}).SinkNamespace(); got != "audit" {
+		t.Errorf("SinkNamespace() = %q, want %q", got, "audit")
+	}
+}
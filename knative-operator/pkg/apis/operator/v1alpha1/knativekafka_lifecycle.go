@@ -5,6 +5,16 @@ import (
 	"knative.dev/pkg/apis"
 )
 
+// KafkaSinkReady reports the state of the KafkaSink component while it's enabled. It doesn't
+// affect the readiness of the KnativeKafka, as its Deployments are covered by
+// DeploymentsAvailable already.
+const KafkaSinkReady apis.ConditionType = "KafkaSinkReady"
+
+// AuthSecretRotated reports whether the channel Deployments have been restarted for the
+// current content of the channel's auth Secret. It doesn't affect the readiness of the
+// KnativeKafka, as the old pods keep serving while the new ones roll out.
+const AuthSecretRotated apis.ConditionType = "AuthSecretRotated"
+
 var (
 	kafkaCondSet = apis.NewLivingConditionSet(
 		knativeoperatorv1alpha1.DeploymentsAvailable,
@@ -49,3 +59,50 @@ func (is *KnativeKafkaStatus) MarkDeploymentsNotReady() {
 		"NotReady",
 		"Waiting on deployments")
 }
+
+// MarkKafkaSinkReady marks the KafkaSinkReady status as true.
+func (is *KnativeKafkaStatus) MarkKafkaSinkReady() {
+	kafkaCondSet.Manage(is).MarkTrue(KafkaSinkReady)
+}
+
+// MarkKafkaSinkNotReady marks the KafkaSinkReady status as false and calls out it's waiting
+// for the KafkaSink deployments.
+func (is *KnativeKafkaStatus) MarkKafkaSinkNotReady() {
+	kafkaCondSet.Manage(is).MarkFalse(
+		KafkaSinkReady,
+		"NotReady",
+		"Waiting on KafkaSink deployments")
+}
+
+// MarkKafkaSinkNotAvailable marks the KafkaSinkReady status as false as the KafkaSink isn't
+// shipped with this release.
+func (is *KnativeKafkaStatus) MarkKafkaSinkNotAvailable() {
+	kafkaCondSet.Manage(is).MarkFalse(
+		KafkaSinkReady,
+		"NotAvailable",
+		"KafkaSink is not available in this release")
+}
+
+// MarkKafkaSinkDisabled removes the KafkaSinkReady status.
+func (is *KnativeKafkaStatus) MarkKafkaSinkDisabled() {
+	kafkaCondSet.Manage(is).ClearCondition(KafkaSinkReady)
+}
+
+// MarkAuthSecretRotated marks the AuthSecretRotated status as true.
+func (is *KnativeKafkaStatus) MarkAuthSecretRotated() {
+	kafkaCondSet.Manage(is).MarkTrue(AuthSecretRotated)
+}
+
+// MarkAuthSecretRotating marks the AuthSecretRotated status as false and calls out the
+// channel Deployments are restarting for the rotated auth Secret.
+func (is *KnativeKafkaStatus) MarkAuthSecretRotating() {
+	kafkaCondSet.Manage(is).MarkFalse(
+		AuthSecretRotated,
+		"RotationInProgress",
+		"Restarting the channel deployments for the rotated auth secret")
+}
+
+// ClearAuthSecretRotated removes the AuthSecretRotated status.
+func (is *KnativeKafkaStatus) ClearAuthSecretRotated() {
+	kafkaCondSet.Manage(is).ClearCondition(AuthSecretRotated)
+}
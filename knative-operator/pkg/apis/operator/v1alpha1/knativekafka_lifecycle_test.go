@@ -65,3 +65,54 @@ func TestKnativeKafkaErrorPath(t *testing.T) {
 		t.Errorf("ks.IsReady() = %v, want true", ready)
 	}
 }
+
+func TestKnativeKafkaSinkCondition(t *testing.T) {
+	ks := &KnativeKafkaStatus{}
+	ks.InitializeConditions()
+	ks.MarkInstallSucceeded()
+	ks.MarkDeploymentsAvailable()
+
+	// The sink doesn't affect the readiness of the KnativeKafka.
+	ks.MarkKafkaSinkNotAvailable()
+	apistest.CheckConditionFailed(ks, KafkaSinkReady, t)
+	if ready := ks.IsReady(); !ready {
+		t.Errorf("ks.IsReady() = %v, want true", ready)
+	}
+
+	ks.MarkKafkaSinkNotReady()
+	apistest.CheckConditionFailed(ks, KafkaSinkReady, t)
+
+	ks.MarkKafkaSinkReady()
+	apistest.CheckConditionSucceeded(ks, KafkaSinkReady, t)
+
+	// Disabling the sink removes its condition.
+	ks.MarkKafkaSinkDisabled()
+	if cond := ks.GetCondition(KafkaSinkReady); cond != nil {
+		t.Errorf("KafkaSinkReady = %v, want none", cond)
+	}
+	if ready := ks.IsReady(); !ready {
+		t.Errorf("ks.IsReady() = %v, want true", ready)
+	}
+}
+
+func TestKnativeKafkaAuthSecretCondition(t *testing.T) {
+	ks := &KnativeKafkaStatus{}
+	ks.InitializeConditions()
+	ks.MarkInstallSucceeded()
+	ks.MarkDeploymentsAvailable()
+
+	// A rotation in progress doesn't affect the readiness of the KnativeKafka.
+	ks.MarkAuthSecretRotating()
+	apistest.CheckConditionFailed(ks, AuthSecretRotated, t)
+	if ready := ks.IsReady(); !ready {
+		t.Errorf("ks.IsReady() = %v, want true", ready)
+	}
+
+	ks.MarkAuthSecretRotated()
+	apistest.CheckConditionSucceeded(ks, AuthSecretRotated, t)
+
+	ks.ClearAuthSecretRotated()
+	if cond := ks.GetCondition(AuthSecretRotated); cond != nil {
+		t.Errorf("AuthSecretRotated = %v, want none", cond)
+	}
+}
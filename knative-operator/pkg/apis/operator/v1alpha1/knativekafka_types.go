@@ -17,9 +17,38 @@ type KnativeKafkaSpec struct {
 	// +optional
 	Channel Channel `json:"channel,omitempty"`
 
+	// Allows configuration for KafkaSink installation
+	// +optional
+	Sink Sink `json:"sink,omitempty"`
+
 	// HighAvailability allows specification of HA control plane.
 	// +optional
 	HighAvailability *commonv1alpha1.HighAvailability `json:"high-availability,omitempty"`
+
+	// ConsumerGroups allows configuration of the naming of Kafka consumer groups
+	// +optional
+	ConsumerGroups ConsumerGroups `json:"consumerGroups,omitempty"`
+
+	// TopologySpread allows spreading the replicas of the Kafka data plane across zones
+	// +optional
+	TopologySpread TopologySpread `json:"topologySpread,omitempty"`
+
+	// Consumers allows configuration of the offsets and lag alerting of Kafka consumers
+	// +optional
+	Consumers Consumers `json:"consumers,omitempty"`
+
+	// Cleanup allows configuration of the cleanup of the Kafka cluster on deletion
+	// +optional
+	Cleanup Cleanup `json:"cleanup,omitempty"`
+
+	// Firehose allows sending the Kubernetes events of labelled namespaces to a Kafka topic
+	// +optional
+	Firehose Firehose `json:"firehose,omitempty"`
+
+	// EgressPolicy allows generating NetworkPolicies that let the Kafka components reach the
+	// Kafka cluster on clusters denying egress by default
+	// +optional
+	EgressPolicy EgressPolicy `json:"egressPolicy,omitempty"`
 }
 
 // KnativeKafkaStatus defines the observed state of KnativeKafka
@@ -30,6 +59,70 @@ type KnativeKafkaStatus struct {
 	// The version of the installed release
 	// +optional
 	Version string `json:"version,omitempty"`
+
+	// InstalledManifests lists the Deployments installed by the operator
+	// +optional
+	InstalledManifests []InstalledManifest `json:"installedManifests,omitempty"`
+
+	// Deployments lists the rollout progress of the Deployments installed by the operator
+	// +optional
+	Deployments []DeploymentStatus `json:"deployments,omitempty"`
+
+	// DriftedResources lists the resources differing from the manifest that were left as they
+	// are, as "Kind namespace/name", if drift is only reported
+	// +optional
+	DriftedResources []string `json:"driftedResources,omitempty"`
+}
+
+// DeploymentStatus describes the rollout progress of a Deployment installed by the operator
+type DeploymentStatus struct {
+	// Namespace of the Deployment
+	Namespace string `json:"namespace"`
+
+	// Name of the Deployment
+	Name string `json:"name"`
+
+	// Replicas is the number of desired replicas
+	Replicas int32 `json:"replicas"`
+
+	// ReadyReplicas is the number of ready replicas
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Generation is the generation of the Deployment's spec
+	Generation int64 `json:"generation"`
+
+	// ObservedGeneration is the generation last observed by the Deployment controller
+	ObservedGeneration int64 `json:"observedGeneration"`
+}
+
+// InstalledManifest describes a Deployment installed by the operator
+type InstalledManifest struct {
+	// Namespace of the Deployment
+	Namespace string `json:"namespace"`
+
+	// Name of the Deployment
+	Name string `json:"name"`
+
+	// Version is the release label of the Deployment
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Images are the images of the Deployment's containers
+	// +optional
+	Images []InstalledImage `json:"images,omitempty"`
+}
+
+// InstalledImage describes the image of a container
+type InstalledImage struct {
+	// Container is the name of the container
+	Container string `json:"container"`
+
+	// Image is the image as configured on the Deployment
+	Image string `json:"image"`
+
+	// ImageID is the image as resolved to a digest by a running pod
+	// +optional
+	ImageID string `json:"imageID,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -81,6 +174,110 @@ type Channel struct {
 	AuthSecretName string `json:"authSecretName"`
 }
 
+// Sink allows configuration for KafkaSink installation
+type Sink struct {
+	// Enabled defines if the KafkaSink installation is enabled. KafkaSinks default to the
+	// bootstrapServers and the auth secret of spec.channel.
+	Enabled bool `json:"enabled"`
+}
+
+// ConsumerGroups allows configuration of the naming of the Kafka consumer groups created for
+// KafkaChannel subscriptions and KafkaSources, so ACLs can be managed by group prefix
+type ConsumerGroups struct {
+	// Prefix is prepended to all consumer group IDs, e.g. to separate clusters or tenants
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Template is a Go template rendering the consumer group ID from the .Namespace, .Name
+	// and .UID of the consuming resource. Defaults to DefaultConsumerGroupTemplate.
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+// TopologySpread allows configuration of the topology spread constraints applied to the
+// dispatchers and the broker receiver
+type TopologySpread struct {
+	// Enabled defines if the replicas are spread across topology domains
+	Enabled bool `json:"enabled"`
+
+	// MaxSkew is the maximum difference in the number of replicas between any two domains.
+	// Defaults to 1.
+	// +optional
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+
+	// TopologyKey is the node label defining the topology domains.
+	// Defaults to "topology.kubernetes.io/zone".
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+
+	// WhenUnsatisfiable is either "DoNotSchedule" or "ScheduleAnyway".
+	// Defaults to "ScheduleAnyway".
+	// +optional
+	WhenUnsatisfiable string `json:"whenUnsatisfiable,omitempty"`
+}
+
+// Consumers allows configuration of the Kafka consumers of KafkaChannel subscriptions, and
+// thus of the Brokers and Triggers backed by KafkaChannels, and of KafkaSources
+type Consumers struct {
+	// InitialOffset is where consumer groups without a committed offset start consuming,
+	// either "earliest" or "latest". Defaults to the data plane's default. It's also the
+	// default of the kafka.knative.openshift.io/initial-offset annotation of Triggers and
+	// KafkaSources, which selects their initial offset individually.
+	// +optional
+	InitialOffset string `json:"initialOffset,omitempty"`
+
+	// LagAlertThreshold is the consumer lag, in messages, above which alerts fire. Alerting
+	// requires the metrics of the Kafka cluster's Kafka Exporter and is disabled if unset.
+	// +optional
+	LagAlertThreshold *int64 `json:"lagAlertThreshold,omitempty"`
+}
+
+// Cleanup allows configuration of the cleanup Jobs run against the Kafka cluster of the
+// KafkaChannels before the KnativeKafka is removed
+type Cleanup struct {
+	// Enabled defines if the dispatchers are drained and the consumer groups of the
+	// KafkaChannels are deleted when the KnativeKafka is deleted
+	Enabled bool `json:"enabled"`
+
+	// DeleteTopics defines if the topics of the KafkaChannels are deleted too
+	// +optional
+	DeleteTopics bool `json:"deleteTopics,omitempty"`
+
+	// DrainTimeoutSeconds is how long to wait for the dispatchers to catch up with their
+	// topics. Defaults to DefaultDrainTimeoutSeconds.
+	// +optional
+	DrainTimeoutSeconds int32 `json:"drainTimeoutSeconds,omitempty"`
+}
+
+// Firehose allows configuration of the cluster events firehose, which sends the Kubernetes
+// events of all namespaces labelled with eventing.knative.openshift.io/firehose=enabled to a
+// Kafka topic, e.g. for audit pipelines. Every labelled namespace gets an ApiServerSource
+// sending its events to a KafkaSink in the firehose's namespace.
+type Firehose struct {
+	// Enabled defines if the firehose is installed. It requires spec.sink to be enabled.
+	Enabled bool `json:"enabled"`
+
+	// Namespace is the namespace of the KafkaSink, which is created by the operator.
+	// Defaults to DefaultFirehoseNamespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Topic is the existing Kafka topic the events are sent to. The KafkaSink uses the
+	// bootstrapServers of spec.channel.
+	// +optional
+	Topic string `json:"topic,omitempty"`
+}
+
+// EgressPolicy allows configuration of the NetworkPolicies allowing the Deployments of the
+// enabled components to reach the bootstrapServers of spec.channel and DNS. Brokers are
+// allowed by the namespace of in-cluster servers, by IP address or, for other hostnames,
+// only by port. Egress to anything else, e.g. the API server or subscribers, has to be
+// allowed separately.
+type EgressPolicy struct {
+	// Enabled defines if the NetworkPolicies are generated
+	Enabled bool `json:"enabled"`
+}
+
 func init() {
 	SchemeBuilder.Register(&KnativeKafka{}, &KnativeKafkaList{})
 }
@@ -24,6 +24,144 @@ func (in *Channel) DeepCopy() *Channel {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cleanup) DeepCopyInto(out *Cleanup) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cleanup.
+func (in *Cleanup) DeepCopy() *Cleanup {
+	if in == nil {
+		return nil
+	}
+	out := new(Cleanup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsumerGroups) DeepCopyInto(out *ConsumerGroups) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsumerGroups.
+func (in *ConsumerGroups) DeepCopy() *ConsumerGroups {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsumerGroups)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Consumers) DeepCopyInto(out *Consumers) {
+	*out = *in
+	if in.LagAlertThreshold != nil {
+		in, out := &in.LagAlertThreshold, &out.LagAlertThreshold
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Consumers.
+func (in *Consumers) DeepCopy() *Consumers {
+	if in == nil {
+		return nil
+	}
+	out := new(Consumers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentStatus) DeepCopyInto(out *DeploymentStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentStatus.
+func (in *DeploymentStatus) DeepCopy() *DeploymentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressPolicy) DeepCopyInto(out *EgressPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressPolicy.
+func (in *EgressPolicy) DeepCopy() *EgressPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Firehose) DeepCopyInto(out *Firehose) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firehose.
+func (in *Firehose) DeepCopy() *Firehose {
+	if in == nil {
+		return nil
+	}
+	out := new(Firehose)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstalledImage) DeepCopyInto(out *InstalledImage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstalledImage.
+func (in *InstalledImage) DeepCopy() *InstalledImage {
+	if in == nil {
+		return nil
+	}
+	out := new(InstalledImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstalledManifest) DeepCopyInto(out *InstalledManifest) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]InstalledImage, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstalledManifest.
+func (in *InstalledManifest) DeepCopy() *InstalledManifest {
+	if in == nil {
+		return nil
+	}
+	out := new(InstalledManifest)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KnativeKafka) DeepCopyInto(out *KnativeKafka) {
 	*out = *in
@@ -90,6 +228,13 @@ func (in *KnativeKafkaSpec) DeepCopyInto(out *KnativeKafkaSpec) {
 	*out = *in
 	out.Source = in.Source
 	out.Channel = in.Channel
+	out.Sink = in.Sink
+	out.ConsumerGroups = in.ConsumerGroups
+	out.TopologySpread = in.TopologySpread
+	in.Consumers.DeepCopyInto(&out.Consumers)
+	out.Cleanup = in.Cleanup
+	out.Firehose = in.Firehose
+	out.EgressPolicy = in.EgressPolicy
 	return
 }
 
@@ -107,6 +252,23 @@ func (in *KnativeKafkaSpec) DeepCopy() *KnativeKafkaSpec {
 func (in *KnativeKafkaStatus) DeepCopyInto(out *KnativeKafkaStatus) {
 	*out = *in
 	in.Status.DeepCopyInto(&out.Status)
+	if in.InstalledManifests != nil {
+		in, out := &in.InstalledManifests, &out.InstalledManifests
+		*out = make([]InstalledManifest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Deployments != nil {
+		in, out := &in.Deployments, &out.Deployments
+		*out = make([]DeploymentStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.DriftedResources != nil {
+		in, out := &in.DriftedResources, &out.DriftedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -120,6 +282,22 @@ func (in *KnativeKafkaStatus) DeepCopy() *KnativeKafkaStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Sink) DeepCopyInto(out *Sink) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Sink.
+func (in *Sink) DeepCopy() *Sink {
+	if in == nil {
+		return nil
+	}
+	out := new(Sink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Source) DeepCopyInto(out *Source) {
 	*out = *in
@@ -135,3 +313,19 @@ func (in *Source) DeepCopy() *Source {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpread) DeepCopyInto(out *TopologySpread) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpread.
+func (in *TopologySpread) DeepCopy() *TopologySpread {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpread)
+	in.DeepCopyInto(out)
+	return out
+}
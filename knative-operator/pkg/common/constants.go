@@ -14,3 +14,13 @@ const (
 	// The namespace of the pod will be available through this key.
 	NamespaceEnvKey = "NAMESPACE"
 )
+
+const (
+	// NamespaceDomainAnnotation is set on a namespace to serve its Knative Services under
+	// their own domain rather than the cluster's default, e.g. "team-a.apps.example.com".
+	NamespaceDomainAnnotation = "serving.knative.openshift.io/domain"
+
+	// NamespaceDomainLabel is set on the Knative Services of annotated namespaces and
+	// selected by the respective entry in config-domain. Knative propagates it to the Routes.
+	NamespaceDomainLabel = "serving.knative.openshift.io/domainNamespace"
+)
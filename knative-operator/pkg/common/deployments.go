@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DeploymentsStatusKey is the status annotation carrying the rollout progress of the
+// Deployments as JSON on KnativeServing and KnativeEventing, whose status is defined upstream.
+const DeploymentsStatusKey = "operator.serverless.openshift.io/deployments"
+
+// DeploymentStatusesInNamespaces describes the rollout progress of all Deployments in the
+// given namespaces.
+func DeploymentStatusesInNamespaces(ctx context.Context, c client.Reader, namespaces ...string) ([]operatorv1alpha1.DeploymentStatus, error) {
+	var statuses []operatorv1alpha1.DeploymentStatus
+	for _, ns := range namespaces {
+		list := &appsv1.DeploymentList{}
+		if err := c.List(ctx, list, client.InNamespace(ns)); err != nil {
+			return nil, fmt.Errorf("failed to list deployments in %s: %w", ns, err)
+		}
+		for i := range list.Items {
+			statuses = append(statuses, DeploymentStatusFor(&list.Items[i]))
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Namespace != statuses[j].Namespace {
+			return statuses[i].Namespace < statuses[j].Namespace
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
+
+// DeploymentStatusFor describes the rollout progress of the given Deployment.
+func DeploymentStatusFor(d *appsv1.Deployment) operatorv1alpha1.DeploymentStatus {
+	// Unset replicas default to 1.
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return operatorv1alpha1.DeploymentStatus{
+		Namespace:          d.Namespace,
+		Name:               d.Name,
+		Replicas:           replicas,
+		ReadyReplicas:      d.Status.ReadyReplicas,
+		Generation:         d.Generation,
+		ObservedGeneration: d.Status.ObservedGeneration,
+	}
+}
+
+// SetDeploymentsStatusAnnotation sets the status annotation in the given status annotations.
+func SetDeploymentsStatusAnnotation(annotations *map[string]string, statuses []operatorv1alpha1.DeploymentStatus) error {
+	if len(statuses) == 0 {
+		delete(*annotations, DeploymentsStatusKey)
+		return nil
+	}
+	raw, err := json.Marshal(statuses)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment statuses: %w", err)
+	}
+	if *annotations == nil {
+		*annotations = make(map[string]string, 1)
+	}
+	(*annotations)[DeploymentsStatusKey] = string(raw)
+	return nil
+}
@@ -0,0 +1,77 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeploymentStatusesInNamespaces(t *testing.T) {
+	deployment := func(ns, name string, replicas *int32, ready int32, generation, observed int64) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: ready, ObservedGeneration: observed},
+		}
+	}
+
+	two := int32(2)
+	cl := fake.NewClientBuilder().WithObjects(
+		deployment("knative-serving", "controller", &two, 1, 3, 2),
+		deployment("knative-serving", "activator", nil, 1, 1, 1),
+		deployment("knative-serving-ingress", "3scale-kourier-gateway", &two, 2, 1, 1),
+		deployment("other", "unrelated", nil, 0, 1, 0),
+	).Build()
+
+	got, err := common.DeploymentStatusesInNamespaces(context.Background(), cl, "knative-serving-ingress", "knative-serving")
+	if err != nil {
+		t.Fatalf("DeploymentStatusesInNamespaces() = %v", err)
+	}
+
+	want := []operatorv1alpha1.DeploymentStatus{{
+		Namespace:          "knative-serving",
+		Name:               "activator",
+		Replicas:           1,
+		ReadyReplicas:      1,
+		Generation:         1,
+		ObservedGeneration: 1,
+	}, {
+		Namespace:          "knative-serving",
+		Name:               "controller",
+		Replicas:           2,
+		ReadyReplicas:      1,
+		Generation:         3,
+		ObservedGeneration: 2,
+	}, {
+		Namespace:          "knative-serving-ingress",
+		Name:               "3scale-kourier-gateway",
+		Replicas:           2,
+		ReadyReplicas:      2,
+		Generation:         1,
+		ObservedGeneration: 1,
+	}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Got unexpected statuses, diff: %s", cmp.Diff(got, want))
+	}
+
+	var annotations map[string]string
+	if err := common.SetDeploymentsStatusAnnotation(&annotations, got[:1]); err != nil {
+		t.Fatalf("SetDeploymentsStatusAnnotation() = %v", err)
+	}
+	wantJSON := `[{"namespace":"knative-serving","name":"activator","replicas":1,"readyReplicas":1,"generation":1,"observedGeneration":1}]`
+	if annotations[common.DeploymentsStatusKey] != wantJSON {
+		t.Errorf("Annotation = %s, want %s", annotations[common.DeploymentsStatusKey], wantJSON)
+	}
+	if err := common.SetDeploymentsStatusAnnotation(&annotations, nil); err != nil {
+		t.Fatalf("SetDeploymentsStatusAnnotation() = %v", err)
+	}
+	if _, ok := annotations[common.DeploymentsStatusKey]; ok {
+		t.Error("Annotation was not removed without deployments")
+	}
+}
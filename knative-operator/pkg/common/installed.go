@@ -0,0 +1,125 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InstalledManifestsAnnotation carries the installed Deployments as JSON on KnativeServing and
+// KnativeEventing, whose status is defined upstream.
+const InstalledManifestsAnnotation = "operator.serverless.openshift.io/installed-manifests"
+
+// releaseLabels are the labels carrying the version of a Deployment, in order of precedence.
+var releaseLabels = []string{
+	"serving.knative.dev/release",
+	"eventing.knative.dev/release",
+	"kafka.eventing.knative.dev/release",
+	"app.kubernetes.io/version",
+}
+
+// InstalledManifestsInNamespaces describes all Deployments in the given namespaces.
+func InstalledManifestsInNamespaces(ctx context.Context, c client.Reader, namespaces ...string) ([]operatorv1alpha1.InstalledManifest, error) {
+	var installed []operatorv1alpha1.InstalledManifest
+	for _, ns := range namespaces {
+		list := &appsv1.DeploymentList{}
+		if err := c.List(ctx, list, client.InNamespace(ns)); err != nil {
+			return nil, fmt.Errorf("failed to list deployments in %s: %w", ns, err)
+		}
+		for i := range list.Items {
+			manifest, err := InstalledManifestFor(ctx, c, &list.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			installed = append(installed, manifest)
+		}
+	}
+	sort.Slice(installed, func(i, j int) bool {
+		if installed[i].Namespace != installed[j].Namespace {
+			return installed[i].Namespace < installed[j].Namespace
+		}
+		return installed[i].Name < installed[j].Name
+	})
+	return installed, nil
+}
+
+// InstalledManifestFor describes the given Deployment. The images are resolved to digests
+// through the status of one of its running pods, if any.
+func InstalledManifestFor(ctx context.Context, c client.Reader, d *appsv1.Deployment) (operatorv1alpha1.InstalledManifest, error) {
+	manifest := operatorv1alpha1.InstalledManifest{
+		Namespace: d.Namespace,
+		Name:      d.Name,
+	}
+	for _, label := range releaseLabels {
+		if version := d.Labels[label]; version != "" {
+			manifest.Version = version
+			break
+		}
+	}
+
+	imageIDs, err := runningImageIDs(ctx, c, d)
+	if err != nil {
+		return manifest, err
+	}
+	for _, container := range d.Spec.Template.Spec.Containers {
+		manifest.Images = append(manifest.Images, operatorv1alpha1.InstalledImage{
+			Container: container.Name,
+			Image:     container.Image,
+			ImageID:   imageIDs[container.Name],
+		})
+	}
+	return manifest, nil
+}
+
+// runningImageIDs returns the image IDs per container of the first running pod of the
+// given Deployment.
+func runningImageIDs(ctx context.Context, c client.Reader, d *appsv1.Deployment) (map[string]string, error) {
+	if d.Spec.Selector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of deployment %s/%s: %w", d.Namespace, d.Name, err)
+	}
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(d.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list pods of deployment %s/%s: %w", d.Namespace, d.Name, err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		ids := make(map[string]string, len(pod.Status.ContainerStatuses))
+		for _, status := range pod.Status.ContainerStatuses {
+			ids[status.Name] = status.ImageID
+		}
+		return ids, nil
+	}
+	return nil, nil
+}
+
+// SetInstalledManifestsAnnotation sets the annotation on the given object and returns true
+// if it changed.
+func SetInstalledManifestsAnnotation(obj metav1.Object, installed []operatorv1alpha1.InstalledManifest) (bool, error) {
+	raw, err := json.Marshal(installed)
+	if err != nil {
+		return false, err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations[InstalledManifestsAnnotation] == string(raw) {
+		return false, nil
+	}
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[InstalledManifestsAnnotation] = string(raw)
+	obj.SetAnnotations(annotations)
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestInstalledManifestsInNamespaces(t *testing.T) {
+	labels := map[string]string{"app": "controller"}
+	deployment := func(ns, name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      name,
+				Labels:    map[string]string{"serving.knative.dev/release": "v0.25.0"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "controller", Image: "quay.io/controller:v0.25"}},
+					},
+				},
+			},
+		}
+	}
+	pod := func(name string, phase corev1.PodPhase, imageID string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving", Name: name, Labels: labels},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "controller", ImageID: imageID}},
+			},
+		}
+	}
+
+	cl := fake.NewClientBuilder().WithObjects(
+		deployment("knative-serving", "controller"),
+		deployment("knative-serving-ingress", "3scale-kourier-gateway"),
+		deployment("other", "unrelated"),
+		pod("pending", corev1.PodPending, ""),
+		pod("running", corev1.PodRunning, "quay.io/controller@sha256:1234"),
+	).Build()
+
+	got, err := common.InstalledManifestsInNamespaces(context.Background(), cl, "knative-serving-ingress", "knative-serving")
+	if err != nil {
+		t.Fatalf("InstalledManifestsInNamespaces() = %v", err)
+	}
+
+	want := []operatorv1alpha1.InstalledManifest{{
+		Namespace: "knative-serving",
+		Name:      "controller",
+		Version:   "v0.25.0",
+		Images: []operatorv1alpha1.InstalledImage{{
+			Container: "controller",
+			Image:     "quay.io/controller:v0.25",
+			ImageID:   "quay.io/controller@sha256:1234",
+		}},
+	}, {
+		Namespace: "knative-serving-ingress",
+		Name:      "3scale-kourier-gateway",
+		Version:   "v0.25.0",
+		Images: []operatorv1alpha1.InstalledImage{{
+			Container: "controller",
+			Image:     "quay.io/controller:v0.25",
+		}},
+	}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Got unexpected manifests, diff: %s", cmp.Diff(got, want))
+	}
+
+	ks := &metav1.ObjectMeta{}
+	if changed, err := common.SetInstalledManifestsAnnotation(ks, got); err != nil || !changed {
+		t.Errorf("SetInstalledManifestsAnnotation() = %v, %v, want true, nil", changed, err)
+	}
+	if changed, err := common.SetInstalledManifestsAnnotation(ks, got); err != nil || changed {
+		t.Errorf("SetInstalledManifestsAnnotation() = %v, %v, want false, nil", changed, err)
+	}
+}
@@ -19,6 +19,13 @@ import (
 
 var Log = logf.Log.WithName("knative").WithName("openshift")
 
+// KafkaLog and MonitoringLog are the loggers of the kafka and monitoring subsystems. Their levels
+// are set separately from Log. They are fulfilled along with Log when the operator starts.
+var (
+	KafkaLog      = logf.NewDelegatingLogger(logf.NullLogger{})
+	MonitoringLog = logf.NewDelegatingLogger(logf.NullLogger{})
+)
+
 // Configure is a  helper to set a value for a key, potentially overriding existing contents.
 func Configure(ks *operatorv1alpha1.KnativeServing, cm, key, value string) bool {
 	if ks.Spec.Config == nil {
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// servedPollInterval is how often kinds that aren't served yet are looked up again.
+const servedPollInterval = 30 * time.Second
+
+// WatchWhenServed watches the kind of the given object like c.Watch, if it's served. Watching
+// a kind that isn't served, e.g. because Knative Eventing isn't installed, fails the start of
+// the controller and with it the whole manager. Instead, the watch is deferred until the
+// kind is served.
+func WatchWhenServed(mgr manager.Manager, c controller.Controller, obj client.Object, h handler.EventHandler, prct ...predicate.Predicate) error {
+	served, err := isServed(mgr.GetRESTMapper(), mgr.GetScheme(), obj)
+	if err != nil {
+		return err
+	}
+	if served {
+		return c.Watch(&source.Kind{Type: obj}, h, prct...)
+	}
+
+	kind := fmt.Sprintf("%T", obj)
+	Log.Info("Kind is not served, deferring watch", "kind", kind)
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		err := wait.PollImmediateUntil(servedPollInterval, func() (bool, error) {
+			served, err := isServed(mgr.GetRESTMapper(), mgr.GetScheme(), obj)
+			if err != nil {
+				Log.Error(err, "Failed to look up kind", "kind", kind)
+			}
+			return served, nil
+		}, ctx.Done())
+		if err != nil {
+			// The manager is stopping.
+			return nil
+		}
+		Log.Info("Kind is served, starting watch", "kind", kind)
+		if err := c.Watch(&source.Kind{Type: obj}, h, prct...); err != nil {
+			Log.Error(err, "Failed to watch", "kind", kind)
+		}
+		return nil
+	}))
+}
+
+// isServed returns whether the kind of the given object is served by the API server.
+func isServed(mapper meta.RESTMapper, scheme *runtime.Scheme, obj client.Object) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return false, err
+	}
+	if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
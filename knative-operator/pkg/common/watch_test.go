@@ -0,0 +1,76 @@
+package common_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestWatchWhenServed(t *testing.T) {
+	eventingv1.AddToScheme(scheme.Scheme)
+	gvk := eventingv1.SchemeGroupVersion.WithKind("Broker")
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mgr := &fakeManager{mapper: mapper}
+	c := &fakeController{}
+
+	if err := common.WatchWhenServed(mgr, c, &eventingv1.Broker{}, &handler.EnqueueRequestForObject{}); err != nil {
+		t.Fatalf("WatchWhenServed() = %v", err)
+	}
+	if c.watches != 0 {
+		t.Errorf("Kind is not served, but %d watches were started", c.watches)
+	}
+	if len(mgr.runnables) != 1 {
+		t.Fatalf("Got %d deferred watches, want 1", len(mgr.runnables))
+	}
+
+	// The deferred watch is started once the kind is served.
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+	if err := mgr.runnables[0].Start(context.Background()); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	if c.watches != 1 {
+		t.Errorf("Got %d watches, want 1", c.watches)
+	}
+
+	// Served kinds are watched right away.
+	if err := common.WatchWhenServed(mgr, c, &eventingv1.Broker{}, &handler.EnqueueRequestForObject{}); err != nil {
+		t.Fatalf("WatchWhenServed() = %v", err)
+	}
+	if c.watches != 2 || len(mgr.runnables) != 1 {
+		t.Errorf("Got %d watches and %d deferred watches, want 2 and 1", c.watches, len(mgr.runnables))
+	}
+}
+
+type fakeManager struct {
+	manager.Manager
+	mapper    meta.RESTMapper
+	runnables []manager.Runnable
+}
+
+func (m *fakeManager) GetRESTMapper() meta.RESTMapper { return m.mapper }
+func (m *fakeManager) GetScheme() *runtime.Scheme     { return scheme.Scheme }
+func (m *fakeManager) Add(r manager.Runnable) error {
+	m.runnables = append(m.runnables, r)
+	return nil
+}
+
+type fakeController struct {
+	controller.Controller
+	watches int
+}
+
+func (c *fakeController) Watch(source.Source, handler.EventHandler, ...predicate.Predicate) error {
+	c.watches++
+	return nil
+}
@@ -0,0 +1,216 @@
+package brokerinjection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// InjectionLabel is the namespace label that enables the provisioning of a default Broker.
+	InjectionLabel = "eventing.knative.openshift.io/injection"
+
+	// BrokerName is the name of the Broker provisioned into labelled namespaces.
+	BrokerName = "default"
+
+	// provisionedLabel marks the Brokers provisioned by this controller. Brokers without it
+	// are never touched.
+	provisionedLabel = "eventing.knative.openshift.io/provisioned"
+)
+
+var log = common.Log.WithName("brokerinjection-controller")
+
+// injectionPredicate selects namespaces labelled for injection. Updates removing the label
+// are selected too, so that the provisioned Broker can be removed.
+var injectionPredicate = predicate.Funcs{
+	CreateFunc:  func(e event.CreateEvent) bool { return isInjected(e.Object) },
+	DeleteFunc:  func(e event.DeleteEvent) bool { return isInjected(e.Object) },
+	GenericFunc: func(e event.GenericEvent) bool { return isInjected(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return isInjected(e.ObjectOld) || isInjected(e.ObjectNew)
+	},
+}
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileBrokerInjection{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("brokerinjection-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Namespaces are the primary resource. Only labelled ones are of interest, including
+	// those that just lost the label.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{}, injectionPredicate)
+	if err != nil {
+		return err
+	}
+
+	// Restore provisioned Brokers if they get changed or deleted. Brokers are only served once
+	// Knative Eventing is installed.
+	enqueueNamespace := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+	})
+	err = common.WatchWhenServed(mgr, c, &eventingv1.Broker{}, handler.EnqueueRequestsFromMapFunc(enqueueNamespace), predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[provisionedLabel] == "true"
+	}))
+	if err != nil {
+		return err
+	}
+
+	// Add or remove the dashboards of all provisioned Brokers once monitoring is toggled.
+	enqueueInjected := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		list := &corev1.NamespaceList{}
+		if err := mgr.GetClient().List(context.Background(), list, client.MatchingLabels{InjectionLabel: "enabled"}); err != nil {
+			log.Error(err, "Failed to list namespaces labelled for injection")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(list.Items))
+		for _, ns := range list.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+		}
+		return requests
+	})
+	return c.Watch(&source.Kind{Type: &operatorv1alpha1.KnativeEventing{}}, handler.EnqueueRequestsFromMapFunc(enqueueInjected))
+}
+
+// blank assignment to verify that ReconcileBrokerInjection implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileBrokerInjection{}
+
+// ReconcileBrokerInjection provisions a default Broker into namespaces labelled for injection.
+type ReconcileBrokerInjection struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile creates the default Broker in the given namespace if it's labelled for injection
+// and removes it once the label is removed.
+func (r *ReconcileBrokerInjection) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	provisioned, err := r.reconcileBroker(ctx, request)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, r.reconcileDashboard(ctx, request.Name, provisioned)
+}
+
+// reconcileBroker returns whether the namespace has a Broker provisioned by the operator.
+func (r *ReconcileBrokerInjection) reconcileBroker(ctx context.Context, request reconcile.Request) (bool, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, request.NamespacedName, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if ns.DeletionTimestamp != nil {
+		// The Broker is removed along with the namespace.
+		return false, nil
+	}
+
+	if !isInjected(ns) {
+		// The provisioned Broker is deleted by its label, so no Brokers are read for
+		// namespaces that aren't labelled. Without Knative Eventing, there's none.
+		err := r.client.DeleteAllOf(ctx, &eventingv1.Broker{}, client.InNamespace(ns.Name), client.MatchingLabels{provisionedLabel: "true"})
+		if err != nil && !meta.IsNoMatchError(err) {
+			return false, fmt.Errorf("failed to delete provisioned Broker: %w", err)
+		}
+		return false, nil
+	}
+
+	existing := &eventingv1.Broker{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: BrokerName}, existing)
+	if meta.IsNoMatchError(err) {
+		reqLogger.Info("Brokers are not served, skipping")
+		return false, nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get Broker: %w", err)
+	}
+	exists := err == nil
+
+	desired := MakeBroker(ns.Name)
+	if !exists {
+		reqLogger.Info("Provisioning Broker")
+		if err := r.client.Create(ctx, desired); err != nil {
+			return false, fmt.Errorf("failed to create Broker: %w", err)
+		}
+		return true, nil
+	}
+
+	if existing.Labels[provisionedLabel] != "true" {
+		reqLogger.Info("Broker exists already and is not managed by the operator, skipping")
+		return false, nil
+	}
+	if equality.Semantic.DeepEqual(existing.Spec.Delivery, desired.Spec.Delivery) {
+		return true, nil
+	}
+	reqLogger.Info("Updating provisioned Broker")
+	copy := existing.DeepCopy()
+	copy.Spec.Delivery = desired.Spec.Delivery
+	if err := r.client.Update(ctx, copy); err != nil {
+		return false, fmt.Errorf("failed to update Broker: %w", err)
+	}
+	return true, nil
+}
+
+// MakeBroker creates the default Broker for the given namespace, retrying delivery with an
+// exponential backoff. Its state is published through the Knative resource metrics.
+func MakeBroker(ns string) *eventingv1.Broker {
+	backoff := eventingduckv1.BackoffPolicyExponential
+	return &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BrokerName,
+			Namespace: ns,
+			Labels:    map[string]string{provisionedLabel: "true"},
+			Annotations: map[string]string{
+				eventing.BrokerClassKey: eventing.MTChannelBrokerClassValue,
+			},
+		},
+		Spec: eventingv1.BrokerSpec{
+			Delivery: &eventingduckv1.DeliverySpec{
+				Retry:         ptr.Int32(5),
+				BackoffPolicy: &backoff,
+				BackoffDelay:  ptr.String("PT0.2S"),
+			},
+		},
+	}
+}
+
+func isInjected(obj client.Object) bool {
+	return obj.GetLabels()[InjectionLabel] == "enabled"
+}
@@ -0,0 +1,123 @@
+package brokerinjection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestBrokerInjectionReconcile(t *testing.T) {
+	unmanaged := &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{Name: BrokerName, Namespace: "test"},
+	}
+	modified := MakeBroker("test")
+	modified.Spec.Delivery.Retry = ptr.Int32(1)
+
+	tests := []struct {
+		name       string
+		labels     map[string]string
+		existing   *eventingv1.Broker
+		wantBroker bool
+		wantRetry  int32
+	}{{
+		name: "not labelled",
+	}, {
+		name:       "labelled",
+		labels:     map[string]string{InjectionLabel: "enabled"},
+		wantBroker: true,
+		wantRetry:  5,
+	}, {
+		name:       "labelled, delivery modified",
+		labels:     map[string]string{InjectionLabel: "enabled"},
+		existing:   modified,
+		wantBroker: true,
+		wantRetry:  5,
+	}, {
+		name:     "label removed",
+		labels:   map[string]string{InjectionLabel: "disabled"},
+		existing: MakeBroker("test"),
+	}, {
+		name:       "labelled, unmanaged broker",
+		labels:     map[string]string{InjectionLabel: "enabled"},
+		existing:   unmanaged,
+		wantBroker: true,
+	}, {
+		name:       "not labelled, unmanaged broker",
+		existing:   unmanaged,
+		wantBroker: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := []client.Object{&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: test.labels},
+			}}
+			if test.existing != nil {
+				objs = append(objs, test.existing.DeepCopy())
+			}
+			cl := fake.NewClientBuilder().WithObjects(objs...).Build()
+			r := &ReconcileBrokerInjection{client: cl, scheme: scheme.Scheme}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &eventingv1.Broker{}
+			err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: BrokerName}, got)
+			if !test.wantBroker {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("Broker should not exist, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			var retry int32
+			if got.Spec.Delivery != nil && got.Spec.Delivery.Retry != nil {
+				retry = *got.Spec.Delivery.Retry
+			}
+			if retry != test.wantRetry {
+				t.Errorf("Got retry %d, want %d", retry, test.wantRetry)
+			}
+		})
+	}
+}
+
+func TestInjectionPredicate(t *testing.T) {
+	injected := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{InjectionLabel: "enabled"},
+	}}
+	plain := &corev1.Namespace{}
+
+	if !injectionPredicate.Update(event.UpdateEvent{ObjectOld: injected, ObjectNew: plain}) {
+		t.Error("Removing the injection label should be selected")
+	}
+	if !injectionPredicate.Update(event.UpdateEvent{ObjectOld: plain, ObjectNew: injected}) {
+		t.Error("Adding the injection label should be selected")
+	}
+	if injectionPredicate.Update(event.UpdateEvent{ObjectOld: plain, ObjectNew: plain}) {
+		t.Error("Updates of unlabelled namespaces should not be selected")
+	}
+	if injectionPredicate.Create(event.CreateEvent{Object: plain}) {
+		t.Error("Unlabelled namespaces should not be selected")
+	}
+}
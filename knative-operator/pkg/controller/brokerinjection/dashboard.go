@@ -0,0 +1,164 @@
+package brokerinjection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring/dashboards"
+	okomon "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/monitoring"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	dashboardLabel = "console.openshift.io/dashboard"
+	dashboardKey   = "broker-dashboard.json"
+)
+
+// knativeEventing is the KnativeEventing whose monitoring setting applies to the Brokers.
+var knativeEventing = types.NamespacedName{Namespace: "knative-eventing", Name: "knative-eventing"}
+
+// dashboardName returns the name of the dashboard of the Broker provisioned into the given
+// namespace.
+func dashboardName(ns string) string {
+	return "grafana-dashboard-definition-knative-broker-" + ns
+}
+
+// reconcileDashboard creates a dashboard of the delivery of the Broker provisioned into the
+// given namespace while monitoring is enabled, and removes it otherwise. The Broker data plane
+// is shared and scraped through the ServiceMonitors of Knative Eventing, so the dashboard only
+// narrows its metrics down to the Broker. It lives in the console's namespace and thus can't be
+// owned by the Broker, but is removed along with it.
+func (r *ReconcileBrokerInjection) reconcileDashboard(ctx context.Context, ns string, provisioned bool) error {
+	key := client.ObjectKey{Namespace: dashboards.ConfigManagedNamespace, Name: dashboardName(ns)}
+	existing := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, key, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get Broker dashboard: %w", err)
+	}
+	exists := err == nil
+
+	enabled, err := r.monitoringEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	if !provisioned || !enabled {
+		if !exists {
+			return nil
+		}
+		log.Info("Deleting Broker dashboard", "Request.Name", ns)
+		if err := r.client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Broker dashboard: %w", err)
+		}
+		return nil
+	}
+
+	desired := makeDashboard(ns)
+	if !exists {
+		err := r.client.Get(ctx, client.ObjectKey{Name: dashboards.ConfigManagedNamespace}, &corev1.Namespace{})
+		if errors.IsNotFound(err) {
+			// The cluster has no console to show dashboards.
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to get namespace %q: %w", dashboards.ConfigManagedNamespace, err)
+		}
+		log.Info("Creating Broker dashboard", "Request.Name", ns)
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create Broker dashboard: %w", err)
+		}
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Data, desired.Data) && existing.Labels[dashboardLabel] == "true" {
+		return nil
+	}
+	copy := existing.DeepCopy()
+	copy.Data = desired.Data
+	if copy.Labels == nil {
+		copy.Labels = make(map[string]string, 1)
+	}
+	copy.Labels[dashboardLabel] = "true"
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to update Broker dashboard: %w", err)
+	}
+	return nil
+}
+
+// monitoringEnabled returns whether monitoring is enabled for Knative Eventing.
+func (r *ReconcileBrokerInjection) monitoringEnabled(ctx context.Context) (bool, error) {
+	ke := &operatorv1alpha1.KnativeEventing{}
+	if err := r.client.Get(ctx, knativeEventing, ke); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get KnativeEventing: %w", err)
+	}
+	return okomon.ShouldEnableMonitoring(ke.Spec.GetConfig()), nil
+}
+
+// makeDashboard creates the dashboard of the Broker provisioned into the given namespace.
+func makeDashboard(ns string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dashboardName(ns),
+			Namespace: dashboards.ConfigManagedNamespace,
+			Labels: map[string]string{
+				dashboardLabel:   "true",
+				provisionedLabel: "true",
+			},
+		},
+		Data: map[string]string{
+			dashboardKey: strings.NewReplacer("$namespace", ns, "$broker", BrokerName).Replace(dashboardTemplate),
+		},
+	}
+}
+
+// dashboardTemplate shows the delivery of a single Broker through its ingress and filter.
+const dashboardTemplate = `{
+  "title": "Knative Broker $namespace/$broker",
+  "editable": false,
+  "schemaVersion": 16,
+  "time": {"from": "now-1h", "to": "now"},
+  "refresh": "10s",
+  "panels": [
+    {
+      "id": 1,
+      "type": "graph",
+      "title": "Ingress Events (per second) by Response Code Class",
+      "datasource": "prometheus",
+      "gridPos": {"h": 8, "w": 12, "x": 0, "y": 0},
+      "targets": [{
+        "expr": "sum(rate(mt_broker_ingress_event_count{namespace_name=\"$namespace\", broker_name=\"$broker\"}[1m])) by (response_code_class)",
+        "legendFormat": "{{response_code_class}}"
+      }]
+    },
+    {
+      "id": 2,
+      "type": "graph",
+      "title": "Delivered Events (per second) by Trigger and Response Code Class",
+      "datasource": "prometheus",
+      "gridPos": {"h": 8, "w": 12, "x": 12, "y": 0},
+      "targets": [{
+        "expr": "sum(rate(mt_broker_filter_event_count{namespace_name=\"$namespace\", broker_name=\"$broker\"}[1m])) by (trigger_name, response_code_class)",
+        "legendFormat": "{{trigger_name}} {{response_code_class}}"
+      }]
+    },
+    {
+      "id": 3,
+      "type": "graph",
+      "title": "Delivery Latency (99th percentile) by Trigger",
+      "datasource": "prometheus",
+      "gridPos": {"h": 8, "w": 24, "x": 0, "y": 8},
+      "targets": [{
+        "expr": "histogram_quantile(0.99, sum(rate(mt_broker_filter_event_dispatch_latencies_bucket{namespace_name=\"$namespace\", broker_name=\"$broker\"}[1m])) by (le, trigger_name))",
+        "legendFormat": "{{trigger_name}}"
+      }]
+    }
+  ]
+}
+`
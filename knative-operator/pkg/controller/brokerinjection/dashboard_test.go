@@ -0,0 +1,82 @@
+package brokerinjection
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring/dashboards"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBrokerDashboardReconcile(t *testing.T) {
+	monitored := &operatorv1alpha1.KnativeEventing{
+		ObjectMeta: metav1.ObjectMeta{Namespace: knativeEventing.Namespace, Name: knativeEventing.Name},
+	}
+	unmonitored := monitored.DeepCopy()
+	unmonitored.Spec.Config = operatorv1alpha1.ConfigMapData{
+		"observability": {"metrics.backend-destination": "none"},
+	}
+	configManaged := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: dashboards.ConfigManagedNamespace}}
+
+	tests := []struct {
+		name          string
+		labels        map[string]string
+		objs          []client.Object
+		wantDashboard bool
+	}{{
+		name:          "labelled, monitoring enabled",
+		labels:        map[string]string{InjectionLabel: "enabled"},
+		objs:          []client.Object{monitored, configManaged},
+		wantDashboard: true,
+	}, {
+		name:   "labelled, monitoring disabled",
+		labels: map[string]string{InjectionLabel: "enabled"},
+		objs:   []client.Object{unmonitored, configManaged, makeDashboard("test")},
+	}, {
+		name:   "labelled, without console",
+		labels: map[string]string{InjectionLabel: "enabled"},
+		objs:   []client.Object{monitored},
+	}, {
+		name: "label removed",
+		objs: []client.Object{monitored, configManaged, MakeBroker("test"), makeDashboard("test")},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := append([]client.Object{&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: test.labels},
+			}}, test.objs...)
+			cl := fake.NewClientBuilder().WithObjects(objs...).Build()
+			r := &ReconcileBrokerInjection{client: cl, scheme: scheme.Scheme}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &corev1.ConfigMap{}
+			err := cl.Get(context.Background(), client.ObjectKey{Namespace: dashboards.ConfigManagedNamespace, Name: dashboardName("test")}, got)
+			if !test.wantDashboard {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("Dashboard should not exist, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			if got.Labels[dashboardLabel] != "true" {
+				t.Errorf("Dashboard is not labelled for the console: %v", got.Labels)
+			}
+			if !json.Valid([]byte(got.Data[dashboardKey])) {
+				t.Errorf("Dashboard is not valid JSON: %s", got.Data[dashboardKey])
+			}
+		})
+	}
+}
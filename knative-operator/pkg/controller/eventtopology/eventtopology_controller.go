@@ -0,0 +1,386 @@
+package eventtopology
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap the topology of a namespace is published to.
+	ConfigMapName = "knative-eventing-topology"
+
+	// TopologyKey is the key of the ConfigMap holding the topology as JSON.
+	TopologyKey = "topology.json"
+
+	// managedLabel marks the ConfigMaps created by us.
+	managedLabel = "eventing.knative.openshift.io/topology"
+)
+
+// The types of the edges of the topology.
+const (
+	// EdgeSink connects a source to its sink.
+	EdgeSink = "sink"
+	// EdgeTrigger connects a Broker to its Triggers.
+	EdgeTrigger = "trigger"
+	// EdgeSubscriber connects a Trigger to its subscriber.
+	EdgeSubscriber = "subscriber"
+	// EdgeDeadLetterSink connects a Broker or Trigger to its dead letter sink.
+	EdgeDeadLetterSink = "deadLetterSink"
+)
+
+var log = common.Log.WithName("eventtopology-controller")
+
+// Topology is the graph of the eventing resources of a namespace, as published to the console.
+type Topology struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Node is a resource of the topology. Nodes that are only referenced, like sinks, have no
+// readiness. Nodes given by a URI only have an ID.
+type Node struct {
+	ID         string `json:"id"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Ready      *bool  `json:"ready,omitempty"`
+}
+
+// Edge is the flow of events from one node to another.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// watched are the eventing resources making up the topology.
+var watched = []client.Object{
+	&eventingv1.Broker{},
+	&eventingv1.Trigger{},
+	&sourcesv1.ApiServerSource{},
+	&sourcesv1.ContainerSource{},
+	&sourcesv1.PingSource{},
+	&sourcesv1.SinkBinding{},
+}
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileEventTopology{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("eventtopology-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// The topology is rebuilt for the namespace of the changed resource only. The eventing
+	// resources are only served once Knative Eventing is installed.
+	enqueueNamespace := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+	})
+	for _, t := range watched {
+		if err := common.WatchWhenServed(mgr, c, t, enqueueNamespace); err != nil {
+			return err
+		}
+	}
+
+	// Restore the topology if it gets changed or deleted.
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, enqueueNamespace, predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[managedLabel] == "true"
+	}))
+}
+
+// blank assignment to verify that ReconcileEventTopology implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileEventTopology{}
+
+// ReconcileEventTopology publishes the topology of the eventing resources of namespaces.
+type ReconcileEventTopology struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile builds the topology of the given namespace and publishes it to a ConfigMap in it,
+// which is removed again once the namespace has no eventing resources left.
+func (r *ReconcileEventTopology) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, request.NamespacedName, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if ns.DeletionTimestamp != nil {
+		// The ConfigMap is removed along with the namespace.
+		return reconcile.Result{}, nil
+	}
+
+	existing := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: ConfigMapName}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, fmt.Errorf("failed to get topology: %w", err)
+	}
+	exists := err == nil
+	if exists && existing.Labels[managedLabel] != "true" {
+		reqLogger.Info("Topology ConfigMap exists already and is not managed by the operator, skipping")
+		return reconcile.Result{}, nil
+	}
+
+	topology, err := r.build(ctx, ns.Name)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if len(topology.Nodes) == 0 {
+		if !exists {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Deleting topology")
+		if err := r.client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete topology: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	raw, err := json.Marshal(topology)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to marshal topology: %w", err)
+	}
+	data := map[string]string{TopologyKey: string(raw)}
+	if !exists {
+		reqLogger.Info("Creating topology")
+		err := r.client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ConfigMapName,
+				Namespace: ns.Name,
+				Labels:    map[string]string{managedLabel: "true"},
+			},
+			Data: data,
+		})
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to create topology: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if existing.Data[TopologyKey] == data[TopologyKey] && len(existing.Data) == 1 {
+		return reconcile.Result{}, nil
+	}
+	reqLogger.Info("Updating topology")
+	copy := existing.DeepCopy()
+	copy.Data = data
+	if err := r.client.Update(ctx, copy); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update topology: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// build returns the topology of the eventing resources of the given namespace, sorted to be
+// stable across reconciles.
+func (r *ReconcileEventTopology) build(ctx context.Context, namespace string) (*Topology, error) {
+	b := &builder{namespace: namespace, nodes: map[string]Node{}}
+
+	brokers := &eventingv1.BrokerList{}
+	if err := r.client.List(ctx, brokers, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list Brokers: %w", err)
+	}
+	for i := range brokers.Items {
+		broker := &brokers.Items[i]
+		id := b.object(broker, "eventing.knative.dev/v1", "Broker", &broker.Status.Status)
+		b.deadLetterSink(id, broker.Spec.Delivery)
+	}
+
+	triggers := &eventingv1.TriggerList{}
+	if err := r.client.List(ctx, triggers, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list Triggers: %w", err)
+	}
+	for i := range triggers.Items {
+		trigger := &triggers.Items[i]
+		id := b.object(trigger, "eventing.knative.dev/v1", "Trigger", &trigger.Status.Status)
+		broker := b.ref(&duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: trigger.Spec.Broker})
+		b.edge(broker, id, EdgeTrigger)
+		b.edge(id, b.destination(&trigger.Spec.Subscriber), EdgeSubscriber)
+		b.deadLetterSink(id, trigger.Spec.Delivery)
+	}
+
+	apiServerSources := &sourcesv1.ApiServerSourceList{}
+	if err := r.client.List(ctx, apiServerSources, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list ApiServerSources: %w", err)
+	}
+	for i := range apiServerSources.Items {
+		src := &apiServerSources.Items[i]
+		b.source(src, "ApiServerSource", &src.Spec.SourceSpec, &src.Status.Status)
+	}
+
+	containerSources := &sourcesv1.ContainerSourceList{}
+	if err := r.client.List(ctx, containerSources, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list ContainerSources: %w", err)
+	}
+	for i := range containerSources.Items {
+		src := &containerSources.Items[i]
+		b.source(src, "ContainerSource", &src.Spec.SourceSpec, &src.Status.Status)
+	}
+
+	pingSources := &sourcesv1.PingSourceList{}
+	if err := r.client.List(ctx, pingSources, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list PingSources: %w", err)
+	}
+	for i := range pingSources.Items {
+		src := &pingSources.Items[i]
+		b.source(src, "PingSource", &src.Spec.SourceSpec, &src.Status.Status)
+	}
+
+	sinkBindings := &sourcesv1.SinkBindingList{}
+	if err := r.client.List(ctx, sinkBindings, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list SinkBindings: %w", err)
+	}
+	for i := range sinkBindings.Items {
+		src := &sinkBindings.Items[i]
+		b.source(src, "SinkBinding", &src.Spec.SourceSpec, &src.Status.Status)
+	}
+
+	return b.topology(), nil
+}
+
+// builder collects the nodes and edges of the topology of a namespace.
+type builder struct {
+	namespace string
+	nodes     map[string]Node
+	edges     []Edge
+}
+
+// object adds a node for the given resource of the namespace and returns its ID.
+func (b *builder) object(obj metav1.Object, apiVersion, kind string, status *duckv1.Status) string {
+	ready := status.GetCondition(apis.ConditionReady).IsTrue()
+	node := Node{
+		ID:         nodeID(kind, obj.GetNamespace(), obj.GetName()),
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Ready:      &ready,
+	}
+	b.nodes[node.ID] = node
+	return node.ID
+}
+
+// source adds a node for the given source and an edge to its sink.
+func (b *builder) source(obj metav1.Object, kind string, spec *duckv1.SourceSpec, status *duckv1.Status) {
+	id := b.object(obj, sourcesv1.SchemeGroupVersion.String(), kind, status)
+	b.edge(id, b.destination(&spec.Sink), EdgeSink)
+}
+
+// deadLetterSink adds an edge from the given node to the dead letter sink of the given
+// delivery, if any.
+func (b *builder) deadLetterSink(from string, delivery *eventingduckv1.DeliverySpec) {
+	if delivery != nil && delivery.DeadLetterSink != nil {
+		b.edge(from, b.destination(delivery.DeadLetterSink), EdgeDeadLetterSink)
+	}
+}
+
+// destination returns the ID of the node of the given destination, adding the node unless
+// it's a resource of the namespace that's part of the topology already. Destinations given
+// by a reference and a URI are identified by the reference, as the URI only extends it.
+func (b *builder) destination(dest *duckv1.Destination) string {
+	if dest.Ref != nil {
+		return b.ref(dest.Ref)
+	}
+	if dest.URI != nil {
+		id := dest.URI.String()
+		if _, ok := b.nodes[id]; !ok {
+			b.nodes[id] = Node{ID: id}
+		}
+		return id
+	}
+	return ""
+}
+
+// ref returns the ID of the node of the given reference, adding the node unless it's part of
+// the topology already. References without a namespace point into the namespace.
+func (b *builder) ref(ref *duckv1.KReference) string {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = b.namespace
+	}
+	id := nodeID(ref.Kind, namespace, ref.Name)
+	if _, ok := b.nodes[id]; !ok {
+		b.nodes[id] = Node{
+			ID:         id,
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Namespace:  namespace,
+			Name:       ref.Name,
+		}
+	}
+	return id
+}
+
+// edge adds an edge between the given nodes, unless one of them is unknown.
+func (b *builder) edge(from, to, typ string) {
+	if from != "" && to != "" {
+		b.edges = append(b.edges, Edge{From: from, To: to, Type: typ})
+	}
+}
+
+// topology returns the collected nodes and edges, sorted by their IDs.
+func (b *builder) topology() *Topology {
+	t := &Topology{Nodes: make([]Node, 0, len(b.nodes)), Edges: b.edges}
+	for _, node := range b.nodes {
+		t.Nodes = append(t.Nodes, node)
+	}
+	sort.Slice(t.Nodes, func(i, j int) bool {
+		return t.Nodes[i].ID < t.Nodes[j].ID
+	})
+	if t.Edges == nil {
+		t.Edges = []Edge{}
+	}
+	sort.Slice(t.Edges, func(i, j int) bool {
+		if t.Edges[i].From != t.Edges[j].From {
+			return t.Edges[i].From < t.Edges[j].From
+		}
+		if t.Edges[i].To != t.Edges[j].To {
+			return t.Edges[i].To < t.Edges[j].To
+		}
+		return t.Edges[i].Type < t.Edges[j].Type
+	})
+	return t
+}
+
+// nodeID returns the ID of the node of the given resource.
+func nodeID(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
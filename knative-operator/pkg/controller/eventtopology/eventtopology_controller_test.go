@@ -0,0 +1,148 @@
+package eventtopology
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	knativeapis "knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestEventTopologyReconcile(t *testing.T) {
+	ksvc := func(name string) *duckv1.KReference {
+		return &duckv1.KReference{APIVersion: "serving.knative.dev/v1", Kind: "Service", Name: name}
+	}
+	broker := &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "test"},
+		Spec: eventingv1.BrokerSpec{
+			Delivery: &eventingduckv1.DeliverySpec{DeadLetterSink: &duckv1.Destination{Ref: ksvc("dls")}},
+		},
+	}
+	broker.Status.SetConditions(knativeapis.Conditions{{Type: knativeapis.ConditionReady, Status: corev1.ConditionTrue}})
+	trigger := &eventingv1.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "test"},
+		Spec: eventingv1.TriggerSpec{
+			Broker:     "default",
+			Subscriber: duckv1.Destination{Ref: ksvc("orders")},
+		},
+	}
+	ping := &sourcesv1.PingSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "ping", Namespace: "test"},
+		Spec: sourcesv1.PingSourceSpec{SourceSpec: duckv1.SourceSpec{Sink: duckv1.Destination{
+			Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: "default"},
+		}}},
+	}
+	url, _ := knativeapis.ParseURL("http://example.com")
+	binding := &sourcesv1.SinkBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "binding", Namespace: "test"},
+		Spec:       sourcesv1.SinkBindingSpec{SourceSpec: duckv1.SourceSpec{Sink: duckv1.Destination{URI: url}}},
+	}
+	// Resources of other namespaces are not part of the topology.
+	other := &sourcesv1.PingSource{ObjectMeta: metav1.ObjectMeta{Name: "ping", Namespace: "other"}}
+
+	cl := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		broker, trigger, ping, binding, other,
+	).Build()
+	r := &ReconcileEventTopology{client: cl, scheme: scheme.Scheme}
+
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: ConfigMapName}, cm); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	got := &Topology{}
+	if err := json.Unmarshal([]byte(cm.Data[TopologyKey]), got); err != nil {
+		t.Fatalf("unmarshal: (%v)", err)
+	}
+
+	ready, notReady := true, false
+	want := &Topology{
+		Nodes: []Node{{
+			ID: "Broker/test/default", APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Namespace: "test", Name: "default", Ready: &ready,
+		}, {
+			ID: "PingSource/test/ping", APIVersion: "sources.knative.dev/v1", Kind: "PingSource", Namespace: "test", Name: "ping", Ready: &notReady,
+		}, {
+			ID: "Service/test/dls", APIVersion: "serving.knative.dev/v1", Kind: "Service", Namespace: "test", Name: "dls",
+		}, {
+			ID: "Service/test/orders", APIVersion: "serving.knative.dev/v1", Kind: "Service", Namespace: "test", Name: "orders",
+		}, {
+			ID: "SinkBinding/test/binding", APIVersion: "sources.knative.dev/v1", Kind: "SinkBinding", Namespace: "test", Name: "binding", Ready: &notReady,
+		}, {
+			ID: "Trigger/test/orders", APIVersion: "eventing.knative.dev/v1", Kind: "Trigger", Namespace: "test", Name: "orders", Ready: &notReady,
+		}, {
+			ID: "http://example.com",
+		}},
+		Edges: []Edge{
+			{From: "Broker/test/default", To: "Service/test/dls", Type: EdgeDeadLetterSink},
+			{From: "Broker/test/default", To: "Trigger/test/orders", Type: EdgeTrigger},
+			{From: "PingSource/test/ping", To: "Broker/test/default", Type: EdgeSink},
+			{From: "SinkBinding/test/binding", To: "http://example.com", Type: EdgeSink},
+			{From: "Trigger/test/orders", To: "Service/test/orders", Type: EdgeSubscriber},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Got = %v, want: %v, diff(-want,+got):\n%s", got, want, cmp.Diff(want, got))
+	}
+
+	// The topology is removed along with the last eventing resource.
+	for _, obj := range []client.Object{broker, trigger, ping, binding} {
+		if err := cl.Delete(context.Background(), obj); err != nil {
+			t.Fatalf("delete: (%v)", err)
+		}
+	}
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: ConfigMapName}, cm)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("ConfigMap should not exist, got: %v", err)
+	}
+}
+
+func TestEventTopologySkipsUnmanaged(t *testing.T) {
+	unmanaged := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "test"},
+		Data:       map[string]string{"foo": "bar"},
+	}
+	cl := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		&eventingv1.Broker{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "test"}},
+		unmanaged,
+	).Build()
+	r := &ReconcileEventTopology{client: cl, scheme: scheme.Scheme}
+
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	got := &corev1.ConfigMap{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: ConfigMapName}, got); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if !cmp.Equal(got.Data, unmanaged.Data) {
+		t.Errorf("Got = %v, want: %v", got.Data, unmanaged.Data)
+	}
+}
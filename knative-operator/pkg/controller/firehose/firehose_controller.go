@@ -0,0 +1,426 @@
+package firehose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// FirehoseLabel is the namespace label that sends the Kubernetes events of a namespace to
+	// the firehose, if it's enabled on the KnativeKafka.
+	FirehoseLabel = "eventing.knative.openshift.io/firehose"
+
+	// ResourceName is the name of the KafkaSink, and of the ApiServerSource, its
+	// ServiceAccount and RBAC in every labelled namespace.
+	ResourceName = "knative-firehose"
+
+	// provisionedLabel marks the resources created by this controller, including the
+	// namespace of the KafkaSink if it didn't exist. Resources without it are never touched.
+	provisionedLabel = "eventing.knative.openshift.io/firehose-provisioned"
+
+	// sinkLabel marks the namespace holding the KafkaSink, so it's found once the firehose is
+	// disabled or moved.
+	sinkLabel = "eventing.knative.openshift.io/firehose-sink"
+
+	// sinkIngress is the host of the KafkaSink data plane, which routes requests by the
+	// namespace and name of the KafkaSink. ApiServerSources can't refer to a KafkaSink in
+	// another namespace, so they send to its address directly.
+	sinkIngress = "kafka-sink-ingress.knative-eventing.svc.cluster.local"
+)
+
+var (
+	log = common.Log.WithName("firehose-controller")
+
+	kafkaSinkGVK = schema.GroupVersionKind{Group: "eventing.knative.dev", Version: "v1alpha1", Kind: "KafkaSink"}
+)
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) *ReconcileFirehose {
+	return &ReconcileFirehose{client: mgr.GetClient(), reader: mgr.GetAPIReader(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileFirehose) error {
+	// Create a new controller
+	c, err := controller.New("firehose-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Namespaces are the primary resource. All changes are of interest, as the removal of the
+	// label has to be noticed too. The sources and sinks aren't watched, as their CRDs might
+	// not be installed, but restored with every resync.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Reconcile all namespaces involved once the firehose is reconfigured.
+	return c.Watch(&source.Kind{Type: &operatorv1alpha1.KnativeKafka{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		namespaces, err := r.involvedNamespaces(context.Background(), obj.(*operatorv1alpha1.KnativeKafka))
+		if err != nil {
+			log.Error(err, "Failed to list the namespaces of the firehose")
+		}
+		requests := make([]reconcile.Request, 0, namespaces.Len())
+		for _, ns := range namespaces.List() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns}})
+		}
+		return requests
+	}))
+}
+
+// blank assignment to verify that ReconcileFirehose implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileFirehose{}
+
+// ReconcileFirehose installs the firehose of Kubernetes events configured on the KnativeKafka.
+type ReconcileFirehose struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	// reader reads the sources and sinks from the apiserver, so no informers are started
+	// for them.
+	reader client.Reader
+	scheme *runtime.Scheme
+}
+
+// Reconcile creates the KafkaSink if the given namespace is the firehose's, and the
+// ApiServerSource sending the namespace's events to it if the namespace is labelled. Both
+// are removed once the firehose is disabled or moved, or the label is removed.
+func (r *ReconcileFirehose) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	firehose, servers, err := r.firehose(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, request.NamespacedName, ns); err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	} else if errors.IsNotFound(err) {
+		ns = nil
+	}
+	if ns != nil && ns.DeletionTimestamp != nil {
+		// Everything is removed along with the namespace.
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.reconcileSink(ctx, request.Name, ns, firehose, servers); err != nil {
+		return reconcile.Result{}, err
+	}
+	if ns == nil {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{}, r.reconcileSource(ctx, ns, firehose)
+}
+
+// firehose returns the firehose configuration and the bootstrap servers of the KnativeKafka,
+// or nil if the firehose is disabled.
+func (r *ReconcileFirehose) firehose(ctx context.Context) (*operatorv1alpha1.Firehose, []string, error) {
+	list := &operatorv1alpha1.KnativeKafkaList{}
+	if err := r.client.List(ctx, list); err != nil {
+		return nil, nil, fmt.Errorf("failed to list KnativeKafkas: %w", err)
+	}
+	if len(list.Items) == 0 || list.Items[0].DeletionTimestamp != nil {
+		return nil, nil, nil
+	}
+	spec := list.Items[0].Spec
+	if !spec.Firehose.Enabled || !spec.Sink.Enabled {
+		return nil, nil, nil
+	}
+	var servers []string
+	for _, server := range strings.Split(spec.Channel.BootstrapServers, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return &spec.Firehose, servers, nil
+}
+
+// involvedNamespaces returns the namespaces whose firehose resources depend on the given
+// KnativeKafka: the labelled namespaces and the namespaces of the current and previous
+// KafkaSinks.
+func (r *ReconcileFirehose) involvedNamespaces(ctx context.Context, kafka *operatorv1alpha1.KnativeKafka) (sets.String, error) {
+	namespaces := sets.NewString(kafka.Spec.Firehose.SinkNamespace())
+	for _, selector := range []client.MatchingLabels{{FirehoseLabel: "enabled"}, {sinkLabel: "true"}} {
+		list := &corev1.NamespaceList{}
+		if err := r.client.List(ctx, list, selector); err != nil {
+			return namespaces, err
+		}
+		for _, ns := range list.Items {
+			namespaces.Insert(ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+// reconcileSink creates or updates the KafkaSink if the given namespace is the firehose's,
+// creating the namespace if necessary, or removes a previously provisioned one otherwise.
+func (r *ReconcileFirehose) reconcileSink(ctx context.Context, name string, ns *corev1.Namespace, firehose *operatorv1alpha1.Firehose, servers []string) error {
+	if firehose == nil || firehose.SinkNamespace() != name {
+		if ns == nil || ns.Labels[sinkLabel] != "true" {
+			return nil
+		}
+		if ns.Labels[provisionedLabel] == "true" {
+			log.Info("Deleting the namespace of the firehose", "Namespace", ns.Name)
+			if err := r.client.Delete(ctx, ns); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete namespace: %w", err)
+			}
+			return nil
+		}
+		if err := r.remove(ctx, makeKafkaSink(name, "", nil)); err != nil {
+			return err
+		}
+		copy := ns.DeepCopy()
+		delete(copy.Labels, sinkLabel)
+		if err := r.client.Update(ctx, copy); err != nil {
+			return fmt.Errorf("failed to update namespace: %w", err)
+		}
+		return nil
+	}
+
+	if ns == nil {
+		log.Info("Creating the namespace of the firehose", "Namespace", name)
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{provisionedLabel: "true", sinkLabel: "true"},
+		}}
+		if err := r.client.Create(ctx, ns); err != nil {
+			return fmt.Errorf("failed to create namespace: %w", err)
+		}
+	} else if ns.Labels[sinkLabel] != "true" {
+		copy := ns.DeepCopy()
+		if copy.Labels == nil {
+			copy.Labels = make(map[string]string, 1)
+		}
+		copy.Labels[sinkLabel] = "true"
+		if err := r.client.Update(ctx, copy); err != nil {
+			return fmt.Errorf("failed to update namespace: %w", err)
+		}
+	}
+
+	desired := makeKafkaSink(name, firehose.Topic, servers)
+	existing := desired.DeepCopy()
+	if err := r.reader.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get KafkaSink: %w", err)
+		}
+		log.Info("Creating the KafkaSink of the firehose", "Namespace", name)
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create KafkaSink: %w", err)
+		}
+		return nil
+	}
+	if existing.GetLabels()[provisionedLabel] != "true" {
+		return fmt.Errorf("KafkaSink %s/%s exists already and is not managed by the operator", name, ResourceName)
+	}
+	if equality.Semantic.DeepEqual(existing.Object["spec"], desired.Object["spec"]) {
+		return nil
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+	log.Info("Updating the KafkaSink of the firehose", "Namespace", name)
+	if err := r.client.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update KafkaSink: %w", err)
+	}
+	return nil
+}
+
+// reconcileSource creates or updates the ApiServerSource of the given namespace if it's
+// labelled, or removes a previously provisioned one otherwise. Its ServiceAccount and RBAC
+// are owned by the source.
+func (r *ReconcileFirehose) reconcileSource(ctx context.Context, ns *corev1.Namespace, firehose *operatorv1alpha1.Firehose) error {
+	if firehose == nil || ns.Labels[FirehoseLabel] != "enabled" {
+		// The ServiceAccount is owned by the source, so it's only there if the source is. It's
+		// looked up first to not query the source of every namespace.
+		sa := &corev1.ServiceAccount{}
+		if err := r.client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: ResourceName}, sa); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get ServiceAccount: %w", err)
+		}
+		if sa.Labels[provisionedLabel] != "true" {
+			return nil
+		}
+		return r.remove(ctx, &sourcesv1.ApiServerSource{ObjectMeta: metav1.ObjectMeta{Namespace: ns.Name, Name: ResourceName}})
+	}
+
+	desired := makeApiServerSource(ns.Name, firehose.SinkNamespace())
+	existing := &sourcesv1.ApiServerSource{}
+	if err := r.reader.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get ApiServerSource: %w", err)
+		}
+		log.Info("Creating the ApiServerSource of the firehose", "Namespace", ns.Name)
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create ApiServerSource: %w", err)
+		}
+		existing = desired
+	} else if existing.Labels[provisionedLabel] != "true" {
+		log.Info("ApiServerSource exists already and is not managed by the operator, skipping", "Namespace", ns.Name)
+		return nil
+	} else if !equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		existing.Spec = desired.Spec
+		log.Info("Updating the ApiServerSource of the firehose", "Namespace", ns.Name)
+		if err := r.client.Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update ApiServerSource: %w", err)
+		}
+	}
+
+	for _, obj := range []client.Object{makeServiceAccount(ns.Name), makeRole(ns.Name), makeRoleBinding(ns.Name)} {
+		if err := r.ensureOwned(ctx, existing, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureOwned creates the given object, controlled by the given source, unless it exists.
+// The RBAC is fixed, so existing objects are not updated.
+func (r *ReconcileFirehose) ensureOwned(ctx context.Context, src *sourcesv1.ApiServerSource, obj client.Object) error {
+	if err := controllerutil.SetControllerReference(src, obj, r.scheme); err != nil {
+		return err
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(obj), existing); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get %T: %w", obj, err)
+	}
+	log.Info(fmt.Sprintf("Creating %T", obj), "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+	if err := r.client.Create(ctx, obj); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create %T: %w", obj, err)
+	}
+	return nil
+}
+
+// remove deletes the given object if it exists and is provisioned by the operator.
+func (r *ReconcileFirehose) remove(ctx context.Context, desired client.Object) error {
+	obj := desired.DeepCopyObject().(client.Object)
+	err := r.reader.Get(ctx, client.ObjectKeyFromObject(desired), obj)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, err)
+	}
+	if obj.GetLabels()[provisionedLabel] != "true" {
+		return nil
+	}
+	log.Info("Deleting firehose resource", "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+	if err := r.client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete firehose resource: %w", err)
+	}
+	return nil
+}
+
+func makeKafkaSink(ns, topic string, servers []string) *unstructured.Unstructured {
+	sink := &unstructured.Unstructured{}
+	sink.SetGroupVersionKind(kafkaSinkGVK)
+	sink.SetNamespace(ns)
+	sink.SetName(ResourceName)
+	sink.SetLabels(map[string]string{provisionedLabel: "true"})
+	bootstrapServers := make([]interface{}, 0, len(servers))
+	for _, server := range servers {
+		bootstrapServers = append(bootstrapServers, server)
+	}
+	sink.Object["spec"] = map[string]interface{}{
+		"topic":            topic,
+		"bootstrapServers": bootstrapServers,
+	}
+	return sink
+}
+
+func makeApiServerSource(ns, sinkNamespace string) *sourcesv1.ApiServerSource {
+	return &sourcesv1.ApiServerSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName,
+			Namespace: ns,
+			Labels:    map[string]string{provisionedLabel: "true"},
+		},
+		Spec: sourcesv1.ApiServerSourceSpec{
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{URI: &apis.URL{
+					Scheme: "http",
+					Host:   sinkIngress,
+					Path:   fmt.Sprintf("/%s/%s", sinkNamespace, ResourceName),
+				}},
+			},
+			Resources:          []sourcesv1.APIVersionKindSelector{{APIVersion: "v1", Kind: "Event"}},
+			EventMode:          sourcesv1.ResourceMode,
+			ServiceAccountName: ResourceName,
+		},
+	}
+}
+
+func makeServiceAccount(ns string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:      ResourceName,
+		Namespace: ns,
+		Labels:    map[string]string{provisionedLabel: "true"},
+	}}
+}
+
+func makeRole(ns string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName,
+			Namespace: ns,
+			Labels:    map[string]string{provisionedLabel: "true"},
+		},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"events"},
+			Verbs:     []string{"get", "list", "watch"},
+		}},
+	}
+}
+
+func makeRoleBinding(ns string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceName,
+			Namespace: ns,
+			Labels:    map[string]string{provisionedLabel: "true"},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     ResourceName,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      ResourceName,
+			Namespace: ns,
+		}},
+	}
+}
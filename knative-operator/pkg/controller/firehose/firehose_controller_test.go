@@ -0,0 +1,160 @@
+package firehose
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func request(ns string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Name: ns}}
+}
+
+func TestFirehoseReconcile(t *testing.T) {
+	ctx := context.Background()
+	kafka := &operatorv1alpha1.KnativeKafka{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+		Spec: operatorv1alpha1.KnativeKafkaSpec{
+			Channel:  operatorv1alpha1.Channel{BootstrapServers: "a:9092, b:9092"},
+			Sink:     operatorv1alpha1.Sink{Enabled: true},
+			Firehose: operatorv1alpha1.Firehose{Enabled: true, Topic: "events"},
+		},
+	}
+	labelled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "test",
+		Labels: map[string]string{FirehoseLabel: "enabled"},
+	}}
+
+	cl := fake.NewClientBuilder().WithObjects(kafka, labelled).Build()
+	r := &ReconcileFirehose{client: cl, reader: cl, scheme: scheme.Scheme}
+
+	// Reconciling twice must not change anything the second time.
+	for i := 0; i < 2; i++ {
+		for _, ns := range []string{operatorv1alpha1.DefaultFirehoseNamespace, "test"} {
+			if _, err := r.Reconcile(ctx, request(ns)); err != nil {
+				t.Fatalf("Reconcile(%s) = %v", ns, err)
+			}
+		}
+	}
+
+	ns := &corev1.Namespace{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: operatorv1alpha1.DefaultFirehoseNamespace}, ns); err != nil {
+		t.Fatal("Failed to get the namespace of the sink:", err)
+	}
+	if ns.Labels[provisionedLabel] != "true" {
+		t.Errorf("Labels = %v, want the namespace to be provisioned", ns.Labels)
+	}
+
+	sink := &unstructured.Unstructured{}
+	sink.SetGroupVersionKind(kafkaSinkGVK)
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: operatorv1alpha1.DefaultFirehoseNamespace, Name: ResourceName}, sink); err != nil {
+		t.Fatal("Failed to get KafkaSink:", err)
+	}
+	if got, _, _ := unstructured.NestedString(sink.Object, "spec", "topic"); got != "events" {
+		t.Errorf("Topic = %q, want %q", got, "events")
+	}
+	if got, _, _ := unstructured.NestedStringSlice(sink.Object, "spec", "bootstrapServers"); len(got) != 2 || got[0] != "a:9092" || got[1] != "b:9092" {
+		t.Errorf("BootstrapServers = %v, want [a:9092 b:9092]", got)
+	}
+
+	key := client.ObjectKey{Namespace: "test", Name: ResourceName}
+	src := &sourcesv1.ApiServerSource{}
+	if err := cl.Get(ctx, key, src); err != nil {
+		t.Fatal("Failed to get ApiServerSource:", err)
+	}
+	if want := "http://" + sinkIngress + "/knative-firehose/knative-firehose"; src.Spec.Sink.URI.String() != want {
+		t.Errorf("Sink = %s, want %s", src.Spec.Sink.URI, want)
+	}
+	if src.Spec.ServiceAccountName != ResourceName {
+		t.Errorf("ServiceAccountName = %q, want %q", src.Spec.ServiceAccountName, ResourceName)
+	}
+	for _, obj := range []client.Object{&corev1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		if err := cl.Get(ctx, key, obj); err != nil {
+			t.Fatalf("Failed to get %T: %v", obj, err)
+		}
+		if !metav1.IsControlledBy(obj, src) {
+			t.Errorf("%T is not owned by the source", obj)
+		}
+	}
+
+	// Removing the label removes the source.
+	labelled.Labels = nil
+	if err := cl.Update(ctx, labelled); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, request("test")); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if err := cl.Get(ctx, key, src); !apierrors.IsNotFound(err) {
+		t.Errorf("Get(ApiServerSource) = %v, want NotFound", err)
+	}
+
+	// Disabling the firehose removes the provisioned namespace of the sink.
+	kafka.Spec.Firehose.Enabled = false
+	if err := cl.Update(ctx, kafka); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, request(operatorv1alpha1.DefaultFirehoseNamespace)); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if err := cl.Get(ctx, client.ObjectKey{Name: operatorv1alpha1.DefaultFirehoseNamespace}, ns); !apierrors.IsNotFound(err) {
+		t.Errorf("Get(Namespace) = %v, want NotFound", err)
+	}
+}
+
+func TestFirehoseKeepsExistingNamespace(t *testing.T) {
+	ctx := context.Background()
+	kafka := &operatorv1alpha1.KnativeKafka{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+		Spec: operatorv1alpha1.KnativeKafkaSpec{
+			Sink:     operatorv1alpha1.Sink{Enabled: true},
+			Firehose: operatorv1alpha1.Firehose{Enabled: true, Namespace: "events", Topic: "events"},
+		},
+	}
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "events"}}
+
+	cl := fake.NewClientBuilder().WithObjects(kafka, existing).Build()
+	r := &ReconcileFirehose{client: cl, reader: cl, scheme: scheme.Scheme}
+	if _, err := r.Reconcile(ctx, request("events")); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+
+	// Disabling the firehose only removes the sink.
+	kafka.Spec.Firehose.Enabled = false
+	if err := cl.Update(ctx, kafka); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, request("events")); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+
+	ns := &corev1.Namespace{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: "events"}, ns); err != nil {
+		t.Fatal("Failed to get namespace:", err)
+	}
+	if _, ok := ns.Labels[sinkLabel]; ok {
+		t.Errorf("Labels = %v, want no sink label", ns.Labels)
+	}
+	sink := &unstructured.Unstructured{}
+	sink.SetGroupVersionKind(kafkaSinkGVK)
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "events", Name: ResourceName}, sink); !apierrors.IsNotFound(err) {
+		t.Errorf("Get(KafkaSink) = %v, want NotFound", err)
+	}
+}
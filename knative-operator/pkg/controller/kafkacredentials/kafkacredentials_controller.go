@@ -0,0 +1,247 @@
+package kafkacredentials
+
+import (
+	"context"
+	"fmt"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ShareCredentialsAnnotation opts a KnativeKafka into delegating its credentials, if set
+	// to "true". The auth Secret of the KnativeKafka is copied as is, so every namespace it's
+	// delegated to shares the credentials the Knative Kafka components authenticate to the
+	// cluster with, commonly admin credentials.
+	ShareCredentialsAnnotation = "kafka.knative.openshift.io/share-admin-credentials"
+
+	// CredentialsLabel is the namespace label that delegates the Kafka credentials of the
+	// KnativeKafka to the namespace, if set to "enabled" and the KnativeKafka shares them.
+	CredentialsLabel = "kafka.knative.openshift.io/credentials"
+
+	// SecretName is the name of the Secret the credentials are copied to in labelled
+	// namespaces, to be referenced by KafkaSources.
+	SecretName = "knative-kafka-credentials"
+
+	// delegatedLabel marks the Secrets created by us.
+	delegatedLabel = "kafka.knative.openshift.io/credentials-delegated"
+
+	// sharedAuthSecretIndex indexes the KnativeKafkas sharing their credentials by the
+	// namespace/name of their auth Secret.
+	sharedAuthSecretIndex = "sharedAuthSecret"
+)
+
+var log = common.Log.WithName("kafkacredentials-controller")
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileKafkaCredentials{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("kafkacredentials-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Namespaces are the primary resource. All changes are of interest, as the removal of the
+	// label has to be noticed too.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Restore the copies if they get changed or deleted.
+	enqueueNamespace := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+	})
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(enqueueNamespace), predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[delegatedLabel] == "true"
+	}))
+	if err != nil {
+		return err
+	}
+
+	// Propagate changes of the credentials, and of which Secret holds them, to all copies.
+	enqueueLabelled := handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		namespaces := &corev1.NamespaceList{}
+		if err := mgr.GetClient().List(context.Background(), namespaces, client.MatchingLabels{CredentialsLabel: "enabled"}); err != nil {
+			log.Error(err, "Failed to list namespaces delegated the Kafka credentials")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(namespaces.Items))
+		for _, ns := range namespaces.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+		}
+		return requests
+	})
+	err = c.Watch(&source.Kind{Type: &operatorv1alpha1.KnativeKafka{}}, enqueueLabelled)
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &operatorv1alpha1.KnativeKafka{}, sharedAuthSecretIndex, func(obj client.Object) []string {
+		if key := sharedAuthSecret(obj.(*operatorv1alpha1.KnativeKafka)); key != nil {
+			return []string{key.String()}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &corev1.Secret{}}, enqueueLabelled, predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		list := &operatorv1alpha1.KnativeKafkaList{}
+		key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		if err := mgr.GetClient().List(context.Background(), list, client.MatchingFields{sharedAuthSecretIndex: key.String()}); err != nil {
+			log.Error(err, "Failed to list KnativeKafkas")
+			return false
+		}
+		return len(list.Items) > 0
+	}))
+}
+
+// sharedAuthSecret returns the key of the auth Secret of the given KnativeKafka, if it shares
+// its credentials.
+func sharedAuthSecret(kk *operatorv1alpha1.KnativeKafka) *types.NamespacedName {
+	channel := kk.Spec.Channel
+	if kk.Annotations[ShareCredentialsAnnotation] != "true" || channel.AuthSecretName == "" || channel.AuthSecretNamespace == "" {
+		return nil
+	}
+	return &types.NamespacedName{Namespace: channel.AuthSecretNamespace, Name: channel.AuthSecretName}
+}
+
+// blank assignment to verify that ReconcileKafkaCredentials implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileKafkaCredentials{}
+
+// ReconcileKafkaCredentials delegates the Kafka credentials of the KnativeKafka to labelled
+// namespaces.
+type ReconcileKafkaCredentials struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile copies the auth Secret of the KnativeKafka into the given namespace if it's
+// labelled to be delegated the credentials and the KnativeKafka shares them, and revokes the copy once the label or the auth
+// Secret are removed. The copy is immutable, so changed credentials are propagated by
+// recreating it.
+func (r *ReconcileKafkaCredentials) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, request.NamespacedName, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if ns.DeletionTimestamp != nil {
+		// The copy is removed along with the namespace.
+		return reconcile.Result{}, nil
+	}
+
+	existing := &corev1.Secret{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: SecretName}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, fmt.Errorf("failed to get delegated credentials: %w", err)
+	}
+	exists := err == nil
+	if exists && existing.Labels[delegatedLabel] != "true" {
+		reqLogger.Info("Secret exists already and is not managed by the operator, skipping")
+		return reconcile.Result{}, nil
+	}
+
+	var credentials *corev1.Secret
+	if ns.Labels[CredentialsLabel] == "enabled" {
+		if credentials, err = r.authSecret(ctx); err != nil {
+			return reconcile.Result{}, err
+		}
+		if credentials != nil && credentials.Namespace == ns.Name {
+			// The namespace holds the credentials already.
+			credentials = nil
+		}
+	}
+
+	if credentials == nil {
+		if !exists {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Revoking delegated credentials")
+		if err := r.client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to revoke delegated credentials: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if exists {
+		if existing.Type == credentials.Type && equality.Semantic.DeepEqual(existing.Data, credentials.Data) {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Deleting outdated delegated credentials")
+		if err := r.client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete outdated delegated credentials: %w", err)
+		}
+	}
+
+	reqLogger.Info("Delegating credentials")
+	immutable := true
+	err = r.client.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName,
+			Namespace: ns.Name,
+			Labels:    map[string]string{delegatedLabel: "true"},
+		},
+		Immutable: &immutable,
+		Type:      credentials.Type,
+		Data:      credentials.Data,
+	})
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to delegate credentials: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// authSecret returns the auth Secret of the KnativeKafka, or nil if there's none or it's not
+// shared.
+func (r *ReconcileKafkaCredentials) authSecret(ctx context.Context) (*corev1.Secret, error) {
+	list := &operatorv1alpha1.KnativeKafkaList{}
+	if err := r.client.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list KnativeKafkas: %w", err)
+	}
+	for i := range list.Items {
+		key := sharedAuthSecret(&list.Items[i])
+		if key == nil {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := r.client.Get(ctx, *key, secret); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get auth secret %s: %w", key, err)
+		}
+		return secret, nil
+	}
+	return nil, nil
+}
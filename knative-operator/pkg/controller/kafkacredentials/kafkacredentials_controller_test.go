@@ -0,0 +1,132 @@
+package kafkacredentials
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestKafkaCredentialsReconcile(t *testing.T) {
+	kk := &operatorv1alpha1.KnativeKafka{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-kafka",
+			Namespace:   "knative-eventing",
+			Annotations: map[string]string{ShareCredentialsAnnotation: "true"},
+		},
+		Spec: operatorv1alpha1.KnativeKafkaSpec{Channel: operatorv1alpha1.Channel{
+			AuthSecretNamespace: "kafka",
+			AuthSecretName:      "kafka-auth",
+		}},
+	}
+	notShared := kk.DeepCopy()
+	notShared.Annotations = nil
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-auth", Namespace: "kafka"},
+		Data:       map[string][]byte{"user": []byte("knative"), "password": []byte("secret")},
+	}
+	outdated := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName,
+			Namespace: "test",
+			Labels:    map[string]string{delegatedLabel: "true"},
+		},
+		Data: map[string][]byte{"user": []byte("knative"), "password": []byte("old")},
+	}
+	unmanaged := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: "test"},
+		Data:       map[string][]byte{"foo": []byte("bar")},
+	}
+	enabled := map[string]string{CredentialsLabel: "enabled"}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		existing []client.Object
+		want     map[string][]byte
+	}{{
+		name:     "not labelled",
+		existing: []client.Object{kk, credentials},
+	}, {
+		name:     "labelled",
+		labels:   enabled,
+		existing: []client.Object{kk, credentials},
+		want:     credentials.Data,
+	}, {
+		name:     "labelled, outdated copy",
+		labels:   enabled,
+		existing: []client.Object{kk, credentials, outdated},
+		want:     credentials.Data,
+	}, {
+		name:     "labelled, no auth secret configured",
+		labels:   enabled,
+		existing: []client.Object{outdated},
+	}, {
+		name:     "labelled, auth secret missing",
+		labels:   enabled,
+		existing: []client.Object{kk, outdated},
+	}, {
+		name:     "labelled, credentials not shared",
+		labels:   enabled,
+		existing: []client.Object{notShared, credentials, outdated},
+	}, {
+		name:     "not labelled, existing copy",
+		existing: []client.Object{kk, credentials, outdated},
+	}, {
+		name:     "labelled, unmanaged secret",
+		labels:   enabled,
+		existing: []client.Object{kk, credentials, unmanaged},
+		want:     unmanaged.Data,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := []client.Object{&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: test.labels},
+			}}
+			for _, obj := range test.existing {
+				objs = append(objs, obj.DeepCopyObject().(client.Object))
+			}
+			cl := fake.NewClientBuilder().WithObjects(objs...).Build()
+			r := &ReconcileKafkaCredentials{client: cl, scheme: scheme.Scheme}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &corev1.Secret{}
+			err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: SecretName}, got)
+			if test.want == nil {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("Secret should not exist, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			if !cmp.Equal(got.Data, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got.Data, test.want, cmp.Diff(got.Data, test.want))
+			}
+			if got.Labels[delegatedLabel] == "true" && (got.Immutable == nil || !*got.Immutable) {
+				t.Error("Delegated credentials are not immutable")
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring"
@@ -127,6 +128,9 @@ func (r *ReconcileKnativeEventing) reconcileKnativeEventing(instance *eventingv1
 		r.configure,
 		r.ensureFinalizers,
 		r.installDashboards,
+		r.reportInstalledManifests,
+		r.reportDeployments,
+		r.trackUpgradeWindow,
 	}
 	for _, stage := range stages {
 		if err := stage(instance); err != nil {
@@ -152,6 +156,31 @@ func (r *ReconcileKnativeEventing) configure(instance *eventingv1alpha1.KnativeE
 	return nil
 }
 
+// reportInstalledManifests annotates the instance with the installed Deployments
+func (r *ReconcileKnativeEventing) reportInstalledManifests(instance *eventingv1alpha1.KnativeEventing) error {
+	installed, err := common.InstalledManifestsInNamespaces(context.TODO(), r.client, instance.Namespace)
+	if err != nil {
+		return err
+	}
+	changed, err := common.SetInstalledManifestsAnnotation(instance, installed)
+	if err != nil || !changed {
+		return err
+	}
+	if err := r.client.Update(context.TODO(), instance); err != nil {
+		return fmt.Errorf("failed to update KnativeEventing with installed manifests: %w", err)
+	}
+	return nil
+}
+
+// reportDeployments reports the rollout progress of the installed Deployments in the status
+func (r *ReconcileKnativeEventing) reportDeployments(instance *eventingv1alpha1.KnativeEventing) error {
+	statuses, err := common.DeploymentStatusesInNamespaces(context.TODO(), r.client, instance.Namespace)
+	if err != nil {
+		return err
+	}
+	return common.SetDeploymentsStatusAnnotation(&instance.Status.Annotations, statuses)
+}
+
 // set a finalizer to clean up the dashboard when instance is deleted
 func (r *ReconcileKnativeEventing) ensureFinalizers(instance *eventingv1alpha1.KnativeEventing) error {
 	for _, finalizer := range instance.GetFinalizers() {
@@ -170,9 +199,16 @@ func (r *ReconcileKnativeEventing) installDashboards(instance *eventingv1alpha1.
 	return dashboards.Apply("eventing", instance, r.client)
 }
 
+// trackUpgradeWindow silences the Serverless alerts while the instance is upgraded
+func (r *ReconcileKnativeEventing) trackUpgradeWindow(instance *eventingv1alpha1.KnativeEventing) error {
+	monitoring.TrackUpgradeWindow("eventing", &instance.Status, &instance.Status.Annotations, time.Now())
+	return nil
+}
+
 // general clean-up, mostly resources in different namespaces from eventingv1alpha1.KnativeEventing.
 func (r *ReconcileKnativeEventing) delete(instance *eventingv1alpha1.KnativeEventing) error {
 	defer monitoring.KnativeUp.DeleteLabelValues("eventing_status")
+	defer monitoring.UpgradeSilenceEnd.DeleteLabelValues("eventing")
 	finalizers := sets.NewString(instance.GetFinalizers()...)
 
 	if !finalizers.Has(finalizerName) {
@@ -0,0 +1,157 @@
+package knativekafka
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	mf "github.com/manifestival/manifestival"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// authSecretHashKey is the pod template annotation of the channel Deployments carrying the
+	// hash of the auth Secret they were last restarted for. It's also the status annotation
+	// recording the hash of the Secret last seen.
+	authSecretHashKey = "operator.serverless.openshift.io/kafka-auth-secret-hash"
+
+	// channelController is part of the manifest, whereas channelDispatcher is created by the
+	// former in the same namespace.
+	channelController = "kafka-ch-controller"
+	channelDispatcher = "kafka-ch-dispatcher"
+)
+
+// rotateAuthSecret restarts the channel Deployments once the auth Secret changes, so that they
+// connect to Kafka with the new credentials. The Deployments roll out as usual: as long as
+// they run few replicas, the default strategy starts a new pod before an old one is stopped,
+// which drains its consumers on shutdown.
+func (r *ReconcileKnativeKafka) rotateAuthSecret(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	channel := instance.Spec.Channel
+	controllers := manifest.Filter(mf.ByKind("Deployment"), mf.ByName(channelController)).Resources()
+	if !channel.Enabled || channel.AuthSecretName == "" || channel.AuthSecretNamespace == "" || len(controllers) == 0 {
+		instance.Status.ClearAuthSecretRotated()
+		delete(instance.Status.Annotations, authSecretHashKey)
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: channel.AuthSecretNamespace, Name: channel.AuthSecretName}
+	if err := r.client.Get(context.TODO(), key, secret); err != nil {
+		if errors.IsNotFound(err) {
+			// The channel reports the missing Secret itself.
+			instance.Status.ClearAuthSecretRotated()
+			return nil
+		}
+		return fmt.Errorf("failed to get auth secret %s: %w", key, err)
+	}
+	hash := secretHash(secret)
+
+	// The Deployments are only restarted for Secrets that changed after they were first seen.
+	seen, ok := instance.Status.Annotations[authSecretHashKey]
+	if !ok {
+		if instance.Status.Annotations == nil {
+			instance.Status.Annotations = make(map[string]string, 1)
+		}
+		instance.Status.Annotations[authSecretHashKey] = hash
+		seen = hash
+	}
+
+	namespace := controllers[0].GetNamespace()
+	rotated := true
+	for _, name := range []string{channelController, channelDispatcher} {
+		deployment := &appsv1.Deployment{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, deployment); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get deployment %s/%s: %w", namespace, name, err)
+		}
+
+		annotations := deployment.Spec.Template.Annotations
+		if seen != hash && annotations[authSecretHashKey] != hash {
+			log.Info("Restarting deployment for the rotated auth secret", "namespace", namespace, "name", name)
+			if annotations == nil {
+				annotations = make(map[string]string, 1)
+			}
+			annotations[authSecretHashKey] = hash
+			deployment.Spec.Template.Annotations = annotations
+			if err := r.client.Update(context.TODO(), deployment); err != nil {
+				return fmt.Errorf("failed to restart deployment %s/%s: %w", namespace, name, err)
+			}
+		}
+		// Only Deployments restarted for the current Secret are waited for.
+		if annotations[authSecretHashKey] == hash && !isRolledOut(deployment) {
+			rotated = false
+		}
+	}
+	instance.Status.Annotations[authSecretHashKey] = hash
+
+	if rotated {
+		instance.Status.MarkAuthSecretRotated()
+	} else {
+		instance.Status.MarkAuthSecretRotating()
+	}
+	return nil
+}
+
+// secretHash hashes the data of the given Secret.
+func secretHash(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write(secret.Data[key])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isRolledOut returns whether the pods of the given Deployment all run its current template.
+func isRolledOut(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+// enqueueForAuthSecret enqueues the KnativeKafkas whose channel authenticates with the
+// changed Secret.
+func enqueueForAuthSecret(cl client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		list := &operatorv1alpha1.KnativeKafkaList{}
+		if err := cl.List(context.Background(), list); err != nil {
+			log.Error(err, "Failed to list KnativeKafkas")
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, kk := range list.Items {
+			channel := kk.Spec.Channel
+			if channel.AuthSecretNamespace != obj.GetNamespace() || channel.AuthSecretName != obj.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: kk.Namespace, Name: kk.Name},
+			})
+		}
+		return requests
+	})
+}
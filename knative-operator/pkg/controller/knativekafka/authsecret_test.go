@@ -0,0 +1,125 @@
+package knativekafka
+
+import (
+	"context"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRotateAuthSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kafka", Name: "auth"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+	controller := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: channelController},
+	}
+	dispatcher := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: channelDispatcher},
+	}
+	instance := makeCr(withChannelEnabled, func(kk *v1alpha1.KnativeKafka) {
+		kk.Spec.Channel.AuthSecretNamespace = secret.Namespace
+		kk.Spec.Channel.AuthSecretName = secret.Name
+	})
+	cl := fake.NewClientBuilder().WithObjects(secret, controller, dispatcher).Build()
+
+	manifest, err := mf.ManifestFrom(mf.Path("testdata/1-channel-consolidated.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load KafkaChannel manifest: %v", err)
+	}
+	r := &ReconcileKnativeKafka{client: cl}
+
+	// The Secret is only recorded when it's first seen.
+	if err := r.rotateAuthSecret(&manifest, instance); err != nil {
+		t.Fatalf("rotateAuthSecret: (%v)", err)
+	}
+	if got := instance.Status.Annotations[authSecretHashKey]; got != secretHash(secret) {
+		t.Errorf("Got hash %q, want %q", got, secretHash(secret))
+	}
+	assertRestartedFor(t, cl, "")
+	if cond := instance.Status.GetCondition(v1alpha1.AuthSecretRotated); cond == nil || !cond.IsTrue() {
+		t.Errorf("AuthSecretRotated = %v, want true", cond)
+	}
+
+	// Rotating the Secret restarts the Deployments.
+	secret.Data["password"] = []byte("new")
+	if err := cl.Update(context.Background(), secret); err != nil {
+		t.Fatalf("update Secret: (%v)", err)
+	}
+	if err := r.rotateAuthSecret(&manifest, instance); err != nil {
+		t.Fatalf("rotateAuthSecret: (%v)", err)
+	}
+	assertRestartedFor(t, cl, secretHash(secret))
+	if cond := instance.Status.GetCondition(v1alpha1.AuthSecretRotated); cond == nil || !cond.IsFalse() {
+		t.Errorf("AuthSecretRotated = %v, want false while rolling out", cond)
+	}
+
+	// The rotation is done once the new pods are available.
+	for _, name := range []string{channelController, channelDispatcher} {
+		d := &appsv1.Deployment{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "knative-eventing", Name: name}, d); err != nil {
+			t.Fatalf("get Deployment %s: (%v)", name, err)
+		}
+		d.Status = appsv1.DeploymentStatus{
+			ObservedGeneration: d.Generation,
+			Replicas:           1,
+			UpdatedReplicas:    1,
+			AvailableReplicas:  1,
+		}
+		if err := cl.Status().Update(context.Background(), d); err != nil {
+			t.Fatalf("update Deployment %s: (%v)", name, err)
+		}
+	}
+	if err := r.rotateAuthSecret(&manifest, instance); err != nil {
+		t.Fatalf("rotateAuthSecret: (%v)", err)
+	}
+	if cond := instance.Status.GetCondition(v1alpha1.AuthSecretRotated); cond == nil || !cond.IsTrue() {
+		t.Errorf("AuthSecretRotated = %v, want true", cond)
+	}
+
+	// Without an auth Secret, nothing is tracked.
+	instance.Spec.Channel.AuthSecretName = ""
+	if err := r.rotateAuthSecret(&manifest, instance); err != nil {
+		t.Fatalf("rotateAuthSecret: (%v)", err)
+	}
+	if cond := instance.Status.GetCondition(v1alpha1.AuthSecretRotated); cond != nil {
+		t.Errorf("AuthSecretRotated = %v, want none", cond)
+	}
+	if _, ok := instance.Status.Annotations[authSecretHashKey]; ok {
+		t.Error("Got a hash without an auth secret")
+	}
+}
+
+func TestSecretHash(t *testing.T) {
+	a := &corev1.Secret{Data: map[string][]byte{"user": []byte("foo"), "password": []byte("bar")}}
+	b := &corev1.Secret{Data: map[string][]byte{"password": []byte("bar"), "user": []byte("foo")}}
+	if secretHash(a) != secretHash(b) {
+		t.Error("Got different hashes for the same data")
+	}
+	// Keys and values must not be ambiguous when concatenated.
+	c := &corev1.Secret{Data: map[string][]byte{"user": []byte("foopassword"), "": []byte("bar")}}
+	if secretHash(a) == secretHash(c) {
+		t.Error("Got the same hash for different data")
+	}
+}
+
+func assertRestartedFor(t *testing.T, cl client.Client, want string) {
+	t.Helper()
+	for _, name := range []string{channelController, channelDispatcher} {
+		d := &appsv1.Deployment{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "knative-eventing", Name: name}, d); err != nil {
+			t.Fatalf("get Deployment %s: (%v)", name, err)
+		}
+		if got := d.Spec.Template.Annotations[authSecretHashKey]; got != want {
+			t.Errorf("Deployment %s restarted for %q, want %q", name, got, want)
+		}
+	}
+}
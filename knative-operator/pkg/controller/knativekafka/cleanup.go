@@ -0,0 +1,261 @@
+package knativekafka
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	drainJobName   = "knative-kafka-drain"
+	cleanupJobName = "knative-kafka-cleanup"
+
+	// cleanupImageEnvVar is the image with the Kafka CLI tools run by the Jobs.
+	cleanupImageEnvVar = "KAFKA_IMAGE_KAFKA_CLI"
+
+	// cleanupPhaseStatusKey reports the progress of the cleanup in the status annotations.
+	cleanupPhaseStatusKey = "operator.serverless.openshift.io/cleanup-phase"
+
+	cleanupPhaseDraining  = "Draining"
+	cleanupPhaseDeleting  = "DeletingConsumerGroups"
+	cleanupPhaseCompleted = "Completed"
+	cleanupPhaseFailed    = "Failed"
+
+	// cleanupPollInterval is how often the Jobs are checked on, as they aren't watched.
+	cleanupPollInterval = 10 * time.Second
+
+	// authMountPath is where the auth Secret of the KafkaChannels is mounted into the Jobs.
+	authMountPath = "/etc/kafka-auth"
+
+	// channelGroupPattern matches the consumer groups of KafkaChannel subscriptions if their
+	// naming isn't configured.
+	channelGroupPattern = `kafka\..+`
+)
+
+// cleanupScript drains or deletes the consumer groups matching $GROUP_PATTERN, depending on
+// its first argument, and deletes the topics matching $TOPIC_PATTERN if $DELETE_TOPICS is set.
+// The connection is configured from the auth Secret of the KafkaChannels, if mounted.
+const cleanupScript = `set -eu
+BIN=/opt/kafka/bin
+CONFIG=/tmp/client.properties
+: > "$CONFIG"
+
+if [ -d "` + authMountPath + `" ]; then
+  auth() { cat "` + authMountPath + `/$1" 2>/dev/null || true; }
+  protocol="$(auth protocol)"
+  if [ -z "$protocol" ]; then
+    if [ "$(auth tls.enabled)" = "true" ]; then protocol=SSL; else protocol=PLAINTEXT; fi
+  fi
+  echo "security.protocol=$protocol" >> "$CONFIG"
+  if [ -s "` + authMountPath + `/ca.crt" ]; then
+    printf 'ssl.truststore.type=PEM\nssl.truststore.location=%s\n' "` + authMountPath + `/ca.crt" >> "$CONFIG"
+  fi
+  if [ -s "` + authMountPath + `/user.crt" ] && [ -s "` + authMountPath + `/user.key" ]; then
+    cat "` + authMountPath + `/user.key" "` + authMountPath + `/user.crt" > /tmp/keystore.pem
+    printf 'ssl.keystore.type=PEM\nssl.keystore.location=/tmp/keystore.pem\n' >> "$CONFIG"
+  fi
+  case "$protocol" in SASL_*)
+    mechanism="$(auth sasl.mechanism)"
+    mechanism="${mechanism:-PLAIN}"
+    module=org.apache.kafka.common.security.scram.ScramLoginModule
+    if [ "$mechanism" = "PLAIN" ]; then module=org.apache.kafka.common.security.plain.PlainLoginModule; fi
+    echo "sasl.mechanism=$mechanism" >> "$CONFIG"
+    echo "sasl.jaas.config=$module required username=\"$(auth user)\" password=\"$(auth password)\";" >> "$CONFIG"
+  esac
+fi
+
+groups() {
+  "$BIN/kafka-consumer-groups.sh" --bootstrap-server "$BOOTSTRAP_SERVERS" --command-config "$CONFIG" --list | grep -E "^(${GROUP_PATTERN})\$" || true
+}
+
+case "$1" in
+drain)
+  deadline=$(( $(date +%s) + DRAIN_TIMEOUT_SECONDS ))
+  while :; do
+    lag=0
+    for group in $(groups); do
+      group_lag=$("$BIN/kafka-consumer-groups.sh" --bootstrap-server "$BOOTSTRAP_SERVERS" --command-config "$CONFIG" --describe --group "$group" |
+        awk -v g="$group" '$1 == g && $6 ~ /^[0-9]+$/ { s += $6 } END { print s + 0 }')
+      lag=$(( lag + group_lag ))
+    done
+    echo "Remaining lag: $lag"
+    if [ "$lag" -eq 0 ]; then exit 0; fi
+    if [ "$(date +%s)" -ge "$deadline" ]; then
+      echo "Lag didn't drain within ${DRAIN_TIMEOUT_SECONDS}s, continuing"
+      exit 0
+    fi
+    sleep 10
+  done
+  ;;
+cleanup)
+  for group in $(groups); do
+    # Groups can only be deleted once their members, the stopped dispatchers, have left.
+    until "$BIN/kafka-consumer-groups.sh" --bootstrap-server "$BOOTSTRAP_SERVERS" --command-config "$CONFIG" --delete --group "$group"; do
+      sleep 10
+    done
+  done
+  if [ "$DELETE_TOPICS" = "true" ]; then
+    "$BIN/kafka-topics.sh" --bootstrap-server "$BOOTSTRAP_SERVERS" --command-config "$CONFIG" --list | grep -E "^(${TOPIC_PATTERN})\$" |
+      while read -r topic; do
+        "$BIN/kafka-topics.sh" --bootstrap-server "$BOOTSTRAP_SERVERS" --command-config "$CONFIG" --delete --topic "$topic"
+      done
+  fi
+  ;;
+esac
+`
+
+// cleanupEnabled tells whether the Kafka cluster of the KafkaChannels is cleaned up on deletion.
+func cleanupEnabled(instance *operatorv1alpha1.KnativeKafka) bool {
+	return instance.Spec.Cleanup.Enabled && instance.Spec.Channel.Enabled && instance.Spec.Channel.BootstrapServers != ""
+}
+
+// cleanupGroupPattern returns the pattern of the consumer groups to drain and delete. Only the
+// groups of KafkaChannels are matched, unless a prefix tells the groups of this installation
+// apart. If only a template is configured, no group can be identified safely.
+func cleanupGroupPattern(groups operatorv1alpha1.ConsumerGroups) string {
+	if groups.Prefix != "" {
+		return regexp.QuoteMeta(groups.Prefix) + ".+"
+	}
+	if !groups.IsSet() {
+		return channelGroupPattern
+	}
+	return ""
+}
+
+// cleanupJobNamespace is where the Jobs run. Secrets can only be mounted from the Job's
+// namespace, so that's the one of the auth Secret, if any.
+func cleanupJobNamespace(instance *operatorv1alpha1.KnativeKafka) string {
+	if channel := instance.Spec.Channel; channel.AuthSecretName != "" && channel.AuthSecretNamespace != "" {
+		return channel.AuthSecretNamespace
+	}
+	return instance.Namespace
+}
+
+// makeCleanupJob creates the Job running the given mode of the cleanup script.
+func makeCleanupJob(instance *operatorv1alpha1.KnativeKafka, name, mode string) *batchv1.Job {
+	cleanup := instance.Spec.Cleanup
+	drainSeconds := int64(cleanup.DrainTimeout().Seconds())
+
+	container := corev1.Container{
+		Name:    "kafka-cli",
+		Image:   os.Getenv(cleanupImageEnvVar),
+		Command: []string{"/bin/bash", "-c", cleanupScript, name, mode},
+		Env: []corev1.EnvVar{
+			{Name: "BOOTSTRAP_SERVERS", Value: instance.Spec.Channel.BootstrapServers},
+			{Name: "GROUP_PATTERN", Value: cleanupGroupPattern(instance.Spec.ConsumerGroups)},
+			{Name: "TOPIC_PATTERN", Value: channelTopicPattern},
+			{Name: "DELETE_TOPICS", Value: strconv.FormatBool(cleanup.DeleteTopics)},
+			{Name: "DRAIN_TIMEOUT_SECONDS", Value: strconv.FormatInt(drainSeconds, 10)},
+		},
+	}
+	spec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers:    []corev1.Container{container},
+	}
+	if secret := instance.Spec.Channel.AuthSecretName; secret != "" {
+		spec.Volumes = []corev1.Volume{{
+			Name: "kafka-auth",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: secret},
+			},
+		}}
+		spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{
+			Name:      "kafka-auth",
+			MountPath: authMountPath,
+			ReadOnly:  true,
+		}}
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cleanupJobNamespace(instance),
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      name,
+				"app.kubernetes.io/component": "knative-kafka-cleanup",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.Int32(2),
+			// Deleting consumer groups retries until the dispatchers are gone, which must not
+			// block the deletion forever.
+			ActiveDeadlineSeconds: ptr.Int64(drainSeconds + 600),
+			Template:              corev1.PodTemplateSpec{Spec: spec},
+		},
+	}
+}
+
+// runCleanupJob creates the given Job unless it exists and tells whether it has finished.
+// A failed Job counts as finished, so a broken Kafka cluster can't block the deletion.
+func (r *ReconcileKnativeKafka) runCleanupJob(instance *operatorv1alpha1.KnativeKafka, name, mode string) (bool, error) {
+	desired := makeCleanupJob(instance, name, mode)
+	job := &batchv1.Job{}
+	err := r.client.Get(context.TODO(), client.ObjectKeyFromObject(desired), job)
+	if errors.IsNotFound(err) {
+		log.Info("Creating cleanup Job", "name", name)
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return false, fmt.Errorf("failed to create Job %s: %w", name, err)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get Job %s: %w", name, err)
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			log.Info("Cleanup Job failed, continuing with the deletion", "name", name, "reason", c.Reason, "message", c.Message)
+			setCleanupPhase(instance, cleanupPhaseFailed)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteCleanupJobs removes the Jobs along with their pods once the cleanup is done.
+func (r *ReconcileKnativeKafka) deleteCleanupJobs(instance *operatorv1alpha1.KnativeKafka) error {
+	for _, name := range []string{drainJobName, cleanupJobName} {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cleanupJobNamespace(instance)}}
+		if err := r.client.Delete(context.TODO(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Job %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// requeueCleanup reports the progress of the cleanup and checks on it again later.
+func (r *ReconcileKnativeKafka) requeueCleanup(instance *operatorv1alpha1.KnativeKafka, err error) (reconcile.Result, error) {
+	if updateErr := r.client.Status().Update(context.TODO(), instance); updateErr != nil && err == nil {
+		err = fmt.Errorf("failed to update status: %w", updateErr)
+	}
+	return reconcile.Result{RequeueAfter: cleanupPollInterval}, err
+}
+
+// setCleanupPhase reports the progress of the cleanup. A failure is kept once reported.
+func setCleanupPhase(instance *operatorv1alpha1.KnativeKafka, phase string) {
+	if instance.Status.Annotations == nil {
+		instance.Status.Annotations = map[string]string{}
+	}
+	if instance.Status.Annotations[cleanupPhaseStatusKey] == cleanupPhaseFailed {
+		return
+	}
+	instance.Status.Annotations[cleanupPhaseStatusKey] = phase
+}
@@ -0,0 +1,128 @@
+package knativekafka
+
+import (
+	"context"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCleanupOnDeletion(t *testing.T) {
+	instance := makeCr(withChannelEnabled, withDeleted, func(kk *v1alpha1.KnativeKafka) {
+		kk.Finalizers = []string{finalizerName}
+		kk.Spec.Cleanup = v1alpha1.Cleanup{Enabled: true, DeleteTopics: true}
+	})
+	cl := fake.NewClientBuilder().WithObjects(instance, &operatorv1alpha1.KnativeEventing{}).Build()
+
+	kafkaChannelManifest, err := mf.ManifestFrom(mf.Path("testdata/1-channel-consolidated.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load KafkaChannel manifest: %v", err)
+	}
+	kafkaSourceManifest, err := mf.ManifestFrom(mf.Path("testdata/2-source.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load KafkaSource manifest: %v", err)
+	}
+	r := &ReconcileKnativeKafka{
+		client:                  cl,
+		scheme:                  scheme.Scheme,
+		rawKafkaChannelManifest: kafkaChannelManifest,
+		rawKafkaSourceManifest:  kafkaSourceManifest,
+	}
+
+	// Each Job is created and waited for, before the next step is taken.
+	for _, step := range []struct {
+		job   string
+		phase string
+	}{{
+		job:   drainJobName,
+		phase: cleanupPhaseDraining,
+	}, {
+		job:   cleanupJobName,
+		phase: cleanupPhaseDeleting,
+	}} {
+		for i := 0; i < 2; i++ {
+			result, err := r.Reconcile(context.Background(), defaultRequest)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			if result.RequeueAfter == 0 {
+				t.Fatalf("Reconcile didn't requeue while waiting for Job %s", step.job)
+			}
+		}
+		assertCleanupPhase(t, cl, step.phase)
+
+		job := &batchv1.Job{}
+		if err := cl.Get(context.Background(), types.NamespacedName{Namespace: "knative-eventing", Name: step.job}, job); err != nil {
+			t.Fatalf("get Job %s: (%v)", step.job, err)
+		}
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		if err := cl.Status().Update(context.Background(), job); err != nil {
+			t.Fatalf("update Job %s: (%v)", step.job, err)
+		}
+	}
+
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	assertCleanupPhase(t, cl, cleanupPhaseCompleted)
+
+	got := &v1alpha1.KnativeKafka{}
+	if err := cl.Get(context.Background(), defaultRequest.NamespacedName, got); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if len(got.Finalizers) != 0 {
+		t.Errorf("Finalizers = %v, want none", got.Finalizers)
+	}
+	for _, name := range []string{drainJobName, cleanupJobName} {
+		err := cl.Get(context.Background(), types.NamespacedName{Namespace: "knative-eventing", Name: name}, &batchv1.Job{})
+		if !errors.IsNotFound(err) {
+			t.Errorf("Job %s wasn't deleted: (%v)", name, err)
+		}
+	}
+}
+
+func TestCleanupGroupPattern(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups v1alpha1.ConsumerGroups
+		want   string
+	}{{
+		name: "default naming",
+		want: channelGroupPattern,
+	}, {
+		name:   "prefix",
+		groups: v1alpha1.ConsumerGroups{Prefix: "tenant-a."},
+		want:   `tenant-a\..+`,
+	}, {
+		name:   "template only",
+		groups: v1alpha1.ConsumerGroups{Template: "{{ .UID }}"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cleanupGroupPattern(test.groups); got != test.want {
+				t.Errorf("cleanupGroupPattern() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func assertCleanupPhase(t *testing.T, cl client.Client, want string) {
+	t.Helper()
+	got := &v1alpha1.KnativeKafka{}
+	if err := cl.Get(context.Background(), defaultRequest.NamespacedName, got); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if phase := got.Status.Annotations[cleanupPhaseStatusKey]; phase != want {
+		t.Errorf("Cleanup phase = %q, want %q", phase, want)
+	}
+}
@@ -0,0 +1,96 @@
+package knativekafka
+
+import (
+	"fmt"
+	"regexp"
+
+	mf "github.com/manifestival/manifestival"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	lagAlertsRuleName = "knative-kafka-consumer-lag-rules"
+
+	// channelTopicPattern matches the topics of KafkaChannels, which are named
+	// "knative-messaging-kafka.<namespace>.<channel>".
+	channelTopicPattern = `knative-messaging-kafka\.([^.]+)\.(.+)`
+)
+
+// setConsumers sets the Sarama config of the consumers in config-kafka, if configured
+func setConsumers(consumers operatorv1alpha1.Consumers) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		config := consumers.SaramaConfig()
+		if config == "" || u.GetKind() != "ConfigMap" || u.GetName() != "config-kafka" {
+			return nil
+		}
+		log.Info("Found ConfigMap config-kafka, updating it with consumers from spec")
+		return unstructured.SetNestedField(u.Object, config, "data", "sarama")
+	}
+}
+
+// lagAlerts returns the PrometheusRule alerting on the lag of the consumers of KafkaChannels,
+// labeled with the namespace and name of the channel, and of KafkaSources, labeled with
+// their consumer group and topic. KafkaSources are only covered if their consumer groups
+// carry a prefix, to tell them apart from other consumers of the Kafka cluster.
+func lagAlerts(instance *operatorv1alpha1.KnativeKafka) (*unstructured.Unstructured, error) {
+	threshold := *instance.Spec.Consumers.LagAlertThreshold
+	const lagFor = "10m"
+
+	var rules []monitoringv1.Rule
+	if instance.Spec.Channel.Enabled {
+		rules = append(rules, monitoringv1.Rule{
+			Alert: "KnativeKafkaChannelConsumerLagHigh",
+			Expr: intstr.FromString(fmt.Sprintf(`label_replace(label_replace(
+  sum by (consumergroup, topic) (kafka_consumergroup_lag{topic=~%[1]q}),
+  "namespace", "$1", "topic", %[1]q),
+  "channel", "$2", "topic", %[1]q
+) > %[2]d`, channelTopicPattern, threshold)),
+			For:    lagFor,
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "Subscribers of a KafkaChannel are falling behind",
+				"description": "The consumer group {{ $labels.consumergroup }} of KafkaChannel {{ $labels.namespace }}/{{ $labels.channel }} " +
+					"lags {{ $value }} messages behind, check whether its dispatcher is stuck or its subscriber is failing.",
+			},
+		})
+	}
+	if prefix := instance.Spec.ConsumerGroups.Prefix; instance.Spec.Source.Enabled && prefix != "" {
+		rules = append(rules, monitoringv1.Rule{
+			Alert: "KnativeKafkaSourceConsumerLagHigh",
+			Expr: intstr.FromString(fmt.Sprintf(
+				`sum by (consumergroup, topic) (kafka_consumergroup_lag{consumergroup=~%q, topic!~%q}) > %d`,
+				regexp.QuoteMeta(prefix)+".+", channelTopicPattern, threshold)),
+			For:    lagFor,
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "A KafkaSource is falling behind",
+				"description": "The consumer group {{ $labels.consumergroup }} lags {{ $value }} messages behind on topic {{ $labels.topic }}, " +
+					"check whether its receive adapter is stuck or its sink is failing.",
+			},
+		})
+	}
+
+	rule := &monitoringv1.PrometheusRule{
+		TypeMeta: metav1.TypeMeta{APIVersion: monitoringv1.SchemeGroupVersion.String(), Kind: monitoringv1.PrometheusRuleKind},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      lagAlertsRuleName,
+			Namespace: instance.Namespace,
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{{
+				Name:  "knative-kafka-consumer-lag.rules",
+				Rules: rules,
+			}},
+		},
+	}
+	u := &unstructured.Unstructured{}
+	if err := scheme.Scheme.Convert(rule, u, nil); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,98 @@
+package knativekafka
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestSetConsumers(t *testing.T) {
+	configKafka := func() *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetName("config-kafka")
+		return u
+	}
+
+	obj := configKafka()
+	if err := setConsumers(v1alpha1.Consumers{})(obj); err != nil {
+		t.Fatal("setConsumers:", err)
+	}
+	if _, ok := obj.Object["data"]; ok {
+		t.Errorf("Set data without configured consumers: %v", obj.Object["data"])
+	}
+
+	consumers := v1alpha1.Consumers{InitialOffset: v1alpha1.InitialOffsetLatest}
+	if err := setConsumers(consumers)(obj); err != nil {
+		t.Fatal("setConsumers:", err)
+	}
+	got, _, _ := unstructured.NestedString(obj.Object, "data", "sarama")
+	if got != consumers.SaramaConfig() {
+		t.Errorf("sarama = %q, want %q", got, consumers.SaramaConfig())
+	}
+}
+
+func TestLagAlerts(t *testing.T) {
+	threshold := int64(500)
+	instance := func(channel, source bool, prefix string) *v1alpha1.KnativeKafka {
+		return &v1alpha1.KnativeKafka{
+			ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+			Spec: v1alpha1.KnativeKafkaSpec{
+				Channel:        v1alpha1.Channel{Enabled: channel},
+				Source:         v1alpha1.Source{Enabled: source},
+				ConsumerGroups: v1alpha1.ConsumerGroups{Prefix: prefix},
+				Consumers:      v1alpha1.Consumers{LagAlertThreshold: &threshold},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		instance *v1alpha1.KnativeKafka
+		want     []string
+	}{{
+		name:     "channel",
+		instance: instance(true, false, ""),
+		want:     []string{"KnativeKafkaChannelConsumerLagHigh"},
+	}, {
+		name:     "source without prefix",
+		instance: instance(false, true, ""),
+	}, {
+		name:     "channel and source",
+		instance: instance(true, true, "tenant-a."),
+		want:     []string{"KnativeKafkaChannelConsumerLagHigh", "KnativeKafkaSourceConsumerLagHigh"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, err := lagAlerts(test.instance)
+			if err != nil {
+				t.Fatal("lagAlerts:", err)
+			}
+			rule := &monitoringv1.PrometheusRule{}
+			if err := scheme.Scheme.Convert(u, rule, nil); err != nil {
+				t.Fatal("Failed to convert PrometheusRule:", err)
+			}
+			if rule.Namespace != "knative-eventing" || rule.Name != lagAlertsRuleName {
+				t.Errorf("Got PrometheusRule %s/%s, want knative-eventing/%s", rule.Namespace, rule.Name, lagAlertsRuleName)
+			}
+
+			var got []string
+			for _, r := range rule.Spec.Groups[0].Rules {
+				got = append(got, r.Alert)
+				if !strings.HasSuffix(r.Expr.String(), "> 500") {
+					t.Errorf("Alert %s doesn't use the threshold: %s", r.Alert, r.Expr.String())
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("Got alerts %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package knativekafka
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// egressPolicySuffix is appended to the name of a Deployment to name its NetworkPolicy.
+	egressPolicySuffix = "-kafka-egress"
+
+	// defaultKafkaPort is the port of bootstrap servers given without one.
+	defaultKafkaPort = 9092
+
+	// namespaceNameLabel is set on all namespaces by Kubernetes.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// dnsPorts are the ports of the cluster DNS. The pods of OpenShift's DNS listen on 5353,
+// behind port 53 of their Service.
+var dnsPorts = []int{53, 5353}
+
+// egressPolicies returns a NetworkPolicy for each Deployment of the given resources, allowing
+// its pods to reach the bootstrap servers of the given KnativeKafka and DNS.
+func egressPolicies(instance *operatorv1alpha1.KnativeKafka, resources []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	rules := egressRules(instance.Spec.Channel.BootstrapServers)
+	var policies []unstructured.Unstructured
+	for _, u := range resources {
+		if u.GetKind() != "Deployment" {
+			continue
+		}
+		selector, _, err := unstructured.NestedStringMap(u.Object, "spec", "selector", "matchLabels")
+		if err != nil {
+			return nil, err
+		}
+		policy := &networkingv1.NetworkPolicy{
+			TypeMeta: metav1.TypeMeta{APIVersion: networkingv1.SchemeGroupVersion.String(), Kind: "NetworkPolicy"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      u.GetName() + egressPolicySuffix,
+				Namespace: u.GetNamespace(),
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: selector},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress:      rules,
+			},
+		}
+		out := unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(policy, &out, nil); err != nil {
+			return nil, err
+		}
+		// The zero-value timestamp defaulted by the conversion causes
+		// superfluous updates
+		out.SetCreationTimestamp(metav1.Time{})
+		policies = append(policies, out)
+	}
+	return policies, nil
+}
+
+// egressRules returns the rules allowing egress to DNS and the given comma-separated
+// bootstrap servers. Servers are matched by the namespace of in-cluster Services, by IP
+// address or, for other hostnames that can't be resolved reliably, by port only.
+func egressRules(bootstrapServers string) []networkingv1.NetworkPolicyEgressRule {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dns := networkingv1.NetworkPolicyEgressRule{}
+	for _, port := range dnsPorts {
+		p := intstr.FromInt(port)
+		dns.Ports = append(dns.Ports,
+			networkingv1.NetworkPolicyPort{Protocol: &udp, Port: &p},
+			networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &p})
+	}
+	rules := []networkingv1.NetworkPolicyEgressRule{dns}
+
+	for _, server := range strings.Split(bootstrapServers, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		host, port := server, defaultKafkaPort
+		if h, p, err := net.SplitHostPort(server); err == nil {
+			if n, err := strconv.Atoi(p); err == nil {
+				host, port = h, n
+			}
+		}
+		p := intstr.FromInt(port)
+		rule := networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &p}},
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			rule.To = []networkingv1.NetworkPolicyPeer{{
+				IPBlock: &networkingv1.IPBlock{CIDR: (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String()},
+			}}
+		} else if ns := serviceNamespace(host); ns != "" {
+			rule.To = []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: ns}},
+			}}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// serviceNamespace returns the namespace of the given host if it's the address of a Service,
+// e.g. "my-cluster-kafka-bootstrap.kafka" or "my-cluster-kafka-bootstrap.kafka.svc". Hosts of
+// two labels are always taken as "<service>.<namespace>". The addresses of pods behind a
+// headless Service, as advertised by Strimzi brokers, are covered as well.
+func serviceNamespace(host string) string {
+	parts := strings.Split(strings.TrimSuffix(host, "."), ".")
+	switch {
+	case len(parts) == 2:
+		return parts[1]
+	case len(parts) >= 3 && parts[2] == "svc":
+		return parts[1]
+	case len(parts) >= 4 && parts[3] == "svc":
+		return parts[2]
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package knativekafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEgressRules(t *testing.T) {
+	tcp := corev1.ProtocolTCP
+	port := func(p int) []networkingv1.NetworkPolicyPort {
+		i := intstr.FromInt(p)
+		return []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &i}}
+	}
+	namespace := func(ns string) []networkingv1.NetworkPolicyPeer {
+		return []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: ns}},
+		}}
+	}
+
+	rules := egressRules("my-cluster-kafka-bootstrap.kafka:9094, broker-0.brokers.strimzi.svc.cluster.local:9092," +
+		"10.0.0.1:9093,[fd00::1]:9093,kafka.example.com")
+	want := []networkingv1.NetworkPolicyEgressRule{{
+		Ports: port(9094),
+		To:    namespace("kafka"),
+	}, {
+		Ports: port(9092),
+		To:    namespace("strimzi"),
+	}, {
+		Ports: port(9093),
+		To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.1/32"}}},
+	}, {
+		Ports: port(9093),
+		To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "fd00::1/128"}}},
+	}, {
+		// Only the port of external hosts is known.
+		Ports: port(defaultKafkaPort),
+	}}
+
+	if len(rules) != len(want)+1 {
+		t.Fatalf("len(rules) = %d, want %d", len(rules), len(want)+1)
+	}
+	if got := len(rules[0].Ports); got != 2*len(dnsPorts) || rules[0].To != nil {
+		t.Errorf("DNS rule = %v, want the DNS ports to any destination", rules[0])
+	}
+	if !cmp.Equal(rules[1:], want) {
+		t.Errorf("Got = %v, want: %v, diff(-want,+got):\n%s", rules[1:], want, cmp.Diff(want, rules[1:]))
+	}
+}
+
+func TestEgressPolicyReconcile(t *testing.T) {
+	instance := makeCr(withChannelEnabled, func(kk *v1alpha1.KnativeKafka) {
+		kk.Spec.EgressPolicy.Enabled = true
+	})
+	cl := fake.NewClientBuilder().WithObjects(instance, &operatorv1alpha1.KnativeEventing{}).Build()
+
+	kafkaChannelManifest, err := mf.ManifestFrom(mf.Path("testdata/1-channel-consolidated.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load KafkaChannel manifest: %v", err)
+	}
+	kafkaSourceManifest, err := mf.ManifestFrom(mf.Path("testdata/2-source.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load KafkaSource manifest: %v", err)
+	}
+	r := &ReconcileKnativeKafka{
+		client:                  cl,
+		scheme:                  scheme.Scheme,
+		rawKafkaChannelManifest: kafkaChannelManifest,
+		rawKafkaSourceManifest:  kafkaSourceManifest,
+	}
+
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	channel := types.NamespacedName{Namespace: "knative-eventing", Name: "kafka-ch-controller" + egressPolicySuffix}
+	policy := &networkingv1.NetworkPolicy{}
+	if err := cl.Get(context.Background(), channel, policy); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	if len(policy.Spec.PodSelector.MatchLabels) == 0 {
+		t.Error("PodSelector is empty, want the selector of the Deployment")
+	}
+	source := types.NamespacedName{Namespace: "knative-eventing", Name: "kafka-controller-manager" + egressPolicySuffix}
+	if err := cl.Get(context.Background(), source, policy); !errors.IsNotFound(err) {
+		t.Errorf("NetworkPolicy of the disabled source = %v, want NotFound", err)
+	}
+
+	// Disabling the policies removes them.
+	if err := cl.Get(context.Background(), defaultRequest.NamespacedName, instance); err != nil {
+		t.Fatalf("get: (%v)", err)
+	}
+	instance.Spec.EgressPolicy.Enabled = false
+	if err := cl.Update(context.Background(), instance); err != nil {
+		t.Fatalf("update: (%v)", err)
+	}
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if err := cl.Get(context.Background(), channel, policy); !errors.IsNotFound(err) {
+		t.Errorf("NetworkPolicy = %v, want NotFound", err)
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	mfc "github.com/manifestival/controller-runtime-client"
 	mf "github.com/manifestival/manifestival"
 	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring"
+	"github.com/openshift-knative/serverless-operator/pkg/drift"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -22,7 +25,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
-	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -32,17 +34,24 @@ const (
 	// DO NOT change to something else in the future!
 	// This needs to remain "knative-kafka-openshift" to be compatible with earlier versions in the future versions.
 	finalizerName = "knative-kafka-openshift"
+
+	// metricsComponent identifies KnativeKafka in the manifest metrics.
+	metricsComponent = "knative-kafka"
 )
 
 var (
-	log               = logf.Log.WithName("controller_knativekafka")
+	log               = common.KafkaLog.WithName("controller_knativekafka")
 	role              = mf.Any(mf.ByKind("ClusterRole"), mf.ByKind("Role"))
 	rolebinding       = mf.Any(mf.ByKind("ClusterRoleBinding"), mf.ByKind("RoleBinding"))
 	roleOrRoleBinding = mf.Any(role, rolebinding)
-	KafkaHAComponents = []string{"kafka-ch-controller", "kafka-controller-manager"}
+	KafkaHAComponents = []string{"kafka-ch-controller", "kafka-controller-manager", "kafka-controller"}
 )
 
-type stage func(*mf.Manifest, *operatorv1alpha1.KnativeKafka) error
+// stage is a named step of the manifest pipeline. The name is used to report its duration.
+type stage struct {
+	name string
+	run  func(*mf.Manifest, *operatorv1alpha1.KnativeKafka) error
+}
 
 // Add creates a new KnativeKafka Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
@@ -66,11 +75,23 @@ func newReconciler(mgr manager.Manager) (*ReconcileKnativeKafka, error) {
 		return nil, fmt.Errorf("failed to load KafkaSource manifest: %w", err)
 	}
 
+	kafkaSinkManifest, err := mf.ManifestFrom(mf.Path(os.Getenv("KAFKASINK_MANIFEST_PATH")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load KafkaSink manifest: %w", err)
+	}
+
+	driftPolicy, err := drift.DriftPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	reconcileKnativeKafka := ReconcileKnativeKafka{
 		client:                  mgr.GetClient(),
 		scheme:                  mgr.GetScheme(),
 		rawKafkaChannelManifest: kafkaChannelManifest,
 		rawKafkaSourceManifest:  kafkaSourceManifest,
+		rawKafkaSinkManifest:    kafkaSinkManifest,
+		driftPolicy:             driftPolicy,
 	}
 	return &reconcileKnativeKafka, nil
 }
@@ -89,7 +110,7 @@ func add(mgr manager.Manager, r *ReconcileKnativeKafka) error {
 		return err
 	}
 
-	gvkToResource := common.BuildGVKToResourceMap(r.rawKafkaChannelManifest, r.rawKafkaSourceManifest)
+	gvkToResource := common.BuildGVKToResourceMap(r.rawKafkaChannelManifest, r.rawKafkaSourceManifest, r.rawKafkaSinkManifest)
 
 	for _, t := range gvkToResource {
 		err = c.Watch(&source.Kind{Type: t}, common.EnqueueRequestByOwnerAnnotations(common.KafkaOwnerName, common.KafkaOwnerNamespace))
@@ -98,6 +119,12 @@ func add(mgr manager.Manager, r *ReconcileKnativeKafka) error {
 		}
 	}
 
+	// Watch the auth Secret of the channel to restart its Deployments once it's rotated.
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, enqueueForAuthSecret(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -112,6 +139,9 @@ type ReconcileKnativeKafka struct {
 	scheme                  *runtime.Scheme
 	rawKafkaChannelManifest mf.Manifest
 	rawKafkaSourceManifest  mf.Manifest
+	rawKafkaSinkManifest    mf.Manifest
+	// driftPolicy is one of drift.DriftPolicyRevert and drift.DriftPolicyReport.
+	driftPolicy string
 }
 
 // Reconcile reads that state of the cluster for a KnativeKafka object and makes changes based on the state read
@@ -136,7 +166,7 @@ func (r *ReconcileKnativeKafka) Reconcile(ctx context.Context, request reconcile
 
 	// check for deletion
 	if original.GetDeletionTimestamp() != nil {
-		return reconcile.Result{}, r.delete(original)
+		return r.delete(original)
 	}
 
 	instance := original.DeepCopy()
@@ -175,11 +205,13 @@ func (r *ReconcileKnativeKafka) executeInstallStages(instance *operatorv1alpha1.
 	}
 
 	stages := []stage{
-		r.configure,
-		r.ensureFinalizers,
-		r.transform,
-		r.apply,
-		r.checkDeployments,
+		{"configure", r.configure},
+		{"ensureFinalizers", r.ensureFinalizers},
+		{"transform", r.transform},
+		{"apply", r.apply},
+		{"checkDeployments", r.checkDeployments},
+		{"rotateAuthSecret", r.rotateAuthSecret},
+		{"checkSink", r.checkSink},
 	}
 
 	return executeStages(instance, manifest, stages)
@@ -192,8 +224,8 @@ func (r *ReconcileKnativeKafka) executeDeleteStages(instance *operatorv1alpha1.K
 	}
 
 	stages := []stage{
-		r.transform,
-		r.deleteResources,
+		{"transform", r.transform},
+		{"deleteResources", r.deleteResources},
 	}
 
 	return executeStages(instance, manifest, stages)
@@ -242,12 +274,16 @@ func (r *ReconcileKnativeKafka) transform(manifest *mf.Manifest, instance *opera
 		setKafkaDeployments(instance.Spec.HighAvailability.Replicas),
 		setBootstrapServers(instance.Spec.Channel.BootstrapServers),
 		setAuthSecret(instance.Spec.Channel.AuthSecretNamespace, instance.Spec.Channel.AuthSecretName),
+		setConsumerGroups(instance.Spec.ConsumerGroups),
+		setConsumers(instance.Spec.Consumers),
 		ImageTransform(common.BuildImageOverrideMapFromEnviron(os.Environ(), "KAFKA_IMAGE_"), log),
 		replicasTransform(manifest.Client),
+		topologySpreadTransform(instance.Spec.TopologySpread),
 		configMapHashTransform(manifest.Client),
 		rbacProxyTranform,
 	)
 	if err != nil {
+		monitoring.ManifestTransformErrors.WithLabelValues(metricsComponent).Inc()
 		return fmt.Errorf("failed to transform manifest: %w", err)
 	}
 	*manifest = m
@@ -257,21 +293,34 @@ func (r *ReconcileKnativeKafka) transform(manifest *mf.Manifest, instance *opera
 // Install Knative Kafka components
 func (r *ReconcileKnativeKafka) apply(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	log.Info("Installing manifest")
+	// Count the resources that have to be updated as they differ from the manifest, or only
+	// report them if manual edits are to be kept.
+	var drifted []string
+	counted := *manifest
+	if r.driftPolicy == drift.DriftPolicyReport {
+		counted.Client = monitoring.ReportDrift(metricsComponent, manifest.Client, func(u *unstructured.Unstructured) {
+			log.Info("Keeping drifted resource", "kind", u.GetKind(), "namespace", u.GetNamespace(), "name", u.GetName())
+			drifted = append(drifted, fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName()))
+		})
+	} else {
+		counted.Client = monitoring.CountDrift(metricsComponent, manifest.Client)
+	}
 	// The Operator needs a higher level of permissions if it 'bind's non-existent roles.
 	// To avoid this, we strictly order the manifest application as (Cluster)Roles, then
 	// (Cluster)RoleBindings, then the rest of the manifest.
-	if err := manifest.Filter(role).Apply(); err != nil {
+	if err := counted.Filter(role).Apply(); err != nil {
 		instance.Status.MarkInstallFailed(err.Error())
 		return fmt.Errorf("failed to apply (cluster)roles in manifest: %w", err)
 	}
-	if err := manifest.Filter(rolebinding).Apply(); err != nil {
+	if err := counted.Filter(rolebinding).Apply(); err != nil {
 		instance.Status.MarkInstallFailed(err.Error())
 		return fmt.Errorf("failed to apply (cluster)rolebindings in manifest: %w", err)
 	}
-	if err := manifest.Filter(not(roleOrRoleBinding)).Apply(); err != nil {
+	if err := counted.Filter(not(roleOrRoleBinding)).Apply(); err != nil {
 		instance.Status.MarkInstallFailed(err.Error())
 		return fmt.Errorf("failed to apply non rbac manifest: %w", err)
 	}
+	instance.Status.DriftedResources = drifted
 	instance.Status.MarkInstallSucceeded()
 	instance.Status.Version = os.Getenv("KNATIVE_EVENTING_KAFKA_VERSION")
 	return nil
@@ -279,6 +328,9 @@ func (r *ReconcileKnativeKafka) apply(manifest *mf.Manifest, instance *operatorv
 
 func (r *ReconcileKnativeKafka) checkDeployments(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	log.Info("Checking deployments")
+	available := true
+	var installed []operatorv1alpha1.InstalledManifest
+	var statuses []operatorv1alpha1.DeploymentStatus
 	for _, u := range manifest.Filter(mf.ByKind("Deployment")).Resources() {
 		u := u // To avoid memory aliasing
 		resource, err := manifest.Client.Get(&u)
@@ -293,15 +345,61 @@ func (r *ReconcileKnativeKafka) checkDeployments(manifest *mf.Manifest, instance
 		if err := scheme.Scheme.Convert(resource, deployment, nil); err != nil {
 			return err
 		}
+		installedManifest, err := common.InstalledManifestFor(context.TODO(), r.client, deployment)
+		if err != nil {
+			return err
+		}
+		installed = append(installed, installedManifest)
+		statuses = append(statuses, common.DeploymentStatusFor(deployment))
 		if !isDeploymentAvailable(deployment) {
-			instance.Status.MarkDeploymentsNotReady()
-			return nil
+			available = false
 		}
 	}
+	instance.Status.InstalledManifests = installed
+	instance.Status.Deployments = statuses
+	if !available {
+		instance.Status.MarkDeploymentsNotReady()
+		return nil
+	}
 	instance.Status.MarkDeploymentsAvailable()
 	return nil
 }
 
+// checkSink reports the state of the KafkaSink component, whose deployments are part of the
+// manifest if it's enabled.
+func (r *ReconcileKnativeKafka) checkSink(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
+	if !instance.Spec.Sink.Enabled {
+		instance.Status.MarkKafkaSinkDisabled()
+		return nil
+	}
+	if len(r.rawKafkaSinkManifest.Resources()) == 0 {
+		instance.Status.MarkKafkaSinkNotAvailable()
+		instance.Status.MarkInstallFailed("KafkaSink is enabled but not available in this release")
+		return nil
+	}
+
+	sink := r.rawKafkaSinkManifest.Filter(mf.ByKind("Deployment")).Resources()
+	for i := range sink {
+		u, err := manifest.Client.Get(&sink[i])
+		if errors.IsNotFound(err) {
+			instance.Status.MarkKafkaSinkNotReady()
+			return nil
+		} else if err != nil {
+			return err
+		}
+		deployment := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(u, deployment, nil); err != nil {
+			return err
+		}
+		if !isDeploymentAvailable(deployment) {
+			instance.Status.MarkKafkaSinkNotReady()
+			return nil
+		}
+	}
+	instance.Status.MarkKafkaSinkReady()
+	return nil
+}
+
 // Delete Knative Kafka resources
 func (r *ReconcileKnativeKafka) deleteResources(manifest *mf.Manifest, instance *operatorv1alpha1.KnativeKafka) error {
 	if len(manifest.Resources()) <= 0 {
@@ -328,25 +426,49 @@ func isDeploymentAvailable(d *appsv1.Deployment) bool {
 }
 
 // general clean-up. required for the resources that cannot be garbage collected with the owner reference mechanism
-func (r *ReconcileKnativeKafka) delete(instance *operatorv1alpha1.KnativeKafka) error {
+//
+// If enabled, the dispatchers are drained before the resources are deleted, and the consumer
+// groups and topics of the KafkaChannels are deleted afterwards, each by a Job.
+func (r *ReconcileKnativeKafka) delete(instance *operatorv1alpha1.KnativeKafka) (reconcile.Result, error) {
 	defer monitoring.KnativeUp.DeleteLabelValues("kafka_status")
 	finalizers := sets.NewString(instance.GetFinalizers()...)
 
 	if !finalizers.Has(finalizerName) {
 		log.Info("Finalizer has already been removed, nothing to do")
-		return nil
+		return reconcile.Result{}, nil
 	}
 
 	log.Info("Running cleanup logic")
+	if cleanupEnabled(instance) {
+		setCleanupPhase(instance, cleanupPhaseDraining)
+		if drained, err := r.runCleanupJob(instance, drainJobName, "drain"); err != nil || !drained {
+			return r.requeueCleanup(instance, err)
+		}
+	}
+
 	log.Info("Deleting KnativeKafka")
 	if err := r.deleteKnativeKafka(instance); err != nil {
-		return fmt.Errorf("failed to delete KnativeKafka: %w", err)
+		return reconcile.Result{}, fmt.Errorf("failed to delete KnativeKafka: %w", err)
+	}
+
+	if cleanupEnabled(instance) {
+		setCleanupPhase(instance, cleanupPhaseDeleting)
+		if done, err := r.runCleanupJob(instance, cleanupJobName, "cleanup"); err != nil || !done {
+			return r.requeueCleanup(instance, err)
+		}
+		setCleanupPhase(instance, cleanupPhaseCompleted)
+		if err := r.client.Status().Update(context.TODO(), instance); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to update status: %w", err)
+		}
+		if err := r.deleteCleanupJobs(instance); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
 
 	// The above might take a while, so we refetch the resource again in case it has changed.
 	refetched := &operatorv1alpha1.KnativeKafka{}
 	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, refetched); err != nil {
-		return fmt.Errorf("failed to refetch KnativeKafka: %w", err)
+		return reconcile.Result{}, fmt.Errorf("failed to refetch KnativeKafka: %w", err)
 	}
 
 	// Update the refetched finalizer list.
@@ -355,9 +477,9 @@ func (r *ReconcileKnativeKafka) delete(instance *operatorv1alpha1.KnativeKafka)
 	refetched.SetFinalizers(finalizers.List())
 
 	if err := r.client.Update(context.TODO(), refetched); err != nil {
-		return fmt.Errorf("failed to update KnativeKafka with removed finalizer: %w", err)
+		return reconcile.Result{}, fmt.Errorf("failed to update KnativeKafka with removed finalizer: %w", err)
 	}
-	return nil
+	return reconcile.Result{}, nil
 }
 
 func (r *ReconcileKnativeKafka) deleteKnativeKafka(instance *operatorv1alpha1.KnativeKafka) error {
@@ -367,8 +489,8 @@ func (r *ReconcileKnativeKafka) deleteKnativeKafka(instance *operatorv1alpha1.Kn
 	}
 
 	stages := []stage{
-		r.transform,
-		r.deleteResources,
+		{"transform", r.transform},
+		{"deleteResources", r.deleteResources},
 	}
 
 	return executeStages(instance, manifest, stages)
@@ -403,6 +525,52 @@ func (r *ReconcileKnativeKafka) buildManifest(instance *operatorv1alpha1.Knative
 		resources = append(resources, r.rawKafkaSourceManifest.Resources()...)
 	}
 
+	if build == manifestBuildAll || (build == manifestBuildEnabledOnly && instance.Spec.Sink.Enabled) || (build == manifestBuildDisabledOnly && !instance.Spec.Sink.Enabled) {
+		// Without the KafkaSink shipped, there are no deployments to scrape metrics from either.
+		if len(r.rawKafkaSinkManifest.Resources()) > 0 {
+			sinkRBACProxy, err := monitoring.AddRBACProxySupportToManifest(instance, monitoring.KafkaSinkComponents)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, sinkRBACProxy.Resources()...)
+			resources = append(resources, r.rawKafkaSinkManifest.Resources()...)
+		}
+	}
+
+	alerting := instance.Spec.Consumers.LagAlertThreshold != nil && (instance.Spec.Channel.Enabled || instance.Spec.Source.Enabled)
+	if build == manifestBuildEnabledOnly && alerting {
+		rule, err := lagAlerts(instance)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *rule)
+	} else if build == manifestBuildAll || (build == manifestBuildDisabledOnly && !alerting) {
+		rule := unstructured.Unstructured{}
+		rule.SetGroupVersionKind(monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.PrometheusRuleKind))
+		rule.SetNamespace(instance.Namespace)
+		rule.SetName(lagAlertsRuleName)
+		resources = append(resources, rule)
+	}
+
+	// The NetworkPolicies of all Deployments are removed once they're disabled, and those of
+	// the disabled components along with them.
+	egress := instance.Spec.EgressPolicy.Enabled
+	var deployments []unstructured.Unstructured
+	if build == manifestBuildAll || (build == manifestBuildDisabledOnly && !egress) {
+		deployments = append(deployments, r.rawKafkaChannelManifest.Resources()...)
+		deployments = append(deployments, r.rawKafkaSourceManifest.Resources()...)
+		deployments = append(deployments, r.rawKafkaSinkManifest.Resources()...)
+	} else {
+		deployments = resources
+	}
+	if build != manifestBuildEnabledOnly || egress {
+		policies, err := egressPolicies(instance, deployments)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, policies...)
+	}
+
 	manifest, err := mf.ManifestFrom(
 		mf.Slice(resources),
 		mf.UseClient(mfc.NewClient(r.client)),
@@ -442,6 +610,24 @@ func setAuthSecret(secretNamespace, secretName string) mf.Transformer {
 	}
 }
 
+// setConsumerGroups sets the consumer group naming policy in config-kafka, if configured
+func setConsumerGroups(groups operatorv1alpha1.ConsumerGroups) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if !groups.IsSet() || u.GetKind() != "ConfigMap" || u.GetName() != "config-kafka" {
+			return nil
+		}
+		log.Info("Found ConfigMap config-kafka, updating it with consumerGroups from spec")
+		template := groups.Template
+		if template == "" {
+			template = operatorv1alpha1.DefaultConsumerGroupTemplate
+		}
+		if err := unstructured.SetNestedField(u.Object, groups.Prefix, "data", "consumerGroupPrefix"); err != nil {
+			return err
+		}
+		return unstructured.SetNestedField(u.Object, template, "data", "consumerGroupTemplate")
+	}
+}
+
 func checkHAComponent(name string) bool {
 	for _, component := range KafkaHAComponents {
 		if name == component {
@@ -477,7 +663,10 @@ func setKafkaDeployments(replicas int32) mf.Transformer {
 func executeStages(instance *operatorv1alpha1.KnativeKafka, manifest *mf.Manifest, stages []stage) error {
 	// Execute each stage in sequence until one returns an error
 	for _, stage := range stages {
-		if err := stage(manifest, instance); err != nil {
+		start := time.Now()
+		err := stage.run(manifest, instance)
+		monitoring.ObserveManifestStage(metricsComponent, stage.name, start, err)
+		if err != nil {
 			return err
 		}
 	}
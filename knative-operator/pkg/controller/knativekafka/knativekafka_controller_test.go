@@ -74,6 +74,28 @@ func TestKnativeKafkaReconcile(t *testing.T) {
 			{Name: "kafka-ch-controller", Namespace: "knative-eventing"},
 			{Name: "kafka-controller-manager", Namespace: "knative-eventing"},
 		},
+	}, {
+		name:     "Create CR with sink enabled",
+		instance: makeCr(withSinkEnabled),
+		exists: []types.NamespacedName{
+			{Name: "kafka-controller", Namespace: "knative-eventing"},
+			{Name: "kafka-webhook-eventing", Namespace: "knative-eventing"},
+		},
+		doesNotExist: []types.NamespacedName{
+			{Name: "kafka-ch-controller", Namespace: "knative-eventing"},
+			{Name: "kafka-controller-manager", Namespace: "knative-eventing"},
+		},
+	}, {
+		name:     "Create CR with sink disabled",
+		instance: makeCr(withSourceEnabled),
+		exists: []types.NamespacedName{
+			{Name: "kafka-controller-manager", Namespace: "knative-eventing"},
+		},
+		doesNotExist: []types.NamespacedName{
+			{Name: "kafka-controller", Namespace: "knative-eventing"},
+			{Name: "kafka-webhook-eventing", Namespace: "knative-eventing"},
+			{Name: "kafka-sink-receiver", Namespace: "knative-eventing"},
+		},
 	}, {
 		name:     "Delete CR",
 		instance: makeCr(withChannelEnabled, withSourceEnabled, withDeleted),
@@ -98,11 +120,17 @@ func TestKnativeKafkaReconcile(t *testing.T) {
 				t.Fatalf("failed to load KafkaSource manifest: %v", err)
 			}
 
+			kafkaSinkManifest, err := mf.ManifestFrom(mf.Path("testdata/3-sink.yaml"))
+			if err != nil {
+				t.Fatalf("failed to load KafkaSink manifest: %v", err)
+			}
+
 			r := &ReconcileKnativeKafka{
 				client:                  cl,
 				scheme:                  scheme.Scheme,
 				rawKafkaChannelManifest: kafkaChannelManifest,
 				rawKafkaSourceManifest:  kafkaSourceManifest,
+				rawKafkaSinkManifest:    kafkaSinkManifest,
 			}
 
 			// Reconcile to initialize
@@ -455,11 +483,130 @@ func withChannelEnabled(kk *v1alpha1.KnativeKafka) {
 	kk.Spec.Channel.Enabled = true
 }
 
+func withSinkEnabled(kk *v1alpha1.KnativeKafka) {
+	kk.Spec.Sink.Enabled = true
+}
+
 func withDeleted(kk *v1alpha1.KnativeKafka) {
 	t := metav1.NewTime(time.Now())
 	kk.ObjectMeta.DeletionTimestamp = &t
 }
 
+func TestSetConsumerGroups(t *testing.T) {
+	configKafka := func(data map[string]interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name": "config-kafka",
+				},
+			},
+		}
+		if data != nil {
+			u.Object["data"] = data
+		}
+		return u
+	}
+
+	tests := []struct {
+		name   string
+		groups v1alpha1.ConsumerGroups
+		expect *unstructured.Unstructured
+	}{{
+		name:   "Not configured",
+		expect: configKafka(nil),
+	}, {
+		name:   "Prefix only",
+		groups: v1alpha1.ConsumerGroups{Prefix: "tenant-a."},
+		expect: configKafka(map[string]interface{}{
+			"consumerGroupPrefix":   "tenant-a.",
+			"consumerGroupTemplate": v1alpha1.DefaultConsumerGroupTemplate,
+		}),
+	}, {
+		name:   "Prefix and template",
+		groups: v1alpha1.ConsumerGroups{Prefix: "tenant-a.", Template: "{{ .Namespace }}-{{ .UID }}"},
+		expect: configKafka(map[string]interface{}{
+			"consumerGroupPrefix":   "tenant-a.",
+			"consumerGroupTemplate": "{{ .Namespace }}-{{ .UID }}",
+		}),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := configKafka(nil)
+			if err := setConsumerGroups(test.groups)(obj); err != nil {
+				t.Fatalf("setConsumerGroups: (%v)", err)
+			}
+
+			if !cmp.Equal(test.expect, obj) {
+				t.Fatalf("Resource wasn't what we expected, diff: %s", cmp.Diff(obj, test.expect))
+			}
+		})
+	}
+}
+
+func TestKafkaSinkStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		instance     *v1alpha1.KnativeKafka
+		manifestPath string
+		wantStatus   corev1.ConditionStatus
+		wantReason   string
+	}{{
+		name:     "disabled",
+		instance: makeCr(withSourceEnabled),
+	}, {
+		name:         "deployments not available",
+		instance:     makeCr(withSinkEnabled),
+		manifestPath: "testdata/3-sink.yaml",
+		wantStatus:   corev1.ConditionFalse,
+		wantReason:   "NotReady",
+	}, {
+		name:       "not shipped",
+		instance:   makeCr(withSinkEnabled),
+		wantStatus: corev1.ConditionFalse,
+		wantReason: "NotAvailable",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithObjects(test.instance, &operatorv1alpha1.KnativeEventing{}).Build()
+
+			r := &ReconcileKnativeKafka{
+				client: cl,
+				scheme: scheme.Scheme,
+			}
+			if test.manifestPath != "" {
+				kafkaSinkManifest, err := mf.ManifestFrom(mf.Path(test.manifestPath))
+				if err != nil {
+					t.Fatalf("failed to load KafkaSink manifest: %v", err)
+				}
+				r.rawKafkaSinkManifest = kafkaSinkManifest
+			}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &v1alpha1.KnativeKafka{}
+			if err := cl.Get(context.TODO(), defaultRequest.NamespacedName, got); err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			cond := got.Status.GetCondition(v1alpha1.KafkaSinkReady)
+			if test.wantStatus == "" {
+				if cond != nil {
+					t.Fatalf("Got condition %v, want none", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != test.wantStatus || cond.Reason != test.wantReason {
+				t.Fatalf("Got condition %v, want status %s with reason %s", cond, test.wantStatus, test.wantReason)
+			}
+		})
+	}
+}
+
 func TestCheckHAComponent(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -477,6 +624,14 @@ func TestCheckHAComponent(t *testing.T) {
 		name:           "kafka source controller",
 		deploymentName: "kafka-controller-manager",
 		shouldFail:     false,
+	}, {
+		name:           "kafka sink controller",
+		deploymentName: "kafka-controller",
+		shouldFail:     false,
+	}, {
+		name:           "kafka sink receiver",
+		deploymentName: "kafka-sink-receiver",
+		shouldFail:     true,
 	}, {
 		name:           "kafka channel dispatcher",
 		deploymentName: "kafka-ch-dispatcher",
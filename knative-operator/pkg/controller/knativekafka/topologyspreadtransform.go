@@ -0,0 +1,84 @@
+package knativekafka
+
+import (
+	mf "github.com/manifestival/manifestival"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const defaultTopologyKey = "topology.kubernetes.io/zone"
+
+// KafkaDataPlaneComponents are the Deployments handling the actual event traffic. All
+// StatefulSets are considered data plane components too.
+var KafkaDataPlaneComponents = []string{"kafka-ch-dispatcher", "kafka-broker-receiver", "kafka-broker-dispatcher", "kafka-sink-receiver"}
+
+// topologySpreadTransform spreads the replicas of the data plane components across the
+// topology domains configured in the given spec.
+func topologySpreadTransform(spread operatorv1alpha1.TopologySpread) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if !spread.Enabled {
+			return nil
+		}
+
+		var obj runtime.Object
+		var template *corev1.PodTemplateSpec
+		switch {
+		case u.GetKind() == "StatefulSet":
+			ss := &appsv1.StatefulSet{}
+			obj, template = ss, &ss.Spec.Template
+		case u.GetKind() == "Deployment" && isDataPlaneComponent(u.GetName()):
+			d := &appsv1.Deployment{}
+			obj, template = d, &d.Spec.Template
+		default:
+			return nil
+		}
+
+		if err := scheme.Scheme.Convert(u, obj, nil); err != nil {
+			return err
+		}
+		log.Info("Setting topology spread constraints", "kind", u.GetKind(), "name", u.GetName())
+		template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+			makeTopologySpreadConstraint(spread, template.Labels),
+		}
+		if err := scheme.Scheme.Convert(obj, u, nil); err != nil {
+			return err
+		}
+		// The zero-value timestamp defaulted by the conversion causes
+		// superfluous updates
+		u.SetCreationTimestamp(metav1.Time{})
+		return nil
+	}
+}
+
+func makeTopologySpreadConstraint(spread operatorv1alpha1.TopologySpread, labels map[string]string) corev1.TopologySpreadConstraint {
+	constraint := corev1.TopologySpreadConstraint{
+		MaxSkew:           spread.MaxSkew,
+		TopologyKey:       spread.TopologyKey,
+		WhenUnsatisfiable: corev1.UnsatisfiableConstraintAction(spread.WhenUnsatisfiable),
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+	}
+	if constraint.MaxSkew == 0 {
+		constraint.MaxSkew = 1
+	}
+	if constraint.TopologyKey == "" {
+		constraint.TopologyKey = defaultTopologyKey
+	}
+	if constraint.WhenUnsatisfiable == "" {
+		constraint.WhenUnsatisfiable = corev1.ScheduleAnyway
+	}
+	return constraint
+}
+
+func isDataPlaneComponent(name string) bool {
+	for _, component := range KafkaDataPlaneComponents {
+		if name == component {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package knativekafka
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestTopologySpreadTransform(t *testing.T) {
+	labels := map[string]string{"app": "kafka-ch-dispatcher"}
+
+	tests := []struct {
+		name   string
+		kind   string
+		object string
+		spread operatorv1alpha1.TopologySpread
+		want   []corev1.TopologySpreadConstraint
+	}{{
+		name:   "disabled",
+		kind:   "StatefulSet",
+		object: "kafka-dispatcher",
+	}, {
+		name:   "statefulset with defaults",
+		kind:   "StatefulSet",
+		object: "kafka-dispatcher",
+		spread: operatorv1alpha1.TopologySpread{Enabled: true},
+		want: []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+		}},
+	}, {
+		name:   "data plane deployment",
+		kind:   "Deployment",
+		object: "kafka-ch-dispatcher",
+		spread: operatorv1alpha1.TopologySpread{
+			Enabled:           true,
+			MaxSkew:           2,
+			TopologyKey:       "kubernetes.io/hostname",
+			WhenUnsatisfiable: string(corev1.DoNotSchedule),
+		},
+		want: []corev1.TopologySpreadConstraint{{
+			MaxSkew:           2,
+			TopologyKey:       "kubernetes.io/hostname",
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+		}},
+	}, {
+		name:   "control plane deployment",
+		kind:   "Deployment",
+		object: "kafka-ch-controller",
+		spread: operatorv1alpha1.TopologySpread{Enabled: true},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template := corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+			var obj interface{}
+			if test.kind == "StatefulSet" {
+				obj = &appsv1.StatefulSet{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+					ObjectMeta: metav1.ObjectMeta{Name: test.object},
+					Spec:       appsv1.StatefulSetSpec{Template: template},
+				}
+			} else {
+				obj = &appsv1.Deployment{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+					ObjectMeta: metav1.ObjectMeta{Name: test.object},
+					Spec:       appsv1.DeploymentSpec{Template: template},
+				}
+			}
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(obj, u, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := topologySpreadTransform(test.spread)(u); err != nil {
+				t.Fatalf("topologySpreadTransform: (%v)", err)
+			}
+
+			// The pod template lives at the same path for StatefulSets and Deployments.
+			applied := &appsv1.Deployment{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, applied); err != nil {
+				t.Fatal(err)
+			}
+			constraints := applied.Spec.Template.Spec.TopologySpreadConstraints
+			if !cmp.Equal(constraints, test.want) {
+				t.Errorf("Got unexpected constraints, diff: %s", cmp.Diff(constraints, test.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package knativeserving
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// caBundleVersionsStatusKey is the status annotation recording the resource versions of
+	// the CA bundles of the KnativeServing. Its changes trigger a reconcile by the Knative
+	// operator, which restarts the deployments depending on the bundles once they rotate.
+	caBundleVersionsStatusKey = "serving.knative.openshift.io/ca-bundle-versions"
+
+	// tagResolutionCAPrefix is prepended to the keys of the tag resolution's CA bundle in the
+	// custom certs ConfigMap, so they don't collide with the keys of the other bundles.
+	tagResolutionCAPrefix = "tag-resolution-"
+)
+
+// trackCABundles records the resource versions of the CA bundles of the given instance on its
+// status. Missing bundles are skipped.
+func (r *ReconcileKnativeServing) trackCABundles(instance *servingv1alpha1.KnativeServing) error {
+	var versions []string
+	for _, name := range okoserving.CABundleNames(instance) {
+		cm := &corev1.ConfigMap{}
+		err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: instance.Namespace, Name: name}, cm)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get CA bundle %s: %w", name, err)
+		}
+		versions = append(versions, name+"="+cm.ResourceVersion)
+	}
+
+	if len(versions) == 0 {
+		delete(instance.Status.Annotations, caBundleVersionsStatusKey)
+		return nil
+	}
+	if instance.Status.Annotations == nil {
+		instance.Status.Annotations = make(map[string]string, 1)
+	}
+	instance.Status.Annotations[caBundleVersionsStatusKey] = strings.Join(versions, ",")
+	return nil
+}
+
+// tagResolutionCABundle returns the CA bundle to trust when resolving tags, with its keys
+// prefixed. A missing bundle is skipped, which only fails the resolution of tags from the
+// registries it's meant for.
+func (r *ReconcileKnativeServing) tagResolutionCABundle(instance *servingv1alpha1.KnativeServing) (map[string]string, error) {
+	tr, err := okoserving.TagResolutionFromAnnotation(instance)
+	if err != nil || tr == nil || tr.CABundle == "" {
+		// An invalid annotation is rejected by the webhook already.
+		return nil, nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = r.client.Get(context.TODO(), client.ObjectKey{Namespace: instance.Namespace, Name: tr.CABundle}, cm)
+	if errors.IsNotFound(err) {
+		log.Info("CA bundle for tag resolution not found", "name", tr.CABundle)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get CA bundle %s: %w", tr.CABundle, err)
+	}
+
+	bundle := make(map[string]string, len(cm.Data))
+	for key, value := range cm.Data {
+		bundle[tagResolutionCAPrefix+key] = value
+	}
+	return bundle, nil
+}
+
+// caBundlePredicate selects the ConfigMaps that might be CA bundles of a KnativeServing.
+func caBundlePredicate(requiredNs string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		if requiredNs != "" && obj.GetNamespace() != requiredNs {
+			return false
+		}
+		return obj.GetName() == okoserving.KubeRootCAName || strings.HasSuffix(obj.GetName(), "-service-ca")
+	})
+}
+
+// enqueueForCABundle enqueues all KnativeServings in the namespace of a changed ConfigMap
+// that depend on it as a CA bundle.
+func enqueueForCABundle(cl client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		list := &servingv1alpha1.KnativeServingList{}
+		if err := cl.List(context.Background(), list, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.Error(err, "Failed to list KnativeServings")
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range list.Items {
+			ks := &list.Items[i]
+			if sets.NewString(okoserving.CABundleNames(ks)...).Has(obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name},
+				})
+			}
+		}
+		return requests
+	})
+}
+
+// enqueueForTagResolutionCA enqueues all KnativeServings in the namespace of a changed
+// ConfigMap that refer to it as the CA bundle of the tag resolution.
+func enqueueForTagResolutionCA(cl client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		list := &servingv1alpha1.KnativeServingList{}
+		if err := cl.List(context.Background(), list, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.Error(err, "Failed to list KnativeServings")
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range list.Items {
+			ks := &list.Items[i]
+			if tr, _ := okoserving.TagResolutionFromAnnotation(ks); tr != nil && tr.CABundle == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name},
+				})
+			}
+		}
+		return requests
+	})
+}
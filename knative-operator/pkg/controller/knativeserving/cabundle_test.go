@@ -0,0 +1,71 @@
+package knativeserving
+
+import (
+	"testing"
+
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestTrackCABundles(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "knative-serving",
+			Namespace: "knative-serving",
+		},
+		Spec: v1alpha1.KnativeServingSpec{
+			ControllerCustomCerts: v1alpha1.CustomCerts{
+				Name: "test-cm",
+				Type: "ConfigMap",
+			},
+		},
+	}
+	rootCA := cm(okoserving.KubeRootCAName, nil, nil, map[string]string{"ca.crt": "root"}, "5")
+	serviceCA := cm("test-cm-service-ca", nil, nil, map[string]string{"service-ca.crt": "service"}, "7")
+
+	cl := fake.NewClientBuilder().WithObjects(rootCA, serviceCA).Build()
+	r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+
+	if err := r.trackCABundles(ks); err != nil {
+		t.Fatal(err)
+	}
+	want := "kube-root-ca.crt=5,test-cm-service-ca=7"
+	if got := ks.Status.Annotations[caBundleVersionsStatusKey]; got != want {
+		t.Errorf("Tracked versions = %q, want %q", got, want)
+	}
+}
+
+func TestCABundlePredicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		want      bool
+	}{{
+		name:      okoserving.KubeRootCAName,
+		namespace: "knative-serving",
+		want:      true,
+	}, {
+		name:      "config-service-ca",
+		namespace: "knative-serving",
+		want:      true,
+	}, {
+		name:      "config-trusted-ca",
+		namespace: "knative-serving",
+	}, {
+		name:      okoserving.KubeRootCAName,
+		namespace: "default",
+	}}
+
+	prct := caBundlePredicate("knative-serving")
+	for _, test := range tests {
+		obj := cm(test.name, nil, nil, nil, "")
+		obj.Namespace = test.namespace
+		if got := prct.Create(event.CreateEvent{Object: obj}); got != test.want {
+			t.Errorf("Selected %s/%s = %v, want %v", test.namespace, test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package knativeserving
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	routev1 "github.com/openshift/api/route/v1"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// deprecationsStatusKey is the status annotation listing the deprecated settings still in
+	// use as JSON, so they can be migrated before an upgrade removes them.
+	deprecationsStatusKey = "operator.serverless.openshift.io/deprecations"
+
+	// httpOptionAnnotationKey is copied from the Ingress onto its Routes. The ingress controller
+	// stops honoring it once Serving sets the Ingress' spec.httpOption itself.
+	httpOptionAnnotationKey = "networking.knative.dev/httpOption"
+	// ingressNameLabelKey is set by the ingress controller on the Routes it creates.
+	ingressNameLabelKey = "serving.knative.openshift.io/ingressName"
+)
+
+// deprecation describes a deprecated setting that is still in use.
+type deprecation struct {
+	// Setting is the deprecated field, config key or annotation.
+	Setting string `json:"setting"`
+	// Message explains what to do instead.
+	Message string `json:"message"`
+}
+
+// reportDeprecations lists the deprecated settings used by the instance or the Routes
+// of its Ingresses in the status of the instance.
+func (r *ReconcileKnativeServing) reportDeprecations(instance *servingv1alpha1.KnativeServing) error {
+	var deprecations []deprecation
+	if len(instance.Spec.DeprecatedKnativeIngressGateway.Selector) > 0 {
+		deprecations = append(deprecations, deprecation{
+			Setting: "spec.knative-ingress-gateway",
+			Message: "The field is going to be removed and has no effect with Kourier, remove it.",
+		})
+	}
+	if len(instance.Spec.DeprecatedClusterLocalGateway.Selector) > 0 {
+		deprecations = append(deprecations, deprecation{
+			Setting: "spec.cluster-local-gateway",
+			Message: "The field is going to be removed and has no effect with Kourier, remove it.",
+		})
+	}
+
+	list := &routev1.RouteList{}
+	// Kourier is installed into the "<namespace>-ingress" namespace, where the Routes live too.
+	err := r.client.List(context.TODO(), list, client.InNamespace(instance.Namespace+"-ingress"),
+		client.HasLabels{ingressNameLabelKey})
+	if err != nil {
+		return fmt.Errorf("failed to list routes: %w", err)
+	}
+	routes := 0
+	for _, route := range list.Items {
+		if _, ok := route.Annotations[httpOptionAnnotationKey]; ok {
+			routes++
+		}
+	}
+	if routes > 0 {
+		deprecations = append(deprecations, deprecation{
+			Setting: httpOptionAnnotationKey,
+			Message: fmt.Sprintf("%d Route(s) take their HTTP option from this annotation on their Ingress, "+
+				"which is going to be ignored once Serving sets the Ingress' spec.httpOption itself.", routes),
+		})
+	}
+
+	delete(instance.Status.Annotations, deprecationsStatusKey)
+	if len(deprecations) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(deprecations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deprecations: %w", err)
+	}
+	if instance.Status.Annotations == nil {
+		instance.Status.Annotations = make(map[string]string, 1)
+	}
+	instance.Status.Annotations[deprecationsStatusKey] = string(raw)
+	return nil
+}
@@ -0,0 +1,91 @@
+package knativeserving
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReportDeprecations(t *testing.T) {
+	route := func(name, namespace string, annotations map[string]string) *routev1.Route {
+		return &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{ingressNameLabelKey: name},
+				Annotations: annotations,
+			},
+		}
+	}
+	httpOption := map[string]string{httpOptionAnnotationKey: "redirected"}
+
+	tests := []struct {
+		name   string
+		spec   v1alpha1.KnativeServingSpec
+		status map[string]string
+		routes []client.Object
+		want   []string
+	}{{
+		name:   "no deprecations",
+		routes: []client.Object{route("current", "knative-serving-ingress", nil)},
+	}, {
+		name:   "deprecations resolved",
+		status: map[string]string{deprecationsStatusKey: `[{"setting":"foo","message":"bar"}]`},
+	}, {
+		name: "deprecated gateway overrides",
+		spec: v1alpha1.KnativeServingSpec{
+			DeprecatedKnativeIngressGateway: v1alpha1.IstioGatewayOverride{Selector: map[string]string{"foo": "bar"}},
+			DeprecatedClusterLocalGateway:   v1alpha1.IstioGatewayOverride{Selector: map[string]string{"foo": "bar"}},
+		},
+		want: []string{"spec.knative-ingress-gateway", "spec.cluster-local-gateway"},
+	}, {
+		name: "routes with httpOption annotation",
+		routes: []client.Object{
+			route("current", "knative-serving-ingress", nil),
+			route("option1", "knative-serving-ingress", httpOption),
+			route("option2", "knative-serving-ingress", httpOption),
+			route("other", "other-ingress", httpOption),
+		},
+		want: []string{httpOptionAnnotationKey},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+				Spec:       test.spec,
+			}
+			ks.Status.Annotations = test.status
+
+			cl := fake.NewClientBuilder().WithObjects(test.routes...).Build()
+			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+			if err := r.reportDeprecations(ks); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			if raw, ok := ks.Status.Annotations[deprecationsStatusKey]; ok {
+				var deprecations []deprecation
+				if err := json.Unmarshal([]byte(raw), &deprecations); err != nil {
+					t.Fatal("Failed to unmarshal deprecations:", err)
+				}
+				for _, d := range deprecations {
+					if d.Message == "" {
+						t.Errorf("Deprecation of %s has no message", d.Setting)
+					}
+					got = append(got, d.Setting)
+				}
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got, test.want, cmp.Diff(got, test.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package knativeserving
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/types"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// The following keys are set by the ingress controller on the Routes it retains after
+	// their host has been removed from their Ingress, e.g. because config-domain changed.
+	obsoleteRouteLabelKey          = "serving.knative.openshift.io/obsolete"
+	obsoleteSinceAnnotation        = "serving.knative.openshift.io/obsoleteSince"
+	migrationGracePeriodNetworkKey = "openshift-route-migration-grace-period"
+
+	// pendingRoutesStatusKey is the status annotation reporting the number of Routes still
+	// serving the previous domain.
+	pendingRoutesStatusKey = "operator.serverless.openshift.io/domain-migration-pending-routes"
+	// migrationDeadlineStatusKey is the status annotation reporting when the last Route of
+	// the previous domain is going to be removed.
+	migrationDeadlineStatusKey = "operator.serverless.openshift.io/domain-migration-deadline"
+)
+
+// reportDomainMigration reports the progress of a domain migration in the status of the
+// instance, i.e. how many Routes of the previous domain are retained and until when.
+func (r *ReconcileKnativeServing) reportDomainMigration(instance *servingv1alpha1.KnativeServing) error {
+	list := &routev1.RouteList{}
+	// Kourier is installed into the "<namespace>-ingress" namespace, where the Routes live too.
+	err := r.client.List(context.TODO(), list, client.InNamespace(instance.Namespace+"-ingress"),
+		client.MatchingLabels{obsoleteRouteLabelKey: "true"})
+	if err != nil {
+		return fmt.Errorf("failed to list obsolete routes: %w", err)
+	}
+
+	delete(instance.Status.Annotations, pendingRoutesStatusKey)
+	delete(instance.Status.Annotations, migrationDeadlineStatusKey)
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	if instance.Status.Annotations == nil {
+		instance.Status.Annotations = make(map[string]string, 2)
+	}
+	instance.Status.Annotations[pendingRoutesStatusKey] = strconv.Itoa(len(list.Items))
+
+	gracePeriod, err := time.ParseDuration(instance.Spec.Config["network"][migrationGracePeriodNetworkKey])
+	if err != nil {
+		// The ingress controller doesn't retain Routes without a valid grace period.
+		return nil
+	}
+	var deadline time.Time
+	for _, route := range list.Items {
+		since, err := time.Parse(time.RFC3339, route.Annotations[obsoleteSinceAnnotation])
+		if err != nil {
+			continue
+		}
+		if d := since.Add(gracePeriod); d.After(deadline) {
+			deadline = d
+		}
+	}
+	if !deadline.IsZero() {
+		instance.Status.Annotations[migrationDeadlineStatusKey] = deadline.UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+// enqueueForObsoleteRoute enqueues the KnativeServings owning the ingress namespace of a
+// changed obsolete Route.
+func enqueueForObsoleteRoute(cl client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		if obj.GetLabels()[obsoleteRouteLabelKey] != "true" {
+			return nil
+		}
+		list := &servingv1alpha1.KnativeServingList{}
+		if err := cl.List(context.Background(), list); err != nil {
+			log.Error(err, "Failed to list KnativeServings")
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, ks := range list.Items {
+			if ks.Namespace+"-ingress" != obj.GetNamespace() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name},
+			})
+		}
+		return requests
+	})
+}
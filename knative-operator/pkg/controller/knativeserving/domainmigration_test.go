@@ -0,0 +1,94 @@
+package knativeserving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReportDomainMigration(t *testing.T) {
+	obsoleteRoute := func(name, namespace, since string) *routev1.Route {
+		return &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{obsoleteRouteLabelKey: "true"},
+				Annotations: map[string]string{obsoleteSinceAnnotation: since},
+			},
+		}
+	}
+	current := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "current", Namespace: "knative-serving-ingress"},
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]string
+		status map[string]string
+		routes []client.Object
+		want   map[string]string
+	}{{
+		name:   "no migration",
+		routes: []client.Object{current},
+	}, {
+		name: "migration finished",
+		status: map[string]string{
+			"foo":                      "bar",
+			pendingRoutesStatusKey:     "1",
+			migrationDeadlineStatusKey: "2021-10-01T13:00:00Z",
+		},
+		routes: []client.Object{current},
+		want:   map[string]string{"foo": "bar"},
+	}, {
+		name:   "migration in progress",
+		config: map[string]string{migrationGracePeriodNetworkKey: "1h"},
+		routes: []client.Object{
+			current,
+			obsoleteRoute("old1", "knative-serving-ingress", "2021-10-01T12:00:00Z"),
+			obsoleteRoute("old2", "knative-serving-ingress", "2021-10-01T12:30:00Z"),
+			obsoleteRoute("other", "other-ingress", "2021-10-01T14:00:00Z"),
+		},
+		want: map[string]string{
+			pendingRoutesStatusKey:     "2",
+			migrationDeadlineStatusKey: "2021-10-01T13:30:00Z",
+		},
+	}, {
+		name: "migration in progress, grace period unknown",
+		routes: []client.Object{
+			obsoleteRoute("old1", "knative-serving-ingress", "2021-10-01T12:00:00Z"),
+		},
+		want: map[string]string{pendingRoutesStatusKey: "1"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+			}
+			if test.config != nil {
+				ks.Spec.Config = v1alpha1.ConfigMapData{"network": test.config}
+			}
+			ks.Status.Annotations = test.status
+
+			cl := fake.NewClientBuilder().WithObjects(test.routes...).Build()
+			r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+			if err := r.reportDomainMigration(ks); err != nil {
+				t.Fatal(err)
+			}
+
+			got := ks.Status.Annotations
+			if len(got) == 0 {
+				got = nil
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got, test.want, cmp.Diff(got, test.want))
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/consoleclidownload"
@@ -106,6 +107,30 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for rotations of the CA bundles the deployments of Knative Serving depend on
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, enqueueForCABundle(mgr.GetClient()), caBundlePredicate(requiredNs))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes of the CA bundles trusted when resolving tags
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, enqueueForTagResolutionCA(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
+	// Watch for Routes retained during a domain migration to report its progress
+	err = c.Watch(&source.Kind{Type: &routev1.Route{}}, enqueueForObsoleteRoute(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
+	// Watch for namespaces to manage their domains.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, enqueueForNamespaceDomain(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
 	gvkToResource := map[schema.GroupVersionKind]client.Object{
 		consolev1.GroupVersion.WithKind("ConsoleCLIDownload"): &consolev1.ConsoleCLIDownload{},
 		routev1.GroupVersion.WithKind("Route"):                &routev1.Route{},
@@ -171,11 +196,19 @@ func (r *ReconcileKnativeServing) Reconcile(ctx context.Context, request reconci
 func (r *ReconcileKnativeServing) reconcileKnativeServing(instance *servingv1alpha1.KnativeServing) error {
 	stages := []func(*servingv1alpha1.KnativeServing) error{
 		r.configure,
+		r.reconcileNamespaceDomains,
 		r.ensureFinalizers,
 		r.ensureCustomCertsConfigMap,
+		r.trackCABundles,
 		r.installDashboard,
 		r.installQuickstarts,
 		r.installKnConsoleCLIDownload,
+		r.reportInstalledManifests,
+		r.reportDeployments,
+		r.reportDomainMigration,
+		r.reportDeprecations,
+		r.propagateImagePullSecrets,
+		r.trackUpgradeWindow,
 	}
 	for _, stage := range stages {
 		if err := stage(instance); err != nil {
@@ -203,6 +236,33 @@ func (r *ReconcileKnativeServing) configure(instance *servingv1alpha1.KnativeSer
 	return nil
 }
 
+// reportInstalledManifests annotates the instance with the installed Deployments
+func (r *ReconcileKnativeServing) reportInstalledManifests(instance *servingv1alpha1.KnativeServing) error {
+	// Kourier is installed into the "<namespace>-ingress" namespace.
+	installed, err := common.InstalledManifestsInNamespaces(context.TODO(), r.client, instance.Namespace, instance.Namespace+"-ingress")
+	if err != nil {
+		return err
+	}
+	changed, err := common.SetInstalledManifestsAnnotation(instance, installed)
+	if err != nil || !changed {
+		return err
+	}
+	if err := r.client.Update(context.TODO(), instance); err != nil {
+		return fmt.Errorf("failed to update KnativeServing with installed manifests: %w", err)
+	}
+	return nil
+}
+
+// reportDeployments reports the rollout progress of the installed Deployments in the status
+func (r *ReconcileKnativeServing) reportDeployments(instance *servingv1alpha1.KnativeServing) error {
+	// Kourier is installed into the "<namespace>-ingress" namespace.
+	statuses, err := common.DeploymentStatusesInNamespaces(context.TODO(), r.client, instance.Namespace, instance.Namespace+"-ingress")
+	if err != nil {
+		return err
+	}
+	return common.SetDeploymentsStatusAnnotation(&instance.Status.Annotations, statuses)
+}
+
 // set a finalizer to clean up service mesh when instance is deleted
 func (r *ReconcileKnativeServing) ensureFinalizers(instance *servingv1alpha1.KnativeServing) error {
 	for _, finalizer := range instance.GetFinalizers() {
@@ -235,13 +295,21 @@ func (r *ReconcileKnativeServing) ensureCustomCertsConfigMap(instance *servingv1
 		return fmt.Errorf("error reconciling serviceCACM: %w", err)
 	}
 
-	combinedContents := make(map[string]string, len(serviceCACM.Data)+len(trustedCACM.Data))
+	tagResolutionCA, err := r.tagResolutionCABundle(instance)
+	if err != nil {
+		return err
+	}
+
+	combinedContents := make(map[string]string, len(serviceCACM.Data)+len(trustedCACM.Data)+len(tagResolutionCA))
 	for key, value := range serviceCACM.Data {
 		combinedContents[key] = value
 	}
 	for key, value := range trustedCACM.Data {
 		combinedContents[key] = value
 	}
+	for key, value := range tagResolutionCA {
+		combinedContents[key] = value
+	}
 
 	combinedCM, err := r.reconcileConfigMap(instance, certs.Name, nil, nil, combinedContents)
 	if err != nil {
@@ -344,9 +412,16 @@ func (r *ReconcileKnativeServing) installDashboard(instance *servingv1alpha1.Kna
 	return dashboards.Apply("serving", instance, r.client)
 }
 
+// trackUpgradeWindow silences the Serverless alerts while the instance is upgraded
+func (r *ReconcileKnativeServing) trackUpgradeWindow(instance *servingv1alpha1.KnativeServing) error {
+	monitoring.TrackUpgradeWindow("serving", &instance.Status, &instance.Status.Annotations, time.Now())
+	return nil
+}
+
 // general clean-up, mostly resources in different namespaces from servingv1alpha1.KnativeServing.
 func (r *ReconcileKnativeServing) delete(instance *servingv1alpha1.KnativeServing) error {
 	defer monitoring.KnativeUp.DeleteLabelValues("serving_status")
+	defer monitoring.UpgradeSilenceEnd.DeleteLabelValues("serving")
 	finalizers := sets.NewString(instance.GetFinalizers()...)
 
 	if !finalizers.Has(finalizerName) {
@@ -370,6 +445,11 @@ func (r *ReconcileKnativeServing) delete(instance *servingv1alpha1.KnativeServin
 		return fmt.Errorf("failed to delete quickstarts: %w", err)
 	}
 
+	log.Info("Deleting propagated image pull secrets")
+	if err := r.deletePropagatedPullSecrets(instance, nil); err != nil {
+		return err
+	}
+
 	// The above might take a while, so we refetch the resource again in case it has changed.
 	refetched := &servingv1alpha1.KnativeServing{}
 	if err := r.client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, refetched); err != nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/controller/knativeserving/quickstart"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring/dashboards"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
 	configv1 "github.com/openshift/api/config/v1"
 	consolev1 "github.com/openshift/api/console/v1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -312,6 +313,43 @@ func TestCustomCertsConfigMap(t *testing.T) {
 	}
 }
 
+func TestCustomCertsConfigMapWithTagResolutionCA(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-serving",
+			Namespace:   "knative-serving",
+			Annotations: map[string]string{okoserving.TagResolutionAnnotation: `{"caBundle": "registry-ca"}`},
+		},
+		Spec: v1alpha1.KnativeServingSpec{
+			ControllerCustomCerts: v1alpha1.CustomCerts{
+				Name: "test-cm",
+				Type: "ConfigMap",
+			},
+		},
+	}
+	cl := fake.NewClientBuilder().
+		WithObjects(&servingNamespace).
+		WithRuntimeObjects(
+			cm("test-cm-service-ca", nil, map[string]string{serviceCAKey: "true"}, map[string]string{"service-ca.crt": "bar"}, "1"),
+			cm("registry-ca", nil, nil, map[string]string{"ca.crt": "baz"}, "1"),
+		).
+		Build()
+
+	r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+	if err := r.ensureCustomCertsConfigMap(ks); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "test-cm", Namespace: "knative-serving"}, got); err != nil {
+		t.Fatalf("Failed to fetch cm: %v", err)
+	}
+	want := map[string]string{"service-ca.crt": "bar", "tag-resolution-ca.crt": "baz"}
+	if !cmp.Equal(got.Data, want) {
+		t.Errorf("Data not equal, diff: %s", cmp.Diff(got.Data, want))
+	}
+}
+
 func ctrl(certVersion string) *appsv1.Deployment {
 	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
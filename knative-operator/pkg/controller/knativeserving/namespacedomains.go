@@ -0,0 +1,124 @@
+package knativeserving
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// managedDomainsAnnotation lists the config-domain entries managed for namespaces, to
+	// remove them once no namespace uses them anymore.
+	managedDomainsAnnotation = "serving.knative.openshift.io/managedNamespaceDomains"
+)
+
+// reconcileNamespaceDomains adds an entry selecting the Knative Services of each namespace
+// with common.NamespaceDomainAnnotation to config-domain. The services are labelled
+// accordingly by a webhook when they're created or updated. A domain claimed by several
+// namespaces is only assigned to the first one by name and domains configured by the user
+// are never overridden.
+func (r *ReconcileKnativeServing) reconcileNamespaceDomains(instance *servingv1alpha1.KnativeServing) error {
+	list := &corev1.NamespaceList{}
+	if err := r.client.List(context.TODO(), list); err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+
+	managed := sets.NewString()
+	if raw := instance.GetAnnotations()[managedDomainsAnnotation]; raw != "" {
+		managed.Insert(strings.Split(raw, ",")...)
+	}
+	current := instance.Spec.Config["domain"]
+
+	desired := make(map[string]string)
+	for _, ns := range list.Items {
+		domain, ok := ns.Annotations[common.NamespaceDomainAnnotation]
+		if !ok {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+			log.Info("Ignoring invalid namespace domain", "namespace", ns.Name, "domain", domain, "errors", errs)
+			continue
+		}
+		if _, ok := desired[domain]; ok {
+			log.Info("Ignoring namespace domain claimed by another namespace", "namespace", ns.Name, "domain", domain)
+			continue
+		}
+		if _, ok := current[domain]; ok && !managed.Has(domain) {
+			log.Info("Ignoring namespace domain configured in config-domain", "namespace", ns.Name, "domain", domain)
+			continue
+		}
+		desired[domain] = fmt.Sprintf("selector:\n  %s: %s\n", common.NamespaceDomainLabel, ns.Name)
+	}
+
+	domains := sets.StringKeySet(desired)
+	changed := !domains.Equal(managed)
+	for _, domain := range managed.Difference(domains).List() {
+		delete(current, domain)
+	}
+	for domain, entry := range desired {
+		if current[domain] != entry {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if len(desired) > 0 {
+		if instance.Spec.Config == nil {
+			instance.Spec.Config = servingv1alpha1.ConfigMapData{}
+		}
+		if instance.Spec.Config["domain"] == nil {
+			instance.Spec.Config["domain"] = make(map[string]string, len(desired))
+		}
+		for domain, entry := range desired {
+			instance.Spec.Config["domain"][domain] = entry
+		}
+	}
+	annotations := instance.GetAnnotations()
+	if domains.Len() > 0 {
+		if annotations == nil {
+			annotations = make(map[string]string, 1)
+		}
+		annotations[managedDomainsAnnotation] = strings.Join(domains.List(), ",")
+	} else {
+		delete(annotations, managedDomainsAnnotation)
+	}
+	instance.SetAnnotations(annotations)
+
+	log.Info("Updating namespace domains", "domains", domains.List())
+	if err := r.client.Update(context.TODO(), instance); err != nil {
+		return fmt.Errorf("failed to update KnativeServing with namespace domains: %w", err)
+	}
+	return nil
+}
+
+// enqueueForNamespaceDomain enqueues all KnativeServings for changes of namespaces.
+func enqueueForNamespaceDomain(cl client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		list := &servingv1alpha1.KnativeServingList{}
+		if err := cl.List(context.Background(), list); err != nil {
+			log.Error(err, "Failed to list KnativeServings")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(list.Items))
+		for _, ks := range list.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name},
+			})
+		}
+		return requests
+	})
+}
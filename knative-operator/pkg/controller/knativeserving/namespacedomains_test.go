@@ -0,0 +1,84 @@
+package knativeserving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileNamespaceDomains(t *testing.T) {
+	namespace := func(name, domain string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if domain != "" {
+			ns.Annotations = map[string]string{common.NamespaceDomainAnnotation: domain}
+		}
+		return ns
+	}
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-serving",
+			Namespace:   "knative-serving",
+			Annotations: map[string]string{managedDomainsAnnotation: "old.example.com"},
+		},
+	}
+	ks.Spec.Config = v1alpha1.ConfigMapData{
+		"domain": map[string]string{
+			"apps.example.com":  "",
+			"user.example.com":  "",
+			"old.example.com":   "selector:\n  " + common.NamespaceDomainLabel + ": old\n",
+			"other.example.com": "",
+		},
+	}
+
+	cl := fake.NewClientBuilder().WithObjects(
+		ks,
+		namespace("team-a", "team-a.example.com"),
+		namespace("team-b", "team-a.example.com"),
+		namespace("team-c", "user.example.com"),
+		namespace("team-d", "Not_A_Domain"),
+		namespace("team-e", ""),
+	).Build()
+	r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+
+	instance := &v1alpha1.KnativeServing{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(ks), instance); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcileNamespaceDomains(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &v1alpha1.KnativeServing{}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(ks), got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"apps.example.com":   "",
+		"user.example.com":   "",
+		"other.example.com":  "",
+		"team-a.example.com": "selector:\n  " + common.NamespaceDomainLabel + ": team-a\n",
+	}
+	if !cmp.Equal(got.Spec.Config["domain"], want) {
+		t.Errorf("config-domain = %v, want: %v", got.Spec.Config["domain"], want)
+	}
+	if got := got.Annotations[managedDomainsAnnotation]; got != "team-a.example.com" {
+		t.Errorf("Managed domains = %q, want %q", got, "team-a.example.com")
+	}
+
+	// Running again doesn't change anything.
+	before := got.ResourceVersion
+	if err := r.reconcileNamespaceDomains(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ResourceVersion != before {
+		t.Error("Updated the KnativeServing without changes")
+	}
+}
@@ -0,0 +1,158 @@
+package knativeserving
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// propagatedPullSecretLabel marks the image pull secrets copied into user namespaces by us.
+	propagatedPullSecretLabel = "serving.knative.openshift.io/propagated-pull-secret"
+
+	// defaultServiceAccount is the ServiceAccount revisions run as unless they specify one.
+	defaultServiceAccount = "default"
+)
+
+// propagateImagePullSecrets copies the image pull secrets of the instance into the namespaces
+// listed in okoserving.PullSecretNamespacesAnnotation and adds them to the namespaces' default
+// ServiceAccount. Copies in namespaces that are no longer listed are removed.
+func (r *ReconcileKnativeServing) propagateImagePullSecrets(instance *servingv1alpha1.KnativeServing) error {
+	namespaces, err := okoserving.PullSecretNamespaces(instance)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[types.NamespacedName]bool)
+	for _, ns := range namespaces {
+		namespace := &corev1.Namespace{}
+		if err := r.client.Get(context.TODO(), client.ObjectKey{Name: ns}, namespace); err != nil {
+			if errors.IsNotFound(err) {
+				log.Info("Namespace does not exist, skipping image pull secrets", "namespace", ns)
+				continue
+			}
+			return err
+		}
+		for _, ref := range instance.Spec.Registry.ImagePullSecrets {
+			secret := &corev1.Secret{}
+			if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: instance.Namespace, Name: ref.Name}, secret); err != nil {
+				if errors.IsNotFound(err) {
+					log.Info("Image pull secret does not exist, skipping propagation", "secret", ref.Name)
+					continue
+				}
+				return fmt.Errorf("failed to get image pull secret %s: %w", ref.Name, err)
+			}
+			if err := r.applyPropagatedPullSecret(instance, ns, secret); err != nil {
+				return fmt.Errorf("failed to propagate image pull secret %s to namespace %s: %w", ref.Name, ns, err)
+			}
+			keep[types.NamespacedName{Namespace: ns, Name: ref.Name}] = true
+		}
+	}
+
+	return r.deletePropagatedPullSecrets(instance, keep)
+}
+
+func (r *ReconcileKnativeServing) applyPropagatedPullSecret(instance *servingv1alpha1.KnativeServing, ns string, source *corev1.Secret) error {
+	existing := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: source.Name}, existing)
+	if errors.IsNotFound(err) {
+		log.Info("Creating image pull secret", "namespace", ns, "secret", source.Name)
+		err = r.client.Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      source.Name,
+				Namespace: ns,
+				Labels:    map[string]string{propagatedPullSecretLabel: "true"},
+				Annotations: map[string]string{
+					common.ServingOwnerName:      instance.Name,
+					common.ServingOwnerNamespace: instance.Namespace,
+				},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		})
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if existing.Labels[propagatedPullSecretLabel] != "true" {
+		log.Info("Secret not managed by the operator, skipping image pull secret", "namespace", ns, "secret", source.Name)
+		return nil
+	} else if !equality.Semantic.DeepEqual(existing.Data, source.Data) {
+		copy := existing.DeepCopy()
+		copy.Data = source.Data
+		if err := r.client.Update(context.TODO(), copy); err != nil {
+			return err
+		}
+	}
+
+	return r.updateDefaultServiceAccount(ns, func(sa *corev1.ServiceAccount) {
+		for _, ref := range sa.ImagePullSecrets {
+			if ref.Name == source.Name {
+				return
+			}
+		}
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: source.Name})
+	})
+}
+
+// deletePropagatedPullSecrets deletes all image pull secrets propagated for the given instance
+// that are not part of keep and removes them from the default ServiceAccount.
+func (r *ReconcileKnativeServing) deletePropagatedPullSecrets(instance *servingv1alpha1.KnativeServing, keep map[types.NamespacedName]bool) error {
+	list := &corev1.SecretList{}
+	if err := r.client.List(context.TODO(), list, client.MatchingLabels{propagatedPullSecretLabel: "true"}); err != nil {
+		return fmt.Errorf("failed to list image pull secrets: %w", err)
+	}
+	for i := range list.Items {
+		secret := &list.Items[i]
+		if keep[types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}] ||
+			secret.Annotations[common.ServingOwnerName] != instance.Name ||
+			secret.Annotations[common.ServingOwnerNamespace] != instance.Namespace {
+			continue
+		}
+		err := r.updateDefaultServiceAccount(secret.Namespace, func(sa *corev1.ServiceAccount) {
+			refs := sa.ImagePullSecrets[:0]
+			for _, ref := range sa.ImagePullSecrets {
+				if ref.Name != secret.Name {
+					refs = append(refs, ref)
+				}
+			}
+			sa.ImagePullSecrets = refs
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove image pull secret %s from namespace %s: %w", secret.Name, secret.Namespace, err)
+		}
+		log.Info("Deleting image pull secret", "namespace", secret.Namespace, "secret", secret.Name)
+		if err := r.client.Delete(context.TODO(), secret); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete image pull secret %s in namespace %s: %w", secret.Name, secret.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// updateDefaultServiceAccount applies the given mutation to the default ServiceAccount of
+// the given namespace, if it exists.
+func (r *ReconcileKnativeServing) updateDefaultServiceAccount(ns string, mutate func(*corev1.ServiceAccount)) error {
+	sa := &corev1.ServiceAccount{}
+	if err := r.client.Get(context.TODO(), client.ObjectKey{Namespace: ns, Name: defaultServiceAccount}, sa); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	updated := sa.DeepCopy()
+	mutate(updated)
+	if equality.Semantic.DeepEqual(sa.ImagePullSecrets, updated.ImagePullSecrets) {
+		return nil
+	}
+	return r.client.Update(context.TODO(), updated)
+}
@@ -0,0 +1,98 @@
+package knativeserving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPropagateImagePullSecrets(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-serving",
+			Namespace:   "knative-serving",
+			Annotations: map[string]string{okoserving.PullSecretNamespacesAnnotation: "team-a,missing"},
+		},
+	}
+	ks.Spec.Registry.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}, {Name: "absent"}}
+
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "knative-serving"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	}
+	namespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	defaultSA := func(ns string, secrets ...string) *corev1.ServiceAccount {
+		sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: defaultServiceAccount, Namespace: ns}}
+		for _, secret := range secrets {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+		}
+		return sa
+	}
+	stale := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registry",
+			Namespace: "team-b",
+			Labels:    map[string]string{propagatedPullSecretLabel: "true"},
+			Annotations: map[string]string{
+				common.ServingOwnerName:      ks.Name,
+				common.ServingOwnerNamespace: ks.Namespace,
+			},
+		},
+	}
+
+	cl := fake.NewClientBuilder().WithObjects(
+		source,
+		namespace("team-a"), defaultSA("team-a", "own"),
+		namespace("team-b"), defaultSA("team-b", "own", "registry"), stale,
+	).Build()
+	r := &ReconcileKnativeServing{client: cl, scheme: scheme.Scheme}
+	if err := r.propagateImagePullSecrets(ks); err != nil {
+		t.Fatal(err)
+	}
+
+	copied := &corev1.Secret{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "registry"}, copied); err != nil {
+		t.Fatal("Failed to get propagated secret:", err)
+	}
+	if copied.Type != source.Type || !cmp.Equal(copied.Data, source.Data) {
+		t.Errorf("Propagated secret = %v, want the data of %v", copied, source)
+	}
+	assertPullSecrets(t, cl, "team-a", []corev1.LocalObjectReference{{Name: "own"}, {Name: "registry"}})
+
+	// The secret is removed from the namespace that is no longer listed.
+	err := cl.Get(context.Background(), client.ObjectKey{Namespace: "team-b", Name: "registry"}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Stale secret wasn't deleted: %v", err)
+	}
+	assertPullSecrets(t, cl, "team-b", []corev1.LocalObjectReference{{Name: "own"}})
+
+	// Running again doesn't change anything.
+	if err := r.propagateImagePullSecrets(ks); err != nil {
+		t.Fatal(err)
+	}
+	assertPullSecrets(t, cl, "team-a", []corev1.LocalObjectReference{{Name: "own"}, {Name: "registry"}})
+}
+
+func assertPullSecrets(t *testing.T, cl client.Client, ns string, want []corev1.LocalObjectReference) {
+	t.Helper()
+	sa := &corev1.ServiceAccount{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: ns, Name: defaultServiceAccount}, sa); err != nil {
+		t.Fatal("Failed to get default ServiceAccount:", err)
+	}
+	if !cmp.Equal(sa.ImagePullSecrets, want) {
+		t.Errorf("ImagePullSecrets of %s = %v, want: %v", ns, sa.ImagePullSecrets, want)
+	}
+}
@@ -0,0 +1,209 @@
+package rbacaggregation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	knativeGroupSuffix = ".knative.dev"
+
+	// managedLabel marks the ClusterRoles managed by this controller.
+	managedLabel = "rbac.serverless.openshift.io/aggregated"
+)
+
+var (
+	log = common.Log.WithName("rbacaggregation-controller")
+
+	// The request is a singleton, as all ClusterRoles are derived from the full set of CRDs.
+	aggregationRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "knative-aggregated-roles"}}
+
+	// excludedGroups are Knative API groups that must not be exposed to developers.
+	excludedGroups = []string{
+		"operator.knative.dev",
+		".internal.knative.dev",
+	}
+
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+
+	// aggregatedRoles are the ClusterRoles to be created, each aggregated to one of the default
+	// view, edit and admin roles.
+	aggregatedRoles = []struct {
+		name      string
+		aggregate string
+		verbs     []string
+	}{{
+		name:      "knative-serverless-aggregate-to-view",
+		aggregate: "view",
+		verbs:     readVerbs,
+	}, {
+		name:      "knative-serverless-aggregate-to-edit",
+		aggregate: "edit",
+		verbs:     writeVerbs,
+	}, {
+		name:      "knative-serverless-aggregate-to-admin",
+		aggregate: "admin",
+		verbs:     writeVerbs,
+	}}
+)
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileRBACAggregation{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("rbacaggregation-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	enqueue := handler.EnqueueRequestsFromMapFunc(func(client.Object) []reconcile.Request {
+		return []reconcile.Request{aggregationRequest}
+	})
+
+	// Recompute the roles whenever the set of Knative CRDs changes.
+	err = c.Watch(&source.Kind{Type: &apiextensionsv1.CustomResourceDefinition{}}, enqueue, predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+		return ok && isKnativeGroup(crd.Spec.Group)
+	}))
+	if err != nil {
+		return err
+	}
+
+	// Restore the roles if they're changed externally.
+	return c.Watch(&source.Kind{Type: &rbacv1.ClusterRole{}}, enqueue, predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[managedLabel] == "true"
+	}))
+}
+
+// blank assignment to verify that ReconcileRBACAggregation implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileRBACAggregation{}
+
+// ReconcileRBACAggregation reconciles ClusterRoles aggregated to the default view, edit and
+// admin roles, granting access to the installed Knative resources.
+type ReconcileRBACAggregation struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile computes the rules for all installed Knative CRDs and applies them to the
+// aggregated ClusterRoles.
+func (r *ReconcileRBACAggregation) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log.Info("Reconciling aggregated ClusterRoles")
+
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := r.client.List(ctx, crds); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list CRDs: %w", err)
+	}
+
+	for _, role := range aggregatedRoles {
+		desired := &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: role.name,
+				Labels: map[string]string{
+					managedLabel: "true",
+					"rbac.authorization.k8s.io/aggregate-to-" + role.aggregate: "true",
+				},
+			},
+			Rules: makeRules(crds.Items, role.verbs),
+		}
+		if err := r.apply(ctx, desired); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to apply ClusterRole %s: %w", role.name, err)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcileRBACAggregation) apply(ctx context.Context, desired *rbacv1.ClusterRole) error {
+	existing := &rbacv1.ClusterRole{}
+	err := r.client.Get(ctx, client.ObjectKey{Name: desired.Name}, existing)
+	if errors.IsNotFound(err) {
+		log.Info("Creating ClusterRole", "name", desired.Name)
+		return r.client.Create(ctx, desired)
+	} else if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Rules, desired.Rules) &&
+		equality.Semantic.DeepEqual(existing.Labels, desired.Labels) {
+		return nil
+	}
+	log.Info("Updating ClusterRole", "name", desired.Name)
+	copy := existing.DeepCopy()
+	copy.Labels = desired.Labels
+	copy.Rules = desired.Rules
+	return r.client.Update(ctx, copy)
+}
+
+// makeRules creates a rule per Knative API group, granting the given verbs on all namespaced
+// resources of that group.
+func makeRules(crds []apiextensionsv1.CustomResourceDefinition, verbs []string) []rbacv1.PolicyRule {
+	resourcesByGroup := make(map[string][]string)
+	for _, crd := range crds {
+		if !isKnativeGroup(crd.Spec.Group) || crd.Spec.Scope != apiextensionsv1.NamespaceScoped {
+			continue
+		}
+		resourcesByGroup[crd.Spec.Group] = append(resourcesByGroup[crd.Spec.Group], crd.Spec.Names.Plural)
+	}
+
+	groups := make([]string, 0, len(resourcesByGroup))
+	for group := range resourcesByGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	rules := make([]rbacv1.PolicyRule, 0, len(groups))
+	for _, group := range groups {
+		resources := resourcesByGroup[group]
+		sort.Strings(resources)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: resources,
+			Verbs:     verbs,
+		})
+	}
+	return rules
+}
+
+// isKnativeGroup returns true if the given API group belongs to a developer facing Knative API.
+func isKnativeGroup(group string) bool {
+	if !strings.HasSuffix(group, knativeGroupSuffix) {
+		return false
+	}
+	for _, excluded := range excludedGroups {
+		if strings.HasSuffix(group, excluded) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package rbacaggregation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestRBACAggregationReconcile(t *testing.T) {
+	cl := fake.NewClientBuilder().WithObjects(
+		crd("services", "serving.knative.dev", apiextensionsv1.NamespaceScoped),
+		crd("routes", "serving.knative.dev", apiextensionsv1.NamespaceScoped),
+		crd("kafkasinks", "eventing.knative.dev", apiextensionsv1.NamespaceScoped),
+		crd("brokers", "eventing.knative.dev", apiextensionsv1.NamespaceScoped),
+		crd("clusterdomainclaims", "networking.internal.knative.dev", apiextensionsv1.ClusterScoped),
+		crd("ingresses", "networking.internal.knative.dev", apiextensionsv1.NamespaceScoped),
+		crd("knativeservings", "operator.knative.dev", apiextensionsv1.NamespaceScoped),
+		crd("routes", "route.openshift.io", apiextensionsv1.NamespaceScoped),
+		// A stale role is updated.
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "knative-serverless-aggregate-to-edit"},
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{"foo.knative.dev"},
+				Resources: []string{"foos"},
+				Verbs:     []string{"*"},
+			}},
+		},
+	).Build()
+	r := &ReconcileRBACAggregation{client: cl, scheme: scheme.Scheme}
+
+	if _, err := r.Reconcile(context.Background(), aggregationRequest); err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	for _, role := range aggregatedRoles {
+		got := &rbacv1.ClusterRole{}
+		if err := cl.Get(context.Background(), client.ObjectKey{Name: role.name}, got); err != nil {
+			t.Fatalf("get: (%v)", err)
+		}
+		if got.Labels["rbac.authorization.k8s.io/aggregate-to-"+role.aggregate] != "true" {
+			t.Errorf("ClusterRole %s is not aggregated to %s: %v", role.name, role.aggregate, got.Labels)
+		}
+		want := []rbacv1.PolicyRule{{
+			APIGroups: []string{"eventing.knative.dev"},
+			Resources: []string{"brokers", "kafkasinks"},
+			Verbs:     role.verbs,
+		}, {
+			APIGroups: []string{"serving.knative.dev"},
+			Resources: []string{"routes", "services"},
+			Verbs:     role.verbs,
+		}}
+		if !cmp.Equal(got.Rules, want) {
+			t.Errorf("Unexpected rules for %s (-want, +got): %s", role.name, cmp.Diff(want, got.Rules))
+		}
+	}
+}
+
+func crd(plural, group string, scope apiextensionsv1.ResourceScope) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: plural + "." + group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: plural},
+			Scope: scope,
+		},
+	}
+}
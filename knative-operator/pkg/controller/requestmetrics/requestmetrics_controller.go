@@ -0,0 +1,203 @@
+package requestmetrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// OptOutLabel is the namespace label that disables request metrics for the namespace,
+	// if set to "disabled".
+	OptOutLabel = "serving.knative.openshift.io/request-metrics"
+
+	// ObservabilityConfigName is the name of the observability ConfigMap, both in the serving
+	// namespace and in namespaces opting out of request metrics.
+	ObservabilityConfigName = "config-observability"
+
+	// RequestMetricsBackendKey configures the backend of the request metrics.
+	RequestMetricsBackendKey = "metrics.request-metrics-backend-destination"
+
+	// overrideLabel marks the namespace-scoped observability ConfigMaps created by us.
+	overrideLabel = "serving.knative.openshift.io/request-metrics-override"
+)
+
+var log = common.Log.WithName("requestmetrics-controller")
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileRequestMetrics{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("requestmetrics-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Namespaces are the primary resource. All changes are of interest, as the removal of the
+	// label has to be noticed too.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Restore the overrides if they get changed or deleted.
+	enqueueNamespace := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+	})
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(enqueueNamespace), predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[overrideLabel] == "true"
+	}))
+	if err != nil {
+		return err
+	}
+
+	// Merge changes of the cluster-wide config into all overrides.
+	enqueueOptedOut := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		namespaces := &corev1.NamespaceList{}
+		if err := mgr.GetClient().List(context.Background(), namespaces, client.MatchingLabels{OptOutLabel: "disabled"}); err != nil {
+			log.Error(err, "Failed to list namespaces opting out of request metrics")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(namespaces.Items))
+		for _, ns := range namespaces.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ns.Name}})
+		}
+		return requests
+	})
+	return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(enqueueOptedOut), predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ownedByServing := obj.GetLabels()["serving.knative.dev/release"]
+		return obj.GetName() == ObservabilityConfigName && ownedByServing
+	}))
+}
+
+// blank assignment to verify that ReconcileRequestMetrics implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileRequestMetrics{}
+
+// ReconcileRequestMetrics disables request metrics for namespaces opting out of them.
+type ReconcileRequestMetrics struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile creates an observability ConfigMap in the given namespace if it opts out of request
+// metrics and removes it once the namespace opts in again.
+func (r *ReconcileRequestMetrics) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, request.NamespacedName, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if ns.DeletionTimestamp != nil {
+		// The ConfigMap is removed along with the namespace.
+		return reconcile.Result{}, nil
+	}
+
+	existing := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: ObservabilityConfigName}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, fmt.Errorf("failed to get observability config: %w", err)
+	}
+	exists := err == nil
+	if exists && existing.Labels[overrideLabel] != "true" {
+		reqLogger.Info("Observability config exists already and is not managed by the operator, skipping")
+		return reconcile.Result{}, nil
+	}
+
+	if ns.Labels[OptOutLabel] != "disabled" {
+		if !exists {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Deleting observability override")
+		if err := r.client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete observability override: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	data, err := r.overrideData(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !exists {
+		reqLogger.Info("Creating observability override")
+		err := r.client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ObservabilityConfigName,
+				Namespace: ns.Name,
+				Labels:    map[string]string{overrideLabel: "true"},
+			},
+			Data: data,
+		})
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to create observability override: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if equality.Semantic.DeepEqual(existing.Data, data) {
+		return reconcile.Result{}, nil
+	}
+	reqLogger.Info("Updating observability override")
+	copy := existing.DeepCopy()
+	copy.Data = data
+	if err := r.client.Update(ctx, copy); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update observability override: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// overrideData merges the cluster-wide observability config with the disabled request metrics.
+func (r *ReconcileRequestMetrics) overrideData(ctx context.Context) (map[string]string, error) {
+	data := map[string]string{}
+
+	list := &servingv1alpha1.KnativeServingList{}
+	if err := r.client.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list KnativeServings: %w", err)
+	}
+	if len(list.Items) > 0 {
+		cluster := &corev1.ConfigMap{}
+		err := r.client.Get(ctx, client.ObjectKey{Namespace: list.Items[0].Namespace, Name: ObservabilityConfigName}, cluster)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get the cluster-wide observability config: %w", err)
+		}
+		for key, value := range cluster.Data {
+			if key != "_example" {
+				data[key] = value
+			}
+		}
+	}
+
+	data[RequestMetricsBackendKey] = "none"
+	return data, nil
+}
@@ -0,0 +1,120 @@
+package requestmetrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestRequestMetricsReconcile(t *testing.T) {
+	ks := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+	}
+	cluster := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ObservabilityConfigName, Namespace: "knative-serving"},
+		Data: map[string]string{
+			"_example":                    "foo",
+			"metrics.backend-destination": "prometheus",
+		},
+	}
+	managed := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ObservabilityConfigName,
+			Namespace: "test",
+			Labels:    map[string]string{overrideLabel: "true"},
+		},
+		Data: map[string]string{RequestMetricsBackendKey: "prometheus"},
+	}
+	unmanaged := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ObservabilityConfigName, Namespace: "test"},
+		Data:       map[string]string{"foo": "bar"},
+	}
+	disabled := map[string]string{OptOutLabel: "disabled"}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		existing []client.Object
+		want     map[string]string
+	}{{
+		name: "not labelled",
+	}, {
+		name:   "labelled, no cluster config",
+		labels: disabled,
+		want:   map[string]string{RequestMetricsBackendKey: "none"},
+	}, {
+		name:     "labelled, merged with cluster config",
+		labels:   disabled,
+		existing: []client.Object{ks, cluster},
+		want: map[string]string{
+			"metrics.backend-destination": "prometheus",
+			RequestMetricsBackendKey:      "none",
+		},
+	}, {
+		name:     "labelled, modified override",
+		labels:   disabled,
+		existing: []client.Object{managed},
+		want:     map[string]string{RequestMetricsBackendKey: "none"},
+	}, {
+		name:     "labelled, unmanaged config",
+		labels:   disabled,
+		existing: []client.Object{unmanaged},
+		want:     unmanaged.Data,
+	}, {
+		name:     "not labelled, existing override",
+		existing: []client.Object{managed},
+	}, {
+		name:     "not labelled, unmanaged config",
+		existing: []client.Object{unmanaged},
+		want:     unmanaged.Data,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := []client.Object{&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: test.labels},
+			}}
+			for _, obj := range test.existing {
+				objs = append(objs, obj.DeepCopyObject().(client.Object))
+			}
+			cl := fake.NewClientBuilder().WithObjects(objs...).Build()
+			r := &ReconcileRequestMetrics{client: cl, scheme: scheme.Scheme}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &corev1.ConfigMap{}
+			err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: ObservabilityConfigName}, got)
+			if test.want == nil {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("ConfigMap should not exist, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			if !cmp.Equal(got.Data, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got.Data, test.want, cmp.Diff(got.Data, test.want))
+			}
+		})
+	}
+}
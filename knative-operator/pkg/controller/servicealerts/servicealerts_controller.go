@@ -0,0 +1,234 @@
+package servicealerts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ErrorRateThresholdAnnotation is the namespace annotation setting the ratio of requests
+	// answered with a 5xx response, e.g. "0.05", above which the Knative Services of the
+	// namespace alert.
+	ErrorRateThresholdAnnotation = "monitoring.knative.openshift.io/error-rate-threshold"
+
+	// LatencyThresholdAnnotation is the namespace annotation setting the 99th percentile of the
+	// request latency, e.g. "500ms", above which the Knative Services of the namespace alert.
+	LatencyThresholdAnnotation = "monitoring.knative.openshift.io/latency-threshold"
+
+	// RuleName is the name of the PrometheusRule rendered into namespaces setting thresholds.
+	RuleName = "knative-service-alerts"
+
+	// managedLabel marks the PrometheusRules created by us.
+	managedLabel = "monitoring.knative.openshift.io/service-alerts"
+
+	// alertFor is how long a threshold has to be exceeded before the alerts fire.
+	alertFor = "5m"
+)
+
+var log = common.Log.WithName("servicealerts-controller")
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileServiceAlerts{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("servicealerts-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Namespaces are the primary resource. All changes are of interest, as the removal of the
+	// annotations has to be noticed too.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Restore the rules if they get changed or deleted.
+	enqueueNamespace := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+	})
+	return c.Watch(&source.Kind{Type: &monitoringv1.PrometheusRule{}}, handler.EnqueueRequestsFromMapFunc(enqueueNamespace), predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[managedLabel] == "true"
+	}))
+}
+
+// blank assignment to verify that ReconcileServiceAlerts implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileServiceAlerts{}
+
+// ReconcileServiceAlerts renders the alerting thresholds set on namespaces into PrometheusRules.
+type ReconcileServiceAlerts struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile creates a PrometheusRule in the given namespace if it sets alerting thresholds
+// and removes it once the thresholds are unset again.
+func (r *ReconcileServiceAlerts) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, request.NamespacedName, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if ns.DeletionTimestamp != nil {
+		// The PrometheusRule is removed along with the namespace.
+		return reconcile.Result{}, nil
+	}
+
+	existing := &monitoringv1.PrometheusRule{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: RuleName}, existing)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, fmt.Errorf("failed to get service alerts: %w", err)
+	}
+	exists := err == nil
+	if exists && existing.Labels[managedLabel] != "true" {
+		reqLogger.Info("PrometheusRule exists already and is not managed by the operator, skipping")
+		return reconcile.Result{}, nil
+	}
+
+	rules, errs := alertRules(ns)
+	for _, err := range errs {
+		// Invalid thresholds are left out rather than failing the others.
+		reqLogger.Error(err, "Ignoring invalid alerting threshold")
+	}
+
+	if len(rules) == 0 {
+		if !exists {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Deleting service alerts")
+		if err := r.client.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete service alerts: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	spec := monitoringv1.PrometheusRuleSpec{
+		Groups: []monitoringv1.RuleGroup{{
+			Name:  "knative-service-alerts.rules",
+			Rules: rules,
+		}},
+	}
+	if !exists {
+		reqLogger.Info("Creating service alerts")
+		err := r.client.Create(ctx, &monitoringv1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      RuleName,
+				Namespace: ns.Name,
+				Labels:    map[string]string{managedLabel: "true"},
+			},
+			Spec: spec,
+		})
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to create service alerts: %w", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, spec) {
+		return reconcile.Result{}, nil
+	}
+	reqLogger.Info("Updating service alerts")
+	copy := existing.DeepCopy()
+	copy.Spec = spec
+	if err := r.client.Update(ctx, copy); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update service alerts: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// alertRules returns the alerts on the queue-proxy metrics of the Knative Services of the
+// given namespace for the thresholds set on it, labeled with the configuration of the
+// failing service. Invalid thresholds are returned as errors.
+func alertRules(ns *corev1.Namespace) ([]monitoringv1.Rule, []error) {
+	var (
+		rules []monitoringv1.Rule
+		errs  []error
+	)
+	selector := fmt.Sprintf(`namespace=%q, container_name="queue-proxy"`, ns.Name)
+
+	if raw, ok := ns.Annotations[ErrorRateThresholdAnnotation]; ok {
+		threshold, err := strconv.ParseFloat(raw, 64)
+		if err == nil && (threshold <= 0 || threshold > 1) {
+			err = fmt.Errorf("%v is not within (0, 1]", threshold)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", ErrorRateThresholdAnnotation, raw, err))
+		} else {
+			rules = append(rules, monitoringv1.Rule{
+				Alert: "KnativeServiceErrorRateHigh",
+				Expr: intstr.FromString(fmt.Sprintf(`sum by (namespace, configuration_name) (rate(revision_app_request_count{%[1]s, response_code_class="5xx"}[5m]))
+  / sum by (namespace, configuration_name) (rate(revision_app_request_count{%[1]s}[5m]))
+> %[2]s`, selector, strconv.FormatFloat(threshold, 'f', -1, 64))),
+				For:    alertFor,
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary": "A Knative Service fails too many requests",
+					"description": "{{ $value | humanizePercentage }} of the requests to {{ $labels.namespace }}/{{ $labels.configuration_name }} " +
+						"fail with a 5xx response, above the threshold of " + raw + ".",
+				},
+			})
+		}
+	}
+
+	if raw, ok := ns.Annotations[LatencyThresholdAnnotation]; ok {
+		threshold, err := time.ParseDuration(raw)
+		if err == nil && threshold <= 0 {
+			err = fmt.Errorf("%v is not positive", threshold)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", LatencyThresholdAnnotation, raw, err))
+		} else {
+			// The latencies are recorded in milliseconds.
+			ms := strconv.FormatFloat(float64(threshold)/float64(time.Millisecond), 'f', -1, 64)
+			rules = append(rules, monitoringv1.Rule{
+				Alert: "KnativeServiceLatencyHigh",
+				Expr: intstr.FromString(fmt.Sprintf(`histogram_quantile(0.99,
+  sum by (namespace, configuration_name, le) (rate(revision_app_request_latencies_bucket{%s}[5m]))
+) > %s`, selector, ms)),
+				For:    alertFor,
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary": "A Knative Service responds too slowly",
+					"description": "The 99th percentile of the request latency of {{ $labels.namespace }}/{{ $labels.configuration_name }} " +
+						"is {{ $value }}ms, above the threshold of " + raw + ".",
+				},
+			})
+		}
+	}
+	return rules, errs
+}
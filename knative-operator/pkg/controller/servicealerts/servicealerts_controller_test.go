@@ -0,0 +1,132 @@
+package servicealerts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "test"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestServiceAlertsReconcile(t *testing.T) {
+	managed := &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RuleName,
+			Namespace: "test",
+			Labels:    map[string]string{managedLabel: "true"},
+		},
+	}
+	unmanaged := &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{Name: RuleName, Namespace: "test"},
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		existing    []client.Object
+		want        []string
+	}{{
+		name: "not annotated",
+	}, {
+		name:        "error rate",
+		annotations: map[string]string{ErrorRateThresholdAnnotation: "0.05"},
+		want:        []string{"KnativeServiceErrorRateHigh"},
+	}, {
+		name: "both thresholds, modified rule",
+		annotations: map[string]string{
+			ErrorRateThresholdAnnotation: "0.05",
+			LatencyThresholdAnnotation:   "500ms",
+		},
+		existing: []client.Object{managed},
+		want:     []string{"KnativeServiceErrorRateHigh", "KnativeServiceLatencyHigh"},
+	}, {
+		name: "invalid threshold is ignored",
+		annotations: map[string]string{
+			ErrorRateThresholdAnnotation: "5%",
+			LatencyThresholdAnnotation:   "1s",
+		},
+		want: []string{"KnativeServiceLatencyHigh"},
+	}, {
+		name:        "only invalid thresholds",
+		annotations: map[string]string{ErrorRateThresholdAnnotation: "2"},
+		existing:    []client.Object{managed},
+	}, {
+		name:     "not annotated, existing rule",
+		existing: []client.Object{managed},
+	}, {
+		name:        "unmanaged rule",
+		annotations: map[string]string{ErrorRateThresholdAnnotation: "0.05"},
+		existing:    []client.Object{unmanaged},
+		want:        []string{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := []client.Object{&corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: test.annotations},
+			}}
+			for _, obj := range test.existing {
+				objs = append(objs, obj.DeepCopyObject().(client.Object))
+			}
+			cl := fake.NewClientBuilder().WithObjects(objs...).Build()
+			r := &ReconcileServiceAlerts{client: cl, scheme: scheme.Scheme}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &monitoringv1.PrometheusRule{}
+			err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: RuleName}, got)
+			if test.want == nil {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("PrometheusRule should not exist, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			alerts := []string{}
+			for _, group := range got.Spec.Groups {
+				for _, rule := range group.Rules {
+					alerts = append(alerts, rule.Alert)
+				}
+			}
+			if !cmp.Equal(alerts, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", alerts, test.want, cmp.Diff(alerts, test.want))
+			}
+		})
+	}
+}
+
+func TestAlertRulesExpr(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Annotations: map[string]string{LatencyThresholdAnnotation: "1.5s"},
+	}}
+	rules, errs := alertRules(ns)
+	if len(errs) != 0 || len(rules) != 1 {
+		t.Fatalf("alertRules() = %v, %v, want a single rule", rules, errs)
+	}
+	want := `histogram_quantile(0.99,
+  sum by (namespace, configuration_name, le) (rate(revision_app_request_latencies_bucket{namespace="test", container_name="queue-proxy"}[5m]))
+) > 1500`
+	if got := rules[0].Expr.String(); got != want {
+		t.Errorf("Expr = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,286 @@
+package servingcerts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	network "knative.dev/networking/pkg"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// EnableAnnotation is the annotation on the KnativeServing CR that enables the generation of
+	// serving certificates for cluster-local services. The certificates are only generated and
+	// their CA published, they're not served by Kourier's internal gateway yet.
+	EnableAnnotation = "serving.knative.openshift.io/enableServingCertificates"
+
+	// CABundleName is the name of the ConfigMap the service CA bundle is published to in every
+	// namespace containing cluster-local services.
+	CABundleName = "knative-serving-certs-ca-bundle"
+
+	// originatingServiceKey is set by the service CA operator on the Secrets it generates,
+	// naming the Service they were generated for.
+	originatingServiceKey = "service.beta.openshift.io/originating-service-name"
+
+	// servingCertKey is an annotation key to trigger Openshift to generate a serving certificate
+	// for the Service carrying the annotation.
+	// Docs: https://github.com/openshift/service-ca-operator
+	servingCertKey = "service.beta.openshift.io/serving-cert-secret-name"
+	// serviceCAKey is an annotation key to trigger Openshift to populate service-ca certs to the
+	// ConfigMap carrying the annotation.
+	serviceCAKey = "service.beta.openshift.io/inject-cabundle"
+
+	clusterLocal = "cluster-local"
+)
+
+var log = common.Log.WithName("servingcerts-controller")
+
+// clusterLocalPredicate selects cluster-local services. Updates removing the label are
+// selected too, so that the serving certificate can be cleaned up.
+var clusterLocalPredicate = predicate.Funcs{
+	CreateFunc:  func(e event.CreateEvent) bool { return isClusterLocal(e.Object) },
+	DeleteFunc:  func(e event.DeleteEvent) bool { return isClusterLocal(e.Object) },
+	GenericFunc: func(e event.GenericEvent) bool { return isClusterLocal(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return isClusterLocal(e.ObjectOld) || isClusterLocal(e.ObjectNew)
+	},
+}
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) *ReconcileServingCerts {
+	return &ReconcileServingCerts{client: mgr.GetClient(), reader: mgr.GetAPIReader(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *ReconcileServingCerts) error {
+	// Create a new controller
+	c, err := controller.New("servingcerts-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Only cluster-local services are of interest here, including those that just stopped
+	// being cluster-local. The manager's cache can't be restricted to some services, so they
+	// are watched through an informer of their own that only holds Knative's services.
+	kube, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(kube, 0, informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+		opts.LabelSelector = serving.RouteLabelKey
+	}))
+	services := factory.Core().V1().Services().Informer()
+	err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		factory.Start(ctx.Done())
+		<-ctx.Done()
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Informer{Informer: services}, &handler.EnqueueRequestForObject{}, clusterLocalPredicate)
+	if err != nil {
+		return err
+	}
+
+	// Resync all cluster-local services if the KnativeServing changes, as it might have toggled
+	// the feature.
+	enqueueServices := handler.MapFunc(func(obj client.Object) []reconcile.Request {
+		list := &corev1.ServiceList{}
+		if err := r.reader.List(context.Background(), list, client.MatchingLabels{network.VisibilityLabelKey: clusterLocal}); err != nil {
+			log.Error(err, "Failed to list cluster-local services")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(list.Items))
+		for _, svc := range list.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name},
+			})
+		}
+		return requests
+	})
+	return c.Watch(&source.Kind{Type: &servingv1alpha1.KnativeServing{}}, handler.EnqueueRequestsFromMapFunc(enqueueServices))
+}
+
+// blank assignment to verify that ReconcileServingCerts implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileServingCerts{}
+
+// ReconcileServingCerts reconciles serving certificates for cluster-local Knative services.
+type ReconcileServingCerts struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	// reader reads the services from the apiserver, so they aren't cached beyond the ones
+	// of Knative.
+	reader client.Reader
+	scheme *runtime.Scheme
+}
+
+// Reconcile annotates cluster-local services for service-ca certificate generation and publishes
+// the service CA to the namespace of the service.
+func (r *ReconcileServingCerts) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling serving certificates")
+
+	svc := &corev1.Service{}
+	if err := r.reader.Get(ctx, request.NamespacedName, svc); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	enabled, err := r.isEnabled(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if !enabled {
+		if err := r.deleteCABundle(ctx, svc.Namespace); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to delete CA bundle: %w", err)
+		}
+		return reconcile.Result{}, r.cleanup(ctx, svc)
+	}
+	if !isClusterLocal(svc) {
+		return reconcile.Result{}, r.cleanup(ctx, svc)
+	}
+
+	if err := r.reconcileCABundle(ctx, svc.Namespace); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to reconcile CA bundle: %w", err)
+	}
+	if svc.Annotations[servingCertKey] == SecretName(svc.Name) {
+		return reconcile.Result{}, nil
+	}
+
+	reqLogger.Info("Annotating service for serving certificate generation")
+	copy := svc.DeepCopy()
+	if copy.Annotations == nil {
+		copy.Annotations = make(map[string]string, 1)
+	}
+	copy.Annotations[servingCertKey] = SecretName(svc.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to annotate service %s: %w", svc.Name, err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// isEnabled returns true if any KnativeServing opted into serving certificates.
+func (r *ReconcileServingCerts) isEnabled(ctx context.Context) (bool, error) {
+	list := &servingv1alpha1.KnativeServingList{}
+	if err := r.client.List(ctx, list); err != nil {
+		return false, fmt.Errorf("failed to list KnativeServings: %w", err)
+	}
+	for _, ks := range list.Items {
+		if strings.EqualFold(ks.GetAnnotations()[EnableAnnotation], "true") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reconcileCABundle makes sure the namespace contains a ConfigMap the service CA gets injected into.
+func (r *ReconcileServingCerts) reconcileCABundle(ctx context.Context, ns string) error {
+	cm := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: ns, Name: CABundleName}, cm)
+	if errors.IsNotFound(err) {
+		log.Info("Creating CA bundle", "namespace", ns)
+		return r.client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        CABundleName,
+				Namespace:   ns,
+				Annotations: map[string]string{serviceCAKey: "true"},
+			},
+		})
+	} else if err != nil {
+		return err
+	}
+
+	if cm.Annotations[serviceCAKey] == "true" {
+		return nil
+	}
+	copy := cm.DeepCopy()
+	if copy.Annotations == nil {
+		copy.Annotations = make(map[string]string, 1)
+	}
+	copy.Annotations[serviceCAKey] = "true"
+	return r.client.Update(ctx, copy)
+}
+
+// deleteCABundle removes the CA bundle from the given namespace, if present.
+func (r *ReconcileServingCerts) deleteCABundle(ctx context.Context, ns string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CABundleName,
+			Namespace: ns,
+		},
+	}
+	if err := r.client.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// cleanup removes the serving certificate annotation from the given service, if it has been
+// set by us, along with the Secret the service CA operator generated for it.
+func (r *ReconcileServingCerts) cleanup(ctx context.Context, svc *corev1.Service) error {
+	if svc.Annotations[servingCertKey] != SecretName(svc.Name) {
+		return nil
+	}
+	log.Info("Removing serving certificate annotation", "namespace", svc.Namespace, "name", svc.Name)
+	copy := svc.DeepCopy()
+	delete(copy.Annotations, servingCertKey)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to remove annotation from service %s: %w", svc.Name, err)
+	}
+
+	secret := &corev1.Secret{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: svc.Namespace, Name: SecretName(svc.Name)}, secret)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get serving certificate of service %s: %w", svc.Name, err)
+	}
+	// Secrets not generated for the service are left alone.
+	if secret.Annotations[originatingServiceKey] != svc.Name {
+		return nil
+	}
+	log.Info("Deleting serving certificate", "namespace", svc.Namespace, "name", secret.Name)
+	if err := r.client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete serving certificate of service %s: %w", svc.Name, err)
+	}
+	return nil
+}
+
+// SecretName returns the name of the secret the serving certificate of the given service is
+// stored in.
+func SecretName(svcName string) string {
+	return svcName + "-serving-cert"
+}
+
+func isClusterLocal(obj client.Object) bool {
+	return obj.GetLabels()[network.VisibilityLabelKey] == clusterLocal
+}
@@ -0,0 +1,155 @@
+package servingcerts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	network "knative.dev/networking/pkg"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	defaultRequest = reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "hello"},
+	}
+)
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestServingCertsReconcile(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      bool
+		labels       map[string]string
+		annotations  map[string]string
+		wantSecret   string
+		wantCABundle bool
+		// wantCertDeleted is true if the generated serving certificate has to be removed.
+		wantCertDeleted bool
+	}{{
+		name:         "enabled, cluster-local",
+		enabled:      true,
+		labels:       map[string]string{network.VisibilityLabelKey: clusterLocal},
+		wantSecret:   SecretName("hello"),
+		wantCABundle: true,
+	}, {
+		name:    "enabled, not cluster-local",
+		enabled: true,
+	}, {
+		name:            "enabled, no longer cluster-local",
+		enabled:         true,
+		annotations:     map[string]string{servingCertKey: SecretName("hello")},
+		wantCertDeleted: true,
+	}, {
+		name:            "disabled, cluster-local",
+		labels:          map[string]string{network.VisibilityLabelKey: clusterLocal},
+		annotations:     map[string]string{servingCertKey: SecretName("hello")},
+		wantCertDeleted: true,
+	}, {
+		name:        "disabled, foreign annotation",
+		labels:      map[string]string{network.VisibilityLabelKey: clusterLocal},
+		annotations: map[string]string{servingCertKey: "foo"},
+		wantSecret:  "foo",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "knative-serving",
+					Namespace: "knative-serving",
+				},
+			}
+			if test.enabled {
+				ks.Annotations = map[string]string{EnableAnnotation: "true"}
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        defaultRequest.Name,
+					Namespace:   defaultRequest.Namespace,
+					Labels:      test.labels,
+					Annotations: test.annotations,
+				},
+			}
+			staleBundle := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      CABundleName,
+					Namespace: "default",
+				},
+			}
+			cert := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        SecretName(defaultRequest.Name),
+					Namespace:   defaultRequest.Namespace,
+					Annotations: map[string]string{originatingServiceKey: defaultRequest.Name},
+				},
+			}
+			cl := fake.NewClientBuilder().WithObjects(ks, svc, staleBundle, cert).Build()
+			r := &ReconcileServingCerts{client: cl, reader: cl, scheme: scheme.Scheme}
+
+			if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+
+			got := &corev1.Service{}
+			if err := cl.Get(context.Background(), defaultRequest.NamespacedName, got); err != nil {
+				t.Fatalf("get: (%v)", err)
+			}
+			if got.Annotations[servingCertKey] != test.wantSecret {
+				t.Errorf("Got secret annotation %q, want %q", got.Annotations[servingCertKey], test.wantSecret)
+			}
+
+			err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: cert.Name}, &corev1.Secret{})
+			if test.wantCertDeleted && !apierrors.IsNotFound(err) {
+				t.Errorf("Serving certificate should have been deleted, got: %v", err)
+			} else if !test.wantCertDeleted && err != nil {
+				t.Errorf("Serving certificate should have been kept, got: %v", err)
+			}
+
+			bundle := &corev1.ConfigMap{}
+			err = cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: CABundleName}, bundle)
+			if test.wantCABundle {
+				if err != nil {
+					t.Fatalf("get CA bundle: (%v)", err)
+				}
+				if bundle.Annotations[serviceCAKey] != "true" {
+					t.Errorf("CA bundle is missing the %q annotation", serviceCAKey)
+				}
+			} else if !test.enabled && !apierrors.IsNotFound(err) {
+				t.Errorf("CA bundle should have been deleted, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestClusterLocalPredicate(t *testing.T) {
+	local := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{network.VisibilityLabelKey: clusterLocal},
+	}}
+	public := &corev1.Service{}
+
+	if !clusterLocalPredicate.Update(event.UpdateEvent{ObjectOld: local, ObjectNew: public}) {
+		t.Error("Removing the cluster-local label should be selected")
+	}
+	if !clusterLocalPredicate.Update(event.UpdateEvent{ObjectOld: public, ObjectNew: local}) {
+		t.Error("Adding the cluster-local label should be selected")
+	}
+	if clusterLocalPredicate.Update(event.UpdateEvent{ObjectOld: public, ObjectNew: public}) {
+		t.Error("Updates of public services should not be selected")
+	}
+	if clusterLocalPredicate.Create(event.CreateEvent{Object: public}) {
+		t.Error("Public services should not be selected")
+	}
+}
@@ -0,0 +1,316 @@
+package sourcerbac
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ProvisionRBACEnvVar enables the provisioning of the RBAC of ApiServerSources if set to
+	// "true".
+	ProvisionRBACEnvVar = "SOURCES_PROVISION_RBAC"
+
+	// managedLabel marks the Roles and RoleBindings created by this controller. Others are
+	// never touched.
+	managedLabel = "sources.knative.openshift.io/rbac-managed"
+
+	// viewClusterRole bounds the permissions granted. It's what every viewer of a namespace may
+	// read, so creating a source grants no access to e.g. Secrets the creator might not have.
+	viewClusterRole = "view"
+)
+
+// adapterVerbs are the verbs the receive adapter of an ApiServerSource needs on the resources
+// it tracks.
+var adapterVerbs = []string{"get", "list", "watch"}
+
+var log = common.Log.WithName("sourcerbac-controller")
+
+// Add creates a new Controller and adds it to the Manager if enabled through the
+// ProvisionRBACEnvVar. The Manager will set fields on the Controller and Start it when the
+// Manager is Started.
+func Add(mgr manager.Manager) error {
+	if enabled, _ := strconv.ParseBool(os.Getenv(ProvisionRBACEnvVar)); !enabled {
+		return nil
+	}
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileSourceRBAC{client: mgr.GetClient(), scheme: mgr.GetScheme(), mapper: mgr.GetRESTMapper()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("sourcerbac-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// ApiServerSources are the primary resource. They're only served once Knative Eventing is
+	// installed.
+	if err := common.WatchWhenServed(mgr, c, &sourcesv1.ApiServerSource{}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	// Restore the created resources if they get changed or deleted.
+	isManaged := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[managedLabel] == "true"
+	})
+	for _, t := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		err = c.Watch(&source.Kind{Type: t}, &handler.EnqueueRequestForOwner{OwnerType: &sourcesv1.ApiServerSource{}, IsController: true}, isManaged)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blank assignment to verify that ReconcileSourceRBAC implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileSourceRBAC{}
+
+// ReconcileSourceRBAC grants the ServiceAccounts of ApiServerSources the permissions their
+// receive adapters need.
+type ReconcileSourceRBAC struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+	mapper meta.RESTMapper
+}
+
+// Reconcile creates a Role allowing to get, list and watch the resources tracked by the given
+// ApiServerSource and binds it to the source's ServiceAccount, so that its receive adapter
+// doesn't fail as forbidden. Only resources the "view" ClusterRole allows to read are granted,
+// others have to be granted manually. The Role and RoleBinding are owned by the source.
+func (r *ReconcileSourceRBAC) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	src := &sourcesv1.ApiServerSource{}
+	if err := r.client.Get(ctx, request.NamespacedName, src); err != nil {
+		if errors.IsNotFound(err) {
+			// The created resources are garbage collected along with the source.
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if src.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	view := &rbacv1.ClusterRole{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: viewClusterRole}, view); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get ClusterRole %q: %w", viewClusterRole, err)
+	}
+
+	var rules []rbacv1.PolicyRule
+	var skipped []string
+	for _, res := range src.Spec.Resources {
+		gvr, err := r.resourceFor(res.APIVersion, res.Kind)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s %s (%v)", res.APIVersion, res.Kind, err))
+			continue
+		}
+		if !allows(view.Rules, gvr.GroupResource()) {
+			skipped = append(skipped, fmt.Sprintf("%s %s (not readable by %q)", res.APIVersion, res.Kind, viewClusterRole))
+			continue
+		}
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{gvr.Group},
+			Resources: []string{gvr.Resource},
+			Verbs:     adapterVerbs,
+		})
+	}
+	if len(skipped) > 0 {
+		reqLogger.Info("Not granting access to resources, they have to be granted manually", "Resources", strings.Join(skipped, ", "))
+	}
+
+	return reconcile.Result{}, r.ensureRBAC(ctx, src, rules)
+}
+
+// resourceFor maps the given kind to its resource.
+func (r *ReconcileSourceRBAC) resourceFor(apiVersion, kind string) (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	mapping, err := r.mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	return mapping.Resource, nil
+}
+
+// allows returns whether the given rules allow all adapterVerbs on all objects of the given
+// resource.
+func allows(rules []rbacv1.PolicyRule, gr schema.GroupResource) bool {
+	for _, verb := range adapterVerbs {
+		allowed := false
+		for _, rule := range rules {
+			if len(rule.ResourceNames) == 0 && matches(rule.APIGroups, gr.Group) &&
+				matches(rule.Resources, gr.Resource) && matches(rule.Verbs, verb) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureRBAC creates or updates the Role with the given rules and its RoleBinding to the
+// ServiceAccount of the given source, or removes both if there are no rules.
+func (r *ReconcileSourceRBAC) ensureRBAC(ctx context.Context, src *sourcesv1.ApiServerSource, rules []rbacv1.PolicyRule) error {
+	role, binding := makeRole(src, rules), makeRoleBinding(src)
+	if len(rules) == 0 {
+		return r.remove(ctx, binding, role)
+	}
+	for _, obj := range []client.Object{role, binding} {
+		if err := controllerutil.SetControllerReference(src, obj, r.scheme); err != nil {
+			return err
+		}
+	}
+
+	existingRole := &rbacv1.Role{}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(role), existingRole); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get Role: %w", err)
+		}
+		log.Info("Creating Role", "Namespace", role.Namespace, "Name", role.Name)
+		if err := r.client.Create(ctx, role); err != nil {
+			return fmt.Errorf("failed to create Role: %w", err)
+		}
+	} else if !metav1.IsControlledBy(existingRole, src) {
+		return fmt.Errorf("Role %s/%s exists already and is not managed by the operator", role.Namespace, role.Name)
+	} else if !equality.Semantic.DeepEqual(existingRole.Rules, role.Rules) {
+		copy := existingRole.DeepCopy()
+		copy.Rules = role.Rules
+		log.Info("Updating Role", "Namespace", role.Namespace, "Name", role.Name)
+		if err := r.client.Update(ctx, copy); err != nil {
+			return fmt.Errorf("failed to update Role: %w", err)
+		}
+	}
+
+	existingBinding := &rbacv1.RoleBinding{}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(binding), existingBinding); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get RoleBinding: %w", err)
+		}
+		log.Info("Creating RoleBinding", "Namespace", binding.Namespace, "Name", binding.Name)
+		if err := r.client.Create(ctx, binding); err != nil {
+			return fmt.Errorf("failed to create RoleBinding: %w", err)
+		}
+		return nil
+	}
+	if !metav1.IsControlledBy(existingBinding, src) {
+		return fmt.Errorf("RoleBinding %s/%s exists already and is not managed by the operator", binding.Namespace, binding.Name)
+	}
+	if equality.Semantic.DeepEqual(existingBinding.Subjects, binding.Subjects) {
+		return nil
+	}
+	copy := existingBinding.DeepCopy()
+	copy.Subjects = binding.Subjects
+	log.Info("Updating RoleBinding", "Namespace", binding.Namespace, "Name", binding.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to update RoleBinding: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the given objects if they're managed by the operator.
+func (r *ReconcileSourceRBAC) remove(ctx context.Context, objs ...client.Object) error {
+	for _, desired := range objs {
+		obj := desired.DeepCopyObject().(client.Object)
+		err := r.client.Get(ctx, client.ObjectKeyFromObject(desired), obj)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get %T: %w", obj, err)
+		}
+		if obj.GetLabels()[managedLabel] != "true" {
+			continue
+		}
+		log.Info(fmt.Sprintf("Deleting %T", obj), "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		if err := r.client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T: %w", obj, err)
+		}
+	}
+	return nil
+}
+
+// rbacName is the name of the Role and RoleBinding of the given source.
+func rbacName(src *sourcesv1.ApiServerSource) string {
+	return "apiserversource-" + src.Name
+}
+
+func serviceAccountName(src *sourcesv1.ApiServerSource) string {
+	if sa := src.Spec.ServiceAccountName; sa != "" {
+		return sa
+	}
+	return "default"
+}
+
+func makeRole(src *sourcesv1.ApiServerSource, rules []rbacv1.PolicyRule) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName(src),
+			Namespace: src.Namespace,
+			Labels:    map[string]string{managedLabel: "true"},
+		},
+		Rules: rules,
+	}
+}
+
+func makeRoleBinding(src *sourcesv1.ApiServerSource) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rbacName(src),
+			Namespace: src.Namespace,
+			Labels:    map[string]string{managedLabel: "true"},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     rbacName(src),
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      serviceAccountName(src),
+			Namespace: src.Namespace,
+		}},
+	}
+}
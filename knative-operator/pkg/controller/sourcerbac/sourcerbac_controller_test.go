@@ -0,0 +1,171 @@
+package sourcerbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "events"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func newMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Event"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func TestSourceRBACReconcile(t *testing.T) {
+	src := &sourcesv1.ApiServerSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "events", Namespace: "test", UID: "events-uid"},
+		Spec: sourcesv1.ApiServerSourceSpec{
+			Resources: []sourcesv1.APIVersionKindSelector{
+				{APIVersion: "v1", Kind: "Event"},
+				{APIVersion: "apps/v1", Kind: "Deployment"},
+				// Not readable by viewers.
+				{APIVersion: "v1", Kind: "Secret"},
+				// Unknown.
+				{APIVersion: "example.com/v1", Kind: "Foo"},
+			},
+			ServiceAccountName: "events-sa",
+		},
+	}
+	view := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "view"},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"events", "pods"},
+			Verbs:     []string{"get", "list", "watch"},
+		}, {
+			APIGroups: []string{"apps"},
+			Resources: []string{"*"},
+			Verbs:     []string{"get", "list", "watch"},
+		}, {
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{"public"},
+			Verbs:         []string{"get", "list", "watch"},
+		}},
+	}
+
+	cl := fake.NewClientBuilder().WithObjects(src, view).Build()
+	r := &ReconcileSourceRBAC{client: cl, scheme: scheme.Scheme, mapper: newMapper()}
+
+	// Reconciling twice must not change anything the second time.
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+			t.Fatalf("Reconcile() = %v", err)
+		}
+	}
+
+	key := client.ObjectKey{Namespace: "test", Name: "apiserversource-events"}
+	role := &rbacv1.Role{}
+	if err := cl.Get(context.Background(), key, role); err != nil {
+		t.Fatal("Failed to get Role:", err)
+	}
+	wantRules := []rbacv1.PolicyRule{{
+		APIGroups: []string{""},
+		Resources: []string{"events"},
+		Verbs:     []string{"get", "list", "watch"},
+	}, {
+		APIGroups: []string{"apps"},
+		Resources: []string{"deployments"},
+		Verbs:     []string{"get", "list", "watch"},
+	}}
+	if !cmp.Equal(role.Rules, wantRules) {
+		t.Errorf("Rules = %v, want: %v, diff(-want,+got):\n%s", role.Rules, wantRules, cmp.Diff(wantRules, role.Rules))
+	}
+	if !metav1.IsControlledBy(role, src) {
+		t.Error("Role is not owned by the source")
+	}
+
+	binding := &rbacv1.RoleBinding{}
+	if err := cl.Get(context.Background(), key, binding); err != nil {
+		t.Fatal("Failed to get RoleBinding:", err)
+	}
+	wantSubjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "events-sa", Namespace: "test"}}
+	if !cmp.Equal(binding.Subjects, wantSubjects) {
+		t.Errorf("Subjects = %v, want: %v", binding.Subjects, wantSubjects)
+	}
+	if binding.RoleRef.Name != role.Name {
+		t.Errorf("RoleRef = %v, want the Role", binding.RoleRef)
+	}
+
+	// Switching the ServiceAccount updates the binding.
+	if err := cl.Get(context.Background(), defaultRequest.NamespacedName, src); err != nil {
+		t.Fatal(err)
+	}
+	src.Spec.ServiceAccountName = ""
+	if err := cl.Update(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if err := cl.Get(context.Background(), key, binding); err != nil {
+		t.Fatal("Failed to get RoleBinding:", err)
+	}
+	if got := binding.Subjects[0].Name; got != "default" {
+		t.Errorf("Subject = %q, want the default ServiceAccount", got)
+	}
+
+	// Tracking only resources that can't be granted removes the RBAC.
+	src.Spec.Resources = []sourcesv1.APIVersionKindSelector{{APIVersion: "v1", Kind: "Secret"}}
+	if err := cl.Update(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	for _, obj := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		if err := cl.Get(context.Background(), key, obj); !apierrors.IsNotFound(err) {
+			t.Errorf("Get(%T) = %v, want NotFound", obj, err)
+		}
+	}
+}
+
+func TestSourceRBACKeepsUnmanaged(t *testing.T) {
+	src := &sourcesv1.ApiServerSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "events", Namespace: "test", UID: "events-uid"},
+		Spec: sourcesv1.ApiServerSourceSpec{
+			Resources: []sourcesv1.APIVersionKindSelector{{APIVersion: "v1", Kind: "Event"}},
+		},
+	}
+	view := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "view"},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"*"},
+			Resources: []string{"*"},
+			Verbs:     []string{"*"},
+		}},
+	}
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "apiserversource-events", Namespace: "test"}}
+
+	cl := fake.NewClientBuilder().WithObjects(src, view, role, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "test"},
+	}).Build()
+	r := &ReconcileSourceRBAC{client: cl, scheme: scheme.Scheme, mapper: newMapper()}
+
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err == nil {
+		t.Error("Reconcile() = nil, want an error for the Role not managed by the operator")
+	}
+}
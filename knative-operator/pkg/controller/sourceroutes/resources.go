@@ -0,0 +1,189 @@
+package sourceroutes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	routev1 "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	proxyContainerName = "oauth-proxy"
+	proxyImageEnvVar   = "IMAGE_OAUTH_PROXY"
+	proxyPort          = 8443
+
+	tlsVolumeName      = "oauth-proxy-tls"
+	tlsMountPath       = "/etc/tls/private"
+	cookieVolumeName   = "oauth-proxy-cookie"
+	cookieMountPath    = "/etc/proxy/secrets"
+	htpasswdVolumeName = "oauth-proxy-htpasswd"
+	htpasswdMountPath  = "/etc/proxy/htpasswd"
+
+	// servingCertAnnotation makes the service CA operator issue a certificate for the Service.
+	servingCertAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+	// oauthRedirectAnnotationPrefix allows a ServiceAccount to act as OAuth client, redirecting to the given Route.
+	oauthRedirectAnnotationPrefix = "serviceaccounts.openshift.io/oauth-redirectreference."
+)
+
+// proxyName is the name of the Service, Route and cookie Secret fronting the given Deployment.
+func proxyName(dep *appsv1.Deployment) string {
+	return dep.Name + "-auth"
+}
+
+// tlsSecretName is the name of the Secret holding the serving certificate of the proxy.
+func tlsSecretName(dep *appsv1.Deployment) string {
+	return proxyName(dep) + "-tls"
+}
+
+func serviceAccountName(dep *appsv1.Deployment) string {
+	if sa := dep.Spec.Template.Spec.ServiceAccountName; sa != "" {
+		return sa
+	}
+	return "default"
+}
+
+// makeProxyContainer creates the oauth-proxy sidecar guarding the given port of the Deployment.
+// Users have to be able to get the Deployment to pass. Callers that can't go through the
+// OpenShift login, like webhooks of external services, can use basic auth if an htpasswd
+// Secret is given.
+func makeProxyContainer(dep *appsv1.Deployment, port int, htpasswdSecret string) corev1.Container {
+	sar := fmt.Sprintf(`{"namespace":%q,"group":"apps","resource":"deployments","name":%q,"verb":"get"}`, dep.Namespace, dep.Name)
+	args := []string{
+		fmt.Sprintf("--https-address=:%d", proxyPort),
+		"--provider=openshift",
+		"--openshift-service-account=" + serviceAccountName(dep),
+		"--openshift-sar=" + sar,
+		fmt.Sprintf("--upstream=http://localhost:%d", port),
+		"--tls-cert=" + filepath.Join(tlsMountPath, "tls.crt"),
+		"--tls-key=" + filepath.Join(tlsMountPath, "tls.key"),
+		"--cookie-secret-file=" + filepath.Join(cookieMountPath, "session_secret"),
+		"--pass-basic-auth=false",
+	}
+	mounts := []corev1.VolumeMount{{
+		Name:      tlsVolumeName,
+		MountPath: tlsMountPath,
+		ReadOnly:  true,
+	}, {
+		Name:      cookieVolumeName,
+		MountPath: cookieMountPath,
+		ReadOnly:  true,
+	}}
+	if htpasswdSecret != "" {
+		args = append(args, "--htpasswd-file="+filepath.Join(htpasswdMountPath, "auth"))
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      htpasswdVolumeName,
+			MountPath: htpasswdMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return corev1.Container{
+		Name:  proxyContainerName,
+		Image: os.Getenv(proxyImageEnvVar),
+		Args:  args,
+		Ports: []corev1.ContainerPort{{
+			Name:          "https",
+			ContainerPort: proxyPort,
+			Protocol:      corev1.ProtocolTCP,
+		}},
+		VolumeMounts: mounts,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("20Mi"),
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+			},
+		},
+	}
+}
+
+// makeProxyVolumes creates the volumes mounted by the oauth-proxy sidecar.
+func makeProxyVolumes(dep *appsv1.Deployment, htpasswdSecret string) []corev1.Volume {
+	volumes := []corev1.Volume{{
+		Name: tlsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: tlsSecretName(dep)},
+		},
+	}, {
+		Name: cookieVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: proxyName(dep)},
+		},
+	}}
+	if htpasswdSecret != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: htpasswdVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: htpasswdSecret},
+			},
+		})
+	}
+	return volumes
+}
+
+// isProxyVolume tells whether the volume is one of those added along with the sidecar.
+func isProxyVolume(name string) bool {
+	return name == tlsVolumeName || name == cookieVolumeName || name == htpasswdVolumeName
+}
+
+// makeService creates the Service exposing the oauth-proxy sidecar, with a serving
+// certificate issued by the service CA.
+func makeService(dep *appsv1.Deployment) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        proxyName(dep),
+			Namespace:   dep.Namespace,
+			Labels:      map[string]string{managedLabel: "true"},
+			Annotations: map[string]string{servingCertAnnotation: tlsSecretName(dep)},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: dep.Spec.Selector.MatchLabels,
+			Ports: []corev1.ServicePort{{
+				Name:       "https",
+				Port:       443,
+				TargetPort: intstr.FromInt(proxyPort),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+}
+
+// makeRoute creates the Route exposing the Service of the oauth-proxy outside of the cluster.
+// TLS is re-encrypted towards the proxy.
+func makeRoute(dep *appsv1.Deployment) *routev1.Route {
+	return &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyName(dep),
+			Namespace: dep.Namespace,
+			Labels:    map[string]string{managedLabel: "true"},
+		},
+		Spec: routev1.RouteSpec{
+			To: routev1.RouteTargetReference{
+				Kind: "Service",
+				Name: proxyName(dep),
+			},
+			Port: &routev1.RoutePort{TargetPort: intstr.FromString("https")},
+			TLS: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationReencrypt,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			},
+		},
+	}
+}
+
+// makeCookieSecret creates the Secret the oauth-proxy encrypts its session cookies with.
+func makeCookieSecret(dep *appsv1.Deployment, secret string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyName(dep),
+			Namespace: dep.Namespace,
+			Labels:    map[string]string{managedLabel: "true"},
+		},
+		StringData: map[string]string{"session_secret": secret},
+	}
+}
@@ -0,0 +1,349 @@
+package sourceroutes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	routev1 "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// AuthenticatedRouteAnnotation is the Deployment annotation exposing the given container port
+	// of a source's receive adapter through a Route, authenticated by an oauth-proxy sidecar.
+	AuthenticatedRouteAnnotation = "sources.knative.openshift.io/authenticated-route"
+
+	// HtpasswdSecretAnnotation names a Secret with an htpasswd file in its "auth" key. Its users
+	// can authenticate with basic auth, e.g. in the webhook URLs given to external services.
+	HtpasswdSecretAnnotation = "sources.knative.openshift.io/htpasswd-secret"
+
+	// injectedAnnotation marks the Deployments the sidecar has been injected into, to remove it
+	// again once the route is no longer requested.
+	injectedAnnotation = "sources.knative.openshift.io/oauth-proxy-injected"
+
+	// managedLabel marks the resources created by this controller. Resources without it
+	// are never touched.
+	managedLabel = "sources.knative.openshift.io/authenticated-route-managed"
+)
+
+var log = common.Log.WithName("sourceroutes-controller")
+
+// Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileSourceRoutes{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("sourceroutes-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Deployments are the primary resource. Those the sidecar has been injected into stay of
+	// interest, as the removal of the annotation has to be noticed too.
+	err = c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForObject{}, predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		annotations := obj.GetAnnotations()
+		return annotations[AuthenticatedRouteAnnotation] != "" || annotations[injectedAnnotation] == "true"
+	}))
+	if err != nil {
+		return err
+	}
+
+	// Restore the created resources if they get changed or deleted.
+	isManaged := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()[managedLabel] == "true"
+	})
+	for _, t := range []client.Object{&corev1.Service{}, &corev1.Secret{}, &routev1.Route{}} {
+		err = c.Watch(&source.Kind{Type: t}, &handler.EnqueueRequestForOwner{OwnerType: &appsv1.Deployment{}, IsController: true}, isManaged)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blank assignment to verify that ReconcileSourceRoutes implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileSourceRoutes{}
+
+// ReconcileSourceRoutes exposes annotated Deployments through authenticated Routes.
+type ReconcileSourceRoutes struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile injects the oauth-proxy sidecar into the given Deployment and creates the Service,
+// Route and cookie Secret in front of it if it's annotated, and removes all of them once the
+// annotation is removed. The created resources are owned by the Deployment.
+//
+// Source controllers that reset the pod template of their receive adapters will drop the
+// sidecar, so this is meant for adapters whose template is left alone, like ContainerSources
+// and SinkBinding subjects.
+func (r *ReconcileSourceRoutes) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	dep := &appsv1.Deployment{}
+	if err := r.client.Get(ctx, request.NamespacedName, dep); err != nil {
+		if errors.IsNotFound(err) {
+			// The created resources are garbage collected along with the Deployment.
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if dep.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	value := dep.Annotations[AuthenticatedRouteAnnotation]
+	if value == "" {
+		return reconcile.Result{}, r.remove(ctx, dep)
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 || port == proxyPort {
+		reqLogger.Info(fmt.Sprintf("Invalid %s %q, must be a port other than %d", AuthenticatedRouteAnnotation, value, proxyPort))
+		return reconcile.Result{}, nil
+	}
+	if dep.Spec.Selector == nil || len(dep.Spec.Selector.MatchLabels) == 0 {
+		reqLogger.Info("Deployment doesn't select its pods by labels, skipping")
+		return reconcile.Result{}, nil
+	}
+	htpasswdSecret := dep.Annotations[HtpasswdSecretAnnotation]
+
+	stages := []func(context.Context, *appsv1.Deployment) error{
+		r.ensureCookieSecret,
+		r.ensureService,
+		r.ensureRoute,
+		r.ensureOAuthRedirect,
+	}
+	for _, stage := range stages {
+		if err := stage(ctx, dep); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{}, r.injectProxy(ctx, dep, port, htpasswdSecret)
+}
+
+// ensureCookieSecret creates the cookie Secret once. Its value is random and never updated,
+// so sessions survive restarts of the proxy.
+func (r *ReconcileSourceRoutes) ensureCookieSecret(ctx context.Context, dep *appsv1.Deployment) error {
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: dep.Namespace, Name: proxyName(dep)}, &corev1.Secret{})
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate cookie secret: %w", err)
+	}
+	secret := makeCookieSecret(dep, hex.EncodeToString(b))
+	if err := controllerutil.SetControllerReference(dep, secret, r.scheme); err != nil {
+		return err
+	}
+	log.Info("Creating cookie Secret", "Namespace", dep.Namespace, "Name", secret.Name)
+	if err := r.client.Create(ctx, secret); err != nil {
+		return fmt.Errorf("failed to create cookie Secret: %w", err)
+	}
+	return nil
+}
+
+func (r *ReconcileSourceRoutes) ensureService(ctx context.Context, dep *appsv1.Deployment) error {
+	desired := makeService(dep)
+	if err := controllerutil.SetControllerReference(dep, desired, r.scheme); err != nil {
+		return err
+	}
+
+	existing := &corev1.Service{}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get Service: %w", err)
+		}
+		log.Info("Creating Service", "Namespace", dep.Namespace, "Name", desired.Name)
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create Service: %w", err)
+		}
+		return nil
+	}
+	if !metav1.IsControlledBy(existing, dep) {
+		return fmt.Errorf("Service %s/%s exists already and is not managed by the operator", existing.Namespace, existing.Name)
+	}
+	if equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) &&
+		equality.Semantic.DeepEqual(existing.Spec.Ports, desired.Spec.Ports) &&
+		existing.Annotations[servingCertAnnotation] == desired.Annotations[servingCertAnnotation] {
+		return nil
+	}
+	copy := existing.DeepCopy()
+	copy.Spec.Selector = desired.Spec.Selector
+	copy.Spec.Ports = desired.Spec.Ports
+	if copy.Annotations == nil {
+		copy.Annotations = map[string]string{}
+	}
+	copy.Annotations[servingCertAnnotation] = desired.Annotations[servingCertAnnotation]
+	log.Info("Updating Service", "Namespace", dep.Namespace, "Name", desired.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to update Service: %w", err)
+	}
+	return nil
+}
+
+func (r *ReconcileSourceRoutes) ensureRoute(ctx context.Context, dep *appsv1.Deployment) error {
+	desired := makeRoute(dep)
+	if err := controllerutil.SetControllerReference(dep, desired, r.scheme); err != nil {
+		return err
+	}
+
+	existing := &routev1.Route{}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get Route: %w", err)
+		}
+		log.Info("Creating Route", "Namespace", dep.Namespace, "Name", desired.Name)
+		if err := r.client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create Route: %w", err)
+		}
+		return nil
+	}
+	if !metav1.IsControlledBy(existing, dep) {
+		return fmt.Errorf("Route %s/%s exists already and is not managed by the operator", existing.Namespace, existing.Name)
+	}
+	// The host is generated by the router and left alone.
+	if equality.Semantic.DeepEqual(existing.Spec.To, desired.Spec.To) &&
+		equality.Semantic.DeepEqual(existing.Spec.Port, desired.Spec.Port) &&
+		equality.Semantic.DeepEqual(existing.Spec.TLS, desired.Spec.TLS) {
+		return nil
+	}
+	copy := existing.DeepCopy()
+	copy.Spec.To = desired.Spec.To
+	copy.Spec.Port = desired.Spec.Port
+	copy.Spec.TLS = desired.Spec.TLS
+	log.Info("Updating Route", "Namespace", dep.Namespace, "Name", desired.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to update Route: %w", err)
+	}
+	return nil
+}
+
+// ensureOAuthRedirect allows the ServiceAccount of the Deployment to act as OAuth client for
+// the OpenShift login, redirecting back to the Route.
+func (r *ReconcileSourceRoutes) ensureOAuthRedirect(ctx context.Context, dep *appsv1.Deployment) error {
+	sa := &corev1.ServiceAccount{}
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: dep.Namespace, Name: serviceAccountName(dep)}, sa); err != nil {
+		return fmt.Errorf("failed to get ServiceAccount: %w", err)
+	}
+	key := oauthRedirectAnnotationPrefix + proxyName(dep)
+	value := fmt.Sprintf(`{"kind":"OAuthRedirectReference","apiVersion":"v1","reference":{"kind":"Route","name":%q}}`, proxyName(dep))
+	if sa.Annotations[key] == value {
+		return nil
+	}
+	copy := sa.DeepCopy()
+	if copy.Annotations == nil {
+		copy.Annotations = map[string]string{}
+	}
+	copy.Annotations[key] = value
+	log.Info("Annotating ServiceAccount with OAuth redirect", "Namespace", sa.Namespace, "Name", sa.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to update ServiceAccount: %w", err)
+	}
+	return nil
+}
+
+// injectProxy adds or updates the oauth-proxy sidecar and its volumes.
+func (r *ReconcileSourceRoutes) injectProxy(ctx context.Context, dep *appsv1.Deployment, port int, htpasswdSecret string) error {
+	copy := dep.DeepCopy()
+	spec := &copy.Spec.Template.Spec
+	spec.Containers = append(withoutProxyContainer(spec.Containers), makeProxyContainer(dep, port, htpasswdSecret))
+	spec.Volumes = append(withoutProxyVolumes(spec.Volumes), makeProxyVolumes(dep, htpasswdSecret)...)
+	if copy.Annotations == nil {
+		copy.Annotations = map[string]string{}
+	}
+	copy.Annotations[injectedAnnotation] = "true"
+
+	if equality.Semantic.DeepEqual(dep.Spec.Template.Spec, copy.Spec.Template.Spec) && dep.Annotations[injectedAnnotation] == "true" {
+		return nil
+	}
+	log.Info("Injecting oauth-proxy", "Namespace", dep.Namespace, "Name", dep.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to inject oauth-proxy: %w", err)
+	}
+	return nil
+}
+
+// remove removes the sidecar from the Deployment and deletes the created resources.
+func (r *ReconcileSourceRoutes) remove(ctx context.Context, dep *appsv1.Deployment) error {
+	if dep.Annotations[injectedAnnotation] != "true" {
+		return nil
+	}
+
+	for _, obj := range []client.Object{&routev1.Route{}, &corev1.Service{}, &corev1.Secret{}} {
+		err := r.client.Get(ctx, client.ObjectKey{Namespace: dep.Namespace, Name: proxyName(dep)}, obj)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get %T: %w", obj, err)
+		}
+		if obj.GetLabels()[managedLabel] != "true" || !metav1.IsControlledBy(obj, dep) {
+			continue
+		}
+		log.Info(fmt.Sprintf("Deleting %T", obj), "Namespace", dep.Namespace, "Name", obj.GetName())
+		if err := r.client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T: %w", obj, err)
+		}
+	}
+
+	copy := dep.DeepCopy()
+	spec := &copy.Spec.Template.Spec
+	spec.Containers = withoutProxyContainer(spec.Containers)
+	spec.Volumes = withoutProxyVolumes(spec.Volumes)
+	delete(copy.Annotations, injectedAnnotation)
+	log.Info("Removing oauth-proxy", "Namespace", dep.Namespace, "Name", dep.Name)
+	if err := r.client.Update(ctx, copy); err != nil {
+		return fmt.Errorf("failed to remove oauth-proxy: %w", err)
+	}
+	return nil
+}
+
+func withoutProxyContainer(containers []corev1.Container) []corev1.Container {
+	filtered := make([]corev1.Container, 0, len(containers))
+	for _, c := range containers {
+		if c.Name != proxyContainerName {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+func withoutProxyVolumes(volumes []corev1.Volume) []corev1.Volume {
+	filtered := make([]corev1.Volume, 0, len(volumes))
+	for _, v := range volumes {
+		if !isProxyVolume(v.Name) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
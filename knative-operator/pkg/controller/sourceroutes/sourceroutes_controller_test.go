@@ -0,0 +1,140 @@
+package sourceroutes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	routev1 "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var defaultRequest = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "adapter"}}
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestSourceRoutesReconcile(t *testing.T) {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "adapter",
+			Namespace: "test",
+			UID:       "adapter-uid",
+			Annotations: map[string]string{
+				AuthenticatedRouteAnnotation: "8080",
+				HtpasswdSecretAnnotation:     "webhook-users",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "adapter"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "adapter",
+					Containers:         []corev1.Container{{Name: "receive-adapter"}},
+				},
+			},
+		},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "adapter", Namespace: "test"}}
+
+	cl := fake.NewClientBuilder().WithObjects(dep, sa).Build()
+	r := &ReconcileSourceRoutes{client: cl, scheme: scheme.Scheme}
+
+	// Reconciling twice must not change anything the second time.
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+			t.Fatalf("Reconcile() = %v", err)
+		}
+	}
+
+	got := &appsv1.Deployment{}
+	if err := cl.Get(context.Background(), defaultRequest.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	containers := got.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[1].Name != proxyContainerName {
+		t.Fatalf("Containers = %v, want the receive adapter and the oauth-proxy", containers)
+	}
+	if len(got.Spec.Template.Spec.Volumes) != 3 {
+		t.Errorf("Volumes = %v, want TLS, cookie and htpasswd volumes", got.Spec.Template.Spec.Volumes)
+	}
+	if got.Annotations[injectedAnnotation] != "true" {
+		t.Error("Deployment is not marked as injected")
+	}
+
+	svc := &corev1.Service{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "adapter-auth"}, svc); err != nil {
+		t.Fatal("Failed to get Service:", err)
+	}
+	if svc.Annotations[servingCertAnnotation] != "adapter-auth-tls" {
+		t.Errorf("Service annotations = %v, want a serving certificate", svc.Annotations)
+	}
+	route := &routev1.Route{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "adapter-auth"}, route); err != nil {
+		t.Fatal("Failed to get Route:", err)
+	}
+	if route.Spec.TLS.Termination != routev1.TLSTerminationReencrypt {
+		t.Errorf("Route termination = %v, want %v", route.Spec.TLS.Termination, routev1.TLSTerminationReencrypt)
+	}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "adapter-auth"}, &corev1.Secret{}); err != nil {
+		t.Fatal("Failed to get cookie Secret:", err)
+	}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(sa), sa); err != nil {
+		t.Fatal(err)
+	}
+	if sa.Annotations[oauthRedirectAnnotationPrefix+"adapter-auth"] == "" {
+		t.Error("ServiceAccount is not allowed to redirect to the Route")
+	}
+
+	// Removing the annotation removes everything again.
+	delete(got.Annotations, AuthenticatedRouteAnnotation)
+	if err := cl.Update(context.Background(), got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	got = &appsv1.Deployment{}
+	if err := cl.Get(context.Background(), defaultRequest.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Spec.Template.Spec.Containers) != 1 || len(got.Spec.Template.Spec.Volumes) != 0 {
+		t.Errorf("Pod spec = %v, want the oauth-proxy removed", got.Spec.Template.Spec)
+	}
+	for _, obj := range []client.Object{&routev1.Route{}, &corev1.Service{}, &corev1.Secret{}} {
+		if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "adapter-auth"}, obj); !apierrors.IsNotFound(err) {
+			t.Errorf("Get %T = %v, want not found", obj, err)
+		}
+	}
+}
+
+func TestSourceRoutesInvalidPort(t *testing.T) {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "adapter",
+			Namespace:   "test",
+			Annotations: map[string]string{AuthenticatedRouteAnnotation: "http"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "adapter"}},
+		},
+	}
+	cl := fake.NewClientBuilder().WithObjects(dep).Build()
+	r := &ReconcileSourceRoutes{client: cl, scheme: scheme.Scheme}
+
+	if _, err := r.Reconcile(context.Background(), defaultRequest); err != nil {
+		t.Fatalf("Reconcile() = %v", err)
+	}
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "adapter-auth"}, &routev1.Route{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Get Route = %v, want not found", err)
+	}
+}
@@ -0,0 +1,118 @@
+package dependency
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// Check is a named check of a dependency of the operator.
+type Check struct {
+	Name string
+	// Required checks fail the readiness of the operator. The failures of other checks are
+	// only logged and reported through metrics.
+	Required bool
+	Checker  healthz.Checker
+}
+
+// Adder adds readiness checks, e.g. a manager.Manager.
+type Adder interface {
+	AddReadyzCheck(name string, check healthz.Checker) error
+}
+
+// AddChecks adds the given checks to the readiness checks served on /readyz. The result of
+// every run of a check is reported through knative_operator_dependency_up.
+func AddChecks(adder Adder, checks ...Check) error {
+	for _, check := range checks {
+		if err := adder.AddReadyzCheck(check.Name, observe(check)); err != nil {
+			return fmt.Errorf("failed to add check %s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+// observe reports the result of the given check through metrics and only fails if the check
+// is required. Failures of checks that aren't required are logged when the check starts or
+// stops failing, rather than on every probe.
+func observe(check Check) healthz.Checker {
+	var failing transitions
+	return func(req *http.Request) error {
+		err := check.Checker(req)
+		changed := failing.set(err != nil)
+		if err == nil {
+			monitoring.DependencyUp.WithLabelValues(check.Name).Set(1)
+			if changed && !check.Required {
+				common.Log.Info("Dependency check recovered", "check", check.Name)
+			}
+			return nil
+		}
+		monitoring.DependencyUp.WithLabelValues(check.Name).Set(0)
+		if !check.Required {
+			if changed {
+				common.Log.Info("Dependency check failed", "check", check.Name, "error", err.Error())
+			}
+			return nil
+		}
+		return err
+	}
+}
+
+// transitions tracks a condition that is checked concurrently, initially false.
+type transitions struct {
+	mu    sync.Mutex
+	value bool
+}
+
+// set sets the condition and returns whether it changed.
+func (t *transitions) set(value bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	changed := t.value != value
+	t.value = value
+	return changed
+}
+
+// APIGroupVersion checks that the given group version, e.g. route.openshift.io/v1, is served.
+func APIGroupVersion(client discovery.DiscoveryInterface, groupVersion string) healthz.Checker {
+	return func(*http.Request) error {
+		if _, err := client.ServerResourcesForGroupVersion(groupVersion); err != nil {
+			return fmt.Errorf("%s is not served: %w", groupVersion, err)
+		}
+		return nil
+	}
+}
+
+// Certificate checks that the PEM encoded certificate in the given file is currently valid.
+func Certificate(certFile string) healthz.Checker {
+	return func(*http.Request) error {
+		raw, err := os.ReadFile(certFile)
+		if err != nil {
+			return fmt.Errorf("failed to read certificate: %w", err)
+		}
+		block, _ := pem.Decode(raw)
+		if block == nil {
+			return fmt.Errorf("no PEM encoded certificate in %s", certFile)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		now := time.Now()
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("certificate is not valid before %s", cert.NotBefore.Format(time.RFC3339))
+		}
+		if now.After(cert.NotAfter) {
+			return fmt.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
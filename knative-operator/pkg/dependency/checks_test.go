@@ -0,0 +1,181 @@
+package dependency
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/monitoring"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+type checks map[string]healthz.Checker
+
+func (c checks) AddReadyzCheck(name string, check healthz.Checker) error {
+	c[name] = check
+	return nil
+}
+
+func TestAddChecks(t *testing.T) {
+	failing := func(*http.Request) error { return errors.New("failing") }
+	added := checks{}
+	if err := AddChecks(added,
+		Check{Name: "required", Required: true, Checker: failing},
+		Check{Name: "optional", Checker: failing},
+		Check{Name: "passing", Required: true, Checker: healthz.Ping},
+	); err != nil {
+		t.Fatal("AddChecks() =", err)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		wantUp  float64
+	}{{
+		name:    "required",
+		wantErr: true,
+	}, {
+		name: "optional",
+	}, {
+		name:   "passing",
+		wantUp: 1,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := added[test.name](nil); (err != nil) != test.wantErr {
+				t.Errorf("Check() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got := gatherGauge(t, test.name); got != test.wantUp {
+				t.Errorf("knative_operator_dependency_up = %v, want %v", got, test.wantUp)
+			}
+		})
+	}
+}
+
+func gatherGauge(t *testing.T, dependency string) float64 {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(monitoring.DependencyUp)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("Failed to gather metrics:", err)
+	}
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "dependency" && l.GetValue() == dependency {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("No metric of dependency %s", dependency)
+	return 0
+}
+
+func TestTransitions(t *testing.T) {
+	var failing transitions
+	for _, step := range []struct {
+		value   bool
+		changed bool
+	}{
+		{value: false, changed: false},
+		{value: true, changed: true},
+		{value: true, changed: false},
+		{value: false, changed: true},
+	} {
+		if got := failing.set(step.value); got != step.changed {
+			t.Errorf("set(%v) = %v, want %v", step.value, got, step.changed)
+		}
+	}
+}
+
+func TestAPIGroupVersion(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{
+		Resources: []*metav1.APIResourceList{{GroupVersion: "route.openshift.io/v1"}},
+	}}
+
+	if err := APIGroupVersion(client, "route.openshift.io/v1")(nil); err != nil {
+		t.Error("Check() =", err)
+	}
+	if err := APIGroupVersion(client, "monitoring.coreos.com/v1")(nil); err == nil {
+		t.Error("Check() = nil, want an error for the group version not served")
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		wantErr   bool
+	}{{
+		name:      "valid",
+		notBefore: now.Add(-time.Hour),
+		notAfter:  now.Add(time.Hour),
+	}, {
+		name:      "expired",
+		notBefore: now.Add(-2 * time.Hour),
+		notAfter:  now.Add(-time.Hour),
+		wantErr:   true,
+	}, {
+		name:      "not yet valid",
+		notBefore: now.Add(time.Hour),
+		notAfter:  now.Add(2 * time.Hour),
+		wantErr:   true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(dir, test.name+".crt")
+			writeCertificate(t, file, test.notBefore, test.notAfter)
+			if err := Certificate(file)(nil); (err != nil) != test.wantErr {
+				t.Errorf("Check() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+
+	if err := Certificate(filepath.Join(dir, "missing.crt"))(nil); err == nil {
+		t.Error("Check() = nil, want an error for the missing certificate")
+	}
+}
+
+func writeCertificate(t *testing.T, file string, notBefore, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Failed to generate key:", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "knative-openshift"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("Failed to create certificate:", err)
+	}
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal("Failed to write certificate:", err)
+	}
+}
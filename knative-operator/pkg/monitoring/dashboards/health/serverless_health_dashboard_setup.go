@@ -16,7 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-var logh = common.Log.WithName("health dashboard")
+var logh = common.MonitoringLog.WithName("health dashboard")
 
 func InstallHealthDashboard(api client.Client) error {
 	namespace := os.Getenv(common.NamespaceEnvKey)
@@ -69,8 +69,8 @@ func manifest(apiclient client.Client, deploymentName string, namespace string)
 	return manifest, nil
 }
 
-// manifestPath returns health dashboard resource manifest path
+// manifestPath returns the health and operator dashboard resource manifest paths
 func manifestPath() string {
 	path := os.Getenv("DASHBOARDS_ROOT_MANIFEST_PATH")
-	return path + "/grafana-dash-knative-health.yaml"
+	return path + "/grafana-dash-knative-health.yaml," + path + "/grafana-dash-knative-operator.yaml"
 }
@@ -15,7 +15,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-var log = common.Log.WithName("dashboard")
+var log = common.MonitoringLog.WithName("dashboard")
 
 const ConfigManagedNamespace = "openshift-config-managed"
 const DashboardsManifestPathEnvVar = "DASHBOARDS_ROOT_MANIFEST_PATH"
@@ -13,6 +13,13 @@ var (
 		},
 		[]string{"type"},
 	)
+	DependencyUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "knative_operator_dependency_up",
+			Help: "Reports if a dependency of the operator passed its last health check",
+		},
+		[]string{"dependency"},
+	)
 	KnativeServingUpG  prometheus.Gauge
 	KnativeEventingUpG prometheus.Gauge
 	KnativeKafkaUpG    prometheus.Gauge
@@ -20,5 +27,5 @@ var (
 
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(KnativeUp)
+	metrics.Registry.MustRegister(KnativeUp, DependencyUp)
 }
@@ -15,6 +15,7 @@ import (
 var (
 	KafkaChannelComponents = []string{"kafka-ch-controller", "kafka-webhook"}
 	KafkaSourceComponents  = []string{"kafka-controller-manager"}
+	KafkaSinkComponents    = []string{"kafka-controller", "kafka-webhook-eventing"}
 )
 
 func AddRBACProxySupportToManifest(instance *operatorv1alpha1.KnativeKafka, components []string) (*mf.Manifest, error) {
@@ -44,7 +45,7 @@ func GetRBACProxyInjectTransformer(apiClient client.Client) (mf.Transformer, err
 		return nil, errors.New("eventing instance not found")
 	}
 	if monitoring.ShouldEnableMonitoring(eventingList.Items[0].GetSpec().GetConfig()) {
-		return monitoring.InjectRbacProxyContainerToDeployments(sets.NewString(append(KafkaChannelComponents, KafkaSourceComponents...)...)), nil
+		return monitoring.InjectRbacProxyContainerToDeployments(sets.NewString(append(append(KafkaChannelComponents, KafkaSourceComponents...), KafkaSinkComponents...)...)), nil
 	}
 	return nil, nil
 }
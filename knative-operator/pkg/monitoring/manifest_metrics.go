@@ -0,0 +1,96 @@
+package monitoring
+
+import (
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	ManifestStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "knative_operator_manifest_stage_duration_seconds",
+			Help:    "Duration of the stages applying a component's manifest",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		},
+		[]string{"component", "stage", "result"},
+	)
+	ManifestDriftedResources = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_operator_manifest_drifted_resources_total",
+			Help: "Number of resources re-applied because they differed from a component's manifest",
+		},
+		[]string{"component", "kind"},
+	)
+	ManifestReportedDrift = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_operator_manifest_reported_drift_total",
+			Help: "Number of times resources were found to differ from a component's manifest and were left as they are",
+		},
+		[]string{"component", "kind"},
+	)
+	ManifestTransformErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_operator_manifest_transform_errors_total",
+			Help: "Number of failed transformations of a component's manifest",
+		},
+		[]string{"component"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(ManifestStageDuration, ManifestDriftedResources, ManifestReportedDrift, ManifestTransformErrors)
+}
+
+// ObserveManifestStage records the duration of a manifest stage of the given component
+// that started at start and finished with err.
+func ObserveManifestStage(component, stage string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	ManifestStageDuration.WithLabelValues(component, stage, result).Observe(time.Since(start).Seconds())
+}
+
+// CountDrift wraps the given client so that every successful update, which manifestival
+// only issues for resources differing from the manifest, is counted as drift of the
+// given component.
+func CountDrift(component string, client mf.Client) mf.Client {
+	return &driftCountingClient{Client: client, component: component}
+}
+
+type driftCountingClient struct {
+	mf.Client
+	component string
+}
+
+func (c *driftCountingClient) Update(obj *unstructured.Unstructured, options ...mf.ApplyOption) error {
+	if err := c.Client.Update(obj, options...); err != nil {
+		return err
+	}
+	ManifestDriftedResources.WithLabelValues(c.component, obj.GetKind()).Inc()
+	return nil
+}
+
+// ReportDrift wraps the given client so that updates, which manifestival only issues for
+// resources differing from the manifest, are skipped. Instead, the resources are passed to
+// report and counted as reported drift of the given component. Missing resources are still
+// created.
+func ReportDrift(component string, client mf.Client, report func(*unstructured.Unstructured)) mf.Client {
+	return &driftReportingClient{Client: client, component: component, report: report}
+}
+
+type driftReportingClient struct {
+	mf.Client
+	component string
+	report    func(*unstructured.Unstructured)
+}
+
+func (c *driftReportingClient) Update(obj *unstructured.Unstructured, _ ...mf.ApplyOption) error {
+	ManifestReportedDrift.WithLabelValues(c.component, obj.GetKind()).Inc()
+	c.report(obj)
+	return nil
+}
@@ -0,0 +1,159 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/manifestival/manifestival/fake"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCountDrift(t *testing.T) {
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("knative-eventing")
+	cm.SetName("config-kafka")
+	unstructured.SetNestedField(cm.Object, "foo", "data", "bootstrapServers")
+
+	client := fake.New()
+	manifest, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*cm}),
+		mf.UseClient(CountDrift("drift-test", client)))
+	if err != nil {
+		t.Fatal("Failed to create manifest:", err)
+	}
+
+	// Creating the resource and re-applying it unchanged is no drift.
+	for i := 0; i < 2; i++ {
+		if err := manifest.Apply(); err != nil {
+			t.Fatal("Failed to apply manifest:", err)
+		}
+	}
+	if got := gatherCounter(t, ManifestDriftedResources, "drift-test"); got != 0 {
+		t.Errorf("Got %v drifted resources, want 0", got)
+	}
+
+	// Simulate the live resource being changed.
+	live, err := client.Get(cm)
+	if err != nil {
+		t.Fatal("Failed to get ConfigMap:", err)
+	}
+	unstructured.SetNestedField(live.Object, "bar", "data", "bootstrapServers")
+	if err := client.Update(live); err != nil {
+		t.Fatal("Failed to update ConfigMap:", err)
+	}
+	if err := manifest.Apply(); err != nil {
+		t.Fatal("Failed to apply manifest:", err)
+	}
+	if got := gatherCounter(t, ManifestDriftedResources, "drift-test"); got != 1 {
+		t.Errorf("Got %v drifted resources, want 1", got)
+	}
+}
+
+func TestReportDrift(t *testing.T) {
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("knative-eventing")
+	cm.SetName("config-kafka")
+	unstructured.SetNestedField(cm.Object, "foo", "data", "bootstrapServers")
+
+	client := fake.New()
+	var reported []string
+	manifest, err := mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{*cm}),
+		mf.UseClient(ReportDrift("report-test", client, func(u *unstructured.Unstructured) {
+			reported = append(reported, u.GetName())
+		})))
+	if err != nil {
+		t.Fatal("Failed to create manifest:", err)
+	}
+
+	// Missing resources are still created.
+	if err := manifest.Apply(); err != nil {
+		t.Fatal("Failed to apply manifest:", err)
+	}
+	live, err := client.Get(cm)
+	if err != nil {
+		t.Fatal("Failed to get ConfigMap:", err)
+	}
+
+	// Simulate the live resource being changed.
+	unstructured.SetNestedField(live.Object, "bar", "data", "bootstrapServers")
+	if err := client.Update(live); err != nil {
+		t.Fatal("Failed to update ConfigMap:", err)
+	}
+	if err := manifest.Apply(); err != nil {
+		t.Fatal("Failed to apply manifest:", err)
+	}
+	if got := gatherCounter(t, ManifestReportedDrift, "report-test"); got != 1 {
+		t.Errorf("Got %v reported drift, want 1", got)
+	}
+	if len(reported) != 1 || reported[0] != "config-kafka" {
+		t.Errorf("Got reported resources %v, want [config-kafka]", reported)
+	}
+}
+
+func TestObserveManifestStage(t *testing.T) {
+	ObserveManifestStage("stage-test", "apply", time.Now(), nil)
+	ObserveManifestStage("stage-test", "apply", time.Now(), errors.New("boom"))
+	ObserveManifestStage("stage-test", "apply", time.Now(), errors.New("boom"))
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(ManifestStageDuration)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("Failed to gather metrics:", err)
+	}
+
+	got := map[string]uint64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["component"] != "stage-test" {
+				continue
+			}
+			got[labels["stage"]+"/"+labels["result"]] = m.GetHistogram().GetSampleCount()
+		}
+	}
+
+	want := map[string]uint64{
+		"apply/success": 1,
+		"apply/error":   2,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Got %v for %q, want %v", got[key], key, value)
+		}
+	}
+}
+
+func gatherCounter(t *testing.T, c prometheus.Collector, component string) float64 {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("Failed to gather metrics:", err)
+	}
+
+	var sum float64
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "component" && l.GetValue() == component {
+					sum += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return sum
+}
@@ -0,0 +1,154 @@
+package monitoring
+
+import (
+	"context"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okomon "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/monitoring"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// collectTimeout bounds how long a scrape waits for the informers of newly counted kinds
+// to sync.
+const collectTimeout = 10 * time.Second
+
+var (
+	servingResources = []schema.GroupVersionKind{
+		{Group: "serving.knative.dev", Version: "v1", Kind: "Service"},
+		{Group: "serving.knative.dev", Version: "v1", Kind: "Revision"},
+	}
+	eventingResources = []schema.GroupVersionKind{
+		{Group: "eventing.knative.dev", Version: "v1", Kind: "Broker"},
+		{Group: "eventing.knative.dev", Version: "v1", Kind: "Trigger"},
+		{Group: "messaging.knative.dev", Version: "v1", Kind: "Channel"},
+		{Group: "messaging.knative.dev", Version: "v1", Kind: "InMemoryChannel"},
+		{Group: "messaging.knative.dev", Version: "v1beta1", Kind: "KafkaChannel"},
+	}
+
+	resourcesDesc = prometheus.NewDesc(
+		"knative_resources",
+		"Number of Knative resources per kind, namespace and ready status",
+		[]string{"kind", "namespace", "ready"}, nil,
+	)
+
+	resourceMetricsLog = common.MonitoringLog.WithName("resource-metrics")
+)
+
+// RegisterResourceMetrics registers a collector publishing the number of Knative resources
+// per namespace and ready status with the global prometheus registry. The reader is queried
+// on every scrape, so it's supposed to be backed by an informer cache, e.g. the manager's
+// cache, rather than hit the API server.
+func RegisterResourceMetrics(reader client.Reader) error {
+	return metrics.Registry.Register(NewResourceCollector(reader))
+}
+
+// NewResourceCollector creates a collector publishing the number of Knative resources
+// per namespace and ready status. Resources of a component are only published if monitoring
+// is enabled for that component.
+func NewResourceCollector(reader client.Reader) prometheus.Collector {
+	return &resourceCollector{reader: reader}
+}
+
+type resourceCollector struct {
+	reader client.Reader
+}
+
+type resourceKey struct {
+	kind      string
+	namespace string
+	ready     string
+}
+
+// Describe implements prometheus.Collector.
+func (c *resourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourcesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *resourceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	counts := make(map[resourceKey]int)
+
+	if c.servingMonitored(ctx) {
+		c.count(ctx, servingResources, counts)
+	}
+	if c.eventingMonitored(ctx) {
+		c.count(ctx, eventingResources, counts)
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(resourcesDesc, prometheus.GaugeValue, float64(count), key.kind, key.namespace, key.ready)
+	}
+}
+
+func (c *resourceCollector) servingMonitored(ctx context.Context) bool {
+	list := &operatorv1alpha1.KnativeServingList{}
+	if err := c.reader.List(ctx, list); err != nil {
+		resourceMetricsLog.Error(err, "Failed to list KnativeServings")
+		return false
+	}
+	for i := range list.Items {
+		if okomon.ShouldEnableMonitoring(list.Items[i].Spec.GetConfig()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *resourceCollector) eventingMonitored(ctx context.Context) bool {
+	list := &operatorv1alpha1.KnativeEventingList{}
+	if err := c.reader.List(ctx, list); err != nil {
+		resourceMetricsLog.Error(err, "Failed to list KnativeEventings")
+		return false
+	}
+	for i := range list.Items {
+		if okomon.ShouldEnableMonitoring(list.Items[i].Spec.GetConfig()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *resourceCollector) count(ctx context.Context, gvks []schema.GroupVersionKind, counts map[resourceKey]int) {
+	for _, gvk := range gvks {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := c.reader.List(ctx, list); err != nil {
+			// The CRD might not be installed (yet), ignore it.
+			if !meta.IsNoMatchError(err) {
+				resourceMetricsLog.Error(err, "Failed to list resources", "kind", gvk.Kind)
+			}
+			continue
+		}
+		for i := range list.Items {
+			counts[resourceKey{
+				kind:      gvk.Kind,
+				namespace: list.Items[i].GetNamespace(),
+				ready:     readyStatus(&list.Items[i]),
+			}]++
+		}
+	}
+}
+
+// readyStatus returns the status of the Ready condition of the given resource.
+func readyStatus(u *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		if status, ok := cond["status"].(string); ok {
+			return status
+		}
+	}
+	return "Unknown"
+}
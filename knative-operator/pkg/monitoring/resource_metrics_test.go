@@ -0,0 +1,93 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResourceCollector(t *testing.T) {
+	ks := &operatorv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+	}
+	ke := &operatorv1alpha1.KnativeEventing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-eventing", Namespace: "knative-eventing"},
+		Spec: operatorv1alpha1.KnativeEventingSpec{
+			CommonSpec: operatorv1alpha1.CommonSpec{
+				Config: operatorv1alpha1.ConfigMapData{
+					"observability": {"metrics.backend-destination": "none"},
+				},
+			},
+		},
+	}
+
+	s := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(s)
+	for _, gvk := range append(servingResources, eventingResources...) {
+		s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		s.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		ks, ke,
+		knativeResource(servingResources[0], "ns1", "a", "True"),
+		knativeResource(servingResources[0], "ns1", "b", "True"),
+		knativeResource(servingResources[0], "ns1", "c", "False"),
+		knativeResource(servingResources[0], "ns2", "d", ""),
+		knativeResource(servingResources[1], "ns1", "a-00001", "True"),
+		knativeResource(eventingResources[0], "ns1", "default", "True"),
+	).Build()
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewResourceCollector(cl))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("Failed to gather metrics:", err)
+	}
+
+	got := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			got[labels["kind"]+"/"+labels["namespace"]+"/"+labels["ready"]] = m.GetGauge().GetValue()
+		}
+	}
+
+	// Brokers are not reported as monitoring is disabled for Eventing.
+	want := map[string]float64{
+		"Service/ns1/True":    2,
+		"Service/ns1/False":   1,
+		"Service/ns2/Unknown": 1,
+		"Revision/ns1/True":   1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Got %v for %q, want %v", got[key], key, value)
+		}
+	}
+}
+
+func knativeResource(gvk schema.GroupVersionKind, ns, name, ready string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(ns)
+	u.SetName(name)
+	if ready != "" {
+		unstructured.SetNestedSlice(u.Object, []interface{}{
+			map[string]interface{}{"type": "Ready", "status": ready},
+		}, "status", "conditions")
+	}
+	return u
+}
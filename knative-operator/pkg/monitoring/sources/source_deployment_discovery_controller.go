@@ -29,7 +29,7 @@ var (
 	rbacLabelKey                        = "serverless.monitoring"
 	sourceRbacLabels                    = map[string]string{rbacLabelKey: "true"}
 
-	log = common.Log.WithName("source-deployment-discovery-controller")
+	log = common.MonitoringLog.WithName("source-deployment-discovery-controller")
 )
 
 // Add creates a new Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -0,0 +1,72 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// UpgradeSilenceGracePeriod is how long the alerts stay silenced after a component last
+	// rolled out a new version, covering the rate windows and "for" clauses of the alerts.
+	UpgradeSilenceGracePeriod = 10 * time.Minute
+
+	// upgradeObservedVersionKey is the status annotation recording the last version of the
+	// component seen, to detect upgrades.
+	upgradeObservedVersionKey = "operator.serverless.openshift.io/upgrade-observed-version"
+	// upgradeSilencedUntilKey is the status annotation reporting until when the alerts of
+	// the component are silenced.
+	upgradeSilencedUntilKey = "operator.serverless.openshift.io/upgrade-silenced-until"
+)
+
+var (
+	// UpgradeSilenceEnd is checked by the Serverless alerts, which don't fire while it's in
+	// the future. Restarts during an upgrade are expected and must not cause an alert storm.
+	UpgradeSilenceEnd = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "knative_operator_upgrade_silence_end_timestamp_seconds",
+			Help: "Unix timestamp until which the alerts are silenced due to an upgrade of a Knative component",
+		},
+		[]string{"component"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(UpgradeSilenceEnd)
+}
+
+// TrackUpgradeWindow opens the upgrade window of the given component once the version in its
+// status changes and keeps it open until the component is ready again plus
+// UpgradeSilenceGracePeriod. The window is recorded in the given status annotations, so that
+// it survives restarts of the operator, and reported through UpgradeSilenceEnd.
+func TrackUpgradeWindow(component string, status v1alpha1.KComponentStatus, annotations *map[string]string, now time.Time) {
+	if *annotations == nil {
+		*annotations = make(map[string]string, 2)
+	}
+	ann := *annotations
+
+	var until time.Time
+	if raw, ok := ann[upgradeSilencedUntilKey]; ok {
+		until, _ = time.Parse(time.RFC3339, raw)
+	}
+
+	version := status.GetVersion()
+	observed := ann[upgradeObservedVersionKey]
+	upgrading := observed != "" && version != "" && observed != version
+	if upgrading || (!until.IsZero() && !status.IsReady()) {
+		until = now.Add(UpgradeSilenceGracePeriod)
+	}
+	if version != "" {
+		ann[upgradeObservedVersionKey] = version
+	}
+
+	if until.IsZero() || !now.Before(until) {
+		delete(ann, upgradeSilencedUntilKey)
+		UpgradeSilenceEnd.WithLabelValues(component).Set(0)
+		return
+	}
+	ann[upgradeSilencedUntilKey] = until.UTC().Format(time.RFC3339)
+	UpgradeSilenceEnd.WithLabelValues(component).Set(float64(until.Unix()))
+}
@@ -0,0 +1,81 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestTrackUpgradeWindow(t *testing.T) {
+	const component = "upgrade-test"
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	status := &v1alpha1.KnativeServingStatus{}
+	var annotations map[string]string
+
+	// The first version seen is the installation, not an upgrade.
+	status.SetVersion("0.24.0")
+	status.MarkInstallSucceeded()
+	TrackUpgradeWindow(component, status, &annotations, now)
+	if _, ok := annotations[upgradeSilencedUntilKey]; ok {
+		t.Errorf("Silenced the initial installation: %v", annotations)
+	}
+	if got := gatherGauge(t, UpgradeSilenceEnd, component); got != 0 {
+		t.Errorf("UpgradeSilenceEnd = %v, want 0", got)
+	}
+
+	// The upgrade opens the window.
+	status.SetVersion("0.25.1")
+	status.MarkDeploymentsNotReady()
+	TrackUpgradeWindow(component, status, &annotations, now)
+	want := now.Add(UpgradeSilenceGracePeriod)
+	if got := annotations[upgradeSilencedUntilKey]; got != want.Format(time.RFC3339) {
+		t.Errorf("Silenced until %q, want %v", got, want)
+	}
+	if got := gatherGauge(t, UpgradeSilenceEnd, component); got != float64(want.Unix()) {
+		t.Errorf("UpgradeSilenceEnd = %v, want %v", got, want.Unix())
+	}
+
+	// The window is kept open while the rollout takes longer than the grace period.
+	now = now.Add(time.Hour)
+	TrackUpgradeWindow(component, status, &annotations, now)
+	want = now.Add(UpgradeSilenceGracePeriod)
+	if got := annotations[upgradeSilencedUntilKey]; got != want.Format(time.RFC3339) {
+		t.Errorf("Silenced until %q, want %v", got, want)
+	}
+
+	// The window closes after the grace period once the component is ready.
+	status.MarkDeploymentsAvailable()
+	status.MarkDependenciesInstalled()
+	status.MarkVersionMigrationEligible()
+	now = now.Add(UpgradeSilenceGracePeriod)
+	TrackUpgradeWindow(component, status, &annotations, now)
+	if _, ok := annotations[upgradeSilencedUntilKey]; ok {
+		t.Errorf("Window still open: %v", annotations)
+	}
+	if got := gatherGauge(t, UpgradeSilenceEnd, component); got != 0 {
+		t.Errorf("UpgradeSilenceEnd = %v, want 0", got)
+	}
+}
+
+func gatherGauge(t *testing.T, c prometheus.Collector, component string) float64 {
+	t.Helper()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal("Failed to gather metrics:", err)
+	}
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "component" && l.GetValue() == component {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	operator "knative.dev/operator/pkg/reconciler/common"
+	ksc "knative.dev/operator/pkg/reconciler/knativeserving/common"
+	"knative.dev/operator/pkg/reconciler/knativeserving/ingress"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ChangeAdded marks resources only the next version installs.
+	ChangeAdded = "added"
+	// ChangeRemoved marks resources the next version removes.
+	ChangeRemoved = "removed"
+	// ChangeChanged marks resources the next version changes.
+	ChangeChanged = "changed"
+
+	// rbacManifestEnv points at the RBAC manifest of the metrics proxies in the kodata directory.
+	rbacManifestEnv = "SERVICE_MONITOR_RBAC_MANIFEST_PATH"
+)
+
+// Report describes the changes an upgrade of the operator applies to the resources installed
+// for a KnativeServing.
+type Report struct {
+	// From is the Knative Serving version currently installed.
+	From string `json:"from"`
+	// To is the Knative Serving version installed after the upgrade.
+	To string `json:"to"`
+	// Changes are the resources that are added, removed or changed, sorted by kind, namespace
+	// and name.
+	Changes []Change `json:"changes"`
+}
+
+// Change is a resource that's added, removed or changed by an upgrade.
+type Change struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Change is one of ChangeAdded, ChangeRemoved or ChangeChanged.
+	Change string `json:"change"`
+	// Fields are the paths of the fields that change, like "spec.template.spec.containers".
+	// Lists are compared as a whole.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// KnativeServing renders the manifests installed for the KnativeServing read from the given
+// reader from the kodata directories of the current and the next operator bundle, and reports
+// how they differ. The currently installed version is taken from the status of the
+// KnativeServing, if set. The overrides in its spec and the defaults of the operator are
+// applied to both, settings depending on the state of the cluster are not.
+//
+// The manifests are looked up through the environment, like the operator does, so this must
+// not be called concurrently.
+func KnativeServing(ctx context.Context, in io.Reader, fromDir, toDir string) (*Report, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KnativeServing: %w", err)
+	}
+	ks := &v1alpha1.KnativeServing{}
+	if err := yaml.Unmarshal(data, ks); err != nil {
+		return nil, fmt.Errorf("failed to parse KnativeServing: %w", err)
+	}
+	if ks.APIVersion != v1alpha1.SchemeGroupVersion.String() || ks.Kind != "KnativeServing" {
+		return nil, fmt.Errorf("expected a KnativeServing, got %s %s", ks.APIVersion, ks.Kind)
+	}
+	if err := okoserving.Default(ks); err != nil {
+		return nil, fmt.Errorf("invalid KnativeServing: %w", err)
+	}
+
+	installed := ks.DeepCopy()
+	if version := ks.Status.GetVersion(); version != "" {
+		installed.Spec.Version = version
+	}
+	from, fromVersion, err := render(ctx, fromDir, installed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the installed manifests: %w", err)
+	}
+	to, toVersion, err := render(ctx, toDir, ks.DeepCopy())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the manifests of the next version: %w", err)
+	}
+
+	return &Report{
+		From:    fromVersion,
+		To:      toVersion,
+		Changes: diff(from, to),
+	}, nil
+}
+
+// render renders the manifests the operator installs for the given KnativeServing from the
+// given kodata directory, along with the Knative Serving version they're for.
+func render(ctx context.Context, kodata string, ks *v1alpha1.KnativeServing) (mf.Manifest, string, error) {
+	if _, err := os.Stat(filepath.Join(kodata, "knative-serving")); err != nil {
+		return mf.Manifest{}, "", fmt.Errorf("no Knative Serving releases in %s: %w", kodata, err)
+	}
+	os.Setenv(operator.KoEnvKey, kodata)
+	os.Setenv(rbacManifestEnv, filepath.Join(kodata, "monitoring", "rbac-proxy.yaml"))
+	operator.ClearCache()
+
+	version := operator.TargetVersion(ks)
+	manifest, err := operator.TargetManifest(ks)
+	if err != nil {
+		return mf.Manifest{}, "", err
+	}
+	if err := ingress.AppendTargetIngresses(ctx, &manifest, ks); err != nil {
+		return mf.Manifest{}, "", err
+	}
+	manifest = manifest.Filter(ingress.Filters(ks))
+	extensions, err := okoserving.Manifests(ks)
+	if err != nil {
+		return mf.Manifest{}, "", err
+	}
+	manifest = manifest.Append(extensions...)
+
+	extra := []mf.Transformer{ksc.CustomCertsTransform(ks, logging.FromContext(ctx))}
+	extra = append(extra, okoserving.Transformers(ks)...)
+	extra = append(extra, ksc.IngressServiceTransform(ks))
+	extra = append(extra, ingress.Transformers(ctx, ks)...)
+	if err := operator.Transform(ctx, &manifest, ks, extra...); err != nil {
+		return mf.Manifest{}, "", err
+	}
+	return manifest, version, nil
+}
+
+// diff returns the resources that differ between the given manifests.
+func diff(from, to mf.Manifest) []Change {
+	key := func(u *unstructured.Unstructured) string {
+		return strings.Join([]string{u.GetObjectKind().GroupVersionKind().GroupKind().String(), u.GetNamespace(), u.GetName()}, "/")
+	}
+	before := make(map[string]*unstructured.Unstructured, len(from.Resources()))
+	for _, u := range from.Resources() {
+		u := u
+		if _, ok := before[key(&u)]; !ok {
+			before[key(&u)] = &u
+		}
+	}
+
+	// Resources can be part of a manifest more than once, like CRDs shipped with the core too.
+	seen := make(map[string]bool, len(to.Resources()))
+	changes := []Change{}
+	for _, u := range to.Resources() {
+		u := u
+		if seen[key(&u)] {
+			continue
+		}
+		seen[key(&u)] = true
+		old, ok := before[key(&u)]
+		if !ok {
+			changes = append(changes, changeFor(&u, ChangeAdded, nil))
+			continue
+		}
+		if fields := changedFields("", old.Object, u.Object); len(fields) > 0 {
+			changes = append(changes, changeFor(&u, ChangeChanged, fields))
+		}
+	}
+	for k, u := range before {
+		if !seen[k] {
+			changes = append(changes, changeFor(u, ChangeRemoved, nil))
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return changes
+}
+
+func changeFor(u *unstructured.Unstructured, change string, fields []string) Change {
+	return Change{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Change:     change,
+		Fields:     fields,
+	}
+}
+
+// changedFields returns the sorted paths of the fields that differ between the given objects.
+func changedFields(prefix string, before, after map[string]interface{}) []string {
+	var fields []string
+	keys := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		b, a := before[k], after[k]
+		bm, bIsMap := b.(map[string]interface{})
+		am, aIsMap := a.(map[string]interface{})
+		if bIsMap && aIsMap {
+			fields = append(fields, changedFields(path, bm, am)...)
+		} else if !reflect.DeepEqual(b, a) {
+			fields = append(fields, path)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
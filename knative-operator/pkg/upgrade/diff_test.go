@@ -0,0 +1,160 @@
+package upgrade
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	kodata = "../../../openshift-knative-operator/cmd/operator/kodata"
+
+	installedKS = `apiVersion: operator.knative.dev/v1alpha1
+kind: KnativeServing
+metadata:
+  name: knative-serving
+  namespace: knative-serving
+spec:
+  config:
+    autoscaler:
+      enable-scale-to-zero: "false"
+status:
+  version: 0.25.1
+`
+)
+
+// nextKodata creates a kodata directory with a Knative Serving 0.26.0 that only changes the
+// release labels compared to 0.25.1.
+func nextKodata(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	copyDir(t, filepath.Join(kodata, "monitoring"), filepath.Join(dir, "monitoring"))
+	copyDir(t, filepath.Join(kodata, "ingress", "0.25"), filepath.Join(dir, "ingress", "0.26"))
+
+	next := filepath.Join(dir, "knative-serving", "0.26.0")
+	copyDir(t, filepath.Join(kodata, "knative-serving", "0.25.1"), next)
+	files, err := ioutil.ReadDir(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		path := filepath.Join(next, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = []byte(strings.ReplaceAll(string(data), `serving.knative.dev/release: "v0.25.1"`, `serving.knative.dev/release: "v0.26.0"`))
+		if err := ioutil.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func copyDir(t *testing.T, from, to string) {
+	t.Helper()
+	if err := os.MkdirAll(to, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(from, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(to, file.Name()), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKnativeServing(t *testing.T) {
+	t.Run("same version", func(t *testing.T) {
+		report, err := KnativeServing(context.Background(), strings.NewReader(installedKS), kodata, kodata)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if report.From != "0.25.1" || report.To != "0.25.1" || len(report.Changes) != 0 {
+			t.Errorf("Got report %+v, want no changes from 0.25.1 to 0.25.1", report)
+		}
+	})
+
+	t.Run("next version", func(t *testing.T) {
+		report, err := KnativeServing(context.Background(), strings.NewReader(installedKS), kodata, nextKodata(t))
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if report.From != "0.25.1" || report.To != "0.26.0" {
+			t.Errorf("Got versions %s to %s, want 0.25.1 to 0.26.0", report.From, report.To)
+		}
+
+		got := map[string]Change{}
+		for _, change := range report.Changes {
+			got[change.Change+" "+change.Kind+" "+change.Name] = change
+		}
+		for _, want := range []Change{{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "knative-serving",
+			Name:       "autoscaler-hpa",
+			Change:     ChangeChanged,
+			Fields: []string{
+				"metadata.labels.serving.knative.dev/release",
+				"spec.template.metadata.labels.serving.knative.dev/release",
+			},
+		}, {
+			APIVersion: "v1",
+			Kind:       "Service",
+			Namespace:  "knative-serving",
+			Name:       "autoscaler-hpa",
+			Change:     ChangeChanged,
+			Fields:     []string{"metadata.labels.serving.knative.dev/release"},
+		}} {
+			if !cmp.Equal(got[want.Change+" "+want.Kind+" "+want.Name], want) {
+				t.Errorf("Got changes %+v, want %+v", report.Changes, want)
+			}
+		}
+
+		// The storage version migration Job is named after the version.
+		var jobs []string
+		for _, change := range report.Changes {
+			if change.Kind == "Job" {
+				jobs = append(jobs, change.Change)
+			}
+		}
+		if want := []string{ChangeRemoved, ChangeAdded}; !cmp.Equal(jobs, want) {
+			t.Errorf("Got Job changes %v, want %v", jobs, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		in := strings.Replace(installedKS, "kind: KnativeServing", "kind: KnativeEventing", 1)
+		if _, err := KnativeServing(context.Background(), strings.NewReader(in), kodata, kodata); err == nil {
+			t.Error("Expected an error for a KnativeEventing")
+		}
+	})
+}
+
+func TestChangedFields(t *testing.T) {
+	before := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "foo", "labels": map[string]interface{}{"a": "1"}},
+		"spec":     map[string]interface{}{"replicas": int64(1), "list": []interface{}{"a"}},
+	}
+	after := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "foo", "labels": map[string]interface{}{"a": "2", "b": "1"}},
+		"spec":     map[string]interface{}{"list": []interface{}{"a", "b"}},
+	}
+
+	want := []string{"metadata.labels.a", "metadata.labels.b", "spec.list", "spec.replicas"}
+	if got := changedFields("", before, after); !cmp.Equal(got, want) {
+		t.Errorf("changedFields() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/knativeserving"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// Result is the outcome of validating a KnativeServing offline.
+type Result struct {
+	// Errors are the reasons the KnativeServing would be rejected by the webhook.
+	Errors []string
+	// Defaults is a diff of the spec before and after the operator applied its defaults. It's
+	// empty if the operator wouldn't change anything.
+	Defaults string
+}
+
+// KnativeServing validates the KnativeServing read from the given reader the way the
+// operator's webhook does and determines what defaults the operator would apply to it.
+// Validations and defaults that depend on the state of the cluster are skipped.
+func KnativeServing(ctx context.Context, in io.Reader) (*Result, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KnativeServing: %w", err)
+	}
+	ks := &servingv1alpha1.KnativeServing{}
+	if err := yaml.UnmarshalStrict(data, ks); err != nil {
+		return nil, fmt.Errorf("failed to parse KnativeServing: %w", err)
+	}
+	if ks.APIVersion != servingv1alpha1.SchemeGroupVersion.String() || ks.Kind != "KnativeServing" {
+		return nil, fmt.Errorf("expected a KnativeServing, got %s %s", ks.APIVersion, ks.Kind)
+	}
+
+	reasons, err := knativeserving.ValidateOffline(ctx, ks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate KnativeServing: %w", err)
+	}
+	if len(reasons) > 0 {
+		return &Result{Errors: reasons}, nil
+	}
+
+	defaulted := ks.DeepCopy()
+	if err := okoserving.Default(defaulted); err != nil {
+		return &Result{Errors: []string{err.Error()}}, nil
+	}
+	before, err := yaml.Marshal(ks.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KnativeServing: %w", err)
+	}
+	after, err := yaml.Marshal(defaulted.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KnativeServing: %w", err)
+	}
+	return &Result{Defaults: cmp.Diff(string(before), string(after))}, nil
+}
@@ -0,0 +1,104 @@
+package validate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const defaultedKS = `apiVersion: operator.knative.dev/v1alpha1
+kind: KnativeServing
+metadata:
+  name: knative-serving
+  namespace: knative-serving
+spec:
+  config:
+    network:
+      autocreateClusterDomainClaims: "true"
+      defaultExternalScheme: https
+      domainTemplate: "{{.Name}}-{{.Namespace}}.{{.Domain}}"
+      ingress.class: kourier.ingress.networking.knative.dev
+  controller-custom-certs:
+    name: config-service-ca
+    type: ConfigMap
+  high-availability:
+    replicas: 2
+  ingress:
+    kourier:
+      enabled: true
+  resources:
+  - container: webhook
+    limits:
+      memory: 1Gi
+`
+
+func TestKnativeServing(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           string
+		wantErr      bool
+		wantErrors   []string
+		wantDefaults bool
+	}{{
+		name: "defaults",
+		in: `apiVersion: operator.knative.dev/v1alpha1
+kind: KnativeServing
+metadata:
+  name: knative-serving
+  namespace: knative-serving
+`,
+		wantDefaults: true,
+	}, {
+		name: "already defaulted",
+		in:   defaultedKS,
+	}, {
+		name: "invalid annotations",
+		in: `apiVersion: operator.knative.dev/v1alpha1
+kind: KnativeServing
+metadata:
+  name: knative-serving
+  namespace: knative-serving
+  annotations:
+    serving.knative.openshift.io/coldStart: '{"initialScale": 0}'
+    serving.knative.openshift.io/rolloutDuration: 1.5s
+`,
+		wantErrors: []string{
+			"invalid serving.knative.openshift.io/coldStart: initialScale = 0 requires allowZeroInitialScale to be true",
+			`serving.knative.openshift.io/rolloutDuration = "1.5s", must be a non-negative number of whole seconds`,
+		},
+	}, {
+		name: "unknown field",
+		in: `apiVersion: operator.knative.dev/v1alpha1
+kind: KnativeServing
+spec:
+  foo: bar
+`,
+		wantErr: true,
+	}, {
+		name: "wrong kind",
+		in: `apiVersion: operator.knative.dev/v1alpha1
+kind: KnativeEventing
+`,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := KnativeServing(context.Background(), strings.NewReader(test.in))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("KnativeServing() = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !cmp.Equal(got.Errors, test.wantErrors) {
+				t.Errorf("Got errors = %v, want: %v, diff:\n%s", got.Errors, test.wantErrors, cmp.Diff(got.Errors, test.wantErrors))
+			}
+			if (got.Defaults != "") != test.wantDefaults {
+				t.Errorf("Got defaults = %q, want defaults: %v", got.Defaults, test.wantDefaults)
+			}
+		})
+	}
+}
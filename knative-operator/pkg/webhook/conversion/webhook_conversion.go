@@ -0,0 +1,130 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	group = "operator.knative.dev"
+
+	// V1alpha1 is the storage version, which is what the operator reconciles.
+	V1alpha1 = group + "/v1alpha1"
+	// V1beta1 is the newer API shape, in which "spec.deployments" is called "spec.workloads".
+	V1beta1 = group + "/v1beta1"
+)
+
+var log = common.Log.WithName("conversion")
+
+// Webhook converts KnativeServing and KnativeEventing CRs between v1alpha1 and v1beta1.
+type Webhook struct{}
+
+// NewWebhook creates a new conversion Webhook.
+func NewWebhook() *Webhook {
+	return &Webhook{}
+}
+
+// Implement http.Handler so the webhook server can serve ConversionReviews.
+var _ http.Handler = (*Webhook)(nil)
+
+// ServeHTTP implements the http.Handler interface.
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "ConversionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = Convert(review.Request)
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		log.Error(err, "Failed to write ConversionReview response")
+	}
+}
+
+// Convert converts all objects of the request to the desired version.
+func Convert(req *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	resp := &apiextensionsv1.ConversionResponse{
+		UID:              req.UID,
+		ConvertedObjects: make([]runtime.RawExtension, 0, len(req.Objects)),
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+
+	for _, raw := range req.Objects {
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(raw.Raw); err != nil {
+			return failed(resp, fmt.Errorf("failed to decode object: %w", err))
+		}
+		if err := convertObject(u, req.DesiredAPIVersion); err != nil {
+			return failed(resp, err)
+		}
+		out, err := u.MarshalJSON()
+		if err != nil {
+			return failed(resp, fmt.Errorf("failed to encode object: %w", err))
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: out})
+	}
+	return resp
+}
+
+// convertObject converts the object in place. Both versions only differ in the name of the
+// deployment overrides, so the rest of the object is carried over as is.
+func convertObject(u *unstructured.Unstructured, version string) error {
+	if kind := u.GetKind(); kind != "KnativeServing" && kind != "KnativeEventing" {
+		return fmt.Errorf("unsupported kind %q", kind)
+	}
+
+	from := u.GetAPIVersion()
+	if from == version {
+		return nil
+	}
+	switch {
+	case from == V1alpha1 && version == V1beta1:
+		if err := renameSpecField(u, "deployments", "workloads"); err != nil {
+			return err
+		}
+	case from == V1beta1 && version == V1alpha1:
+		if err := renameSpecField(u, "workloads", "deployments"); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported conversion from %q to %q", from, version)
+	}
+	u.SetAPIVersion(version)
+	return nil
+}
+
+// renameSpecField moves the given field of the spec to a new name.
+func renameSpecField(u *unstructured.Unstructured, from, to string) error {
+	value, found, err := unstructured.NestedFieldNoCopy(u.Object, "spec", from)
+	if err != nil || !found {
+		return err
+	}
+	if _, exists, _ := unstructured.NestedFieldNoCopy(u.Object, "spec", to); exists {
+		return fmt.Errorf("spec.%s and spec.%s must not be set both", from, to)
+	}
+	unstructured.RemoveNestedField(u.Object, "spec", from)
+	return unstructured.SetNestedField(u.Object, value, "spec", to)
+}
+
+func failed(resp *apiextensionsv1.ConversionResponse, err error) *apiextensionsv1.ConversionResponse {
+	log.Error(err, "Conversion failed")
+	resp.ConvertedObjects = nil
+	resp.Result = metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: err.Error(),
+	}
+	return resp
+}
@@ -0,0 +1,94 @@
+package conversion
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func object(apiVersion, kind, field string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": "knative", "namespace": "knative"},
+		"spec": map[string]interface{}{
+			"config": map[string]interface{}{"network": map[string]interface{}{"foo": "bar"}},
+			field: []interface{}{
+				map[string]interface{}{"name": "controller", "replicas": float64(2)},
+			},
+		},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      map[string]interface{}
+		version string
+		want    map[string]interface{}
+		wantErr bool
+	}{{
+		name:    "serving v1alpha1 to v1beta1",
+		in:      object(V1alpha1, "KnativeServing", "deployments"),
+		version: V1beta1,
+		want:    object(V1beta1, "KnativeServing", "workloads"),
+	}, {
+		name:    "eventing v1beta1 to v1alpha1",
+		in:      object(V1beta1, "KnativeEventing", "workloads"),
+		version: V1alpha1,
+		want:    object(V1alpha1, "KnativeEventing", "deployments"),
+	}, {
+		name:    "same version",
+		in:      object(V1alpha1, "KnativeServing", "deployments"),
+		version: V1alpha1,
+		want:    object(V1alpha1, "KnativeServing", "deployments"),
+	}, {
+		name:    "unknown version",
+		in:      object(V1alpha1, "KnativeServing", "deployments"),
+		version: group + "/v1",
+		wantErr: true,
+	}, {
+		name:    "unsupported kind",
+		in:      object(V1alpha1, "KnativeKafka", "deployments"),
+		version: V1beta1,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := json.Marshal(test.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := Convert(&apiextensionsv1.ConversionRequest{
+				UID:               "test",
+				DesiredAPIVersion: test.version,
+				Objects:           []runtime.RawExtension{{Raw: raw}},
+			})
+
+			if resp.UID != "test" {
+				t.Errorf("UID = %q, want %q", resp.UID, "test")
+			}
+			if test.wantErr {
+				if resp.Result.Status != metav1.StatusFailure {
+					t.Errorf("Status = %q, want %q", resp.Result.Status, metav1.StatusFailure)
+				}
+				return
+			}
+			if resp.Result.Status != metav1.StatusSuccess {
+				t.Fatalf("Status = %q, want %q: %s", resp.Result.Status, metav1.StatusSuccess, resp.Result.Message)
+			}
+			got := map[string]interface{}{}
+			if err := json.Unmarshal(resp.ConvertedObjects[0].Raw, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got, test.want, cmp.Diff(got, test.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package domainclaim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// txtRecordPrefix is prepended to the domain to get the name of the TXT record carrying
+	// the challenge token.
+	txtRecordPrefix = "_knative-challenge."
+	// httpChallengePath is the path the challenge token is served at, followed by the name of
+	// the claiming namespace.
+	httpChallengePath = "/.well-known/knative-challenge/"
+
+	// challengeTimeout bounds a single verification, which has to finish well within the
+	// timeout of the webhook.
+	challengeTimeout = 5 * time.Second
+)
+
+// Validator verifies that the namespace a ClusterDomainClaim is created for owns the claimed
+// domain, if domain verification is enabled on the KnativeServing. Without it, any namespace
+// can claim any domain not yet claimed through a DomainMapping.
+//
+// Only claims created by the Knative Serving controller on behalf of a DomainMapping are
+// verified. Cluster admins can still claim domains for a namespace manually.
+type Validator struct {
+	client  client.Client
+	decoder *admission.Decoder
+
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
+	fetch     func(ctx context.Context, url string) (string, error)
+}
+
+// NewValidator creates a new Validator instance to validate ClusterDomainClaims.
+func NewValidator(client client.Client, decoder *admission.Decoder) *Validator {
+	return &Validator{
+		client:    client,
+		decoder:   decoder,
+		lookupTXT: net.DefaultResolver.LookupTXT,
+		fetch:     fetch,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Validator)(nil)
+
+// Handle implements the Handler interface. ClusterDomainClaims are decoded unstructured as
+// their types aren't registered with the operator.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	claim := &unstructured.Unstructured{}
+	if err := v.decoder.Decode(req, claim); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	namespace, _, err := unstructured.NestedString(claim.Object, "spec", "namespace")
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Update {
+		old := &unstructured.Unstructured{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// The claim only has to be verified again if it's handed to another namespace.
+		if oldNamespace, _, _ := unstructured.NestedString(old.Object, "spec", "namespace"); oldNamespace == namespace {
+			return admission.Allowed("")
+		}
+	}
+
+	allowed, reason, err := v.validate(ctx, req.UserInfo.Username, claim.GetName(), namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.ValidationResponse(allowed, reason)
+}
+
+// validate verifies that the given namespace owns the domain, if the claim is made by the
+// Knative Serving controller and domain verification is enabled.
+func (v *Validator) validate(ctx context.Context, username, domain, namespace string) (bool, string, error) {
+	log := common.Log.WithName("validate-domainclaim")
+
+	list := &operatorv1alpha1.KnativeServingList{}
+	if err := v.client.List(ctx, list); err != nil {
+		return false, "Unable to list KnativeServings", err
+	}
+	if len(list.Items) == 0 {
+		return true, "", nil
+	}
+	ks := &list.Items[0]
+	method, err := okoserving.DomainVerificationFromAnnotation(ks)
+	if err != nil || method == "" {
+		// An invalid method is rejected by the KnativeServing webhook already.
+		return true, "", nil
+	}
+	if username != controllerUsername(ks.Namespace) {
+		return true, "", nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, "Unable to get namespace", err
+	}
+	token := ChallengeToken(ns)
+
+	ctx, cancel := context.WithTimeout(ctx, challengeTimeout)
+	defer cancel()
+	switch method {
+	case okoserving.DomainVerificationTXT:
+		name := txtRecordPrefix + domain
+		records, err := v.lookupTXT(ctx, name)
+		if err != nil {
+			log.Info("Failed to look up the TXT record", "name", name, "error", err.Error())
+		}
+		for _, record := range records {
+			if record == token {
+				return true, "", nil
+			}
+		}
+		return false, fmt.Sprintf("Namespace %q has to prove the ownership of %q by a TXT record %q with the value %q",
+			namespace, domain, name, token), nil
+	case okoserving.DomainVerificationHTTP:
+		url := "http://" + domain + httpChallengePath + namespace
+		body, err := v.fetch(ctx, url)
+		if err != nil {
+			log.Info("Failed to fetch the HTTP challenge", "url", url, "error", err.Error())
+		}
+		if strings.TrimSpace(body) == token {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("Namespace %q has to prove the ownership of %q by serving %q at %s",
+			namespace, domain, token, url), nil
+	}
+	return true, "", nil
+}
+
+// ChallengeToken returns the token the given namespace has to publish to prove the ownership
+// of a domain. It's bound to the namespace's UID, so a namespace recreated under the same name
+// has to prove the ownership again.
+func ChallengeToken(ns *corev1.Namespace) string {
+	return "knative-domain-verification=" + string(ns.UID)
+}
+
+// controllerUsername is the user the Knative Serving controller creates ClusterDomainClaims as.
+func controllerUsername(servingNamespace string) string {
+	return "system:serviceaccount:" + servingNamespace + ":controller"
+}
+
+// fetch returns the body of the given URL, which has to respond with 200.
+func fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	// The token is short, anything longer isn't it anyway.
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return string(body), err
+}
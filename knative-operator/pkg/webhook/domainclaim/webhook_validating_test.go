@@ -0,0 +1,164 @@
+package domainclaim
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	domain   = "app.example.com"
+	token    = "knative-domain-verification=team-a-uid"
+	username = "system:serviceaccount:knative-serving:controller"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		username string
+		txt      []string
+		body     string
+		old      string
+		allowed  bool
+	}{{
+		name:     "verification disabled",
+		username: username,
+		allowed:  true,
+	}, {
+		name:     "claimed by a cluster admin",
+		method:   okoserving.DomainVerificationTXT,
+		username: "kube:admin",
+		allowed:  true,
+	}, {
+		name:     "TXT record matches",
+		method:   okoserving.DomainVerificationTXT,
+		username: username,
+		txt:      []string{"unrelated", token},
+		allowed:  true,
+	}, {
+		name:     "TXT record missing",
+		method:   okoserving.DomainVerificationTXT,
+		username: username,
+	}, {
+		name:     "TXT record of another namespace",
+		method:   okoserving.DomainVerificationTXT,
+		username: username,
+		txt:      []string{"knative-domain-verification=team-b-uid"},
+	}, {
+		name:     "HTTP challenge matches",
+		method:   okoserving.DomainVerificationHTTP,
+		username: username,
+		body:     token + "\n",
+		allowed:  true,
+	}, {
+		name:     "HTTP challenge missing",
+		method:   okoserving.DomainVerificationHTTP,
+		username: username,
+	}, {
+		name:     "update within the same namespace",
+		method:   okoserving.DomainVerificationTXT,
+		username: username,
+		old:      "team-a",
+		allowed:  true,
+	}, {
+		name:     "update handing the claim to another namespace",
+		method:   okoserving.DomainVerificationTXT,
+		username: username,
+		old:      "team-b",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &operatorv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+			}
+			if test.method != "" {
+				ks.Annotations = map[string]string{okoserving.DomainVerificationAnnotation: test.method}
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "team-a-uid"}}
+
+			v := NewValidator(fake.NewClientBuilder().WithObjects(ks, ns).Build(), decoder)
+			v.lookupTXT = func(_ context.Context, name string) ([]string, error) {
+				if name != "_knative-challenge."+domain {
+					t.Errorf("Looked up TXT record %q", name)
+				}
+				return test.txt, nil
+			}
+			v.fetch = func(_ context.Context, url string) (string, error) {
+				if url != "http://"+domain+"/.well-known/knative-challenge/team-a" {
+					t.Errorf("Fetched %q", url)
+				}
+				if test.body == "" {
+					return "", errors.New("not found")
+				}
+				return test.body, nil
+			}
+
+			req, err := testutil.RequestFor(claim("team-a"))
+			if err != nil {
+				t.Fatal("Failed to generate a request:", err)
+			}
+			req.UserInfo.Username = test.username
+			if test.old != "" {
+				req.Operation = admissionv1.Update
+				old := claim(test.old)
+				req.OldObject.Object = old
+				if req.OldObject.Raw, err = old.MarshalJSON(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result := v.Handle(context.Background(), req)
+			if result.Allowed != test.allowed {
+				t.Errorf("Allowed = %v, want %v: %v", result.Allowed, test.allowed, result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutKnativeServing(t *testing.T) {
+	v := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(claim("team-a"))
+	if err != nil {
+		t.Fatal("Failed to generate a request:", err)
+	}
+	req.UserInfo.Username = username
+
+	if result := v.Handle(context.Background(), req); !result.Allowed {
+		t.Errorf("Claim denied without a KnativeServing: %v", result.AdmissionResponse)
+	}
+}
+
+func claim(namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.internal.knative.dev/v1alpha1",
+		"kind":       "ClusterDomainClaim",
+		"metadata": map[string]interface{}{
+			"name": domain,
+		},
+		"spec": map[string]interface{}{
+			"namespace": namespace,
+		},
+	}}
+}
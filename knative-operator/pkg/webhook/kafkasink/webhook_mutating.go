@@ -0,0 +1,90 @@
+package kafkasink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Configurator defaults the Kafka cluster of KafkaSinks to the one configured on the
+// KnativeKafka instance.
+type Configurator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// NewConfigurator creates a new Configurator instance to configure KafkaSinks.
+func NewConfigurator(client client.Client, decoder *admission.Decoder) *Configurator {
+	return &Configurator{
+		client:  client,
+		decoder: decoder,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Configurator)(nil)
+
+// Handle implements the Handler interface. KafkaSinks without bootstrap servers get the ones of
+// spec.channel of the KnativeKafka instance. Its auth secret is used as well, if the KafkaSink
+// lives in the secret's namespace, as a KafkaSink can only refer to secrets in its own namespace.
+func (v *Configurator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := common.KafkaLog.WithName("mutate-kafkasink")
+
+	sink := &unstructured.Unstructured{}
+	if err := v.decoder.Decode(req, sink); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	list := &operatorv1alpha1.KnativeKafkaList{}
+	if err := v.client.List(ctx, list); err != nil {
+		log.Error(err, "Unable to list KnativeKafkas")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(list.Items) == 0 || !list.Items[0].Spec.Sink.Enabled {
+		return admission.Allowed("")
+	}
+	channel := list.Items[0].Spec.Channel
+
+	servers, _, err := unstructured.NestedStringSlice(sink.Object, "spec", "bootstrapServers")
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if len(servers) > 0 || channel.BootstrapServers == "" {
+		return admission.Allowed("")
+	}
+
+	defaults := strings.Split(channel.BootstrapServers, ",")
+	for i := range defaults {
+		defaults[i] = strings.TrimSpace(defaults[i])
+	}
+	if err := unstructured.SetNestedStringSlice(sink.Object, defaults, "spec", "bootstrapServers"); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	namespace := sink.GetNamespace()
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	secret, _, err := unstructured.NestedString(sink.Object, "spec", "auth", "secret", "ref", "name")
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if secret == "" && channel.AuthSecretName != "" && channel.AuthSecretNamespace == namespace {
+		if err := unstructured.SetNestedField(sink.Object, channel.AuthSecretName, "spec", "auth", "secret", "ref", "name"); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+	}
+
+	marshaled, err := json.Marshal(sink)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.AdmissionRequest.Object.Raw, marshaled)
+}
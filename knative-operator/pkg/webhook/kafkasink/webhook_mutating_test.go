@@ -0,0 +1,116 @@
+package kafkasink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func kafkaSink(namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	spec["topic"] = "topic"
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "eventing.knative.dev/v1alpha1",
+		"kind":       "KafkaSink",
+		"metadata": map[string]interface{}{
+			"name":      "sink",
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+}
+
+func TestKafkaSinkDefaults(t *testing.T) {
+	channel := operatorv1alpha1.Channel{
+		BootstrapServers:    "broker-1:9092, broker-2:9092",
+		AuthSecretNamespace: "ns",
+		AuthSecretName:      "kafka-auth",
+	}
+
+	tests := []struct {
+		name        string
+		disabled    bool
+		noKafka     bool
+		sink        *unstructured.Unstructured
+		wantPatches map[string]interface{}
+	}{{
+		name:    "no KnativeKafka",
+		noKafka: true,
+		sink:    kafkaSink("ns", map[string]interface{}{}),
+	}, {
+		name:     "sink disabled",
+		disabled: true,
+		sink:     kafkaSink("ns", map[string]interface{}{}),
+	}, {
+		name: "defaults with auth",
+		sink: kafkaSink("ns", map[string]interface{}{}),
+		wantPatches: map[string]interface{}{
+			"/spec/bootstrapServers": []interface{}{"broker-1:9092", "broker-2:9092"},
+			"/spec/auth": map[string]interface{}{
+				"secret": map[string]interface{}{"ref": map[string]interface{}{"name": "kafka-auth"}},
+			},
+		},
+	}, {
+		name: "defaults without auth in other namespace",
+		sink: kafkaSink("other", map[string]interface{}{}),
+		wantPatches: map[string]interface{}{
+			"/spec/bootstrapServers": []interface{}{"broker-1:9092", "broker-2:9092"},
+		},
+	}, {
+		name: "explicit bootstrap servers",
+		sink: kafkaSink("ns", map[string]interface{}{"bootstrapServers": []interface{}{"mine:9092"}}),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			if !test.noKafka {
+				builder = builder.WithObjects(&operatorv1alpha1.KnativeKafka{
+					ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+					Spec: operatorv1alpha1.KnativeKafkaSpec{
+						Channel: channel,
+						Sink:    operatorv1alpha1.Sink{Enabled: !test.disabled},
+					},
+				})
+			}
+			configurator := NewConfigurator(builder.Build(), decoder)
+
+			req, err := testutil.RequestFor(test.sink)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", test.sink, err)
+			}
+
+			result := configurator.Handle(context.Background(), req)
+			if !result.Allowed {
+				t.Fatalf("Allowed = false, want true: %v", result.AdmissionResponse)
+			}
+
+			got := map[string]interface{}{}
+			for _, patch := range result.Patches {
+				got[patch.Path] = patch.Value
+			}
+			want := test.wantPatches
+			if want == nil {
+				want = map[string]interface{}{}
+			}
+			if !cmp.Equal(got, want) {
+				t.Errorf("Got unexpected patches (-want, +got): %s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
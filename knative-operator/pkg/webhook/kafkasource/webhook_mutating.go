@@ -0,0 +1,110 @@
+package kafkasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Configurator applies the consumer group naming policy and the initial offset of the
+// KnativeKafka instance to KafkaSources.
+type Configurator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// NewConfigurator creates a new Configurator instance to configure KafkaSources.
+func NewConfigurator(client client.Client, decoder *admission.Decoder) *Configurator {
+	return &Configurator{
+		client:  client,
+		decoder: decoder,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Configurator)(nil)
+
+// Handle implements the Handler interface. KafkaSources without a consumer group get one
+// generated from the policy, while explicitly set consumer groups must carry its prefix.
+// KafkaSources without an initial offset annotation get the configured initial offset.
+func (v *Configurator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := common.KafkaLog.WithName("mutate-kafkasource")
+
+	source := &unstructured.Unstructured{}
+	if err := v.decoder.Decode(req, source); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	list := &operatorv1alpha1.KnativeKafkaList{}
+	if err := v.client.List(ctx, list); err != nil {
+		log.Error(err, "Unable to list KnativeKafkas")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	var spec operatorv1alpha1.KnativeKafkaSpec
+	if len(list.Items) > 0 {
+		spec = list.Items[0].Spec
+	}
+
+	mutated, err := spec.Consumers.ApplyInitialOffset(source)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	group, _, err := unstructured.NestedString(source.Object, "spec", "consumerGroup")
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	groups := spec.ConsumerGroups
+	if !groups.IsSet() {
+		return patchResponse(req, source, mutated)
+	}
+	if group != "" {
+		if !strings.HasPrefix(group, groups.Prefix) {
+			return admission.Denied(fmt.Sprintf("spec.consumerGroup must start with %q", groups.Prefix))
+		}
+		return patchResponse(req, source, mutated)
+	}
+
+	namespace := source.GetNamespace()
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	name := source.GetName()
+	if name == "" {
+		name = source.GetGenerateName()
+	}
+	group, err = groups.GroupID(operatorv1alpha1.ConsumerGroupRef{
+		Namespace: namespace,
+		Name:      name,
+		UID:       string(source.GetUID()),
+	})
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err := unstructured.SetNestedField(source.Object, group, "spec", "consumerGroup"); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return patchResponse(req, source, true)
+}
+
+// patchResponse allows the given request, patching its object to the given source if it has
+// been mutated.
+func patchResponse(req admission.Request, source *unstructured.Unstructured, mutated bool) admission.Response {
+	if !mutated {
+		return admission.Allowed("")
+	}
+	marshaled, err := json.Marshal(source)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.AdmissionRequest.Object.Raw, marshaled)
+}
@@ -0,0 +1,180 @@
+package kafkasource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func kafkaSource(consumerGroup string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "sources.knative.dev/v1beta1",
+		"kind":       "KafkaSource",
+		"metadata": map[string]interface{}{
+			"name":      "source",
+			"namespace": "ns",
+		},
+		"spec": map[string]interface{}{
+			"topics": []interface{}{"topic"},
+		},
+	}}
+	if consumerGroup != "" {
+		unstructured.SetNestedField(u.Object, consumerGroup, "spec", "consumerGroup")
+	}
+	return u
+}
+
+func TestConsumerGroupPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		groups      *operatorv1alpha1.ConsumerGroups
+		source      *unstructured.Unstructured
+		wantAllowed bool
+		wantGroup   string
+	}{{
+		name:        "no KnativeKafka",
+		source:      kafkaSource(""),
+		wantAllowed: true,
+	}, {
+		name:        "no policy",
+		groups:      &operatorv1alpha1.ConsumerGroups{},
+		source:      kafkaSource(""),
+		wantAllowed: true,
+	}, {
+		name:        "generated consumer group",
+		groups:      &operatorv1alpha1.ConsumerGroups{Prefix: "tenant-a."},
+		source:      kafkaSource(""),
+		wantAllowed: true,
+		wantGroup:   "tenant-a.ns.source",
+	}, {
+		name:        "explicit consumer group with prefix",
+		groups:      &operatorv1alpha1.ConsumerGroups{Prefix: "tenant-a."},
+		source:      kafkaSource("tenant-a.mine"),
+		wantAllowed: true,
+	}, {
+		name:        "explicit consumer group without prefix",
+		groups:      &operatorv1alpha1.ConsumerGroups{Prefix: "tenant-a."},
+		source:      kafkaSource("mine"),
+		wantAllowed: false,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			if test.groups != nil {
+				builder = builder.WithObjects(&operatorv1alpha1.KnativeKafka{
+					ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+					Spec:       operatorv1alpha1.KnativeKafkaSpec{ConsumerGroups: *test.groups},
+				})
+			}
+			configurator := NewConfigurator(builder.Build(), decoder)
+
+			req, err := testutil.RequestFor(test.source)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", test.source, err)
+			}
+
+			result := configurator.Handle(context.Background(), req)
+			if result.Allowed != test.wantAllowed {
+				t.Fatalf("Allowed = %v, want %v: %v", result.Allowed, test.wantAllowed, result.AdmissionResponse)
+			}
+
+			patched := test.source.DeepCopy()
+			for _, patch := range result.Patches {
+				if patch.Path == "/spec/consumerGroup" {
+					patched.Object["spec"].(map[string]interface{})["consumerGroup"] = patch.Value
+				}
+			}
+			got, _, _ := unstructured.NestedString(patched.Object, "spec", "consumerGroup")
+			want := test.wantGroup
+			if want == "" {
+				want, _, _ = unstructured.NestedString(test.source.Object, "spec", "consumerGroup")
+			}
+			if test.wantAllowed && got != want {
+				raw, _ := json.Marshal(result.Patches)
+				t.Errorf("consumerGroup = %q, want %q, patches: %s", got, want, raw)
+			}
+		})
+	}
+}
+
+func TestInitialOffset(t *testing.T) {
+	tests := []struct {
+		name        string
+		consumers   *operatorv1alpha1.Consumers
+		offset      string
+		wantAllowed bool
+		wantOffset  string
+	}{{
+		name:        "no KnativeKafka",
+		wantAllowed: true,
+	}, {
+		name:        "defaulted",
+		consumers:   &operatorv1alpha1.Consumers{InitialOffset: operatorv1alpha1.InitialOffsetEarliest},
+		wantAllowed: true,
+		wantOffset:  operatorv1alpha1.InitialOffsetEarliest,
+	}, {
+		name:        "explicit",
+		consumers:   &operatorv1alpha1.Consumers{InitialOffset: operatorv1alpha1.InitialOffsetEarliest},
+		offset:      operatorv1alpha1.InitialOffsetLatest,
+		wantAllowed: true,
+	}, {
+		name:        "invalid",
+		offset:      "oldest",
+		wantAllowed: false,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			if test.consumers != nil {
+				builder = builder.WithObjects(&operatorv1alpha1.KnativeKafka{
+					ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+					Spec:       operatorv1alpha1.KnativeKafkaSpec{Consumers: *test.consumers},
+				})
+			}
+			configurator := NewConfigurator(builder.Build(), decoder)
+
+			source := kafkaSource("")
+			if test.offset != "" {
+				source.SetAnnotations(map[string]string{operatorv1alpha1.InitialOffsetAnnotation: test.offset})
+			}
+			req, err := testutil.RequestFor(source)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", source, err)
+			}
+
+			result := configurator.Handle(context.Background(), req)
+			if result.Allowed != test.wantAllowed {
+				t.Fatalf("Allowed = %v, want %v: %v", result.Allowed, test.wantAllowed, result.AdmissionResponse)
+			}
+
+			var got string
+			for _, patch := range result.Patches {
+				if patch.Path == "/metadata/annotations" {
+					got = patch.Value.(map[string]interface{})[operatorv1alpha1.InitialOffsetAnnotation].(string)
+				}
+			}
+			if got != test.wantOffset {
+				raw, _ := json.Marshal(result.Patches)
+				t.Errorf("Defaulted initial offset = %q, want %q, patches: %s", got, test.wantOffset, raw)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okoeventing "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/eventing"
 	eventingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -51,6 +52,10 @@ func (v *Validator) validate(ctx context.Context, ke *eventingv1alpha1.KnativeEv
 	stages := []func(context.Context, *eventingv1alpha1.KnativeEventing) (bool, string, error){
 		v.validateNamespace,
 		v.validateLoneliness,
+		v.validateWorkloadOverrides,
+		v.validateServiceMesh,
+		v.validateSourceNamespaceSelector,
+		v.validateTelemetry,
 	}
 	for _, stage := range stages {
 		allowed, reason, err = stage(ctx, ke)
@@ -90,3 +95,35 @@ func (v *Validator) validateLoneliness(ctx context.Context, ke *eventingv1alpha1
 	}
 	return true, "", nil
 }
+
+// validate the workload overrides, if any
+func (v *Validator) validateWorkloadOverrides(ctx context.Context, ke *eventingv1alpha1.KnativeEventing) (bool, string, error) {
+	if _, err := okoeventing.WorkloadOverridesFromAnnotation(ke); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the service mesh mode, if any
+func (v *Validator) validateServiceMesh(ctx context.Context, ke *eventingv1alpha1.KnativeEventing) (bool, string, error) {
+	if _, err := okoeventing.ServiceMesh(ke); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the namespace selector of sources, if any
+func (v *Validator) validateSourceNamespaceSelector(ctx context.Context, ke *eventingv1alpha1.KnativeEventing) (bool, string, error) {
+	if _, err := okoeventing.SourceNamespaceSelector(ke); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the telemetry settings, if any
+func (v *Validator) validateTelemetry(ctx context.Context, ke *eventingv1alpha1.KnativeEventing) (bool, string, error) {
+	if _, err := okoeventing.TelemetryFromAnnotation(ke); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	okoeventing "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/eventing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	eventingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
@@ -66,3 +67,85 @@ func TestLoneliness(t *testing.T) {
 		t.Errorf("Too many KnativeEventings: %v", result.AdmissionResponse)
 	}
 }
+
+func TestInvalidWorkloadOverrides(t *testing.T) {
+	os.Clearenv()
+
+	ke := ke1.DeepCopy()
+	ke.Annotations = map[string]string{
+		okoeventing.WorkloadOverridesAnnotation: `[{"name": "mt-broker-ingress", "env": [{"name": "1FOO"}]}]`,
+	}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ke)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ke, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid workload overrides, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidServiceMesh(t *testing.T) {
+	os.Clearenv()
+
+	ke := ke1.DeepCopy()
+	ke.Annotations = map[string]string{okoeventing.ServiceMeshAnnotation: "true"}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ke)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ke, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid service mesh mode, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidSourceNamespaceSelector(t *testing.T) {
+	os.Clearenv()
+
+	for _, selector := range []string{"eventing=(", ""} {
+		ke := ke1.DeepCopy()
+		ke.Annotations = map[string]string{okoeventing.SourceNamespaceSelectorAnnotation: selector}
+
+		validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+		req, err := testutil.RequestFor(ke)
+		if err != nil {
+			t.Fatalf("Failed to generate a request for %v: %v", ke, err)
+		}
+
+		result := validator.Handle(context.Background(), req)
+		if result.Allowed {
+			t.Errorf("Invalid source namespace selector %q, but the request is allowed: %v", selector, result.AdmissionResponse)
+		}
+	}
+}
+
+func TestInvalidTelemetry(t *testing.T) {
+	os.Clearenv()
+
+	for _, telemetry := range []string{`{"traces": true}`, `{"exporters": {"logging": {}}}`, `{"traces": true, "exporters": {"logging": "debug"}}`} {
+		ke := ke1.DeepCopy()
+		ke.Annotations = map[string]string{okoeventing.TelemetryAnnotation: telemetry}
+
+		validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+		req, err := testutil.RequestFor(ke)
+		if err != nil {
+			t.Fatalf("Failed to generate a request for %v: %v", ke, err)
+		}
+
+		result := validator.Handle(context.Background(), req)
+		if result.Allowed {
+			t.Errorf("Invalid telemetry %s, but the request is allowed: %v", telemetry, result.AdmissionResponse)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
 	eventingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -48,7 +49,7 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 
 // Validator checks for a minimum OpenShift version
 func (v *Validator) validate(ctx context.Context, ke *operatorv1alpha1.KnativeKafka) (allowed bool, reason string, err error) {
-	log := common.Log.WithName("validate")
+	log := common.KafkaLog.WithName("validate")
 	stages := []func(context.Context, *operatorv1alpha1.KnativeKafka) (bool, string, error){
 		v.validateNamespace,
 		v.validateLoneliness,
@@ -105,6 +106,29 @@ func (v *Validator) validateShape(_ context.Context, ke *operatorv1alpha1.Knativ
 	if ke.Spec.Channel.AuthSecretNamespace != "" && ke.Spec.Channel.AuthSecretName == "" {
 		return false, "spec.channel.authSecretName is required when spec.channel.authSecretNamespace is defined", nil
 	}
+	if err := ke.Spec.ConsumerGroups.Validate(); err != nil {
+		return false, fmt.Sprintf("spec.consumerGroups is invalid: %v", err), nil
+	}
+	if err := ke.Spec.Consumers.Validate(); err != nil {
+		return false, fmt.Sprintf("spec.consumers is invalid: %v", err), nil
+	}
+	if err := ke.Spec.Cleanup.Validate(); err != nil {
+		return false, fmt.Sprintf("spec.cleanup is invalid: %v", err), nil
+	}
+	if err := ke.Spec.Firehose.Validate(); err != nil {
+		return false, fmt.Sprintf("spec.firehose is invalid: %v", err), nil
+	}
+	if ke.Spec.Firehose.Enabled && !ke.Spec.Sink.Enabled {
+		return false, "spec.sink.enabled is required when spec.firehose.enabled is true", nil
+	}
+	if ke.Spec.TopologySpread.MaxSkew < 0 {
+		return false, "spec.topologySpread.maxSkew must not be negative", nil
+	}
+	switch corev1.UnsatisfiableConstraintAction(ke.Spec.TopologySpread.WhenUnsatisfiable) {
+	case "", corev1.DoNotSchedule, corev1.ScheduleAnyway:
+	default:
+		return false, fmt.Sprintf("spec.topologySpread.whenUnsatisfiable must be either %s or %s", corev1.DoNotSchedule, corev1.ScheduleAnyway), nil
+	}
 	return true, "", nil
 }
 
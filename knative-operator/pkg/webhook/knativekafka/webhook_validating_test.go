@@ -108,6 +108,81 @@ var (
 				},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalidShapeCR-4",
+				Namespace: "knative-eventing",
+			},
+			Spec: operatorv1alpha1.KnativeKafkaSpec{
+				Source: operatorv1alpha1.Source{
+					Enabled: true,
+				},
+				ConsumerGroups: operatorv1alpha1.ConsumerGroups{
+					Prefix:   "tenant-a.",
+					Template: "{{ .Topic }}", // not a field of the consuming resource
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalidShapeCR-5",
+				Namespace: "knative-eventing",
+			},
+			Spec: operatorv1alpha1.KnativeKafkaSpec{
+				Source: operatorv1alpha1.Source{
+					Enabled: true,
+				},
+				TopologySpread: operatorv1alpha1.TopologySpread{
+					Enabled:           true,
+					WhenUnsatisfiable: "Sometimes",
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalidShapeCR-6",
+				Namespace: "knative-eventing",
+			},
+			Spec: operatorv1alpha1.KnativeKafkaSpec{
+				Source: operatorv1alpha1.Source{
+					Enabled: true,
+				},
+				Consumers: operatorv1alpha1.Consumers{
+					InitialOffset: "oldest", // must be earliest or latest
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalidShapeCR-7",
+				Namespace: "knative-eventing",
+			},
+			Spec: operatorv1alpha1.KnativeKafkaSpec{
+				Source: operatorv1alpha1.Source{
+					Enabled: true,
+				},
+				Cleanup: operatorv1alpha1.Cleanup{
+					Enabled:             true,
+					DrainTimeoutSeconds: -1, // must not be negative
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalidShapeCR-8",
+				Namespace: "knative-eventing",
+			},
+			Spec: operatorv1alpha1.KnativeKafkaSpec{
+				Channel: operatorv1alpha1.Channel{
+					Enabled:          true,
+					BootstrapServers: "foo.example.com",
+				},
+				Firehose: operatorv1alpha1.Firehose{
+					Enabled: true,
+					Topic:   "cluster-events", // requires the KafkaSink
+				},
+			},
+		},
 	}
 	validKnativeEventingCR = &eventingv1alpha1.KnativeEventing{
 		ObjectMeta: metav1.ObjectMeta{
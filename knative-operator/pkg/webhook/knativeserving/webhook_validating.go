@@ -5,13 +5,26 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// ForceDeletionAnnotation allows to delete a KnativeServing CR while Knative Services still
+// exist if set to "true". Their Routes, Revisions and pods stop working along with Knative
+// Serving.
+const ForceDeletionAnnotation = "serving.knative.openshift.io/forceDeletion"
+
+// maxListedNamespaces is the number of namespaces listed in the reason a deletion is denied.
+const maxListedNamespaces = 10
+
 // Validator validates KnativeServing CR's
 type Validator struct {
 	client  client.Client
@@ -33,6 +46,17 @@ var _ admission.Handler = (*Validator)(nil)
 func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
 	ks := &servingv1alpha1.KnativeServing{}
 
+	if req.Operation == admissionv1.Delete {
+		if err := v.decoder.DecodeRaw(req.OldObject, ks); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		allowed, reason, err := v.validateDeletion(ctx, ks)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		return admission.ValidationResponse(allowed, reason)
+	}
+
 	err := v.decoder.Decode(req, ks)
 	if err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
@@ -48,9 +72,23 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 // Validator checks for a minimum OpenShift version
 func (v *Validator) validate(ctx context.Context, ks *servingv1alpha1.KnativeServing) (allowed bool, reason string, err error) {
 	log := common.Log.WithName("validate")
-	stages := []func(context.Context, *servingv1alpha1.KnativeServing) (bool, string, error){
+	stages := []validationStage{
 		v.validateNamespace,
 		v.validateLoneliness,
+		v.validateColdStart,
+		v.validateRollout,
+		v.validateRevisionGC,
+		v.validateKourierConnections,
+		v.validateKourierBootstrap,
+		v.validatePullSecretNamespaces,
+		v.validateInstallPhase,
+		v.validateClusterAutoscaler,
+		v.validateDomainVerification,
+		v.validateTagResolution,
+		v.validateKourierHostNetwork,
+		v.validateDomainTemplate,
+		v.validateTelemetry,
+		v.validateRequestLogging,
 	}
 	for _, stage := range stages {
 		allowed, reason, err = stage(ctx, ks)
@@ -68,6 +106,44 @@ func (v *Validator) validate(ctx context.Context, ks *servingv1alpha1.KnativeSer
 	return
 }
 
+type validationStage func(context.Context, *servingv1alpha1.KnativeServing) (bool, string, error)
+
+// offlineStages returns the validations that don't require access to the cluster, i.e.
+// all but the loneliness validation.
+func (v *Validator) offlineStages() []validationStage {
+	return []validationStage{
+		v.validateNamespace,
+		v.validateColdStart,
+		v.validateRollout,
+		v.validateRevisionGC,
+		v.validateKourierConnections,
+		v.validateKourierBootstrap,
+		v.validatePullSecretNamespaces,
+		v.validateInstallPhase,
+		v.validateClusterAutoscaler,
+		v.validateDomainTemplate,
+		v.validateTelemetry,
+		v.validateRequestLogging,
+	}
+}
+
+// ValidateOffline runs all validations of KnativeServing CRs that don't require access to
+// the cluster and returns the reasons of the failed ones.
+func ValidateOffline(ctx context.Context, ks *servingv1alpha1.KnativeServing) ([]string, error) {
+	v := &Validator{}
+	var reasons []string
+	for _, stage := range v.offlineStages() {
+		allowed, reason, err := stage(ctx, ks)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons, nil
+}
+
 // validate required namespace, if any
 func (v *Validator) validateNamespace(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
 	ns, required := os.LookupEnv("REQUIRED_SERVING_NAMESPACE")
@@ -90,3 +166,140 @@ func (v *Validator) validateLoneliness(ctx context.Context, ks *servingv1alpha1.
 	}
 	return true, "", nil
 }
+
+// validateDeletion denies the deletion of a KnativeServing while Knative Services exist, unless
+// it's forced through the ForceDeletionAnnotation.
+func (v *Validator) validateDeletion(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if strings.EqualFold(ks.GetAnnotations()[ForceDeletionAnnotation], "true") {
+		return true, "", nil
+	}
+	list := &servingv1.ServiceList{}
+	if err := v.client.List(ctx, list); err != nil {
+		return false, "Unable to list Knative Services", err
+	}
+	if len(list.Items) == 0 {
+		return true, "", nil
+	}
+	namespaces := sets.NewString()
+	for _, ksvc := range list.Items {
+		namespaces.Insert(ksvc.Namespace)
+	}
+	listed := namespaces.List()
+	if len(listed) > maxListedNamespaces {
+		listed = append(listed[:maxListedNamespaces], fmt.Sprintf("and %d more", namespaces.Len()-maxListedNamespaces))
+	}
+	return false, fmt.Sprintf("%d Knative Services still exist in the namespaces %s. Delete them first, or annotate the KnativeServing with %s=true to delete it anyway",
+		len(list.Items), strings.Join(listed, ", "), ForceDeletionAnnotation), nil
+}
+
+// validate the cold-start profile, if any
+func (v *Validator) validateColdStart(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.ColdStartFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the rollout defaults, if any
+func (v *Validator) validateRollout(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.RolloutFromAnnotations(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the revision garbage collection policy, if any
+func (v *Validator) validateRevisionGC(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.RevisionGCFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the Kourier connection settings, if any
+func (v *Validator) validateKourierConnections(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.KourierConnectionsFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the namespaces image pull secrets are propagated to, if any
+func (v *Validator) validatePullSecretNamespaces(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.PullSecretNamespaces(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the install phase, if any
+func (v *Validator) validateInstallPhase(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.InstallPhase(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the cluster autoscaler hints, if any
+func (v *Validator) validateClusterAutoscaler(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.ClusterAutoscalerFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the Kourier bootstrap overrides, if any
+func (v *Validator) validateKourierBootstrap(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if err := okoserving.ValidateKourierBootstrap(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the domain verification method, if any
+func (v *Validator) validateDomainVerification(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.DomainVerificationFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the tag resolution settings, if any
+func (v *Validator) validateTagResolution(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.TagResolutionFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the Kourier host network settings, if any
+func (v *Validator) validateKourierHostNetwork(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.KourierHostNetworkFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the domainTemplate preset, if any, and that the domainTemplate doesn't route by path
+func (v *Validator) validateDomainTemplate(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.DomainTemplate(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the telemetry settings, if any
+func (v *Validator) validateTelemetry(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.TelemetryFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
+
+// validate the request logging settings, if any
+func (v *Validator) validateRequestLogging(ctx context.Context, ks *servingv1alpha1.KnativeServing) (bool, string, error) {
+	if _, err := okoserving.RequestLoggingFromAnnotation(ks); err != nil {
+		return false, err.Error(), nil
+	}
+	return true, "", nil
+}
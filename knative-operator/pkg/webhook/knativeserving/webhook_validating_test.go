@@ -3,13 +3,20 @@ package knativeserving
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
 	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -66,3 +73,523 @@ func TestLoneliness(t *testing.T) {
 		t.Errorf("Too many KnativeServings: %v", result.AdmissionResponse)
 	}
 }
+
+func TestInvalidColdStart(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name      string
+		coldStart string
+	}{{
+		name:      "malformed",
+		coldStart: `{"initialScale": `,
+	}, {
+		name:      "unknown field",
+		coldStart: `{"minScale": 1}`,
+	}, {
+		name:      "negative initial scale",
+		coldStart: `{"initialScale": -1}`,
+	}, {
+		name:      "zero initial scale not allowed",
+		coldStart: `{"initialScale": 0, "allowZeroInitialScale": false}`,
+	}, {
+		name:      "invalid progress deadline",
+		coldStart: `{"progressDeadline": "forever"}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = map[string]string{okoserving.ColdStartAnnotation: test.coldStart}
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid cold-start profile, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidRollout(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+	}{{
+		name:        "malformed rollout duration",
+		annotations: map[string]string{okoserving.RolloutDurationAnnotation: "soon"},
+	}, {
+		name:        "negative rollout duration",
+		annotations: map[string]string{okoserving.RolloutDurationAnnotation: "-1m"},
+	}, {
+		name:        "zero progress deadline",
+		annotations: map[string]string{okoserving.ProgressDeadlineAnnotation: "0s"},
+	}, {
+		name: "contradicting cold-start profile",
+		annotations: map[string]string{
+			okoserving.ProgressDeadlineAnnotation: "10m",
+			okoserving.ColdStartAnnotation:        `{"progressDeadline": "5m"}`,
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = test.annotations
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid rollout defaults, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidKourierConnections(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name        string
+		connections string
+	}{{
+		name:        "malformed",
+		connections: `{"idleTimeout": `,
+	}, {
+		name:        "unknown field",
+		connections: `{"streamIdleTimeout": "5m"}`,
+	}, {
+		name:        "invalid idle timeout",
+		connections: `{"idleTimeout": "forever"}`,
+	}, {
+		name:        "negative max connection duration",
+		connections: `{"maxConnectionDuration": "-1h"}`,
+	}, {
+		name:        "no concurrent streams",
+		connections: `{"maxConcurrentStreams": 0}`,
+	}, {
+		name:        "too many concurrent streams",
+		connections: `{"maxConcurrentStreams": 2147483648}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = map[string]string{okoserving.KourierConnectionsAnnotation: test.connections}
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid Kourier connection settings, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidPullSecretNamespaces(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name       string
+		namespaces string
+	}{{
+		name:       "invalid namespace",
+		namespaces: "foo,Bar_baz",
+	}, {
+		name:       "serving namespace",
+		namespaces: "foo,knative-serving",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Namespace = "knative-serving"
+			ks.Annotations = map[string]string{okoserving.PullSecretNamespacesAnnotation: test.namespaces}
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid image pull secret namespaces, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidInstallPhase(t *testing.T) {
+	os.Clearenv()
+
+	ks := ks1.DeepCopy()
+	ks.Annotations = map[string]string{okoserving.InstallPhaseAnnotation: "controlplane"}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid install phase, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidDomainTemplate(t *testing.T) {
+	os.Clearenv()
+
+	ks := ks1.DeepCopy()
+	ks.Annotations = map[string]string{okoserving.DomainTemplateAnnotation: "path"}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid domainTemplate preset, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidClusterAutoscaler(t *testing.T) {
+	os.Clearenv()
+
+	ks := ks1.DeepCopy()
+	ks.Annotations = map[string]string{okoserving.ClusterAutoscalerAnnotation: `{"overprovisioning": {"replicas": 0, "cpu": "1"}}`}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid cluster autoscaler hints, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidDomainVerification(t *testing.T) {
+	os.Clearenv()
+
+	ks := ks1.DeepCopy()
+	ks.Annotations = map[string]string{okoserving.DomainVerificationAnnotation: "dns"}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid domain verification method, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidRevisionGC(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name       string
+		revisionGC string
+	}{{
+		name:       "malformed",
+		revisionGC: `{"minNonActiveRevisions": `,
+	}, {
+		name:       "unknown field",
+		revisionGC: `{"retainSinceLastActiveTime": "15h"}`,
+	}, {
+		name:       "invalid retain since create time",
+		revisionGC: `{"retainSinceCreateTime": "forever"}`,
+	}, {
+		name:       "negative retain since create time",
+		revisionGC: `{"retainSinceCreateTime": "-1h"}`,
+	}, {
+		name:       "negative min non-active revisions",
+		revisionGC: `{"minNonActiveRevisions": -1}`,
+	}, {
+		name:       "invalid max non-active revisions",
+		revisionGC: `{"maxNonActiveRevisions": -2}`,
+	}, {
+		name:       "min exceeds max",
+		revisionGC: `{"minNonActiveRevisions": 10, "maxNonActiveRevisions": 5}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = map[string]string{okoserving.RevisionGCAnnotation: test.revisionGC}
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid revision gc policy, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidTagResolution(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name          string
+		tagResolution string
+		certs         servingv1alpha1.CustomCerts
+	}{{
+		name:          "malformed",
+		tagResolution: `{"skipRegistries": `,
+	}, {
+		name:          "unknown field",
+		tagResolution: `{"registriesSkippingTagResolving": "ko.local"}`,
+	}, {
+		name:          "registry with scheme",
+		tagResolution: `{"skipRegistries": ["https://nexus.example.com"]}`,
+	}, {
+		name:          "registry with path",
+		tagResolution: `{"skipRegistries": ["nexus.example.com/team"]}`,
+	}, {
+		name:          "registry with invalid port",
+		tagResolution: `{"skipRegistries": ["nexus.example.com:http"]}`,
+	}, {
+		name:          "duplicate registry",
+		tagResolution: `{"skipRegistries": ["ko.local", "ko.local"]}`,
+	}, {
+		name:          "invalid CA bundle name",
+		tagResolution: `{"caBundle": "Registry CA"}`,
+	}, {
+		name:          "CA bundle with custom certs from a Secret",
+		tagResolution: `{"caBundle": "registry-ca"}`,
+		certs:         servingv1alpha1.CustomCerts{Type: "Secret", Name: "certs"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = map[string]string{okoserving.TagResolutionAnnotation: test.tagResolution}
+			ks.Spec.ControllerCustomCerts = test.certs
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid tag resolution settings, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidKourierHostNetwork(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name        string
+		hostNetwork string
+		serviceType corev1.ServiceType
+	}{{
+		name:        "malformed",
+		hostNetwork: `{"hostNetwork": `,
+	}, {
+		name:        "both modes",
+		hostNetwork: `{"hostNetwork": true, "hostPorts": {"http": 80}}`,
+	}, {
+		name:        "invalid port",
+		hostNetwork: `{"hostPorts": {"http": 80, "https": 65536}}`,
+	}, {
+		name:        "load balancer service",
+		hostNetwork: `{"hostNetwork": true}`,
+		serviceType: corev1.ServiceTypeLoadBalancer,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = map[string]string{okoserving.KourierHostNetworkAnnotation: test.hostNetwork}
+			if test.serviceType != "" {
+				ks.Spec.Ingress = &servingv1alpha1.IngressConfigs{
+					Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true, ServiceType: test.serviceType},
+				}
+			}
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid Kourier host network settings, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestInvalidKourierBootstrap(t *testing.T) {
+	os.Clearenv()
+
+	ks := ks1.DeepCopy()
+	ks.Annotations = map[string]string{okoserving.KourierBootstrapAnnotation: `static_resources: {secrets: []}`}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid Kourier bootstrap, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidTelemetry(t *testing.T) {
+	os.Clearenv()
+
+	ks := ks1.DeepCopy()
+	ks.Annotations = map[string]string{okoserving.TelemetryAnnotation: `{"metrics": true, "exporters": {}}`}
+
+	validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+	req, err := testutil.RequestFor(ks)
+	if err != nil {
+		t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+	}
+
+	result := validator.Handle(context.Background(), req)
+	if result.Allowed {
+		t.Errorf("Invalid telemetry, but the request is allowed: %v", result.AdmissionResponse)
+	}
+}
+
+func TestInvalidRequestLogging(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		name           string
+		requestLogging string
+	}{{
+		name:           "malformed",
+		requestLogging: `{"enabled": `,
+	}, {
+		name:           "unknown field",
+		requestLogging: `{"enabled": true, "template": "{{.Request.Method}}"}`,
+	}, {
+		name:           "probes without request logs",
+		requestLogging: `{"enabled": false, "probes": true}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = map[string]string{okoserving.RequestLoggingAnnotation: test.requestLogging}
+
+			validator := NewValidator(fake.NewClientBuilder().Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed {
+				t.Errorf("Invalid request logging settings, but the request is allowed: %v", result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestDeletionProtection(t *testing.T) {
+	os.Clearenv()
+
+	ksvc := func(ns string) *servingv1.Service {
+		return &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: ns}}
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		services    []client.Object
+		wantAllowed bool
+	}{{
+		name:        "no services",
+		wantAllowed: true,
+	}, {
+		name:     "existing services",
+		services: []client.Object{ksvc("a"), ksvc("b")},
+	}, {
+		name:        "forced",
+		annotations: map[string]string{ForceDeletionAnnotation: "true"},
+		services:    []client.Object{ksvc("a")},
+		wantAllowed: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := ks1.DeepCopy()
+			ks.Annotations = test.annotations
+
+			validator := NewValidator(fake.NewClientBuilder().WithObjects(test.services...).Build(), decoder)
+
+			req, err := testutil.RequestFor(ks)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ks, err)
+			}
+			// The object being deleted is only passed as the old object.
+			req.Operation = admissionv1.Delete
+			req.OldObject, req.Object = req.Object, runtime.RawExtension{}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed != test.wantAllowed {
+				t.Errorf("Allowed = %v, want %v: %v", result.Allowed, test.wantAllowed, result.AdmissionResponse)
+			}
+			if !test.wantAllowed && !strings.Contains(string(result.Result.Reason), "a, b") {
+				t.Errorf("Reason = %q, want the namespaces listed", result.Result.Reason)
+			}
+		})
+	}
+}
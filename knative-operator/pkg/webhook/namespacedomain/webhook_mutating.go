@@ -0,0 +1,70 @@
+package namespacedomain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Configurator labels Knative Services in namespaces with a domain of their own, so they're
+// selected by the namespace's entry in config-domain.
+type Configurator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// NewConfigurator creates a new Configurator instance to configure Knative Services.
+func NewConfigurator(client client.Client, decoder *admission.Decoder) *Configurator {
+	return &Configurator{
+		client:  client,
+		decoder: decoder,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Configurator)(nil)
+
+// Handle implements the Handler interface. Knative Services created or updated in a namespace
+// annotated with a domain get labelled with the namespace's name. Services that existed
+// before the namespace was annotated are labelled with their next update.
+func (v *Configurator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ksvc := &unstructured.Unstructured{}
+	if err := v.decoder.Decode(req, ksvc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	namespace := ksvc.GetNamespace()
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	if ksvc.GetLabels()[common.NamespaceDomainLabel] == namespace {
+		return admission.Allowed("")
+	}
+
+	ns := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if _, ok := ns.Annotations[common.NamespaceDomainAnnotation]; !ok {
+		return admission.Allowed("")
+	}
+
+	labels := ksvc.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	labels[common.NamespaceDomainLabel] = namespace
+	ksvc.SetLabels(labels)
+
+	marshaled, err := json.Marshal(ksvc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.AdmissionRequest.Object.Raw, marshaled)
+}
@@ -0,0 +1,74 @@
+package namespacedomain
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func TestNamespaceDomainLabel(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantLabel   string
+	}{{
+		name: "namespace without domain",
+	}, {
+		name:        "namespace with domain",
+		annotations: map[string]string{common.NamespaceDomainAnnotation: "team-a.example.com"},
+		wantLabel:   "team-a",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Annotations: test.annotations}}
+			configurator := NewConfigurator(fake.NewClientBuilder().WithObjects(ns).Build(), decoder)
+
+			ksvc := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "serving.knative.dev/v1",
+				"kind":       "Service",
+				"metadata": map[string]interface{}{
+					"name":      "hello",
+					"namespace": "team-a",
+				},
+			}}
+			req, err := testutil.RequestFor(ksvc)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", ksvc, err)
+			}
+
+			result := configurator.Handle(context.Background(), req)
+			if !result.Allowed {
+				t.Fatalf("Knative Service denied: %v", result.AdmissionResponse)
+			}
+
+			var got string
+			for _, patch := range result.Patches {
+				if patch.Path == "/metadata/labels" {
+					got = patch.Value.(map[string]interface{})[common.NamespaceDomainLabel].(string)
+				}
+			}
+			if got != test.wantLabel {
+				raw, _ := json.Marshal(result.Patches)
+				t.Errorf("Label %s = %q, want %q, patches: %s", common.NamespaceDomainLabel, got, test.wantLabel, raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package pingsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	// Embed the timezone database as the operator image doesn't necessarily ship one.
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Validator validates the schedule and timezone of PingSources, which otherwise only fail
+// once the adapter tries to schedule them.
+type Validator struct {
+	decoder *admission.Decoder
+}
+
+// NewValidator creates a new Validator instance to validate PingSources.
+func NewValidator(decoder *admission.Decoder) *Validator {
+	return &Validator{
+		decoder: decoder,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Validator)(nil)
+
+// Handle implements the Handler interface. PingSources are decoded unstructured to
+// validate all of their versions alike.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	source := &unstructured.Unstructured{}
+	if err := v.decoder.Decode(req, source); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	schedule, _, err := unstructured.NestedString(source.Object, "spec", "schedule")
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	timezone, _, err := unstructured.NestedString(source.Object, "spec", "timezone")
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := validate(schedule, timezone); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+
+// validate checks the schedule the way the adapter parses it, a standard cron expression
+// optionally prefixed with its timezone, in addition to the timezone set separately.
+func validate(schedule, timezone string) error {
+	if strings.TrimSpace(schedule) == "" {
+		return fmt.Errorf("spec.schedule must be set")
+	}
+	if strings.HasPrefix(schedule, "@every") {
+		return fmt.Errorf("spec.schedule %q is invalid: the @every descriptor is not supported", schedule)
+	}
+
+	if prefix := scheduleTimezone(schedule); prefix != "" {
+		if timezone != "" {
+			return fmt.Errorf("spec.schedule %q must not set a timezone when spec.timezone is set", schedule)
+		}
+		if _, err := time.LoadLocation(prefix); err != nil {
+			return fmt.Errorf("spec.schedule %q has an unknown timezone %q", schedule, prefix)
+		}
+	}
+
+	spec := schedule
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("spec.timezone %q is not a known timezone, use an IANA name like \"Europe/Berlin\"", timezone)
+		}
+		spec = "CRON_TZ=" + timezone + " " + schedule
+	}
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return fmt.Errorf("spec.schedule %q is not a valid cron expression: %v", schedule, err)
+	}
+	return nil
+}
+
+// scheduleTimezone returns the timezone the schedule is prefixed with, if any.
+func scheduleTimezone(schedule string) string {
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(schedule, prefix) {
+			if fields := strings.Fields(strings.TrimPrefix(schedule, prefix)); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package pingsource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func pingSource(schedule, timezone string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "sources.knative.dev/v1beta2",
+		"kind":       "PingSource",
+		"metadata": map[string]interface{}{
+			"name":      "source",
+			"namespace": "ns",
+		},
+		"spec": map[string]interface{}{
+			"schedule": schedule,
+		},
+	}}
+	if timezone != "" {
+		unstructured.SetNestedField(u.Object, timezone, "spec", "timezone")
+	}
+	return u
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		timezone string
+		allowed  bool
+	}{{
+		name:     "valid schedule",
+		schedule: "*/2 * * * *",
+		allowed:  true,
+	}, {
+		name:     "valid descriptor",
+		schedule: "@hourly",
+		allowed:  true,
+	}, {
+		name:     "valid schedule with timezone",
+		schedule: "0 9 * * 1-5",
+		timezone: "Europe/Berlin",
+		allowed:  true,
+	}, {
+		name:     "valid schedule with timezone prefix",
+		schedule: "CRON_TZ=America/New_York 0 9 * * *",
+		allowed:  true,
+	}, {
+		name: "no schedule",
+	}, {
+		name:     "too few fields",
+		schedule: "* * *",
+	}, {
+		name:     "out of range",
+		schedule: "0 25 * * *",
+	}, {
+		name:     "every descriptor",
+		schedule: "@every 1m",
+	}, {
+		name:     "unknown timezone",
+		schedule: "* * * * *",
+		timezone: "Mars/Olympus_Mons",
+	}, {
+		name:     "unknown timezone prefix",
+		schedule: "TZ=Mars/Olympus_Mons * * * * *",
+	}, {
+		name:     "timezone set twice",
+		schedule: "CRON_TZ=Europe/Berlin * * * * *",
+		timezone: "Europe/Berlin",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := pingSource(test.schedule, test.timezone)
+			req, err := testutil.RequestFor(source)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", source, err)
+			}
+
+			result := NewValidator(decoder).Handle(context.Background(), req)
+			if result.Allowed != test.allowed {
+				t.Errorf("Allowed = %v, want %v: %v", result.Allowed, test.allowed, result.AdmissionResponse)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package servicequota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The quota annotations are set on the namespace the quota applies to.
+const (
+	// MaxServicesAnnotation limits the number of Knative Services in a namespace.
+	MaxServicesAnnotation = "serving.knative.openshift.io/maxServices"
+	// MaxRevisionsAnnotation limits the number of revisions in a namespace.
+	MaxRevisionsAnnotation = "serving.knative.openshift.io/maxRevisions"
+	// MaxScaleAnnotation limits the max scale a Knative Service in a namespace can configure.
+	MaxScaleAnnotation = "serving.knative.openshift.io/maxScale"
+)
+
+// Validator validates Knative Services against the quota of their namespace and the
+// installation of Knative Serving.
+//
+// Services and revisions are counted with an uncached reader, but concurrent creations
+// are not serialized, so the limits on their number are best-effort and might be exceeded
+// by a few racing requests.
+type Validator struct {
+	client  client.Client
+	reader  client.Reader
+	decoder *admission.Decoder
+}
+
+// NewValidator creates a new Validator instance to validate Knative Services. The reader is
+// used to count the existing services and revisions and should not be backed by a cache.
+func NewValidator(client client.Client, reader client.Reader, decoder *admission.Decoder) *Validator {
+	return &Validator{
+		client:  client,
+		reader:  reader,
+		decoder: decoder,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Validator)(nil)
+
+// Handle implements the Handler interface.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ksvc := &servingv1.Service{}
+	if err := v.decoder.Decode(req, ksvc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var old *servingv1.Service
+	if req.Operation == admissionv1.Update {
+		old = &servingv1.Service{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	allowed, reason, err := v.validate(ctx, ksvc, old)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.ValidationResponse(allowed, reason)
+}
+
+// validate checks the service against the quota of its namespace and the installation of
+// Knative Serving. old is nil on creation.
+func (v *Validator) validate(ctx context.Context, ksvc, old *servingv1.Service) (allowed bool, reason string, err error) {
+	log := common.Log.WithName("validate-quota")
+
+	ns := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: ksvc.Namespace}, ns); err != nil {
+		return false, "Unable to get namespace", err
+	}
+
+	stages := []func(context.Context, *corev1.Namespace, *servingv1.Service, *servingv1.Service) (bool, string, error){
+		v.validateInstallPhase,
+		v.validateServices,
+		v.validateRevisions,
+		v.validateMaxScale,
+	}
+	for _, stage := range stages {
+		allowed, reason, err = stage(ctx, ns, ksvc, old)
+		if len(reason) > 0 {
+			if err != nil {
+				log.Error(err, reason)
+			} else {
+				log.Info(reason)
+			}
+		}
+		if !allowed {
+			return
+		}
+	}
+	return
+}
+
+// validate that Knative Serving is fully installed when creating services
+func (v *Validator) validateInstallPhase(ctx context.Context, ns *corev1.Namespace, ksvc, old *servingv1.Service) (bool, string, error) {
+	if old != nil {
+		return true, "", nil
+	}
+
+	list := &operatorv1alpha1.KnativeServingList{}
+	if err := v.client.List(ctx, list); err != nil {
+		return false, "Unable to list KnativeServings", err
+	}
+	for i := range list.Items {
+		if phase, _ := okoserving.InstallPhase(&list.Items[i]); phase == okoserving.InstallPhaseCRDs {
+			return false, "Knative Serving only has its CRDs installed, set " + okoserving.InstallPhaseAnnotation +
+				" to " + okoserving.InstallPhaseFull + " before creating Knative Services", nil
+		}
+	}
+	return true, "", nil
+}
+
+// validate the number of services in the namespace
+func (v *Validator) validateServices(ctx context.Context, ns *corev1.Namespace, ksvc, old *servingv1.Service) (bool, string, error) {
+	max, ok, err := quota(ns, MaxServicesAnnotation)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	if !ok || old != nil {
+		return true, "", nil
+	}
+
+	list := &servingv1.ServiceList{}
+	if err := v.reader.List(ctx, list, client.InNamespace(ns.Name)); err != nil {
+		return false, "Unable to list Knative Services", err
+	}
+	if len(list.Items) >= max {
+		return false, fmt.Sprintf("Namespace %s is limited to %d Knative Services", ns.Name, max), nil
+	}
+	return true, "", nil
+}
+
+// validate the number of revisions in the namespace, if the change creates a new revision
+func (v *Validator) validateRevisions(ctx context.Context, ns *corev1.Namespace, ksvc, old *servingv1.Service) (bool, string, error) {
+	max, ok, err := quota(ns, MaxRevisionsAnnotation)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	if !ok {
+		return true, "", nil
+	}
+	if old != nil && equality.Semantic.DeepEqual(old.Spec.Template, ksvc.Spec.Template) {
+		return true, "", nil
+	}
+
+	list := &servingv1.RevisionList{}
+	if err := v.reader.List(ctx, list, client.InNamespace(ns.Name)); err != nil {
+		return false, "Unable to list revisions", err
+	}
+	if len(list.Items) >= max {
+		return false, fmt.Sprintf("Namespace %s is limited to %d revisions, delete unused revisions first", ns.Name, max), nil
+	}
+	return true, "", nil
+}
+
+// validate the max scale of the service, falling back to the cluster-wide default
+func (v *Validator) validateMaxScale(ctx context.Context, ns *corev1.Namespace, ksvc, old *servingv1.Service) (bool, string, error) {
+	max, ok, err := quota(ns, MaxScaleAnnotation)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	if !ok {
+		return true, "", nil
+	}
+
+	raw, ok := ksvc.Spec.Template.Annotations[autoscaling.MaxScaleAnnotationKey]
+	if !ok {
+		if raw, err = v.defaultMaxScale(ctx); err != nil {
+			return false, "Unable to determine the default max scale", err
+		}
+		if raw == "" {
+			return false, fmt.Sprintf("Namespace %s requires %s to be set to at most %d", ns.Name, autoscaling.MaxScaleAnnotationKey, max), nil
+		}
+	}
+	scale, err := strconv.Atoi(raw)
+	if err != nil {
+		// Leave reporting malformed values to Knative's own validation.
+		return true, "", nil
+	}
+	if scale == 0 || scale > max {
+		return false, fmt.Sprintf("Namespace %s limits %s to at most %d", ns.Name, autoscaling.MaxScaleAnnotationKey, max), nil
+	}
+	return true, "", nil
+}
+
+// defaultMaxScale returns the max scale configured for all services in the autoscaler config
+// of Knative Serving, or an empty string if there's none.
+func (v *Validator) defaultMaxScale(ctx context.Context) (string, error) {
+	list := &operatorv1alpha1.KnativeServingList{}
+	if err := v.client.List(ctx, list); err != nil {
+		return "", err
+	}
+	for i := range list.Items {
+		if raw := list.Items[i].Spec.Config["autoscaler"]["max-scale"]; raw != "" {
+			return raw, nil
+		}
+	}
+	return "", nil
+}
+
+// quota returns the value of the given quota annotation on the namespace and whether
+// it is set at all. Malformed quotas are reported as an error, denying all changes until
+// they're fixed.
+func quota(ns *corev1.Namespace, key string) (int, bool, error) {
+	raw, ok := ns.Annotations[key]
+	if !ok {
+		return 0, false, nil
+	}
+	max, err := strconv.Atoi(raw)
+	if err != nil || max < 0 {
+		return 0, false, fmt.Errorf("invalid quota %s=%q on namespace %s", key, raw, ns.Name)
+	}
+	return max, true, nil
+}
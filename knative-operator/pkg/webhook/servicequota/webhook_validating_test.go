@@ -0,0 +1,193 @@
+package servicequota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	okoserving "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func TestQuota(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    map[string]string
+		existing []client.Object
+		in       *servingv1.Service
+		old      *servingv1.Service
+		allowed  bool
+	}{{
+		name:    "no quota",
+		in:      ksvc("a", ""),
+		allowed: true,
+	}, {
+		name:     "services within quota",
+		quota:    map[string]string{MaxServicesAnnotation: "2"},
+		existing: []client.Object{ksvc("b", "")},
+		in:       ksvc("a", ""),
+		allowed:  true,
+	}, {
+		name:     "services exceeding quota",
+		quota:    map[string]string{MaxServicesAnnotation: "1"},
+		existing: []client.Object{ksvc("b", "")},
+		in:       ksvc("a", ""),
+	}, {
+		name:     "update with services exceeding quota",
+		quota:    map[string]string{MaxServicesAnnotation: "1"},
+		existing: []client.Object{ksvc("a", "")},
+		in:       ksvc("a", ""),
+		old:      ksvc("a", ""),
+		allowed:  true,
+	}, {
+		name:     "revisions exceeding quota",
+		quota:    map[string]string{MaxRevisionsAnnotation: "1"},
+		existing: []client.Object{revision("a-00001")},
+		in:       ksvc("a", "2"),
+		old:      ksvc("a", "1"),
+	}, {
+		name:     "revisions exceeding quota without new revision",
+		quota:    map[string]string{MaxRevisionsAnnotation: "1"},
+		existing: []client.Object{revision("a-00001")},
+		in: func() *servingv1.Service {
+			svc := ksvc("a", "1")
+			svc.Labels = map[string]string{"foo": "bar"}
+			return svc
+		}(),
+		old:     ksvc("a", "1"),
+		allowed: true,
+	}, {
+		name:    "max scale within quota",
+		quota:   map[string]string{MaxScaleAnnotation: "5"},
+		in:      ksvc("a", "5"),
+		allowed: true,
+	}, {
+		name:  "max scale exceeding quota",
+		quota: map[string]string{MaxScaleAnnotation: "5"},
+		in:    ksvc("a", "6"),
+	}, {
+		name:  "max scale unlimited",
+		quota: map[string]string{MaxScaleAnnotation: "5"},
+		in:    ksvc("a", "0"),
+	}, {
+		name:  "max scale unset",
+		quota: map[string]string{MaxScaleAnnotation: "5"},
+		in:    ksvc("a", ""),
+	}, {
+		name:     "max scale unset, default within quota",
+		quota:    map[string]string{MaxScaleAnnotation: "5"},
+		existing: []client.Object{knativeServingWithMaxScale("3")},
+		in:       ksvc("a", ""),
+		allowed:  true,
+	}, {
+		name:     "max scale unset, default exceeding quota",
+		quota:    map[string]string{MaxScaleAnnotation: "5"},
+		existing: []client.Object{knativeServingWithMaxScale("10")},
+		in:       ksvc("a", ""),
+	}, {
+		name:  "malformed quota",
+		quota: map[string]string{MaxServicesAnnotation: "many"},
+		in:    ksvc("a", ""),
+	}, {
+		name:     "crds install phase",
+		existing: []client.Object{knativeServing(okoserving.InstallPhaseCRDs)},
+		in:       ksvc("a", ""),
+	}, {
+		name:     "update in crds install phase",
+		existing: []client.Object{knativeServing(okoserving.InstallPhaseCRDs), ksvc("a", "")},
+		in:       ksvc("a", ""),
+		old:      ksvc("a", ""),
+		allowed:  true,
+	}, {
+		name:     "full install phase",
+		existing: []client.Object{knativeServing(okoserving.InstallPhaseFull)},
+		in:       ksvc("a", ""),
+		allowed:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default",
+					Annotations: test.quota,
+				},
+			}
+			cl := fake.NewClientBuilder().WithObjects(append(test.existing, ns)...).Build()
+			validator := NewValidator(cl, cl, decoder)
+
+			req, err := testutil.RequestFor(test.in)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", test.in, err)
+			}
+			if test.old != nil {
+				oldReq, err := testutil.RequestFor(test.old)
+				if err != nil {
+					t.Fatalf("Failed to generate a request for %v: %v", test.old, err)
+				}
+				req.Operation = admissionv1.Update
+				req.OldObject = oldReq.Object
+			}
+
+			result := validator.Handle(context.Background(), req)
+			if result.Allowed != test.allowed {
+				t.Errorf("Allowed = %v, want %v: %v", result.Allowed, test.allowed, result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func ksvc(name, maxScale string) *servingv1.Service {
+	svc := &servingv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+	if maxScale != "" {
+		svc.Spec.Template.Annotations = map[string]string{autoscaling.MaxScaleAnnotationKey: maxScale}
+	}
+	return svc
+}
+
+func knativeServing(phase string) *operatorv1alpha1.KnativeServing {
+	return &operatorv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-serving",
+			Namespace:   "knative-serving",
+			Annotations: map[string]string{okoserving.InstallPhaseAnnotation: phase},
+		},
+	}
+}
+
+func knativeServingWithMaxScale(maxScale string) *operatorv1alpha1.KnativeServing {
+	ks := knativeServing(okoserving.InstallPhaseFull)
+	ks.Spec.Config = operatorv1alpha1.ConfigMapData{"autoscaler": {"max-scale": maxScale}}
+	return ks
+}
+
+func revision(name string) *servingv1.Revision {
+	return &servingv1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+	}
+}
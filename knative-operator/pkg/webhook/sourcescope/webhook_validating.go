@@ -0,0 +1,97 @@
+package sourcescope
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+
+	okoeventing "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/eventing"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// requiredNsEnvName is the environment variable carrying the namespace KnativeEventing has
+// to be created in, if any.
+const requiredNsEnvName = "REQUIRED_EVENTING_NAMESPACE"
+
+// Validator rejects the creation of event sources in namespaces not selected by the
+// KnativeEventing. Only creations are checked, so that existing sources can still be updated
+// and finalized once their namespace is no longer selected.
+type Validator struct {
+	client client.Client
+}
+
+// NewValidator creates a new Validator instance to validate event sources.
+func NewValidator(client client.Client) *Validator {
+	return &Validator{
+		client: client,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Validator)(nil)
+
+// Handle implements the Handler interface. The source itself is irrelevant, only the
+// namespace it's created in is checked.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	allowed, reason, err := v.validate(ctx, req.Namespace, req.Kind.Kind)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.ValidationResponse(allowed, reason)
+}
+
+// validate checks the labels of the given namespace against the selector of the KnativeEventing.
+func (v *Validator) validate(ctx context.Context, namespace, kind string) (bool, string, error) {
+	ke, err := v.knativeEventing(ctx)
+	if err != nil {
+		return false, "Unable to list KnativeEventings", err
+	}
+	if ke == nil {
+		return true, "", nil
+	}
+	selector, err := okoeventing.SourceNamespaceSelector(ke)
+	if err != nil || selector == nil {
+		// An invalid selector is rejected by the KnativeEventing webhook already.
+		return true, "", nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := v.client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, "Unable to get namespace", err
+	}
+	if !selector.Matches(labels.Set(ns.Labels)) {
+		return false, fmt.Sprintf("%s cannot be created in namespace %s, event sources are limited to namespaces matching %q",
+			kind, namespace, selector.String()), nil
+	}
+	return true, "", nil
+}
+
+// knativeEventing returns the KnativeEventing selecting the namespaces, if any. That's the one
+// in the required namespace, which may only hold one. Without a required namespace, the oldest
+// KnativeEventing is picked, so that the choice doesn't depend on the order of the list.
+func (v *Validator) knativeEventing(ctx context.Context) (*operatorv1alpha1.KnativeEventing, error) {
+	list := &operatorv1alpha1.KnativeEventingList{}
+	if err := v.client.List(ctx, list, client.InNamespace(os.Getenv(requiredNsEnvName))); err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i], list.Items[j]
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return a.CreationTimestamp.Before(&b.CreationTimestamp)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return &list.Items[0], nil
+}
@@ -0,0 +1,138 @@
+package sourcescope
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	okoeventing "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/eventing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	operatorv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		labels   map[string]string
+		allowed  bool
+	}{{
+		name:    "no selector",
+		allowed: true,
+	}, {
+		name:     "selected namespace",
+		selector: "eventing=approved",
+		labels:   map[string]string{"eventing": "approved"},
+		allowed:  true,
+	}, {
+		name:     "unselected namespace",
+		selector: "eventing=approved",
+		labels:   map[string]string{"eventing": "denied"},
+	}, {
+		name:     "unlabelled namespace",
+		selector: "eventing in (approved, trusted)",
+	}, {
+		name:     "invalid selector",
+		selector: "eventing=(",
+		allowed:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ke := &operatorv1alpha1.KnativeEventing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-eventing", Namespace: "knative-eventing"},
+			}
+			if test.selector != "" {
+				ke.Annotations = map[string]string{okoeventing.SourceNamespaceSelectorAnnotation: test.selector}
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: test.labels}}
+
+			result := NewValidator(fake.NewClientBuilder().WithObjects(ke, ns).Build()).
+				Handle(context.Background(), request(t, "team-a"))
+			if result.Allowed != test.allowed {
+				t.Errorf("Allowed = %v, want %v: %v", result.Allowed, test.allowed, result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutKnativeEventing(t *testing.T) {
+	v := NewValidator(fake.NewClientBuilder().Build())
+	if result := v.Handle(context.Background(), request(t, "team-a")); !result.Allowed {
+		t.Errorf("Source denied without a KnativeEventing: %v", result.AdmissionResponse)
+	}
+}
+
+func TestValidateWithSeveralKnativeEventings(t *testing.T) {
+	now := metav1.Now()
+	older := &operatorv1alpha1.KnativeEventing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "knative-eventing",
+			Namespace:         "zzz",
+			CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+			Annotations:       map[string]string{okoeventing.SourceNamespaceSelectorAnnotation: "eventing=approved"},
+		},
+	}
+	newer := &operatorv1alpha1.KnativeEventing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "knative-eventing",
+			Namespace:         "aaa",
+			CreationTimestamp: now,
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+
+	tests := []struct {
+		name       string
+		requiredNs string
+		allowed    bool
+	}{{
+		name: "oldest KnativeEventing",
+	}, {
+		name:       "KnativeEventing in the required namespace",
+		requiredNs: "aaa",
+		allowed:    true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.requiredNs != "" {
+				t.Setenv(requiredNsEnvName, test.requiredNs)
+			}
+			v := NewValidator(fake.NewClientBuilder().WithObjects(newer, older, ns).Build())
+			if result := v.Handle(context.Background(), request(t, "team-a")); result.Allowed != test.allowed {
+				t.Errorf("Allowed = %v, want %v: %v", result.Allowed, test.allowed, result.AdmissionResponse)
+			}
+		})
+	}
+}
+
+func request(t *testing.T, namespace string) admission.Request {
+	t.Helper()
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "sources.knative.dev/v1",
+		"kind":       "ApiServerSource",
+		"metadata": map[string]interface{}{
+			"name":      "source",
+			"namespace": namespace,
+		},
+	}}
+	req, err := testutil.RequestFor(source)
+	if err != nil {
+		t.Fatal("Failed to generate a request:", err)
+	}
+	req.Namespace = namespace
+	req.Kind = metav1.GroupVersionKind{Group: "sources.knative.dev", Version: "v1", Kind: "ApiServerSource"}
+	return req
+}
@@ -0,0 +1,65 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Configurator applies the initial offset of the KnativeKafka instance to Triggers.
+type Configurator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// NewConfigurator creates a new Configurator instance to configure Triggers.
+func NewConfigurator(client client.Client, decoder *admission.Decoder) *Configurator {
+	return &Configurator{
+		client:  client,
+		decoder: decoder,
+	}
+}
+
+// Implement admission.Handler so the controller can handle admission request.
+var _ admission.Handler = (*Configurator)(nil)
+
+// Handle implements the Handler interface. Triggers with an initial offset annotation must
+// use a known offset, while Triggers without one get the configured initial offset.
+func (v *Configurator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := common.KafkaLog.WithName("mutate-trigger")
+
+	trigger := &unstructured.Unstructured{}
+	if err := v.decoder.Decode(req, trigger); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	list := &operatorv1alpha1.KnativeKafkaList{}
+	if err := v.client.List(ctx, list); err != nil {
+		log.Error(err, "Unable to list KnativeKafkas")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	var consumers operatorv1alpha1.Consumers
+	if len(list.Items) > 0 {
+		consumers = list.Items[0].Spec.Consumers
+	}
+
+	mutated, err := consumers.ApplyInitialOffset(trigger)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if !mutated {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(trigger)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.AdmissionRequest.Object.Raw, marshaled)
+}
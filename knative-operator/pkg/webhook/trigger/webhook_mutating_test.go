@@ -0,0 +1,110 @@
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis"
+	operatorv1alpha1 "github.com/openshift-knative/serverless-operator/knative-operator/pkg/apis/operator/v1alpha1"
+	"github.com/openshift-knative/serverless-operator/knative-operator/pkg/webhook/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var decoder *admission.Decoder
+
+func init() {
+	apis.AddToScheme(scheme.Scheme)
+	decoder, _ = admission.NewDecoder(scheme.Scheme)
+}
+
+func trigger(offset string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "eventing.knative.dev/v1",
+		"kind":       "Trigger",
+		"metadata": map[string]interface{}{
+			"name":      "trigger",
+			"namespace": "ns",
+		},
+		"spec": map[string]interface{}{
+			"broker": "default",
+		},
+	}}
+	if offset != "" {
+		u.SetAnnotations(map[string]string{operatorv1alpha1.InitialOffsetAnnotation: offset})
+	}
+	return u
+}
+
+func TestInitialOffset(t *testing.T) {
+	tests := []struct {
+		name        string
+		consumers   *operatorv1alpha1.Consumers
+		trigger     *unstructured.Unstructured
+		wantAllowed bool
+		wantOffset  string
+	}{{
+		name:        "no KnativeKafka",
+		trigger:     trigger(""),
+		wantAllowed: true,
+	}, {
+		name:        "no default",
+		consumers:   &operatorv1alpha1.Consumers{},
+		trigger:     trigger(""),
+		wantAllowed: true,
+	}, {
+		name:        "defaulted",
+		consumers:   &operatorv1alpha1.Consumers{InitialOffset: operatorv1alpha1.InitialOffsetEarliest},
+		trigger:     trigger(""),
+		wantAllowed: true,
+		wantOffset:  operatorv1alpha1.InitialOffsetEarliest,
+	}, {
+		name:        "explicit",
+		consumers:   &operatorv1alpha1.Consumers{InitialOffset: operatorv1alpha1.InitialOffsetEarliest},
+		trigger:     trigger(operatorv1alpha1.InitialOffsetLatest),
+		wantAllowed: true,
+	}, {
+		name:        "invalid",
+		consumers:   &operatorv1alpha1.Consumers{InitialOffset: operatorv1alpha1.InitialOffsetEarliest},
+		trigger:     trigger("oldest"),
+		wantAllowed: false,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder()
+			if test.consumers != nil {
+				builder = builder.WithObjects(&operatorv1alpha1.KnativeKafka{
+					ObjectMeta: metav1.ObjectMeta{Name: "knative-kafka", Namespace: "knative-eventing"},
+					Spec:       operatorv1alpha1.KnativeKafkaSpec{Consumers: *test.consumers},
+				})
+			}
+			configurator := NewConfigurator(builder.Build(), decoder)
+
+			req, err := testutil.RequestFor(test.trigger)
+			if err != nil {
+				t.Fatalf("Failed to generate a request for %v: %v", test.trigger, err)
+			}
+
+			result := configurator.Handle(context.Background(), req)
+			if result.Allowed != test.wantAllowed {
+				t.Fatalf("Allowed = %v, want %v: %v", result.Allowed, test.wantAllowed, result.AdmissionResponse)
+			}
+
+			var got string
+			for _, patch := range result.Patches {
+				if patch.Path == "/metadata/annotations" {
+					got = patch.Value.(map[string]interface{})[operatorv1alpha1.InitialOffsetAnnotation].(string)
+				}
+			}
+			if got != test.wantOffset {
+				raw, _ := json.Marshal(result.Patches)
+				t.Errorf("Defaulted initial offset = %q, want %q, patches: %s", got, test.wantOffset, raw)
+			}
+		})
+	}
+}
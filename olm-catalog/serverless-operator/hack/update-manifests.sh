@@ -32,3 +32,6 @@ git apply "$root/olm-catalog/serverless-operator/hack/003-eventing-drop-unsuppor
 
 # Drop unsupported sources field from the Eventing CRD.
 git apply "$root/olm-catalog/serverless-operator/hack/004-eventing-drop-unsupported-sources.patch"
+
+# Serve the v1beta1 shape of the operator's APIs, converted by our conversion webhook.
+git apply "$root/olm-catalog/serverless-operator/hack/005-add-v1beta1-version.patch"
@@ -1,16 +1,37 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"knative.dev/operator/pkg/reconciler/knativeeventing"
 	"knative.dev/operator/pkg/reconciler/knativeserving"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/signals"
 
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/eventing"
 	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/serving"
+	"github.com/openshift-knative/serverless-operator/pkg/drift"
 )
 
 func main() {
-	sharedmain.Main("knative-operator",
+	// Lab clusters might run an unsupported version of Kubernetes, which is reported on the
+	// components rather than failing the startup.
+	common.AllowUnsupportedVersion()
+
+	ctx := signals.NewContext()
+	// The resync reverts manual edits of the resources of KnativeServings and KnativeEventings.
+	resync, err := drift.ResyncInterval()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if resync != nil {
+		ctx = controller.WithResyncPeriod(ctx, *resync)
+	}
+	sharedmain.MainWithContext(ctx, "knative-operator",
 		knativeeventing.NewExtendedController(eventing.NewExtension),
 		knativeserving.NewExtendedController(serving.NewExtension),
 	)
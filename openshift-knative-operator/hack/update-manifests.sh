@@ -98,6 +98,15 @@ yaml.break_image_references "$kourier_file"
 # TODO: Make this obsolete and buy-into the renaming.
 git apply "$root/openshift-knative-operator/hack/009-undo-kourier-rename.patch"
 
+# Contour and its Knative integration. There are no downstream builds of Contour and Envoy, so
+# the image references are kept as released.
+contour_file="$root/openshift-knative-operator/cmd/operator/kodata/ingress/$(versions.major_minor "${KNATIVE_SERVING_VERSION}")/contour.yaml"
+: > "$contour_file"
+for file in contour.yaml net-contour.yaml; do
+  wget --no-check-certificate "https://github.com/knative-sandbox/net-contour/releases/download/v$(metadata.get dependencies.net_contour)/$file" -O - >> "$contour_file"
+  echo "---" >> "$contour_file"
+done
+
 #
 # DOWNLOAD EVENTING
 #
@@ -0,0 +1,146 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+// FeaturesStatusKey is the status annotation listing the feature flags a component is running
+// with, as JSON keyed by flag, for example:
+//
+//	operator.serverless.openshift.io/features: |
+//	  {"multi-container": "enabled", "tag-header-based-routing": "disabled"}
+//
+// Flags not set explicitly are reported with their default, so this answers "is flag X
+// actually on" without reading the ConfigMap and its example.
+const FeaturesStatusKey = "operator.serverless.openshift.io/features"
+
+const (
+	featuresConfigMap = "config-features"
+	// exampleKey holds the documented defaults of a Knative ConfigMap.
+	exampleKey = "_example"
+)
+
+var (
+	featureStates = []string{"enabled", "disabled", "allowed"}
+
+	// featureFlagM is 1 for the state each feature flag of a component is in, and 0 otherwise.
+	featureFlagM = stats.Int64(
+		"feature_flag",
+		"The state of the feature flags of the Knative components",
+		stats.UnitDimensionless)
+
+	componentKey    = tag.MustNewKey("component")
+	featureKey      = tag.MustNewKey("feature")
+	featureStateKey = tag.MustNewKey("state")
+)
+
+func init() {
+	if err := view.Register(&view.View{
+		Description: featureFlagM.Description(),
+		Measure:     featureFlagM,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{componentKey, featureKey, featureStateKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// EffectiveConfig returns the values the given Knative ConfigMap configures, which are the
+// defaults of its example overridden by its data.
+func EffectiveConfig(cm *corev1.ConfigMap) (map[string]string, error) {
+	effective := make(map[string]string, len(cm.Data))
+	if example := cm.Data[exampleKey]; example != "" {
+		defaults := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(example), &defaults); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of %s: %w", exampleKey, cm.Name, err)
+		}
+		for key, value := range defaults {
+			effective[key] = fmt.Sprint(value)
+		}
+	}
+	for key, value := range cm.Data {
+		if key != exampleKey {
+			effective[key] = value
+		}
+	}
+	return effective, nil
+}
+
+// FeatureRecorder reports the feature flags of a component.
+type FeatureRecorder struct {
+	mu sync.Mutex
+	// recorded are the flags recorded before, to reset them once they're gone.
+	recorded map[string]bool
+}
+
+// ReportFeatures sets the FeaturesStatusKey on the given status to the feature flags the
+// given component is running with in the given namespace, and records the respective metrics.
+// Nothing is reported before the features ConfigMap has been created.
+func (r *FeatureRecorder) ReportFeatures(ctx context.Context, kube kubernetes.Interface, component, namespace string, status *duckv1.Status) error {
+	cm, err := kube.CoreV1().ConfigMaps(namespace).Get(ctx, featuresConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		delete(status.Annotations, FeaturesStatusKey)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s/%s: %w", namespace, featuresConfigMap, err)
+	}
+	features, err := EffectiveConfig(cm)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(features)
+	if err != nil {
+		return fmt.Errorf("failed to marshal features: %w", err)
+	}
+	if status.Annotations == nil {
+		status.Annotations = make(map[string]string, 1)
+	}
+	status.Annotations[FeaturesStatusKey] = string(raw)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Flags that are gone are reset to zero.
+	flags := make(map[string]bool, len(features))
+	for flag := range r.recorded {
+		flags[flag] = true
+	}
+	for flag := range features {
+		flags[flag] = true
+	}
+	for flag := range flags {
+		value := strings.ToLower(strings.TrimSpace(features[flag]))
+		for _, state := range featureStates {
+			tagged, err := tag.New(ctx, tag.Upsert(componentKey, component), tag.Upsert(featureKey, flag),
+				tag.Upsert(featureStateKey, state))
+			if err != nil {
+				return err
+			}
+			var m int64
+			if value == state {
+				m = 1
+			}
+			metrics.Record(tagged, featureFlagM.M(m))
+		}
+	}
+	r.recorded = make(map[string]bool, len(features))
+	for flag := range features {
+		r.recorded[flag] = true
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-features"},
+		Data: map[string]string{
+			"_example": `
+# Documentation of foo.
+foo: "disabled"
+bar: "enabled"
+`,
+			"foo": "enabled",
+			"baz": "allowed",
+		},
+	}
+	got, err := EffectiveConfig(cm)
+	if err != nil {
+		t.Fatalf("EffectiveConfig() = %v", err)
+	}
+	want := map[string]string{"foo": "enabled", "bar": "enabled", "baz": "allowed"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("EffectiveConfig() = %v, diff(-want,+got): %s", got, cmp.Diff(want, got))
+	}
+
+	cm.Data["_example"] = "not: [yaml"
+	if _, err := EffectiveConfig(cm); err == nil {
+		t.Error("EffectiveConfig() = nil, want an error for a broken example")
+	}
+}
+
+func TestReportFeatures(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	status := &duckv1.Status{Annotations: map[string]string{FeaturesStatusKey: "stale"}}
+	r := &FeatureRecorder{}
+
+	// Nothing is reported before the ConfigMap exists.
+	if err := r.ReportFeatures(context.Background(), kube, "serving", "knative-serving", status); err != nil {
+		t.Fatalf("ReportFeatures() = %v", err)
+	}
+	if got, ok := status.Annotations[FeaturesStatusKey]; ok {
+		t.Errorf("Got features %q without a ConfigMap", got)
+	}
+
+	if _, err := kube.CoreV1().ConfigMaps("knative-serving").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving", Name: "config-features"},
+		Data: map[string]string{
+			"_example":                 `multi-container: "enabled"`,
+			"tag-header-based-routing": "enabled",
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create ConfigMap: %v", err)
+	}
+	if err := r.ReportFeatures(context.Background(), kube, "serving", "knative-serving", status); err != nil {
+		t.Fatalf("ReportFeatures() = %v", err)
+	}
+	got := map[string]string{}
+	if err := json.Unmarshal([]byte(status.Annotations[FeaturesStatusKey]), &got); err != nil {
+		t.Fatalf("Failed to unmarshal features: %v", err)
+	}
+	want := map[string]string{"multi-container": "enabled", "tag-header-based-routing": "enabled"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Got features %v, diff(-want,+got): %s", got, cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,129 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// PodSecurityEnforceLabel is the namespace label setting the pod security level pods
+	// violating it are rejected for.
+	PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	// PodSecurityWarnLabel is the namespace label setting the pod security level violations of
+	// which are returned as warnings.
+	PodSecurityWarnLabel = "pod-security.kubernetes.io/warn"
+	// PodSecurityAuditLabel is the namespace label setting the pod security level violations of
+	// which are recorded in the audit log.
+	PodSecurityAuditLabel = "pod-security.kubernetes.io/audit"
+
+	// podSecurityLabelSyncLabel stops OpenShift from deriving the pod security labels of a
+	// namespace from the SCCs its ServiceAccounts may use, which would revert ours.
+	podSecurityLabelSyncLabel = "security.openshift.io/scc.podSecurityLabelSync"
+
+	// The pod security levels.
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// PodSecurity are the pod security admission levels of the namespaces of a Knative component,
+// set through an annotation on its CR as JSON, for example:
+//
+//	{"enforce": "restricted", "warn": "restricted"}
+//
+// Levels that aren't set keep their default. The pods of Knative satisfy the baseline level,
+// which is enforced by default, while violations of the restricted level are warned about
+// and audited, so that they surface before OpenShift tightens its defaults.
+type PodSecurity struct {
+	// Enforce is the level pods violating it are rejected for.
+	Enforce string `json:"enforce,omitempty"`
+	// Warn is the level violations of which are warned about and audited.
+	Warn string `json:"warn,omitempty"`
+}
+
+// DefaultPodSecurity are the levels applied unless overridden.
+var DefaultPodSecurity = PodSecurity{Enforce: PodSecurityBaseline, Warn: PodSecurityRestricted}
+
+// PodSecurityFromAnnotation parses the pod security levels in the given annotation of the
+// given component, defaulting the levels that aren't set.
+func PodSecurityFromAnnotation(comp metav1.Object, annotation string) (PodSecurity, error) {
+	p := DefaultPodSecurity
+	raw, ok := comp.GetAnnotations()[annotation]
+	if !ok {
+		return p, nil
+	}
+
+	override := PodSecurity{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&override); err != nil {
+		return p, fmt.Errorf("failed to parse %s: %w", annotation, err)
+	}
+	for _, level := range []string{override.Enforce, override.Warn} {
+		switch level {
+		case "", PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted:
+		default:
+			return p, fmt.Errorf("invalid %s: unknown level %q, must be one of %s, %s or %s",
+				annotation, level, PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted)
+		}
+	}
+	if override.Enforce != "" {
+		p.Enforce = override.Enforce
+	}
+	if override.Warn != "" {
+		p.Warn = override.Warn
+	}
+	return p, nil
+}
+
+// labels returns the namespace labels applying the levels.
+func (p PodSecurity) labels() map[string]string {
+	return map[string]string{
+		PodSecurityEnforceLabel:   p.Enforce,
+		PodSecurityWarnLabel:      p.Warn,
+		PodSecurityAuditLabel:     p.Warn,
+		podSecurityLabelSyncLabel: "false",
+	}
+}
+
+// ReconcilePodSecurityLabels labels the given namespace with the given pod security levels.
+// Namespaces that don't exist yet are skipped, they're labelled once they're installed.
+func ReconcilePodSecurityLabels(ctx context.Context, api kubernetes.Interface, namespace string, p PodSecurity) error {
+	ns, err := api.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %q: %w", namespace, err)
+	}
+
+	labels := p.labels()
+	changed := false
+	for key, value := range labels {
+		if ns.Labels[key] != value {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	logging.FromContext(ctx).Infof("Setting the pod security levels of namespace %q to enforce %s and warn %s", namespace, p.Enforce, p.Warn)
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string, len(labels))
+	}
+	for key, value := range labels {
+		ns.Labels[key] = value
+	}
+	if _, err := api.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to set the pod security labels of namespace %q: %w", namespace, err)
+	}
+	return nil
+}
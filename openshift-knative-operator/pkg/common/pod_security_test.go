@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const podSecurityAnnotation = "test/pod-security"
+
+func TestPodSecurityFromAnnotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      map[string]string
+		want    PodSecurity
+		wantErr bool
+	}{{
+		name: "no annotation",
+		want: DefaultPodSecurity,
+	}, {
+		name: "enforce",
+		in:   map[string]string{podSecurityAnnotation: `{"enforce": "restricted"}`},
+		want: PodSecurity{Enforce: PodSecurityRestricted, Warn: PodSecurityRestricted},
+	}, {
+		name: "both",
+		in:   map[string]string{podSecurityAnnotation: `{"enforce": "privileged", "warn": "baseline"}`},
+		want: PodSecurity{Enforce: PodSecurityPrivileged, Warn: PodSecurityBaseline},
+	}, {
+		name:    "unknown level",
+		in:      map[string]string{podSecurityAnnotation: `{"enforce": "strict"}`},
+		want:    DefaultPodSecurity,
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		in:      map[string]string{podSecurityAnnotation: `{"audit": "restricted"}`},
+		want:    DefaultPodSecurity,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			comp := &metav1.ObjectMeta{Annotations: test.in}
+			got, err := PodSecurityFromAnnotation(comp, podSecurityAnnotation)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("PodSecurityFromAnnotation() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("PodSecurityFromAnnotation() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReconcilePodSecurityLabels(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "knative-serving",
+		Labels: map[string]string{"foo": "bar", PodSecurityEnforceLabel: PodSecurityPrivileged},
+	}}
+	api := fake.NewSimpleClientset(ns)
+
+	if err := ReconcilePodSecurityLabels(context.Background(), api, ns.Name, DefaultPodSecurity); err != nil {
+		t.Fatalf("ReconcilePodSecurityLabels() = %v", err)
+	}
+	got, err := api.CoreV1().Namespaces().Get(context.Background(), ns.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %v", err)
+	}
+	want := map[string]string{
+		"foo":                     "bar",
+		PodSecurityEnforceLabel:   PodSecurityBaseline,
+		PodSecurityWarnLabel:      PodSecurityRestricted,
+		PodSecurityAuditLabel:     PodSecurityRestricted,
+		podSecurityLabelSyncLabel: "false",
+	}
+	if !cmp.Equal(got.Labels, want) {
+		t.Errorf("Labels = %v, want: %v, diff(-want,+got):\n%s", got.Labels, want, cmp.Diff(want, got.Labels))
+	}
+
+	// Namespaces that aren't installed yet are skipped.
+	if err := ReconcilePodSecurityLabels(context.Background(), api, "knative-serving-ingress", DefaultPodSecurity); err != nil {
+		t.Errorf("ReconcilePodSecurityLabels() = %v, want no error for a missing namespace", err)
+	}
+}
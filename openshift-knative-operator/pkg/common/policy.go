@@ -0,0 +1,86 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+// SupportedConfiguration is false if a component is configured in ways that are not
+// supported. It only carries a warning for support cases and doesn't affect the readiness
+// of the component.
+const SupportedConfiguration apis.ConditionType = "SupportedConfiguration"
+
+// policyCondSet only manages warning conditions, without ever touching the readiness.
+var policyCondSet = apis.NewLivingConditionSet()
+
+// ConfigRule marks values of a key of spec.config as unsupported.
+type ConfigRule struct {
+	// ConfigMap is the name of the ConfigMap, with or without the "config-" prefix.
+	ConfigMap string
+	Key       string
+	// Values are the unsupported values, compared case-insensitively. Any value is
+	// unsupported if there are none.
+	Values []string
+	// Reason tells why the configuration is unsupported.
+	Reason string
+}
+
+// ConfigPolicy is a set of rules a spec.config must not match to be supported.
+type ConfigPolicy []ConfigRule
+
+// Violations returns a message for each rule the given spec matches, sorted.
+func (p ConfigPolicy) Violations(spec *v1alpha1.CommonSpec) []string {
+	var violations []string
+	for _, rule := range p {
+		for _, cm := range []string{rule.ConfigMap, "config-" + rule.ConfigMap} {
+			value, ok := spec.Config[cm][rule.Key]
+			if !ok || !rule.matches(value) {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf("%s %q in %s is unsupported: %s", rule.Key, value, cm, rule.Reason))
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+func (r ConfigRule) matches(value string) bool {
+	if len(r.Values) == 0 {
+		return true
+	}
+	for _, v := range r.Values {
+		if strings.EqualFold(v, strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkSupportedConfiguration sets SupportedConfiguration on the given status to false with a
+// warning listing the given violations, or removes it if there are none.
+func MarkSupportedConfiguration(status apis.ConditionsAccessor, violations []string) {
+	MarkWarnings(status, SupportedConfiguration, "UnsupportedConfiguration", violations)
+}
+
+// MarkWarnings sets the given condition on the given status to false with a warning listing
+// the given messages, or removes it if there are none. Such conditions never affect the
+// readiness of the component.
+func MarkWarnings(status apis.ConditionsAccessor, condition apis.ConditionType, reason string, warnings []string) {
+	if len(warnings) == 0 {
+		// Never fails as the condition isn't terminal.
+		_ = policyCondSet.Manage(status).ClearCondition(condition)
+		return
+	}
+	policyCondSet.Manage(status).SetCondition(apis.Condition{
+		Type:     condition,
+		Status:   corev1.ConditionFalse,
+		Severity: apis.ConditionSeverityWarning,
+		Reason:   reason,
+		Message:  strings.Join(warnings, "; "),
+	})
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+func TestConfigPolicyViolations(t *testing.T) {
+	policy := ConfigPolicy{{
+		ConfigMap: "features",
+		Key:       "foo",
+		Values:    []string{"enabled"},
+		Reason:    "foo is alpha",
+	}, {
+		ConfigMap: "network",
+		Key:       "bar",
+		Reason:    "bar is never supported",
+	}}
+
+	cases := []struct {
+		name   string
+		config v1alpha1.ConfigMapData
+		want   []string
+	}{{
+		name: "no config",
+	}, {
+		name: "supported value",
+		config: v1alpha1.ConfigMapData{
+			"features": {"foo": "disabled"},
+		},
+	}, {
+		name: "unsupported values",
+		config: v1alpha1.ConfigMapData{
+			"config-features": {"foo": " Enabled"},
+			"network":         {"bar": "anything"},
+		},
+		want: []string{
+			`bar "anything" in network is unsupported: bar is never supported`,
+			`foo " Enabled" in config-features is unsupported: foo is alpha`,
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := policy.Violations(&v1alpha1.CommonSpec{Config: c.config})
+			if !cmp.Equal(got, c.want) {
+				t.Errorf("Got unexpected violations, diff: %s", cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
+
+func TestMarkSupportedConfiguration(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{}
+	ks.Status.InitializeConditions()
+	ks.Status.MarkInstallSucceeded()
+	ks.Status.MarkDeploymentsAvailable()
+	ks.Status.MarkDependenciesInstalled()
+	ks.Status.MarkVersionMigrationEligible()
+	if !ks.Status.IsReady() {
+		t.Fatalf("KnativeServing isn't ready: %v", ks.Status.Conditions)
+	}
+
+	MarkSupportedConfiguration(&ks.Status, []string{"foo", "bar"})
+	cond := ks.Status.GetCondition(SupportedConfiguration)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Severity != apis.ConditionSeverityWarning || cond.Message != "foo; bar" {
+		t.Errorf("Got condition %v, want a warning", cond)
+	}
+	// The warning doesn't affect the readiness.
+	if !ks.Status.IsReady() {
+		t.Error("KnativeServing isn't ready with a warning")
+	}
+
+	MarkSupportedConfiguration(&ks.Status, nil)
+	if cond := ks.Status.GetCondition(SupportedConfiguration); cond != nil {
+		t.Errorf("Got condition %v, want none", cond)
+	}
+}
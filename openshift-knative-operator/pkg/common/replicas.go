@@ -0,0 +1,50 @@
+package common
+
+import (
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// OverrideAutoscaledReplicas makes the replicas set for a deployment in spec.deployments
+// the minimum replicas of the HorizontalPodAutoscaler scaling it. Upstream only sets the
+// replicas of the deployment itself, which the autoscaler scales back to its own minimum
+// otherwise, so per-deployment replicas only had an effect on deployments without one.
+func OverrideAutoscaledReplicas(overrides []v1alpha1.DeploymentOverride) mf.Transformer {
+	replicas := make(map[string]int64, len(overrides))
+	for _, override := range overrides {
+		if override.Replicas > 0 {
+			replicas[override.Name] = int64(override.Replicas)
+		}
+	}
+
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "HorizontalPodAutoscaler" {
+			return nil
+		}
+		kind, _, err := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "kind")
+		if err != nil || kind != "Deployment" {
+			return err
+		}
+		target, _, err := unstructured.NestedString(u.Object, "spec", "scaleTargetRef", "name")
+		if err != nil {
+			return err
+		}
+		min, ok := replicas[target]
+		if !ok {
+			return nil
+		}
+
+		if err := unstructured.SetNestedField(u.Object, min, "spec", "minReplicas"); err != nil {
+			return err
+		}
+		max, _, err := unstructured.NestedInt64(u.Object, "spec", "maxReplicas")
+		if err != nil {
+			return err
+		}
+		if max < min {
+			return unstructured.SetNestedField(u.Object, min, "spec", "maxReplicas")
+		}
+		return nil
+	}
+}
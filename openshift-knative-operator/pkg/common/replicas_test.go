@@ -0,0 +1,84 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestOverrideAutoscaledReplicas(t *testing.T) {
+	hpa := func(target string, min, max int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "autoscaling/v2beta2",
+			"kind":       "HorizontalPodAutoscaler",
+			"metadata": map[string]interface{}{
+				"name": target,
+			},
+			"spec": map[string]interface{}{
+				"minReplicas": min,
+				"maxReplicas": max,
+				"scaleTargetRef": map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"name":       target,
+				},
+			},
+		}}
+	}
+	overrides := []v1alpha1.DeploymentOverride{{
+		Name:     "activator",
+		Replicas: 3,
+	}, {
+		Name:     "webhook",
+		Replicas: 8,
+	}, {
+		Name:   "controller",
+		Labels: map[string]string{"foo": "bar"},
+	}}
+
+	tests := []struct {
+		name string
+		in   *unstructured.Unstructured
+		want *unstructured.Unstructured
+	}{{
+		name: "overridden",
+		in:   hpa("activator", 1, 20),
+		want: hpa("activator", 3, 20),
+	}, {
+		name: "overridden above maximum",
+		in:   hpa("webhook", 1, 5),
+		want: hpa("webhook", 8, 8),
+	}, {
+		name: "no replicas overridden",
+		in:   hpa("controller", 1, 5),
+		want: hpa("controller", 1, 5),
+	}, {
+		name: "not overridden",
+		in:   hpa("autoscaler", 1, 5),
+		want: hpa("autoscaler", 1, 5),
+	}, {
+		name: "not an autoscaler",
+		in: &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":     "Deployment",
+			"metadata": map[string]interface{}{"name": "activator"},
+		}},
+		want: &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind":     "Deployment",
+			"metadata": map[string]interface{}{"name": "activator"},
+		}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.in.DeepCopy()
+			if err := OverrideAutoscaledReplicas(overrides)(got); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got, test.want, cmp.Diff(got, test.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TelemetryCollectorImageKey is the key of the image of the OpenTelemetry collector in the
+	// image overrides, set through the IMAGE_otel-collector environment variable.
+	TelemetryCollectorImageKey = "otel-collector"
+
+	telemetryCollectorName = "knative-otel-collector"
+	telemetryConfigKey     = "collector.yaml"
+	telemetryConfigHashKey = "operator.serverless.openshift.io/collector-config-hash"
+
+	zipkinPort     = 9411
+	opencensusPort = 55678
+)
+
+// Telemetry configures an OpenTelemetry collector deployed alongside a Knative component, which
+// receives its traces and metrics and forwards them to the configured exporters, for example:
+//
+//	{"traces": true, "metrics": true, "exporters": {"otlp": {"endpoint": "tempo.observability:4317"}}}
+type Telemetry struct {
+	// Traces makes the component send its traces to the collector.
+	Traces bool `json:"traces,omitempty"`
+	// Metrics makes the component push its metrics to the collector. They're no longer
+	// exposed to Prometheus then, which disables the monitoring by OpenShift.
+	Metrics bool `json:"metrics,omitempty"`
+	// Exporters are the exporters of the collector in its own configuration format, keyed by
+	// their name. All of them receive all enabled signals.
+	Exporters map[string]json.RawMessage `json:"exporters"`
+}
+
+// TelemetryFromAnnotation parses the telemetry settings in the given annotation of the given
+// component. It returns nil if none are set.
+func TelemetryFromAnnotation(comp metav1.Object, annotation string) (*Telemetry, error) {
+	raw, ok := comp.GetAnnotations()[annotation]
+	if !ok {
+		return nil, nil
+	}
+
+	t := &Telemetry{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(t); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", annotation, err)
+	}
+	if !t.Traces && !t.Metrics {
+		return nil, fmt.Errorf("invalid %s: traces, metrics or both must be enabled", annotation)
+	}
+	if len(t.Exporters) == 0 {
+		return nil, fmt.Errorf("invalid %s: at least one exporter must be configured", annotation)
+	}
+	for name, exporter := range t.Exporters {
+		var config map[string]interface{}
+		if err := json.Unmarshal(exporter, &config); err != nil {
+			return nil, fmt.Errorf("invalid %s: exporter %q must be an object: %w", annotation, name, err)
+		}
+	}
+	return t, nil
+}
+
+// Apply points the tracing and metrics of the component in the given namespace to the
+// collector, overriding the respective ConfigMap keys.
+func (t *Telemetry) Apply(spec *v1alpha1.CommonSpec, namespace string) {
+	host := fmt.Sprintf("%s.%s.svc", telemetryCollectorName, namespace)
+	if t.Traces {
+		Configure(spec, "tracing", "backend", "zipkin")
+		Configure(spec, "tracing", "zipkin-endpoint", fmt.Sprintf("http://%s:%d/api/v2/spans", host, zipkinPort))
+	}
+	if t.Metrics {
+		Configure(spec, "observability", "metrics.backend-destination", "opencensus")
+		Configure(spec, "observability", "metrics.opencensus-address", fmt.Sprintf("%s:%d", host, opencensusPort))
+	}
+}
+
+// config renders the configuration of the collector.
+func (t *Telemetry) config() (string, error) {
+	exporters := make(map[string]interface{}, len(t.Exporters))
+	names := make([]string, 0, len(t.Exporters))
+	for name, raw := range t.Exporters {
+		var exporter interface{}
+		if err := json.Unmarshal(raw, &exporter); err != nil {
+			return "", err
+		}
+		exporters[name] = exporter
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	receivers := map[string]interface{}{}
+	pipelines := map[string]interface{}{}
+	if t.Traces {
+		receivers["zipkin"] = map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", zipkinPort)}
+		pipelines["traces"] = map[string]interface{}{"receivers": []string{"zipkin"}, "exporters": names}
+	}
+	if t.Metrics {
+		receivers["opencensus"] = map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", opencensusPort)}
+		pipelines["metrics"] = map[string]interface{}{"receivers": []string{"opencensus"}, "exporters": names}
+	}
+	config, err := yaml.Marshal(map[string]interface{}{
+		"receivers": receivers,
+		"exporters": exporters,
+		"service":   map[string]interface{}{"pipelines": pipelines},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render the collector config: %w", err)
+	}
+	return string(config), nil
+}
+
+// Manifest returns the ConfigMap, Deployment and Service of the collector of the component in
+// the given namespace, running the given image.
+func (t *Telemetry) Manifest(namespace, image string) (*mf.Manifest, error) {
+	config, err := t.config()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(config))
+	labels := map[string]string{"app": telemetryCollectorName}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: telemetryCollectorName, Namespace: namespace, Labels: labels},
+		Data:       map[string]string{telemetryConfigKey: config},
+	}
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: telemetryCollectorName, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					// Restart the collector once its config changes.
+					Annotations: map[string]string{telemetryConfigHashKey: hex.EncodeToString(hash[:])},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "collector",
+						Image: image,
+						Args:  []string{"--config=/conf/" + telemetryConfigKey},
+						Ports: []corev1.ContainerPort{
+							{Name: "zipkin", ContainerPort: zipkinPort},
+							{Name: "opencensus", ContainerPort: opencensusPort},
+						},
+						VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/conf", ReadOnly: true}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: telemetryCollectorName},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	service := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: telemetryCollectorName, Namespace: namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "zipkin", Port: zipkinPort, TargetPort: intstr.FromInt(zipkinPort)},
+				{Name: "opencensus", Port: opencensusPort, TargetPort: intstr.FromInt(opencensusPort)},
+			},
+		},
+	}
+
+	us := make([]unstructured.Unstructured, 0, 3)
+	for _, obj := range []interface{}{configMap, deployment, service} {
+		u := unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(obj, &u, nil); err != nil {
+			return nil, err
+		}
+		us = append(us, u)
+	}
+	manifest, err := mf.ManifestFrom(mf.Slice(us))
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// DeleteTelemetryCollector removes the collector from the given namespace once telemetry is
+// disabled, as it's no longer part of the manifest.
+func DeleteTelemetryCollector(ctx context.Context, kube kubernetes.Interface, namespace string) error {
+	deletes := []func() error{
+		func() error {
+			return kube.AppsV1().Deployments(namespace).Delete(ctx, telemetryCollectorName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return kube.CoreV1().Services(namespace).Delete(ctx, telemetryCollectorName, metav1.DeleteOptions{})
+		},
+		func() error {
+			return kube.CoreV1().ConfigMaps(namespace).Delete(ctx, telemetryCollectorName, metav1.DeleteOptions{})
+		},
+	}
+	for _, del := range deletes {
+		if err := del(); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the telemetry collector in %s: %w", namespace, err)
+		}
+	}
+	return nil
+}
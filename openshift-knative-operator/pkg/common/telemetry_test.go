@@ -0,0 +1,131 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+const telemetryAnnotation = "test/telemetry"
+
+func TestTelemetryFromAnnotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      map[string]string
+		want    bool
+		wantErr bool
+	}{{
+		name: "no annotation",
+	}, {
+		name: "traces",
+		in:   map[string]string{telemetryAnnotation: `{"traces": true, "exporters": {"logging": {}}}`},
+		want: true,
+	}, {
+		name:    "no json",
+		in:      map[string]string{telemetryAnnotation: "otlp"},
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		in:      map[string]string{telemetryAnnotation: `{"traces": true, "logs": true, "exporters": {"logging": {}}}`},
+		wantErr: true,
+	}, {
+		name:    "no signals",
+		in:      map[string]string{telemetryAnnotation: `{"exporters": {"logging": {}}}`},
+		wantErr: true,
+	}, {
+		name:    "no exporters",
+		in:      map[string]string{telemetryAnnotation: `{"metrics": true}`},
+		wantErr: true,
+	}, {
+		name:    "exporter no object",
+		in:      map[string]string{telemetryAnnotation: `{"metrics": true, "exporters": {"logging": "debug"}}`},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			comp := &metav1.ObjectMeta{Annotations: test.in}
+			got, err := TelemetryFromAnnotation(comp, telemetryAnnotation)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("TelemetryFromAnnotation() = %v, wantErr %v", err, test.wantErr)
+			}
+			if (got != nil) != test.want {
+				t.Errorf("TelemetryFromAnnotation() = %v, want settings: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTelemetryApply(t *testing.T) {
+	spec := &v1alpha1.CommonSpec{}
+	(&Telemetry{Traces: true, Metrics: true}).Apply(spec, "knative-serving")
+
+	want := v1alpha1.ConfigMapData{
+		"tracing": {
+			"backend":         "zipkin",
+			"zipkin-endpoint": "http://knative-otel-collector.knative-serving.svc:9411/api/v2/spans",
+		},
+		"observability": {
+			"metrics.backend-destination": "opencensus",
+			"metrics.opencensus-address":  "knative-otel-collector.knative-serving.svc:55678",
+		},
+	}
+	if !cmp.Equal(spec.Config, want) {
+		t.Errorf("Got = %v, want: %v, diff:\n%s", spec.Config, want, cmp.Diff(want, spec.Config))
+	}
+}
+
+func TestTelemetryManifest(t *testing.T) {
+	telemetry, err := TelemetryFromAnnotation(&metav1.ObjectMeta{Annotations: map[string]string{
+		telemetryAnnotation: `{"traces": true, "exporters": {"otlp": {"endpoint": "tempo:4317"}}}`,
+	}}, telemetryAnnotation)
+	if err != nil {
+		t.Fatal("Failed to parse telemetry", err)
+	}
+	manifest, err := telemetry.Manifest("knative-serving", "collector:latest")
+	if err != nil {
+		t.Fatal("Failed to create the manifest", err)
+	}
+
+	kinds := make([]string, 0, len(manifest.Resources()))
+	for _, u := range manifest.Resources() {
+		kinds = append(kinds, u.GetKind())
+		if u.GetNamespace() != "knative-serving" {
+			t.Errorf("%s namespace = %q, want knative-serving", u.GetKind(), u.GetNamespace())
+		}
+	}
+	if want := []string{"ConfigMap", "Deployment", "Service"}; !cmp.Equal(kinds, want) {
+		t.Errorf("Got kinds %v, want: %v", kinds, want)
+	}
+
+	config := manifest.Resources()[0].Object["data"].(map[string]interface{})[telemetryConfigKey].(string)
+	for _, want := range []string{"zipkin:", "endpoint: tempo:4317", "traces:"} {
+		if !strings.Contains(config, want) {
+			t.Errorf("Config doesn't contain %q:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "opencensus") {
+		t.Errorf("Config receives metrics, which are disabled:\n%s", config)
+	}
+}
+
+func TestDeleteTelemetryCollector(t *testing.T) {
+	ctx := context.Background()
+	kube := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: telemetryCollectorName, Namespace: "knative-serving"},
+	})
+
+	// Missing resources are fine.
+	if err := DeleteTelemetryCollector(ctx, kube, "knative-serving"); err != nil {
+		t.Fatal("Failed to delete the collector", err)
+	}
+	if _, err := kube.CoreV1().ConfigMaps("knative-serving").Get(ctx, telemetryCollectorName, metav1.GetOptions{}); err == nil {
+		t.Error("The config of the collector still exists")
+	}
+}
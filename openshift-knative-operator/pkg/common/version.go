@@ -0,0 +1,96 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"k8s.io/client-go/discovery"
+	knativeversion "knative.dev/pkg/version"
+)
+
+const (
+	// AllowUnsupportedVersionEnvKey is the environment variable that, if "true", lets the
+	// operator run on Kubernetes versions older than MinimumKubernetesVersion, e.g. on lab
+	// clusters. The components then carry a SupportedConfiguration warning instead of the
+	// operator failing on startup.
+	AllowUnsupportedVersionEnvKey = "ALLOW_UNSUPPORTED_VERSION"
+
+	// MinimumKubernetesVersion is the oldest supported version of Kubernetes, which is also
+	// the minimum knative.dev/pkg checks on startup.
+	MinimumKubernetesVersion = "v1.19.0"
+)
+
+// AllowUnsupportedVersion lowers the minimum version of Kubernetes knative.dev/pkg checks on
+// startup if unsupported versions are allowed, unless it's configured explicitly. It returns
+// whether unsupported versions are allowed.
+func AllowUnsupportedVersion() bool {
+	if !strings.EqualFold(os.Getenv(AllowUnsupportedVersionEnvKey), "true") {
+		return false
+	}
+	if os.Getenv(knativeversion.KubernetesMinVersionKey) == "" {
+		os.Setenv(knativeversion.KubernetesMinVersionKey, "v0.0.0")
+	}
+	return true
+}
+
+// UnsupportedVersionViolations returns a violation of the supported configuration if the
+// cluster runs a version of Kubernetes older than MinimumKubernetesVersion.
+func UnsupportedVersionViolations(versioner discovery.ServerVersionInterface) ([]string, error) {
+	v, err := versioner.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	// Versions that can't be parsed can't be checked either.
+	if _, err := semver.Make(normalizeVersion(v.GitVersion)); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(v.GitVersion, MinimumKubernetesVersion); err != nil {
+		return []string{err.Error()}, nil
+	}
+	return nil, nil
+}
+
+// CheckMinimumVersion checks if the version in the arg meets the requirement or not.
+// It is similar logic with CheckMinimumVersion() in knative.dev/pkg/version.
+func CheckMinimumVersion(versioner discovery.ServerVersionInterface, version string) error {
+	v, err := versioner.ServerVersion()
+	if err != nil {
+		return err
+	}
+	return checkVersion(v.GitVersion, version)
+}
+
+// checkVersion checks if the current version meets the minimum version.
+func checkVersion(current, version string) error {
+	currentVersion, err := semver.Make(normalizeVersion(current))
+	if err != nil {
+		return err
+	}
+
+	minimumVersion, err := semver.Make(normalizeVersion(version))
+	if err != nil {
+		return err
+	}
+
+	// If no specific pre-release requirement is set, we default to "-0" to always allow
+	// pre-release versions of the same Major.Minor.Patch version.
+	if len(minimumVersion.Pre) == 0 {
+		minimumVersion.Pre = []semver.PRVersion{{VersionNum: 0, IsNum: true}}
+	}
+
+	if currentVersion.LT(minimumVersion) {
+		return fmt.Errorf("kubernetes version %q is not compatible, need at least %q",
+			currentVersion, minimumVersion)
+	}
+	return nil
+}
+
+func normalizeVersion(v string) string {
+	if strings.HasPrefix(v, "v") {
+		// No need to account for unicode widths.
+		return v[1:]
+	}
+	return v
+}
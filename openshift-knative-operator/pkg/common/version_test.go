@@ -0,0 +1,119 @@
+package common
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/version"
+	knativeversion "knative.dev/pkg/version"
+)
+
+type testVersioner struct {
+	version string
+	err     error
+}
+
+func (t *testVersioner) ServerVersion() (*version.Info, error) {
+	return &version.Info{GitVersion: t.version}, t.err
+}
+
+func TestVersionCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		actualVersion *testVersioner
+		wantError     bool
+	}{{
+		name:          "greater version (patch)",
+		actualVersion: &testVersioner{version: "v1.20.0"},
+	}, {
+		name:          "greater version (patch), no v",
+		actualVersion: &testVersioner{version: "1.20.0"},
+	}, {
+		name:          "greater version (patch), pre-release",
+		actualVersion: &testVersioner{version: "1.20.2-kpn-065dce"},
+	}, {
+		name:          "greater version (patch), pre-release with build",
+		actualVersion: &testVersioner{version: "1.20.0-1095+9689d22dc3121e-dirty"},
+	}, {
+		name:          "greater version (minor)",
+		actualVersion: &testVersioner{version: "v1.20.0"},
+	}, {
+		name:          "same version",
+		actualVersion: &testVersioner{version: "v1.20.0"},
+	}, {
+		name:          "same version with build",
+		actualVersion: &testVersioner{version: "v1.20.0+k3s.1"},
+	}, {
+		name:          "same version with pre-release",
+		actualVersion: &testVersioner{version: "v1.20.0-k3s.1"},
+	}, {
+		name:          "smaller version",
+		actualVersion: &testVersioner{version: "v1.19.3"},
+		wantError:     true,
+	}, {
+		name:          "error while fetching",
+		actualVersion: &testVersioner{err: errors.New("random error")},
+		wantError:     true,
+	}, {
+		name:          "unparseable actual version",
+		actualVersion: &testVersioner{version: "v1.19.foo"},
+		wantError:     true,
+	}}
+
+	minVersion := "1.20.0"
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckMinimumVersion(test.actualVersion, minVersion)
+			if err == nil && test.wantError {
+				t.Errorf("Expected an error for minimum: %q, actual: %v", minVersion, test.actualVersion)
+			}
+
+			if err != nil && !test.wantError {
+				t.Errorf("Expected no error but got %v for minimum: %q, actual: %v", err, minVersion, test.actualVersion)
+			}
+		})
+	}
+}
+
+func TestUnsupportedVersionViolations(t *testing.T) {
+	got, err := UnsupportedVersionViolations(&testVersioner{version: "v1.19.3"})
+	if err != nil || len(got) != 0 {
+		t.Errorf("UnsupportedVersionViolations() = %v, %v, want none", got, err)
+	}
+
+	got, err = UnsupportedVersionViolations(&testVersioner{version: "v1.18.2"})
+	want := `kubernetes version "1.18.2" is not compatible, need at least "1.19.0-0"`
+	if err != nil || len(got) != 1 || got[0] != want {
+		t.Errorf("UnsupportedVersionViolations() = %v, %v, want %q", got, err, want)
+	}
+
+	if _, err := UnsupportedVersionViolations(&testVersioner{err: errors.New("random error")}); err == nil {
+		t.Error("UnsupportedVersionViolations() = nil, want the error of fetching the version")
+	}
+	if _, err := UnsupportedVersionViolations(&testVersioner{version: "v1.19.foo"}); err == nil {
+		t.Error("UnsupportedVersionViolations() = nil, want the error of parsing the version")
+	}
+}
+
+func TestAllowUnsupportedVersion(t *testing.T) {
+	defer os.Setenv(knativeversion.KubernetesMinVersionKey, os.Getenv(knativeversion.KubernetesMinVersionKey))
+	defer os.Setenv(AllowUnsupportedVersionEnvKey, os.Getenv(AllowUnsupportedVersionEnvKey))
+	os.Unsetenv(knativeversion.KubernetesMinVersionKey)
+	os.Unsetenv(AllowUnsupportedVersionEnvKey)
+	if AllowUnsupportedVersion() {
+		t.Error("AllowUnsupportedVersion() = true without the env var")
+	}
+	if got := os.Getenv(knativeversion.KubernetesMinVersionKey); got != "" {
+		t.Errorf("Got minimum version %q, want it unset", got)
+	}
+
+	os.Setenv(AllowUnsupportedVersionEnvKey, "true")
+	if !AllowUnsupportedVersion() {
+		t.Error("AllowUnsupportedVersion() = false, want true")
+	}
+	if got := os.Getenv(knativeversion.KubernetesMinVersionKey); got != "v0.0.0" {
+		t.Errorf("Got minimum version %q, want it lowered", got)
+	}
+}
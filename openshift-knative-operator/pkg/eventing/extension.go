@@ -8,12 +8,17 @@ import (
 	mf "github.com/manifestival/manifestival"
 	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/monitoring"
+	apixclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	operator "knative.dev/operator/pkg/reconciler/common"
+	apixclient "knative.dev/pkg/client/injection/apiextensions/client"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/logging"
 )
 
 const requiredNsEnvName = "REQUIRED_EVENTING_NAMESPACE"
@@ -21,20 +26,50 @@ const requiredNsEnvName = "REQUIRED_EVENTING_NAMESPACE"
 // NewExtension creates a new extension for a Knative Eventing controller.
 func NewExtension(ctx context.Context) operator.Extension {
 	return &extension{
-		kubeclient: kubeclient.Get(ctx),
+		kubeclient:    kubeclient.Get(ctx),
+		apixclient:    apixclient.Get(ctx),
+		dynamicclient: dynamicclient.Get(ctx),
 	}
 }
 
 type extension struct {
-	kubeclient kubernetes.Interface
+	kubeclient    kubernetes.Interface
+	apixclient    apixclientset.Interface
+	dynamicclient dynamic.Interface
+	// features reports the feature flags Eventing is running with.
+	features common.FeatureRecorder
 }
 
-func (e *extension) Manifests(ke v1alpha1.KComponent) ([]mf.Manifest, error) {
-	return monitoring.GetEventingMonitoringPlatformManifests(ke)
+func (e *extension) Manifests(comp v1alpha1.KComponent) ([]mf.Manifest, error) {
+	manifests, err := monitoring.GetEventingMonitoringPlatformManifests(comp)
+	if err != nil {
+		return nil, err
+	}
+	ke := comp.(*v1alpha1.KnativeEventing)
+	// The mode is validated by Reconcile already.
+	mode, _ := ServiceMesh(ke)
+	mesh, err := serviceMeshManifest(ke, mode)
+	if err != nil {
+		return nil, err
+	}
+	// The settings are validated by Reconcile already.
+	telemetry, _ := TelemetryFromAnnotation(ke)
+	collector, err := telemetryManifest(ke, telemetry)
+	if err != nil {
+		return nil, err
+	}
+	return append(manifests, mesh, collector), nil
 }
 
 func (e *extension) Transformers(ke v1alpha1.KComponent) []mf.Transformer {
-	return monitoring.GetEventingTransformers(ke)
+	// The overrides are validated by Reconcile already.
+	overrides, _ := WorkloadOverridesFromAnnotation(ke.(*v1alpha1.KnativeEventing))
+	mode, _ := ServiceMesh(ke.(*v1alpha1.KnativeEventing))
+	return append([]mf.Transformer{
+		common.OverrideAutoscaledReplicas(ke.GetSpec().GetDeploymentOverride()),
+		overrideWorkloads(overrides),
+		serviceMeshTransform(mode),
+	}, monitoring.GetEventingTransformers(ke)...)
 }
 
 func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) error {
@@ -46,12 +81,60 @@ func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) err
 		return controller.NewPermanentError(fmt.Errorf("deployed Knative Eventing into unsupported namespace %q", ke.Namespace))
 	}
 
+	if _, err := WorkloadOverridesFromAnnotation(ke); err != nil {
+		ke.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+
+	// Make the data plane join or leave the mesh.
+	mode, err := ServiceMesh(ke)
+	if err != nil {
+		ke.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+	if mode == ServiceMeshDisabled {
+		if err := deletePeerAuthentication(ctx, e.dynamicclient, ke.Namespace); err != nil {
+			return err
+		}
+	} else {
+		installed, err := serviceMeshInstalled(ctx, e.apixclient)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			ke.Status.MarkInstallFailed(errServiceMeshMissing.Error())
+			return errServiceMeshMissing
+		}
+	}
+
 	// Override images.
 	// TODO(SRVCOM-1069): Rethink overriding behavior and/or error surfacing.
 	images := common.ImageMapFromEnvironment(os.Environ())
 	ke.Spec.Registry.Override = images
 	ke.Spec.Registry.Default = images["default"]
 
+	// Point the tracing and metrics to the telemetry collector, or remove it once it's disabled.
+	telemetry, err := TelemetryFromAnnotation(ke)
+	if err != nil {
+		ke.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+	if telemetry != nil {
+		telemetry.Apply(&ke.Spec.CommonSpec, ke.Namespace)
+	} else if err := common.DeleteTelemetryCollector(ctx, e.kubeclient, ke.Namespace); err != nil {
+		return err
+	}
+
+	// Label the namespace with the pod security levels the components are admitted by.
+	podSecurity, err := PodSecurityFromAnnotation(ke)
+	if err != nil {
+		ke.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+	if err := common.ReconcilePodSecurityLabels(ctx, e.kubeclient, ke.Namespace, podSecurity); err != nil {
+		return err
+	}
+
 	// Ensure webhook has 1G of memory.
 	common.EnsureContainerMemoryLimit(&ke.Spec.CommonSpec, "eventing-webhook", resource.MustParse("1024Mi"))
 
@@ -67,6 +150,29 @@ func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) err
 		}
 	}
 
+	// Install Knative Eventing without the in-memory channel if it's disabled.
+	if inMemoryChannelDisabled(ke) {
+		if err := disableInMemoryChannel(ke); err != nil {
+			return err
+		}
+		if err := deleteInMemoryChannelCRD(ctx, e.apixclient); err != nil {
+			return err
+		}
+	}
+
+	// Warn about configurations that are not supported to aid the triage of support cases.
+	violations := supportedConfigPolicy.Violations(&ke.Spec.CommonSpec)
+	if unsupported, err := common.UnsupportedVersionViolations(e.kubeclient.Discovery()); err != nil {
+		logging.FromContext(ctx).Warnf("Could not check for an unsupported Kubernetes version: %v", err)
+	} else {
+		violations = append(unsupported, violations...)
+	}
+	common.MarkSupportedConfiguration(&ke.Status, violations)
+
+	if err := e.features.ReportFeatures(ctx, e.kubeclient, "eventing", ke.Namespace, &ke.Status.Status); err != nil {
+		return err
+	}
+
 	return monitoring.ReconcileMonitoringForEventing(ctx, e.kubeclient, ke)
 }
 
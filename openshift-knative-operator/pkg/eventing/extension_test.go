@@ -14,12 +14,20 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"knative.dev/pkg/apis"
+	apixfake "knative.dev/pkg/client/injection/apiextensions/client/fake"
 	kubefake "knative.dev/pkg/client/injection/kube/client/fake"
+	dynamicfake "knative.dev/pkg/injection/clients/dynamicclient/fake"
 )
 
-const requiredNs = "knative-eventing"
+const (
+	requiredNs        = "knative-eventing"
+	defaultK8sVersion = "v1.20.0"
+)
 
 var (
 	eventingNamespace = corev1.Namespace{
@@ -98,6 +106,16 @@ func TestReconcile(t *testing.T) {
 			ke.Namespace = "foo"
 			ke.Status.MarkInstallFailed(`Knative Eventing must be installed into the namespace "knative-eventing"`)
 		}),
+	}, {
+		name: "Invalid workload overrides",
+		in: ke(func(ke *v1alpha1.KnativeEventing) {
+			ke.Annotations = map[string]string{WorkloadOverridesAnnotation: `[{"name": "activator"}]`}
+		}),
+		expected: ke(func(ke *v1alpha1.KnativeEventing) {
+			ke.Annotations = map[string]string{WorkloadOverridesAnnotation: `[{"name": "activator"}]`}
+			ke.Status.MarkInstallFailed("invalid " + WorkloadOverridesAnnotation + `: "activator" is not a Knative Eventing deployment, must be one of ` +
+				"eventing-controller, eventing-webhook, imc-controller, imc-dispatcher, mt-broker-controller, mt-broker-filter, mt-broker-ingress, pingsource-mt-adapter, sugar-controller")
+		}),
 	}}
 
 	for _, c := range cases {
@@ -108,7 +126,10 @@ func TestReconcile(t *testing.T) {
 			}
 
 			ke := c.in.DeepCopy()
-			ctx, _ := kubefake.With(context.Background(), &eventingNamespace)
+			ctx, kube := kubefake.With(context.Background(), &eventingNamespace)
+			withServerVersion(t, kube)
+			ctx, _ = apixfake.With(ctx)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
 			ext := NewExtension(ctx)
 			ext.Reconcile(context.Background(), ke)
 
@@ -242,6 +263,9 @@ func TestMonitoring(t *testing.T) {
 			c.expected.Namespace = ke.Namespace
 			ctx, _ := ocpfake.With(context.Background(), objs...)
 			ctx, kube := kubefake.With(ctx, &eventingNamespace)
+			withServerVersion(t, kube)
+			ctx, _ = apixfake.With(ctx)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
 			ext := NewExtension(ctx)
 			shouldEnableMonitoring, err := c.setupMonitoringToggle()
 
@@ -304,3 +328,15 @@ func ke(mods ...func(*v1alpha1.KnativeEventing)) *v1alpha1.KnativeEventing {
 
 	return base
 }
+
+// withServerVersion makes the given fake client report a supported version of Kubernetes,
+// rather than the version client-go was built with.
+func withServerVersion(t *testing.T, kube kubernetes.Interface) {
+	fakeDiscovery, ok := kube.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+	fakeDiscovery.FakedServerVersion = &version.Info{
+		GitVersion: defaultK8sVersion,
+	}
+}
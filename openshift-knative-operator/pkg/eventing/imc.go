@@ -0,0 +1,87 @@
+package eventing
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	apixclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	operator "knative.dev/operator/pkg/reconciler/common"
+)
+
+const (
+	// DisableInMemoryChannelAnnotation disables the in-memory channel if set to "true" on the
+	// KnativeEventing CR. Its data plane and CRD are removed, alongside all existing
+	// InMemoryChannels, and channels default to KafkaChannels instead.
+	DisableInMemoryChannelAnnotation = "eventing.knative.openshift.io/disableInMemoryChannel"
+
+	// inMemoryChannelManifestSuffix identifies the manifest of the in-memory channel in kodata.
+	inMemoryChannelManifestSuffix = "in-memory-channel.yaml"
+	inMemoryChannelCRD            = "inmemorychannels.messaging.knative.dev"
+
+	// kafkaChannelDefault is the channel template channels default to if the in-memory
+	// channel is disabled.
+	kafkaChannelDefault = `clusterDefault:
+  apiVersion: messaging.knative.dev/v1beta1
+  kind: KafkaChannel
+`
+)
+
+// inMemoryChannelDisabled returns whether the in-memory channel is disabled for the given
+// KnativeEventing.
+func inMemoryChannelDisabled(ke *v1alpha1.KnativeEventing) bool {
+	disabled, _ := strconv.ParseBool(ke.GetAnnotations()[DisableInMemoryChannelAnnotation])
+	return disabled
+}
+
+// disableInMemoryChannel makes the given KnativeEventing be installed without the in-memory
+// channel by explicitly listing the shipped manifests except for the in-memory channel's.
+// Upstream then removes the resources of the in-memory channel, except for its CRD, as
+// they're no longer part of the manifest.
+//
+// Custom manifests are respected as is.
+func disableInMemoryChannel(ke *v1alpha1.KnativeEventing) error {
+	common.ConfigureIfUnset(&ke.Spec.CommonSpec, "default-ch-webhook", "default-ch-config", kafkaChannelDefault)
+
+	if len(ke.Spec.Manifests) > 0 {
+		return nil
+	}
+	version := operator.TargetVersion(ke)
+	dir := filepath.Join(os.Getenv(operator.KoEnvKey), "knative-eventing", version)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list the manifests of version %q: %w", version, err)
+	}
+
+	manifests := make([]v1alpha1.Manifest, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || strings.HasSuffix(file.Name(), inMemoryChannelManifestSuffix) {
+			continue
+		}
+		manifests = append(manifests, v1alpha1.Manifest{Url: filepath.Join(dir, file.Name())})
+	}
+
+	// The version is no longer derived from the shipped manifests once they're listed
+	// explicitly, so it has to be pinned.
+	ke.Spec.Version = version
+	ke.Spec.Manifests = manifests
+	return nil
+}
+
+// deleteInMemoryChannelCRD removes the CRD of the in-memory channel, which upstream never
+// removes on its own.
+func deleteInMemoryChannelCRD(ctx context.Context, client apixclientset.Interface) error {
+	err := client.ApiextensionsV1().CustomResourceDefinitions().Delete(ctx, inMemoryChannelCRD, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the in-memory channel CRD: %w", err)
+	}
+	return nil
+}
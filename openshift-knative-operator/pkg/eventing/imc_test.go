@@ -0,0 +1,107 @@
+package eventing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	operator "knative.dev/operator/pkg/reconciler/common"
+	apixfake "knative.dev/pkg/client/injection/apiextensions/client/fake"
+	kubefake "knative.dev/pkg/client/injection/kube/client/fake"
+	dynamicfake "knative.dev/pkg/injection/clients/dynamicclient/fake"
+)
+
+func TestDisableInMemoryChannel(t *testing.T) {
+	koData, err := ioutil.TempDir("", "kodata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(koData)
+	dir := filepath.Join(koData, "knative-eventing", "0.25.1")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"1-eventing-crds.yaml", "2-eventing-core.yaml", "3-in-memory-channel.yaml", "4-mt-channel-broker.yaml"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Setenv(operator.KoEnvKey, os.Getenv(operator.KoEnvKey))
+	os.Setenv(operator.KoEnvKey, koData)
+
+	custom := []v1alpha1.Manifest{{Url: "https://example.com/eventing.yaml"}}
+
+	tests := []struct {
+		name          string
+		annotation    string
+		manifests     []v1alpha1.Manifest
+		wantManifests []v1alpha1.Manifest
+		wantVersion   string
+		wantDisabled  bool
+	}{{
+		name: "enabled",
+	}, {
+		name:       "explicitly enabled",
+		annotation: "false",
+	}, {
+		name:       "disabled",
+		annotation: "true",
+		wantManifests: []v1alpha1.Manifest{
+			{Url: filepath.Join(dir, "1-eventing-crds.yaml")},
+			{Url: filepath.Join(dir, "2-eventing-core.yaml")},
+			{Url: filepath.Join(dir, "4-mt-channel-broker.yaml")},
+		},
+		wantVersion:  "0.25.1",
+		wantDisabled: true,
+	}, {
+		name:          "disabled with custom manifests",
+		annotation:    "true",
+		manifests:     custom,
+		wantManifests: custom,
+		wantDisabled:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ke := &v1alpha1.KnativeEventing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-eventing", Namespace: requiredNs},
+			}
+			ke.Spec.Manifests = test.manifests
+			if test.annotation != "" {
+				ke.Annotations = map[string]string{DisableInMemoryChannelAnnotation: test.annotation}
+			}
+
+			crd := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: inMemoryChannelCRD}}
+			ctx, _ := kubefake.With(context.Background(), &eventingNamespace)
+			ctx, apix := apixfake.With(ctx, crd)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
+			if err := NewExtension(ctx).Reconcile(context.Background(), ke); err != nil {
+				t.Fatal(err)
+			}
+
+			if !cmp.Equal(ke.Spec.Manifests, test.wantManifests) {
+				t.Errorf("Got manifests = %v, want: %v, diff:\n%s", ke.Spec.Manifests, test.wantManifests, cmp.Diff(ke.Spec.Manifests, test.wantManifests))
+			}
+			if ke.Spec.Version != test.wantVersion {
+				t.Errorf("Got version = %q, want: %q", ke.Spec.Version, test.wantVersion)
+			}
+
+			_, hasDefault := ke.Spec.Config["default-ch-webhook"]["default-ch-config"]
+			if hasDefault != test.wantDisabled {
+				t.Errorf("Got default channel configured = %v, want: %v", hasDefault, test.wantDisabled)
+			}
+			_, err := apix.ApiextensionsV1().CustomResourceDefinitions().Get(context.Background(), inMemoryChannelCRD, metav1.GetOptions{})
+			if gotDeleted := apierrors.IsNotFound(err); gotDeleted != test.wantDisabled {
+				t.Errorf("Got CRD deleted = %v, want: %v", gotDeleted, test.wantDisabled)
+			}
+		})
+	}
+}
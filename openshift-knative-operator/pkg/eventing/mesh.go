@@ -0,0 +1,134 @@
+package eventing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	apixclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+const (
+	// ServiceMeshAnnotation makes the data plane of brokers and channels join OpenShift Service
+	// Mesh if set on the KnativeEventing CR, so that events flow over mTLS inside the mesh. It's
+	// either ServiceMeshDisabled (the default), ServiceMeshPermissive or ServiceMeshStrict.
+	//
+	// The namespace of Knative Eventing has to be a member of the ServiceMeshMemberRoll.
+	ServiceMeshAnnotation = "eventing.knative.openshift.io/serviceMesh"
+
+	// ServiceMeshDisabled keeps the data plane out of the mesh.
+	ServiceMeshDisabled = "disabled"
+	// ServiceMeshPermissive injects sidecars into the data plane, which accepts both mTLS
+	// traffic from within the mesh and plain text traffic from outside of it.
+	ServiceMeshPermissive = "permissive"
+	// ServiceMeshStrict injects sidecars into the data plane, which only accepts mTLS traffic
+	// from within the mesh. Event sources and subscribers have to be part of the mesh.
+	ServiceMeshStrict = "strict"
+
+	// peerAuthenticationCRD is installed alongside OpenShift Service Mesh.
+	peerAuthenticationCRD = "peerauthentications.security.istio.io"
+	// peerAuthenticationName is the name of the PeerAuthentication enforcing the mTLS mode.
+	peerAuthenticationName = "knative-eventing-data-plane"
+)
+
+var (
+	peerAuthenticationGVR = schema.GroupVersionResource{Group: "security.istio.io", Version: "v1beta1", Resource: "peerauthentications"}
+
+	// meshDataPlane are the deployments sending and receiving events, which join the mesh.
+	meshDataPlane = sets.NewString(
+		"imc-dispatcher",
+		"mt-broker-filter",
+		"mt-broker-ingress",
+		"pingsource-mt-adapter",
+	)
+
+	errServiceMeshMissing = errors.New("OpenShift Service Mesh must be installed to enable " + ServiceMeshAnnotation)
+)
+
+// ServiceMesh returns the service mesh mode selected by the given KnativeEventing.
+func ServiceMesh(ke *v1alpha1.KnativeEventing) (string, error) {
+	mode, ok := ke.GetAnnotations()[ServiceMeshAnnotation]
+	if !ok {
+		return ServiceMeshDisabled, nil
+	}
+	switch mode {
+	case ServiceMeshDisabled, ServiceMeshPermissive, ServiceMeshStrict:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q: must be %q, %q or %q", ServiceMeshAnnotation, mode,
+			ServiceMeshDisabled, ServiceMeshPermissive, ServiceMeshStrict)
+	}
+}
+
+// serviceMeshInstalled returns whether the CRDs of OpenShift Service Mesh are present.
+func serviceMeshInstalled(ctx context.Context, client apixclientset.Interface) (bool, error) {
+	_, err := client.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, peerAuthenticationCRD, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get the %s CRD: %w", peerAuthenticationCRD, err)
+	}
+	return true, nil
+}
+
+// deletePeerAuthentication removes the PeerAuthentication once the data plane leaves the mesh,
+// as it's no longer part of the manifest.
+func deletePeerAuthentication(ctx context.Context, client dynamic.Interface, namespace string) error {
+	err := client.Resource(peerAuthenticationGVR).Namespace(namespace).Delete(ctx, peerAuthenticationName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PeerAuthentication %s/%s: %w", namespace, peerAuthenticationName, err)
+	}
+	return nil
+}
+
+// serviceMeshManifest returns the PeerAuthentication enforcing the given mode on the data
+// plane of the given KnativeEventing, which is empty if the data plane isn't in the mesh.
+func serviceMeshManifest(ke *v1alpha1.KnativeEventing, mode string) (mf.Manifest, error) {
+	if mode == ServiceMeshDisabled {
+		return mf.Manifest{}, nil
+	}
+	mtlsMode := "PERMISSIVE"
+	if mode == ServiceMeshStrict {
+		mtlsMode = "STRICT"
+	}
+	pa := unstructured.Unstructured{}
+	pa.SetAPIVersion(peerAuthenticationGVR.GroupVersion().String())
+	pa.SetKind("PeerAuthentication")
+	pa.SetNamespace(ke.Namespace)
+	pa.SetName(peerAuthenticationName)
+	// Without a selector, the mode applies to all pods of the namespace with a sidecar, which
+	// are only the ones of the data plane.
+	if err := unstructured.SetNestedField(pa.Object, mtlsMode, "spec", "mtls", "mode"); err != nil {
+		return mf.Manifest{}, err
+	}
+	return mf.ManifestFrom(mf.Slice([]unstructured.Unstructured{pa}))
+}
+
+// serviceMeshTransform injects sidecars into the data plane if it's in the mesh. The
+// probes are rewritten by the sidecar, as they can't pass mTLS.
+func serviceMeshTransform(mode string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if mode == ServiceMeshDisabled || u.GetKind() != "Deployment" || !meshDataPlane.Has(u.GetName()) {
+			return nil
+		}
+		annotations, _, err := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "annotations")
+		if err != nil {
+			return err
+		}
+		if annotations == nil {
+			annotations = make(map[string]string, 2)
+		}
+		annotations["sidecar.istio.io/inject"] = "true"
+		annotations["sidecar.istio.io/rewriteAppHTTPProbers"] = "true"
+		return unstructured.SetNestedStringMap(u.Object, annotations, "spec", "template", "metadata", "annotations")
+	}
+}
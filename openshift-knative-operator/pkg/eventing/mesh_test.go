@@ -0,0 +1,138 @@
+package eventing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	apixfake "knative.dev/pkg/client/injection/apiextensions/client/fake"
+	kubefake "knative.dev/pkg/client/injection/kube/client/fake"
+	dynamicfake "knative.dev/pkg/injection/clients/dynamicclient/fake"
+)
+
+func TestServiceMeshTransform(t *testing.T) {
+	cases := []struct {
+		name       string
+		mode       string
+		deployment string
+		want       map[string]string
+	}{{
+		name:       "disabled",
+		mode:       ServiceMeshDisabled,
+		deployment: "mt-broker-ingress",
+	}, {
+		name:       "data plane",
+		mode:       ServiceMeshStrict,
+		deployment: "mt-broker-ingress",
+		want: map[string]string{
+			"sidecar.istio.io/inject":                "true",
+			"sidecar.istio.io/rewriteAppHTTPProbers": "true",
+		},
+	}, {
+		name:       "control plane",
+		mode:       ServiceMeshStrict,
+		deployment: "eventing-controller",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: c.deployment},
+			}
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(deployment, u, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if err := serviceMeshTransform(c.mode)(u); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			got, _, _ := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "annotations")
+			if !cmp.Equal(got, c.want) {
+				t.Errorf("Got unexpected annotations, diff: %s", cmp.Diff(got, c.want))
+			}
+		})
+	}
+}
+
+func TestServiceMeshManifest(t *testing.T) {
+	ke := &v1alpha1.KnativeEventing{ObjectMeta: metav1.ObjectMeta{Namespace: requiredNs}}
+
+	disabled, err := serviceMeshManifest(ke, ServiceMeshDisabled)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(disabled.Resources()) != 0 {
+		t.Errorf("Got %d resources without mesh, want 0", len(disabled.Resources()))
+	}
+
+	for mode, want := range map[string]string{ServiceMeshPermissive: "PERMISSIVE", ServiceMeshStrict: "STRICT"} {
+		manifest, err := serviceMeshManifest(ke, mode)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if len(manifest.Resources()) != 1 {
+			t.Fatalf("Got %d resources in mode %s, want 1", len(manifest.Resources()), mode)
+		}
+		pa := manifest.Resources()[0]
+		if pa.GetKind() != "PeerAuthentication" || pa.GetNamespace() != requiredNs || pa.GetName() != peerAuthenticationName {
+			t.Errorf("Got unexpected resource %s %s/%s", pa.GetKind(), pa.GetNamespace(), pa.GetName())
+		}
+		if got, _, _ := unstructured.NestedString(pa.Object, "spec", "mtls", "mode"); got != want {
+			t.Errorf("Got mTLS mode %q in mode %s, want %q", got, mode, want)
+		}
+	}
+}
+
+func TestReconcileServiceMesh(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: peerAuthenticationCRD}}
+
+	cases := []struct {
+		name     string
+		mode     string
+		crds     []runtime.Object
+		wantErr  bool
+		wantFail string
+	}{{
+		name: "mesh installed",
+		mode: ServiceMeshStrict,
+		crds: []runtime.Object{crd},
+	}, {
+		name:     "mesh missing",
+		mode:     ServiceMeshPermissive,
+		wantErr:  true,
+		wantFail: errServiceMeshMissing.Error(),
+	}, {
+		name:     "invalid mode",
+		mode:     "mutual",
+		wantErr:  true,
+		wantFail: `invalid ` + ServiceMeshAnnotation + ` "mutual": must be "disabled", "permissive" or "strict"`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ke := ke(func(ke *v1alpha1.KnativeEventing) {
+				ke.Annotations = map[string]string{ServiceMeshAnnotation: c.mode}
+			})
+			ctx, _ := kubefake.With(context.Background(), &eventingNamespace)
+			ctx, _ = apixfake.With(ctx, c.crds...)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
+
+			err := NewExtension(ctx).Reconcile(context.Background(), ke)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Reconcile() = %v, wantErr %v", err, c.wantErr)
+			}
+			cond := ke.Status.GetCondition(v1alpha1.InstallSucceeded)
+			if c.wantFail != "" && (cond == nil || cond.Message != "Install failed with message: "+c.wantFail) {
+				t.Errorf("Got condition %v, want the install to fail with %q", cond, c.wantFail)
+			}
+		})
+	}
+}
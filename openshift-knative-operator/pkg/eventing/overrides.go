@@ -0,0 +1,157 @@
+package eventing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// WorkloadOverridesAnnotation is the annotation on the KnativeEventing CR carrying resource
+// and environment overrides of the Eventing deployments as JSON, for example:
+//
+//	eventing.knative.openshift.io/workloadOverrides: |
+//	  [{"name": "mt-broker-ingress", "resources": {"limits": {"memory": "1Gi"}},
+//	    "env": [{"name": "GOMAXPROCS", "value": "4"}]}]
+//
+// Unlike spec.resources, which applies to all containers of the given name, the overrides
+// target a single deployment. They take precedence over spec.resources.
+const WorkloadOverridesAnnotation = "eventing.knative.openshift.io/workloadOverrides"
+
+// overridableDeployments are the deployments of Knative Eventing that can be overridden.
+var overridableDeployments = sets.NewString(
+	"eventing-controller",
+	"eventing-webhook",
+	"imc-controller",
+	"imc-dispatcher",
+	"mt-broker-controller",
+	"mt-broker-filter",
+	"mt-broker-ingress",
+	"pingsource-mt-adapter",
+	"sugar-controller",
+)
+
+// WorkloadOverride overrides the main container of an Eventing deployment.
+type WorkloadOverride struct {
+	// Name is the name of the deployment to override.
+	Name string `json:"name"`
+	// Resources are merged into the resources of the container, replacing the given limits
+	// and requests only.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Env is added to the environment of the container, replacing variables of the same name.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// WorkloadOverridesFromAnnotation parses the workload overrides of the given KnativeEventing.
+// It returns nil if none are set.
+func WorkloadOverridesFromAnnotation(ke *v1alpha1.KnativeEventing) ([]WorkloadOverride, error) {
+	raw, ok := ke.GetAnnotations()[WorkloadOverridesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	var overrides []WorkloadOverride
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", WorkloadOverridesAnnotation, err)
+	}
+
+	seen := sets.NewString()
+	for _, override := range overrides {
+		if seen.Has(override.Name) {
+			return nil, fmt.Errorf("invalid %s: %q is overridden more than once", WorkloadOverridesAnnotation, override.Name)
+		}
+		seen.Insert(override.Name)
+		if err := override.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", WorkloadOverridesAnnotation, err)
+		}
+	}
+	return overrides, nil
+}
+
+// Validate checks the override for consistency.
+func (o *WorkloadOverride) Validate() error {
+	if !overridableDeployments.Has(o.Name) {
+		return fmt.Errorf("%q is not a Knative Eventing deployment, must be one of %s", o.Name, strings.Join(overridableDeployments.List(), ", "))
+	}
+	for name, request := range o.Resources.Requests {
+		if limit, ok := o.Resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s: the %s request %s must not exceed its limit %s", o.Name, name, request.String(), limit.String())
+		}
+	}
+	names := sets.NewString()
+	for _, env := range o.Env {
+		if errs := validation.IsEnvVarName(env.Name); len(errs) > 0 {
+			return fmt.Errorf("%s: invalid environment variable %q: %s", o.Name, env.Name, strings.Join(errs, ", "))
+		}
+		if names.Has(env.Name) {
+			return fmt.Errorf("%s: environment variable %q is set more than once", o.Name, env.Name)
+		}
+		names.Insert(env.Name)
+	}
+	return nil
+}
+
+// overrideWorkloads applies the given overrides to the first container of the respective
+// deployments, which is the main one.
+func overrideWorkloads(overrides []WorkloadOverride) mf.Transformer {
+	byName := make(map[string]WorkloadOverride, len(overrides))
+	for _, override := range overrides {
+		byName[override.Name] = override
+	}
+
+	return func(u *unstructured.Unstructured) error {
+		override, ok := byName[u.GetName()]
+		if u.GetKind() != "Deployment" || !ok {
+			return nil
+		}
+
+		deployment := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(u, deployment, nil); err != nil {
+			return err
+		}
+		if len(deployment.Spec.Template.Spec.Containers) == 0 {
+			return nil
+		}
+		container := &deployment.Spec.Template.Spec.Containers[0]
+
+		mergeResources(&container.Resources.Limits, override.Resources.Limits)
+		mergeResources(&container.Resources.Requests, override.Resources.Requests)
+		for _, env := range override.Env {
+			container.Env = upsertEnv(container.Env, env)
+		}
+		return scheme.Scheme.Convert(deployment, u, nil)
+	}
+}
+
+func mergeResources(target *corev1.ResourceList, overrides corev1.ResourceList) {
+	if len(overrides) == 0 {
+		return
+	}
+	if *target == nil {
+		*target = make(corev1.ResourceList, len(overrides))
+	}
+	for name, quantity := range overrides {
+		(*target)[name] = quantity
+	}
+}
+
+func upsertEnv(envs []corev1.EnvVar, env corev1.EnvVar) []corev1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+	return append(envs, env)
+}
@@ -0,0 +1,137 @@
+package eventing
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestWorkloadOverridesFromAnnotation(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		wantErr    bool
+	}{{
+		name:       "valid",
+		annotation: `[{"name": "mt-broker-ingress", "resources": {"requests": {"cpu": "1"}, "limits": {"cpu": "2"}}, "env": [{"name": "FOO", "value": "bar"}]}]`,
+	}, {
+		name:       "malformed",
+		annotation: `[{"name": `,
+		wantErr:    true,
+	}, {
+		name:       "unknown field",
+		annotation: `[{"name": "mt-broker-ingress", "replicas": 3}]`,
+		wantErr:    true,
+	}, {
+		name:       "unknown deployment",
+		annotation: `[{"name": "activator"}]`,
+		wantErr:    true,
+	}, {
+		name:       "duplicate deployment",
+		annotation: `[{"name": "imc-dispatcher"}, {"name": "imc-dispatcher"}]`,
+		wantErr:    true,
+	}, {
+		name:       "request exceeds limit",
+		annotation: `[{"name": "mt-broker-filter", "resources": {"requests": {"memory": "2Gi"}, "limits": {"memory": "1Gi"}}}]`,
+		wantErr:    true,
+	}, {
+		name:       "invalid environment variable",
+		annotation: `[{"name": "mt-broker-filter", "env": [{"name": "1FOO", "value": "bar"}]}]`,
+		wantErr:    true,
+	}, {
+		name:       "duplicate environment variable",
+		annotation: `[{"name": "mt-broker-filter", "env": [{"name": "FOO", "value": "a"}, {"name": "FOO", "value": "b"}]}]`,
+		wantErr:    true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ke := &v1alpha1.KnativeEventing{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{WorkloadOverridesAnnotation: test.annotation},
+				},
+			}
+			_, err := WorkloadOverridesFromAnnotation(ke)
+			if (err != nil) != test.wantErr {
+				t.Errorf("WorkloadOverridesFromAnnotation() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestOverrideWorkloads(t *testing.T) {
+	deployment := func(name string, container corev1.Container) *unstructured.Unstructured {
+		d := &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{container, {Name: "kube-rbac-proxy"}},
+					},
+				},
+			},
+		}
+		u := &unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(d, u, nil); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	ingress := corev1.Container{
+		Name: "ingress",
+		Env:  []corev1.EnvVar{{Name: "FOO", Value: "foo"}, {Name: "BAR", Value: "bar"}},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("500Mi")},
+		},
+	}
+
+	overrides := []WorkloadOverride{{
+		Name: "mt-broker-ingress",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		Env: []corev1.EnvVar{{Name: "FOO", Value: "override"}, {Name: "BAZ", Value: "baz"}},
+	}}
+
+	tests := []struct {
+		name string
+		in   *unstructured.Unstructured
+		want *unstructured.Unstructured
+	}{{
+		name: "overridden",
+		in:   deployment("mt-broker-ingress", ingress),
+		want: deployment("mt-broker-ingress", corev1.Container{
+			Name: "ingress",
+			Env:  []corev1.EnvVar{{Name: "FOO", Value: "override"}, {Name: "BAR", Value: "bar"}, {Name: "BAZ", Value: "baz"}},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		}),
+	}, {
+		name: "not overridden",
+		in:   deployment("mt-broker-filter", ingress),
+		want: deployment("mt-broker-filter", ingress),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.in.DeepCopy()
+			if err := overrideWorkloads(overrides)(got); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got, test.want, cmp.Diff(got, test.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package eventing
+
+import (
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// PodSecurityAnnotation overrides the pod security admission levels of the namespace of
+// Knative Eventing if set on the KnativeEventing CR. See common.PodSecurity for its format.
+const PodSecurityAnnotation = "eventing.knative.openshift.io/pod-security"
+
+// PodSecurityFromAnnotation parses the pod security levels of the given KnativeEventing.
+func PodSecurityFromAnnotation(ke *v1alpha1.KnativeEventing) (common.PodSecurity, error) {
+	return common.PodSecurityFromAnnotation(ke, PodSecurityAnnotation)
+}
@@ -0,0 +1,34 @@
+package eventing
+
+import "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+
+// alphaFeature is the reason of features upstream only ships as alpha.
+const alphaFeature = "alpha features of Knative Eventing are not supported"
+
+// supportedConfigPolicy lists the configurations of Knative Eventing that are not supported.
+var supportedConfigPolicy = common.ConfigPolicy{{
+	ConfigMap: "features",
+	Key:       "kreference-group",
+	Values:    []string{"enabled"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "kreference-mapping",
+	Values:    []string{"enabled"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "delivery-retryafter",
+	Values:    []string{"enabled"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "delivery-timeout",
+	Values:    []string{"enabled"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "strict-subscriber",
+	Values:    []string{"enabled"},
+	Reason:    alphaFeature,
+}}
@@ -0,0 +1,31 @@
+package eventing
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// SourceNamespaceSelectorAnnotation limits event sources to the namespaces matching the label
+// selector it's set to on the KnativeEventing CR, e.g. "eventing.example.com/approved=true".
+// Sources of the sources.knative.dev group can't be created in other namespaces, so that no
+// source adapters run there. Existing sources are left alone, to be removed by their owners.
+const SourceNamespaceSelectorAnnotation = "eventing.knative.openshift.io/sourceNamespaceSelector"
+
+// SourceNamespaceSelector returns the selector of the namespaces sources are allowed in, or
+// nil if they're allowed everywhere.
+func SourceNamespaceSelector(ke *v1alpha1.KnativeEventing) (labels.Selector, error) {
+	raw, ok := ke.GetAnnotations()[SourceNamespaceSelectorAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	selector, err := labels.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", SourceNamespaceSelectorAnnotation, raw, err)
+	}
+	if selector.Empty() {
+		return nil, fmt.Errorf("invalid %s %q: must select namespaces by at least one label", SourceNamespaceSelectorAnnotation, raw)
+	}
+	return selector, nil
+}
@@ -0,0 +1,37 @@
+package eventing
+
+import (
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// TelemetryAnnotation deploys an OpenTelemetry collector into the namespace of Knative Eventing
+// if set on the KnativeEventing CR, which receives the traces and metrics of Knative Eventing
+// and forwards them to the configured exporters. See common.Telemetry for its format.
+const TelemetryAnnotation = "eventing.knative.openshift.io/telemetry"
+
+// TelemetryFromAnnotation parses the telemetry settings of the given KnativeEventing. It
+// returns nil if none are set.
+func TelemetryFromAnnotation(ke *v1alpha1.KnativeEventing) (*common.Telemetry, error) {
+	return common.TelemetryFromAnnotation(ke, TelemetryAnnotation)
+}
+
+// telemetryManifest returns the collector of the given KnativeEventing, which is empty if
+// telemetry is disabled.
+func telemetryManifest(ke *v1alpha1.KnativeEventing, t *common.Telemetry) (mf.Manifest, error) {
+	if t == nil {
+		return mf.Manifest{}, nil
+	}
+	image := ke.Spec.Registry.Override[common.TelemetryCollectorImageKey]
+	if image == "" {
+		return mf.Manifest{}, fmt.Errorf("%s requires the image of the OpenTelemetry collector to be configured", TelemetryAnnotation)
+	}
+	manifest, err := t.Manifest(ke.Namespace, image)
+	if err != nil {
+		return mf.Manifest{}, err
+	}
+	return *manifest, nil
+}
@@ -0,0 +1,145 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// ArchitecturesStatusKey is the status annotation listing the architectures the pods of
+	// Knative Serving are pinned to. It's only set on clusters whose nodes are of different
+	// architectures, some of which the payload has no images for.
+	ArchitecturesStatusKey = "operator.serverless.openshift.io/architectures"
+
+	// SupportedArchitectures is false with a warning if the cluster has nodes of architectures
+	// the payload has no images for. Knative Serving isn't scheduled on them.
+	SupportedArchitectures apis.ConditionType = "SupportedArchitectures"
+
+	// payloadArchitecturesEnvKey lists the architectures the images of the payload are built
+	// for, comma-separated. It defaults to the architecture of the operator itself.
+	payloadArchitecturesEnvKey = "PAYLOAD_ARCHITECTURES"
+)
+
+// payloadArchitectures returns the architectures the images of the payload are built for.
+func payloadArchitectures() sets.String {
+	archs := sets.NewString()
+	for _, arch := range strings.Split(os.Getenv(payloadArchitecturesEnvKey), ",") {
+		if arch = strings.TrimSpace(arch); arch != "" {
+			archs.Insert(arch)
+		}
+	}
+	if archs.Len() == 0 {
+		archs.Insert(runtime.GOARCH)
+	}
+	return archs
+}
+
+// nodeArchitectures returns the architectures of the nodes of the cluster.
+func nodeArchitectures(ctx context.Context, kube kubernetes.Interface) (sets.String, error) {
+	nodes, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	archs := sets.NewString()
+	for _, node := range nodes.Items {
+		if arch := node.Labels[corev1.LabelArchStable]; arch != "" {
+			archs.Insert(arch)
+		}
+	}
+	return archs, nil
+}
+
+// reconcileArchitectures pins the pods of the given KnativeServing to the architectures of the
+// payload on clusters whose nodes are of different architectures, and warns about the
+// architectures that are skipped. The pinned architectures are recorded on the status to be
+// applied by architectureAffinityTransform.
+func reconcileArchitectures(ctx context.Context, kube kubernetes.Interface, ks *v1alpha1.KnativeServing) error {
+	nodes, err := nodeArchitectures(ctx, kube)
+	if err != nil {
+		return err
+	}
+	payload := payloadArchitectures()
+	pinned := nodes.Intersection(payload)
+	skipped := nodes.Difference(payload)
+
+	// Homogeneous clusters need no pinning, and pods can't be scheduled anywhere if no
+	// architecture is supported.
+	if nodes.Len() > 1 && skipped.Len() > 0 && pinned.Len() > 0 {
+		if ks.Status.Annotations == nil {
+			ks.Status.Annotations = make(map[string]string, 1)
+		}
+		ks.Status.Annotations[ArchitecturesStatusKey] = strings.Join(pinned.List(), ",")
+	} else {
+		delete(ks.Status.Annotations, ArchitecturesStatusKey)
+	}
+
+	var warnings []string
+	if skipped.Len() > 0 {
+		warnings = append(warnings, fmt.Sprintf("no images for the nodes of architecture %s, which Knative Serving isn't scheduled on",
+			strings.Join(skipped.List(), ", ")))
+	}
+	common.MarkWarnings(&ks.Status, SupportedArchitectures, "UnsupportedArchitectures", warnings)
+	return nil
+}
+
+// architectureAffinityTransform requires the pods of all Deployments to be scheduled on nodes of
+// the architectures recorded on the status of the given KnativeServing, if any.
+func architectureAffinityTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	raw := ks.Status.Annotations[ArchitecturesStatusKey]
+	if raw == "" {
+		return func(*unstructured.Unstructured) error { return nil }
+	}
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelArchStable,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   strings.Split(raw, ","),
+	}
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" {
+			return nil
+		}
+		deployment := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(u, deployment, nil); err != nil {
+			return err
+		}
+
+		podSpec := &deployment.Spec.Template.Spec
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &corev1.Affinity{}
+		}
+		if podSpec.Affinity.NodeAffinity == nil {
+			podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+		}
+		required := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		if required == nil || len(required.NodeSelectorTerms) == 0 {
+			podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{requirement},
+				}},
+			}
+		} else {
+			// The terms are ORed, so each of them has to require the architecture.
+			for i := range required.NodeSelectorTerms {
+				term := &required.NodeSelectorTerms[i]
+				term.MatchExpressions = append(term.MatchExpressions, requirement)
+			}
+		}
+
+		return scheme.Scheme.Convert(deployment, u, nil)
+	}
+}
@@ -0,0 +1,152 @@
+package serving
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestReconcileArchitectures(t *testing.T) {
+	defer os.Setenv(payloadArchitecturesEnvKey, os.Getenv(payloadArchitecturesEnvKey))
+	os.Setenv(payloadArchitecturesEnvKey, "amd64, ppc64le")
+
+	node := func(name, arch string) runtime.Object {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{corev1.LabelArchStable: arch},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		nodes       []runtime.Object
+		wantPinned  string
+		wantWarning bool
+	}{{
+		name: "no nodes",
+	}, {
+		name:  "homogeneous",
+		nodes: []runtime.Object{node("a", "amd64"), node("b", "amd64")},
+	}, {
+		name:  "supported mix",
+		nodes: []runtime.Object{node("a", "amd64"), node("b", "ppc64le")},
+	}, {
+		name:        "unsupported mix",
+		nodes:       []runtime.Object{node("a", "amd64"), node("b", "arm64"), node("c", "ppc64le")},
+		wantPinned:  "amd64,ppc64le",
+		wantWarning: true,
+	}, {
+		name:        "unsupported only",
+		nodes:       []runtime.Object{node("a", "arm64")},
+		wantWarning: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{}
+			// A stale pinning is removed.
+			ks.Status.Annotations = map[string]string{ArchitecturesStatusKey: "s390x"}
+
+			kube := fake.NewSimpleClientset(test.nodes...)
+			if err := reconcileArchitectures(context.Background(), kube, ks); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			if got := ks.Status.Annotations[ArchitecturesStatusKey]; got != test.wantPinned {
+				t.Errorf("Pinned architectures = %q, want %q", got, test.wantPinned)
+			}
+			cond := ks.Status.GetCondition(SupportedArchitectures)
+			if got := cond != nil && cond.IsFalse(); got != test.wantWarning {
+				t.Errorf("Warning = %v, want %v, condition: %v", got, test.wantWarning, cond)
+			}
+		})
+	}
+}
+
+func TestArchitectureAffinityTransform(t *testing.T) {
+	arch := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelArchStable,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"amd64", "ppc64le"},
+	}
+	zone := corev1.NodeSelectorRequirement{
+		Key:      corev1.LabelTopologyZone,
+		Operator: corev1.NodeSelectorOpExists,
+	}
+
+	tests := []struct {
+		name     string
+		pinned   string
+		affinity *corev1.Affinity
+		want     *corev1.Affinity
+	}{{
+		name: "not pinned",
+	}, {
+		name:   "pinned",
+		pinned: "amd64,ppc64le",
+		want: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{arch}}},
+			},
+		}},
+	}, {
+		name:   "existing terms",
+		pinned: "amd64,ppc64le",
+		affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{zone}},
+					{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}}},
+				},
+			},
+		}},
+		want: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{zone, arch}},
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{arch},
+						MatchFields:      []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+					},
+				},
+			},
+		}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{}
+			if test.pinned != "" {
+				ks.Status.Annotations = map[string]string{ArchitecturesStatusKey: test.pinned}
+			}
+			in := deployment("knative-serving", "activator")
+			in.Spec.Template.Spec.Affinity = test.affinity
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(in, u, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if err := architectureAffinityTransform(ks)(u); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			got := &appsv1.Deployment{}
+			if err := scheme.Scheme.Convert(u, got, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if !cmp.Equal(got.Spec.Template.Spec.Affinity, test.want) {
+				t.Errorf("Affinity = %v, want %v, diff(-want,+got):\n%s", got.Spec.Template.Spec.Affinity, test.want,
+					cmp.Diff(test.want, got.Spec.Template.Spec.Affinity))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package serving
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	mf "github.com/manifestival/manifestival"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+const (
+	// CABundleChecksumStatusKey is the status annotation carrying the checksum of the CA
+	// bundles of the KnativeServing, to be applied by caBundleChecksumTransform.
+	CABundleChecksumStatusKey = "operator.serverless.openshift.io/ca-bundle-checksum"
+
+	// caBundleChecksumKey is the annotation on the PodTemplates of Knative Serving's webhooks
+	// and controllers that makes them redeploy when the apiserver or service CA rotates.
+	caBundleChecksumKey = "serving.knative.openshift.io/ca-bundle-checksum"
+
+	// KubeRootCAName is the ConfigMap the apiserver's CA bundle is published to in every
+	// namespace.
+	KubeRootCAName = "kube-root-ca.crt"
+)
+
+// caDependentDeployments are the deployments of Knative Serving that talk TLS to the apiserver
+// or to other services and thus have to pick up rotated CAs.
+var caDependentDeployments = sets.NewString("controller", "webhook", "domain-mapping", "domainmapping-webhook")
+
+// CABundleNames returns the names of the ConfigMaps in the namespace of the given
+// KnativeServing holding the CA bundles its deployments depend on.
+func CABundleNames(ks *v1alpha1.KnativeServing) []string {
+	names := []string{KubeRootCAName}
+	if certs := ks.Spec.ControllerCustomCerts; certs.Type == "ConfigMap" && certs.Name != "" {
+		names = append(names, certs.Name+"-service-ca")
+	}
+	return names
+}
+
+// reconcileCABundleChecksum records a checksum of the current CA bundles of the given
+// KnativeServing on its status, to be applied by caBundleChecksumTransform. Missing bundles
+// are skipped, and there's no checksum without any bundle.
+func reconcileCABundleChecksum(ctx context.Context, kube kubernetes.Interface, ks *v1alpha1.KnativeServing) error {
+	hash := sha256.New()
+	found := false
+	for _, name := range CABundleNames(ks) {
+		cm, err := kube.CoreV1().ConfigMaps(ks.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get CA bundle %s: %w", name, err)
+		}
+		writeData(hash, name, cm.Data)
+		found = true
+	}
+
+	if !found {
+		delete(ks.Status.Annotations, CABundleChecksumStatusKey)
+		return nil
+	}
+	if ks.Status.Annotations == nil {
+		ks.Status.Annotations = make(map[string]string, 1)
+	}
+	ks.Status.Annotations[CABundleChecksumStatusKey] = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// writeData writes the given ConfigMap data to the writer in a stable order.
+func writeData(w io.Writer, name string, data map[string]string) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "%s\n", name)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s=%s\n", key, data[key])
+	}
+}
+
+// caBundleChecksumTransform annotates the PodTemplates of the CA dependent deployments with
+// the checksum recorded on the status of the given KnativeServing, which restarts them
+// whenever one of the bundles is rotated.
+func caBundleChecksumTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	checksum := ks.Status.Annotations[CABundleChecksumStatusKey]
+	return func(u *unstructured.Unstructured) error {
+		if checksum == "" || u.GetKind() != "Deployment" || !caDependentDeployments.Has(u.GetName()) {
+			return nil
+		}
+		return unstructured.SetNestedField(u.Object, checksum, "spec", "template", "metadata", "annotations", caBundleChecksumKey)
+	}
+}
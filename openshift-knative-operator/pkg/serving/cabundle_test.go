@@ -0,0 +1,90 @@
+package serving
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestReconcileCABundleChecksum(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+		Spec: v1alpha1.KnativeServingSpec{
+			ControllerCustomCerts: v1alpha1.CustomCerts{Name: "test-cm", Type: "ConfigMap"},
+		},
+	}
+	rootCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: KubeRootCAName, Namespace: "knative-serving"},
+		Data:       map[string]string{"ca.crt": "root"},
+	}
+	serviceCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cm-service-ca", Namespace: "knative-serving"},
+		Data:       map[string]string{"service-ca.crt": "service"},
+	}
+	kube := fake.NewSimpleClientset(rootCA, serviceCA)
+
+	checksum := func() string {
+		t.Helper()
+		if err := reconcileCABundleChecksum(context.Background(), kube, ks); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		return ks.Status.Annotations[CABundleChecksumStatusKey]
+	}
+
+	initial := checksum()
+	if initial == "" {
+		t.Fatal("No CA bundle checksum recorded")
+	}
+	if got := checksum(); got != initial {
+		t.Errorf("Checksum changed without a rotation, got %q, want %q", got, initial)
+	}
+
+	// Rotating either bundle changes the checksum.
+	for _, bundle := range []*corev1.ConfigMap{rootCA, serviceCA} {
+		rotated := bundle.DeepCopy()
+		for key := range rotated.Data {
+			rotated.Data[key] += "-rotated"
+		}
+		if _, err := kube.CoreV1().ConfigMaps(rotated.Namespace).Update(context.Background(), rotated, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		before := ks.Status.Annotations[CABundleChecksumStatusKey]
+		if got := checksum(); got == before {
+			t.Errorf("Checksum did not change after rotating %s", bundle.Name)
+		}
+	}
+}
+
+func TestCABundleChecksumTransform(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{}
+	ks.Status.Annotations = map[string]string{CABundleChecksumStatusKey: "abc"}
+
+	for _, test := range []struct {
+		name string
+		want string
+	}{{
+		name: "webhook",
+		want: "abc",
+	}, {
+		name: "activator",
+	}} {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": test.name},
+		}}
+		if err := caBundleChecksumTransform(ks)(u); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		got, _, _ := unstructured.NestedString(u.Object, "spec", "template", "metadata", "annotations", caBundleChecksumKey)
+		if got != test.want {
+			t.Errorf("Checksum of %s = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
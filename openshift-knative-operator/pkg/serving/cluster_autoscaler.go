@@ -0,0 +1,187 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// ClusterAutoscalerAnnotation is the annotation on the KnativeServing CR carrying hints for
+// the cluster autoscaler as JSON, for example:
+//
+//	serving.knative.openshift.io/clusterAutoscaler: |
+//	  {"safeToEvict": false, "overprovisioning": {"replicas": 2, "cpu": "1", "memory": "2Gi"}}
+//
+// Scaling from zero must not wait for the cluster autoscaler to provision new nodes, so the
+// activator and the Kourier gateway can be pinned to their nodes and placeholder pods can
+// reserve capacity that is preempted as soon as revisions need it.
+const ClusterAutoscalerAnnotation = "serving.knative.openshift.io/clusterAutoscaler"
+
+const (
+	// safeToEvictKey is the pod annotation the cluster autoscaler checks before evicting a
+	// pod to remove its node.
+	safeToEvictKey = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+	overprovisioningName     = "overprovisioning"
+	overprovisioningImageKey = "overprovisioning"
+	// overprovisioningPriorityClass is cluster-scoped, hence the prefix.
+	overprovisioningPriorityClass = "knative-serving-overprovisioning"
+	// overprovisioningPriority is below the default priority of 0, so that every other pod
+	// preempts the placeholders.
+	overprovisioningPriority = -10
+)
+
+// ClusterAutoscaler bundles the hints for the cluster autoscaler.
+type ClusterAutoscaler struct {
+	// SafeToEvict is set as the safe-to-evict hint on the pods of the activator and the
+	// Kourier gateway. Setting it to false keeps their nodes from being scaled down.
+	SafeToEvict *bool `json:"safeToEvict,omitempty"`
+	// Overprovisioning configures placeholder pods reserving capacity for bursts.
+	Overprovisioning *Overprovisioning `json:"overprovisioning,omitempty"`
+}
+
+// Overprovisioning sizes the placeholder pods. Their requests should match the capacity a
+// burst of revisions needs at once.
+type Overprovisioning struct {
+	// Replicas is the number of placeholder pods.
+	Replicas int32 `json:"replicas"`
+	// CPU requested by each placeholder pod.
+	CPU string `json:"cpu,omitempty"`
+	// Memory requested by each placeholder pod.
+	Memory string `json:"memory,omitempty"`
+}
+
+// ClusterAutoscalerFromAnnotation parses the cluster autoscaler hints of the given
+// KnativeServing. It returns nil if none are set.
+func ClusterAutoscalerFromAnnotation(ks *v1alpha1.KnativeServing) (*ClusterAutoscaler, error) {
+	raw, ok := ks.GetAnnotations()[ClusterAutoscalerAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	hints := &ClusterAutoscaler{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(hints); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ClusterAutoscalerAnnotation, err)
+	}
+	if err := hints.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ClusterAutoscalerAnnotation, err)
+	}
+	return hints, nil
+}
+
+// Validate checks the hints for consistency.
+func (c *ClusterAutoscaler) Validate() error {
+	o := c.Overprovisioning
+	if o == nil {
+		return nil
+	}
+	if o.Replicas < 1 {
+		return fmt.Errorf("overprovisioning.replicas = %d, must be at least 1", o.Replicas)
+	}
+	if o.CPU == "" && o.Memory == "" {
+		return fmt.Errorf("overprovisioning must request cpu or memory")
+	}
+	if _, err := o.requests(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// requests returns the resources requested by each placeholder pod.
+func (o *Overprovisioning) requests() (corev1.ResourceList, error) {
+	requests := corev1.ResourceList{}
+	for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: o.CPU, corev1.ResourceMemory: o.Memory} {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("overprovisioning.%s = %q, must be a quantity: %w", name, value, err)
+		}
+		if q.Sign() <= 0 {
+			return nil, fmt.Errorf("overprovisioning.%s = %q, must be positive", name, value)
+		}
+		requests[name] = q
+	}
+	return requests, nil
+}
+
+// safeToEvictHint sets the safe-to-evict hint on the activator and the Kourier gateway.
+func safeToEvictHint(c *ClusterAutoscaler) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if c == nil || c.SafeToEvict == nil || u.GetKind() != "Deployment" ||
+			(u.GetName() != "activator" && u.GetName() != kourierGatewayDeployment) {
+			return nil
+		}
+		return unstructured.SetNestedField(u.Object, strconv.FormatBool(*c.SafeToEvict),
+			"spec", "template", "metadata", "annotations", safeToEvictKey)
+	}
+}
+
+// overprovisioningManifest returns the PriorityClass and Deployment of the placeholder pods.
+func overprovisioningManifest(ks *v1alpha1.KnativeServing, o *Overprovisioning) (*mf.Manifest, error) {
+	requests, err := o.requests()
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{"app": overprovisioningName}
+	zero := int64(0)
+
+	priorityClass := &schedulingv1.PriorityClass{
+		TypeMeta:    metav1.TypeMeta{APIVersion: "scheduling.k8s.io/v1", Kind: "PriorityClass"},
+		ObjectMeta:  metav1.ObjectMeta{Name: overprovisioningPriorityClass},
+		Value:       overprovisioningPriority,
+		Description: "Placeholder pods reserving capacity for Knative Serving, preempted by any other pod.",
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      overprovisioningName,
+			Namespace: ks.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &o.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					PriorityClassName:             overprovisioningPriorityClass,
+					TerminationGracePeriodSeconds: &zero,
+					Containers: []corev1.Container{{
+						Name:      overprovisioningName,
+						Image:     ks.Spec.Registry.Override[overprovisioningImageKey],
+						Resources: corev1.ResourceRequirements{Requests: requests},
+					}},
+				},
+			},
+		},
+	}
+
+	us := make([]unstructured.Unstructured, 0, 2)
+	for _, obj := range []interface{}{priorityClass, deployment} {
+		u := unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(obj, &u, nil); err != nil {
+			return nil, err
+		}
+		us = append(us, u)
+	}
+	manifest, err := mf.ManifestFrom(mf.Slice(us))
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,147 @@
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestClusterAutoscalerFromAnnotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{{
+		name: "safe to evict",
+		in:   `{"safeToEvict": false}`,
+	}, {
+		name: "overprovisioning",
+		in:   `{"overprovisioning": {"replicas": 2, "cpu": "500m", "memory": "1Gi"}}`,
+	}, {
+		name:    "unknown field",
+		in:      `{"safeToEvict": false, "foo": "bar"}`,
+		wantErr: true,
+	}, {
+		name:    "no replicas",
+		in:      `{"overprovisioning": {"cpu": "1"}}`,
+		wantErr: true,
+	}, {
+		name:    "no requests",
+		in:      `{"overprovisioning": {"replicas": 1}}`,
+		wantErr: true,
+	}, {
+		name:    "malformed quantity",
+		in:      `{"overprovisioning": {"replicas": 1, "memory": "lots"}}`,
+		wantErr: true,
+	}, {
+		name:    "negative quantity",
+		in:      `{"overprovisioning": {"replicas": 1, "cpu": "-1"}}`,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{ClusterAutoscalerAnnotation: test.in},
+				},
+			}
+			_, err := ClusterAutoscalerFromAnnotation(ks)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ClusterAutoscalerFromAnnotation() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSafeToEvictHint(t *testing.T) {
+	safe := false
+	hints := &ClusterAutoscaler{SafeToEvict: &safe}
+
+	tests := []struct {
+		name string
+		in   *appsv1.Deployment
+		want map[string]string
+	}{{
+		name: "activator",
+		in:   deployment("knative-serving", "activator"),
+		want: map[string]string{safeToEvictKey: "false"},
+	}, {
+		name: "kourier gateway",
+		in:   deployment("knative-serving-ingress", kourierGatewayDeployment),
+		want: map[string]string{safeToEvictKey: "false"},
+	}, {
+		name: "other deployment",
+		in:   deployment("knative-serving", "controller"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(test.in, u, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if err := safeToEvictHint(hints)(u); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			got := &appsv1.Deployment{}
+			if err := scheme.Scheme.Convert(u, got, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if !cmp.Equal(got.Spec.Template.Annotations, test.want) {
+				t.Errorf("Got = %v, want: %v", got.Spec.Template.Annotations, test.want)
+			}
+		})
+	}
+}
+
+func TestOverprovisioningManifest(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving"},
+	}
+	ks.Spec.Registry.Override = map[string]string{overprovisioningImageKey: "pause"}
+
+	manifest, err := overprovisioningManifest(ks, &Overprovisioning{Replicas: 3, CPU: "1"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := manifest.Filter(mf.ByKind("PriorityClass")).Resources(); len(got) != 1 {
+		t.Fatalf("Got %d PriorityClasses, want 1", len(got))
+	}
+	deployments := manifest.Filter(mf.ByKind("Deployment")).Resources()
+	if len(deployments) != 1 {
+		t.Fatalf("Got %d Deployments, want 1", len(deployments))
+	}
+
+	got := &appsv1.Deployment{}
+	if err := scheme.Scheme.Convert(&deployments[0], got, nil); err != nil {
+		t.Fatal("Failed to convert deployment:", err)
+	}
+	if got.Namespace != "knative-serving" || *got.Spec.Replicas != 3 {
+		t.Errorf("Got deployment %s/%s with %d replicas, want knative-serving/%s with 3", got.Namespace, got.Name, *got.Spec.Replicas, overprovisioningName)
+	}
+	pod := got.Spec.Template.Spec
+	if pod.PriorityClassName != overprovisioningPriorityClass {
+		t.Errorf("PriorityClassName = %q, want %q", pod.PriorityClassName, overprovisioningPriorityClass)
+	}
+	want := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+	if pod.Containers[0].Image != "pause" || !cmp.Equal(pod.Containers[0].Resources.Requests, want) {
+		t.Errorf("Got container %v, want image pause requesting %v", pod.Containers[0], want)
+	}
+}
+
+func deployment(ns, name string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+}
@@ -0,0 +1,90 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// ColdStartAnnotation is the annotation on the KnativeServing CR carrying the cold-start
+// tuning profile as JSON, for example:
+//
+//	serving.knative.openshift.io/coldStart: |
+//	  {"initialScale": 0, "allowZeroInitialScale": true, "progressDeadline": "10m"}
+//
+// The settings take precedence over the respective keys in spec.config.
+const ColdStartAnnotation = "serving.knative.openshift.io/coldStart"
+
+// ColdStart bundles the settings relevant to the cold-start behavior of Knative Services.
+type ColdStart struct {
+	// InitialScale is the number of pods a revision is scaled to when it is created.
+	// Maps to "initial-scale" in config-autoscaler.
+	InitialScale *int32 `json:"initialScale,omitempty"`
+	// AllowZeroInitialScale allows revisions to be created with an initial scale of 0.
+	// Maps to "allow-zero-initial-scale" in config-autoscaler.
+	AllowZeroInitialScale *bool `json:"allowZeroInitialScale,omitempty"`
+	// ProgressDeadline is the time a revision has to become ready before it's considered failed.
+	// Maps to "progressDeadline" in config-deployment.
+	ProgressDeadline string `json:"progressDeadline,omitempty"`
+}
+
+// ColdStartFromAnnotation parses the cold-start profile of the given KnativeServing. It
+// returns nil if no profile is set.
+func ColdStartFromAnnotation(ks *v1alpha1.KnativeServing) (*ColdStart, error) {
+	raw, ok := ks.GetAnnotations()[ColdStartAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	cs := &ColdStart{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ColdStartAnnotation, err)
+	}
+	if err := cs.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ColdStartAnnotation, err)
+	}
+	return cs, nil
+}
+
+// Validate checks the profile for consistency.
+func (cs *ColdStart) Validate() error {
+	if cs.InitialScale != nil {
+		if *cs.InitialScale < 0 {
+			return fmt.Errorf("initialScale = %d, must be at least 0", *cs.InitialScale)
+		}
+		if *cs.InitialScale == 0 && (cs.AllowZeroInitialScale == nil || !*cs.AllowZeroInitialScale) {
+			return errors.New("initialScale = 0 requires allowZeroInitialScale to be true")
+		}
+	}
+	if cs.ProgressDeadline != "" {
+		d, err := time.ParseDuration(cs.ProgressDeadline)
+		if err != nil {
+			return fmt.Errorf("progressDeadline = %q, must be a duration: %w", cs.ProgressDeadline, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("progressDeadline = %q, must be positive", cs.ProgressDeadline)
+		}
+	}
+	return nil
+}
+
+// apply renders the profile into the respective ConfigMaps of the given spec.
+func (cs *ColdStart) apply(spec *v1alpha1.CommonSpec) {
+	if cs.InitialScale != nil {
+		common.Configure(spec, "autoscaler", "initial-scale", strconv.Itoa(int(*cs.InitialScale)))
+	}
+	if cs.AllowZeroInitialScale != nil {
+		common.Configure(spec, "autoscaler", "allow-zero-initial-scale", strconv.FormatBool(*cs.AllowZeroInitialScale))
+	}
+	if cs.ProgressDeadline != "" {
+		common.Configure(spec, "deployment", "progressDeadline", cs.ProgressDeadline)
+	}
+}
@@ -0,0 +1,142 @@
+package serving
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/metrics"
+)
+
+// ConfigOriginsStatusKey is the status annotation telling who set the config keys of the
+// KnativeServing, as JSON keyed by ConfigMap and key, for example:
+//
+//	operator.serverless.openshift.io/config-origins: |
+//	  {"network": {"domainTemplate": "defaulted", "ingress.class": "user"}}
+//
+// This answers "who set this value" without diffing the spec against the operator's defaults.
+const ConfigOriginsStatusKey = "operator.serverless.openshift.io/config-origins"
+
+const (
+	// ConfigOriginUser marks keys set by the user and left as they are.
+	ConfigOriginUser = "user"
+	// ConfigOriginDefaulted marks keys the user didn't set, which the operator defaulted.
+	ConfigOriginDefaulted = "defaulted"
+	// ConfigOriginEnforced marks keys set by the user, whose value the operator replaced.
+	ConfigOriginEnforced = "enforced"
+)
+
+var (
+	configOrigins = []string{ConfigOriginUser, ConfigOriginDefaulted, ConfigOriginEnforced}
+
+	// configKeysM is the number of config keys per ConfigMap and origin.
+	configKeysM = stats.Int64(
+		"serving_config_keys",
+		"The number of config keys of the KnativeServing per ConfigMap and who set them",
+		stats.UnitDimensionless)
+
+	configMapKey    = tag.MustNewKey("configmap")
+	configOriginKey = tag.MustNewKey("origin")
+)
+
+func init() {
+	if err := view.Register(&view.View{
+		Description: configKeysM.Description(),
+		Measure:     configKeysM,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{configMapKey, configOriginKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// copyConfig returns a deep copy of the given config, to compare against once defaulted.
+func copyConfig(config v1alpha1.ConfigMapData) v1alpha1.ConfigMapData {
+	out := make(v1alpha1.ConfigMapData, len(config))
+	for cm, data := range config {
+		out[cm] = make(map[string]string, len(data))
+		for key, value := range data {
+			out[cm][key] = value
+		}
+	}
+	return out
+}
+
+// ConfigOrigins tells who set each of the keys of the given config, given the config the
+// user set.
+func ConfigOrigins(user, config v1alpha1.ConfigMapData) map[string]map[string]string {
+	origins := make(map[string]map[string]string, len(config))
+	for cm, data := range config {
+		if len(data) == 0 {
+			continue
+		}
+		origins[cm] = make(map[string]string, len(data))
+		for key, value := range data {
+			userValue, ok := user[cm][key]
+			switch {
+			case !ok:
+				origins[cm][key] = ConfigOriginDefaulted
+			case userValue != value:
+				origins[cm][key] = ConfigOriginEnforced
+			default:
+				origins[cm][key] = ConfigOriginUser
+			}
+		}
+	}
+	return origins
+}
+
+// configOriginRecorder records the number of config keys per ConfigMap and origin.
+type configOriginRecorder struct {
+	mu sync.Mutex
+	// recorded are the ConfigMaps recorded before, to reset them once they're gone.
+	recorded map[string]bool
+}
+
+// reportConfigOrigins sets the ConfigOriginsStatusKey on the given KnativeServing and records
+// the respective metrics, given the config the user set.
+func (r *configOriginRecorder) reportConfigOrigins(ctx context.Context, ks *v1alpha1.KnativeServing, user v1alpha1.ConfigMapData) error {
+	origins := ConfigOrigins(user, ks.Spec.Config)
+	raw, err := json.Marshal(origins)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config origins: %w", err)
+	}
+	if ks.Status.Annotations == nil {
+		ks.Status.Annotations = make(map[string]string, 1)
+	}
+	ks.Status.Annotations[ConfigOriginsStatusKey] = string(raw)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// ConfigMaps without any keys anymore are reset to zero.
+	configMaps := make(map[string]bool, len(origins))
+	for cm := range r.recorded {
+		configMaps[cm] = true
+	}
+	for cm := range origins {
+		configMaps[cm] = true
+	}
+	for cm := range configMaps {
+		counts := make(map[string]int64, len(configOrigins))
+		for _, origin := range origins[cm] {
+			counts[origin]++
+		}
+		for _, origin := range configOrigins {
+			tagged, err := tag.New(ctx, tag.Upsert(configMapKey, cm), tag.Upsert(configOriginKey, origin))
+			if err != nil {
+				return err
+			}
+			metrics.Record(tagged, configKeysM.M(counts[origin]))
+		}
+	}
+	r.recorded = make(map[string]bool, len(origins))
+	for cm := range origins {
+		r.recorded[cm] = true
+	}
+	return nil
+}
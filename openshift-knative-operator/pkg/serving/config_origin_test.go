@@ -0,0 +1,78 @@
+package serving
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ocpfake "github.com/openshift-knative/serverless-operator/pkg/client/injection/client/fake"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	kubefake "knative.dev/pkg/client/injection/kube/client/fake"
+	dynamicfake "knative.dev/pkg/injection/clients/dynamicclient/fake"
+)
+
+func TestConfigOrigins(t *testing.T) {
+	user := v1alpha1.ConfigMapData{
+		"network":    {"ingress.class": "istio.ingress.networking.knative.dev"},
+		"deployment": {"queueSidecarImage": "mine"},
+		"empty":      {},
+	}
+	config := v1alpha1.ConfigMapData{
+		"network": {
+			"ingress.class":  "istio.ingress.networking.knative.dev",
+			"domainTemplate": defaultDomainTemplate,
+		},
+		"deployment": {"queueSidecarImage": "theirs"},
+		"empty":      {},
+	}
+
+	want := map[string]map[string]string{
+		"network": {
+			"ingress.class":  ConfigOriginUser,
+			"domainTemplate": ConfigOriginDefaulted,
+		},
+		"deployment": {"queueSidecarImage": ConfigOriginEnforced},
+	}
+	if got := ConfigOrigins(user, config); !cmp.Equal(got, want) {
+		t.Errorf("ConfigOrigins() = %v, want %v, diff:\n%s", got, want, cmp.Diff(got, want))
+	}
+}
+
+func TestReconcileConfigOrigins(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{}
+	ks.Namespace = servingNamespace.Name
+	ks.Spec.Config = v1alpha1.ConfigMapData{
+		"network":    {"domainTemplate": "{{.Name}}.{{.Namespace}}.{{.Domain}}"},
+		"deployment": {"queueSidecarImage": "mine"},
+	}
+
+	ctx, _ := ocpfake.With(context.Background(), defaultIngress)
+	ctx, _ = kubefake.With(ctx, &servingNamespace)
+	ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
+	ext := newFakeExtension(ctx, t)
+	if err := ext.Reconcile(context.Background(), ks); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	raw, ok := ks.Status.Annotations[ConfigOriginsStatusKey]
+	if !ok {
+		t.Fatalf("Status annotations = %v, want %s", ks.Status.Annotations, ConfigOriginsStatusKey)
+	}
+	got := map[string]map[string]string{}
+	if err := json.Unmarshal([]byte(raw), &got); err != nil {
+		t.Fatal("Failed to unmarshal config origins:", err)
+	}
+	want := map[string]map[string]string{
+		"network":    {"domainTemplate": ConfigOriginUser, "defaultExternalScheme": ConfigOriginDefaulted},
+		"deployment": {"queueSidecarImage": ConfigOriginEnforced},
+	}
+	for cm, keys := range want {
+		for key, origin := range keys {
+			if got[cm][key] != origin {
+				t.Errorf("Origin of %s/%s = %q, want %q", cm, key, got[cm][key], origin)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/network"
+)
+
+const (
+	// WildcardDNS is false with a warning if a hostname under one of the domains of
+	// config-domain doesn't resolve, which usually means the wildcard DNS record of the domain
+	// is missing or not delegated to the router, so no Knative Service is reachable under it.
+	// It doesn't affect the readiness of Knative Serving.
+	WildcardDNS apis.ConditionType = "WildcardDNS"
+
+	// dnsProbeLabel is prepended to a domain to form the hostname that is resolved. It's not
+	// expected to exist, so it's only resolvable through a wildcard record.
+	dnsProbeLabel = "knative-wildcard-dns-probe"
+
+	// dnsProbeTimeout bounds the resolution of each domain.
+	dnsProbeTimeout = 3 * time.Second
+
+	// maxProbedDomains bounds the number of domains probed.
+	maxProbedDomains = 5
+
+	// dnsProbeInterval is how long the result of probing a domain is reported before the
+	// domain is probed again.
+	dnsProbeInterval = 5 * time.Minute
+)
+
+// resolver resolves the given host to its addresses, like net.Resolver.LookupHost.
+type resolver func(ctx context.Context, host string) ([]string, error)
+
+// dnsProber probes the wildcard DNS of domains in the background and caches the results, so
+// that reconciling doesn't wait on slow or unreachable name servers.
+type dnsProber struct {
+	resolve resolver
+
+	mu      sync.Mutex
+	results map[string]dnsProbeResult
+	// probes tracks the probes in flight.
+	probes sync.WaitGroup
+}
+
+// dnsProbeResult is the result of probing a domain.
+type dnsProbeResult struct {
+	// warning is empty if the domain resolves.
+	warning string
+	// probed is the time of the probe, zero until the first probe completes.
+	probed time.Time
+	// pending is true while the domain is being probed.
+	pending bool
+}
+
+// newDNSProber creates a dnsProber resolving through the given resolver.
+func newDNSProber(resolve resolver) *dnsProber {
+	return &dnsProber{
+		resolve: resolve,
+		results: make(map[string]dnsProbeResult),
+	}
+}
+
+// warnings returns the warnings of the last probes of the given domains, and probes the
+// domains not probed within dnsProbeInterval in the background. Their results are returned
+// by the next call.
+func (p *dnsProber) warnings(domains []string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	probed := make(map[string]bool, len(domains))
+	var warnings []string
+	for _, domain := range domains {
+		probed[domain] = true
+		result := p.results[domain]
+		if result.warning != "" {
+			warnings = append(warnings, result.warning)
+		}
+		if !result.pending && time.Since(result.probed) >= dnsProbeInterval {
+			result.pending = true
+			p.results[domain] = result
+			p.probes.Add(1)
+			go p.probe(domain)
+		}
+	}
+	// Forget domains that are no longer configured.
+	for domain, result := range p.results {
+		if !probed[domain] && !result.pending {
+			delete(p.results, domain)
+		}
+	}
+	return warnings
+}
+
+// probe resolves a hostname under the given domain and records the result.
+func (p *dnsProber) probe(domain string) {
+	defer p.probes.Done()
+
+	host := dnsProbeLabel + "." + domain
+	ctx, cancel := context.WithTimeout(context.Background(), dnsProbeTimeout)
+	addrs, err := p.resolve(ctx, host)
+	cancel()
+
+	result := dnsProbeResult{probed: time.Now()}
+	if err != nil || len(addrs) == 0 {
+		result.warning = fmt.Sprintf("%s doesn't resolve, check the wildcard DNS record *.%s: %v", host, domain, err)
+	}
+	p.mu.Lock()
+	p.results[domain] = result
+	p.mu.Unlock()
+}
+
+// probedDomains returns the domains of config-domain that are served through Routes, i.e.
+// all but the cluster-local ones.
+func probedDomains(spec *v1alpha1.CommonSpec) []string {
+	clusterLocal := "svc." + network.GetClusterDomainName()
+	var domains []string
+	for _, cm := range []string{"domain", "config-domain"} {
+		for domain := range spec.Config[cm] {
+			domain = strings.Trim(strings.TrimSpace(domain), ".")
+			if domain == "" || strings.HasPrefix(domain, "_") || domain == clusterLocal || strings.HasSuffix(domain, "."+clusterLocal) {
+				continue
+			}
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	if len(domains) > maxProbedDomains {
+		domains = domains[:maxProbedDomains]
+	}
+	return domains
+}
+
+// markWildcardDNS warns on the status of the given KnativeServing about domains whose
+// wildcard DNS doesn't resolve, according to the last probes of the given prober. Domains
+// are reported once their first probe completes, with a later reconcile.
+func markWildcardDNS(prober *dnsProber, ks *v1alpha1.KnativeServing) {
+	warnings := prober.warnings(probedDomains(&ks.Spec.CommonSpec))
+	common.MarkWarnings(&ks.Status, WildcardDNS, "WildcardDNSUnresolvable", warnings)
+}
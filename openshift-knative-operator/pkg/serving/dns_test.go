@@ -0,0 +1,103 @@
+package serving
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestProbedDomains(t *testing.T) {
+	spec := &v1alpha1.CommonSpec{Config: v1alpha1.ConfigMapData{
+		"domain": {
+			"apps.example.com":  "",
+			"svc.cluster.local": "selector:\n  visibility: cluster-local",
+			"_example":          "...",
+		},
+		"config-domain": {"internal.example.com.": ""},
+	}}
+	want := []string{"apps.example.com", "internal.example.com"}
+	if got := probedDomains(spec); !cmp.Equal(got, want) {
+		t.Errorf("probedDomains() = %v, want %v", got, want)
+	}
+}
+
+func TestMarkWildcardDNS(t *testing.T) {
+	resolve := func(ctx context.Context, host string) ([]string, error) {
+		if host == dnsProbeLabel+".apps.example.com" {
+			return []string{"10.0.0.1"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	tests := []struct {
+		name        string
+		domains     map[string]string
+		wantWarning bool
+	}{{
+		name: "no domains",
+	}, {
+		name:    "resolvable",
+		domains: map[string]string{"apps.example.com": ""},
+	}, {
+		name:        "unresolvable",
+		domains:     map[string]string{"apps.example.com": "", "custom.example.com": ""},
+		wantWarning: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{}
+			ks.Spec.Config = v1alpha1.ConfigMapData{"domain": test.domains}
+			prober := newDNSProber(resolve)
+
+			// The domains are probed in the background, nothing is known yet.
+			markWildcardDNS(prober, ks)
+			if cond := ks.Status.GetCondition(WildcardDNS); cond != nil {
+				t.Errorf("Condition before probing = %v, want none", cond)
+			}
+
+			prober.probes.Wait()
+			markWildcardDNS(prober, ks)
+			cond := ks.Status.GetCondition(WildcardDNS)
+			if got := cond != nil && cond.IsFalse(); got != test.wantWarning {
+				t.Errorf("Warning = %v, want %v, condition: %v", got, test.wantWarning, cond)
+			}
+		})
+	}
+}
+
+func TestDNSProberCachesResults(t *testing.T) {
+	var mu sync.Mutex
+	probes := 0
+	prober := newDNSProber(func(ctx context.Context, host string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		probes++
+		return nil, errors.New("no such host")
+	})
+	domains := []string{"apps.example.com"}
+
+	prober.warnings(domains)
+	prober.probes.Wait()
+	if got := prober.warnings(domains); len(got) != 1 {
+		t.Errorf("warnings() = %v, want one warning", got)
+	}
+	prober.probes.Wait()
+	if probes != 1 {
+		t.Errorf("Probed %d times, want the result of the first probe to be reused", probes)
+	}
+
+	// Results are forgotten with their domain, and probed again once it's back.
+	prober.warnings(nil)
+	if got := prober.warnings(domains); len(got) != 0 {
+		t.Errorf("warnings() = %v, want none before the domain is probed again", got)
+	}
+	prober.probes.Wait()
+	if probes != 2 {
+		t.Errorf("Probed %d times, want the domain to be probed again", probes)
+	}
+}
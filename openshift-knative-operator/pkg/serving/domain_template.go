@@ -0,0 +1,71 @@
+package serving
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+const (
+	// DomainTemplateAnnotation selects a preset of the domainTemplate of config-network, either
+	// DomainTemplateFlat (the default) or DomainTemplateSubdomain. The preset takes precedence
+	// over the domainTemplate in spec.config.
+	DomainTemplateAnnotation = "serving.knative.openshift.io/domainTemplate"
+
+	// DomainTemplateFlat exposes Knative Services at $name-$ns.$domain. The hosts are covered
+	// by the wildcard certificate of the OpenShift router.
+	DomainTemplateFlat = "flat"
+	// DomainTemplateSubdomain exposes Knative Services at $name.$ns.$domain, like upstream.
+	// The hosts aren't covered by the wildcard certificate of the OpenShift router, so HTTPS
+	// requires a certificate per namespace.
+	DomainTemplateSubdomain = "subdomain"
+
+	// domainTemplatePath is the rejected path-style preset, to point out why it's unsupported.
+	domainTemplatePath = "path"
+
+	subdomainDomainTemplate = "{{.Name}}.{{.Namespace}}.{{.Domain}}"
+
+	// pathRoutingUnsupported explains why Knative Services can't be exposed by path.
+	pathRoutingUnsupported = "Knative Services are routed by host and OpenShift Routes can't rewrite " +
+		"the Host header, so path-style routing isn't supported"
+)
+
+// domainTemplatePresets maps the presets to their domainTemplate.
+var domainTemplatePresets = map[string]string{
+	DomainTemplateFlat:      defaultDomainTemplate,
+	DomainTemplateSubdomain: subdomainDomainTemplate,
+}
+
+// DomainTemplate returns the domainTemplate preset selected by the given KnativeServing, or
+// an empty string if none is selected. It also rejects a domainTemplate in spec.config that
+// tries to route by path.
+func DomainTemplate(ks *v1alpha1.KnativeServing) (string, error) {
+	if template := ks.Spec.Config["network"]["domainTemplate"]; strings.Contains(template, "/") {
+		return "", fmt.Errorf("invalid domainTemplate %q in spec.config: %s", template, pathRoutingUnsupported)
+	}
+
+	preset, ok := ks.GetAnnotations()[DomainTemplateAnnotation]
+	if !ok {
+		return "", nil
+	}
+	if preset == domainTemplatePath {
+		return "", fmt.Errorf("invalid %s %q: %s", DomainTemplateAnnotation, preset, pathRoutingUnsupported)
+	}
+	template, ok := domainTemplatePresets[preset]
+	if !ok {
+		return "", fmt.Errorf("invalid %s %q: must be %q or %q", DomainTemplateAnnotation, preset, DomainTemplateFlat, DomainTemplateSubdomain)
+	}
+	return template, nil
+}
+
+// applyDomainTemplate renders the selected preset, if any, into config-network of the given spec.
+func applyDomainTemplate(ks *v1alpha1.KnativeServing) error {
+	template, err := DomainTemplate(ks)
+	if err != nil || template == "" {
+		return err
+	}
+	common.Configure(&ks.Spec.CommonSpec, "network", "domainTemplate", template)
+	return nil
+}
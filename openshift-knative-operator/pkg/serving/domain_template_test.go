@@ -0,0 +1,59 @@
+package serving
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestDomainTemplate(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		template    string
+		want        string
+		wantErr     bool
+	}{{
+		name: "no preset",
+	}, {
+		name:        "flat",
+		annotations: map[string]string{DomainTemplateAnnotation: DomainTemplateFlat},
+		want:        "{{.Name}}-{{.Namespace}}.{{.Domain}}",
+	}, {
+		name:        "subdomain",
+		annotations: map[string]string{DomainTemplateAnnotation: DomainTemplateSubdomain},
+		want:        "{{.Name}}.{{.Namespace}}.{{.Domain}}",
+	}, {
+		name:        "path",
+		annotations: map[string]string{DomainTemplateAnnotation: "path"},
+		wantErr:     true,
+	}, {
+		name:        "unknown",
+		annotations: map[string]string{DomainTemplateAnnotation: "nested"},
+		wantErr:     true,
+	}, {
+		name:     "custom template",
+		template: "{{.Name}}.{{.Domain}}",
+	}, {
+		name:     "path in custom template",
+		template: "{{.Domain}}/{{.Namespace}}/{{.Name}}",
+		wantErr:  true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			if c.template != "" {
+				ks.Spec.Config = v1alpha1.ConfigMapData{"network": {"domainTemplate": c.template}}
+			}
+			got, err := DomainTemplate(ks)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("DomainTemplate() = %v, wantErr %v", err, c.wantErr)
+			}
+			if got != c.want {
+				t.Errorf("DomainTemplate() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
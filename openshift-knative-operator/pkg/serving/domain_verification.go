@@ -0,0 +1,38 @@
+package serving
+
+import (
+	"fmt"
+
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// DomainVerificationAnnotation enables the verification of the ownership of a domain before
+// a namespace may claim it through a DomainMapping. The value is the method the ownership is
+// proven with, see DomainVerificationTXT and DomainVerificationHTTP. Verification is disabled
+// if the annotation is not set.
+const DomainVerificationAnnotation = "serving.knative.openshift.io/domainVerification"
+
+const (
+	// DomainVerificationTXT requires a TXT record on the domain carrying the challenge token
+	// of the claiming namespace.
+	DomainVerificationTXT = "txt"
+	// DomainVerificationHTTP requires the domain to serve the challenge token of the claiming
+	// namespace over HTTP.
+	DomainVerificationHTTP = "http"
+)
+
+// DomainVerificationFromAnnotation returns the method domain ownership is verified with for
+// the given KnativeServing. It returns an empty string if verification is disabled.
+func DomainVerificationFromAnnotation(ks *v1alpha1.KnativeServing) (string, error) {
+	method, ok := ks.GetAnnotations()[DomainVerificationAnnotation]
+	if !ok {
+		return "", nil
+	}
+	switch method {
+	case DomainVerificationTXT, DomainVerificationHTTP:
+		return method, nil
+	default:
+		return "", fmt.Errorf("%s = %q, must be either %q or %q", DomainVerificationAnnotation, method,
+			DomainVerificationTXT, DomainVerificationHTTP)
+	}
+}
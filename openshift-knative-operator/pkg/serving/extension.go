@@ -3,26 +3,30 @@ package serving
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
-	"strings"
 
-	"github.com/blang/semver/v4"
 	mf "github.com/manifestival/manifestival"
 	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
 	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/monitoring"
 	"github.com/openshift-knative/serverless-operator/pkg/client/clientset/versioned"
 	ocpclient "github.com/openshift-knative/serverless-operator/pkg/client/injection/client"
+	"github.com/openshift-knative/serverless-operator/pkg/loglevel"
+	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/discovery"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	operator "knative.dev/operator/pkg/reconciler/common"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/logging/logkey"
 )
 
 const (
@@ -35,21 +39,106 @@ const (
 // NewExtension creates a new extension for a Knative Serving controller.
 func NewExtension(ctx context.Context) operator.Extension {
 	return &extension{
-		ocpclient:  ocpclient.Get(ctx),
-		kubeclient: kubeclient.Get(ctx),
+		ocpclient:     ocpclient.Get(ctx),
+		kubeclient:    kubeclient.Get(ctx),
+		dynamicclient: dynamicclient.Get(ctx),
+		logger:        logging.FromContext(loglevel.WithLogger(ctx, loglevel.ServingExtension)),
+		dns:           newDNSProber(net.DefaultResolver.LookupHost),
 	}
 }
 
 type extension struct {
-	ocpclient  versioned.Interface
-	kubeclient kubernetes.Interface
+	ocpclient     versioned.Interface
+	kubeclient    kubernetes.Interface
+	dynamicclient dynamic.Interface
+	// logger is the logger of the serving-extension subsystem, whose level is set separately
+	// from the rest of the operator.
+	logger *zap.SugaredLogger
+	// origins records who set the config keys of the KnativeServing.
+	origins configOriginRecorder
+	// features reports the feature flags Serving is running with.
+	features common.FeatureRecorder
+	// dns probes the wildcard DNS of the domains. The probe is skipped if it's nil.
+	dns *dnsProber
 }
 
-func (e *extension) Manifests(ks v1alpha1.KComponent) ([]mf.Manifest, error) {
-	return monitoring.GetServingMonitoringPlatformManifests(ks)
+func (e *extension) Manifests(comp v1alpha1.KComponent) ([]mf.Manifest, error) {
+	return Manifests(comp.(*v1alpha1.KnativeServing))
 }
 
-func (e *extension) Transformers(ks v1alpha1.KComponent) []mf.Transformer {
+// Manifests returns the manifests the extension installs in addition to Knative Serving for
+// the given KnativeServing. They don't depend on the state of the cluster.
+func Manifests(ks *v1alpha1.KnativeServing) ([]mf.Manifest, error) {
+	// There's nothing to monitor without the control plane.
+	if phase, _ := InstallPhase(ks); phase == InstallPhaseCRDs {
+		return nil, nil
+	}
+	manifests, err := monitoring.GetServingMonitoringPlatformManifests(ks)
+	if err != nil {
+		return nil, err
+	}
+
+	// net-contour runs next to Serving and is scraped like the rest of its control plane.
+	if ks.Spec.Ingress != nil && ks.Spec.Ingress.Contour.Enabled {
+		var contour mf.Manifest
+		if err := monitoring.AppendManifestsForComponent(contourController, ks.GetNamespace(), &contour); err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, contour)
+	}
+
+	hints, err := ClusterAutoscalerFromAnnotation(ks)
+	if err != nil {
+		return nil, err
+	}
+	if hints != nil && hints.Overprovisioning != nil {
+		overprovisioning, err := overprovisioningManifest(ks, hints.Overprovisioning)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *overprovisioning)
+	}
+
+	hostNetwork, err := KourierHostNetworkFromAnnotation(ks)
+	if err != nil {
+		return nil, err
+	}
+	if hostNetwork != nil {
+		serviceAccount, err := kourierHostNetworkManifest(ks)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *serviceAccount)
+	}
+
+	telemetry, err := TelemetryFromAnnotation(ks)
+	if err != nil {
+		return nil, err
+	}
+	if telemetry != nil {
+		collector, err := telemetryManifest(ks, telemetry)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *collector)
+	}
+	return manifests, nil
+}
+
+func (e *extension) Transformers(comp v1alpha1.KComponent) []mf.Transformer {
+	return Transformers(comp.(*v1alpha1.KnativeServing))
+}
+
+// Transformers returns the transformers the extension applies to the manifests of the given
+// KnativeServing. They don't depend on the state of the cluster.
+func Transformers(ks *v1alpha1.KnativeServing) []mf.Transformer {
+	// Malformed hints and host network settings fail the reconciliation in Default already.
+	hints, _ := ClusterAutoscalerFromAnnotation(ks)
+	hostNetwork, _ := KourierHostNetworkFromAnnotation(ks)
+	transformers := monitoring.GetServingTransformers(ks)
+	if ks.Spec.Ingress != nil && ks.Spec.Ingress.Contour.Enabled && monitoring.ShouldEnableMonitoring(ks.GetSpec().GetConfig()) {
+		transformers = append(transformers, monitoring.InjectRbacProxyContainerToDeployments(sets.NewString(contourController)))
+	}
 	return append([]mf.Transformer{
 		common.InjectEnvironmentIntoDeployment("controller", "controller",
 			corev1.EnvVar{Name: "HTTP_PROXY", Value: os.Getenv("HTTP_PROXY")},
@@ -57,12 +146,24 @@ func (e *extension) Transformers(ks v1alpha1.KComponent) []mf.Transformer {
 			corev1.EnvVar{Name: "NO_PROXY", Value: os.Getenv("NO_PROXY")},
 		),
 		overrideKourierNamespace(kourierNamespace(ks.GetNamespace())),
-	}, monitoring.GetServingTransformers(ks)...)
+		overrideKourierBootstrap(ks.GetAnnotations()[KourierBootstrapAnnotation]),
+		common.OverrideAutoscaledReplicas(ks.GetSpec().GetDeploymentOverride()),
+		serviceAccountPullSecrets(ks.GetNamespace(), ks.GetSpec().GetRegistry().ImagePullSecrets),
+		safeToEvictHint(hints),
+		kourierHostNetworkTransform(hostNetwork),
+		kourierGatewayHATransform(ks),
+		kourierGatewayReadinessTransform(),
+		architectureAffinityTransform(ks),
+		caBundleChecksumTransform(ks),
+	}, transformers...)
 }
 
 func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) error {
 	ks := comp.(*v1alpha1.KnativeServing)
-	log := logging.FromContext(ctx)
+	log := e.logger.With(zap.String(logkey.Key, ks.Namespace+"/"+ks.Name))
+	ctx = logging.WithLogger(ctx, log)
+	// The config as set by the user, before any of the defaults below are applied.
+	userConfig := copyConfig(ks.Spec.Config)
 
 	// Make sure Knative Serving is always installed in the defined namespace.
 	requiredNs := os.Getenv(requiredNsEnvName)
@@ -76,11 +177,35 @@ func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) err
 		ks.Status.MarkDependenciesInstalled()
 	}
 
-	// Set the default host to the cluster's host.
+	phase, err := InstallPhase(ks)
+	if err != nil {
+		ks.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+	reportInstallPhase(ks, phase)
+	if phase == InstallPhaseCRDs {
+		// None of the defaults below apply to the CRDs.
+		restrictToCRDs(ks)
+		return nil
+	}
+
+	hosted, err := hostedControlPlane(ctx, e.dynamicclient)
+	if err != nil {
+		return err
+	}
+
+	// Set the default host to the cluster's host. The ingress config of clusters with a hosted
+	// control plane is managed outside of the cluster and might not be available yet, in which
+	// case the upstream default domain is kept.
 	if domain, err := e.fetchClusterHost(ctx); err != nil {
-		return fmt.Errorf("failed to fetch cluster host: %w", err)
+		if !hosted || !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to fetch cluster host: %w", err)
+		}
+		log.Warn("No ingress config available on the hosted cluster, not defaulting the domain")
 	} else if domain != "" {
 		common.Configure(&ks.Spec.CommonSpec, "domain", domain, "")
+	} else if hosted {
+		log.Warn("No domain set in the ingress config of the hosted cluster, not defaulting the domain")
 	}
 
 	// Attempt to locate kibana route which is available if openshift-logging has been configured
@@ -95,7 +220,90 @@ func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) err
 	ks.Spec.Registry.Override = images
 	ks.Spec.Registry.Default = images["default"]
 	common.Configure(&ks.Spec.CommonSpec, "deployment", "queueSidecarImage", images["queue-proxy"])
+	if image := ks.GetAnnotations()[KourierGatewayImageAnnotation]; image != "" {
+		ks.Spec.Registry.Override[kourierGatewayImageKey] = image
+	}
+
+	// Apply an Ingress config with Kourier enabled if nothing else is defined. This is
+	// repeated by Default but needed to default the Kourier gateway service type.
+	defaultToKourier(ks)
+
+	// Changing service type from LoadBalancer to ClusterIP has a bug https://github.com/kubernetes/kubernetes/pull/95196
+	// Do not apply the default if the version is less than v1.20.0.
+	if err := common.CheckMinimumVersion(e.kubeclient.Discovery(), "1.20.0"); err != nil {
+		log.Warnf("Could not apply default service type for Kourier Gateway: %v", err)
+	} else {
+		// Apply Kourier gateway service type.
+		defaultKourierServiceType(ks)
+	}
+
+	// Pin the pods to the architectures images exist for on clusters of mixed architectures.
+	if err := reconcileArchitectures(ctx, e.kubeclient, ks); err != nil {
+		return err
+	}
+
+	// Remove the telemetry collector once it's disabled.
+	if _, ok := ks.GetAnnotations()[TelemetryAnnotation]; !ok {
+		if err := common.DeleteTelemetryCollector(ctx, e.kubeclient, ks.Namespace); err != nil {
+			return err
+		}
+	}
+
+	// Label the namespaces with the pod security levels the components are admitted by.
+	podSecurity, err := PodSecurityFromAnnotation(ks)
+	if err != nil {
+		ks.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+	if err := reconcilePodSecurity(ctx, e.kubeclient, ks, podSecurity); err != nil {
+		return err
+	}
+
+	// Keep the KnativeServing from becoming ready until Kourier can receive external traffic.
+	if err := checkKourierReadiness(ctx, e.kubeclient, ks); err != nil {
+		return err
+	}
+
+	if err := Default(ks); err != nil {
+		ks.Status.MarkInstallFailed(err.Error())
+		return controller.NewPermanentError(err)
+	}
+
+	// Warn about configurations that are not supported to aid the triage of support cases.
+	violations := supportedConfigPolicy.Violations(&ks.Spec.CommonSpec)
+	if unsupported, err := common.UnsupportedVersionViolations(e.kubeclient.Discovery()); err != nil {
+		log.Warnf("Could not check for an unsupported Kubernetes version: %v", err)
+	} else {
+		violations = append(unsupported, violations...)
+	}
+	common.MarkSupportedConfiguration(&ks.Status, violations)
+	markConsistentTimeouts(ks)
+	if e.dns != nil {
+		markWildcardDNS(e.dns, ks)
+	}
+	if err := reconcileReplicas(ctx, e.kubeclient, ks); err != nil {
+		return err
+	}
+
+	// Restart the webhooks and controllers once the CA bundles they depend on rotate.
+	if err := reconcileCABundleChecksum(ctx, e.kubeclient, ks); err != nil {
+		return err
+	}
+
+	if err := e.origins.reportConfigOrigins(ctx, ks, userConfig); err != nil {
+		return err
+	}
+
+	if err := e.features.ReportFeatures(ctx, e.kubeclient, "serving", ks.Namespace, &ks.Status.Status); err != nil {
+		return err
+	}
 
+	return monitoring.ReconcileMonitoringForServing(ctx, e.kubeclient, ks)
+}
+
+// Default applies the defaults of the extension that don't depend on the cluster to the
+// given KnativeServing. It's also used to lint KnativeServings without a cluster.
+func Default(ks *v1alpha1.KnativeServing) error {
 	// Default to 2 replicas.
 	if ks.Spec.HighAvailability == nil {
 		ks.Spec.HighAvailability = &v1alpha1.HighAvailability{
@@ -107,15 +315,10 @@ func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) err
 	defaultToKourier(ks)
 	common.ConfigureIfUnset(&ks.Spec.CommonSpec, "network", "ingress.class", defaultIngressClass(ks))
 
-	// Changing service type from LoadBalancer to ClusterIP has a bug https://github.com/kubernetes/kubernetes/pull/95196
-	// Do not apply the default if the version is less than v1.20.0.
-	if err := checkMinimumVersion(e.kubeclient.Discovery(), "1.20.0"); err != nil {
-		log.Warnf("Could not apply default service type for Kourier Gateway: %v", err)
-	} else {
-		// Apply Kourier gateway service type.
-		defaultKourierServiceType(ks)
+	// Render the domainTemplate preset, if any, overriding the respective ConfigMap key.
+	if err := applyDomainTemplate(ks); err != nil {
+		return err
 	}
-
 	// Override the default domainTemplate to use $name-$ns rather than $name.$ns.
 	common.ConfigureIfUnset(&ks.Spec.CommonSpec, "network", "domainTemplate", defaultDomainTemplate)
 
@@ -138,11 +341,90 @@ func (e *extension) Reconcile(ctx context.Context, comp v1alpha1.KComponent) err
 	// independent from upstream default changes.
 	common.ConfigureIfUnset(&ks.Spec.CommonSpec, "network", "autocreateClusterDomainClaims", "true")
 
-	// Temporary fix for SRVKS-743
+	// Render the cold-start profile, overriding the respective ConfigMap keys.
+	coldStart, err := ColdStartFromAnnotation(ks)
+	if err != nil {
+		return err
+	}
+	if coldStart != nil {
+		coldStart.apply(&ks.Spec.CommonSpec)
+	}
+
+	// Render the rollout defaults, overriding the respective ConfigMap keys.
+	rollout, err := RolloutFromAnnotations(ks)
+	if err != nil {
+		return err
+	}
+	if rollout != nil {
+		rollout.apply(&ks.Spec.CommonSpec)
+	}
+
+	// Render the revision garbage collection policy, overriding the respective ConfigMap keys.
+	revisionGC, err := RevisionGCFromAnnotation(ks)
+	if err != nil {
+		return err
+	}
+	if revisionGC != nil {
+		revisionGC.apply(&ks.Spec.CommonSpec)
+	}
+
+	// Render the Kourier connection settings, overriding the respective ConfigMap keys.
+	kourierConns, err := KourierConnectionsFromAnnotation(ks)
+	if err != nil {
+		return err
+	}
+	if kourierConns != nil {
+		kourierConns.apply(&ks.Spec.CommonSpec)
+	}
+
+	// Render the tag resolution settings, overriding the respective ConfigMap keys.
+	tagResolution, err := TagResolutionFromAnnotation(ks)
+	if err != nil {
+		return err
+	}
+	if tagResolution != nil {
+		tagResolution.apply(&ks.Spec.CommonSpec)
+	}
+
+	// Render the request logging settings, overriding the respective ConfigMap keys.
+	requestLogging, err := RequestLoggingFromAnnotation(ks)
+	if err != nil {
+		return err
+	}
+	if requestLogging != nil {
+		requestLogging.apply(&ks.Spec.CommonSpec)
+	}
+
+	// Point the tracing and metrics to the telemetry collector, overriding the respective
+	// ConfigMap keys.
+	telemetry, err := TelemetryFromAnnotation(ks)
+	if err != nil {
+		return err
+	}
+	if telemetry != nil {
+		telemetry.Apply(&ks.Spec.CommonSpec, ks.Namespace)
+	}
+
+	// Align the Route timeout with the timeouts rendered above, if requested.
+	alignTimeouts(ks)
+
+	// The cluster autoscaler hints are rendered into the manifest, only validate them here.
+	if _, err := ClusterAutoscalerFromAnnotation(ks); err != nil {
+		return err
+	}
+
+	// The Kourier host network settings are rendered into the manifest, only validate them here.
+	if _, err := KourierHostNetworkFromAnnotation(ks); err != nil {
+		return err
+	}
+
+	// Temporary fix for SRVKS-743. Only Istio injects sidecars, Kourier and Contour keep the
+	// metrics backend as configured. With Contour, net-contour reports through that backend
+	// as well and is scraped along with Serving, see Manifests and Transformers.
 	if ks.Spec.Ingress.Istio.Enabled {
 		common.ConfigureIfUnset(&ks.Spec.CommonSpec, monitoring.ObservabilityCMName, monitoring.ObservabilityBackendKey, "none")
 	}
-	return monitoring.ReconcileMonitoringForServing(ctx, e.kubeclient, ks)
+	return nil
 }
 
 func (e *extension) Finalize(ctx context.Context, comp v1alpha1.KComponent) error {
@@ -179,41 +461,3 @@ func (e *extension) fetchLoggingHost(ctx context.Context) string {
 	}
 	return route.Status.Ingress[0].Host
 }
-
-// checkMinimumVersion checks if the version in the arg meets the requirement or not.
-// It is similar logic with CheckMinimumVersion() in knative.dev/pkg/version.
-func checkMinimumVersion(versioner discovery.ServerVersionInterface, version string) error {
-	v, err := versioner.ServerVersion()
-	if err != nil {
-		return err
-	}
-	currentVersion, err := semver.Make(normalizeVersion(v.GitVersion))
-	if err != nil {
-		return err
-	}
-
-	minimumVersion, err := semver.Make(normalizeVersion(version))
-	if err != nil {
-		return err
-	}
-
-	// If no specific pre-release requirement is set, we default to "-0" to always allow
-	// pre-release versions of the same Major.Minor.Patch version.
-	if len(minimumVersion.Pre) == 0 {
-		minimumVersion.Pre = []semver.PRVersion{{VersionNum: 0, IsNum: true}}
-	}
-
-	if currentVersion.LT(minimumVersion) {
-		return fmt.Errorf("kubernetes version %q is not compatible, need at least %q",
-			currentVersion, minimumVersion)
-	}
-	return nil
-}
-
-func normalizeVersion(v string) string {
-	if strings.HasPrefix(v, "v") {
-		// No need to account for unicode widths.
-		return v[1:]
-	}
-	return v
-}
@@ -2,9 +2,9 @@ package serving
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -26,6 +26,9 @@ import (
 	"knative.dev/pkg/apis"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	kubefake "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	dynamicfake "knative.dev/pkg/injection/clients/dynamicclient/fake"
+	"knative.dev/pkg/logging"
 )
 
 var (
@@ -47,6 +50,15 @@ var (
 
 const defaultK8sVersion = "v1.20.0"
 
+// dropConfigOrigins removes the config origins reported in the status, which are covered by
+// TestReconcileConfigOrigins.
+func dropConfigOrigins(ks *v1alpha1.KnativeServing) {
+	delete(ks.Status.Annotations, ConfigOriginsStatusKey)
+	if len(ks.Status.Annotations) == 0 {
+		ks.Status.Annotations = nil
+	}
+}
+
 func init() {
 	os.Setenv("IMAGE_foo", "bar")
 	os.Setenv("IMAGE_default", "bar2")
@@ -213,6 +225,26 @@ func TestReconcile(t *testing.T) {
 			common.Configure(&ks.Spec.CommonSpec, "network", "ingress.class", istioIngressClassName)
 			common.Configure(&ks.Spec.CommonSpec, monitoring.ObservabilityCMName, monitoring.ObservabilityBackendKey, "none")
 		}),
+	}, {
+		name: "contour ingress config",
+		in: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{
+					Contour: v1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Spec.Ingress = &v1alpha1.IngressConfigs{
+				Contour: v1alpha1.ContourIngressConfiguration{
+					Enabled: true,
+				},
+			}
+			// Contour runs without sidecars, so the metrics backend is left alone.
+			common.Configure(&ks.Spec.CommonSpec, "network", "ingress.class", contourIngressClassName)
+		}),
 	}, {
 		name: "fix 'wrong' ingress config", // https://github.com/knative/operator/issues/568
 		in: &v1alpha1.KnativeServing{
@@ -298,6 +330,262 @@ func TestReconcile(t *testing.T) {
 		expected: ks(func(ks *v1alpha1.KnativeServing) {
 			ks.Status.MarkDependenciesInstalled()
 		}),
+	}, {
+		name: "cold-start profile",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					ColdStartAnnotation: `{"initialScale": 0, "allowZeroInitialScale": true, "progressDeadline": "10m"}`,
+				},
+			},
+			Spec: v1alpha1.KnativeServingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					Config: v1alpha1.ConfigMapData{
+						"autoscaler": map[string]string{
+							"initial-scale": "3",
+						},
+					},
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				ColdStartAnnotation: `{"initialScale": 0, "allowZeroInitialScale": true, "progressDeadline": "10m"}`,
+			}
+			common.Configure(&ks.Spec.CommonSpec, "autoscaler", "initial-scale", "0")
+			common.Configure(&ks.Spec.CommonSpec, "autoscaler", "allow-zero-initial-scale", "true")
+			common.Configure(&ks.Spec.CommonSpec, "deployment", "progressDeadline", "10m")
+		}),
+	}, {
+		name: "domainTemplate preset",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					DomainTemplateAnnotation: DomainTemplateSubdomain,
+				},
+			},
+			Spec: v1alpha1.KnativeServingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					Config: v1alpha1.ConfigMapData{
+						"network": map[string]string{
+							"domainTemplate": "{{.Name}}-x-{{.Namespace}}.{{.Domain}}",
+						},
+					},
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				DomainTemplateAnnotation: DomainTemplateSubdomain,
+			}
+			common.Configure(&ks.Spec.CommonSpec, "network", "domainTemplate", "{{.Name}}.{{.Namespace}}.{{.Domain}}")
+		}),
+	}, {
+		name: "rollout defaults",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					RolloutDurationAnnotation:  "5m",
+					ProgressDeadlineAnnotation: "10m",
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				RolloutDurationAnnotation:  "5m",
+				ProgressDeadlineAnnotation: "10m",
+			}
+			common.Configure(&ks.Spec.CommonSpec, "network", "rolloutDuration", "300")
+			common.Configure(&ks.Spec.CommonSpec, "deployment", "progressDeadline", "10m0s")
+		}),
+	}, {
+		name: "revision gc policy",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					RevisionGCAnnotation: `{"retainSinceCreateTime": "24h", "minNonActiveRevisions": 5, "maxNonActiveRevisions": -1}`,
+				},
+			},
+			Spec: v1alpha1.KnativeServingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					Config: v1alpha1.ConfigMapData{
+						"gc": map[string]string{
+							"min-non-active-revisions":      "20",
+							"retain-since-last-active-time": "15h",
+						},
+					},
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				RevisionGCAnnotation: `{"retainSinceCreateTime": "24h", "minNonActiveRevisions": 5, "maxNonActiveRevisions": -1}`,
+			}
+			common.Configure(&ks.Spec.CommonSpec, "gc", "retain-since-last-active-time", "15h")
+			common.Configure(&ks.Spec.CommonSpec, "gc", "retain-since-create-time", "24h")
+			common.Configure(&ks.Spec.CommonSpec, "gc", "min-non-active-revisions", "5")
+			common.Configure(&ks.Spec.CommonSpec, "gc", "max-non-active-revisions", "disabled")
+		}),
+	}, {
+		name: "tag resolution settings",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					TagResolutionAnnotation: `{"skipRegistries": ["nexus.example.com:8443", "image-registry.openshift-image-registry.svc:5000"], "caBundle": "registry-ca"}`,
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				TagResolutionAnnotation: `{"skipRegistries": ["nexus.example.com:8443", "image-registry.openshift-image-registry.svc:5000"], "caBundle": "registry-ca"}`,
+			}
+			common.Configure(&ks.Spec.CommonSpec, "deployment", "registriesSkippingTagResolving",
+				"nexus.example.com:8443,image-registry.openshift-image-registry.svc:5000")
+		}),
+	}, {
+		name: "kourier connection settings",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					KourierConnectionsAnnotation: `{"idleTimeout": "90m", "maxConnectionDuration": "0s", "maxConcurrentStreams": 100}`,
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				KourierConnectionsAnnotation: `{"idleTimeout": "90m", "maxConnectionDuration": "0s", "maxConcurrentStreams": 100}`,
+			}
+			common.Configure(&ks.Spec.CommonSpec, "kourier", "idle-timeout", "1h30m0s")
+			common.Configure(&ks.Spec.CommonSpec, "kourier", "max-connection-duration", "0s")
+			common.Configure(&ks.Spec.CommonSpec, "kourier", "max-concurrent-streams", "100")
+		}),
+	}, {
+		name: "pinned kourier gateway image",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					KourierGatewayImageAnnotation: "quay.io/envoy:pinned",
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				KourierGatewayImageAnnotation: "quay.io/envoy:pinned",
+			}
+			ks.Spec.Registry.Override[kourierGatewayImageKey] = "quay.io/envoy:pinned"
+		}),
+	}, {
+		name: "invalid cold-start profile",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					ColdStartAnnotation: `{"initialScale": 0}`,
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				ColdStartAnnotation: `{"initialScale": 0}`,
+			}
+			ks.Status.MarkInstallFailed("invalid " + ColdStartAnnotation + ": initialScale = 0 requires allowZeroInitialScale to be true")
+		}),
+	}, {
+		name: "invalid rollout duration",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					RolloutDurationAnnotation: "1.5s",
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				RolloutDurationAnnotation: "1.5s",
+			}
+			ks.Status.MarkInstallFailed(RolloutDurationAnnotation + ` = "1.5s", must be a non-negative number of whole seconds`)
+		}),
+	}, {
+		name: "invalid revision gc policy",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					RevisionGCAnnotation: `{"minNonActiveRevisions": 10, "maxNonActiveRevisions": 5}`,
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				RevisionGCAnnotation: `{"minNonActiveRevisions": 10, "maxNonActiveRevisions": 5}`,
+			}
+			ks.Status.MarkInstallFailed("invalid " + RevisionGCAnnotation + ": minNonActiveRevisions = 10, must not exceed maxNonActiveRevisions = 5")
+		}),
+	}, {
+		name: "invalid kourier connection settings",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					KourierConnectionsAnnotation: `{"maxConcurrentStreams": 0}`,
+				},
+			},
+		},
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			ks.Annotations = map[string]string{
+				KourierConnectionsAnnotation: `{"maxConcurrentStreams": 0}`,
+			}
+			ks.Status.MarkInstallFailed("invalid " + KourierConnectionsAnnotation + ": maxConcurrentStreams = 0, must be between 1 and 2147483647")
+		}),
+	}, {
+		name: "crds install phase",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					InstallPhaseAnnotation: InstallPhaseCRDs,
+				},
+			},
+			Spec: v1alpha1.KnativeServingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					Version: "0.25.1",
+				},
+			},
+		},
+		expected: func() *v1alpha1.KnativeServing {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: servingNamespace.Name,
+					Annotations: map[string]string{
+						InstallPhaseAnnotation: InstallPhaseCRDs,
+					},
+				},
+			}
+			ks.Spec.Version = "0.25.1"
+			ks.Spec.Manifests = []v1alpha1.Manifest{{
+				Url: filepath.Join(os.Getenv(operator.KoEnvKey), "knative-serving", "0.25.1", crdsManifest),
+			}}
+			ks.Spec.Ingress = &v1alpha1.IngressConfigs{}
+			ks.Status.Annotations = map[string]string{InstallPhaseStatusKey: InstallPhaseCRDs}
+			return ks
+		}(),
+	}, {
+		name: "invalid install phase",
+		in: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					InstallPhaseAnnotation: "controlplane",
+				},
+			},
+		},
+		expected: func() *v1alpha1.KnativeServing {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: servingNamespace.Name,
+					Annotations: map[string]string{
+						InstallPhaseAnnotation: "controlplane",
+					},
+				},
+			}
+			ks.Status.MarkInstallFailed(`invalid ` + InstallPhaseAnnotation + ` "controlplane": must be "crds" or "full"`)
+			return ks
+		}(),
 	}, {
 		name: "wrong namespace",
 		in: ks(func(ks *v1alpha1.KnativeServing) {
@@ -307,6 +595,17 @@ func TestReconcile(t *testing.T) {
 			ks.Namespace = "foo"
 			ks.Status.MarkInstallFailed(`Knative Serving must be installed into the namespace "knative-serving"`)
 		}),
+	}, {
+		name: "unsupported feature",
+		in: ks(func(ks *v1alpha1.KnativeServing) {
+			common.Configure(&ks.Spec.CommonSpec, "features", "tag-header-based-routing", "Enabled")
+		}),
+		expected: ks(func(ks *v1alpha1.KnativeServing) {
+			common.Configure(&ks.Spec.CommonSpec, "features", "tag-header-based-routing", "Enabled")
+			common.MarkSupportedConfiguration(&ks.Status, []string{
+				`tag-header-based-routing "Enabled" in features is unsupported: ` + alphaFeature,
+			})
+		}),
 	}}
 
 	for _, c := range cases {
@@ -323,12 +622,14 @@ func TestReconcile(t *testing.T) {
 			ks := c.in.DeepCopy()
 			ctx, _ := ocpfake.With(context.Background(), objs...)
 			ctx, _ = kubefake.With(ctx, &servingNamespace)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
 			ext := newFakeExtension(ctx, t)
 			ext.Reconcile(context.Background(), ks)
 			// Ignore time differences.
 			opt := cmp.Comparer(func(apis.VolatileTime, apis.VolatileTime) bool {
 				return true
 			})
+			dropConfigOrigins(ks)
 			if !cmp.Equal(ks, c.expected, opt) {
 				t.Errorf("Got = %v, want: %v, diff:\n%s", ks, c.expected, cmp.Diff(ks, c.expected, opt))
 			}
@@ -348,8 +649,10 @@ func newFakeExtension(ctx context.Context, t *testing.T) operator.Extension {
 	}
 
 	return &extension{
-		ocpclient:  ocpclient.Get(ctx),
-		kubeclient: kclient,
+		ocpclient:     ocpclient.Get(ctx),
+		kubeclient:    kclient,
+		dynamicclient: dynamicclient.Get(ctx),
+		logger:        logging.FromContext(ctx),
 	}
 }
 
@@ -469,6 +772,7 @@ func TestMonitoring(t *testing.T) {
 			c.expected.Namespace = ks.Namespace
 			ctx, _ := ocpfake.With(context.Background(), objs...)
 			ctx, kube := kubefake.With(ctx, &servingNamespace)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme())
 			ext := newFakeExtension(ctx, t)
 			shouldEnableMonitoring, err := c.setupMonitoringToggle()
 
@@ -481,6 +785,7 @@ func TestMonitoring(t *testing.T) {
 			opt := cmp.Comparer(func(apis.VolatileTime, apis.VolatileTime) bool {
 				return true
 			})
+			dropConfigOrigins(ks)
 			if !cmp.Equal(ks, c.expected, opt) {
 				t.Errorf("Got = %v, want: %v, diff:\n%s", ks, c.expected, cmp.Diff(ks, c.expected, opt))
 			}
@@ -555,71 +860,3 @@ func ks(mods ...func(*v1alpha1.KnativeServing)) *v1alpha1.KnativeServing {
 
 	return base
 }
-
-type testVersioner struct {
-	version string
-	err     error
-}
-
-func (t *testVersioner) ServerVersion() (*version.Info, error) {
-	return &version.Info{GitVersion: t.version}, t.err
-}
-
-func TestVersionCheck(t *testing.T) {
-	tests := []struct {
-		name          string
-		actualVersion *testVersioner
-		wantError     bool
-	}{{
-		name:          "greater version (patch)",
-		actualVersion: &testVersioner{version: "v1.20.0"},
-	}, {
-		name:          "greater version (patch), no v",
-		actualVersion: &testVersioner{version: "1.20.0"},
-	}, {
-		name:          "greater version (patch), pre-release",
-		actualVersion: &testVersioner{version: "1.20.2-kpn-065dce"},
-	}, {
-		name:          "greater version (patch), pre-release with build",
-		actualVersion: &testVersioner{version: "1.20.0-1095+9689d22dc3121e-dirty"},
-	}, {
-		name:          "greater version (minor)",
-		actualVersion: &testVersioner{version: "v1.20.0"},
-	}, {
-		name:          "same version",
-		actualVersion: &testVersioner{version: "v1.20.0"},
-	}, {
-		name:          "same version with build",
-		actualVersion: &testVersioner{version: "v1.20.0+k3s.1"},
-	}, {
-		name:          "same version with pre-release",
-		actualVersion: &testVersioner{version: "v1.20.0-k3s.1"},
-	}, {
-		name:          "smaller version",
-		actualVersion: &testVersioner{version: "v1.19.3"},
-		wantError:     true,
-	}, {
-		name:          "error while fetching",
-		actualVersion: &testVersioner{err: errors.New("random error")},
-		wantError:     true,
-	}, {
-		name:          "unparseable actual version",
-		actualVersion: &testVersioner{version: "v1.19.foo"},
-		wantError:     true,
-	}}
-
-	minVersion := "1.20.0"
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			err := checkMinimumVersion(test.actualVersion, minVersion)
-			if err == nil && test.wantError {
-				t.Errorf("Expected an error for minimum: %q, actual: %v", minVersion, test.actualVersion)
-			}
-
-			if err != nil && !test.wantError {
-				t.Errorf("Expected no error but got %v for minimum: %q, actual: %v", err, minVersion, test.actualVersion)
-			}
-		})
-	}
-}
@@ -6,7 +6,13 @@ import (
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 )
 
-const istioIngressClassName = "istio.ingress.networking.knative.dev"
+const (
+	istioIngressClassName   = "istio.ingress.networking.knative.dev"
+	contourIngressClassName = "contour.ingress.networking.knative.dev"
+
+	// contourController is the deployment of net-contour, running next to Knative Serving.
+	contourController = "net-contour-controller"
+)
 
 // defaultToKourier applies an Ingress config with Kourier enabled if nothing else is defined.
 // Also handles the (buggy) case, where all Ingresses are disabled.
@@ -38,7 +44,8 @@ func defaultKourierServiceType(ks *v1alpha1.KnativeServing) {
 // defaultIngressClass tries to figure out which ingress class to default to.
 // - If nothing is defined, Kourier will be used.
 // - If Kourier is enabled, it'll always take precedence.
-// - If only Istio is enabled, it'll be used.
+// - If Istio is enabled, it'll be used over Contour.
+// - If only Contour is enabled, it'll be used.
 func defaultIngressClass(ks *v1alpha1.KnativeServing) string {
 	if ks.Spec.Ingress == nil {
 		return kourierIngressClassName
@@ -49,5 +56,8 @@ func defaultIngressClass(ks *v1alpha1.KnativeServing) string {
 	if ks.Spec.Ingress.Istio.Enabled {
 		return istioIngressClassName
 	}
+	if ks.Spec.Ingress.Contour.Enabled {
+		return contourIngressClassName
+	}
 	return kourierIngressClassName
 }
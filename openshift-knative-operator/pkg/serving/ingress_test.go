@@ -1,9 +1,12 @@
 package serving
 
 import (
+	"os"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/monitoring"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 )
 
@@ -51,6 +54,33 @@ func TestDefaultIngressClass(t *testing.T) {
 			},
 		},
 		expected: kourierIngressClassName,
+	}, {
+		name: "contour enabled",
+		in: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{
+					Contour: v1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expected: contourIngressClassName,
+	}, {
+		name: "istio and contour enabled",
+		in: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{
+					Istio: v1alpha1.IstioIngressConfiguration{
+						Enabled: true,
+					},
+					Contour: v1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expected: istioIngressClassName,
 	}}
 
 	for _, c := range cases {
@@ -62,3 +92,56 @@ func TestDefaultIngressClass(t *testing.T) {
 		})
 	}
 }
+
+func TestContourMonitoring(t *testing.T) {
+	os.Setenv("SERVICE_MONITOR_RBAC_MANIFEST_PATH", "../../cmd/operator/kodata/monitoring/rbac-proxy.yaml")
+	defer os.Unsetenv("SERVICE_MONITOR_RBAC_MANIFEST_PATH")
+
+	ks := &v1alpha1.KnativeServing{
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Config: map[string]map[string]string{monitoring.ObservabilityCMName: {monitoring.ObservabilityBackendKey: "prometheus"}},
+			},
+			Ingress: &v1alpha1.IngressConfigs{
+				Contour: v1alpha1.ContourIngressConfiguration{Enabled: true},
+			},
+		},
+	}
+	ks.Namespace = "knative-serving"
+
+	manifests, err := Manifests(ks)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	found := false
+	for _, manifest := range manifests {
+		for _, u := range manifest.Resources() {
+			found = found || (u.GetKind() == "ServiceMonitor" && u.GetName() == contourController+"-sm")
+		}
+	}
+	if !found {
+		t.Errorf("No ServiceMonitor for %s", contourController)
+	}
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": contourController, "namespace": ks.Namespace},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "controller"}},
+				},
+			},
+		},
+	}}
+	for _, transform := range Transformers(ks) {
+		if err := transform(deployment); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	if len(containers) != 2 {
+		t.Errorf("Got %d containers in %s, want the RBAC proxy added", len(containers), contourController)
+	}
+}
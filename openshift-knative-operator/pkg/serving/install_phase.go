@@ -0,0 +1,68 @@
+package serving
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	operator "knative.dev/operator/pkg/reconciler/common"
+)
+
+const (
+	// InstallPhaseAnnotation selects how much of Knative Serving is installed, either
+	// InstallPhaseCRDs or InstallPhaseFull (the default). Installing only the CRDs allows
+	// pre-provisioning them, e.g. through GitOps, before rolling out the control plane.
+	InstallPhaseAnnotation = "serving.knative.openshift.io/installPhase"
+
+	// InstallPhaseCRDs only installs the CRDs.
+	InstallPhaseCRDs = "crds"
+	// InstallPhaseFull installs the CRDs, the control plane and the ingress.
+	InstallPhaseFull = "full"
+
+	// InstallPhaseStatusKey is the status annotation reporting the active install phase.
+	InstallPhaseStatusKey = "operator.serverless.openshift.io/install-phase"
+
+	// crdsManifest is the file of the Serving release only containing the CRDs.
+	crdsManifest = "1-serving-crds.yaml"
+)
+
+// InstallPhase returns the install phase selected by the given KnativeServing.
+func InstallPhase(ks *v1alpha1.KnativeServing) (string, error) {
+	phase, ok := ks.GetAnnotations()[InstallPhaseAnnotation]
+	if !ok {
+		return InstallPhaseFull, nil
+	}
+	switch phase {
+	case InstallPhaseCRDs, InstallPhaseFull:
+		return phase, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q: must be %q or %q", InstallPhaseAnnotation, phase, InstallPhaseCRDs, InstallPhaseFull)
+	}
+}
+
+// reportInstallPhase reports the given install phase in the status of the given KnativeServing,
+// unless the phase isn't selected explicitly.
+func reportInstallPhase(ks *v1alpha1.KnativeServing, phase string) {
+	if _, ok := ks.GetAnnotations()[InstallPhaseAnnotation]; !ok {
+		delete(ks.Status.Annotations, InstallPhaseStatusKey)
+		return
+	}
+	if ks.Status.Annotations == nil {
+		ks.Status.Annotations = make(map[string]string, 1)
+	}
+	ks.Status.Annotations[InstallPhaseStatusKey] = phase
+}
+
+// restrictToCRDs limits the manifest installed for the given KnativeServing to the CRDs of
+// its target version, without any ingress. Switching back to this phase after a full
+// installation removes the control plane.
+func restrictToCRDs(ks *v1alpha1.KnativeServing) {
+	version := operator.TargetVersion(ks)
+	ks.Spec.Version = version
+	ks.Spec.Manifests = []v1alpha1.Manifest{{
+		Url: filepath.Join(os.Getenv(operator.KoEnvKey), "knative-serving", version, crdsManifest),
+	}}
+	ks.Spec.AdditionalManifests = nil
+	ks.Spec.Ingress = &v1alpha1.IngressConfigs{}
+}
@@ -0,0 +1,104 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// KourierConnectionsAnnotation is the annotation on the KnativeServing CR carrying the
+// connection settings of the Kourier gateway as JSON, for example:
+//
+//	serving.knative.openshift.io/kourierConnections: |
+//	  {"idleTimeout": "1h", "maxConnectionDuration": "24h", "maxConcurrentStreams": 100}
+//
+// Long-polling and gRPC streaming workloads usually need longer timeouts than the defaults.
+// The settings take precedence over the respective keys in spec.config.
+const KourierConnectionsAnnotation = "serving.knative.openshift.io/kourierConnections"
+
+// KourierConnections bundles the settings of the connections downstream of the Kourier
+// gateway, which configure the HTTP connection managers of Envoy's listeners.
+type KourierConnections struct {
+	// IdleTimeout is the time a connection without active requests is kept open for, or 0
+	// to never close idle connections.
+	// Maps to "idle-timeout" in config-kourier.
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// MaxConnectionDuration is the time after which a connection is drained and closed,
+	// or 0 to not limit the lifetime of connections.
+	// Maps to "max-connection-duration" in config-kourier.
+	MaxConnectionDuration string `json:"maxConnectionDuration,omitempty"`
+	// MaxConcurrentStreams is the maximum number of concurrent streams of an HTTP/2
+	// connection.
+	// Maps to "max-concurrent-streams" in config-kourier.
+	MaxConcurrentStreams *int64 `json:"maxConcurrentStreams,omitempty"`
+}
+
+// KourierConnectionsFromAnnotation parses the Kourier connection settings of the given
+// KnativeServing. It returns nil if none are set.
+func KourierConnectionsFromAnnotation(ks *v1alpha1.KnativeServing) (*KourierConnections, error) {
+	raw, ok := ks.GetAnnotations()[KourierConnectionsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	conns := &KourierConnections{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(conns); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", KourierConnectionsAnnotation, err)
+	}
+	if err := conns.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", KourierConnectionsAnnotation, err)
+	}
+	return conns, nil
+}
+
+// Validate checks the settings for consistency.
+func (c *KourierConnections) Validate() error {
+	if _, err := parseTimeout("idleTimeout", c.IdleTimeout); err != nil {
+		return err
+	}
+	if _, err := parseTimeout("maxConnectionDuration", c.MaxConnectionDuration); err != nil {
+		return err
+	}
+	// Envoy only accepts up to 2^31-1 streams, as per RFC 7540.
+	if c.MaxConcurrentStreams != nil && (*c.MaxConcurrentStreams < 1 || *c.MaxConcurrentStreams > math.MaxInt32) {
+		return fmt.Errorf("maxConcurrentStreams = %d, must be between 1 and %d", *c.MaxConcurrentStreams, math.MaxInt32)
+	}
+	return nil
+}
+
+// apply renders the settings into config-kourier of the given spec.
+func (c *KourierConnections) apply(spec *v1alpha1.CommonSpec) {
+	if d, _ := parseTimeout("idleTimeout", c.IdleTimeout); d != nil {
+		common.Configure(spec, "kourier", "idle-timeout", d.String())
+	}
+	if d, _ := parseTimeout("maxConnectionDuration", c.MaxConnectionDuration); d != nil {
+		common.Configure(spec, "kourier", "max-connection-duration", d.String())
+	}
+	if c.MaxConcurrentStreams != nil {
+		common.Configure(spec, "kourier", "max-concurrent-streams", strconv.FormatInt(*c.MaxConcurrentStreams, 10))
+	}
+}
+
+// parseTimeout parses the given non-negative duration of the named setting. It returns nil
+// if the setting is empty.
+func parseTimeout(name, value string) (*time.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s = %q, must be a duration: %w", name, value, err)
+	}
+	if d < 0 {
+		return nil, fmt.Errorf("%s = %q, must not be negative", name, value)
+	}
+	return &d, nil
+}
@@ -0,0 +1,66 @@
+package serving
+
+import (
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// hostnameTopologyKey spreads pods across nodes.
+const hostnameTopologyKey = "kubernetes.io/hostname"
+
+// kourierGatewayHATransform scales the Kourier gateway to the replicas of spec.high-availability
+// and prefers to spread them across nodes. The same gateway serves cluster-local traffic
+// through the kourier-internal Service, so local traffic is as available as external traffic.
+// Replicas set through spec.deployments take precedence, as do affinities set in the manifest.
+func kourierGatewayHATransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	ha := ks.GetSpec().GetHighAvailability()
+	if ha == nil {
+		return func(*unstructured.Unstructured) error { return nil }
+	}
+	overridden := false
+	for _, override := range ks.GetSpec().GetDeploymentOverride() {
+		if override.Name == kourierGatewayDeployment && override.Replicas > 0 {
+			overridden = true
+		}
+	}
+
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" || u.GetName() != kourierGatewayDeployment {
+			return nil
+		}
+		deployment := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(u, deployment, nil); err != nil {
+			return err
+		}
+
+		if !overridden {
+			replicas := ha.Replicas
+			deployment.Spec.Replicas = &replicas
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		podSpec := &deployment.Spec.Template.Spec
+		if replicas > 1 && podSpec.Affinity == nil && deployment.Spec.Selector != nil {
+			// Preferred rather than required to still schedule all replicas on small clusters.
+			podSpec.Affinity = &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+						Weight: 100,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: deployment.Spec.Selector.MatchLabels},
+							TopologyKey:   hostnameTopologyKey,
+						},
+					}},
+				},
+			}
+		}
+		return scheme.Scheme.Convert(deployment, u, nil)
+	}
+}
@@ -0,0 +1,114 @@
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/ptr"
+)
+
+func TestKourierGatewayHATransform(t *testing.T) {
+	selector := map[string]string{"app": "3scale-kourier-gateway"}
+	antiAffinity := &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: selector},
+					TopologyKey:   hostnameTopologyKey,
+				},
+			}},
+		},
+	}
+	nodeAffinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+
+	tests := []struct {
+		name         string
+		ha           *v1alpha1.HighAvailability
+		overrides    []v1alpha1.DeploymentOverride
+		deployment   string
+		affinity     *corev1.Affinity
+		wantReplicas *int32
+		wantAffinity *corev1.Affinity
+	}{{
+		name:         "ha",
+		ha:           &v1alpha1.HighAvailability{Replicas: 3},
+		deployment:   kourierGatewayDeployment,
+		wantReplicas: ptr.Int32(3),
+		wantAffinity: antiAffinity,
+	}, {
+		name:         "single replica",
+		ha:           &v1alpha1.HighAvailability{Replicas: 1},
+		deployment:   kourierGatewayDeployment,
+		wantReplicas: ptr.Int32(1),
+	}, {
+		name:       "no ha",
+		deployment: kourierGatewayDeployment,
+	}, {
+		name: "replicas overridden",
+		ha:   &v1alpha1.HighAvailability{Replicas: 1},
+		overrides: []v1alpha1.DeploymentOverride{{
+			Name:     kourierGatewayDeployment,
+			Replicas: 4,
+		}},
+		deployment:   kourierGatewayDeployment,
+		wantReplicas: ptr.Int32(4),
+		wantAffinity: antiAffinity,
+	}, {
+		name:         "affinity kept",
+		ha:           &v1alpha1.HighAvailability{Replicas: 2},
+		deployment:   kourierGatewayDeployment,
+		affinity:     nodeAffinity,
+		wantReplicas: ptr.Int32(2),
+		wantAffinity: nodeAffinity,
+	}, {
+		name:       "other deployment",
+		ha:         &v1alpha1.HighAvailability{Replicas: 2},
+		deployment: "3scale-kourier-control",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				Spec: v1alpha1.KnativeServingSpec{
+					CommonSpec: v1alpha1.CommonSpec{
+						HighAvailability:   test.ha,
+						DeploymentOverride: test.overrides,
+					},
+				},
+			}
+			in := deployment("knative-serving-ingress", test.deployment)
+			in.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+			in.Spec.Template.Spec.Affinity = test.affinity
+			// Replicas set through spec.deployments are applied by the operator's common
+			// transformers.
+			for _, override := range test.overrides {
+				in.Spec.Replicas = ptr.Int32(override.Replicas)
+			}
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(in, u, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if err := kourierGatewayHATransform(ks)(u); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			got := &appsv1.Deployment{}
+			if err := scheme.Scheme.Convert(u, got, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if !cmp.Equal(got.Spec.Replicas, test.wantReplicas) {
+				t.Errorf("Replicas = %v, want %v", got.Spec.Replicas, test.wantReplicas)
+			}
+			if !cmp.Equal(got.Spec.Template.Spec.Affinity, test.wantAffinity) {
+				t.Errorf("Affinity = %v, want %v", got.Spec.Template.Spec.Affinity, test.wantAffinity)
+			}
+		})
+	}
+}
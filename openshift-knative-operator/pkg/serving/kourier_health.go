@@ -0,0 +1,55 @@
+package serving
+
+import (
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const kourierGatewayContainer = "kourier-gateway"
+
+// kourierGatewayReadinessScript only succeeds if the gateway is in sync with the control
+// plane:
+// * the internal listener serves the readiness route pushed by kourier-control,
+// * Envoy is LIVE, i.e. done with the initial fetch of its listeners and clusters, and
+// * no listener is still warming, i.e. waiting for the clusters of an update.
+//
+// A lost connection to kourier-control doesn't fail the check, as Envoy keeps serving its
+// last config and all gateways would otherwise become unready at once.
+const kourierGatewayReadinessScript = `curl -sf -o /dev/null -H 'Host: internalkourier' http://localhost:8081/ready && ` +
+	`curl -sf -o /dev/null --unix-socket /tmp/envoy.admin http://localhost/ready && ` +
+	`curl -sf --unix-socket /tmp/envoy.admin 'http://localhost/stats?filter=^listener_manager\.total_listeners_warming$' | grep -q ': 0$'`
+
+// kourierGatewayReadinessTransform replaces the readiness probe of the Kourier gateway with a
+// deep check of its sync state, so that the kourier Service, and thus the Routes, only send
+// traffic to gateways serving the current config.
+func kourierGatewayReadinessTransform() mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" || u.GetName() != kourierGatewayDeployment {
+			return nil
+		}
+		deployment := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(u, deployment, nil); err != nil {
+			return err
+		}
+		containers := deployment.Spec.Template.Spec.Containers
+		for i := range containers {
+			if containers[i].Name != kourierGatewayContainer {
+				continue
+			}
+			probe := containers[i].ReadinessProbe
+			if probe == nil {
+				probe = &corev1.Probe{PeriodSeconds: 5}
+			}
+			probe.Handler = corev1.Handler{
+				Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", kourierGatewayReadinessScript}},
+			}
+			// Three requests are made, which might take longer than the default second.
+			probe.TimeoutSeconds = 5
+			containers[i].ReadinessProbe = probe
+		}
+		return scheme.Scheme.Convert(deployment, u, nil)
+	}
+}
@@ -0,0 +1,87 @@
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestKourierGatewayReadinessTransform(t *testing.T) {
+	manifestProbe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/ready"},
+		},
+		InitialDelaySeconds: 10,
+		PeriodSeconds:       5,
+	}
+	deepProbe := &corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", kourierGatewayReadinessScript}},
+		},
+		InitialDelaySeconds: 10,
+		TimeoutSeconds:      5,
+		PeriodSeconds:       5,
+	}
+
+	tests := []struct {
+		name       string
+		deployment string
+		container  string
+		want       *corev1.Probe
+	}{{
+		name:       "gateway",
+		deployment: kourierGatewayDeployment,
+		container:  kourierGatewayContainer,
+		want:       deepProbe,
+	}, {
+		name:       "other container",
+		deployment: kourierGatewayDeployment,
+		container:  "sidecar",
+		want:       manifestProbe,
+	}, {
+		name:       "other deployment",
+		deployment: "net-kourier-controller",
+		container:  kourierGatewayContainer,
+		want:       manifestProbe,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := &appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: test.deployment},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:           test.container,
+								ReadinessProbe: manifestProbe.DeepCopy(),
+							}},
+						},
+					},
+				},
+			}
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(in, u, nil); err != nil {
+				t.Fatal("Failed to convert deployment to unstructured", err)
+			}
+
+			if err := kourierGatewayReadinessTransform()(u); err != nil {
+				t.Fatal("Unexpected error from transformer", err)
+			}
+
+			got := &appsv1.Deployment{}
+			if err := scheme.Scheme.Convert(u, got, nil); err != nil {
+				t.Fatal("Failed to convert unstructured to deployment", err)
+			}
+			if probe := got.Spec.Template.Spec.Containers[0].ReadinessProbe; !cmp.Equal(probe, test.want) {
+				t.Errorf("Got unexpected probe, diff: %s", cmp.Diff(probe, test.want))
+			}
+		})
+	}
+}
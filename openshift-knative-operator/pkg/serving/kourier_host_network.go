@@ -0,0 +1,201 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// KourierHostNetworkAnnotation is the annotation on the KnativeServing CR binding the Kourier
+// gateway to the network of the nodes it runs on, as JSON, for example:
+//
+//	serving.knative.openshift.io/kourierHostNetwork: |
+//	  {"hostPorts": {"http": 80, "https": 443}}
+//
+// This exposes Knative Services on bare metal clusters without a load balancer. Either the
+// gateway's ports are bound to the given ports of the nodes, or the gateway runs in the nodes'
+// network namespace altogether, listening on its container ports 8080, 8081 and 8443. Only one
+// gateway can run per node, so the gateway is rolled one replica at a time.
+const KourierHostNetworkAnnotation = "serving.knative.openshift.io/kourierHostNetwork"
+
+const (
+	// kourierHostNetworkServiceAccount runs the gateway when it's bound to the nodes' network.
+	kourierHostNetworkServiceAccount = "kourier-gateway-host-network"
+	// hostNetworkSCCRole allows using the "hostnetwork" SCC, which admits both host networking
+	// and host ports.
+	hostNetworkSCCRole = "system:openshift:scc:hostnetwork"
+
+	kourierHTTPPortName  = "http2-external"
+	kourierHTTPSPortName = "https-external"
+)
+
+// KourierHostNetwork bundles the settings binding the Kourier gateway to the nodes' network.
+type KourierHostNetwork struct {
+	// HostNetwork runs the gateway in the network namespace of the nodes.
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+	// HostPorts binds the external ports of the gateway to the given ports of the nodes.
+	HostPorts *KourierHostPorts `json:"hostPorts,omitempty"`
+}
+
+// KourierHostPorts are the ports of the nodes the external ports of the gateway are bound to.
+type KourierHostPorts struct {
+	// HTTP is the node port plain HTTP traffic is received on.
+	HTTP int32 `json:"http,omitempty"`
+	// HTTPS is the node port TLS traffic is received on.
+	HTTPS int32 `json:"https,omitempty"`
+}
+
+// KourierHostNetworkFromAnnotation parses the host network settings of the Kourier gateway of
+// the given KnativeServing. It returns nil if none are set.
+func KourierHostNetworkFromAnnotation(ks *v1alpha1.KnativeServing) (*KourierHostNetwork, error) {
+	raw, ok := ks.GetAnnotations()[KourierHostNetworkAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	h := &KourierHostNetwork{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(h); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", KourierHostNetworkAnnotation, err)
+	}
+	if err := h.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", KourierHostNetworkAnnotation, err)
+	}
+	// A load balancer is what's missing in the first place. The Service would never get an
+	// address, keeping the KnativeServing from becoming ready.
+	if ks.Spec.Ingress != nil && ks.Spec.Ingress.Kourier.ServiceType == corev1.ServiceTypeLoadBalancer {
+		return nil, fmt.Errorf("invalid %s: the Kourier service must not be of type %s", KourierHostNetworkAnnotation,
+			corev1.ServiceTypeLoadBalancer)
+	}
+	return h, nil
+}
+
+// Validate checks the settings for consistency.
+func (h *KourierHostNetwork) Validate() error {
+	if h.HostNetwork == (h.HostPorts != nil) {
+		return fmt.Errorf("exactly one of hostNetwork and hostPorts must be set")
+	}
+	if h.HostPorts == nil {
+		return nil
+	}
+	if h.HostPorts.HTTP == 0 && h.HostPorts.HTTPS == 0 {
+		return fmt.Errorf("hostPorts must set at least one of http and https")
+	}
+	for name, port := range map[string]int32{"http": h.HostPorts.HTTP, "https": h.HostPorts.HTTPS} {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("hostPorts.%s = %d, must be a port between 1 and 65535", name, port)
+		}
+	}
+	if h.HostPorts.HTTP == h.HostPorts.HTTPS {
+		return fmt.Errorf("hostPorts.http and hostPorts.https must differ")
+	}
+	return nil
+}
+
+// kourierHostNetworkTransform binds the Kourier gateway to the nodes' network. The gateway
+// runs as a dedicated ServiceAccount allowed to do so, see kourierHostNetworkManifest.
+func kourierHostNetworkTransform(h *KourierHostNetwork) mf.Transformer {
+	if h == nil {
+		return func(*unstructured.Unstructured) error { return nil }
+	}
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Deployment" || u.GetName() != kourierGatewayDeployment {
+			return nil
+		}
+		deployment := &appsv1.Deployment{}
+		if err := scheme.Scheme.Convert(u, deployment, nil); err != nil {
+			return err
+		}
+
+		podSpec := &deployment.Spec.Template.Spec
+		podSpec.ServiceAccountName = kourierHostNetworkServiceAccount
+		if h.HostNetwork {
+			podSpec.HostNetwork = true
+			podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+		}
+		if h.HostPorts != nil {
+			for i := range podSpec.Containers {
+				for j := range podSpec.Containers[i].Ports {
+					port := &podSpec.Containers[i].Ports[j]
+					switch {
+					case port.Name == kourierHTTPPortName && h.HostPorts.HTTP != 0:
+						port.HostPort = h.HostPorts.HTTP
+					case port.Name == kourierHTTPSPortName && h.HostPorts.HTTPS != 0:
+						port.HostPort = h.HostPorts.HTTPS
+					}
+				}
+			}
+		}
+
+		// A surging replica can't be scheduled if every node already runs a gateway.
+		maxSurge := intstr.FromInt(0)
+		maxUnavailable := intstr.FromInt(1)
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       &maxSurge,
+				MaxUnavailable: &maxUnavailable,
+			},
+		}
+		return scheme.Scheme.Convert(deployment, u, nil)
+	}
+}
+
+// kourierHostNetworkManifest creates the ServiceAccount the Kourier gateway runs as when bound
+// to the nodes' network, along with the permission to use the respective SCC.
+func kourierHostNetworkManifest(ks *v1alpha1.KnativeServing) (*mf.Manifest, error) {
+	namespace := kourierNamespace(ks.Namespace)
+	labels := map[string]string{providerLabel: "kourier"}
+
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kourierHostNetworkServiceAccount,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+	roleBinding := &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kourierHostNetworkServiceAccount,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     hostNetworkSCCRole,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      kourierHostNetworkServiceAccount,
+			Namespace: namespace,
+		}},
+	}
+
+	us := make([]unstructured.Unstructured, 0, 2)
+	for _, obj := range []interface{}{serviceAccount, roleBinding} {
+		u := unstructured.Unstructured{}
+		if err := scheme.Scheme.Convert(obj, &u, nil); err != nil {
+			return nil, err
+		}
+		us = append(us, u)
+	}
+	manifest, err := mf.ManifestFrom(mf.Slice(us))
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,187 @@
+package serving
+
+import (
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestKourierHostNetworkFromAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		serviceType corev1.ServiceType
+		wantErr     bool
+	}{{
+		name: "host network",
+		in:   `{"hostNetwork": true}`,
+	}, {
+		name: "host ports",
+		in:   `{"hostPorts": {"http": 80, "https": 443}}`,
+	}, {
+		name: "https only",
+		in:   `{"hostPorts": {"https": 443}}`,
+	}, {
+		name:        "node port service",
+		in:          `{"hostNetwork": true}`,
+		serviceType: corev1.ServiceTypeNodePort,
+	}, {
+		name:    "unknown field",
+		in:      `{"hostNetwork": true, "foo": "bar"}`,
+		wantErr: true,
+	}, {
+		name:    "nothing set",
+		in:      `{}`,
+		wantErr: true,
+	}, {
+		name:    "both set",
+		in:      `{"hostNetwork": true, "hostPorts": {"http": 80}}`,
+		wantErr: true,
+	}, {
+		name:    "no ports",
+		in:      `{"hostPorts": {}}`,
+		wantErr: true,
+	}, {
+		name:    "port out of range",
+		in:      `{"hostPorts": {"http": 80, "https": 70000}}`,
+		wantErr: true,
+	}, {
+		name:    "same ports",
+		in:      `{"hostPorts": {"http": 8000, "https": 8000}}`,
+		wantErr: true,
+	}, {
+		name:        "load balancer service",
+		in:          `{"hostNetwork": true}`,
+		serviceType: corev1.ServiceTypeLoadBalancer,
+		wantErr:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{KourierHostNetworkAnnotation: test.in},
+				},
+			}
+			if test.serviceType != "" {
+				ks.Spec.Ingress = &v1alpha1.IngressConfigs{
+					Kourier: v1alpha1.KourierIngressConfiguration{Enabled: true, ServiceType: test.serviceType},
+				}
+			}
+			_, err := KourierHostNetworkFromAnnotation(ks)
+			if (err != nil) != test.wantErr {
+				t.Errorf("KourierHostNetworkFromAnnotation() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestKourierHostNetworkTransform(t *testing.T) {
+	tests := []struct {
+		name            string
+		in              *KourierHostNetwork
+		deployment      string
+		wantHostNetwork bool
+		wantHostPorts   map[string]int32
+		wantSA          string
+	}{{
+		name:            "host network",
+		in:              &KourierHostNetwork{HostNetwork: true},
+		deployment:      kourierGatewayDeployment,
+		wantHostNetwork: true,
+		wantHostPorts:   map[string]int32{kourierHTTPPortName: 0, kourierHTTPSPortName: 0, "http2-internal": 0},
+		wantSA:          kourierHostNetworkServiceAccount,
+	}, {
+		name:          "host ports",
+		in:            &KourierHostNetwork{HostPorts: &KourierHostPorts{HTTP: 80, HTTPS: 443}},
+		deployment:    kourierGatewayDeployment,
+		wantHostPorts: map[string]int32{kourierHTTPPortName: 80, kourierHTTPSPortName: 443, "http2-internal": 0},
+		wantSA:        kourierHostNetworkServiceAccount,
+	}, {
+		name:          "other deployment",
+		in:            &KourierHostNetwork{HostNetwork: true},
+		deployment:    "net-kourier-controller",
+		wantHostPorts: map[string]int32{kourierHTTPPortName: 0, kourierHTTPSPortName: 0, "http2-internal": 0},
+	}, {
+		name:          "disabled",
+		deployment:    kourierGatewayDeployment,
+		wantHostPorts: map[string]int32{kourierHTTPPortName: 0, kourierHTTPSPortName: 0, "http2-internal": 0},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := deployment("knative-serving-ingress", test.deployment)
+			in.Spec.Template.Spec.Containers = []corev1.Container{{
+				Name: "kourier-gateway",
+				Ports: []corev1.ContainerPort{
+					{Name: kourierHTTPPortName, ContainerPort: 8080},
+					{Name: "http2-internal", ContainerPort: 8081},
+					{Name: kourierHTTPSPortName, ContainerPort: 8443},
+				},
+			}}
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(in, u, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			if err := kourierHostNetworkTransform(test.in)(u); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			got := &appsv1.Deployment{}
+			if err := scheme.Scheme.Convert(u, got, nil); err != nil {
+				t.Fatal("Failed to convert deployment:", err)
+			}
+			pod := got.Spec.Template.Spec
+			if pod.HostNetwork != test.wantHostNetwork {
+				t.Errorf("HostNetwork = %v, want %v", pod.HostNetwork, test.wantHostNetwork)
+			}
+			if pod.ServiceAccountName != test.wantSA {
+				t.Errorf("ServiceAccountName = %q, want %q", pod.ServiceAccountName, test.wantSA)
+			}
+			for _, port := range pod.Containers[0].Ports {
+				if port.HostPort != test.wantHostPorts[port.Name] {
+					t.Errorf("HostPort of %s = %d, want %d", port.Name, port.HostPort, test.wantHostPorts[port.Name])
+				}
+			}
+			if test.wantSA != "" && got.Spec.Strategy.RollingUpdate.MaxSurge.IntValue() != 0 {
+				t.Errorf("MaxSurge = %v, want 0", got.Spec.Strategy.RollingUpdate.MaxSurge)
+			}
+		})
+	}
+}
+
+func TestKourierHostNetworkManifest(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving"},
+	}
+
+	manifest, err := kourierHostNetworkManifest(ks)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := manifest.Filter(mf.ByKind("ServiceAccount")).Resources(); len(got) != 1 {
+		t.Fatalf("Got %d ServiceAccounts, want 1", len(got))
+	}
+	bindings := manifest.Filter(mf.ByKind("RoleBinding")).Resources()
+	if len(bindings) != 1 {
+		t.Fatalf("Got %d RoleBindings, want 1", len(bindings))
+	}
+
+	got := &rbacv1.RoleBinding{}
+	if err := scheme.Scheme.Convert(&bindings[0], got, nil); err != nil {
+		t.Fatal("Failed to convert rolebinding:", err)
+	}
+	if got.Namespace != "knative-serving-ingress" || got.RoleRef.Name != hostNetworkSCCRole {
+		t.Errorf("Got rolebinding in %q to %q, want knative-serving-ingress and %q", got.Namespace, got.RoleRef.Name, hostNetworkSCCRole)
+	}
+	if got.Labels[providerLabel] != "kourier" {
+		t.Errorf("Got labels %v, want the kourier provider label", got.Labels)
+	}
+}
@@ -0,0 +1,162 @@
+package serving
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// KourierGatewayImageAnnotation pins the Envoy image used by the Kourier gateway.
+	KourierGatewayImageAnnotation = "serving.knative.openshift.io/kourierGatewayImage"
+
+	// KourierBootstrapAnnotation carries a YAML snippet merged into Kourier's Envoy bootstrap.
+	// Additional listeners and clusters are appended to "static_resources" and top-level keys
+	// not yet present in the bootstrap (e.g. "stats_sinks") are added. Everything else is
+	// rejected to not break the gateway.
+	KourierBootstrapAnnotation = "serving.knative.openshift.io/kourierBootstrap"
+
+	kourierGatewayImageKey    = "kourier-gateway"
+	kourierGatewayDeployment  = "3scale-kourier-gateway"
+	kourierBootstrapConfigMap = "kourier-bootstrap"
+	kourierBootstrapKey       = "envoy-bootstrap.yaml"
+
+	// kourierBootstrapChecksumKey annotates the gateway's PodTemplate to roll it when the
+	// bootstrap overrides change, as Envoy only reads its bootstrap on startup.
+	kourierBootstrapChecksumKey = "serving.knative.openshift.io/kourier-bootstrap-checksum"
+)
+
+// ValidateKourierBootstrap checks that the bootstrap overrides of the given KnativeServing
+// can be merged safely.
+func ValidateKourierBootstrap(ks *v1alpha1.KnativeServing) error {
+	snippet, ok := ks.GetAnnotations()[KourierBootstrapAnnotation]
+	if !ok {
+		return nil
+	}
+	_, err := mergeBootstrap(map[string]interface{}{}, snippet)
+	return err
+}
+
+// overrideKourierBootstrap merges the given snippet into Kourier's bootstrap ConfigMap and
+// rolls the gateway if it changes.
+func overrideKourierBootstrap(snippet string) mf.Transformer {
+	if snippet == "" {
+		return func(*unstructured.Unstructured) error { return nil }
+	}
+	sum := sha256.Sum256([]byte(snippet))
+	checksum := hex.EncodeToString(sum[:])
+
+	return func(u *unstructured.Unstructured) error {
+		switch {
+		case u.GetKind() == "ConfigMap" && u.GetName() == kourierBootstrapConfigMap:
+			raw, _, err := unstructured.NestedString(u.Object, "data", kourierBootstrapKey)
+			if err != nil {
+				return err
+			}
+			base := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(raw), &base); err != nil {
+				return fmt.Errorf("failed to parse Kourier bootstrap: %w", err)
+			}
+			merged, err := mergeBootstrap(base, snippet)
+			if err != nil {
+				return err
+			}
+			out, err := yaml.Marshal(merged)
+			if err != nil {
+				return err
+			}
+			return unstructured.SetNestedField(u.Object, string(out), "data", kourierBootstrapKey)
+		case u.GetKind() == "Deployment" && u.GetName() == kourierGatewayDeployment:
+			annotations, _, err := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "annotations")
+			if err != nil {
+				return err
+			}
+			if annotations == nil {
+				annotations = make(map[string]string, 1)
+			}
+			annotations[kourierBootstrapChecksumKey] = checksum
+			return unstructured.SetNestedStringMap(u.Object, annotations, "spec", "template", "metadata", "annotations")
+		}
+		return nil
+	}
+}
+
+// mergeBootstrap merges the snippet into the given bootstrap, refusing to override anything
+// that's already present.
+func mergeBootstrap(base map[string]interface{}, snippet string) (map[string]interface{}, error) {
+	overrides := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(snippet), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", KourierBootstrapAnnotation, err)
+	}
+
+	for key, value := range overrides {
+		if key != "static_resources" {
+			if _, exists := base[key]; exists {
+				return nil, fmt.Errorf("overriding %q of the Kourier bootstrap is not allowed", key)
+			}
+			base[key] = value
+			continue
+		}
+
+		static, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%q must be an object", key)
+		}
+		baseStatic, _ := base[key].(map[string]interface{})
+		if baseStatic == nil {
+			baseStatic = make(map[string]interface{}, len(static))
+		}
+		for kind, entries := range static {
+			if kind != "listeners" && kind != "clusters" {
+				return nil, fmt.Errorf("only listeners and clusters can be added to %q, got %q", key, kind)
+			}
+			merged, err := appendNamed(baseStatic[kind], entries, kind)
+			if err != nil {
+				return nil, err
+			}
+			baseStatic[kind] = merged
+		}
+		base[key] = baseStatic
+	}
+	return base, nil
+}
+
+// appendNamed appends the additions to the existing list, requiring every addition to have
+// a name not yet taken.
+func appendNamed(existing, additions interface{}, kind string) ([]interface{}, error) {
+	list, _ := existing.([]interface{})
+	added, ok := additions.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list", kind)
+	}
+
+	names := make(map[string]bool, len(list)+len(added))
+	for _, entry := range list {
+		if m, ok := entry.(map[string]interface{}); ok {
+			if name, ok := m["name"].(string); ok {
+				names[name] = true
+			}
+		}
+	}
+	for _, entry := range added {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be objects", kind)
+		}
+		name, _ := m["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("%s must have a name", kind)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("%s %q already exists in the Kourier bootstrap", kind, name)
+		}
+		names[name] = true
+		list = append(list, entry)
+	}
+	return list, nil
+}
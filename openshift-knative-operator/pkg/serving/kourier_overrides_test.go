@@ -0,0 +1,113 @@
+package serving
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const testBootstrap = `
+node:
+  id: 3scale-kourier-gateway
+static_resources:
+  listeners:
+  - name: stats_listener
+  clusters:
+  - name: xds_cluster
+`
+
+func TestOverrideKourierBootstrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+		wantErr string
+	}{{
+		name: "additional listener, cluster and stats sink",
+		snippet: `
+static_resources:
+  listeners:
+  - name: extra_listener
+  clusters:
+  - name: extra_cluster
+stats_sinks:
+- name: envoy.stat_sinks.statsd
+`,
+		want: `
+node:
+  id: 3scale-kourier-gateway
+static_resources:
+  listeners:
+  - name: stats_listener
+  - name: extra_listener
+  clusters:
+  - name: xds_cluster
+  - name: extra_cluster
+stats_sinks:
+- name: envoy.stat_sinks.statsd
+`,
+	}, {
+		name:    "overriding existing keys",
+		snippet: `node: {id: foo}`,
+		wantErr: `overriding "node"`,
+	}, {
+		name:    "duplicate listener",
+		snippet: `static_resources: {listeners: [{name: stats_listener}]}`,
+		wantErr: `listeners "stats_listener" already exists`,
+	}, {
+		name:    "unnamed cluster",
+		snippet: `static_resources: {clusters: [{type: static}]}`,
+		wantErr: "clusters must have a name",
+	}, {
+		name:    "unsupported static resources",
+		snippet: `static_resources: {secrets: []}`,
+		wantErr: "only listeners and clusters",
+	}, {
+		name:    "malformed",
+		snippet: `static_resources: [`,
+		wantErr: "failed to parse",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cm := &unstructured.Unstructured{}
+			cm.SetKind("ConfigMap")
+			cm.SetName(kourierBootstrapConfigMap)
+			unstructured.SetNestedField(cm.Object, testBootstrap, "data", kourierBootstrapKey)
+
+			err := overrideKourierBootstrap(test.snippet)(cm)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got, _, _ := unstructured.NestedString(cm.Object, "data", kourierBootstrapKey)
+			want, _ := yaml.YAMLToJSON([]byte(test.want))
+			gotJSON, _ := yaml.YAMLToJSON([]byte(got))
+			if string(gotJSON) != string(want) {
+				t.Errorf("Got bootstrap %s, want %s", gotJSON, want)
+			}
+		})
+	}
+}
+
+func TestOverrideKourierBootstrapRollsGateway(t *testing.T) {
+	deployment := &unstructured.Unstructured{}
+	deployment.SetKind("Deployment")
+	deployment.SetName(kourierGatewayDeployment)
+
+	if err := overrideKourierBootstrap(`stats_sinks: []`)(deployment); err != nil {
+		t.Fatal(err)
+	}
+	checksum, _, _ := unstructured.NestedString(deployment.Object, "spec", "template", "metadata", "annotations", kourierBootstrapChecksumKey)
+	if checksum == "" {
+		t.Error("Gateway has not been annotated with the bootstrap checksum")
+	}
+}
@@ -0,0 +1,80 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// kourierGatewayService is the Service external traffic reaches the Kourier gateway through.
+	kourierGatewayService = "kourier"
+
+	// IngressNotReadyReason is the reason of the KnativeServing not being ready while the
+	// Kourier gateway cannot be reached yet.
+	IngressNotReadyReason = "IngressNotReady"
+)
+
+// servingCondSet mirrors the condition set of KnativeServing, which is not exported but
+// needed to mark conditions with our own reasons.
+var servingCondSet = apis.NewLivingConditionSet(
+	v1alpha1.DependenciesInstalled,
+	v1alpha1.DeploymentsAvailable,
+	v1alpha1.InstallSucceeded,
+	v1alpha1.VersionMigrationEligible,
+)
+
+// checkKourierReadiness keeps the KnativeServing from becoming ready until the Kourier
+// gateway can actually receive external traffic, i.e. its Service has ready endpoints and,
+// if of type LoadBalancer, an ingress address.
+//
+// The check only applies to installations that succeeded before, as there's nothing to wait
+// for before Kourier has been installed.
+func checkKourierReadiness(ctx context.Context, kube kubernetes.Interface, ks *v1alpha1.KnativeServing) error {
+	if ks.Spec.Ingress == nil || !ks.Spec.Ingress.Kourier.Enabled ||
+		!ks.Status.GetCondition(v1alpha1.InstallSucceeded).IsTrue() {
+		return nil
+	}
+
+	msg, err := kourierNotReadyMessage(ctx, kube, kourierNamespace(ks.Namespace))
+	if err != nil {
+		return err
+	}
+	if msg != "" {
+		servingCondSet.Manage(&ks.Status).MarkFalse(v1alpha1.DependenciesInstalled, IngressNotReadyReason, msg)
+	}
+	return nil
+}
+
+// kourierNotReadyMessage returns why the Kourier gateway in the given namespace cannot be
+// reached, or an empty string if it can.
+func kourierNotReadyMessage(ctx context.Context, kube kubernetes.Interface, namespace string) (string, error) {
+	svc, err := kube.CoreV1().Services(namespace).Get(ctx, kourierGatewayService, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "Waiting for the Kourier gateway service to be created", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get the Kourier gateway service: %w", err)
+	}
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return "Waiting for the load balancer of the Kourier gateway to be provisioned", nil
+	}
+
+	endpoints, err := kube.CoreV1().Endpoints(namespace).Get(ctx, kourierGatewayService, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "Waiting for the Kourier gateway to have ready endpoints", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get the Kourier gateway endpoints: %w", err)
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return "", nil
+		}
+	}
+	return "Waiting for the Kourier gateway to have ready endpoints", nil
+}
@@ -0,0 +1,111 @@
+package serving
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestCheckKourierReadiness(t *testing.T) {
+	const ns = "knative-serving-ingress"
+	service := func(svcType corev1.ServiceType, lbIngress ...corev1.LoadBalancerIngress) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: kourierGatewayService, Namespace: ns},
+			Spec:       corev1.ServiceSpec{Type: svcType},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: lbIngress},
+			},
+		}
+	}
+	endpoints := func(addresses ...corev1.EndpointAddress) *corev1.Endpoints {
+		return &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: kourierGatewayService, Namespace: ns},
+			Subsets:    []corev1.EndpointSubset{{Addresses: addresses}},
+		}
+	}
+	ready := corev1.EndpointAddress{IP: "10.0.0.1"}
+
+	tests := []struct {
+		name      string
+		installed bool
+		istio     bool
+		objs      []runtime.Object
+		wantReady bool
+	}{{
+		name:      "not installed yet",
+		wantReady: true,
+	}, {
+		name:      "istio",
+		installed: true,
+		istio:     true,
+		wantReady: true,
+	}, {
+		name:      "service missing",
+		installed: true,
+	}, {
+		name:      "no endpoints",
+		installed: true,
+		objs:      []runtime.Object{service(corev1.ServiceTypeClusterIP)},
+	}, {
+		name:      "no ready endpoints",
+		installed: true,
+		objs:      []runtime.Object{service(corev1.ServiceTypeClusterIP), endpoints()},
+	}, {
+		name:      "ready",
+		installed: true,
+		objs:      []runtime.Object{service(corev1.ServiceTypeClusterIP), endpoints(ready)},
+		wantReady: true,
+	}, {
+		name:      "load balancer pending",
+		installed: true,
+		objs:      []runtime.Object{service(corev1.ServiceTypeLoadBalancer), endpoints(ready)},
+	}, {
+		name:      "load balancer ready",
+		installed: true,
+		objs: []runtime.Object{
+			service(corev1.ServiceTypeLoadBalancer, corev1.LoadBalancerIngress{IP: "1.2.3.4"}),
+			endpoints(ready),
+		},
+		wantReady: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+				Spec: v1alpha1.KnativeServingSpec{
+					Ingress: &v1alpha1.IngressConfigs{
+						Kourier: v1alpha1.KourierIngressConfiguration{Enabled: !test.istio},
+						Istio:   v1alpha1.IstioIngressConfiguration{Enabled: test.istio},
+					},
+				},
+			}
+			ks.Status.InitializeConditions()
+			if test.installed {
+				ks.Status.MarkInstallSucceeded()
+				ks.Status.MarkDeploymentsAvailable()
+				ks.Status.MarkVersionMigrationEligible()
+			}
+
+			if err := checkKourierReadiness(context.Background(), fake.NewSimpleClientset(test.objs...), ks); err != nil {
+				t.Fatal(err)
+			}
+
+			cond := ks.Status.GetCondition(v1alpha1.DependenciesInstalled)
+			gotReady := cond.Reason != IngressNotReadyReason
+			if gotReady != test.wantReady {
+				t.Errorf("Ready = %v, want %v, condition: %+v", gotReady, test.wantReady, cond)
+			}
+			if !test.wantReady {
+				if ready := ks.Status.GetCondition("Ready"); ready.IsTrue() || ready.Reason != IngressNotReadyReason {
+					t.Errorf("Ready condition = %+v, want false with reason %s", ready, IngressNotReadyReason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package serving
+
+import (
+	"context"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// PodSecurityAnnotation overrides the pod security admission levels of the namespaces of
+// Knative Serving and its ingress if set on the KnativeServing CR. See common.PodSecurity for
+// its format.
+const PodSecurityAnnotation = "serving.knative.openshift.io/pod-security"
+
+// PodSecurityFromAnnotation parses the pod security levels of the given KnativeServing.
+func PodSecurityFromAnnotation(ks *v1alpha1.KnativeServing) (common.PodSecurity, error) {
+	return common.PodSecurityFromAnnotation(ks, PodSecurityAnnotation)
+}
+
+// reconcilePodSecurity labels the namespaces of Knative Serving and Kourier with the given pod
+// security levels. A Kourier gateway bound to the nodes' network is only admitted by the
+// privileged level, which is applied to its namespace then.
+func reconcilePodSecurity(ctx context.Context, api kubernetes.Interface, ks *v1alpha1.KnativeServing, p common.PodSecurity) error {
+	if err := common.ReconcilePodSecurityLabels(ctx, api, ks.Namespace, p); err != nil {
+		return err
+	}
+	if hostNetwork, _ := KourierHostNetworkFromAnnotation(ks); hostNetwork != nil {
+		p = common.PodSecurity{Enforce: common.PodSecurityPrivileged, Warn: common.PodSecurityPrivileged}
+	}
+	return common.ReconcilePodSecurityLabels(ctx, api, kourierNamespace(ks.Namespace), p)
+}
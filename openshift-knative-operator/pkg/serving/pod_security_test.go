@@ -0,0 +1,65 @@
+package serving
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestReconcilePodSecurity(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantIngress string
+	}{{
+		name:        "default",
+		wantIngress: common.PodSecurityBaseline,
+	}, {
+		name:        "override",
+		annotations: map[string]string{PodSecurityAnnotation: `{"enforce": "restricted"}`},
+		wantIngress: common.PodSecurityRestricted,
+	}, {
+		name: "kourier on the host network",
+		annotations: map[string]string{
+			PodSecurityAnnotation:        `{"enforce": "restricted"}`,
+			KourierHostNetworkAnnotation: `{"hostNetwork": true}`,
+		},
+		wantIngress: common.PodSecurityPrivileged,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{ObjectMeta: metav1.ObjectMeta{
+				Name:        "knative-serving",
+				Namespace:   "knative-serving",
+				Annotations: test.annotations,
+			}}
+			api := fake.NewSimpleClientset(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "knative-serving"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "knative-serving-ingress"}},
+			)
+
+			p, err := PodSecurityFromAnnotation(ks)
+			if err != nil {
+				t.Fatalf("PodSecurityFromAnnotation() = %v", err)
+			}
+			if err := reconcilePodSecurity(context.Background(), api, ks, p); err != nil {
+				t.Fatalf("reconcilePodSecurity() = %v", err)
+			}
+
+			serving, _ := api.CoreV1().Namespaces().Get(context.Background(), "knative-serving", metav1.GetOptions{})
+			if got := serving.Labels[common.PodSecurityEnforceLabel]; got != p.Enforce {
+				t.Errorf("Enforced level of Serving = %q, want %q", got, p.Enforce)
+			}
+			ingress, _ := api.CoreV1().Namespaces().Get(context.Background(), "knative-serving-ingress", metav1.GetOptions{})
+			if got := ingress.Labels[common.PodSecurityEnforceLabel]; got != test.wantIngress {
+				t.Errorf("Enforced level of the ingress = %q, want %q", got, test.wantIngress)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package serving
+
+import "github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+
+// alphaFeature is the reason of features upstream only ships as alpha.
+const alphaFeature = "alpha features of Knative Serving are not supported"
+
+// supportedConfigPolicy lists the configurations of Knative Serving that are not supported.
+var supportedConfigPolicy = common.ConfigPolicy{{
+	ConfigMap: "features",
+	Key:       "kubernetes.podspec-dryrun",
+	Values:    []string{"enabled", "allowed"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "kubernetes.podspec-runtimeclassname",
+	Values:    []string{"enabled", "allowed"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "tag-header-based-routing",
+	Values:    []string{"enabled", "allowed"},
+	Reason:    alphaFeature,
+}, {
+	ConfigMap: "features",
+	Key:       "autodetect-http2",
+	Values:    []string{"enabled", "allowed"},
+	Reason:    alphaFeature,
+}}
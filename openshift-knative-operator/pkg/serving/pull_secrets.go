@@ -0,0 +1,79 @@
+package serving
+
+import (
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// PullSecretNamespacesAnnotation lists the namespaces, separated by commas, the secrets of
+// spec.registry.imagePullSecrets are propagated to. They're copied into these namespaces and
+// added to their "default" ServiceAccount, so that revisions running as it can be deployed
+// from the private registry, including the resolution of their image tags.
+const PullSecretNamespacesAnnotation = "serving.knative.openshift.io/imagePullSecretNamespaces"
+
+// PullSecretNamespaces returns the sorted namespaces the image pull secrets of the given
+// KnativeServing are propagated to.
+func PullSecretNamespaces(ks *v1alpha1.KnativeServing) ([]string, error) {
+	raw, ok := ks.GetAnnotations()[PullSecretNamespacesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	namespaces := sets.NewString()
+	for _, ns := range strings.Split(raw, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s: namespace %q: %s", PullSecretNamespacesAnnotation, ns, strings.Join(errs, ", "))
+		}
+		if ns == ks.Namespace {
+			return nil, fmt.Errorf("invalid %s: namespace %q already holds the secrets", PullSecretNamespacesAnnotation, ns)
+		}
+		namespaces.Insert(ns)
+	}
+	return namespaces.List(), nil
+}
+
+// serviceAccountPullSecrets adds the given secrets, usually spec.registry.imagePullSecrets, to
+// the ServiceAccounts in the given namespace, e.g. the "controller" ServiceAccount of the
+// controller and activator. Upstream only adds them to the PodSpecs of the manifest, which
+// doesn't cover other pods running as these ServiceAccounts.
+func serviceAccountPullSecrets(namespace string, secrets []corev1.LocalObjectReference) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if len(secrets) == 0 || u.GetKind() != "ServiceAccount" || u.GetNamespace() != namespace {
+			return nil
+		}
+		sa := &corev1.ServiceAccount{}
+		if err := scheme.Scheme.Convert(u, sa, nil); err != nil {
+			return err
+		}
+		sa.ImagePullSecrets = appendPullSecrets(sa.ImagePullSecrets, secrets...)
+		return scheme.Scheme.Convert(sa, u, nil)
+	}
+}
+
+// appendPullSecrets appends the given secrets to the existing ones, skipping duplicates.
+func appendPullSecrets(existing []corev1.LocalObjectReference, secrets ...corev1.LocalObjectReference) []corev1.LocalObjectReference {
+	for _, secret := range secrets {
+		found := false
+		for _, e := range existing {
+			if e.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, secret)
+		}
+	}
+	return existing
+}
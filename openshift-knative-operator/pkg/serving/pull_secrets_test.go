@@ -0,0 +1,79 @@
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestServiceAccountPullSecrets(t *testing.T) {
+	secrets := []corev1.LocalObjectReference{{Name: "registry"}, {Name: "mirror"}}
+
+	tests := []struct {
+		name string
+		in   *corev1.ServiceAccount
+		want []corev1.LocalObjectReference
+	}{{
+		name: "add secrets",
+		in:   serviceAccount("knative-serving", "controller"),
+		want: secrets,
+	}, {
+		name: "keep existing secrets",
+		in: serviceAccount("knative-serving", "controller", corev1.LocalObjectReference{Name: "foo"},
+			corev1.LocalObjectReference{Name: "mirror"}),
+		want: []corev1.LocalObjectReference{{Name: "foo"}, {Name: "mirror"}, {Name: "registry"}},
+	}, {
+		name: "other namespace",
+		in:   serviceAccount("knative-serving-ingress", "3scale-kourier"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := scheme.Scheme.Convert(test.in, u, nil); err != nil {
+				t.Fatal("Failed to convert ServiceAccount:", err)
+			}
+			if err := serviceAccountPullSecrets("knative-serving", secrets)(u); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			got := &corev1.ServiceAccount{}
+			if err := scheme.Scheme.Convert(u, got, nil); err != nil {
+				t.Fatal("Failed to convert ServiceAccount:", err)
+			}
+			if !cmp.Equal(got.ImagePullSecrets, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got.ImagePullSecrets, test.want, cmp.Diff(got.ImagePullSecrets, test.want))
+			}
+		})
+	}
+}
+
+func TestPullSecretNamespaces(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "knative-serving",
+			Namespace:   "knative-serving",
+			Annotations: map[string]string{PullSecretNamespacesAnnotation: " team-b, team-a,,team-b "},
+		},
+	}
+	got, err := PullSecretNamespaces(ks)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if want := []string{"team-a", "team-b"}; !cmp.Equal(got, want) {
+		t.Errorf("Got = %v, want: %v", got, want)
+	}
+}
+
+func serviceAccount(ns, name string, secrets ...corev1.LocalObjectReference) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:         metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta:       metav1.ObjectMeta{Namespace: ns, Name: name},
+		ImagePullSecrets: secrets,
+	}
+}
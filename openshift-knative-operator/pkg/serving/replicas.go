@@ -0,0 +1,99 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+)
+
+// FeasibleReplicas is false with a warning if more replicas are requested through
+// spec.high-availability or spec.deployments than there are nodes they can be scheduled on.
+// The replicas then share nodes, so a node failure takes down several of them, and they stay
+// Pending if their anti-affinity is made required. It doesn't affect the readiness of Knative
+// Serving.
+const FeasibleReplicas apis.ConditionType = "FeasibleReplicas"
+
+// schedulable returns whether pods of Knative Serving can be scheduled on the given node,
+// considering the architectures they're pinned to, if any.
+func schedulable(node *corev1.Node, archs sets.String) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	if archs.Len() > 0 && !archs.Has(node.Labels[corev1.LabelArchStable]) {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		// The pods of Knative Serving don't tolerate any taints.
+		if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+			return false
+		}
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// replicasWarnings returns a message for the replicas of spec.high-availability and each
+// deployment override exceeding the given schedulable nodes.
+func replicasWarnings(ks *v1alpha1.KnativeServing, nodes []corev1.Node) []string {
+	var warnings []string
+	if ha := ks.Spec.HighAvailability; ha != nil && ha.Replicas > 1 && int(ha.Replicas) > len(nodes) {
+		warnings = append(warnings, fmt.Sprintf("spec.high-availability.replicas %d exceeds the %d schedulable nodes",
+			ha.Replicas, len(nodes)))
+	}
+	for _, override := range ks.Spec.DeploymentOverride {
+		if override.Replicas <= 1 {
+			continue
+		}
+		selector := labels.SelectorFromSet(override.NodeSelector)
+		matching := 0
+		for i := range nodes {
+			if selector.Matches(labels.Set(nodes[i].Labels)) {
+				matching++
+			}
+		}
+		if int(override.Replicas) > matching {
+			warnings = append(warnings, fmt.Sprintf("replicas %d of deployment %s exceed the %d schedulable nodes",
+				override.Replicas, override.Name, matching))
+		}
+	}
+	return warnings
+}
+
+// reconcileReplicas warns about replicas of the given KnativeServing that exceed the nodes they
+// can be scheduled on.
+func reconcileReplicas(ctx context.Context, kube kubernetes.Interface, ks *v1alpha1.KnativeServing) error {
+	list, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(list.Items) == 0 {
+		// Nodes might not have joined yet, e.g. on clusters with a hosted control plane.
+		common.MarkWarnings(&ks.Status, FeasibleReplicas, "InfeasibleReplicas", nil)
+		return nil
+	}
+	archs := sets.NewString()
+	if pinned := ks.Status.Annotations[ArchitecturesStatusKey]; pinned != "" {
+		archs.Insert(strings.Split(pinned, ",")...)
+	}
+	nodes := make([]corev1.Node, 0, len(list.Items))
+	for i := range list.Items {
+		if schedulable(&list.Items[i], archs) {
+			nodes = append(nodes, list.Items[i])
+		}
+	}
+	common.MarkWarnings(&ks.Status, FeasibleReplicas, "InfeasibleReplicas", replicasWarnings(ks, nodes))
+	return nil
+}
@@ -0,0 +1,106 @@
+package serving
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestReconcileReplicas(t *testing.T) {
+	node := func(name, arch string, opts ...func(*corev1.Node)) runtime.Object {
+		n := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{corev1.LabelArchStable: arch},
+			},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+				Type:   corev1.NodeReady,
+				Status: corev1.ConditionTrue,
+			}}},
+		}
+		for _, opt := range opts {
+			opt(n)
+		}
+		return n
+	}
+	master := func(n *corev1.Node) {
+		n.Spec.Taints = []corev1.Taint{{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}}
+	}
+	cordoned := func(n *corev1.Node) { n.Spec.Unschedulable = true }
+	notReady := func(n *corev1.Node) { n.Status.Conditions[0].Status = corev1.ConditionFalse }
+	infra := func(n *corev1.Node) { n.Labels["node-role.kubernetes.io/infra"] = "" }
+
+	tests := []struct {
+		name        string
+		nodes       []runtime.Object
+		replicas    int32
+		overrides   []v1alpha1.DeploymentOverride
+		pinned      string
+		wantWarning bool
+	}{{
+		name:     "enough nodes",
+		nodes:    []runtime.Object{node("a", "amd64"), node("b", "amd64")},
+		replicas: 2,
+	}, {
+		name:        "too few nodes",
+		nodes:       []runtime.Object{node("a", "amd64"), node("b", "amd64"), node("c", "amd64")},
+		replicas:    5,
+		wantWarning: true,
+	}, {
+		name: "unschedulable nodes",
+		nodes: []runtime.Object{node("a", "amd64"), node("b", "amd64", master),
+			node("c", "amd64", cordoned), node("d", "amd64", notReady)},
+		replicas:    2,
+		wantWarning: true,
+	}, {
+		name:     "no nodes",
+		replicas: 2,
+	}, {
+		name:     "single replica",
+		nodes:    []runtime.Object{node("a", "amd64", cordoned)},
+		replicas: 1,
+	}, {
+		name:        "pinned architectures",
+		nodes:       []runtime.Object{node("a", "amd64"), node("b", "arm64")},
+		replicas:    2,
+		pinned:      "amd64",
+		wantWarning: true,
+	}, {
+		name:      "override on enough nodes",
+		nodes:     []runtime.Object{node("a", "amd64", infra), node("b", "amd64", infra)},
+		replicas:  2,
+		overrides: []v1alpha1.DeploymentOverride{{Name: "activator", Replicas: 2, NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""}}},
+	}, {
+		name:        "override on too few selected nodes",
+		nodes:       []runtime.Object{node("a", "amd64", infra), node("b", "amd64")},
+		replicas:    2,
+		overrides:   []v1alpha1.DeploymentOverride{{Name: "activator", Replicas: 2, NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""}}},
+		wantWarning: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{}
+			ks.Spec.HighAvailability = &v1alpha1.HighAvailability{Replicas: test.replicas}
+			ks.Spec.DeploymentOverride = test.overrides
+			if test.pinned != "" {
+				ks.Status.Annotations = map[string]string{ArchitecturesStatusKey: test.pinned}
+			}
+
+			kube := fake.NewSimpleClientset(test.nodes...)
+			if err := reconcileReplicas(context.Background(), kube, ks); err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+
+			cond := ks.Status.GetCondition(FeasibleReplicas)
+			if got := cond != nil && cond.IsFalse(); got != test.wantWarning {
+				t.Errorf("Warning = %v, want %v, condition: %v", got, test.wantWarning, cond)
+			}
+		})
+	}
+}
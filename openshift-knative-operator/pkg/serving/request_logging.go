@@ -0,0 +1,87 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/monitoring"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// RequestLoggingAnnotation is the annotation on the KnativeServing CR configuring the request
+// logs of the queue-proxies of all revisions as JSON, for example:
+//
+//	serving.knative.openshift.io/requestLogging: |
+//	  {"enabled": true, "probes": false}
+//
+// The settings take precedence over the respective keys in spec.config.
+const RequestLoggingAnnotation = "serving.knative.openshift.io/requestLogging"
+
+// requestLogTemplate renders every request as a single line of JSON on stdout, which the
+// cluster logging stack collects along with the other container logs and can parse into
+// structured fields. The fields follow the httpRequest of the Google Cloud logging format
+// used by the upstream examples, plus the revision the request has been served by. Values
+// sent by clients are quoted with %q rather than escaped with js, whose \' isn't valid JSON.
+const requestLogTemplate = `{"httpRequest": {` +
+	`"requestMethod": "{{.Request.Method}}", ` +
+	`"requestUrl": {{printf "%q" .Request.RequestURI}}, ` +
+	`"requestSize": "{{.Request.ContentLength}}", ` +
+	`"status": {{.Response.Code}}, ` +
+	`"responseSize": "{{.Response.Size}}", ` +
+	`"userAgent": {{printf "%q" .Request.UserAgent}}, ` +
+	`"remoteIp": {{printf "%q" .Request.RemoteAddr}}, ` +
+	`"serverIp": "{{.Revision.PodIP}}", ` +
+	`"referer": {{printf "%q" .Request.Referer}}, ` +
+	`"latency": "{{.Response.Latency}}s", ` +
+	`"protocol": "{{.Request.Proto}}"}, ` +
+	`"knative": {` +
+	`"namespace": "{{.Revision.Namespace}}", ` +
+	`"service": "{{.Revision.Service}}", ` +
+	`"configuration": "{{.Revision.Configuration}}", ` +
+	`"revision": "{{.Revision.Name}}", ` +
+	`"pod": "{{.Revision.PodName}}"}, ` +
+	`"traceId": "{{.Request.Header.Get "X-B3-Traceid"}}"}`
+
+// RequestLogging bundles the settings of the request logs of the queue-proxies.
+type RequestLogging struct {
+	// Enabled logs every request served by a revision as JSON on stdout, or disables the
+	// request logs if false.
+	// Maps to "logging.request-log-template" in config-observability.
+	Enabled bool `json:"enabled"`
+	// Probes logs the probe requests as well.
+	// Maps to "logging.enable-probe-request-log" in config-observability.
+	Probes bool `json:"probes,omitempty"`
+}
+
+// RequestLoggingFromAnnotation parses the request logging settings of the given
+// KnativeServing. It returns nil if none are set.
+func RequestLoggingFromAnnotation(ks *v1alpha1.KnativeServing) (*RequestLogging, error) {
+	raw, ok := ks.GetAnnotations()[RequestLoggingAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	rl := &RequestLogging{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(rl); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RequestLoggingAnnotation, err)
+	}
+	if rl.Probes && !rl.Enabled {
+		return nil, fmt.Errorf("invalid %s: probes requires the request logs to be enabled", RequestLoggingAnnotation)
+	}
+	return rl, nil
+}
+
+// apply renders the settings into config-observability of the given spec.
+func (rl *RequestLogging) apply(spec *v1alpha1.CommonSpec) {
+	template := ""
+	if rl.Enabled {
+		template = requestLogTemplate
+	}
+	common.Configure(spec, monitoring.ObservabilityCMName, "logging.request-log-template", template)
+	common.Configure(spec, monitoring.ObservabilityCMName, "logging.enable-probe-request-log", strconv.FormatBool(rl.Probes))
+}
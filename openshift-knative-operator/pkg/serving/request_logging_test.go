@@ -0,0 +1,118 @@
+package serving
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"text/template"
+
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestRequestLogging(t *testing.T) {
+	tests := []struct {
+		name         string
+		annotation   string
+		wantTemplate bool
+		wantProbes   string
+		wantErr      bool
+	}{{
+		name:         "enabled",
+		annotation:   `{"enabled": true}`,
+		wantTemplate: true,
+		wantProbes:   "false",
+	}, {
+		name:         "with probes",
+		annotation:   `{"enabled": true, "probes": true}`,
+		wantTemplate: true,
+		wantProbes:   "true",
+	}, {
+		name:       "disabled",
+		annotation: `{"enabled": false}`,
+		wantProbes: "false",
+	}, {
+		name:       "probes only",
+		annotation: `{"probes": true}`,
+		wantErr:    true,
+	}, {
+		name:       "unknown field",
+		annotation: `{"enabled": true, "format": "json"}`,
+		wantErr:    true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{}
+			ks.Annotations = map[string]string{RequestLoggingAnnotation: test.annotation}
+			// The annotation overrides manual settings.
+			ks.Spec.Config = v1alpha1.ConfigMapData{"observability": {"logging.request-log-template": "{{.Request.Method}}"}}
+
+			rl, err := RequestLoggingFromAnnotation(ks)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RequestLoggingFromAnnotation() = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			rl.apply(&ks.Spec.CommonSpec)
+
+			config := ks.Spec.Config["observability"]
+			if got := config["logging.request-log-template"] == requestLogTemplate; got != test.wantTemplate {
+				t.Errorf("Template = %q, want the JSON template: %v", config["logging.request-log-template"], test.wantTemplate)
+			}
+			if got := config["logging.enable-probe-request-log"]; got != test.wantProbes {
+				t.Errorf("Probe logs = %q, want %q", got, test.wantProbes)
+			}
+		})
+	}
+}
+
+func TestRequestLogTemplate(t *testing.T) {
+	tmpl, err := template.New("requestLog").Parse(requestLogTemplate)
+	if err != nil {
+		t.Fatal("Failed to parse the template:", err)
+	}
+
+	// A subset of the input of the queue-proxy's request logs.
+	type request struct {
+		Method, RequestURI, UserAgent, RemoteAddr, Referer, Proto string
+		ContentLength                                             int64
+		Header                                                    http.Header
+	}
+	input := map[string]interface{}{
+		"Request": request{
+			Method:     "GET",
+			RequestURI: "/path?query='quoted'&other=\"quoted\"",
+			UserAgent:  "curl",
+			Proto:      "HTTP/1.1",
+			Header:     http.Header{"X-B3-Traceid": []string{"abc"}},
+		},
+		"Response": map[string]interface{}{"Code": 200, "Size": 42, "Latency": 0.5},
+		"Revision": map[string]interface{}{
+			"Name": "hello-00001", "Namespace": "default", "Service": "hello",
+			"Configuration": "hello", "PodName": "hello-00001-deployment-abc", "PodIP": "10.0.0.1",
+		},
+	}
+	out := &strings.Builder{}
+	if err := tmpl.Execute(out, input); err != nil {
+		t.Fatal("Failed to execute the template:", err)
+	}
+
+	var got struct {
+		HTTPRequest struct {
+			RequestURL string `json:"requestUrl"`
+			Status     int    `json:"status"`
+		} `json:"httpRequest"`
+		TraceID string `json:"traceId"`
+		Knative struct {
+			Revision string `json:"revision"`
+		} `json:"knative"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatalf("Request log %s is not JSON: %v", out, err)
+	}
+	if got.HTTPRequest.RequestURL != "/path?query='quoted'&other=\"quoted\"" || got.HTTPRequest.Status != 200 || got.Knative.Revision != "hello-00001" || got.TraceID != "abc" {
+		t.Errorf("Unexpected request log %s", out)
+	}
+}
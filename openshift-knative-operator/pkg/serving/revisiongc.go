@@ -0,0 +1,103 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// RevisionGCAnnotation is the annotation on the KnativeServing CR carrying the cluster-wide
+// garbage collection policy of revisions as JSON, for example:
+//
+//	serving.knative.openshift.io/revisionGC: |
+//	  {"retainSinceCreateTime": "24h", "minNonActiveRevisions": 5, "maxNonActiveRevisions": 100}
+//
+// The settings take precedence over the respective keys in spec.config.
+const RevisionGCAnnotation = "serving.knative.openshift.io/revisionGC"
+
+// revisionGCDisabled is the value disabling a setting of config-gc.
+const revisionGCDisabled = "disabled"
+
+// RevisionGC bundles the settings of the garbage collection of non-active revisions.
+type RevisionGC struct {
+	// RetainSinceCreateTime is the minimum time since its creation a non-active revision is
+	// retained for, or "disabled" to not retain revisions based on their age.
+	// Maps to "retain-since-create-time" in config-gc.
+	RetainSinceCreateTime string `json:"retainSinceCreateTime,omitempty"`
+	// MinNonActiveRevisions is the number of non-active revisions always retained.
+	// Maps to "min-non-active-revisions" in config-gc.
+	MinNonActiveRevisions *int64 `json:"minNonActiveRevisions,omitempty"`
+	// MaxNonActiveRevisions is the number of non-active revisions retained at most, or -1 to
+	// not limit the number of revisions.
+	// Maps to "max-non-active-revisions" in config-gc.
+	MaxNonActiveRevisions *int64 `json:"maxNonActiveRevisions,omitempty"`
+}
+
+// RevisionGCFromAnnotation parses the revision garbage collection policy of the given
+// KnativeServing. It returns nil if no policy is set.
+func RevisionGCFromAnnotation(ks *v1alpha1.KnativeServing) (*RevisionGC, error) {
+	raw, ok := ks.GetAnnotations()[RevisionGCAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	gc := &RevisionGC{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(gc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RevisionGCAnnotation, err)
+	}
+	if err := gc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", RevisionGCAnnotation, err)
+	}
+	return gc, nil
+}
+
+// Validate checks the policy for consistency.
+func (gc *RevisionGC) Validate() error {
+	if gc.RetainSinceCreateTime != "" && !strings.EqualFold(gc.RetainSinceCreateTime, revisionGCDisabled) {
+		d, err := time.ParseDuration(gc.RetainSinceCreateTime)
+		if err != nil {
+			return fmt.Errorf("retainSinceCreateTime = %q, must be a duration or %q: %w", gc.RetainSinceCreateTime, revisionGCDisabled, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("retainSinceCreateTime = %q, must not be negative", gc.RetainSinceCreateTime)
+		}
+	}
+	if gc.MinNonActiveRevisions != nil && *gc.MinNonActiveRevisions < 0 {
+		return fmt.Errorf("minNonActiveRevisions = %d, must be at least 0", *gc.MinNonActiveRevisions)
+	}
+	if gc.MaxNonActiveRevisions != nil {
+		if *gc.MaxNonActiveRevisions < -1 {
+			return fmt.Errorf("maxNonActiveRevisions = %d, must be at least 0 or -1 to disable it", *gc.MaxNonActiveRevisions)
+		}
+		if *gc.MaxNonActiveRevisions >= 0 && gc.MinNonActiveRevisions != nil && *gc.MinNonActiveRevisions > *gc.MaxNonActiveRevisions {
+			return fmt.Errorf("minNonActiveRevisions = %d, must not exceed maxNonActiveRevisions = %d",
+				*gc.MinNonActiveRevisions, *gc.MaxNonActiveRevisions)
+		}
+	}
+	return nil
+}
+
+// apply renders the policy into config-gc of the given spec.
+func (gc *RevisionGC) apply(spec *v1alpha1.CommonSpec) {
+	if gc.RetainSinceCreateTime != "" {
+		common.Configure(spec, "gc", "retain-since-create-time", gc.RetainSinceCreateTime)
+	}
+	if gc.MinNonActiveRevisions != nil {
+		common.Configure(spec, "gc", "min-non-active-revisions", strconv.FormatInt(*gc.MinNonActiveRevisions, 10))
+	}
+	if gc.MaxNonActiveRevisions != nil {
+		max := strconv.FormatInt(*gc.MaxNonActiveRevisions, 10)
+		if *gc.MaxNonActiveRevisions < 0 {
+			max = revisionGCDisabled
+		}
+		common.Configure(spec, "gc", "max-non-active-revisions", max)
+	}
+}
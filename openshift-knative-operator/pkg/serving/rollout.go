@@ -0,0 +1,87 @@
+package serving
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+const (
+	// RolloutDurationAnnotation sets the cluster-wide default duration over which traffic is
+	// gradually shifted to a new revision, e.g. "5m". Maps to "rolloutDuration" in
+	// config-network, which only accepts whole seconds.
+	RolloutDurationAnnotation = "serving.knative.openshift.io/rolloutDuration"
+
+	// ProgressDeadlineAnnotation sets the cluster-wide default time a new revision has to
+	// become ready, e.g. "10m". Maps to "progressDeadline" in config-deployment.
+	//
+	// The gradual rollout only starts once the new revision is ready, so a change takes up to
+	// the progress deadline plus the rollout duration until it receives all traffic. A failing
+	// revision is only detected after the progress deadline, during which the previous
+	// revision keeps serving all traffic.
+	ProgressDeadlineAnnotation = "serving.knative.openshift.io/progressDeadline"
+)
+
+// Rollout bundles the settings relevant to rolling out new revisions.
+type Rollout struct {
+	Duration         *time.Duration
+	ProgressDeadline *time.Duration
+}
+
+// RolloutFromAnnotations parses the rollout settings of the given KnativeServing. It returns
+// nil if none are set.
+func RolloutFromAnnotations(ks *v1alpha1.KnativeServing) (*Rollout, error) {
+	annotations := ks.GetAnnotations()
+	rawDuration, hasDuration := annotations[RolloutDurationAnnotation]
+	rawDeadline, hasDeadline := annotations[ProgressDeadlineAnnotation]
+	if !hasDuration && !hasDeadline {
+		return nil, nil
+	}
+
+	rollout := &Rollout{}
+	if hasDuration {
+		d, err := time.ParseDuration(rawDuration)
+		if err != nil {
+			return nil, fmt.Errorf("%s = %q, must be a duration: %w", RolloutDurationAnnotation, rawDuration, err)
+		}
+		if d < 0 || d%time.Second != 0 {
+			return nil, fmt.Errorf("%s = %q, must be a non-negative number of whole seconds", RolloutDurationAnnotation, rawDuration)
+		}
+		rollout.Duration = &d
+	}
+	if hasDeadline {
+		d, err := time.ParseDuration(rawDeadline)
+		if err != nil {
+			return nil, fmt.Errorf("%s = %q, must be a duration: %w", ProgressDeadlineAnnotation, rawDeadline, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%s = %q, must be positive", ProgressDeadlineAnnotation, rawDeadline)
+		}
+		rollout.ProgressDeadline = &d
+	}
+
+	// The cold-start profile can set the progress deadline too, which must not contradict.
+	coldStart, err := ColdStartFromAnnotation(ks)
+	if err != nil {
+		return nil, err
+	}
+	if rollout.ProgressDeadline != nil && coldStart != nil && coldStart.ProgressDeadline != "" {
+		if d, _ := time.ParseDuration(coldStart.ProgressDeadline); d != *rollout.ProgressDeadline {
+			return nil, fmt.Errorf("%s = %q contradicts the progressDeadline of %s", ProgressDeadlineAnnotation, rawDeadline, ColdStartAnnotation)
+		}
+	}
+	return rollout, nil
+}
+
+// apply renders the settings into the respective ConfigMaps of the given spec.
+func (r *Rollout) apply(spec *v1alpha1.CommonSpec) {
+	if r.Duration != nil {
+		common.Configure(spec, "network", "rolloutDuration", strconv.Itoa(int(r.Duration.Seconds())))
+	}
+	if r.ProgressDeadline != nil {
+		common.Configure(spec, "deployment", "progressDeadline", r.ProgressDeadline.String())
+	}
+}
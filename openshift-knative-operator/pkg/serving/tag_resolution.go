@@ -0,0 +1,115 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// TagResolutionAnnotation is the annotation on the KnativeServing CR configuring how image
+// tags are resolved to digests, as JSON, for example:
+//
+//	serving.knative.openshift.io/tagResolution: |
+//	  {"skipRegistries": ["nexus.example.com:8443"], "caBundle": "registry-ca"}
+//
+// The settings take precedence over the respective keys in spec.config.
+const TagResolutionAnnotation = "serving.knative.openshift.io/tagResolution"
+
+// TagResolution bundles the settings of the resolution of image tags to digests.
+type TagResolution struct {
+	// SkipRegistries are the registries, as host with an optional port, whose images are
+	// deployed by tag rather than by digest.
+	// Maps to "registriesSkippingTagResolving" in config-deployment.
+	SkipRegistries []string `json:"skipRegistries,omitempty"`
+	// CABundle is the name of a ConfigMap in the namespace of the KnativeServing holding
+	// additional CA certificates in PEM format to trust when resolving tags, e.g. the CA of
+	// an internal registry. It's added to the certificates of the controller's
+	// ControllerCustomCerts ConfigMap.
+	CABundle string `json:"caBundle,omitempty"`
+}
+
+// TagResolutionFromAnnotation parses the tag resolution settings of the given KnativeServing.
+// It returns nil if none are set.
+func TagResolutionFromAnnotation(ks *v1alpha1.KnativeServing) (*TagResolution, error) {
+	raw, ok := ks.GetAnnotations()[TagResolutionAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	tr := &TagResolution{}
+	decoder := json.NewDecoder(bytes.NewBufferString(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(tr); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", TagResolutionAnnotation, err)
+	}
+	if err := tr.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TagResolutionAnnotation, err)
+	}
+	// The custom certs default to a ConfigMap, the CA bundle can't be added if they're
+	// explicitly set to something else.
+	certs := ks.Spec.ControllerCustomCerts
+	if tr.CABundle != "" && certs != (v1alpha1.CustomCerts{}) && (certs.Type != "ConfigMap" || certs.Name == "") {
+		return nil, fmt.Errorf("invalid %s: caBundle requires spec.controller-custom-certs to be a ConfigMap", TagResolutionAnnotation)
+	}
+	return tr, nil
+}
+
+// Validate checks the settings for consistency. Registries are compared the way the
+// controller does, so they must not carry a scheme or a path.
+func (tr *TagResolution) Validate() error {
+	seen := make(map[string]bool, len(tr.SkipRegistries))
+	for _, registry := range tr.SkipRegistries {
+		if err := validateRegistry(registry); err != nil {
+			return fmt.Errorf("skipRegistries: %q %w", registry, err)
+		}
+		if seen[registry] {
+			return fmt.Errorf("skipRegistries: %q is listed twice", registry)
+		}
+		seen[registry] = true
+	}
+	if tr.CABundle != "" {
+		if errs := validation.IsDNS1123Subdomain(tr.CABundle); len(errs) > 0 {
+			return fmt.Errorf("caBundle = %q, must be the name of a ConfigMap: %s", tr.CABundle, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validateRegistry checks that the registry is a host with an optional port.
+func validateRegistry(registry string) error {
+	if strings.Contains(registry, "://") {
+		return fmt.Errorf("must not have a scheme")
+	}
+	if strings.Contains(registry, "/") {
+		return fmt.Errorf("must not have a path")
+	}
+	host := registry
+	if h, port, err := net.SplitHostPort(registry); err == nil {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("has an invalid port")
+		}
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return fmt.Errorf("is not a valid host: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// apply renders the settings into the respective ConfigMaps of the given spec. The CA bundle
+// is merged into the custom certs ConfigMap by the operator.
+func (tr *TagResolution) apply(spec *v1alpha1.CommonSpec) {
+	if len(tr.SkipRegistries) > 0 {
+		common.Configure(spec, "deployment", "registriesSkippingTagResolving", strings.Join(tr.SkipRegistries, ","))
+	}
+}
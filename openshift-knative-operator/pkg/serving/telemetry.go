@@ -0,0 +1,29 @@
+package serving
+
+import (
+	"fmt"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// TelemetryAnnotation deploys an OpenTelemetry collector into the namespace of Knative Serving
+// if set on the KnativeServing CR, which receives the traces and metrics of Knative Serving
+// and forwards them to the configured exporters. See common.Telemetry for its format.
+const TelemetryAnnotation = "serving.knative.openshift.io/telemetry"
+
+// TelemetryFromAnnotation parses the telemetry settings of the given KnativeServing. It
+// returns nil if none are set.
+func TelemetryFromAnnotation(ks *v1alpha1.KnativeServing) (*common.Telemetry, error) {
+	return common.TelemetryFromAnnotation(ks, TelemetryAnnotation)
+}
+
+// telemetryManifest returns the collector of the given KnativeServing.
+func telemetryManifest(ks *v1alpha1.KnativeServing, t *common.Telemetry) (*mf.Manifest, error) {
+	image := ks.Spec.Registry.Override[common.TelemetryCollectorImageKey]
+	if image == "" {
+		return nil, fmt.Errorf("%s requires the image of the OpenTelemetry collector to be configured", TelemetryAnnotation)
+	}
+	return t.Manifest(ks.Namespace, image)
+}
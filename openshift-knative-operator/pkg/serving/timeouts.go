@@ -0,0 +1,117 @@
+package serving
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/openshift-knative-operator/pkg/common"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/apis"
+	"knative.dev/serving/pkg/apis/config"
+	servingdeployment "knative.dev/serving/pkg/deployment"
+)
+
+const (
+	// AlignTimeoutsAnnotation makes the Route timeout follow the other timeouts if set to "true"
+	// on the KnativeServing CR. The Route timeout is set to the longer of the
+	// max-revision-timeout-seconds and the progressDeadline, so that the router neither cuts
+	// off requests Knative still allows nor requests waiting for a revision to start.
+	AlignTimeoutsAnnotation = "serving.knative.openshift.io/alignTimeouts"
+
+	// ConsistentTimeouts is false with a warning if the timeouts of requests are inconsistent,
+	// which usually shows as requests failing with a 504 from the router. It doesn't affect
+	// the readiness of Knative Serving.
+	ConsistentTimeouts apis.ConditionType = "ConsistentTimeouts"
+
+	// routeTimeoutKey is the key in config-network the ingress controller takes the timeout
+	// of Routes from.
+	routeTimeoutKey = "openshift-route-timeout"
+)
+
+// timeouts are the timeouts a request to a Knative Service is subject to.
+type timeouts struct {
+	// route is the timeout of the OpenShift Route.
+	route time.Duration
+	// revision and maxRevision are the default and the maximum timeout of revisions.
+	revision    time.Duration
+	maxRevision time.Duration
+	// progressDeadline is the time a revision has to become ready.
+	progressDeadline time.Duration
+}
+
+// timeoutsFromConfig returns the timeouts configured in the given spec, falling back to the
+// defaults of Knative Serving. Unparsable values are left to Knative Serving to report.
+func timeoutsFromConfig(spec *v1alpha1.CommonSpec) timeouts {
+	t := timeouts{
+		route:            config.DefaultMaxRevisionTimeoutSeconds * time.Second,
+		revision:         config.DefaultRevisionTimeoutSeconds * time.Second,
+		maxRevision:      config.DefaultMaxRevisionTimeoutSeconds * time.Second,
+		progressDeadline: servingdeployment.ProgressDeadlineDefault,
+	}
+	if s, err := strconv.ParseInt(configValue(spec, "defaults", "revision-timeout-seconds"), 10, 64); err == nil {
+		t.revision = time.Duration(s) * time.Second
+	}
+	if s, err := strconv.ParseInt(configValue(spec, "defaults", "max-revision-timeout-seconds"), 10, 64); err == nil {
+		t.maxRevision = time.Duration(s) * time.Second
+		// The ingress controller defaults the Route timeout to the max revision timeout.
+		t.route = t.maxRevision
+	}
+	if d, err := time.ParseDuration(configValue(spec, "deployment", servingdeployment.ProgressDeadlineKey)); err == nil {
+		t.progressDeadline = d
+	}
+	if d, err := time.ParseDuration(configValue(spec, "network", routeTimeoutKey)); err == nil {
+		t.route = d
+	}
+	return t
+}
+
+// configValue returns the value of the given key of the given ConfigMap, with or without its
+// "config-" prefix.
+func configValue(spec *v1alpha1.CommonSpec, cm, key string) string {
+	if value, ok := spec.Config[cm][key]; ok {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(spec.Config["config-"+cm][key])
+}
+
+// warnings returns a message for each inconsistency between the timeouts.
+func (t timeouts) warnings() []string {
+	var warnings []string
+	if t.revision > t.maxRevision {
+		warnings = append(warnings, fmt.Sprintf("revision-timeout-seconds %v exceeds max-revision-timeout-seconds %v",
+			t.revision, t.maxRevision))
+	}
+	if t.route < t.maxRevision {
+		warnings = append(warnings, fmt.Sprintf("Routes time out after %v, requests running up to max-revision-timeout-seconds %v fail with a 504",
+			t.route, t.maxRevision))
+	}
+	if t.route < t.progressDeadline {
+		warnings = append(warnings, fmt.Sprintf("Routes time out after %v, requests waiting for a revision to start within the progressDeadline %v fail with a 504",
+			t.route, t.progressDeadline))
+	}
+	return warnings
+}
+
+// alignTimeouts sets the Route timeout of the given KnativeServing to cover the other timeouts,
+// if enabled.
+func alignTimeouts(ks *v1alpha1.KnativeServing) {
+	if align, _ := strconv.ParseBool(ks.GetAnnotations()[AlignTimeoutsAnnotation]); !align {
+		return
+	}
+	t := timeoutsFromConfig(&ks.Spec.CommonSpec)
+	route := t.maxRevision
+	if t.progressDeadline > route {
+		route = t.progressDeadline
+	}
+	// Routes only take whole seconds.
+	route = (route + time.Second - 1).Truncate(time.Second)
+	common.Configure(&ks.Spec.CommonSpec, "network", routeTimeoutKey, route.String())
+}
+
+// markConsistentTimeouts warns about inconsistent timeouts on the status of the given
+// KnativeServing.
+func markConsistentTimeouts(ks *v1alpha1.KnativeServing) {
+	common.MarkWarnings(&ks.Status, ConsistentTimeouts, "InconsistentTimeouts", timeoutsFromConfig(&ks.Spec.CommonSpec).warnings())
+}
@@ -0,0 +1,86 @@
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+func TestTimeoutWarnings(t *testing.T) {
+	cases := []struct {
+		name   string
+		config v1alpha1.ConfigMapData
+		want   int
+	}{{
+		name: "defaults",
+	}, {
+		name: "longer max revision timeout",
+		config: v1alpha1.ConfigMapData{
+			"defaults": {"max-revision-timeout-seconds": "3600"},
+		},
+	}, {
+		name: "short route timeout",
+		config: v1alpha1.ConfigMapData{
+			"config-defaults": {"max-revision-timeout-seconds": "3600"},
+			"network":         {routeTimeoutKey: "10m"},
+		},
+		want: 1,
+	}, {
+		name: "long progress deadline",
+		config: v1alpha1.ConfigMapData{
+			"deployment": {"progressDeadline": "20m"},
+		},
+		want: 1,
+	}, {
+		name: "revision timeout exceeding the max",
+		config: v1alpha1.ConfigMapData{
+			"defaults": {"revision-timeout-seconds": "900"},
+		},
+		want: 1,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := timeoutsFromConfig(&v1alpha1.CommonSpec{Config: c.config}).warnings()
+			if len(got) != c.want {
+				t.Errorf("Got warnings %v, want %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAlignTimeouts(t *testing.T) {
+	ks := &v1alpha1.KnativeServing{}
+	ks.Annotations = map[string]string{AlignTimeoutsAnnotation: "true"}
+	ks.Spec.Config = v1alpha1.ConfigMapData{
+		"defaults":   {"max-revision-timeout-seconds": "3600"},
+		"deployment": {"progressDeadline": "90m0.5s"},
+		"network":    {routeTimeoutKey: "10m"},
+	}
+
+	alignTimeouts(ks)
+	if got, want := ks.Spec.Config["network"][routeTimeoutKey], "1h30m1s"; got != want {
+		t.Errorf("Got Route timeout %q, want %q", got, want)
+	}
+	if got := timeoutsFromConfig(&ks.Spec.CommonSpec).warnings(); len(got) != 0 {
+		t.Errorf("Got warnings after aligning: %v", got)
+	}
+
+	markConsistentTimeouts(ks)
+	if cond := ks.Status.GetCondition(ConsistentTimeouts); cond != nil {
+		t.Errorf("Got condition %v for consistent timeouts", cond)
+	}
+
+	// Nothing is touched without the annotation.
+	ks.Annotations = nil
+	ks.Spec.Config["network"][routeTimeoutKey] = "10m"
+	alignTimeouts(ks)
+	if diff := cmp.Diff("10m", ks.Spec.Config["network"][routeTimeoutKey]); diff != "" {
+		t.Errorf("Route timeout changed without the annotation (-want, +got): %s", diff)
+	}
+	markConsistentTimeouts(ks)
+	if cond := ks.Status.GetCondition(ConsistentTimeouts); cond == nil || !cond.IsFalse() {
+		t.Errorf("ConsistentTimeouts = %v, want false", cond)
+	}
+}
@@ -0,0 +1,37 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// externalTopologyMode is the control plane topology of clusters whose control plane is
+// hosted outside of the cluster, as with HyperShift.
+const externalTopologyMode = "External"
+
+// infrastructureGVR is the resource of the cluster's Infrastructure config. It's read
+// unstructured as the vendored API predates its control plane topology.
+var infrastructureGVR = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "infrastructures"}
+
+// hostedControlPlane returns whether the control plane of the cluster is hosted externally.
+// Such clusters have no control plane nodes or MachineConfigs and their ingress operator
+// runs outside of the cluster, so its config might be missing or incomplete.
+func hostedControlPlane(ctx context.Context, client dynamic.Interface) (bool, error) {
+	infra, err := client.Resource(infrastructureGVR).Get(ctx, "cluster", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to fetch infrastructure config: %w", err)
+	}
+	topology, _, err := unstructured.NestedString(infra.Object, "status", "controlPlaneTopology")
+	if err != nil {
+		return false, fmt.Errorf("failed to read the control plane topology: %w", err)
+	}
+	return topology == externalTopologyMode, nil
+}
@@ -0,0 +1,112 @@
+package serving
+
+import (
+	"context"
+	"testing"
+
+	ocpfake "github.com/openshift-knative/serverless-operator/pkg/client/injection/client/fake"
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	kubefake "knative.dev/pkg/client/injection/kube/client/fake"
+	dynamicfake "knative.dev/pkg/injection/clients/dynamicclient/fake"
+)
+
+func infrastructure(topology string) *unstructured.Unstructured {
+	infra := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"controlPlaneTopology": topology,
+		},
+	}}
+	infra.SetAPIVersion("config.openshift.io/v1")
+	infra.SetKind("Infrastructure")
+	infra.SetName("cluster")
+	return infra
+}
+
+func TestHostedControlPlane(t *testing.T) {
+	tests := []struct {
+		name  string
+		infra []runtime.Object
+		want  bool
+	}{{
+		name: "no infrastructure config",
+	}, {
+		name:  "highly available",
+		infra: []runtime.Object{infrastructure("HighlyAvailable")},
+	}, {
+		name:  "external",
+		infra: []runtime.Object{infrastructure(externalTopologyMode)},
+		want:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := dynamicfake.With(context.Background(), runtime.NewScheme(), test.infra...)
+			got, err := hostedControlPlane(context.Background(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("hostedControlPlane() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReconcileHostedControlPlane(t *testing.T) {
+	emptyIngress := &configv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+	tests := []struct {
+		name       string
+		topology   string
+		objs       []runtime.Object
+		wantErr    bool
+		wantDomain string
+	}{{
+		name:       "hosted, ingress config available",
+		topology:   externalTopologyMode,
+		objs:       []runtime.Object{defaultIngress},
+		wantDomain: "routing.example.com",
+	}, {
+		name:     "hosted, no ingress config",
+		topology: externalTopologyMode,
+	}, {
+		name:     "hosted, no domain",
+		topology: externalTopologyMode,
+		objs:     []runtime.Object{emptyIngress},
+	}, {
+		name:     "highly available, no ingress config",
+		topology: "HighlyAvailable",
+		wantErr:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ks := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: servingNamespace.Name},
+			}
+
+			ctx, _ := ocpfake.With(context.Background(), test.objs...)
+			ctx, _ = kubefake.With(ctx, &servingNamespace)
+			ctx, _ = dynamicfake.With(ctx, runtime.NewScheme(), infrastructure(test.topology))
+			err := newFakeExtension(ctx, t).Reconcile(context.Background(), ks)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Reconcile() = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var gotDomain string
+			for domain := range ks.Spec.Config["domain"] {
+				gotDomain = domain
+			}
+			if gotDomain != test.wantDomain {
+				t.Errorf("Got domain = %q, want %q", gotDomain, test.wantDomain)
+			}
+		})
+	}
+}
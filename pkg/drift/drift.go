@@ -0,0 +1,55 @@
+// Package drift configures how the operators deal with manual edits of the resources they
+// manage, shared by the operators of all components.
+package drift
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// ResyncIntervalEnvKey is the environment variable carrying the interval, as a duration,
+	// all resources are reconciled in even without changes. Every resync detects manual edits
+	// of the managed resources. Defaults to the resync interval of each operator.
+	ResyncIntervalEnvKey = "RESYNC_INTERVAL"
+
+	// DriftPolicyEnvKey is the environment variable selecting how manual edits of the managed
+	// resources are dealt with, either DriftPolicyRevert (the default) or DriftPolicyReport.
+	// Only KnativeKafka honours it: KnativeServing and KnativeEventing are reconciled by the
+	// upstream operator, which always reverts manual edits.
+	DriftPolicyEnvKey = "DRIFT_POLICY"
+	// DriftPolicyRevert reapplies the manifest over manual edits.
+	DriftPolicyRevert = "revert"
+	// DriftPolicyReport keeps manual edits and reports the drifted resources.
+	DriftPolicyReport = "report"
+)
+
+// ResyncInterval returns the configured resync interval, or nil if the default applies.
+func ResyncInterval() (*time.Duration, error) {
+	raw, ok := os.LookupEnv(ResyncIntervalEnvKey)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", ResyncIntervalEnvKey, raw, err)
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("invalid %s %q: must be positive", ResyncIntervalEnvKey, raw)
+	}
+	return &d, nil
+}
+
+// DriftPolicy returns the configured drift policy.
+func DriftPolicy() (string, error) {
+	policy := os.Getenv(DriftPolicyEnvKey)
+	switch policy {
+	case "":
+		return DriftPolicyRevert, nil
+	case DriftPolicyRevert, DriftPolicyReport:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q: must be %q or %q", DriftPolicyEnvKey, policy, DriftPolicyRevert, DriftPolicyReport)
+	}
+}
@@ -0,0 +1,47 @@
+package drift_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift-knative/serverless-operator/pkg/drift"
+)
+
+func TestResyncInterval(t *testing.T) {
+	t.Setenv(drift.ResyncIntervalEnvKey, "")
+	if got, err := drift.ResyncInterval(); err != nil || got != nil {
+		t.Errorf("ResyncInterval() = %v, %v, want nil, nil", got, err)
+	}
+
+	t.Setenv(drift.ResyncIntervalEnvKey, "30m")
+	if got, err := drift.ResyncInterval(); err != nil || got == nil || *got != 30*time.Minute {
+		t.Errorf("ResyncInterval() = %v, %v, want 30m", got, err)
+	}
+
+	for _, invalid := range []string{"30", "-1m", "0s"} {
+		t.Setenv(drift.ResyncIntervalEnvKey, invalid)
+		if _, err := drift.ResyncInterval(); err == nil {
+			t.Errorf("ResyncInterval() = nil error for %q, want an error", invalid)
+		}
+	}
+}
+
+func TestDriftPolicy(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{env: "", want: drift.DriftPolicyRevert},
+		{env: drift.DriftPolicyRevert, want: drift.DriftPolicyRevert},
+		{env: drift.DriftPolicyReport, want: drift.DriftPolicyReport},
+		{env: "ignore", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Setenv(drift.DriftPolicyEnvKey, test.env)
+		got, err := drift.DriftPolicy()
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("DriftPolicy() with %q = %q, %v, want %q, error %v", test.env, got, err, test.want, test.wantErr)
+		}
+	}
+}
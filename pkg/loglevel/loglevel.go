@@ -0,0 +1,104 @@
+// Package loglevel creates the loggers of the subsystems of the operators. The level of each
+// subsystem can be changed at runtime through the "loglevel.<subsystem>" key of config-logging,
+// to debug a single subsystem without raising the level of the whole operator.
+package loglevel
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/configmap/informer"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+// The subsystems whose level can be set separately.
+const (
+	ServingExtension = "serving-extension"
+	Ingress          = "ingress"
+	Monitoring       = "monitoring"
+	Kafka            = "kafka"
+)
+
+// Loggers holds the loggers of a set of subsystems, all configured by the same config-logging.
+type Loggers struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+
+	loggers map[string]*zap.SugaredLogger
+	levels  map[string]zap.AtomicLevel
+}
+
+// New creates a logger for each of the given subsystems from config-logging in the given
+// namespace. The defaults of Knative are used if config-logging doesn't exist.
+func New(ctx context.Context, kubeClient kubernetes.Interface, namespace string, subsystems ...string) (*Loggers, error) {
+	var data map[string]string
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, logging.ConfigMapName(), metav1.GetOptions{})
+	if err == nil {
+		data = cm.Data
+	} else if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get %s: %w", logging.ConfigMapName(), err)
+	}
+	cfg, err := logging.NewConfigFromMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", logging.ConfigMapName(), err)
+	}
+
+	l := &Loggers{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		loggers:    make(map[string]*zap.SugaredLogger, len(subsystems)),
+		levels:     make(map[string]zap.AtomicLevel, len(subsystems)),
+	}
+	for _, subsystem := range subsystems {
+		l.loggers[subsystem], l.levels[subsystem] = logging.NewLoggerFromConfig(cfg, subsystem)
+	}
+	return l, nil
+}
+
+// Get returns the logger of the given subsystem, or nil if it wasn't passed to New.
+func (l *Loggers) Get(subsystem string) *zap.SugaredLogger {
+	return l.loggers[subsystem]
+}
+
+// Watch updates the levels of the loggers whenever config-logging changes, until ctx is done.
+func (l *Loggers) Watch(ctx context.Context) error {
+	watcher := informer.NewInformedWatcher(l.kubeClient, l.namespace)
+	// config-logging is defaulted so that deleting it resets the levels rather than keeping
+	// whatever was set last.
+	watcher.WatchWithDefault(corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      logging.ConfigMapName(),
+			Namespace: l.namespace,
+		},
+	}, l.update)
+	return watcher.Start(ctx.Done())
+}
+
+func (l *Loggers) update(cm *corev1.ConfigMap) {
+	for subsystem, logger := range l.loggers {
+		logging.UpdateLevelFromConfigMap(logger, l.levels[subsystem], subsystem)(cm)
+	}
+}
+
+// WithLogger returns a context carrying the logger of the given subsystem, for controllers
+// started through sharedmain. config-logging is read from the system namespace and watched
+// until ctx is done.
+func WithLogger(ctx context.Context, subsystem string) context.Context {
+	logger := logging.FromContext(ctx)
+
+	l, err := New(ctx, kubeclient.Get(ctx), system.Namespace(), subsystem)
+	if err != nil {
+		logger.Fatalw("Failed to create the logger of "+subsystem, zap.Error(err))
+	}
+	if err := l.Watch(ctx); err != nil {
+		logger.Fatalw("Failed to start watching "+logging.ConfigMapName(), zap.Error(err))
+	}
+	return logging.WithLogger(ctx, l.Get(subsystem))
+}
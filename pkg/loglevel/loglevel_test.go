@@ -0,0 +1,59 @@
+package loglevel
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/pkg/logging"
+)
+
+func TestLoggers(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      logging.ConfigMapName(),
+			Namespace: "openshift-serverless",
+		},
+		Data: map[string]string{
+			"zap-logger-config": `{"level": "info", "encoding": "json"}`,
+			"loglevel.kafka":    "debug",
+		},
+	}
+
+	l, err := New(context.Background(), fake.NewSimpleClientset(cm), "openshift-serverless", Kafka, Ingress)
+	if err != nil {
+		t.Fatal("New() =", err)
+	}
+	assertLevel(t, l, Kafka, zapcore.DebugLevel)
+	assertLevel(t, l, Ingress, zapcore.InfoLevel)
+
+	// Levels follow changes of the ConfigMap and fall back to the global level.
+	cm.Data = map[string]string{
+		"zap-logger-config": `{"level": "warn", "encoding": "json"}`,
+		"loglevel.ingress":  "error",
+	}
+	l.update(cm)
+	assertLevel(t, l, Kafka, zapcore.WarnLevel)
+	assertLevel(t, l, Ingress, zapcore.ErrorLevel)
+}
+
+func TestLoggersDefaults(t *testing.T) {
+	l, err := New(context.Background(), fake.NewSimpleClientset(), "openshift-serverless", Monitoring)
+	if err != nil {
+		t.Fatal("New() =", err)
+	}
+	assertLevel(t, l, Monitoring, zapcore.InfoLevel)
+	if l.Get(Kafka) != nil {
+		t.Error("Got a logger for a subsystem that wasn't requested")
+	}
+}
+
+func assertLevel(t *testing.T, l *Loggers, subsystem string, want zapcore.Level) {
+	t.Helper()
+	if got := l.levels[subsystem].Level(); got != want {
+		t.Errorf("Level of %s = %v, want %v", subsystem, got, want)
+	}
+}
@@ -12,12 +12,13 @@ import (
 var ctors = []injection.ControllerConstructor{
 	ingress.NewIstioController,
 	ingress.NewKourierController,
+	ingress.NewContourController,
 }
 
 func main() {
 	ctx := signals.NewContext()
 
-	// Disable leader election to allow both ingress controllers to do their job.
+	// Disable leader election to allow all ingress controllers to do their job.
 	// TODO: Fix the respective clash in Knative's reconciler framework.
 	ctx = sharedmain.WithHADisabled(ctx)
 
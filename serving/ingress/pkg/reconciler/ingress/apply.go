@@ -0,0 +1,293 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+)
+
+// FieldManager is the field manager the Routes of Ingresses are applied server-side with.
+// The fields of a Route are owned as follows:
+//
+//   - The spec and the labels and annotations the controller sets are owned by the controller.
+//     Changes to them by others are reverted, which is reported through a RouteFieldConflict
+//     event on the Ingress.
+//   - Labels and annotations the controller doesn't set, e.g. added through `oc annotate`,
+//     are owned by whoever added them and kept.
+//   - Labels and annotations the controller no longer sets are removed, unless others set
+//     them too.
+const FieldManager = "knative-openshift-ingress"
+
+// legacyFieldManager is the field manager of the Updates the controller made to Routes before
+// applying them server-side. That's the default of client-go, the name of the binary.
+var legacyFieldManager = strings.SplitN(rest.DefaultKubernetesUserAgent(), "/", 2)[0]
+
+// routeApplyPatch returns the apply patch of the given Route, which only holds the fields the
+// controller owns.
+func routeApplyPatch(route *routev1.Route) ([]byte, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": routev1.GroupVersion.String(),
+		"kind":       "Route",
+		"metadata": map[string]interface{}{
+			"name":        route.Name,
+			"namespace":   route.Namespace,
+			"labels":      route.Labels,
+			"annotations": route.Annotations,
+		},
+		"spec": route.Spec,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal route: %w", err)
+	}
+	return patch, nil
+}
+
+// applyRoute applies the given Route of the given Ingress. Conflicts with other field
+// managers are reported through an event and resolved in favor of the controller.
+func (r *Reconciler) applyRoute(ctx context.Context, ing *v1alpha1.Ingress, route *routev1.Route) error {
+	patch, err := routeApplyPatch(route)
+	if err != nil {
+		return err
+	}
+	force := false
+	opts := metav1.PatchOptions{FieldManager: FieldManager, Force: &force}
+	_, err = r.routeClient.Routes(route.Namespace).Patch(ctx, route.Name, types.ApplyPatchType, patch, opts)
+	if errors.IsConflict(err) {
+		// Fields still owned by the legacy field manager are the controller's own.
+		if !legacyConflict(err) {
+			controller.GetEventRecorder(ctx).Eventf(ing, corev1.EventTypeWarning, "RouteFieldConflict",
+				"Reverting the changes to route %s(%s) by others: %s", route.Name, route.Spec.Host, conflicts(err))
+		}
+		force = true
+		_, err = r.routeClient.Routes(route.Namespace).Patch(ctx, route.Name, types.ApplyPatchType, patch, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply route: %w", err)
+	}
+	return nil
+}
+
+// conflicts describes the conflicting fields of the given conflict error.
+func conflicts(err error) string {
+	status, ok := err.(errors.APIStatus)
+	if !ok || status.Status().Details == nil || len(status.Status().Details.Causes) == 0 {
+		return err.Error()
+	}
+	messages := make([]string, 0, len(status.Status().Details.Causes))
+	for _, cause := range status.Status().Details.Causes {
+		messages = append(messages, cause.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// legacyConflict returns whether all the conflicts of the given conflict error are with the
+// legacy field manager of the controller.
+func legacyConflict(err error) bool {
+	status, ok := err.(errors.APIStatus)
+	if !ok || status.Status().Details == nil || len(status.Status().Details.Causes) == 0 {
+		return false
+	}
+	for _, cause := range status.Status().Details.Causes {
+		if !strings.HasPrefix(cause.Message, fmt.Sprintf("conflict with %q", legacyFieldManager)) {
+			return false
+		}
+	}
+	return true
+}
+
+// upgradeManagedFields hands the fields the controller owns through Updates of its legacy
+// field manager over to FieldManager, so that applying the Route neither conflicts with
+// them nor leaves them behind when the controller stops setting them. It follows
+// csaupgrade of client-go: the legacy entries are merged into the apply entry, and the
+// managed fields are replaced only if the Route didn't change in the meantime. The given
+// Route is returned as is if there's nothing to upgrade.
+func (r *Reconciler) upgradeManagedFields(ctx context.Context, route *routev1.Route) (*routev1.Route, error) {
+	managedFields, upgraded, err := upgradedManagedFields(route.ManagedFields)
+	if err != nil || !upgraded {
+		return route, err
+	}
+	patch, err := json.Marshal([]map[string]interface{}{{
+		"op":    "test",
+		"path":  "/metadata/resourceVersion",
+		"value": route.ResourceVersion,
+	}, {
+		"op":    "replace",
+		"path":  "/metadata/managedFields",
+		"value": managedFields,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal managed fields: %w", err)
+	}
+	route, err = r.routeClient.Routes(route.Namespace).Patch(ctx, route.Name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upgrade managed fields of route: %w", err)
+	}
+	return route, nil
+}
+
+// upgradedManagedFields returns the given managed fields with the Update entries of the
+// legacy field manager merged into the Apply entry of FieldManager, and whether there were
+// any.
+func upgradedManagedFields(entries []metav1.ManagedFieldsEntry) ([]metav1.ManagedFieldsEntry, bool, error) {
+	var (
+		upgraded []metav1.ManagedFieldsEntry
+		legacy   []metav1.ManagedFieldsEntry
+		applied  = -1
+	)
+	for _, entry := range entries {
+		switch {
+		case entry.Manager == legacyFieldManager && entry.Operation == metav1.ManagedFieldsOperationUpdate:
+			legacy = append(legacy, entry)
+			continue
+		case entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply:
+			applied = len(upgraded)
+		}
+		upgraded = append(upgraded, entry)
+	}
+	if len(legacy) == 0 {
+		return entries, false, nil
+	}
+
+	if applied < 0 {
+		applied = len(upgraded)
+		upgraded = append(upgraded, metav1.ManagedFieldsEntry{
+			Manager:    FieldManager,
+			Operation:  metav1.ManagedFieldsOperationApply,
+			APIVersion: legacy[0].APIVersion,
+			Time:       legacy[0].Time,
+			FieldsType: "FieldsV1",
+		})
+	}
+	fields := map[string]interface{}{}
+	for _, entry := range append([]metav1.ManagedFieldsEntry{upgraded[applied]}, legacy...) {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var set map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &set); err != nil {
+			return nil, false, fmt.Errorf("failed to parse the managed fields of %s: %w", entry.Manager, err)
+		}
+		mergeFields(fields, set)
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal managed fields: %w", err)
+	}
+	upgraded[applied].FieldsV1 = &metav1.FieldsV1{Raw: raw}
+	return upgraded, true, nil
+}
+
+// mergeFields merges the given field set into the given one. Field sets are tries of JSON
+// objects, so their union is the deep merge of the objects.
+func mergeFields(into, from map[string]interface{}) {
+	for key, value := range from {
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			into[key] = value
+			continue
+		}
+		existing, ok := into[key].(map[string]interface{})
+		if !ok {
+			existing = map[string]interface{}{}
+			into[key] = existing
+		}
+		mergeFields(existing, child)
+	}
+}
+
+// routeUpToDate returns whether applying the given desired Route wouldn't change the given
+// existing one. Labels and annotations of others are ignored, but those previously applied by
+// the controller must still be desired.
+func routeUpToDate(existing, desired *routev1.Route) bool {
+	if !equality.Semantic.DeepEqual(existing.Spec, desired.Spec) ||
+		!containsAll(existing.Labels, desired.Labels) ||
+		!containsAll(existing.Annotations, desired.Annotations) {
+		return false
+	}
+	labels, annotations := appliedMetadataKeys(existing)
+	return hasAll(desired.Labels, labels) && hasAll(desired.Annotations, annotations)
+}
+
+// containsAll returns whether all entries of want are in got.
+func containsAll(got, want map[string]string) bool {
+	for key, value := range want {
+		if v, ok := got[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAll returns whether the given map has all the given keys.
+func hasAll(m map[string]string, keys sets.String) bool {
+	for key := range keys {
+		if _, ok := m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// appliedMetadataKeys returns the keys of the labels and annotations of the given Route owned
+// by the controller, according to its managed fields.
+func appliedMetadataKeys(route *routev1.Route) (labels, annotations sets.String) {
+	labels, annotations = sets.NewString(), sets.NewString()
+	for _, entry := range route.ManagedFields {
+		if entry.Manager != FieldManager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Metadata struct {
+				Labels      map[string]interface{} `json:"f:labels"`
+				Annotations map[string]interface{} `json:"f:annotations"`
+			} `json:"f:metadata"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		for key := range fields.Metadata.Labels {
+			if strings.HasPrefix(key, "f:") {
+				labels.Insert(strings.TrimPrefix(key, "f:"))
+			}
+		}
+		for key := range fields.Metadata.Annotations {
+			if strings.HasPrefix(key, "f:") {
+				annotations.Insert(strings.TrimPrefix(key, "f:"))
+			}
+		}
+	}
+	return labels, annotations
+}
+
+// reconcileRoute applies the given desired Route of the given Ingress unless it's up to date.
+func (r *Reconciler) reconcileRoute(ctx context.Context, ing *v1alpha1.Ingress, desired *routev1.Route) error {
+	logger := logging.FromContext(ctx)
+
+	// Check if this Route already exists
+	route, err := r.routeLister.Routes(desired.Namespace).Get(desired.Name)
+	if errors.IsNotFound(err) {
+		logger.Infof("Creating route %s(%s)", desired.Name, desired.Spec.Host)
+	} else if err != nil {
+		return fmt.Errorf("failed to get route: %w", err)
+	} else if route, err = r.upgradeManagedFields(ctx, route); err != nil {
+		return err
+	} else if routeUpToDate(route, desired) {
+		return nil
+	} else {
+		logger.Infof("Updating route %s(%s)", desired.Name, desired.Spec.Host)
+	}
+	return r.applyRoute(ctx, ing, desired)
+}
@@ -0,0 +1,122 @@
+package ingress
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	ingressinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
+)
+
+// wildcardCertificate returns the certificate of the Secret with the given name in the serving
+// namespace. Routes fall back to the router's default certificate if it's not configured,
+// missing or invalid.
+func (r *Reconciler) wildcardCertificate(ctx context.Context, name string) *resources.WildcardCertificate {
+	if name == "" || r.secretLister == nil {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+	secret, err := r.secretLister.Get(name)
+	if err != nil {
+		logger.Warnw("Failed to get the wildcard certificate, using the router's default certificate", "secret", name, "error", err)
+		return nil
+	}
+	cert, err := resources.NewWildcardCertificate(secret)
+	if err != nil {
+		logger.Warnw("Invalid wildcard certificate, using the router's default certificate", "secret", name, "error", err)
+		return nil
+	}
+	return cert
+}
+
+// watchWildcardCertificate watches the Secrets of the serving namespace and resyncs all
+// Ingresses when the one configured as the wildcard certificate changes, e.g. when it's
+// rotated.
+func watchWildcardCertificate(ctx context.Context, impl *controller.Impl, store *config.Store) corev1listers.SecretNamespaceLister {
+	namespace := servingNamespace()
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeclient.Get(ctx), controller.GetResyncPeriod(ctx),
+		informers.WithNamespace(namespace))
+	secretInformer := factory.Core().V1().Secrets()
+
+	secretInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			secret, ok := obj.(*corev1.Secret)
+			return ok && secret.Name == store.Load().Route.WildcardCertificate
+		},
+		Handler: controller.HandleAll(func(interface{}) {
+			impl.GlobalResync(ingressinformer.Get(ctx).Informer())
+		}),
+	})
+	factory.Start(ctx.Done())
+	return secretInformer.Lister().Secrets(namespace)
+}
+
+// serviceCertificate returns the certificate of the Secret the given Ingress references by
+// the TLSSecretAnnotation in its own namespace. Routes fall back to the wildcard or the
+// router's default certificate if it's missing or invalid.
+func (r *Reconciler) serviceCertificate(ctx context.Context, ing *v1alpha1.Ingress) *resources.WildcardCertificate {
+	name := ing.GetAnnotations()[resources.TLSSecretAnnotation]
+	if name == "" || r.tlsSecretLister == nil {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+	secret, err := r.tlsSecretLister.Secrets(ing.Namespace).Get(name)
+	if err != nil {
+		logger.Warnw("Failed to get the certificate of the service, using the default certificate", "secret", name, "error", err)
+		return nil
+	}
+	cert, err := resources.NewWildcardCertificate(secret)
+	if err != nil {
+		logger.Warnw("Invalid certificate of the service, using the default certificate", "secret", name, "error", err)
+		return nil
+	}
+	return cert
+}
+
+// watchServiceCertificates watches the TLS Secrets of all namespaces and enqueues the
+// Ingresses referencing a Secret when it changes. Only Secrets of type kubernetes.io/tls are
+// cached.
+func watchServiceCertificates(ctx context.Context, impl *controller.Impl) corev1listers.SecretLister {
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeclient.Get(ctx), controller.GetResyncPeriod(ctx),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)).String()
+		}))
+	secretInformer := factory.Core().V1().Secrets()
+	ingressLister := ingressinformer.Get(ctx).Lister()
+
+	secretInformer.Informer().AddEventHandler(controller.HandleAll(func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return
+		}
+		ings, err := ingressLister.Ingresses(secret.Namespace).List(labels.Everything())
+		if err != nil {
+			return
+		}
+		for _, ing := range ings {
+			if ing.GetAnnotations()[resources.TLSSecretAnnotation] == secret.Name {
+				impl.Enqueue(ing)
+			}
+		}
+	}))
+	factory.Start(ctx.Done())
+	return secretInformer.Lister()
+}
@@ -0,0 +1,347 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/network"
+)
+
+// ExcludedDomainsKey is the key in config-network listing the domain suffixes, separated by
+// commas, for which no Routes must be created. That's useful for domains which are handled
+// by an external load balancer rather than the OpenShift router. It can be set through
+// spec.config.network of the KnativeServing CR.
+const ExcludedDomainsKey = "openshift-route-excluded-domains"
+
+// MigrationGracePeriodKey is the key in config-network setting how long Routes of hosts that
+// are no longer part of their Ingress, e.g. after changing config-domain, keep serving the
+// old hosts before they are removed. Defaults to 0, which removes them right away.
+const MigrationGracePeriodKey = "openshift-route-migration-grace-period"
+
+// HSTSHeaderKey is the key in config-network setting the Strict-Transport-Security header,
+// e.g. "max-age=31536000;includeSubDomains", the OpenShift router adds to the responses of
+// all Routes. Knative Services can opt out through the DisableHSTSAnnotation.
+const HSTSHeaderKey = "openshift-route-hsts-header"
+
+// WildcardCertificateKey is the key in config-network naming a Secret of type kubernetes.io/tls
+// in the serving namespace, usually holding a wildcard certificate of a custom domain. Routes
+// of hosts the certificate is valid for are edge terminated with it instead of the router's
+// default certificate. The certificate is copied into the Routes and they're updated when the
+// Secret is rotated.
+const WildcardCertificateKey = "openshift-route-wildcard-certificate"
+
+// TimeoutKey is the key in config-network setting the timeout of Routes, e.g. "1h". It should
+// be at least the max-revision-timeout-seconds of config-defaults, which it defaults to, as the
+// router otherwise fails longer requests with a 504.
+const TimeoutKey = "openshift-route-timeout"
+
+// InsecurePolicyKey is the key in config-network setting how Routes treat plain HTTP requests
+// if the Ingress doesn't state it through its HTTPOption: "Allow" serves them (the default),
+// "Redirect" redirects them to HTTPS and "None" rejects them.
+const InsecurePolicyKey = "openshift-route-insecure-edge-termination-policy"
+
+const (
+	// HTTPTargetPortKey is the key in config-network setting the port, by name or number, of
+	// the Kourier gateway Service that Routes terminating TLS at the router target. Defaults
+	// to "http2". It's needed if the listeners of the gateway are remapped.
+	HTTPTargetPortKey = "openshift-route-http-target-port"
+	// HTTPSTargetPortKey is the key in config-network setting the port, by name or number, of
+	// the Kourier gateway Service that passthrough Routes target. Defaults to "https".
+	HTTPSTargetPortKey = "openshift-route-https-target-port"
+)
+
+const (
+	// RateLimitConcurrentTCPKey is the key in config-network setting the number of concurrent
+	// TCP connections a client IP may open to a Route. It's passed to the OpenShift router as
+	// the haproxy.router.openshift.io/rate-limit-connections.concurrent-tcp annotation.
+	RateLimitConcurrentTCPKey = "openshift-route-rate-limit-concurrent-tcp"
+	// RateLimitHTTPKey is the key in config-network setting the number of HTTP requests a
+	// client IP may make to a Route within 3 seconds.
+	RateLimitHTTPKey = "openshift-route-rate-limit-rate-http"
+	// RateLimitTCPKey is the key in config-network setting the number of TCP connections a
+	// client IP may open to a Route within 3 seconds.
+	RateLimitTCPKey = "openshift-route-rate-limit-rate-tcp"
+)
+
+// LabelsKey is the key in config-network setting labels, as "key=value" pairs separated by
+// commas, that are added to all Routes, e.g. for network policies or cost attribution
+// selecting Routes by label. Labels of the Ingress and those set by the controller take
+// precedence.
+const LabelsKey = "openshift-route-labels"
+
+const (
+	// OutputKey is the key in config-network selecting the resources generated from Ingresses,
+	// either OutputRoute (the default), OutputGatewayAPI or OutputExternal. Except for the
+	// latter, it can be overridden per Ingress through the "serving.knative.openshift.io/ingressOutput"
+	// annotation.
+	OutputKey = "openshift-ingress-output"
+	// GatewayKey is the key in config-network setting the Gateway, as "<namespace>/<name>",
+	// the HTTPRoutes of the gateway-api output are attached to.
+	GatewayKey = "openshift-ingress-gateway"
+
+	// OutputRoute generates OpenShift Routes.
+	OutputRoute = "route"
+	// OutputGatewayAPI generates Gateway API HTTPRoutes.
+	OutputGatewayAPI = "gateway-api"
+	// OutputExternal generates nothing, as the Routes are managed externally, e.g. through
+	// GitOps. The Routes labeled for an Ingress are still watched and reported on.
+	OutputExternal = "external"
+)
+
+// Route contains the configuration of how Routes are created from Ingresses.
+type Route struct {
+	// ExcludedDomains are the domain suffixes for which no Routes are created.
+	ExcludedDomains []string
+
+	// MigrationGracePeriod is the time obsolete Routes are retained for.
+	MigrationGracePeriod time.Duration
+
+	// HSTSHeader is the Strict-Transport-Security header set on all Routes, if any.
+	HSTSHeader string
+
+	// Output is the kind of resources generated from Ingresses.
+	Output string
+
+	// Gateway is the Gateway HTTPRoutes are attached to.
+	Gateway types.NamespacedName
+	// WildcardCertificate is the name of the Secret holding the certificate Routes are edge
+	// terminated with, if any.
+	WildcardCertificate string
+
+	// Timeout is the timeout of Routes. Zero means the default max-revision-timeout-seconds.
+	Timeout time.Duration
+
+	// InsecurePolicy is the policy for plain HTTP requests of Ingresses without an HTTPOption.
+	// Empty means routev1.InsecureEdgeTerminationPolicyAllow.
+	InsecurePolicy routev1.InsecureEdgeTerminationPolicyType
+
+	// Labels are added to all Routes.
+	Labels map[string]string
+
+	// RateLimit are the connection and request limits per client IP set on all Routes.
+	RateLimit RateLimit
+
+	// HTTPTargetPort and HTTPSTargetPort are the ports of the Kourier gateway Routes target,
+	// if they differ from the defaults.
+	HTTPTargetPort  *intstr.IntOrString
+	HTTPSTargetPort *intstr.IntOrString
+}
+
+// RateLimit are the limits the OpenShift router applies per client IP. Zero leaves a limit
+// unset.
+type RateLimit struct {
+	ConcurrentTCP int
+	RateHTTP      int
+	RateTCP       int
+}
+
+// DefaultExcludedDomains returns the domains Routes are never created for, i.e. the
+// cluster-local domains of Services.
+func DefaultExcludedDomains() []string {
+	return []string{"svc", "svc." + network.GetClusterDomainName()}
+}
+
+// defaultRoute returns the Route configuration used if config-network is absent.
+func defaultRoute() *Route {
+	return &Route{ExcludedDomains: DefaultExcludedDomains(), Output: OutputRoute}
+}
+
+// NewRouteFromConfigMap creates a Route configuration from the given config-network
+// ConfigMap. The configured domains are excluded in addition to the default ones.
+func NewRouteFromConfigMap(cm *corev1.ConfigMap) (*Route, error) {
+	route := defaultRoute()
+	if raw, ok := cm.Data[MigrationGracePeriodKey]; ok {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", MigrationGracePeriodKey, raw, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("invalid %s %q: must not be negative", MigrationGracePeriodKey, raw)
+		}
+		route.MigrationGracePeriod = d
+	}
+
+	if raw, ok := cm.Data[HSTSHeaderKey]; ok && strings.TrimSpace(raw) != "" {
+		if err := validateHSTSHeader(raw); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", HSTSHeaderKey, raw, err)
+		}
+		route.HSTSHeader = strings.TrimSpace(raw)
+	}
+
+	if raw, ok := cm.Data[GatewayKey]; ok && raw != "" {
+		parts := strings.Split(raw, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid %s %q: must be <namespace>/<name>", GatewayKey, raw)
+		}
+		route.Gateway = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+	if raw, ok := cm.Data[OutputKey]; ok && raw != "" {
+		switch raw {
+		case OutputRoute, OutputExternal:
+		case OutputGatewayAPI:
+			if route.Gateway.Name == "" {
+				return nil, fmt.Errorf("%s %q requires %s to be set", OutputKey, raw, GatewayKey)
+			}
+		default:
+			return nil, fmt.Errorf("invalid %s %q: must be %q, %q or %q", OutputKey, raw, OutputRoute, OutputGatewayAPI, OutputExternal)
+		}
+		route.Output = raw
+	}
+
+	if raw, ok := cm.Data[WildcardCertificateKey]; ok && strings.TrimSpace(raw) != "" {
+		name := strings.TrimSpace(raw)
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s %q: %s", WildcardCertificateKey, raw, strings.Join(errs, ", "))
+		}
+		route.WildcardCertificate = name
+	}
+	if raw, ok := cm.Data[TimeoutKey]; ok && strings.TrimSpace(raw) != "" {
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", TimeoutKey, raw, err)
+		}
+		if d <= 0 || d%time.Second != 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive number of whole seconds", TimeoutKey, raw)
+		}
+		route.Timeout = d
+	}
+	if raw, ok := cm.Data[InsecurePolicyKey]; ok && strings.TrimSpace(raw) != "" {
+		policy, err := parseInsecurePolicy(raw)
+		if err != nil {
+			return nil, err
+		}
+		route.InsecurePolicy = policy
+	}
+	for key, port := range map[string]**intstr.IntOrString{
+		HTTPTargetPortKey:  &route.HTTPTargetPort,
+		HTTPSTargetPortKey: &route.HTTPSTargetPort,
+	} {
+		if raw, ok := cm.Data[key]; ok && strings.TrimSpace(raw) != "" {
+			p, err := ParseTargetPort(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*port = &p
+		}
+	}
+	for key, limit := range map[string]*int{
+		RateLimitConcurrentTCPKey: &route.RateLimit.ConcurrentTCP,
+		RateLimitHTTPKey:          &route.RateLimit.RateHTTP,
+		RateLimitTCPKey:           &route.RateLimit.RateTCP,
+	} {
+		if raw, ok := cm.Data[key]; ok && strings.TrimSpace(raw) != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(raw))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid %s %q: must be a positive number", key, raw)
+			}
+			*limit = n
+		}
+	}
+	if raw, ok := cm.Data[LabelsKey]; ok && strings.TrimSpace(raw) != "" {
+		labels, err := parseLabels(raw)
+		if err != nil {
+			return nil, err
+		}
+		route.Labels = labels
+	}
+	raw, ok := cm.Data[ExcludedDomainsKey]
+	if !ok {
+		return route, nil
+	}
+	for _, domain := range strings.Split(raw, ",") {
+		domain = strings.Trim(strings.TrimSpace(domain), ".")
+		if domain == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid domain %q in %s: %s", domain, ExcludedDomainsKey, strings.Join(errs, ", "))
+		}
+		route.ExcludedDomains = append(route.ExcludedDomains, domain)
+	}
+	return route, nil
+}
+
+// parseInsecurePolicy parses the value of the InsecurePolicyKey case-insensitively.
+func parseInsecurePolicy(raw string) (routev1.InsecureEdgeTerminationPolicyType, error) {
+	for _, policy := range []routev1.InsecureEdgeTerminationPolicyType{
+		routev1.InsecureEdgeTerminationPolicyAllow,
+		routev1.InsecureEdgeTerminationPolicyRedirect,
+		routev1.InsecureEdgeTerminationPolicyNone,
+	} {
+		if strings.EqualFold(strings.TrimSpace(raw), string(policy)) {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q: must be %q, %q or %q", InsecurePolicyKey, raw,
+		routev1.InsecureEdgeTerminationPolicyAllow, routev1.InsecureEdgeTerminationPolicyRedirect, routev1.InsecureEdgeTerminationPolicyNone)
+}
+
+// ParseTargetPort parses the target port of a Route, either the name or the number of a port
+// of the targeted Service.
+func ParseTargetPort(raw string) (intstr.IntOrString, error) {
+	raw = strings.TrimSpace(raw)
+	if n, err := strconv.Atoi(raw); err == nil {
+		if errs := validation.IsValidPortNum(n); len(errs) > 0 {
+			return intstr.IntOrString{}, fmt.Errorf("port %q: %s", raw, strings.Join(errs, ", "))
+		}
+		return intstr.FromInt(n), nil
+	}
+	if errs := validation.IsValidPortName(raw); len(errs) > 0 {
+		return intstr.IntOrString{}, fmt.Errorf("port %q: %s", raw, strings.Join(errs, ", "))
+	}
+	return intstr.FromString(raw), nil
+}
+
+// parseLabels parses the value of the LabelsKey.
+func parseLabels(raw string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid label %q in %s: must be key=value", pair, LabelsKey)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q in %s: %s", key, LabelsKey, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %q in %s: %s", value, LabelsKey, strings.Join(errs, ", "))
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// validateHSTSHeader verifies that the given header only consists of the directives
+// supported by the OpenShift router and contains the mandatory max-age.
+func validateHSTSHeader(header string) error {
+	hasMaxAge := false
+	for _, directive := range strings.Split(header, ";") {
+		directive = strings.TrimSpace(directive)
+		switch {
+		case strings.HasPrefix(strings.ToLower(directive), "max-age="):
+			maxAge := strings.Trim(directive[len("max-age="):], `"`)
+			if _, err := strconv.ParseUint(maxAge, 10, 64); err != nil {
+				return fmt.Errorf("max-age must be a number of seconds, got %q", maxAge)
+			}
+			hasMaxAge = true
+		case strings.EqualFold(directive, "includeSubDomains"), strings.EqualFold(directive, "preload"):
+		default:
+			return fmt.Errorf("unsupported directive %q", directive)
+		}
+	}
+	if !hasMaxAge {
+		return fmt.Errorf("max-age is required")
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNewRouteFromConfigMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      map[string]string
+		want      []string
+		wantGrace time.Duration
+		wantHSTS  string
+		wantOut   string
+		wantGW    types.NamespacedName
+		wantCert  string
+		wantIP    routev1.InsecureEdgeTerminationPolicyType
+		wantTO    time.Duration
+		wantLbl   map[string]string
+		wantHTTP  *intstr.IntOrString
+		wantHTTPS *intstr.IntOrString
+		wantRL    RateLimit
+		wantErr   bool
+	}{{
+		name: "defaults",
+		want: DefaultExcludedDomains(),
+	}, {
+		name: "additional domains",
+		data: map[string]string{ExcludedDomainsKey: "lb.example.com, .internal.example.com,"},
+		want: append(DefaultExcludedDomains(), "lb.example.com", "internal.example.com"),
+	}, {
+		name:    "invalid domain",
+		data:    map[string]string{ExcludedDomainsKey: "lb.example.com,not a domain"},
+		wantErr: true,
+	}, {
+		name:      "migration grace period",
+		data:      map[string]string{MigrationGracePeriodKey: "1h"},
+		want:      DefaultExcludedDomains(),
+		wantGrace: time.Hour,
+	}, {
+		name:    "invalid migration grace period",
+		data:    map[string]string{MigrationGracePeriodKey: "forever"},
+		wantErr: true,
+	}, {
+		name:    "negative migration grace period",
+		data:    map[string]string{MigrationGracePeriodKey: "-1h"},
+		wantErr: true,
+	}, {
+		name:     "hsts header",
+		data:     map[string]string{HSTSHeaderKey: " max-age=31536000; includeSubDomains;preload "},
+		want:     DefaultExcludedDomains(),
+		wantHSTS: "max-age=31536000; includeSubDomains;preload",
+	}, {
+		name:    "hsts header without max-age",
+		data:    map[string]string{HSTSHeaderKey: "includeSubDomains"},
+		wantErr: true,
+	}, {
+		name:    "hsts header with invalid max-age",
+		data:    map[string]string{HSTSHeaderKey: "max-age=forever"},
+		wantErr: true,
+	}, {
+		name:    "hsts header with unsupported directive",
+		data:    map[string]string{HSTSHeaderKey: "max-age=1;foo"},
+		wantErr: true,
+	}, {
+		name:    "gateway-api output",
+		data:    map[string]string{OutputKey: OutputGatewayAPI, GatewayKey: "gateways/external"},
+		want:    DefaultExcludedDomains(),
+		wantOut: OutputGatewayAPI,
+		wantGW:  types.NamespacedName{Namespace: "gateways", Name: "external"},
+	}, {
+		name:    "gateway-api output without gateway",
+		data:    map[string]string{OutputKey: OutputGatewayAPI},
+		wantErr: true,
+	}, {
+		name:    "external output",
+		data:    map[string]string{OutputKey: OutputExternal},
+		want:    DefaultExcludedDomains(),
+		wantOut: OutputExternal,
+	}, {
+		name:    "invalid gateway",
+		data:    map[string]string{GatewayKey: "external"},
+		wantErr: true,
+	}, {
+		name:    "invalid output",
+		data:    map[string]string{OutputKey: "ingress"},
+		wantErr: true,
+	}, {
+		name:     "wildcard certificate",
+		data:     map[string]string{WildcardCertificateKey: " apps-wildcard "},
+		want:     DefaultExcludedDomains(),
+		wantCert: "apps-wildcard",
+	}, {
+		name:    "invalid wildcard certificate",
+		data:    map[string]string{WildcardCertificateKey: "openshift-ingress/apps-wildcard"},
+		wantErr: true,
+	}, {
+		name:   "insecure policy",
+		data:   map[string]string{InsecurePolicyKey: " redirect "},
+		want:   DefaultExcludedDomains(),
+		wantIP: routev1.InsecureEdgeTerminationPolicyRedirect,
+	}, {
+		name:   "timeout",
+		data:   map[string]string{TimeoutKey: "1h"},
+		want:   DefaultExcludedDomains(),
+		wantTO: time.Hour,
+	}, {
+		name:    "fractional timeout",
+		data:    map[string]string{TimeoutKey: "1.5s"},
+		wantErr: true,
+	}, {
+		name:    "invalid timeout",
+		data:    map[string]string{TimeoutKey: "0s"},
+		wantErr: true,
+	}, {
+		name:    "labels",
+		data:    map[string]string{LabelsKey: " team=payments, example.com/cost-center=42,"},
+		want:    DefaultExcludedDomains(),
+		wantLbl: map[string]string{"team": "payments", "example.com/cost-center": "42"},
+	}, {
+		name:    "label without value",
+		data:    map[string]string{LabelsKey: "team"},
+		wantErr: true,
+	}, {
+		name:    "invalid label key",
+		data:    map[string]string{LabelsKey: "not a key=payments"},
+		wantErr: true,
+	}, {
+		name:    "invalid label value",
+		data:    map[string]string{LabelsKey: "team=not a value"},
+		wantErr: true,
+	}, {
+		name:      "target ports",
+		data:      map[string]string{HTTPTargetPortKey: " http-alt ", HTTPSTargetPortKey: "8443"},
+		want:      DefaultExcludedDomains(),
+		wantHTTP:  intstrPtr(intstr.FromString("http-alt")),
+		wantHTTPS: intstrPtr(intstr.FromInt(8443)),
+	}, {
+		name:    "invalid target port name",
+		data:    map[string]string{HTTPTargetPortKey: "not_a_port"},
+		wantErr: true,
+	}, {
+		name:    "invalid target port number",
+		data:    map[string]string{HTTPSTargetPortKey: "70000"},
+		wantErr: true,
+	}, {
+		name:   "rate limits",
+		data:   map[string]string{RateLimitConcurrentTCPKey: "10", RateLimitHTTPKey: " 100 "},
+		want:   DefaultExcludedDomains(),
+		wantRL: RateLimit{ConcurrentTCP: 10, RateHTTP: 100},
+	}, {
+		name:    "invalid rate limit",
+		data:    map[string]string{RateLimitTCPKey: "unlimited"},
+		wantErr: true,
+	}, {
+		name:    "zero rate limit",
+		data:    map[string]string{RateLimitHTTPKey: "0"},
+		wantErr: true,
+	}, {
+		name:    "invalid insecure policy",
+		data:    map[string]string{InsecurePolicyKey: "Deny"},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			route, err := NewRouteFromConfigMap(&corev1.ConfigMap{Data: test.data})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("NewRouteFromConfigMap() = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !cmp.Equal(route.ExcludedDomains, test.want) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", route.ExcludedDomains, test.want, cmp.Diff(route.ExcludedDomains, test.want))
+			}
+			if route.MigrationGracePeriod != test.wantGrace {
+				t.Errorf("MigrationGracePeriod = %v, want %v", route.MigrationGracePeriod, test.wantGrace)
+			}
+			if route.HSTSHeader != test.wantHSTS {
+				t.Errorf("HSTSHeader = %q, want %q", route.HSTSHeader, test.wantHSTS)
+			}
+			wantOut := test.wantOut
+			if wantOut == "" {
+				wantOut = OutputRoute
+			}
+			if route.Output != wantOut {
+				t.Errorf("Output = %q, want %q", route.Output, wantOut)
+			}
+			if route.Gateway != test.wantGW {
+				t.Errorf("Gateway = %v, want %v", route.Gateway, test.wantGW)
+			}
+			if route.WildcardCertificate != test.wantCert {
+				t.Errorf("WildcardCertificate = %q, want %q", route.WildcardCertificate, test.wantCert)
+			}
+			if route.Timeout != test.wantTO {
+				t.Errorf("Timeout = %v, want %v", route.Timeout, test.wantTO)
+			}
+			if route.InsecurePolicy != test.wantIP {
+				t.Errorf("InsecurePolicy = %q, want %q", route.InsecurePolicy, test.wantIP)
+			}
+			if !cmp.Equal(route.HTTPTargetPort, test.wantHTTP) {
+				t.Errorf("HTTPTargetPort = %v, want %v", route.HTTPTargetPort, test.wantHTTP)
+			}
+			if !cmp.Equal(route.HTTPSTargetPort, test.wantHTTPS) {
+				t.Errorf("HTTPSTargetPort = %v, want %v", route.HTTPSTargetPort, test.wantHTTPS)
+			}
+			if !cmp.Equal(route.Labels, test.wantLbl) {
+				t.Errorf("Labels = %v, want %v", route.Labels, test.wantLbl)
+			}
+			if route.RateLimit != test.wantRL {
+				t.Errorf("RateLimit = %v, want %v", route.RateLimit, test.wantRL)
+			}
+		})
+	}
+}
+
+func intstrPtr(i intstr.IntOrString) *intstr.IntOrString {
+	return &i
+}
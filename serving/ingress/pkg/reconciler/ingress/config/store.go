@@ -0,0 +1,73 @@
+package config
+
+import (
+	"context"
+
+	networkingpkg "knative.dev/networking/pkg"
+	"knative.dev/pkg/configmap"
+)
+
+type cfgKey struct{}
+
+// Config holds the configuration relevant to the Ingress reconciler.
+type Config struct {
+	Route *Route
+}
+
+// FromContext extracts a Config from the provided context.
+func FromContext(ctx context.Context) *Config {
+	x, ok := ctx.Value(cfgKey{}).(*Config)
+	if ok {
+		return x
+	}
+	return nil
+}
+
+// FromContextOrDefaults is like FromContext, but when no Config is attached it returns
+// a Config populated with the defaults.
+func FromContextOrDefaults(ctx context.Context) *Config {
+	if cfg := FromContext(ctx); cfg != nil {
+		return cfg
+	}
+	return &Config{Route: defaultRoute()}
+}
+
+// ToContext attaches the provided Config to the provided context, returning the new context
+// with the Config attached.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle our configmaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new store of Configs and optionally calls functions when ConfigMaps
+// are updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"ingress",
+			logger,
+			configmap.Constructors{
+				networkingpkg.ConfigName: NewRouteFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config state to the provided context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load creates a Config from the current config state of the Store.
+func (s *Store) Load() *Config {
+	route, _ := s.UntypedLoad(networkingpkg.ConfigName).(*Route)
+	if route == nil {
+		route = defaultRoute()
+	}
+	return &Config{Route: route}
+}
@@ -2,24 +2,42 @@ package ingress
 
 import (
 	"context"
+	"os"
+	"time"
 
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/tools/cache"
+	networkingpkg "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking"
+	networkingclient "knative.dev/networking/pkg/client/injection/client"
 	ingressinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress"
 	ingressreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/ingress"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/configmap/informer"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/reconciler"
 
 	routeclient "github.com/openshift-knative/serverless-operator/pkg/client/injection/client"
 	routeinformer "github.com/openshift-knative/serverless-operator/pkg/client/injection/informers/route/v1/route"
+	"github.com/openshift-knative/serverless-operator/pkg/loglevel"
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
 	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
 )
 
 const (
 	kourierIngressClassName = "kourier.ingress.networking.knative.dev"
 	istioIngressClassName   = "istio.ingress.networking.knative.dev"
+	contourIngressClassName = "contour.ingress.networking.knative.dev"
+
+	// defaultServingNamespace is the namespace of config-network, unless overridden by
+	// the SERVING_NAMESPACE environment variable.
+	defaultServingNamespace = "knative-serving"
 )
 
 // NewIstioController returns a new Ingress controller for Ingress on Openshift.
@@ -27,39 +45,7 @@ func NewIstioController(
 	ctx context.Context,
 	cmw configmap.Watcher,
 ) *controller.Impl {
-	logger := logging.FromContext(ctx)
-
-	ingressInformer := ingressinformer.Get(ctx)
-	routeInformer := routeinformer.Get(ctx)
-
-	c := &Reconciler{
-		routeLister: routeInformer.Lister(),
-		routeClient: routeclient.Get(ctx).RouteV1(),
-	}
-
-	impl := ingressreconciler.NewImpl(ctx, c, istioIngressClassName, func(impl *controller.Impl) controller.Options {
-		return controller.Options{
-			SkipStatusUpdates: true,
-			FinalizerName:     "ocp-ingress",
-		}
-	})
-
-	logger.Info("Setting up event handlers")
-
-	ingressInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, istioIngressClassName, false),
-		Handler:    controller.HandleAll(impl.Enqueue),
-	})
-
-	routeInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: reconciler.LabelExistsFilterFunc(networking.IngressLabelKey),
-		Handler: controller.HandleAll(impl.EnqueueLabelOfNamespaceScopedResource(
-			resources.OpenShiftIngressNamespaceLabelKey,
-			resources.OpenShiftIngressLabelKey,
-		)),
-	})
-
-	return impl
+	return newController(ctx, istioIngressClassName)
 }
 
 // NewKourierController returns a new Ingress controller for Ingress on Openshift.
@@ -67,32 +53,57 @@ func NewKourierController(
 	ctx context.Context,
 	cmw configmap.Watcher,
 ) *controller.Impl {
+	return newController(ctx, kourierIngressClassName)
+}
+
+// NewContourController returns a new Ingress controller for Ingress on Openshift, exposing
+// Contour's Envoy service through Routes.
+func NewContourController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	return newController(ctx, contourIngressClassName)
+}
+
+// newController returns a new Ingress controller for the Ingresses of the given class.
+func newController(ctx context.Context, ingressClassName string) *controller.Impl {
+	ctx = loglevel.WithLogger(ctx, loglevel.Ingress)
 	logger := logging.FromContext(ctx)
 
 	ingressInformer := ingressinformer.Get(ctx)
 	routeInformer := routeinformer.Get(ctx)
 
 	c := &Reconciler{
-		routeLister: routeInformer.Lister(),
-		routeClient: routeclient.Get(ctx).RouteV1(),
+		routeLister:   routeInformer.Lister(),
+		routeClient:   routeclient.Get(ctx).RouteV1(),
+		ingressLister: ingressInformer.Lister(),
+		ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+		dynamicClient: dynamicclient.Get(ctx),
+		clock:         clock.RealClock{},
+		admissions:    admissionRecorder{since: time.Now()},
 	}
 
-	impl := ingressreconciler.NewImpl(ctx, c, kourierIngressClassName, func(impl *controller.Impl) controller.Options {
+	var store *config.Store
+	impl := ingressreconciler.NewImpl(ctx, c, ingressClassName, func(impl *controller.Impl) controller.Options {
+		store = watchConfig(ctx, impl)
 		return controller.Options{
 			SkipStatusUpdates: true,
 			FinalizerName:     "ocp-ingress",
+			ConfigStore:       store,
 		}
 	})
+	c.secretLister = watchWildcardCertificate(ctx, impl, store)
+	c.tlsSecretLister = watchServiceCertificates(ctx, impl)
 
 	logger.Info("Setting up event handlers")
 
 	ingressInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, kourierIngressClassName, false),
+		FilterFunc: reconciler.AnnotationFilterFunc(networking.IngressClassAnnotationKey, ingressClassName, false),
 		Handler:    controller.HandleAll(impl.Enqueue),
 	})
 
 	routeInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
-		FilterFunc: reconciler.LabelExistsFilterFunc(networking.IngressLabelKey),
+		FilterFunc: reconciler.LabelExistsFilterFunc(resources.OpenShiftIngressLabelKey),
 		Handler: controller.HandleAll(impl.EnqueueLabelOfNamespaceScopedResource(
 			resources.OpenShiftIngressNamespaceLabelKey,
 			resources.OpenShiftIngressLabelKey,
@@ -101,3 +112,36 @@ func NewKourierController(
 
 	return impl
 }
+
+// watchConfig returns a Store tracking config-network in the serving namespace. All Ingresses
+// are resynced when it changes.
+func watchConfig(ctx context.Context, impl *controller.Impl) *config.Store {
+	logger := logging.FromContext(ctx)
+
+	store := config.NewStore(logger.Named("config-store"), func(string, interface{}) {
+		impl.GlobalResync(ingressinformer.Get(ctx).Informer())
+	})
+
+	namespace := servingNamespace()
+	// config-network is watched separately as it lives in the serving namespace rather than in
+	// the namespace of this controller. It's defaulted as Serving might not be installed yet.
+	watcher := informer.NewInformedWatcher(kubeclient.Get(ctx), namespace)
+	watcher.WatchWithDefault(corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkingpkg.ConfigName,
+			Namespace: namespace,
+		},
+	}, store.OnConfigChanged)
+	if err := watcher.Start(ctx.Done()); err != nil {
+		logger.Fatalw("Failed to start watching "+networkingpkg.ConfigName, zap.Error(err))
+	}
+	return store
+}
+
+// servingNamespace returns the namespace Knative Serving is installed into.
+func servingNamespace() string {
+	if namespace := os.Getenv("SERVING_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return defaultServingNamespace
+}
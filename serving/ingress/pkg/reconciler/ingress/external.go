@@ -0,0 +1,57 @@
+package ingress
+
+import (
+	"context"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
+)
+
+// reconcileExternalRoutes leaves the Routes of the given ingress to be managed externally,
+// e.g. through GitOps. The Routes labeled for the ingress are only observed: their admission
+// is recorded and hosts lacking a Route are reported through an event. The resources of the
+// other outputs are cleaned up, but existing Routes are kept to be adopted.
+func (r *Reconciler) reconcileExternalRoutes(ctx context.Context, ing *v1alpha1.Ingress, cfg *config.Route, existing map[string]*routev1.Route) reconciler.Event {
+	logger := logging.FromContext(ctx)
+
+	hosts := sets.NewString()
+	routes := make([]*routev1.Route, 0, len(existing))
+	for _, rt := range existing {
+		hosts.Insert(rt.Spec.Host)
+		r.admissions.record(ctx, ing, rt)
+		routes = append(routes, rt)
+	}
+
+	desired, err := resources.MakeRoutes(ing, cfg)
+	if err != nil {
+		logger.Warnf("Failed to determine the routes of ingress %v", err)
+	} else {
+		missing := sets.NewString()
+		for _, route := range desired {
+			if !hosts.Has(route.Spec.Host) {
+				missing.Insert(route.Spec.Host)
+			}
+		}
+		if missing.Len() > 0 {
+			controller.GetEventRecorder(ctx).Eventf(ing, corev1.EventTypeWarning, "RouteMissing",
+				"No externally managed route for %s", strings.Join(missing.List(), ", "))
+		}
+	}
+
+	if err := r.deleteHTTPRoutes(ctx, ing, nil); err != nil {
+		return err
+	}
+	if err := r.reconcileMigrationIngress(ctx, ing, nil); err != nil {
+		return err
+	}
+	return r.reportRouteURLs(ctx, ing, routes)
+}
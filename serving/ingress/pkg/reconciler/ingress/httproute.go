@@ -0,0 +1,118 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/reconciler"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
+)
+
+// reconcileHTTPRoutes generates Gateway API HTTPRoutes instead of OpenShift Routes for the
+// given ingress. The ingress' existing Routes are removed, as they're superseded.
+//
+// HTTPRoutes aren't watched, so changes to them are only reverted on the next resync.
+func (r *Reconciler) reconcileHTTPRoutes(ctx context.Context, ing *v1alpha1.Ingress, cfg *config.Route, existingRoutes map[string]*routev1.Route) reconciler.Event {
+	logger := logging.FromContext(ctx)
+
+	if cfg.Gateway.Name == "" {
+		logger.Warnf("The %s output requires %s to be set in config-network", config.OutputGatewayAPI, config.GatewayKey)
+		return nil
+	}
+	httpRoutes, err := resources.MakeHTTPRoutes(ing, cfg)
+	if err != nil {
+		logger.Warnf("Failed to generate HTTPRoutes from ingress %v", err)
+		// Returning nil aborts the reconciliation. It will be retriggered once the status of the ingress changes.
+		return nil
+	}
+	if resources.IsDryRun(ing) {
+		logger.Infof("[dry-run] Only supported for the %s output, skipping", config.OutputRoute)
+		return nil
+	}
+
+	desired := make(map[string]bool, len(httpRoutes))
+	for _, httpRoute := range httpRoutes {
+		if err := r.reconcileHTTPRoute(ctx, httpRoute); err != nil {
+			return err
+		}
+		desired[httpRoute.GetNamespace()+"/"+httpRoute.GetName()] = true
+	}
+	if err := r.deleteHTTPRoutes(ctx, ing, desired); err != nil {
+		return err
+	}
+
+	for _, rt := range existingRoutes {
+		if err := r.deleteRoute(ctx, rt); err != nil {
+			return err
+		}
+	}
+	return r.reconcileMigrationIngress(ctx, ing, nil)
+}
+
+func (r *Reconciler) reconcileHTTPRoute(ctx context.Context, desired *unstructured.Unstructured) error {
+	logger := logging.FromContext(ctx)
+	client := r.dynamicClient.Resource(resources.HTTPRouteGVR).Namespace(desired.GetNamespace())
+
+	existing, err := client.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		logger.Infof("Creating HTTPRoute %s", desired.GetName())
+		if _, err := client.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create HTTPRoute: %w", err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get HTTPRoute: %w", err)
+	}
+
+	if equality.Semantic.DeepEqual(existing.Object["spec"], desired.Object["spec"]) &&
+		equality.Semantic.DeepEqual(existing.GetLabels(), desired.GetLabels()) {
+		return nil
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+	existing.SetLabels(desired.GetLabels())
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update HTTPRoute: %w", err)
+	}
+	return nil
+}
+
+// deleteHTTPRoutes deletes the HTTPRoutes of the given ingress except for the ones to keep,
+// keyed by "<namespace>/<name>".
+func (r *Reconciler) deleteHTTPRoutes(ctx context.Context, ing *v1alpha1.Ingress, keep map[string]bool) error {
+	logger := logging.FromContext(ctx)
+
+	list, err := r.dynamicClient.Resource(resources.HTTPRouteGVR).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{
+			resources.OpenShiftIngressLabelKey:          ing.GetName(),
+			resources.OpenShiftIngressNamespaceLabelKey: ing.GetNamespace(),
+		}).String(),
+	})
+	if errors.IsNotFound(err) {
+		// The Gateway API isn't installed, so there's nothing to clean up.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to list HTTPRoutes: %w", err)
+	}
+
+	for _, httpRoute := range list.Items {
+		if keep[httpRoute.GetNamespace()+"/"+httpRoute.GetName()] {
+			continue
+		}
+		logger.Infof("Deleting HTTPRoute %s", httpRoute.GetName())
+		err := r.dynamicClient.Resource(resources.HTTPRouteGVR).Namespace(httpRoute.GetNamespace()).Delete(ctx, httpRoute.GetName(), metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete HTTPRoute: %w", err)
+		}
+	}
+	return nil
+}
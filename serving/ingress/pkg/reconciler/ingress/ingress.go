@@ -3,26 +3,47 @@ package ingress
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	networkingv1alpha1client "knative.dev/networking/pkg/client/clientset/versioned/typed/networking/v1alpha1"
 	ingressreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/ingress"
+	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/reconciler"
 
 	routev1client "github.com/openshift-knative/serverless-operator/pkg/client/clientset/versioned/typed/route/v1"
 	routev1lister "github.com/openshift-knative/serverless-operator/pkg/client/listers/route/v1"
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
 	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
 	routev1 "github.com/openshift/api/route/v1"
 )
 
 // Reconciler implements controller.Reconciler for Ingress resources.
 type Reconciler struct {
-	routeLister routev1lister.RouteLister
-	routeClient routev1client.RouteV1Interface
+	routeLister   routev1lister.RouteLister
+	routeClient   routev1client.RouteV1Interface
+	ingressLister networkinglisters.IngressLister
+	ingressClient networkingv1alpha1client.NetworkingV1alpha1Interface
+	dynamicClient dynamic.Interface
+	clock         clock.PassiveClock
+	admissions    admissionRecorder
+	// secretLister lists the Secrets of the serving namespace, see wildcardCertificate.
+	secretLister corev1listers.SecretNamespaceLister
+	// tlsSecretLister lists the TLS Secrets of all namespaces, see serviceCertificate.
+	tlsSecretLister corev1listers.SecretLister
 }
 
 var _ ingressreconciler.Interface = (*Reconciler)(nil)
@@ -35,12 +56,20 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, ing *v1alpha1.Ingress) re
 		return fmt.Errorf("failed to list routes for deletion: %w", err)
 	}
 
+	// Externally managed routes are left to their owner.
+	if resources.OutputFor(ing, config.FromContextOrDefaults(ctx).Route) == config.OutputExternal {
+		for _, route := range routes {
+			r.admissions.forget(route)
+		}
+		routes = nil
+	}
+
 	for _, route := range routes {
 		if err := r.deleteRoute(ctx, route); err != nil {
 			return fmt.Errorf("failed to delete routes: %w", err)
 		}
 	}
-	return nil
+	return r.deleteHTTPRoutes(ctx, ing, nil)
 }
 
 // ReconcileKind reconciles ingress resource.
@@ -52,63 +81,295 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ing *v1alpha1.Ingress) r
 		return fmt.Errorf("failed to list routes: %w", err)
 	}
 
-	routes, err := resources.MakeRoutes(ing)
+	cfg := config.FromContextOrDefaults(ctx)
+	switch output := resources.OutputFor(ing, cfg.Route); output {
+	case config.OutputRoute:
+	case config.OutputGatewayAPI:
+		return r.reconcileHTTPRoutes(ctx, ing, cfg.Route, existingMap)
+	case config.OutputExternal:
+		return r.reconcileExternalRoutes(ctx, ing, cfg.Route, existingMap)
+	default:
+		logger.Warnf("Unsupported %s %q", resources.OutputAnnotation, output)
+		return nil
+	}
+
+	routes, err := resources.MakeRoutes(ing, cfg.Route)
 	if err != nil {
 		logger.Warnf("Failed to generate routes from ingress %v", err)
 		// Returning nil aborts the reconciliation. It will be retriggered once the status of the ingress changes.
 		return nil
 	}
+
+	if cert := r.wildcardCertificate(ctx, cfg.Route.WildcardCertificate); cert != nil {
+		resources.ApplyWildcardCertificate(routes, cert)
+	}
+	// The certificate of the service takes precedence over the wildcard certificate.
+	if cert := r.serviceCertificate(ctx, ing); cert != nil {
+		resources.ApplyWildcardCertificate(routes, cert)
+	}
+
+	// Routes are named after the UID of their Ingress, so a recreated Ingress would create
+	// new routes claiming the hosts of the ones left over by its predecessor.
+	adoptRoutes(ctx, routes, existingMap)
+
+	// Only report the changes that would be made in dry-run mode.
+	if resources.IsDryRun(ing) {
+		r.reportRouteChanges(ctx, ing, routes, existingMap)
+		return nil
+	}
+
+	// Recreate the routes on request, e.g. to clear their state in the router.
+	recreated, retryAfter, err := r.recreateRoutes(ctx, ing, routes, existingMap)
+	if err != nil {
+		return err
+	}
+
+	var reported []*routev1.Route
 	for _, route := range routes {
-		if err := r.reconcileRoute(ctx, route); err != nil {
+		if recreated.Has(route.Name) {
+			continue
+		}
+		if err := r.reconcileRoute(ctx, ing, route); err != nil {
 			return err
 		}
+		if existing, ok := existingMap[route.Name]; ok {
+			r.admissions.record(ctx, ing, existing)
+			reported = append(reported, existing)
+		}
 		delete(existingMap, route.Name)
 	}
+	// Clean up the HTTPRoutes after switching from the gateway-api output.
+	if err := r.deleteHTTPRoutes(ctx, ing, nil); err != nil {
+		return err
+	}
+	if err := r.reportRouteURLs(ctx, ing, reported); err != nil {
+		return err
+	}
 	// If routes remains in existingMap, it must be obsoleted routes. Clean them up.
-	for _, rt := range existingMap {
-		if err := r.deleteRoute(ctx, rt); err != nil {
-			return err
+	served := sets.NewString()
+	for _, route := range routes {
+		served.Insert(route.Spec.Host)
+	}
+	if err := r.reconcileObsoleteRoutes(ctx, ing, existingMap, served, cfg.Route); err != nil {
+		return err
+	}
+	if retryAfter > 0 {
+		return controller.NewRequeueAfter(retryAfter)
+	}
+	return nil
+}
+
+// adoptRoutes renames the desired routes lacking an existing route of the same name after
+// an existing route for the same host, which is then updated instead of conflicting with a
+// newly created one.
+func adoptRoutes(ctx context.Context, desired []*routev1.Route, existing map[string]*routev1.Route) {
+	logger := logging.FromContext(ctx)
+
+	names := sets.NewString()
+	for _, route := range desired {
+		names.Insert(route.Name)
+	}
+	// Sort by name to adopt the same route consistently if there are multiple.
+	byHost := make(map[string]*routev1.Route, len(existing))
+	for _, name := range sets.StringKeySet(existing).List() {
+		rt := existing[name]
+		if _, taken := byHost[rt.Spec.Host]; !taken && !names.Has(name) {
+			byHost[rt.Spec.Host] = rt
+		}
+	}
+
+	for _, route := range desired {
+		if _, ok := existing[route.Name]; ok {
+			continue
+		}
+		adopted, ok := byHost[route.Spec.Host]
+		if !ok || adopted.Namespace != route.Namespace {
+			continue
+		}
+		logger.Infof("Adopting route %s(%s) instead of creating %s", adopted.Name, adopted.Spec.Host, route.Name)
+		route.Name = adopted.Name
+		delete(byHost, route.Spec.Host)
+	}
+}
+
+// reconcileObsoleteRoutes deletes the given obsolete routes once the grace period passed.
+// Until then, they're retained and their hosts are kept served through a migration Ingress,
+// so that clients have time to move to the new hosts. Routes for hosts that are still served
+// are deleted right away, as there's nothing to migrate.
+//
+// Routes for hosts that are no longer served, e.g. as the Knative Service became cluster-local,
+// are left alone until the Ingress is ready for its current generation. A Service switching
+// its visibility back and forth thus keeps its Routes instead of having them deleted and
+// recreated.
+//
+// Routes for hosts within the excluded domains are deleted right away, as those hosts must
+// not be exposed at all, e.g. after they've been added to the excluded domains.
+func (r *Reconciler) reconcileObsoleteRoutes(ctx context.Context, ing *v1alpha1.Ingress, obsolete map[string]*routev1.Route, served sets.String, cfg *config.Route) error {
+	logger := logging.FromContext(ctx)
+	now := r.clock.Now()
+	settled := ing.IsReady()
+	gracePeriod := cfg.MigrationGracePeriod
+	var retainedHosts []string
+	var requeueAfter time.Duration
+	for _, rt := range obsolete {
+		if resources.IsExcluded(rt.Spec.Host, cfg.ExcludedDomains) {
+			if err := r.deleteRoute(ctx, rt); err != nil {
+				return err
+			}
+			continue
+		}
+		since, marked := resources.ObsoleteSince(rt)
+		if !settled && !served.Has(rt.Spec.Host) {
+			// The Ingress is reconciled again once its status changes.
+			logger.Infof("Keeping route %s(%s) until the ingress is ready", rt.Name, rt.Spec.Host)
+			if marked {
+				retainedHosts = append(retainedHosts, rt.Spec.Host)
+			}
+			continue
+		}
+		if gracePeriod == 0 || served.Has(rt.Spec.Host) || (marked && now.Sub(since) >= gracePeriod) {
+			if err := r.deleteRoute(ctx, rt); err != nil {
+				return err
+			}
+			continue
+		}
+		if !marked {
+			since = now
+			if err := r.markRouteObsolete(ctx, rt, since); err != nil {
+				return err
+			}
+		}
+		retainedHosts = append(retainedHosts, rt.Spec.Host)
+		if remaining := gracePeriod - now.Sub(since); requeueAfter == 0 || remaining < requeueAfter {
+			requeueAfter = remaining
 		}
 	}
+	sort.Strings(retainedHosts)
 
+	if err := r.reconcileMigrationIngress(ctx, ing, retainedHosts); err != nil {
+		return err
+	}
+	if requeueAfter > 0 {
+		return controller.NewRequeueAfter(requeueAfter)
+	}
 	return nil
 }
 
-func (r *Reconciler) deleteRoute(ctx context.Context, route *routev1.Route) error {
+func (r *Reconciler) markRouteObsolete(ctx context.Context, route *routev1.Route, since time.Time) error {
 	logger := logging.FromContext(ctx)
-	logger.Infof("Deleting route %s(%s)", route.Name, route.Spec.Host)
-	if err := r.routeClient.Routes(route.Namespace).Delete(ctx, route.Name, metav1.DeleteOptions{}); err != nil {
-		return fmt.Errorf("failed to delete route: %w", err)
+	logger.Infof("Retaining obsolete route %s(%s)", route.Name, route.Spec.Host)
+
+	// Don't modify the informers copy
+	existing := route.DeepCopy()
+	resources.MarkObsolete(existing, since)
+	if _, err := r.routeClient.Routes(existing.Namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to mark route as obsolete: %w", err)
+	}
+	return nil
+}
+
+// reconcileMigrationIngress makes sure the given hosts are served like their successors of
+// the given Ingress, or removes the migration Ingress if there are none.
+func (r *Reconciler) reconcileMigrationIngress(ctx context.Context, ing *v1alpha1.Ingress, hosts []string) error {
+	logger := logging.FromContext(ctx)
+
+	name := resources.MigrationIngressName(ing)
+	existing, err := r.ingressLister.Ingresses(ing.Namespace).Get(name)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get migration ingress: %w", err)
+	}
+	exists := err == nil
+
+	desired := resources.MakeMigrationIngress(ing, hosts)
+	if desired == nil {
+		if !exists {
+			return nil
+		}
+		logger.Infof("Deleting migration ingress %s", name)
+		if err := r.ingressClient.Ingresses(ing.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete migration ingress: %w", err)
+		}
+		return nil
+	}
+
+	if !exists {
+		logger.Infof("Creating migration ingress %s for %v", name, hosts)
+		if _, err := r.ingressClient.Ingresses(ing.Namespace).Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create migration ingress: %w", err)
+		}
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(existing.Annotations, desired.Annotations) {
+		return nil
+	}
+	// Don't modify the informers copy
+	update := existing.DeepCopy()
+	update.Spec = desired.Spec
+	update.Annotations = desired.Annotations
+	if _, err := r.ingressClient.Ingresses(ing.Namespace).Update(ctx, update, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update migration ingress: %w", err)
 	}
 	return nil
 }
 
-func (r *Reconciler) reconcileRoute(ctx context.Context, desired *routev1.Route) error {
+// reportRouteChanges logs and emits events for the changes to the ingress' routes without
+// applying them.
+func (r *Reconciler) reportRouteChanges(ctx context.Context, ing *v1alpha1.Ingress, desired []*routev1.Route, existingMap map[string]*routev1.Route) {
 	logger := logging.FromContext(ctx)
+	recorder := controller.GetEventRecorder(ctx)
 
-	// Check if this Route already exists
-	route, err := r.routeLister.Routes(desired.Namespace).Get(desired.Name)
-	if errors.IsNotFound(err) {
-		logger.Infof("Creating route %s(%s)", desired.Name, desired.Spec.Host)
-		if _, err := r.routeClient.Routes(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{}); err != nil {
-			return fmt.Errorf("failed to create route :%w", err)
+	for _, route := range desired {
+		existing, ok := existingMap[route.Name]
+		delete(existingMap, route.Name)
+		if !ok {
+			logger.Infof("[dry-run] Would create route %s(%s)", route.Name, route.Spec.Host)
+			recorder.Eventf(ing, corev1.EventTypeNormal, "DryRunCreate", "Would create route %s(%s)", route.Name, route.Spec.Host)
+			continue
+		}
+		if !routeUpToDate(existing, route) {
+			logger.Infof("[dry-run] Would update route %s(%s), diff (-existing, +desired):\n%s", route.Name, route.Spec.Host, routeDiff(existing, route))
+			recorder.Eventf(ing, corev1.EventTypeNormal, "DryRunUpdate", "Would update route %s(%s)", route.Name, route.Spec.Host)
 		}
-	} else if err != nil {
-		return fmt.Errorf("failed to get route: %w", err)
-	} else if !equality.Semantic.DeepEqual(route.Spec, desired.Spec) ||
-		!equality.Semantic.DeepEqual(route.Annotations, desired.Annotations) ||
-		!equality.Semantic.DeepEqual(route.Labels, desired.Labels) {
-		// Don't modify the informers copy
-		existing := route.DeepCopy()
-		existing.Spec = desired.Spec
-		existing.Annotations = desired.Annotations
-		existing.Labels = desired.Labels
+	}
+	for _, rt := range existingMap {
+		logger.Infof("[dry-run] Would delete route %s(%s)", rt.Name, rt.Spec.Host)
+		recorder.Eventf(ing, corev1.EventTypeNormal, "DryRunDelete", "Would delete route %s(%s)", rt.Name, rt.Spec.Host)
+	}
+}
 
-		if _, err := r.routeClient.Routes(existing.Namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
-			return fmt.Errorf("failed to update route :%w", err)
+// routeDiff returns a human readable diff of the fields of the existing route that would
+// be changed to get to the desired route.
+func routeDiff(existing, desired *routev1.Route) string {
+	relevant := func(r *routev1.Route) *routev1.Route {
+		return &routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      r.Labels,
+				Annotations: r.Annotations,
+			},
+			Spec: redactKey(r).Spec,
 		}
 	}
+	return cmp.Diff(relevant(existing), relevant(desired))
+}
+
+// redactKey hides the private key of a Route's certificate from diffs and logs.
+func redactKey(r *routev1.Route) *routev1.Route {
+	if r.Spec.TLS == nil || r.Spec.TLS.Key == "" {
+		return r
+	}
+	redacted := r.DeepCopy()
+	redacted.Spec.TLS.Key = "<redacted>"
+	return redacted
+}
 
+func (r *Reconciler) deleteRoute(ctx context.Context, route *routev1.Route) error {
+	logger := logging.FromContext(ctx)
+	logger.Infof("Deleting route %s(%s)", route.Name, route.Spec.Host)
+	if err := r.routeClient.Routes(route.Namespace).Delete(ctx, route.Name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("failed to delete route: %w", err)
+	}
+	r.admissions.forget(route)
 	return nil
 }
 
@@ -2,26 +2,37 @@ package ingress
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
 	fakerouteclient "github.com/openshift-knative/serverless-operator/pkg/client/injection/client/fake"
 	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	clientgotesting "k8s.io/client-go/testing"
 	"knative.dev/networking/pkg/apis/networking"
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	networkingclient "knative.dev/networking/pkg/client/injection/client/fake"
 	ingressreconciler "knative.dev/networking/pkg/client/injection/reconciler/networking/v1alpha1/ingress"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/ptr"
 	"knative.dev/serving/pkg/apis/serving"
 
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
 	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
 	. "github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/testing"
 	. "knative.dev/pkg/reconciler/testing"
@@ -57,7 +68,7 @@ func TestKourierReconcile(t *testing.T) {
 		SkipNamespaceValidation: true,
 		Key:                     key,
 		Objects:                 []runtime.Object{ing(ingNamespace, ingName)},
-		WantCreates:             []runtime.Object{route(ingressNamespace, routeName)},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
 	}, {
 		Name:                    "remove outdated routes",
 		SkipNamespaceValidation: true,
@@ -88,12 +99,10 @@ func TestKourierReconcile(t *testing.T) {
 				i.Labels["foo.bar/baz"] = "baz"
 			}),
 		},
-		WantCreates: []runtime.Object{
-			route(ingressNamespace, routeName, func(r *routev1.Route) {
-				r.Annotations["foo.bar/baz"] = "baz"
-				r.Labels["foo.bar/baz"] = "baz"
-			}),
-		},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, func(r *routev1.Route) {
+			r.Annotations["foo.bar/baz"] = "baz"
+			r.Labels["foo.bar/baz"] = "baz"
+		}))},
 	}, {
 		Name:                    "copy annotations and labels on update too",
 		SkipNamespaceValidation: true,
@@ -105,14 +114,51 @@ func TestKourierReconcile(t *testing.T) {
 			}),
 			route(ingressNamespace, routeName),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route(ingressNamespace, routeName, func(r *routev1.Route) {
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, func(r *routev1.Route) {
+			r.Annotations["foo.bar/baz"] = "baz"
+			r.Labels["foo.bar/baz"] = "baz"
+		}))},
+	}, {
+		Name:                    "fix spec",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				r.Spec.To.Kind = "foo"
+			}),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
+	}, {
+		Name:                    "keep annotations of others",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				r.Annotations["admin.example.com/note"] = "keep"
+			}),
+		},
+	}, {
+		Name:                    "remove annotations no longer applied",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
 				r.Annotations["foo.bar/baz"] = "baz"
-				r.Labels["foo.bar/baz"] = "baz"
+				r.ManagedFields = []metav1.ManagedFieldsEntry{{
+					Manager:   FieldManager,
+					Operation: metav1.ManagedFieldsOperationApply,
+					FieldsV1: &metav1.FieldsV1{
+						Raw: []byte(`{"f:metadata":{"f:annotations":{"f:foo.bar/baz":{}}}}`),
+					},
+				}}
 			}),
-		}},
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
 	}, {
-		Name:                    "fix spec",
+		Name:                    "revert conflicting changes",
 		SkipNamespaceValidation: true,
 		Key:                     key,
 		Objects: []runtime.Object{
@@ -121,9 +167,56 @@ func TestKourierReconcile(t *testing.T) {
 				r.Spec.To.Kind = "foo"
 			}),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: route(ingressNamespace, routeName),
-		}},
+		WithReactors: []clientgotesting.ReactionFunc{conflictOnce(
+			`conflict with "kubectl-edit" using route.openshift.io/v1: .spec.to.kind`)},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			applyRoute(route(ingressNamespace, routeName)),
+			applyRoute(route(ingressNamespace, routeName)),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "RouteFieldConflict", "Reverting the changes to route %s(%s) by others: %s",
+				routeName, domainName, `conflict with "kubectl-edit" using route.openshift.io/v1: .spec.to.kind`),
+		},
+	}, {
+		Name:                    "don't report conflicts with the legacy field manager",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				r.Spec.To.Kind = "foo"
+			}),
+		},
+		WithReactors: []clientgotesting.ReactionFunc{conflictOnce(
+			fmt.Sprintf(`conflict with %q using route.openshift.io/v1: .spec.to.kind`, legacyFieldManager))},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			applyRoute(route(ingressNamespace, routeName)),
+			applyRoute(route(ingressNamespace, routeName)),
+		},
+	}, {
+		Name:                    "upgrade the managed fields of the legacy field manager",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				r.ResourceVersion = "1"
+				r.Annotations["foo.bar/baz"] = "baz"
+				r.ManagedFields = []metav1.ManagedFieldsEntry{
+					managedFields(legacyFieldManager, metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:annotations":{"f:foo.bar/baz":{}}}}`),
+					managedFields("kubectl-annotate", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:annotations":{"f:admin.example.com/note":{}}}}`),
+					managedFields(FieldManager, metav1.ManagedFieldsOperationApply, `{"f:spec":{"f:host":{}}}`),
+				}
+			}),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			upgradeManagedFields("1", []metav1.ManagedFieldsEntry{
+				managedFields("kubectl-annotate", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:annotations":{"f:admin.example.com/note":{}}}}`),
+				managedFields(FieldManager, metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:annotations":{"f:foo.bar/baz":{}}},"f:spec":{"f:host":{}}}`),
+			}),
+			// The annotation is owned by the controller now, and no longer set.
+			applyRoute(route(ingressNamespace, routeName)),
+		},
 	}, {
 		Name:                    "create nothing",
 		SkipNamespaceValidation: true,
@@ -133,6 +226,45 @@ func TestKourierReconcile(t *testing.T) {
 				i.Annotations[resources.DisableRouteAnnotation] = "true"
 			}),
 		},
+	}, {
+		Name:                    "dry-run create",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, func(i *v1alpha1.Ingress) {
+				i.Annotations[resources.DryRunAnnotation] = "true"
+			}),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "DryRunCreate", "Would create route %s(%s)", routeName, domainName),
+		},
+	}, {
+		Name:                    "dry-run update and delete",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, func(i *v1alpha1.Ingress) {
+				i.Annotations[resources.DryRunAnnotation] = "true"
+			}),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				r.Spec.To.Kind = "foo"
+			}),
+			route(ingressNamespace, "foo"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "DryRunUpdate", "Would update route %s(%s)", routeName, domainName),
+			Eventf(corev1.EventTypeNormal, "DryRunDelete", "Would delete route %s(%s)", "foo", domainName),
+		},
+	}, {
+		Name:                    "dry-run steady state",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, func(i *v1alpha1.Ingress) {
+				i.Annotations[resources.DryRunAnnotation] = "true"
+			}),
+			route(ingressNamespace, routeName),
+		},
 	}, {
 		Name:                    "add finalizer",
 		SkipNamespaceValidation: true,
@@ -186,8 +318,12 @@ func TestKourierReconcile(t *testing.T) {
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
 		r := &Reconciler{
-			routeClient: fakerouteclient.Get(ctx).RouteV1(),
-			routeLister: listers.GetRouteLister(),
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
 		}
 
 		ingr := ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
@@ -220,7 +356,7 @@ func TestIstioReconcile(t *testing.T) {
 		SkipNamespaceValidation: true,
 		Key:                     key,
 		Objects:                 []runtime.Object{ingIstio(ingNamespace, ingName)},
-		WantCreates:             []runtime.Object{routeIstio(ingressNamespace, routeName)},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(routeIstio(ingressNamespace, routeName))},
 	}, {
 		Name:                    "remove outdated routes",
 		SkipNamespaceValidation: true,
@@ -251,12 +387,10 @@ func TestIstioReconcile(t *testing.T) {
 				i.Labels["foo.bar/baz"] = "baz"
 			}),
 		},
-		WantCreates: []runtime.Object{
-			routeIstio(ingressNamespace, routeName, func(r *routev1.Route) {
-				r.Annotations["foo.bar/baz"] = "baz"
-				r.Labels["foo.bar/baz"] = "baz"
-			}),
-		},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(routeIstio(ingressNamespace, routeName, func(r *routev1.Route) {
+			r.Annotations["foo.bar/baz"] = "baz"
+			r.Labels["foo.bar/baz"] = "baz"
+		}))},
 	}, {
 		Name:                    "copy annotations and labels on update too",
 		SkipNamespaceValidation: true,
@@ -268,12 +402,10 @@ func TestIstioReconcile(t *testing.T) {
 			}),
 			routeIstio(ingressNamespace, routeName),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: routeIstio(ingressNamespace, routeName, func(r *routev1.Route) {
-				r.Annotations["foo.bar/baz"] = "baz"
-				r.Labels["foo.bar/baz"] = "baz"
-			}),
-		}},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(routeIstio(ingressNamespace, routeName, func(r *routev1.Route) {
+			r.Annotations["foo.bar/baz"] = "baz"
+			r.Labels["foo.bar/baz"] = "baz"
+		}))},
 	}, {
 		Name:                    "fix spec",
 		SkipNamespaceValidation: true,
@@ -284,9 +416,7 @@ func TestIstioReconcile(t *testing.T) {
 				r.Spec.To.Kind = "foo"
 			}),
 		},
-		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: routeIstio(ingressNamespace, routeName),
-		}},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(routeIstio(ingressNamespace, routeName))},
 	}, {
 		Name:                    "create nothing",
 		SkipNamespaceValidation: true,
@@ -349,8 +479,12 @@ func TestIstioReconcile(t *testing.T) {
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
 		r := &Reconciler{
-			routeClient: fakerouteclient.Get(ctx).RouteV1(),
-			routeLister: listers.GetRouteLister(),
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
 		}
 
 		ingr := ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
@@ -364,110 +498,895 @@ func TestIstioReconcile(t *testing.T) {
 	}))
 }
 
-type ingressOption func(*v1alpha1.Ingress)
+func TestObsoleteRouteMigration(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	oldDomainName := ingName + "." + ingNamespace + ".old.domainName"
 
-func ing(ns, name string, opts ...ingressOption) *v1alpha1.Ingress {
-	i := &v1alpha1.Ingress{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        name,
-			Namespace:   ns,
-			UID:         ingUID,
-			Labels:      map[string]string{serving.RouteNamespaceLabelKey: ns, serving.RouteLabelKey: name},
-			Annotations: map[string]string{networking.IngressClassAnnotationKey: kourierIngressClassName},
-			Finalizers:  []string{"ocp-ingress"},
-		},
-		Spec: v1alpha1.IngressSpec{
-			Rules: []v1alpha1.IngressRule{{
-				Hosts: []string{domainName},
-			}},
-		},
-		Status: v1alpha1.IngressStatus{
-			PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
-				Ingress: []v1alpha1.LoadBalancerIngressStatus{{
-					DomainInternal: svcName + "." + ingressNamespace + ".svc.cluster.local",
-				}},
-			},
-		},
+	oldRoute := func(opts ...routeOption) *routev1.Route {
+		return route(ingressNamespace, "old", append([]routeOption{func(r *routev1.Route) {
+			r.Spec.Host = oldDomainName
+		}}, opts...)...)
 	}
-	for _, opt := range opts {
-		opt(i)
+	obsoleteSince := func(since time.Time) routeOption {
+		return func(r *routev1.Route) {
+			resources.MarkObsolete(r, since)
+		}
+	}
+	migrationIngress := func() *v1alpha1.Ingress {
+		return resources.MakeMigrationIngress(ing(ingNamespace, ingName), []string{oldDomainName})
 	}
-	return i
-}
 
-func ingIstio(ns, name string, opts ...ingressOption) *v1alpha1.Ingress {
-	i := &v1alpha1.Ingress{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        name,
-			Namespace:   ns,
-			UID:         ingUID,
-			Labels:      map[string]string{serving.RouteNamespaceLabelKey: ns, serving.RouteLabelKey: name},
-			Annotations: map[string]string{networking.IngressClassAnnotationKey: istioIngressClassName},
-			Finalizers:  []string{"ocp-ingress"},
+	table := TableTest{{
+		Name:                    "retain obsolete route",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName),
+			oldRoute(),
 		},
-		Spec: v1alpha1.IngressSpec{
-			Rules: []v1alpha1.IngressRule{{
-				Hosts: []string{domainName},
-			}},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: oldRoute(obsoleteSince(now)),
+		}},
+		WantCreates: []runtime.Object{migrationIngress()},
+		// Requeued to remove the route once the grace period passed.
+		WantErr: true,
+	}, {
+		Name:                    "obsolete route within grace period",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName),
+			oldRoute(obsoleteSince(now.Add(-30 * time.Minute))),
+			migrationIngress(),
 		},
-		Status: v1alpha1.IngressStatus{
-			PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
-				Ingress: []v1alpha1.LoadBalancerIngressStatus{{
-					DomainInternal: svcName + "." + ingressNamespace + ".svc.cluster.local",
-				}},
-			},
+		WantErr: true,
+	}, {
+		Name:                    "obsolete route after grace period",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName),
+			oldRoute(obsoleteSince(now.Add(-2 * time.Hour))),
+			migrationIngress(),
 		},
-	}
-	for _, opt := range opts {
-		opt(i)
-	}
-	return i
-}
-
-type routeOption func(*routev1.Route)
-
-func route(ns, name string, opts ...routeOption) *routev1.Route {
-	r := &routev1.Route{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-			Labels: map[string]string{
-				networking.IngressLabelKey:                  "test",
-				serving.RouteLabelKey:                       "test",
-				serving.RouteNamespaceLabelKey:              "testNs",
-				resources.OpenShiftIngressLabelKey:          "test",
-				resources.OpenShiftIngressNamespaceLabelKey: "testNs",
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: ingressNamespace,
+				Resource:  routev1.GroupVersion.WithResource("routes"),
 			},
-			Annotations: map[string]string{
-				resources.TimeoutAnnotation:          resources.DefaultTimeout,
-				networking.IngressClassAnnotationKey: kourierIngressClassName,
+			Name: "old",
+		}, {
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: ingNamespace,
+				Resource:  v1alpha1.SchemeGroupVersion.WithResource("ingresses"),
 			},
+			Name: resources.MigrationIngressName(ing(ingNamespace, ingName)),
+		}},
+	}, {
+		Name:                    "routes of a recreated ingress",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, "route-a-306330363338"),
+			route(ingressNamespace, "route-b-306330363338"),
 		},
-		Spec: routev1.RouteSpec{
-			Host: domainName,
-			Port: &routev1.RoutePort{
-				TargetPort: intstr.FromString(resources.HTTPPort),
-			},
-			To: routev1.RouteTargetReference{
-				Kind:   "Service",
-				Name:   svcName,
-				Weight: ptr.Int32(100),
-			},
-			TLS: &routev1.TLSConfig{
-				Termination:                   routev1.TLSTerminationEdge,
-				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow,
+		// The first route is adopted while the other one conflicts on the host and is
+		// deleted regardless of the grace period.
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: ingressNamespace,
+				Resource:  routev1.GroupVersion.WithResource("routes"),
 			},
-			WildcardPolicy: routev1.WildcardPolicyNone,
+			Name: "route-b-306330363338",
+		}},
+	}, {
+		Name:                    "obsolete route without successor",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName),
+			oldRoute(obsoleteSince(now), func(r *routev1.Route) {
+				r.Spec.Host = "other." + ingNamespace + ".old.domainName"
+			}),
 		},
+		WantErr: true,
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.NewFakePassiveClock(now),
+		}
+
+		cfg := &config.Config{Route: &config.Route{
+			ExcludedDomains:      config.DefaultExcludedDomains(),
+			MigrationGracePeriod: time.Hour,
+		}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestRecreateRoutes(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	withRecreate := func(token string) ingressOption {
+		return func(i *v1alpha1.Ingress) {
+			i.Annotations[resources.RecreateRoutesAnnotation] = token
+		}
 	}
-	for _, opt := range opts {
-		opt(r)
+	createdAt := func(t time.Time) routeOption {
+		return func(r *routev1.Route) {
+			r.CreationTimestamp = metav1.NewTime(t)
+		}
+	}
+	recreatedFor := func(token string) routeOption {
+		return func(r *routev1.Route) {
+			r.Annotations[resources.RecreateRoutesAnnotation] = token
+		}
 	}
-	return r
-}
 
-func routeIstio(ns, name string, opts ...routeOption) *routev1.Route {
-	r := &routev1.Route{
+	table := TableTest{{
+		Name:                    "recreate routes",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, withRecreate("1")),
+			route(ingressNamespace, routeName, createdAt(now.Add(-time.Hour))),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: ingressNamespace,
+				Resource:  routev1.GroupVersion.WithResource("routes"),
+			},
+			Name: routeName,
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, recreatedFor("1")))},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "RecreatingRoute", "Recreating route %s(%s) as requested by %s", routeName, domainName, resources.RecreateRoutesAnnotation),
+		},
+	}, {
+		Name:                    "routes already recreated",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, withRecreate("1")),
+			route(ingressNamespace, routeName, createdAt(now.Add(-time.Minute)), recreatedFor("1")),
+		},
+	}, {
+		Name:                    "recreation throttled",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, withRecreate("2")),
+			route(ingressNamespace, routeName, createdAt(now.Add(-time.Minute)), recreatedFor("1")),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "RecreateRoutesThrottled", "Route %s(%s) was created %s ago, recreating it in %s", routeName, domainName, time.Minute, 4*time.Minute),
+		},
+		// Requeued to recreate the route once it's old enough.
+		WantErr: true,
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.NewFakePassiveClock(now),
+		}
+
+		cfg := &config.Config{Route: &config.Route{ExcludedDomains: config.DefaultExcludedDomains()}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestReportRouteURLs(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	urls := `[{"url":"https://` + domainName + `","routerCanonicalHostname":"router-default.apps.example.com"}]`
+
+	ksvc := func(annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(resources.ServiceGVR.GroupVersion().WithKind("Service"))
+		u.SetNamespace(ingNamespace)
+		u.SetName(ingName)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	ofService := func(i *v1alpha1.Ingress) {
+		i.Labels[serving.ServiceLabelKey] = ingName
+	}
+	admitted := func(r *routev1.Route) {
+		r.Labels[serving.ServiceLabelKey] = ingName
+		r.Status.Ingress = []routev1.RouteIngress{{
+			Host:                    domainName,
+			RouterCanonicalHostname: "router-default.apps.example.com",
+			Conditions: []routev1.RouteIngressCondition{{
+				Type:   routev1.RouteAdmitted,
+				Status: corev1.ConditionTrue,
+			}},
+		}}
+	}
+	patch := func(value string) clientgotesting.PatchActionImpl {
+		return clientgotesting.PatchActionImpl{
+			Name:       ingName,
+			ActionImpl: clientgotesting.ActionImpl{Namespace: ingNamespace, Resource: resources.ServiceGVR},
+			Patch:      []byte(`{"metadata":{"annotations":{"` + resources.RouteURLsAnnotation + `":` + value + `}}}`),
+		}
+	}
+
+	table := TableTest{{
+		Name:                    "report admitted route",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, ofService),
+			route(ingressNamespace, routeName, admitted),
+			ksvc(nil),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{patch(strconv.Quote(urls))},
+	}, {
+		Name:                    "already reported",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, ofService),
+			route(ingressNamespace, routeName, admitted),
+			ksvc(map[string]string{resources.RouteURLsAnnotation: urls}),
+		},
+	}, {
+		Name:                    "remove URLs of routes no longer admitted",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, ofService),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				r.Labels[serving.ServiceLabelKey] = ingName
+			}),
+			ksvc(map[string]string{resources.RouteURLsAnnotation: urls}),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{patch("null")},
+	}, {
+		Name:                    "service not found",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, ofService),
+			route(ingressNamespace, routeName, admitted),
+		},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
+		}
+
+		cfg := &config.Config{Route: &config.Route{ExcludedDomains: config.DefaultExcludedDomains()}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestGatewayAPIReconcile(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	cfg := &config.Config{Route: &config.Route{
+		ExcludedDomains: config.DefaultExcludedDomains(),
+		Output:          config.OutputGatewayAPI,
+		Gateway:         types.NamespacedName{Namespace: "gateways", Name: "external"},
+	}}
+
+	httpRoute := func() *unstructured.Unstructured {
+		httpRoutes, err := resources.MakeHTTPRoutes(ing(ingNamespace, ingName), cfg.Route)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return httpRoutes[0]
+	}
+
+	table := TableTest{{
+		Name:                    "steady state",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName), httpRoute()},
+	}, {
+		Name:                    "replace route with httproute",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName), route(ingressNamespace, routeName)},
+		WantCreates:             []runtime.Object{httpRoute()},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: ingressNamespace,
+				Resource:  routev1.GroupVersion.WithResource("routes"),
+			},
+			Name: routeName,
+		}},
+	}, {
+		Name:                    "fix httproute",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{ing(ingNamespace, ingName), func() *unstructured.Unstructured {
+			u := httpRoute()
+			unstructured.SetNestedStringSlice(u.Object, []string{"foo.example.com"}, "spec", "hostnames")
+			return u
+		}()},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: httpRoute(),
+		}},
+	}, {
+		Name:                    "route output selected by annotation",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, func(i *v1alpha1.Ingress) {
+				i.Annotations[resources.OutputAnnotation] = config.OutputRoute
+			}),
+			httpRoute(),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: ingressNamespace,
+				Resource:  resources.HTTPRouteGVR,
+			},
+			Name: routeName,
+		}},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
+		}
+
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestExternalRoutesReconcile(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	cfg := &config.Config{Route: &config.Route{
+		ExcludedDomains: config.DefaultExcludedDomains(),
+		Output:          config.OutputExternal,
+	}}
+
+	table := TableTest{{
+		Name:                    "steady state",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, "gitops", func(r *routev1.Route) {
+				r.Spec.To.Kind = "foo"
+			}),
+		},
+	}, {
+		Name:                    "report missing route",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName)},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "RouteMissing", "No externally managed route for %s", domainName),
+		},
+	}, {
+		Name:                    "route output not selectable by annotation",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, func(i *v1alpha1.Ingress) {
+				i.Annotations[resources.OutputAnnotation] = config.OutputRoute
+			}),
+			route(ingressNamespace, "gitops"),
+			route(ingressNamespace, "other", func(r *routev1.Route) {
+				r.Spec.Host = "other.example.com"
+			}),
+		},
+	}, {
+		Name:                    "keep routes on deletion",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, func(i *v1alpha1.Ingress) {
+				i.DeletionTimestamp = &metav1.Time{
+					Time: time.Now(),
+				}
+			}),
+			route(ingressNamespace, "gitops"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			{
+				Name:       ingName,
+				ActionImpl: clientgotesting.ActionImpl{Namespace: ingNamespace},
+				Patch:      []byte(`{"metadata":{"finalizers":[],"resourceVersion":""}}`),
+			},
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", ingName),
+		},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
+		}
+
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestExcludedHostRoutes(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+
+	// The Ingress got a new generation, which isn't confirmed by its status yet.
+	pending := func(i *v1alpha1.Ingress) {
+		i.Generation = 2
+		i.Status.ObservedGeneration = 1
+	}
+	deleteRoute := clientgotesting.DeleteActionImpl{
+		ActionImpl: clientgotesting.ActionImpl{
+			Namespace: ingressNamespace,
+			Resource:  routev1.GroupVersion.WithResource("routes"),
+		},
+		Name: routeName,
+	}
+
+	// The routes of hosts that became excluded are deleted right away, regardless of the
+	// grace period and the state of the Ingress.
+	table := TableTest{{
+		Name:                    "delete route of newly excluded host",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{deleteRoute},
+	}, {
+		Name:                    "delete route of newly excluded host while pending",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, pending),
+			route(ingressNamespace, routeName),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{deleteRoute},
+	}, {
+		Name:                    "delete obsolete route of newly excluded host",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, func(r *routev1.Route) {
+				resources.MarkObsolete(r, time.Now())
+			}),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{deleteRoute},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
+		}
+
+		cfg := &config.Config{Route: &config.Route{
+			ExcludedDomains:      append(config.DefaultExcludedDomains(), "default.domainName"),
+			MigrationGracePeriod: time.Hour,
+		}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestVisibilityTransition(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+
+	clusterLocal := func(i *v1alpha1.Ingress) {
+		i.Spec.Rules[0].Visibility = v1alpha1.IngressVisibilityClusterLocal
+	}
+	// The Ingress got a new generation, which isn't confirmed by its status yet.
+	pending := func(i *v1alpha1.Ingress) {
+		i.Generation = 2
+		i.Status.ObservedGeneration = 1
+	}
+	deleteRoute := clientgotesting.DeleteActionImpl{
+		ActionImpl: clientgotesting.ActionImpl{
+			Namespace: ingressNamespace,
+			Resource:  routev1.GroupVersion.WithResource("routes"),
+		},
+		Name: routeName,
+	}
+
+	table := TableTest{{
+		Name:                    "keep route until cluster-local is confirmed",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, clusterLocal, pending),
+			route(ingressNamespace, routeName),
+		},
+	}, {
+		Name:                    "delete route once cluster-local is confirmed",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, clusterLocal),
+			route(ingressNamespace, routeName),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{deleteRoute},
+	}, {
+		Name:                    "keep route when switching back to external",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, pending),
+			route(ingressNamespace, routeName),
+		},
+	}, {
+		Name:                    "create route when switching to external",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName, pending)},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
+	}, {
+		Name:                    "delete conflicting route while pending",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName, pending),
+			route(ingressNamespace, routeName),
+			route(ingressNamespace, "duplicate"),
+		},
+		// The host is still served by the route of the Ingress.
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: deleteRoute.ActionImpl,
+			Name:       "duplicate",
+		}},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
+		}
+
+		cfg := &config.Config{Route: &config.Route{
+			ExcludedDomains: config.DefaultExcludedDomains(),
+		}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestWildcardCertificateReconcile(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	const certName = "apps-wildcard"
+	cert := TLSSecret(defaultServingNamespace, certName, "*."+ingNamespace+".default.domainName")
+	rotated := TLSSecret(defaultServingNamespace, certName, "*."+ingNamespace+".default.domainName")
+	other := TLSSecret(defaultServingNamespace, certName, "*.example.com")
+
+	withCert := func(secret *corev1.Secret) routeOption {
+		return func(r *routev1.Route) {
+			r.Spec.TLS.Certificate = string(secret.Data[corev1.TLSCertKey])
+			r.Spec.TLS.Key = string(secret.Data[corev1.TLSPrivateKeyKey])
+		}
+	}
+
+	table := TableTest{{
+		Name:                    "create route with the wildcard certificate",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName), cert},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, withCert(cert)))},
+	}, {
+		Name:                    "rotate the wildcard certificate",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects: []runtime.Object{
+			ing(ingNamespace, ingName),
+			route(ingressNamespace, routeName, withCert(cert)),
+			rotated,
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, withCert(rotated)))},
+	}, {
+		Name:                    "certificate not covering the host",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName), other},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
+	}, {
+		Name:                    "missing certificate",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName)},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:   fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:   listers.GetRouteLister(),
+			ingressClient: networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister: listers.GetIngressLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			clock:         clock.RealClock{},
+			secretLister:  listers.GetSecretLister().Secrets(defaultServingNamespace),
+		}
+
+		cfg := &config.Config{Route: &config.Route{
+			ExcludedDomains:     config.DefaultExcludedDomains(),
+			WildcardCertificate: certName,
+		}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+func TestServiceCertificateReconcile(t *testing.T) {
+	key := ingNamespace + "/" + ingName
+	const certName = "apps-wildcard"
+	wildcard := TLSSecret(defaultServingNamespace, certName, "*."+ingNamespace+".default.domainName")
+	service := TLSSecret(ingNamespace, "my-cert", domainName)
+	elsewhere := TLSSecret("other", "my-cert", domainName)
+
+	withCert := func(secret *corev1.Secret) routeOption {
+		return func(r *routev1.Route) {
+			r.Spec.TLS.Certificate = string(secret.Data[corev1.TLSCertKey])
+			r.Spec.TLS.Key = string(secret.Data[corev1.TLSPrivateKeyKey])
+		}
+	}
+	withTLSSecret := func(i *v1alpha1.Ingress) {
+		i.Annotations[resources.TLSSecretAnnotation] = "my-cert"
+	}
+
+	table := TableTest{{
+		Name:                    "create route with the certificate of the service",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName, withTLSSecret), service},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, withCert(service)))},
+	}, {
+		Name:                    "certificate of the service takes precedence",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName, withTLSSecret), service, wildcard},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, withCert(service)))},
+	}, {
+		Name:                    "certificate in another namespace",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName, withTLSSecret), elsewhere, wildcard},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName, withCert(wildcard)))},
+	}, {
+		Name:                    "missing certificate of the service",
+		SkipNamespaceValidation: true,
+		Key:                     key,
+		Objects:                 []runtime.Object{ing(ingNamespace, ingName, withTLSSecret)},
+		WantPatches:             []clientgotesting.PatchActionImpl{applyRoute(route(ingressNamespace, routeName))},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &Reconciler{
+			routeClient:     fakerouteclient.Get(ctx).RouteV1(),
+			routeLister:     listers.GetRouteLister(),
+			ingressClient:   networkingclient.Get(ctx).NetworkingV1alpha1(),
+			ingressLister:   listers.GetIngressLister(),
+			dynamicClient:   dynamicclient.Get(ctx),
+			clock:           clock.RealClock{},
+			secretLister:    listers.GetSecretLister().Secrets(defaultServingNamespace),
+			tlsSecretLister: listers.GetSecretLister(),
+		}
+
+		cfg := &config.Config{Route: &config.Route{
+			ExcludedDomains:     config.DefaultExcludedDomains(),
+			WildcardCertificate: certName,
+		}}
+		return ingressreconciler.NewReconciler(ctx, logging.FromContext(ctx), networkingclient.Get(ctx),
+			listers.GetIngressLister(), controller.GetEventRecorder(ctx), r, kourierIngressClassName,
+			controller.Options{
+				SkipStatusUpdates: true,
+				FinalizerName:     "ocp-ingress",
+				ConfigStore:       &testConfigStore{config: cfg},
+			})
+	}))
+}
+
+type testConfigStore struct {
+	config *config.Config
+}
+
+func (t *testConfigStore) ToContext(ctx context.Context) context.Context {
+	return config.ToContext(ctx, t.config)
+}
+
+type ingressOption func(*v1alpha1.Ingress)
+
+func ing(ns, name string, opts ...ingressOption) *v1alpha1.Ingress {
+	i := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ns,
+			UID:         ingUID,
+			Labels:      map[string]string{serving.RouteNamespaceLabelKey: ns, serving.RouteLabelKey: name},
+			Annotations: map[string]string{networking.IngressClassAnnotationKey: kourierIngressClassName},
+			Finalizers:  []string{"ocp-ingress"},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{domainName},
+			}},
+		},
+		Status: v1alpha1.IngressStatus{
+			Status: duckv1.Status{
+				Conditions: duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}},
+			},
+			PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+				Ingress: []v1alpha1.LoadBalancerIngressStatus{{
+					DomainInternal: svcName + "." + ingressNamespace + ".svc.cluster.local",
+				}},
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+func ingIstio(ns, name string, opts ...ingressOption) *v1alpha1.Ingress {
+	i := &v1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ns,
+			UID:         ingUID,
+			Labels:      map[string]string{serving.RouteNamespaceLabelKey: ns, serving.RouteLabelKey: name},
+			Annotations: map[string]string{networking.IngressClassAnnotationKey: istioIngressClassName},
+			Finalizers:  []string{"ocp-ingress"},
+		},
+		Spec: v1alpha1.IngressSpec{
+			Rules: []v1alpha1.IngressRule{{
+				Hosts: []string{domainName},
+			}},
+		},
+		Status: v1alpha1.IngressStatus{
+			Status: duckv1.Status{
+				Conditions: duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}},
+			},
+			PublicLoadBalancer: &v1alpha1.LoadBalancerStatus{
+				Ingress: []v1alpha1.LoadBalancerIngressStatus{{
+					DomainInternal: svcName + "." + ingressNamespace + ".svc.cluster.local",
+				}},
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+type routeOption func(*routev1.Route)
+
+func route(ns, name string, opts ...routeOption) *routev1.Route {
+	r := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels: map[string]string{
+				networking.IngressLabelKey:                  "test",
+				serving.RouteLabelKey:                       "test",
+				serving.RouteNamespaceLabelKey:              "testNs",
+				resources.OpenShiftIngressLabelKey:          "test",
+				resources.OpenShiftIngressNamespaceLabelKey: "testNs",
+			},
+			Annotations: map[string]string{
+				resources.TimeoutAnnotation:          resources.DefaultTimeout,
+				networking.IngressClassAnnotationKey: kourierIngressClassName,
+			},
+		},
+		Spec: routev1.RouteSpec{
+			Host: domainName,
+			Port: &routev1.RoutePort{
+				TargetPort: intstr.FromString(resources.HTTPPort),
+			},
+			To: routev1.RouteTargetReference{
+				Kind:   "Service",
+				Name:   svcName,
+				Weight: ptr.Int32(100),
+			},
+			TLS: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationEdge,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow,
+			},
+			WildcardPolicy: routev1.WildcardPolicyNone,
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func routeIstio(ns, name string, opts ...routeOption) *routev1.Route {
+	r := &routev1.Route{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
@@ -505,3 +1424,69 @@ func routeIstio(ns, name string, opts ...routeOption) *routev1.Route {
 	}
 	return r
 }
+
+// applyRoute returns the action applying the given route.
+func applyRoute(route *routev1.Route) clientgotesting.PatchActionImpl {
+	patch, err := routeApplyPatch(route)
+	if err != nil {
+		panic(err)
+	}
+	return clientgotesting.PatchActionImpl{
+		ActionImpl: clientgotesting.ActionImpl{Namespace: route.Namespace},
+		Name:       route.Name,
+		PatchType:  types.ApplyPatchType,
+		Patch:      patch,
+	}
+}
+
+// managedFields returns the managed fields entry of the given manager and operation, owning
+// the given fields.
+func managedFields(manager string, operation metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  operation,
+		APIVersion: "route.openshift.io/v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+// upgradeManagedFields returns the action replacing the managed fields of the route with the
+// given ones, if it's still at the given resource version.
+func upgradeManagedFields(resourceVersion string, entries []metav1.ManagedFieldsEntry) clientgotesting.PatchActionImpl {
+	patch, err := json.Marshal([]map[string]interface{}{{
+		"op":    "test",
+		"path":  "/metadata/resourceVersion",
+		"value": resourceVersion,
+	}, {
+		"op":    "replace",
+		"path":  "/metadata/managedFields",
+		"value": entries,
+	}})
+	if err != nil {
+		panic(err)
+	}
+	return clientgotesting.PatchActionImpl{
+		ActionImpl: clientgotesting.ActionImpl{Namespace: ingressNamespace},
+		Name:       routeName,
+		PatchType:  types.JSONPatchType,
+		Patch:      patch,
+	}
+}
+
+// conflictOnce returns a reactor failing the first apply patch with a conflict of the given
+// cause, as if it wasn't forced.
+func conflictOnce(cause string) clientgotesting.ReactionFunc {
+	conflicted := false
+	return func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(clientgotesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType || conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, apierrs.NewApplyConflict([]metav1.StatusCause{{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: cause,
+		}}, "Apply failed with 1 conflict: "+cause)
+	}
+}
@@ -0,0 +1,96 @@
+package ingress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/metrics"
+)
+
+// routeAdmissionLatencyM is the time it takes the router to admit a route, which is when
+// the URL of the Knative Service becomes reachable.
+var routeAdmissionLatencyM = stats.Float64(
+	"route_admission_latencies",
+	"The time from the creation of a Knative Ingress to the admission of its routes by the router",
+	stats.UnitMilliseconds)
+
+func init() {
+	if err := view.Register(&view.View{
+		Description: routeAdmissionLatencyM.Description(),
+		Measure:     routeAdmissionLatencyM,
+		Aggregation: view.Distribution(metrics.Buckets125(100, 600000)...),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// admissionRecorder records the admission latency of each route once.
+type admissionRecorder struct {
+	// since excludes routes admitted earlier, like before a restart of the controller,
+	// which would otherwise be recorded again.
+	since time.Time
+
+	mu       sync.Mutex
+	recorded map[types.UID]time.Time
+}
+
+// record records the admission latency of the given route of the Ingress, unless it
+// isn't admitted yet or its admission was already recorded.
+func (a *admissionRecorder) record(ctx context.Context, ing *v1alpha1.Ingress, route *routev1.Route) {
+	admitted, ok := admissionTime(route)
+	if !ok || admitted.Before(a.since) {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if recorded, ok := a.recorded[route.UID]; ok && recorded.Equal(admitted) {
+		return
+	}
+	if a.recorded == nil {
+		a.recorded = make(map[types.UID]time.Time)
+	}
+	a.recorded[route.UID] = admitted
+
+	// Routes added to an existing Ingress, like for a new tag, are measured from their own
+	// creation instead.
+	created := ing.CreationTimestamp.Time
+	if route.CreationTimestamp.After(created) {
+		created = route.CreationTimestamp.Time
+	}
+	latency := admitted.Sub(created)
+	if latency < 0 {
+		latency = 0
+	}
+	metrics.Record(ctx, routeAdmissionLatencyM.M(float64(latency.Milliseconds())))
+}
+
+// forget drops the given route from the recorded admissions.
+func (a *admissionRecorder) forget(route *routev1.Route) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.recorded, route.UID)
+}
+
+// admissionTime returns when the route was first admitted by any of the routers.
+func admissionTime(route *routev1.Route) (time.Time, bool) {
+	var admitted time.Time
+	for _, ingress := range route.Status.Ingress {
+		for _, cond := range ingress.Conditions {
+			if cond.Type != routev1.RouteAdmitted || cond.Status != corev1.ConditionTrue || cond.LastTransitionTime == nil {
+				continue
+			}
+			if admitted.IsZero() || cond.LastTransitionTime.Time.Before(admitted) {
+				admitted = cond.LastTransitionTime.Time
+			}
+		}
+	}
+	return admitted, !admitted.IsZero()
+}
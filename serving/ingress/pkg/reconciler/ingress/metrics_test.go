@@ -0,0 +1,99 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"go.opencensus.io/stats/view"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	_ "knative.dev/pkg/metrics/testing"
+)
+
+func TestRecordAdmission(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	admitted := func(uid string, created, admitted time.Time) *routev1.Route {
+		r := route(ingressNamespace, routeName)
+		r.UID = types.UID(uid)
+		r.CreationTimestamp = metav1.NewTime(created)
+		if !admitted.IsZero() {
+			transition := metav1.NewTime(admitted)
+			r.Status.Ingress = []routev1.RouteIngress{{
+				Conditions: []routev1.RouteIngressCondition{{
+					Type:               routev1.RouteAdmitted,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: &transition,
+				}},
+			}}
+		}
+		return r
+	}
+
+	ingress := ing(ingNamespace, ingName)
+	ingress.CreationTimestamp = metav1.NewTime(now.Add(-10 * time.Second))
+
+	tests := []struct {
+		name      string
+		routes    []*routev1.Route
+		wantCount int64
+		wantSum   float64
+	}{{
+		name:   "not admitted",
+		routes: []*routev1.Route{admitted("a", now, time.Time{})},
+	}, {
+		name:      "admitted",
+		routes:    []*routev1.Route{admitted("a", now.Add(-10*time.Second), now)},
+		wantCount: 1,
+		wantSum:   10000,
+	}, {
+		name:      "admitted twice",
+		routes:    []*routev1.Route{admitted("a", now, now), admitted("a", now, now)},
+		wantCount: 1,
+	}, {
+		name:      "route created after the ingress",
+		routes:    []*routev1.Route{admitted("a", now.Add(-2*time.Second), now)},
+		wantCount: 1,
+		wantSum:   2000,
+	}, {
+		name:   "admitted before the controller started",
+		routes: []*routev1.Route{admitted("a", now.Add(-time.Hour), now.Add(-time.Hour))},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			view.Unregister(view.Find(routeAdmissionLatencyM.Name()))
+			if err := view.Register(&view.View{
+				Measure:     routeAdmissionLatencyM,
+				Aggregation: view.Distribution(),
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := &admissionRecorder{since: now.Add(-time.Minute)}
+			for _, r := range test.routes {
+				recorder.record(context.Background(), ingress, r)
+			}
+
+			rows, err := view.RetrieveData(routeAdmissionLatencyM.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var count int64
+			var sum float64
+			for _, row := range rows {
+				data := row.Data.(*view.DistributionData)
+				count += data.Count
+				sum += data.Sum()
+			}
+			if count != test.wantCount {
+				t.Errorf("Got %d recorded admissions, want %d", count, test.wantCount)
+			}
+			if test.wantSum != 0 && sum != test.wantSum {
+				t.Errorf("Got a total latency of %vms, want %vms", sum, test.wantSum)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
+)
+
+// RecreateRoutesInterval is the minimum age of a Route before it's recreated on request, so
+// flipping the annotation can't keep the router busy.
+const RecreateRoutesInterval = 5 * time.Minute
+
+// recreateRoutes deletes and creates anew the existing routes that were created for another
+// value of the RecreateRoutesAnnotation of the ingress. Routes younger than the
+// RecreateRoutesInterval are left alone for now, along with the value they were created for,
+// and the returned duration tells when to retry. The names of the recreated routes are
+// returned, as the listers don't know about them yet.
+func (r *Reconciler) recreateRoutes(ctx context.Context, ing *v1alpha1.Ingress, desired []*routev1.Route, existingMap map[string]*routev1.Route) (sets.String, time.Duration, error) {
+	recreated := sets.NewString()
+	token, ok := ing.Annotations[resources.RecreateRoutesAnnotation]
+	if !ok {
+		return recreated, 0, nil
+	}
+
+	logger := logging.FromContext(ctx)
+	recorder := controller.GetEventRecorder(ctx)
+	now := r.clock.Now()
+
+	var retryAfter time.Duration
+	for _, route := range desired {
+		existing, ok := existingMap[route.Name]
+		if !ok || existing.Annotations[resources.RecreateRoutesAnnotation] == token {
+			continue
+		}
+
+		if age := now.Sub(existing.CreationTimestamp.Time); age < RecreateRoutesInterval {
+			// Keep the value the route was created for, so the request isn't lost.
+			if previous, ok := existing.Annotations[resources.RecreateRoutesAnnotation]; ok {
+				route.Annotations[resources.RecreateRoutesAnnotation] = previous
+			} else {
+				delete(route.Annotations, resources.RecreateRoutesAnnotation)
+			}
+			remaining := RecreateRoutesInterval - age
+			recorder.Eventf(ing, corev1.EventTypeWarning, "RecreateRoutesThrottled",
+				"Route %s(%s) was created %s ago, recreating it in %s", existing.Name, existing.Spec.Host, age.Round(time.Second), remaining.Round(time.Second))
+			if retryAfter == 0 || remaining < retryAfter {
+				retryAfter = remaining
+			}
+			continue
+		}
+
+		logger.Infof("Recreating route %s(%s) as requested by %s=%q", existing.Name, existing.Spec.Host, resources.RecreateRoutesAnnotation, token)
+		recorder.Eventf(ing, corev1.EventTypeNormal, "RecreatingRoute",
+			"Recreating route %s(%s) as requested by %s", existing.Name, existing.Spec.Host, resources.RecreateRoutesAnnotation)
+		if err := r.deleteRoute(ctx, existing); err != nil {
+			return nil, 0, err
+		}
+		if err := r.applyRoute(ctx, ing, route); err != nil {
+			return nil, 0, fmt.Errorf("failed to recreate route: %w", err)
+		}
+		delete(existingMap, route.Name)
+		recreated.Insert(route.Name)
+	}
+	return recreated, retryAfter, nil
+}
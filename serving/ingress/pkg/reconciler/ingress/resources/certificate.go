@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// caCertKey is the optional key of a kubernetes.io/tls Secret holding the CA chain.
+const caCertKey = "ca.crt"
+
+// WildcardCertificate is a certificate Routes are edge terminated with, along with the hosts
+// it's valid for.
+type WildcardCertificate struct {
+	Certificate   string
+	Key           string
+	CACertificate string
+	// DNSNames are the names of the certificate, possibly wildcards like "*.example.com".
+	DNSNames []string
+}
+
+// NewWildcardCertificate reads the certificate of the given kubernetes.io/tls Secret.
+func NewWildcardCertificate(secret *corev1.Secret) (*WildcardCertificate, error) {
+	if secret.Type != corev1.SecretTypeTLS {
+		return nil, fmt.Errorf("secret %s/%s is of type %q, must be %q", secret.Namespace, secret.Name, secret.Type, corev1.SecretTypeTLS)
+	}
+	crt, key := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+	if len(crt) == 0 || len(key) == 0 {
+		return nil, fmt.Errorf("secret %s/%s must contain %s and %s", secret.Namespace, secret.Name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+	block, _ := pem.Decode(crt)
+	if block == nil {
+		return nil, errors.New("no PEM encoded certificate in " + corev1.TLSCertKey)
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", corev1.TLSCertKey, err)
+	}
+	return &WildcardCertificate{
+		Certificate:   string(crt),
+		Key:           string(key),
+		CACertificate: string(secret.Data[caCertKey]),
+		DNSNames:      parsed.DNSNames,
+	}, nil
+}
+
+// Covers returns true if the certificate is valid for the given host. Wildcards only match a
+// single label, as with TLS clients.
+func (c *WildcardCertificate) Covers(host string) bool {
+	host = strings.ToLower(host)
+	for _, name := range c.DNSNames {
+		name = strings.ToLower(name)
+		if name == host {
+			return true
+		}
+		if suffix := strings.TrimPrefix(name, "*"); suffix != name && strings.HasPrefix(suffix, ".") {
+			label := strings.TrimSuffix(host, suffix)
+			if label != host && label != "" && !strings.Contains(label, ".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApplyWildcardCertificate makes the edge terminated Routes of hosts covered by the given
+// certificate serve it instead of the router's default certificate.
+func ApplyWildcardCertificate(routes []*routev1.Route, cert *WildcardCertificate) {
+	for _, route := range routes {
+		tls := route.Spec.TLS
+		if tls == nil || tls.Termination != routev1.TLSTerminationEdge || !cert.Covers(route.Spec.Host) {
+			continue
+		}
+		tls.Certificate = cert.Certificate
+		tls.Key = cert.Key
+		tls.CACertificate = cert.CACertificate
+	}
+}
@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewWildcardCertificateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+	}{{
+		name:   "opaque secret",
+		secret: &corev1.Secret{Type: corev1.SecretTypeOpaque},
+	}, {
+		name: "missing key",
+		secret: &corev1.Secret{
+			Type: corev1.SecretTypeTLS,
+			Data: map[string][]byte{corev1.TLSCertKey: []byte("cert")},
+		},
+	}, {
+		name: "no certificate",
+		secret: &corev1.Secret{
+			Type: corev1.SecretTypeTLS,
+			Data: map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewWildcardCertificate(test.secret); err == nil {
+				t.Error("NewWildcardCertificate() = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWildcardCertificateCovers(t *testing.T) {
+	cert := &WildcardCertificate{DNSNames: []string{"*.apps.example.com", "exact.example.com"}}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "foo.apps.example.com", want: true},
+		{host: "FOO.Apps.Example.com", want: true},
+		{host: "exact.example.com", want: true},
+		{host: "apps.example.com"},
+		{host: "foo.bar.apps.example.com"},
+		{host: "other.example.com"},
+		{host: "fooapps.example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			if got := cert.Covers(test.host); got != test.want {
+				t.Errorf("Covers(%q) = %v, want %v", test.host, got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyWildcardCertificate(t *testing.T) {
+	cert := &WildcardCertificate{
+		Certificate:   "cert",
+		Key:           "key",
+		CACertificate: "ca",
+		DNSNames:      []string{"*.apps.example.com"},
+	}
+	makeRoute := func(host string, tls *routev1.TLSConfig) *routev1.Route {
+		return &routev1.Route{Spec: routev1.RouteSpec{Host: host, TLS: tls}}
+	}
+	edge := makeRoute("foo.apps.example.com", &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge})
+	uncovered := makeRoute("foo.example.com", &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge})
+	passthrough := makeRoute("bar.apps.example.com", &routev1.TLSConfig{Termination: routev1.TLSTerminationPassthrough})
+	plain := makeRoute("baz.apps.example.com", nil)
+
+	ApplyWildcardCertificate([]*routev1.Route{edge, uncovered, passthrough, plain}, cert)
+
+	if edge.Spec.TLS.Certificate != "cert" || edge.Spec.TLS.Key != "key" || edge.Spec.TLS.CACertificate != "ca" {
+		t.Errorf("TLS of the covered route = %+v, want the certificate", edge.Spec.TLS)
+	}
+	for _, route := range []*routev1.Route{uncovered, passthrough} {
+		if route.Spec.TLS.Certificate != "" || route.Spec.TLS.Key != "" {
+			t.Errorf("TLS of %s = %+v, want no certificate", route.Spec.Host, route.Spec.TLS)
+		}
+	}
+	if plain.Spec.TLS != nil {
+		t.Errorf("TLS of %s = %+v, want nil", plain.Spec.Host, plain.Spec.TLS)
+	}
+}
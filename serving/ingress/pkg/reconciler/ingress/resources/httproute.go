@@ -0,0 +1,92 @@
+package resources
+
+import (
+	"errors"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+
+	ingressconfig "github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
+)
+
+const (
+	// OutputAnnotation overrides the cluster-wide output of the ingress reconciler, see
+	// config.OutputKey, for a single Ingress.
+	OutputAnnotation = "serving.knative.openshift.io/ingressOutput"
+
+	// HTTPRouteBackendPort is the plain HTTP port of the gateway Services Routes point to.
+	HTTPRouteBackendPort = 80
+)
+
+// HTTPRouteGVR is the resource of the Gateway API HTTPRoutes generated in the gateway-api output.
+var HTTPRouteGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "httproutes"}
+
+// ErrPassthroughUnsupported indicates that an Ingress requires its TLS connections to be
+// passed through, which HTTPRoutes cannot do.
+var ErrPassthroughUnsupported = errors.New("passthrough TLS is not supported by the gateway-api output")
+
+// OutputFor returns the kind of resources generated for the given Ingress. Externally managed
+// Routes can't be opted out of per Ingress.
+func OutputFor(ci *networkingv1alpha1.Ingress, cfg *ingressconfig.Route) string {
+	if cfg.Output == ingressconfig.OutputExternal {
+		return cfg.Output
+	}
+	if output := ci.GetAnnotations()[OutputAnnotation]; output != "" {
+		return output
+	}
+	if cfg.Output == "" {
+		return ingressconfig.OutputRoute
+	}
+	return cfg.Output
+}
+
+// MakeHTTPRoutes creates Gateway API HTTPRoutes attached to the configured Gateway from a
+// Knative Ingress. They're equivalent to the OpenShift Routes created by MakeRoutes, except
+// for TLS being terminated by the Gateway rather than the Route.
+func MakeHTTPRoutes(ci *networkingv1alpha1.Ingress, cfg *ingressconfig.Route) ([]*unstructured.Unstructured, error) {
+	routes, err := MakeRoutes(ci, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRoutes := make([]*unstructured.Unstructured, 0, len(routes))
+	for _, route := range routes {
+		if route.Spec.TLS != nil && route.Spec.TLS.Termination == routev1.TLSTerminationPassthrough {
+			return nil, ErrPassthroughUnsupported
+		}
+		httpRoutes = append(httpRoutes, makeHTTPRoute(route, cfg))
+	}
+	return httpRoutes, nil
+}
+
+func makeHTTPRoute(route *routev1.Route, cfg *ingressconfig.Route) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{
+					"name":      cfg.Gateway.Name,
+					"namespace": cfg.Gateway.Namespace,
+				},
+			},
+			"hostnames": []interface{}{route.Spec.Host},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name": route.Spec.To.Name,
+							"port": int64(HTTPRouteBackendPort),
+						},
+					},
+				},
+			},
+		},
+	}}
+	u.SetAPIVersion(HTTPRouteGVR.GroupVersion().String())
+	u.SetKind("HTTPRoute")
+	u.SetName(route.Name)
+	u.SetNamespace(route.Namespace)
+	u.SetLabels(route.Labels)
+	return u
+}
@@ -0,0 +1,78 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
+)
+
+func TestMakeHTTPRoutes(t *testing.T) {
+	cfg := &config.Route{
+		ExcludedDomains: config.DefaultExcludedDomains(),
+		Output:          config.OutputGatewayAPI,
+		Gateway:         types.NamespacedName{Namespace: "gateways", Name: "external"},
+	}
+
+	httpRoutes, err := MakeHTTPRoutes(ingress(withRules(rule(withHosts([]string{localDomain, externalDomain})))), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(httpRoutes) != 1 {
+		t.Fatalf("len(httpRoutes) = %d, want 1", len(httpRoutes))
+	}
+
+	got := httpRoutes[0]
+	if got.GetName() != routeName0 || got.GetNamespace() != lbNamespace {
+		t.Errorf("HTTPRoute = %s/%s, want %s/%s", got.GetNamespace(), got.GetName(), lbNamespace, routeName0)
+	}
+	if got.GetLabels()[OpenShiftIngressLabelKey] != "ingress" {
+		t.Errorf("HTTPRoute labels = %v, want the ingress labels", got.GetLabels())
+	}
+	want := map[string]interface{}{
+		"parentRefs": []interface{}{
+			map[string]interface{}{"name": "external", "namespace": "gateways"},
+		},
+		"hostnames": []interface{}{externalDomain},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": lbService, "port": int64(HTTPRouteBackendPort)},
+				},
+			},
+		},
+	}
+	if !cmp.Equal(got.Object["spec"], want) {
+		t.Errorf("Got = %v, want: %v, diff:\n%s", got.Object["spec"], want, cmp.Diff(got.Object["spec"], want))
+	}
+}
+
+func TestMakeHTTPRoutesPassthrough(t *testing.T) {
+	cfg := &config.Route{
+		ExcludedDomains: config.DefaultExcludedDomains(),
+		Gateway:         types.NamespacedName{Namespace: "gateways", Name: "external"},
+	}
+	_, err := MakeHTTPRoutes(ingress(withPassthroughAnnotation, withRules(rule(withHosts([]string{externalDomain})))), cfg)
+	if err != ErrPassthroughUnsupported {
+		t.Errorf("MakeHTTPRoutes() = %v, want %v", err, ErrPassthroughUnsupported)
+	}
+}
+
+func TestOutputFor(t *testing.T) {
+	cfg := &config.Route{Output: config.OutputGatewayAPI}
+	if got := OutputFor(ingress(), cfg); got != config.OutputGatewayAPI {
+		t.Errorf("OutputFor() = %q, want %q", got, config.OutputGatewayAPI)
+	}
+	if got := OutputFor(ingress(withAnnotation(OutputAnnotation, config.OutputRoute)), cfg); got != config.OutputRoute {
+		t.Errorf("OutputFor() = %q, want %q", got, config.OutputRoute)
+	}
+	if got := OutputFor(ingress(), &config.Route{}); got != config.OutputRoute {
+		t.Errorf("OutputFor() = %q, want %q", got, config.OutputRoute)
+	}
+	external := &config.Route{Output: config.OutputExternal}
+	if got := OutputFor(ingress(withAnnotation(OutputAnnotation, config.OutputRoute)), external); got != config.OutputExternal {
+		t.Errorf("OutputFor() = %q, want %q", got, config.OutputExternal)
+	}
+}
@@ -0,0 +1,110 @@
+package resources
+
+import (
+	"strings"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/networking/pkg/apis/networking"
+	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/kmeta"
+)
+
+const (
+	// ObsoleteSinceAnnotation records when a Route's host stopped being part of its Ingress,
+	// e.g. because config-domain changed. The Route is retained for the migration grace
+	// period counted from then.
+	ObsoleteSinceAnnotation = "serving.knative.openshift.io/obsoleteSince"
+
+	// ObsoleteLabelKey marks Routes retained for the migration grace period, to allow
+	// tracking the progress of a migration.
+	ObsoleteLabelKey = "serving.knative.openshift.io/obsolete"
+)
+
+// ObsoleteSince returns the time the given Route became obsolete and whether it's marked
+// as obsolete at all.
+func ObsoleteSince(route *routev1.Route) (time.Time, bool) {
+	since, err := time.Parse(time.RFC3339, route.Annotations[ObsoleteSinceAnnotation])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+// MarkObsolete marks the given Route as obsolete since the given time.
+func MarkObsolete(route *routev1.Route, since time.Time) {
+	route.Annotations = kmeta.UnionMaps(route.Annotations, map[string]string{
+		ObsoleteSinceAnnotation: since.UTC().Format(time.RFC3339),
+	})
+	route.Labels = kmeta.UnionMaps(route.Labels, map[string]string{
+		ObsoleteLabelKey: "true",
+	})
+}
+
+// MigrationIngressName returns the name of the Ingress keeping the obsolete hosts of the
+// given Ingress served.
+func MigrationIngressName(ci *networkingv1alpha1.Ingress) string {
+	return kmeta.ChildName(ci.Name, "-migration")
+}
+
+// MakeMigrationIngress creates an Ingress routing the given obsolete hosts like their
+// successors in the given Ingress. That keeps the retained Routes of the obsolete hosts
+// working, as the gateway only accepts the hosts of an Ingress.
+//
+// An obsolete host is paired with the hosts of a rule by its first label, which stays the
+// same as long as only the domain changes. Hosts without a successor are skipped. Returns
+// nil if no host could be paired.
+func MakeMigrationIngress(ci *networkingv1alpha1.Ingress, obsoleteHosts []string) *networkingv1alpha1.Ingress {
+	var rules []networkingv1alpha1.IngressRule
+	for _, rule := range ci.Spec.Rules {
+		if rule.Visibility == networkingv1alpha1.IngressVisibilityClusterLocal {
+			continue
+		}
+		var hosts []string
+		for _, host := range obsoleteHosts {
+			if hasSuccessor(host, rule.Hosts) {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+		migrated := *rule.DeepCopy()
+		migrated.Hosts = hosts
+		rules = append(rules, migrated)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return &networkingv1alpha1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MigrationIngressName(ci),
+			Namespace: ci.Namespace,
+			Annotations: map[string]string{
+				networking.IngressClassAnnotationKey: ci.Annotations[networking.IngressClassAnnotationKey],
+				// The obsolete hosts are still served by the retained Routes.
+				DisableRouteAnnotation: "true",
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(ci)},
+		},
+		Spec: networkingv1alpha1.IngressSpec{
+			Rules:      rules,
+			HTTPOption: ci.Spec.HTTPOption,
+		},
+	}
+}
+
+func hasSuccessor(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if firstLabel(h) == firstLabel(host) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLabel(host string) string {
+	return strings.SplitN(host, ".", 2)[0]
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 
 	routev1 "github.com/openshift/api/route/v1"
@@ -14,16 +16,53 @@ import (
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/ptr"
 	"knative.dev/serving/pkg/apis/config"
+
+	ingressconfig "github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
 )
 
 const (
 	TimeoutAnnotation                = "haproxy.router.openshift.io/timeout"
 	DisableRouteAnnotation           = "serving.knative.openshift.io/disableRoute"
 	EnablePassthroughRouteAnnotation = "serving.knative.openshift.io/enablePassthrough"
+	DryRunAnnotation                 = "serving.knative.openshift.io/dryRun"
+	HSTSHeaderAnnotation             = "haproxy.router.openshift.io/hsts_header"
+	DisableHSTSAnnotation            = "serving.knative.openshift.io/disableHSTS"
+	DisableTLSAnnotation             = "serving.knative.openshift.io/disableTLS"
+	IPWhitelistAnnotation            = "haproxy.router.openshift.io/ip_whitelist"
+	IPAllowlistAnnotation            = "serving.knative.openshift.io/ipAllowlist"
+
+	// TLSSecretAnnotation names a kubernetes.io/tls Secret in the namespace of a Knative
+	// Service, whose certificate its edge terminated Routes serve instead of the wildcard or
+	// the router's default certificate.
+	TLSSecretAnnotation = "serving.knative.openshift.io/tlsSecret"
+
+	// RateLimitAnnotation enables the rate limits of the OpenShift router on a Route. The
+	// limits themselves are set through the annotations prefixed with it. Knative Services
+	// setting it opt out of the limits configured in config-network, or override them
+	// individually.
+	RateLimitAnnotation              = "haproxy.router.openshift.io/rate-limit-connections"
+	RateLimitConcurrentTCPAnnotation = RateLimitAnnotation + ".concurrent-tcp"
+	RateLimitHTTPAnnotation          = RateLimitAnnotation + ".rate-http"
+	RateLimitTCPAnnotation           = RateLimitAnnotation + ".rate-tcp"
+
+	// TargetPortAnnotation sets the port, by name or number, of the gateway Service the Routes
+	// of a Knative Service target, overriding the port selected by the TLS termination and
+	// the openshift-route-http(s)-target-port keys of config-network.
+	TargetPortAnnotation = "serving.knative.openshift.io/targetPort"
+
+	// RecreateRoutesAnnotation forces the Routes of an Ingress to be deleted and created anew
+	// whenever its value changes. It's kept on the Routes to tell which value they were
+	// created for.
+	RecreateRoutesAnnotation = "serving.knative.openshift.io/recreateRoutes"
 
 	HTTPPort  = "http2"
 	HTTPSPort = "https"
 
+	// ContourHTTPPort is the plain HTTP port of Contour's Envoy service.
+	ContourHTTPPort = "http"
+
+	contourIngressClassName = "contour.ingress.networking.knative.dev"
+
 	OpenShiftIngressLabelKey          = "serving.knative.openshift.io/ingressName"
 	OpenShiftIngressNamespaceLabelKey = "serving.knative.openshift.io/ingressNamespace"
 )
@@ -36,8 +75,13 @@ var DefaultTimeout = fmt.Sprintf("%vs", config.DefaultMaxRevisionTimeoutSeconds)
 // said field does not contain a value we can work with.
 var ErrNoValidLoadbalancerDomain = errors.New("unable to find Ingress LoadBalancer with DomainInternal set")
 
-// MakeRoutes creates OpenShift Routes from a Knative Ingress
-func MakeRoutes(ci *networkingv1alpha1.Ingress) ([]*routev1.Route, error) {
+// ErrInsecurePassthrough indicates that an Ingress requires its TLS connections to be passed
+// through but also opted out of TLS.
+var ErrInsecurePassthrough = errors.New(DisableTLSAnnotation + " cannot be combined with passthrough TLS")
+
+// MakeRoutes creates OpenShift Routes from a Knative Ingress according to the given
+// configuration. No Routes are created for hosts within the excluded domains.
+func MakeRoutes(ci *networkingv1alpha1.Ingress, cfg *ingressconfig.Route) ([]*routev1.Route, error) {
 	routes := []*routev1.Route{}
 
 	for _, rule := range ci.Spec.Rules {
@@ -47,29 +91,86 @@ func MakeRoutes(ci *networkingv1alpha1.Ingress) ([]*routev1.Route, error) {
 		}
 		for _, host := range rule.Hosts {
 			// Ignore domains like myksvc.myproject.svc.cluster.local
-			parts := strings.Split(host, ".")
-			if len(parts) == 2 || (len(parts) > 2 && parts[2] != "svc") {
-				route, err := makeRoute(ci, host, rule)
-				if err != nil {
-					return nil, err
-				}
-				if route == nil {
-					continue
-				}
-				routes = append(routes, route)
+			if IsExcluded(host, cfg.ExcludedDomains) {
+				continue
+			}
+			route, err := makeRoute(ci, host, rule, cfg)
+			if err != nil {
+				return nil, err
 			}
+			if route == nil {
+				continue
+			}
+			routes = append(routes, route)
 		}
 	}
 
 	return routes, nil
 }
 
-func makeRoute(ci *networkingv1alpha1.Ingress, host string, rule networkingv1alpha1.IngressRule) (*routev1.Route, error) {
-	// Take over annotaitons from ingress.
-	annotations := ci.GetAnnotations()
-	if annotations == nil {
-		annotations = make(map[string]string)
+// httpPort returns the plain HTTP port of the service the given Ingress is exposed through,
+// which depends on its class. The configured port only applies to Kourier.
+func httpPort(ci *networkingv1alpha1.Ingress, cfg *ingressconfig.Route) intstr.IntOrString {
+	if ci.GetAnnotations()[networking.IngressClassAnnotationKey] == contourIngressClassName {
+		return intstr.FromString(ContourHTTPPort)
+	}
+	if cfg.HTTPTargetPort != nil {
+		return *cfg.HTTPTargetPort
+	}
+	return intstr.FromString(HTTPPort)
+}
+
+// httpsPort returns the HTTPS port of the service the given Ingress is exposed through.
+func httpsPort(cfg *ingressconfig.Route) intstr.IntOrString {
+	if cfg.HTTPSTargetPort != nil {
+		return *cfg.HTTPSTargetPort
+	}
+	return intstr.FromString(HTTPSPort)
+}
+
+// applyRateLimit adds the configured rate limits to the given annotations of a Route, unless
+// they're set already. Routes that disable the rate limits through the RateLimitAnnotation
+// are left alone.
+func applyRateLimit(annotations map[string]string, limit ingressconfig.RateLimit) {
+	if limit == (ingressconfig.RateLimit{}) {
+		return
+	}
+	if enabled, err := strconv.ParseBool(annotations[RateLimitAnnotation]); err == nil && !enabled {
+		return
+	}
+	for key, value := range map[string]int{
+		RateLimitConcurrentTCPAnnotation: limit.ConcurrentTCP,
+		RateLimitHTTPAnnotation:          limit.RateHTTP,
+		RateLimitTCPAnnotation:           limit.RateTCP,
+	} {
+		if _, ok := annotations[key]; !ok && value > 0 {
+			annotations[key] = strconv.Itoa(value)
+		}
+	}
+	annotations[RateLimitAnnotation] = "true"
+}
+
+// IsExcluded returns true if the host is a single label or within any of the given domains.
+func IsExcluded(host string, excludedDomains []string) bool {
+	if !strings.Contains(host, ".") {
+		return true
+	}
+	for _, domain := range excludedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
 	}
+	return false
+}
+
+func makeRoute(ci *networkingv1alpha1.Ingress, host string, rule networkingv1alpha1.IngressRule, cfg *ingressconfig.Route) (*routev1.Route, error) {
+	// Take over annotaitons from ingress, except for the ones only relevant to the ingress
+	// reconciler itself.
+	annotations := kmeta.FilterMap(ci.GetAnnotations(), func(key string) bool {
+		return key == DryRunAnnotation || key == DisableHSTSAnnotation || key == DisableTLSAnnotation ||
+			key == OutputAnnotation || key == IPAllowlistAnnotation || key == RouteURLsAnnotation ||
+			key == TLSSecretAnnotation || key == TargetPortAnnotation
+	})
 
 	// Skip making route when visibility of the rule is local only.
 	if rule.Visibility == networkingv1alpha1.IngressVisibilityClusterLocal {
@@ -83,8 +184,23 @@ func makeRoute(ci *networkingv1alpha1.Ingress, host string, rule networkingv1alp
 
 	// Set timeout for OpenShift Route
 	annotations[TimeoutAnnotation] = DefaultTimeout
+	if cfg.Timeout > 0 {
+		annotations[TimeoutAnnotation] = fmt.Sprintf("%ds", int64(cfg.Timeout.Seconds()))
+	}
+
+	// The allowlist takes precedence over a whitelist set on the Knative Service directly.
+	if raw, ok := ci.GetAnnotations()[IPAllowlistAnnotation]; ok {
+		allowlist, err := ParseIPAllowlist(raw)
+		if err != nil {
+			return nil, err
+		}
+		annotations[IPWhitelistAnnotation] = allowlist
+	}
 
-	labels := kmeta.UnionMaps(ci.Labels, map[string]string{
+	applyRateLimit(annotations, cfg.RateLimit)
+
+	// The configured labels are overridden by those of the Ingress and the controller.
+	labels := kmeta.UnionMaps(cfg.Labels, ci.Labels, map[string]string{
 		networking.IngressLabelKey:        ci.GetName(),
 		OpenShiftIngressLabelKey:          ci.GetName(),
 		OpenShiftIngressNamespaceLabelKey: ci.GetNamespace(),
@@ -111,10 +227,17 @@ func makeRoute(ci *networkingv1alpha1.Ingress, host string, rule networkingv1alp
 		return nil, ErrNoValidLoadbalancerDomain
 	}
 
-	terminationPolicy := routev1.InsecureEdgeTerminationPolicyAllow
-	if ci.Spec.HTTPOption == networkingv1alpha1.HTTPOptionRedirected {
+	// Ingresses without an HTTPOption get the cluster-wide policy.
+	terminationPolicy := cfg.InsecurePolicy
+	switch ci.Spec.HTTPOption {
+	case networkingv1alpha1.HTTPOptionEnabled:
+		terminationPolicy = routev1.InsecureEdgeTerminationPolicyAllow
+	case networkingv1alpha1.HTTPOptionRedirected:
 		terminationPolicy = routev1.InsecureEdgeTerminationPolicyRedirect
 	}
+	if terminationPolicy == "" {
+		terminationPolicy = routev1.InsecureEdgeTerminationPolicyAllow
+	}
 
 	// TODO: Remove this annotation handling after serving 0.26+.
 	// Ingress configures the HTTPOption based on the annotation.
@@ -141,7 +264,7 @@ func makeRoute(ci *networkingv1alpha1.Ingress, host string, rule networkingv1alp
 		Spec: routev1.RouteSpec{
 			Host: host,
 			Port: &routev1.RoutePort{
-				TargetPort: intstr.FromString(HTTPPort),
+				TargetPort: httpPort(ci, cfg),
 			},
 			To: routev1.RouteTargetReference{
 				Kind:   "Service",
@@ -159,15 +282,69 @@ func makeRoute(ci *networkingv1alpha1.Ingress, host string, rule networkingv1alp
 	// Target the HTTPS port and configure passthrough when:
 	// * the passthrough annotation is set.
 	// * the ingress.spec.tls is set. (DomainMapping with BYP cert.)
-	if _, ok := annotations[EnablePassthroughRouteAnnotation]; ok || len(ci.Spec.TLS) > 0 {
-		route.Spec.Port.TargetPort = intstr.FromString(HTTPSPort)
+	_, passthrough := annotations[EnablePassthroughRouteAnnotation]
+	passthrough = passthrough || len(ci.Spec.TLS) > 0
+	if passthrough {
+		route.Spec.Port.TargetPort = httpsPort(cfg)
 		route.Spec.TLS.Termination = routev1.TLSTerminationPassthrough
 		route.Spec.TLS.InsecureEdgeTerminationPolicy = routev1.InsecureEdgeTerminationPolicyRedirect
 	}
 
+	if raw, ok := ci.GetAnnotations()[TargetPortAnnotation]; ok {
+		port, err := ingressconfig.ParseTargetPort(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", TargetPortAnnotation, err)
+		}
+		route.Spec.Port.TargetPort = port
+	}
+
+	// Serve plain HTTP only, e.g. for routers without certificates, when the annotation is set.
+	if _, ok := ci.GetAnnotations()[DisableTLSAnnotation]; ok {
+		if passthrough {
+			return nil, ErrInsecurePassthrough
+		}
+		route.Spec.TLS = nil
+		return route, nil
+	}
+
+	// The router can only add the HSTS header to connections it terminates. A header set on
+	// the Knative Service directly takes precedence.
+	_, optOut := ci.GetAnnotations()[DisableHSTSAnnotation]
+	_, custom := annotations[HSTSHeaderAnnotation]
+	if cfg.HSTSHeader != "" && !optOut && !custom && route.Spec.TLS.Termination == routev1.TLSTerminationEdge {
+		annotations[HSTSHeaderAnnotation] = cfg.HSTSHeader
+	}
+
 	return route, nil
 }
 
+// ParseIPAllowlist validates the given value of the IPAllowlistAnnotation, a space or comma
+// separated list of the IPs and CIDRs the routes of a Knative Service are reachable from, and
+// returns it in the space separated format of the router.
+func ParseIPAllowlist(raw string) (string, error) {
+	entries := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if len(entries) == 0 {
+		return "", fmt.Errorf("%s must contain at least one IP or CIDR", IPAllowlistAnnotation)
+	}
+	for _, entry := range entries {
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			return "", fmt.Errorf("%s contains %q, which is neither an IP nor a CIDR", IPAllowlistAnnotation, entry)
+		}
+	}
+	return strings.Join(entries, " "), nil
+}
+
+// IsDryRun returns true if the Routes of the given Ingress should not be changed but only the
+// changes that would be made should be reported.
+func IsDryRun(ci *networkingv1alpha1.Ingress) bool {
+	return strings.EqualFold(ci.GetAnnotations()[DryRunAnnotation], "true")
+}
+
 func routeName(uid, host string) string {
 	return fmt.Sprintf("route-%s-%x", uid, hashHost(host))
 }
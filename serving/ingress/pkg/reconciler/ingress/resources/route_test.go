@@ -2,7 +2,9 @@ package resources
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	routev1 "github.com/openshift/api/route/v1"
@@ -12,6 +14,8 @@ import (
 	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/pkg/ptr"
 	"knative.dev/serving/pkg/apis/serving"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/config"
 )
 
 const (
@@ -29,10 +33,11 @@ const (
 
 func TestMakeRoute(t *testing.T) {
 	tests := []struct {
-		name    string
-		ingress *networkingv1alpha1.Ingress
-		want    []*routev1.Route
-		wantErr error
+		name     string
+		ingress  *networkingv1alpha1.Ingress
+		excluded []string
+		want     []*routev1.Route
+		wantErr  error
 	}{
 		{
 			name:    "no rules",
@@ -46,6 +51,14 @@ func TestMakeRoute(t *testing.T) {
 			),
 			want: []*routev1.Route{},
 		},
+		{
+			name: "skip excluded domain",
+			ingress: ingress(withRules(
+				rule(withHosts([]string{localDomain, externalDomain}))),
+			),
+			excluded: []string{"default.domainName"},
+			want:     []*routev1.Route{},
+		},
 		{
 			name: "valid, default timeout",
 			ingress: ingress(withRules(
@@ -84,6 +97,47 @@ func TestMakeRoute(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "contour, routes to envoy",
+			ingress: ingress(
+				withAnnotation(networking.IngressClassAnnotationKey, contourIngressClassName),
+				withLBInternalDomain("envoy.contour-external.svc.cluster.local"),
+				withRules(rule(withHosts([]string{localDomain, externalDomain}))),
+			),
+			want: []*routev1.Route{{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						networking.IngressLabelKey:        "ingress",
+						serving.RouteLabelKey:             "route1",
+						serving.RouteNamespaceLabelKey:    "default",
+						OpenShiftIngressLabelKey:          "ingress",
+						OpenShiftIngressNamespaceLabelKey: "default",
+					},
+					Annotations: map[string]string{
+						networking.IngressClassAnnotationKey: contourIngressClassName,
+						TimeoutAnnotation:                    DefaultTimeout,
+					},
+					Namespace: "contour-external",
+					Name:      routeName0,
+				},
+				Spec: routev1.RouteSpec{
+					Host: externalDomain,
+					To: routev1.RouteTargetReference{
+						Kind:   "Service",
+						Name:   "envoy",
+						Weight: ptr.Int32(100),
+					},
+					Port: &routev1.RoutePort{
+						TargetPort: intstr.FromString(ContourHTTPPort),
+					},
+					TLS: &routev1.TLSConfig{
+						Termination:                   routev1.TLSTerminationEdge,
+						InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyAllow,
+					},
+					WildcardPolicy: routev1.WildcardPolicyNone,
+				},
+			}},
+		},
 		{
 			name: "valid but disabled",
 			ingress: ingress(withDisabledAnnotation, withRules(
@@ -416,7 +470,8 @@ func TestMakeRoute(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			routes, err := MakeRoutes(test.ingress)
+			cfg := &config.Route{ExcludedDomains: append(config.DefaultExcludedDomains(), test.excluded...)}
+			routes, err := MakeRoutes(test.ingress, cfg)
 			if test.want != nil && !cmp.Equal(routes, test.want) {
 				t.Errorf("got = %v, want: %v, diff: %s", routes, test.want, cmp.Diff(routes, test.want))
 			}
@@ -427,6 +482,392 @@ func TestMakeRoute(t *testing.T) {
 	}
 }
 
+func TestMakeRouteHSTS(t *testing.T) {
+	const header = "max-age=31536000;includeSubDomains"
+
+	tests := []struct {
+		name    string
+		ingress *networkingv1alpha1.Ingress
+		header  string
+		want    string
+	}{{
+		name:    "no header configured",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+	}, {
+		name:    "header configured",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		header:  header,
+		want:    header,
+	}, {
+		name:    "opted out",
+		ingress: ingress(withAnnotation(DisableHSTSAnnotation, ""), withRules(rule(withHosts([]string{externalDomain})))),
+		header:  header,
+	}, {
+		name:    "custom header",
+		ingress: ingress(withAnnotation(HSTSHeaderAnnotation, "max-age=1"), withRules(rule(withHosts([]string{externalDomain})))),
+		header:  header,
+		want:    "max-age=1",
+	}, {
+		name:    "passthrough",
+		ingress: ingress(withPassthroughAnnotation, withRules(rule(withHosts([]string{externalDomain})))),
+		header:  header,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Route{ExcludedDomains: config.DefaultExcludedDomains(), HSTSHeader: test.header}
+			routes, err := MakeRoutes(test.ingress, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(routes) != 1 {
+				t.Fatalf("len(routes) = %d, want 1", len(routes))
+			}
+			if got := routes[0].Annotations[HSTSHeaderAnnotation]; got != test.want {
+				t.Errorf("%s = %q, want %q", HSTSHeaderAnnotation, got, test.want)
+			}
+			if _, ok := routes[0].Annotations[DisableHSTSAnnotation]; ok {
+				t.Errorf("%s should not be taken over", DisableHSTSAnnotation)
+			}
+		})
+	}
+}
+
+func TestMakeRouteTimeout(t *testing.T) {
+	ing := ingress(withRules(rule(withHosts([]string{externalDomain}))))
+	for _, test := range []struct {
+		timeout time.Duration
+		want    string
+	}{{
+		want: DefaultTimeout,
+	}, {
+		timeout: time.Hour,
+		want:    "3600s",
+	}} {
+		cfg := &config.Route{ExcludedDomains: config.DefaultExcludedDomains(), Timeout: test.timeout}
+		routes, err := MakeRoutes(ing, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routes) != 1 {
+			t.Fatalf("len(routes) = %d, want 1", len(routes))
+		}
+		if got := routes[0].Annotations[TimeoutAnnotation]; got != test.want {
+			t.Errorf("%s = %q, want %q", TimeoutAnnotation, got, test.want)
+		}
+	}
+}
+
+func TestMakeRouteLabels(t *testing.T) {
+	ing := ingress(withRules(rule(withHosts([]string{externalDomain}))))
+	cfg := &config.Route{
+		ExcludedDomains: config.DefaultExcludedDomains(),
+		Labels: map[string]string{
+			"team":                     "payments",
+			networking.IngressLabelKey: "overridden",
+		},
+	}
+	routes, err := MakeRoutes(ing, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 {
+		t.Fatalf("len(routes) = %d, want 1", len(routes))
+	}
+	if got := routes[0].Labels["team"]; got != "payments" {
+		t.Errorf("team label = %q, want %q", got, "payments")
+	}
+	if got := routes[0].Labels[networking.IngressLabelKey]; got != ing.Name {
+		t.Errorf("%s = %q, want %q", networking.IngressLabelKey, got, ing.Name)
+	}
+}
+
+func TestMakeRouteTargetPort(t *testing.T) {
+	httpAlt, https := intstr.FromInt(8081), intstr.FromInt(8443)
+	tests := []struct {
+		name    string
+		ingress *networkingv1alpha1.Ingress
+		http    *intstr.IntOrString
+		https   *intstr.IntOrString
+		want    intstr.IntOrString
+		wantErr bool
+	}{{
+		name:    "default",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		want:    intstr.FromString(HTTPPort),
+	}, {
+		name:    "configured http port",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		http:    &httpAlt,
+		want:    httpAlt,
+	}, {
+		name:    "configured https port",
+		ingress: ingress(withPassthroughAnnotation, withRules(rule(withHosts([]string{externalDomain})))),
+		http:    &httpAlt,
+		https:   &https,
+		want:    https,
+	}, {
+		name:    "annotation",
+		ingress: ingress(withAnnotation(TargetPortAnnotation, "custom"), withRules(rule(withHosts([]string{externalDomain})))),
+		http:    &httpAlt,
+		want:    intstr.FromString("custom"),
+	}, {
+		name:    "invalid annotation",
+		ingress: ingress(withAnnotation(TargetPortAnnotation, "0"), withRules(rule(withHosts([]string{externalDomain})))),
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Route{ExcludedDomains: config.DefaultExcludedDomains(), HTTPTargetPort: test.http, HTTPSTargetPort: test.https}
+			routes, err := MakeRoutes(test.ingress, cfg)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("MakeRoutes() = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(routes) != 1 {
+				t.Fatalf("len(routes) = %d, want 1", len(routes))
+			}
+			if got := routes[0].Spec.Port.TargetPort; got != test.want {
+				t.Errorf("TargetPort = %v, want %v", got, test.want)
+			}
+			if _, ok := routes[0].Annotations[TargetPortAnnotation]; ok {
+				t.Errorf("Annotations = %v, want no %s", routes[0].Annotations, TargetPortAnnotation)
+			}
+		})
+	}
+}
+
+func TestMakeRouteRateLimit(t *testing.T) {
+	limit := config.RateLimit{ConcurrentTCP: 10, RateHTTP: 100}
+	tests := []struct {
+		name    string
+		ingress *networkingv1alpha1.Ingress
+		limit   config.RateLimit
+		want    map[string]string
+	}{{
+		name:    "no limits configured",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		want:    map[string]string{},
+	}, {
+		name:    "limits configured",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		limit:   limit,
+		want: map[string]string{
+			RateLimitAnnotation:              "true",
+			RateLimitConcurrentTCPAnnotation: "10",
+			RateLimitHTTPAnnotation:          "100",
+		},
+	}, {
+		name:    "limit overridden",
+		ingress: ingress(withAnnotation(RateLimitHTTPAnnotation, "20"), withRules(rule(withHosts([]string{externalDomain})))),
+		limit:   limit,
+		want: map[string]string{
+			RateLimitAnnotation:              "true",
+			RateLimitConcurrentTCPAnnotation: "10",
+			RateLimitHTTPAnnotation:          "20",
+		},
+	}, {
+		name:    "limits disabled",
+		ingress: ingress(withAnnotation(RateLimitAnnotation, "false"), withRules(rule(withHosts([]string{externalDomain})))),
+		limit:   limit,
+		want:    map[string]string{RateLimitAnnotation: "false"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Route{ExcludedDomains: config.DefaultExcludedDomains(), RateLimit: test.limit}
+			routes, err := MakeRoutes(test.ingress, cfg)
+			if err != nil {
+				t.Fatalf("MakeRoutes() = %v", err)
+			}
+			if len(routes) != 1 {
+				t.Fatalf("len(routes) = %d, want 1", len(routes))
+			}
+			got := map[string]string{}
+			for key, value := range routes[0].Annotations {
+				if strings.HasPrefix(key, RateLimitAnnotation) {
+					got[key] = value
+				}
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("Got = %v, want: %v, diff(-want,+got):\n%s", got, test.want, cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
+
+func TestMakeRouteInsecurePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		ingress *networkingv1alpha1.Ingress
+		policy  routev1.InsecureEdgeTerminationPolicyType
+		want    routev1.InsecureEdgeTerminationPolicyType
+	}{{
+		name:    "no policy configured",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		want:    routev1.InsecureEdgeTerminationPolicyAllow,
+	}, {
+		name:    "policy configured",
+		ingress: ingress(withRules(rule(withHosts([]string{externalDomain})))),
+		policy:  routev1.InsecureEdgeTerminationPolicyNone,
+		want:    routev1.InsecureEdgeTerminationPolicyNone,
+	}, {
+		name:    "http enabled option",
+		ingress: ingress(withHTTPEnabled(), withRules(rule(withHosts([]string{externalDomain})))),
+		policy:  routev1.InsecureEdgeTerminationPolicyRedirect,
+		want:    routev1.InsecureEdgeTerminationPolicyAllow,
+	}, {
+		name:    "http redirect option",
+		ingress: ingress(withRedirect(), withRules(rule(withHosts([]string{externalDomain})))),
+		policy:  routev1.InsecureEdgeTerminationPolicyNone,
+		want:    routev1.InsecureEdgeTerminationPolicyRedirect,
+	}, {
+		name:    "passthrough",
+		ingress: ingress(withPassthroughAnnotation, withRules(rule(withHosts([]string{externalDomain})))),
+		policy:  routev1.InsecureEdgeTerminationPolicyAllow,
+		want:    routev1.InsecureEdgeTerminationPolicyRedirect,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Route{ExcludedDomains: config.DefaultExcludedDomains(), InsecurePolicy: test.policy}
+			routes, err := MakeRoutes(test.ingress, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(routes) != 1 {
+				t.Fatalf("len(routes) = %d, want 1", len(routes))
+			}
+			if got := routes[0].Spec.TLS.InsecureEdgeTerminationPolicy; got != test.want {
+				t.Errorf("InsecureEdgeTerminationPolicy = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMakeRouteDisableTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		ingress *networkingv1alpha1.Ingress
+		wantErr error
+	}{{
+		name:    "plain HTTP",
+		ingress: ingress(withAnnotation(DisableTLSAnnotation, ""), withRules(rule(withHosts([]string{externalDomain})))),
+	}, {
+		name:    "plain HTTP with redirect",
+		ingress: ingress(withAnnotation(DisableTLSAnnotation, ""), withRedirect(), withRules(rule(withHosts([]string{externalDomain})))),
+	}, {
+		name: "passthrough annotation",
+		ingress: ingress(withAnnotation(DisableTLSAnnotation, ""), withPassthroughAnnotation,
+			withRules(rule(withHosts([]string{externalDomain})))),
+		wantErr: ErrInsecurePassthrough,
+	}, {
+		name: "ingress TLS",
+		ingress: ingress(withAnnotation(DisableTLSAnnotation, ""), withTLS(networkingv1alpha1.IngressTLS{Hosts: []string{externalDomain}}),
+			withRules(rule(withHosts([]string{externalDomain})))),
+		wantErr: ErrInsecurePassthrough,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Route{ExcludedDomains: config.DefaultExcludedDomains(), HSTSHeader: "max-age=31536000"}
+			routes, err := MakeRoutes(test.ingress, cfg)
+			if err != test.wantErr {
+				t.Fatalf("got = %v, want: %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(routes) != 1 {
+				t.Fatalf("len(routes) = %d, want 1", len(routes))
+			}
+			route := routes[0]
+			if route.Spec.TLS != nil {
+				t.Errorf("TLS = %v, want nil", route.Spec.TLS)
+			}
+			if got := route.Spec.Port.TargetPort; got != intstr.FromString(HTTPPort) {
+				t.Errorf("TargetPort = %v, want %s", got, HTTPPort)
+			}
+			for _, key := range []string{DisableTLSAnnotation, HSTSHeaderAnnotation} {
+				if _, ok := route.Annotations[key]; ok {
+					t.Errorf("%s should not be set", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMakeRouteIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist string
+		existing  string
+		want      string
+		wantErr   bool
+	}{{
+		name:      "single CIDR",
+		allowlist: "192.168.0.0/16",
+		want:      "192.168.0.0/16",
+	}, {
+		name:      "mixed separators",
+		allowlist: "10.0.0.0/8, 172.16.0.1 2001:db8::/32",
+		want:      "10.0.0.0/8 172.16.0.1 2001:db8::/32",
+	}, {
+		name:      "overrides whitelist",
+		allowlist: "10.0.0.0/8",
+		existing:  "0.0.0.0/0",
+		want:      "10.0.0.0/8",
+	}, {
+		name:     "whitelist only",
+		existing: "0.0.0.0/0",
+		want:     "0.0.0.0/0",
+	}, {
+		name:      "empty",
+		allowlist: " , ",
+		wantErr:   true,
+	}, {
+		name:      "invalid CIDR",
+		allowlist: "10.0.0.0/8 10.0.0.0/33",
+		wantErr:   true,
+	}, {
+		name:      "hostname",
+		allowlist: "office.example.com",
+		wantErr:   true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := []ingressOption{withRules(rule(withHosts([]string{externalDomain})))}
+			if test.allowlist != "" {
+				opts = append(opts, withAnnotation(IPAllowlistAnnotation, test.allowlist))
+			}
+			if test.existing != "" {
+				opts = append(opts, withAnnotation(IPWhitelistAnnotation, test.existing))
+			}
+
+			routes, err := MakeRoutes(ingress(opts...), &config.Route{ExcludedDomains: config.DefaultExcludedDomains()})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("MakeRoutes() = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(routes) != 1 {
+				t.Fatalf("len(routes) = %d, want 1", len(routes))
+			}
+			if got := routes[0].Annotations[IPWhitelistAnnotation]; got != test.want {
+				t.Errorf("%s = %q, want %q", IPWhitelistAnnotation, got, test.want)
+			}
+			if _, ok := routes[0].Annotations[IPAllowlistAnnotation]; ok {
+				t.Errorf("%s should not be set", IPAllowlistAnnotation)
+			}
+		})
+	}
+}
+
 func ingress(options ...ingressOption) *networkingv1alpha1.Ingress {
 	ing := &networkingv1alpha1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
@@ -488,6 +929,12 @@ func withRedirect() ingressOption {
 	}
 }
 
+func withHTTPEnabled() ingressOption {
+	return func(ing *networkingv1alpha1.Ingress) {
+		ing.Spec.HTTPOption = networkingv1alpha1.HTTPOptionEnabled
+	}
+}
+
 func withHTTPOptionAnnotation(httpOpt string) ingressOption {
 	return func(ing *networkingv1alpha1.Ingress) {
 		ing.Spec.HTTPOption = networkingv1alpha1.HTTPOptionRedirected
@@ -509,6 +956,17 @@ func withDisabledAnnotation(ing *networkingv1alpha1.Ingress) {
 	ing.SetAnnotations(annos)
 }
 
+func withAnnotation(key, value string) ingressOption {
+	return func(ing *networkingv1alpha1.Ingress) {
+		annos := ing.GetAnnotations()
+		if annos == nil {
+			annos = map[string]string{}
+		}
+		annos[key] = value
+		ing.SetAnnotations(annos)
+	}
+}
+
 func withPassthroughAnnotation(ing *networkingv1alpha1.Ingress) {
 	annos := ing.GetAnnotations()
 	if annos == nil {
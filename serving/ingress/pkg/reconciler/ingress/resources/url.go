@@ -0,0 +1,105 @@
+package resources
+
+import (
+	"encoding/json"
+	"sort"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// RouteURLsAnnotation is set on the Knative Service, or the Knative Route if it's not part of
+// a Service, with the URLs its Routes were admitted at by the OpenShift routers, as JSON, e.g.
+//
+//	serving.knative.openshift.io/routeURLs: |
+//	  [{"url": "https://hello-default.apps.example.com", "routerCanonicalHostname": "router-default.apps.example.com"}]
+//
+// The URL in the status of the Knative Service is derived from the domainTemplate, which
+// doesn't necessarily match what the routers expose. The canonical hostname of a router is
+// what a DNS record for a custom domain has to point to.
+//
+// It's not set on the Ingress itself, as its annotations are owned by the Knative Route.
+const RouteURLsAnnotation = "serving.knative.openshift.io/routeURLs"
+
+var (
+	// ServiceGVR is the resource of the Knative Services the RouteURLsAnnotation is set on.
+	ServiceGVR = schema.GroupVersionResource{Group: serving.GroupName, Version: "v1", Resource: "services"}
+	// KnativeRouteGVR is the resource of the Knative Routes the RouteURLsAnnotation is set on.
+	KnativeRouteGVR = schema.GroupVersionResource{Group: serving.GroupName, Version: "v1", Resource: "routes"}
+)
+
+// RouteURL is a URL a Route was admitted at.
+type RouteURL struct {
+	// URL is the externally reachable URL of the Route.
+	URL string `json:"url"`
+	// RouterCanonicalHostname is the external hostname of the router that admitted the Route.
+	RouterCanonicalHostname string `json:"routerCanonicalHostname,omitempty"`
+}
+
+// RouteURLsTarget returns the resource the RouteURLsAnnotation of the given Ingress is set on.
+// It returns false for Ingresses not created for a Knative Route, like the ones of
+// DomainMappings.
+func RouteURLsTarget(ci *networkingv1alpha1.Ingress) (schema.GroupVersionResource, types.NamespacedName, bool) {
+	namespace := ci.Labels[serving.RouteNamespaceLabelKey]
+	if namespace == "" {
+		namespace = ci.Namespace
+	}
+	if name := ci.Labels[serving.ServiceLabelKey]; name != "" {
+		return ServiceGVR, types.NamespacedName{Namespace: namespace, Name: name}, true
+	}
+	if name := ci.Labels[serving.RouteLabelKey]; name != "" {
+		return KnativeRouteGVR, types.NamespacedName{Namespace: namespace, Name: name}, true
+	}
+	return schema.GroupVersionResource{}, types.NamespacedName{}, false
+}
+
+// RouteURLs returns the value of the RouteURLsAnnotation for the given Routes, or an empty
+// string if none of them is admitted yet. Routes serving TLS are reachable through HTTPS.
+func RouteURLs(routes []*routev1.Route) (string, error) {
+	seen := make(map[RouteURL]bool)
+	urls := []RouteURL{}
+	for _, route := range routes {
+		scheme := "https"
+		if route.Spec.TLS == nil {
+			scheme = "http"
+		}
+		for _, ingress := range route.Status.Ingress {
+			if !isAdmitted(ingress) {
+				continue
+			}
+			host := ingress.Host
+			if host == "" {
+				host = route.Spec.Host
+			}
+			url := RouteURL{URL: scheme + "://" + host, RouterCanonicalHostname: ingress.RouterCanonicalHostname}
+			if !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return "", nil
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if urls[i].URL != urls[j].URL {
+			return urls[i].URL < urls[j].URL
+		}
+		return urls[i].RouterCanonicalHostname < urls[j].RouterCanonicalHostname
+	})
+	raw, err := json.Marshal(urls)
+	return string(raw), err
+}
+
+func isAdmitted(ingress routev1.RouteIngress) bool {
+	for _, cond := range ingress.Conditions {
+		if cond.Type == routev1.RouteAdmitted {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package resources
+
+import (
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	networkingv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+func TestRouteURLs(t *testing.T) {
+	admitted := func(host, router string) routev1.RouteIngress {
+		return routev1.RouteIngress{
+			Host:                    host,
+			RouterCanonicalHostname: router,
+			Conditions: []routev1.RouteIngressCondition{{
+				Type:   routev1.RouteAdmitted,
+				Status: corev1.ConditionTrue,
+			}},
+		}
+	}
+	route := func(tls bool, ingresses ...routev1.RouteIngress) *routev1.Route {
+		r := &routev1.Route{
+			Spec:   routev1.RouteSpec{Host: externalDomain},
+			Status: routev1.RouteStatus{Ingress: ingresses},
+		}
+		if tls {
+			r.Spec.TLS = &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge}
+		}
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		routes []*routev1.Route
+		want   string
+	}{{
+		name: "no routes",
+	}, {
+		name: "not admitted",
+		routes: []*routev1.Route{route(true, routev1.RouteIngress{
+			Host: externalDomain,
+			Conditions: []routev1.RouteIngressCondition{{
+				Type:   routev1.RouteAdmitted,
+				Status: corev1.ConditionFalse,
+			}},
+		})},
+	}, {
+		name:   "admitted",
+		routes: []*routev1.Route{route(true, admitted(externalDomain, "router-default.apps.example.com"))},
+		want:   `[{"url":"https://` + externalDomain + `","routerCanonicalHostname":"router-default.apps.example.com"}]`,
+	}, {
+		name:   "plain HTTP",
+		routes: []*routev1.Route{route(false, admitted("", ""))},
+		want:   `[{"url":"http://` + externalDomain + `"}]`,
+	}, {
+		name: "sharded routers",
+		routes: []*routev1.Route{route(true,
+			admitted(externalDomain, "router-internal.apps.example.com"),
+			admitted(externalDomain, "router-default.apps.example.com"),
+		)},
+		want: `[{"url":"https://` + externalDomain + `","routerCanonicalHostname":"router-default.apps.example.com"},` +
+			`{"url":"https://` + externalDomain + `","routerCanonicalHostname":"router-internal.apps.example.com"}]`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := RouteURLs(test.routes)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if got != test.want {
+				t.Errorf("RouteURLs() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRouteURLsTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantGVR string
+		wantKey types.NamespacedName
+		wantOK  bool
+	}{{
+		name: "service",
+		labels: map[string]string{
+			serving.ServiceLabelKey:        "hello",
+			serving.RouteLabelKey:          "hello",
+			serving.RouteNamespaceLabelKey: "default",
+		},
+		wantGVR: ServiceGVR.String(),
+		wantKey: types.NamespacedName{Namespace: "default", Name: "hello"},
+		wantOK:  true,
+	}, {
+		name:    "route",
+		labels:  map[string]string{serving.RouteLabelKey: "hello"},
+		wantGVR: KnativeRouteGVR.String(),
+		wantKey: types.NamespacedName{Namespace: "ns", Name: "hello"},
+		wantOK:  true,
+	}, {
+		name: "domain mapping",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ing := &networkingv1alpha1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Labels: test.labels}}
+			gvr, key, ok := RouteURLsTarget(ing)
+			if ok != test.wantOK {
+				t.Fatalf("RouteURLsTarget() ok = %v, want %v", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if gvr.String() != test.wantGVR || key != test.wantKey {
+				t.Errorf("RouteURLsTarget() = %s %s, want %s %s", gvr, key, test.wantGVR, test.wantKey)
+			}
+		})
+	}
+}
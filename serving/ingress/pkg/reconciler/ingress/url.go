@@ -0,0 +1,61 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/logging"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
+)
+
+// reportRouteURLs sets the URLs the given Routes of the ingress were admitted at on the
+// Knative Service or Route the ingress was created for, see resources.RouteURLsAnnotation.
+// The annotation is removed again once none of the Routes is admitted.
+func (r *Reconciler) reportRouteURLs(ctx context.Context, ing *v1alpha1.Ingress, routes []*routev1.Route) error {
+	gvr, key, ok := resources.RouteURLsTarget(ing)
+	if !ok {
+		return nil
+	}
+	urls, err := resources.RouteURLs(routes)
+	if err != nil {
+		return fmt.Errorf("failed to render route URLs: %w", err)
+	}
+
+	client := r.dynamicClient.Resource(gvr).Namespace(key.Namespace)
+	target, err := client.Get(ctx, key.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// Nothing to report to, e.g. the Knative Service is being deleted.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s %s: %w", gvr.Resource, key, err)
+	}
+	existing, exists := target.GetAnnotations()[resources.RouteURLsAnnotation]
+	if existing == urls && exists == (urls != "") {
+		return nil
+	}
+
+	logging.FromContext(ctx).Infof("Reporting route URLs %q on %s %s", urls, gvr.Resource, key)
+	var value interface{}
+	if urls != "" {
+		value = urls
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{resources.RouteURLsAnnotation: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := client.Patch(ctx, key.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to report route URLs on %s %s: %w", gvr.Resource, key, err)
+	}
+	return nil
+}
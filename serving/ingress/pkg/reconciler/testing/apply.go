@@ -0,0 +1,51 @@
+package testing
+
+import (
+	"encoding/json"
+
+	routev1 "github.com/openshift/api/route/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// ApplyRoutes returns a reactor handling apply patches of Routes on the given tracker, which
+// creates missing Routes and otherwise adds the applied labels and annotations and replaces the
+// spec. Field ownership isn't tracked.
+func ApplyRoutes(tracker ktesting.ObjectTracker) ktesting.ReactionFunc {
+	return func(action ktesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(ktesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		applied := &routev1.Route{}
+		if err := json.Unmarshal(patch.GetPatch(), applied); err != nil {
+			return true, nil, err
+		}
+
+		gvr := action.GetResource()
+		obj, err := tracker.Get(gvr, patch.GetNamespace(), patch.GetName())
+		if apierrs.IsNotFound(err) {
+			return true, applied, tracker.Create(gvr, applied, patch.GetNamespace())
+		} else if err != nil {
+			return true, nil, err
+		}
+
+		route := obj.(*routev1.Route).DeepCopy()
+		if route.Labels == nil {
+			route.Labels = make(map[string]string, len(applied.Labels))
+		}
+		for key, value := range applied.Labels {
+			route.Labels[key] = value
+		}
+		if route.Annotations == nil {
+			route.Annotations = make(map[string]string, len(applied.Annotations))
+		}
+		for key, value := range applied.Annotations {
+			route.Annotations[key] = value
+		}
+		route.Spec = applied.Spec
+		return true, route, tracker.Update(gvr, route, patch.GetNamespace())
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	fakerouteclient "github.com/openshift-knative/serverless-operator/pkg/client/injection/client/fake"
 	fakenetworkingclient "knative.dev/networking/pkg/client/injection/client/fake"
+	fakedynamicclient "knative.dev/pkg/injection/clients/dynamicclient/fake"
 	"knative.dev/pkg/reconciler"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -39,6 +40,7 @@ func MakeFactory(ctor Ctor) rtesting.Factory {
 
 		ctx, client := fakenetworkingclient.With(ctx, ls.GetNetworkingObjects()...)
 		ctx, routeclient := fakerouteclient.With(ctx, ls.GetRouteObjects()...)
+		ctx, dynamicclient := fakedynamicclient.With(ctx, NewScheme(), ls.GetGatewayAPIObjects()...)
 
 		// Set up our Controller from the fakes.
 		c := ctor(ctx, &ls, configmap.NewStaticWatcher())
@@ -50,9 +52,13 @@ func MakeFactory(ctor Ctor) rtesting.Factory {
 			la.Promote(reconciler.UniversalBucket(), func(reconciler.Bucket, types.NamespacedName) {})
 		}
 
+		// The object tracker doesn't support server-side apply.
+		routeclient.PrependReactor("patch", "routes", ApplyRoutes(routeclient.Tracker()))
+
 		for _, reactor := range r.WithReactors {
 			client.PrependReactor("*", "*", reactor)
 			routeclient.PrependReactor("*", "*", reactor)
+			dynamicclient.PrependReactor("*", "*", reactor)
 		}
 
 		// Validate all Create operations through the serving client.
@@ -63,7 +69,7 @@ func MakeFactory(ctor Ctor) rtesting.Factory {
 			return rtesting.ValidateUpdates(context.Background(), action)
 		})
 
-		actionRecorderList := rtesting.ActionRecorderList{client, routeclient}
+		actionRecorderList := rtesting.ActionRecorderList{client, routeclient, dynamicclient}
 		eventList := rtesting.EventList{Recorder: eventRecorder}
 
 		return c, actionRecorderList, eventList
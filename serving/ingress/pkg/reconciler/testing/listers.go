@@ -4,17 +4,46 @@ import (
 	fakerouteclientset "github.com/openshift-knative/serverless-operator/pkg/client/clientset/versioned/fake"
 	routev1listers "github.com/openshift-knative/serverless-operator/pkg/client/listers/route/v1"
 	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	networking "knative.dev/networking/pkg/apis/networking/v1alpha1"
 	fakenetworkingclientset "knative.dev/networking/pkg/client/clientset/versioned/fake"
 	networkinglisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
 	"knative.dev/pkg/reconciler/testing"
+
+	"github.com/openshift-knative/serverless-operator/serving/ingress/pkg/reconciler/ingress/resources"
 )
 
 var clientSetSchemes = []func(*runtime.Scheme) error{
 	fakenetworkingclientset.AddToScheme,
 	fakerouteclientset.AddToScheme,
+	fakekubeclientset.AddToScheme,
+	addGatewayAPIToScheme,
+	addServingToScheme,
+}
+
+// addGatewayAPIToScheme registers the Gateway API types, which are only handled as
+// unstructured objects.
+func addGatewayAPIToScheme(scheme *runtime.Scheme) error {
+	gv := resources.HTTPRouteGVR.GroupVersion()
+	scheme.AddKnownTypeWithName(gv.WithKind("HTTPRoute"), &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gv.WithKind("HTTPRouteList"), &unstructured.UnstructuredList{})
+	return nil
+}
+
+// addServingToScheme registers the Knative Services and Routes the route URLs are reported
+// on, which are only handled as unstructured objects.
+func addServingToScheme(scheme *runtime.Scheme) error {
+	gv := resources.ServiceGVR.GroupVersion()
+	for _, kind := range []string{"Service", "Route"} {
+		scheme.AddKnownTypeWithName(gv.WithKind(kind), &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gv.WithKind(kind+"List"), &unstructured.UnstructuredList{})
+	}
+	return nil
 }
 
 type Listers struct {
@@ -59,6 +88,12 @@ func (l *Listers) GetRouteObjects() []runtime.Object {
 	return l.sorter.ObjectsForSchemeFunc(fakerouteclientset.AddToScheme)
 }
 
+// GetGatewayAPIObjects returns the unstructured objects, which includes the Knative Services
+// and Routes as they're sorted by their type.
+func (l *Listers) GetGatewayAPIObjects() []runtime.Object {
+	return l.sorter.ObjectsForSchemeFunc(addGatewayAPIToScheme)
+}
+
 // GetIngressLister get lister for Ingress resource.
 func (l *Listers) GetIngressLister() networkinglisters.IngressLister {
 	return networkinglisters.NewIngressLister(l.IndexerFor(&networking.Ingress{}))
@@ -68,3 +103,8 @@ func (l *Listers) GetIngressLister() networkinglisters.IngressLister {
 func (l *Listers) GetRouteLister() routev1listers.RouteLister {
 	return routev1listers.NewRouteLister(l.IndexerFor(&routev1.Route{}))
 }
+
+// GetSecretLister get lister for Secret resource.
+func (l *Listers) GetSecretLister() corev1listers.SecretLister {
+	return corev1listers.NewSecretLister(l.IndexerFor(&corev1.Secret{}))
+}